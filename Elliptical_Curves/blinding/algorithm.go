@@ -0,0 +1,277 @@
+package blinding
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// AlgorithmID identifies a signature scheme with key blinding, with its
+// group, hash function and blinding method, on the wire, such as
+// "ECDSA-P256-SHA256-BLIND-MUL". Unlike the name of a scheme, which is meant
+// for humans, an AlgorithmID is stable: a registered identifier always refers
+// to the same encodings of keys and signatures, and a new variant of a scheme
+// gets a new identifier, so that fleets can roll it out while verifiers that
+// don't know it yet reject its keys and signatures explicitly.
+type AlgorithmID string
+
+// Algorithm identifiers of the schemes of this package. The blinding method
+// MUL multiplies keys by a scalar derived from the blind and the context.
+const (
+	AlgorithmRistretto255       AlgorithmID = "SCHNORR-RISTRETTO255-SHA512-BLIND-MUL"
+	AlgorithmEd25519            AlgorithmID = "EDDSA-ED25519-SHA512-BLIND-MUL"
+	AlgorithmBLS12381           AlgorithmID = "BLS-BLS12381G1-SHA256-BLIND-MUL"
+	AlgorithmECDSAP256          AlgorithmID = "ECDSA-P256-SHA256-BLIND-MUL"
+	AlgorithmECDSAP384          AlgorithmID = "ECDSA-P384-SHA384-BLIND-MUL"
+	AlgorithmECDSAP521          AlgorithmID = "ECDSA-P521-SHA512-BLIND-MUL"
+	AlgorithmECDSABrainpoolP256 AlgorithmID = "ECDSA-BP256R1-SHA256-BLIND-MUL"
+	AlgorithmECDSABrainpoolP384 AlgorithmID = "ECDSA-BP384R1-SHA384-BLIND-MUL"
+	AlgorithmECDSABrainpoolP512 AlgorithmID = "ECDSA-BP512R1-SHA512-BLIND-MUL"
+)
+
+// Algorithm identifiers of ECDSA schemes whose messages are hashed with SHA-3
+// and whose blinds are hashed with the function named after MUL, as returned
+// by ECDSAWithBlindHash, for environments that only allow SHA-3.
+const (
+	AlgorithmECDSAP256SHA3     AlgorithmID = "ECDSA-P256-SHA3_256-BLIND-MUL-SHA3_256"
+	AlgorithmECDSAP384SHA3     AlgorithmID = "ECDSA-P384-SHA3_384-BLIND-MUL-SHA3_384"
+	AlgorithmECDSAP521SHA3     AlgorithmID = "ECDSA-P521-SHA3_512-BLIND-MUL-SHA3_512"
+	AlgorithmECDSAP256SHAKE128 AlgorithmID = "ECDSA-P256-SHA3_256-BLIND-MUL-SHAKE128"
+	AlgorithmECDSAP384SHAKE256 AlgorithmID = "ECDSA-P384-SHA3_384-BLIND-MUL-SHAKE256"
+)
+
+var (
+	// ErrUnknownAlgorithm is returned when an algorithm identifier is not
+	// registered, or a scheme has no registered identifier.
+	ErrUnknownAlgorithm = errors.New("blinding: unknown algorithm")
+
+	// ErrNoCommonAlgorithm is returned by Negotiate when two parties have
+	// no algorithm in common.
+	ErrNoCommonAlgorithm = errors.New("blinding: no common algorithm")
+
+	// ErrInvalidEncoding is returned when a tagged key or signature is
+	// badly encoded.
+	ErrInvalidEncoding = errors.New("blinding: invalid tagged encoding")
+)
+
+var algorithmRegistry = struct {
+	sync.RWMutex
+	ids     []AlgorithmID
+	schemes map[AlgorithmID]BlindableScheme
+	byName  map[string]AlgorithmID
+}{
+	schemes: make(map[AlgorithmID]BlindableScheme),
+	byName:  make(map[string]AlgorithmID),
+}
+
+func init() {
+	for _, a := range []struct {
+		id AlgorithmID
+		s  BlindableScheme
+	}{
+		{AlgorithmRistretto255, Ristretto255},
+		{AlgorithmEd25519, Ed25519},
+		{AlgorithmBLS12381, BLS12381},
+		{AlgorithmECDSAP256, ECDSA(elliptic.P256(), crypto.SHA256)},
+		{AlgorithmECDSAP384, ECDSA(elliptic.P384(), crypto.SHA384)},
+		{AlgorithmECDSAP521, ECDSA(elliptic.P521(), crypto.SHA512)},
+		{AlgorithmECDSABrainpoolP256, ECDSA(brainpool.P256r1(), crypto.SHA256)},
+		{AlgorithmECDSABrainpoolP384, ECDSA(brainpool.P384r1(), crypto.SHA384)},
+		{AlgorithmECDSABrainpoolP512, ECDSA(brainpool.P512r1(), crypto.SHA512)},
+		{AlgorithmECDSAP256SHA3, ECDSAWithBlindHash(elliptic.P256(), crypto.SHA3_256, ecdsa.BlindHashSHA3_256)},
+		{AlgorithmECDSAP384SHA3, ECDSAWithBlindHash(elliptic.P384(), crypto.SHA3_384, ecdsa.BlindHashSHA3_384)},
+		{AlgorithmECDSAP521SHA3, ECDSAWithBlindHash(elliptic.P521(), crypto.SHA3_512, ecdsa.BlindHashSHA3_512)},
+		{AlgorithmECDSAP256SHAKE128, ECDSAWithBlindHash(elliptic.P256(), crypto.SHA3_256, ecdsa.BlindHashSHAKE128)},
+		{AlgorithmECDSAP384SHAKE256, ECDSAWithBlindHash(elliptic.P384(), crypto.SHA3_384, ecdsa.BlindHashSHAKE256)},
+	} {
+		if err := RegisterAlgorithm(a.id, a.s); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterAlgorithm registers s under id, so that keys and signatures tagged
+// with id are handled by s. Identifiers must be 1 to 255 bytes long, and
+// neither an identifier nor the name of a scheme can be registered twice.
+// The schemes of this package are registered by default.
+func RegisterAlgorithm(id AlgorithmID, s BlindableScheme) error {
+	if len(id) == 0 || len(id) > 255 {
+		return fmt.Errorf("%w: identifier of %d bytes", ErrUnknownAlgorithm, len(id))
+	}
+	algorithmRegistry.Lock()
+	defer algorithmRegistry.Unlock()
+	if _, ok := algorithmRegistry.schemes[id]; ok {
+		return fmt.Errorf("blinding: algorithm %s already registered", id)
+	}
+	if other, ok := algorithmRegistry.byName[s.Name()]; ok {
+		return fmt.Errorf("blinding: scheme %s already registered as %s", s.Name(), other)
+	}
+	algorithmRegistry.ids = append(algorithmRegistry.ids, id)
+	algorithmRegistry.schemes[id] = s
+	algorithmRegistry.byName[s.Name()] = id
+	return nil
+}
+
+// RegisteredAlgorithms returns the registered identifiers, in the order they
+// were registered.
+func RegisteredAlgorithms() []AlgorithmID {
+	algorithmRegistry.RLock()
+	defer algorithmRegistry.RUnlock()
+	return append([]AlgorithmID(nil), algorithmRegistry.ids...)
+}
+
+// LookupAlgorithm returns the scheme registered under id.
+func LookupAlgorithm(id AlgorithmID) (BlindableScheme, error) {
+	algorithmRegistry.RLock()
+	defer algorithmRegistry.RUnlock()
+	s, ok := algorithmRegistry.schemes[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, id)
+	}
+	return s, nil
+}
+
+// AlgorithmOf returns the identifier under which s, or a scheme of the same
+// name, is registered.
+func AlgorithmOf(s BlindableScheme) (AlgorithmID, error) {
+	algorithmRegistry.RLock()
+	defer algorithmRegistry.RUnlock()
+	id, ok := algorithmRegistry.byName[s.Name()]
+	if !ok {
+		return "", fmt.Errorf("%w: no identifier for scheme %s", ErrUnknownAlgorithm, s.Name())
+	}
+	return id, nil
+}
+
+// Negotiate returns the first algorithm of local, in order of preference,
+// that remote supports and that is registered.
+func Negotiate(local, remote []AlgorithmID) (AlgorithmID, error) {
+	supported := make(map[AlgorithmID]bool, len(remote))
+	for _, id := range remote {
+		supported[id] = true
+	}
+	for _, id := range local {
+		if _, err := LookupAlgorithm(id); err == nil && supported[id] {
+			return id, nil
+		}
+	}
+	return "", ErrNoCommonAlgorithm
+}
+
+// MarshalAlgorithms encodes a list of identifiers, such as the algorithms a
+// verifier supports, for negotiation:
+//
+//	opaque AlgorithmID<1..2^8-1>;
+//	AlgorithmID algorithms<0..2^16-1>;
+func MarshalAlgorithms(ids []AlgorithmID) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, id := range ids {
+			id := id
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(id))
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+// UnmarshalAlgorithms decodes a list of identifiers encoded by
+// MarshalAlgorithms. Unknown identifiers are kept, so that they can be
+// skipped by Negotiate.
+func UnmarshalAlgorithms(data []byte) ([]AlgorithmID, error) {
+	s := cryptobyte.String(data)
+	var list cryptobyte.String
+	if !s.ReadUint16LengthPrefixed(&list) || !s.Empty() {
+		return nil, ErrInvalidEncoding
+	}
+	var ids []AlgorithmID
+	for !list.Empty() {
+		var id cryptobyte.String
+		if !list.ReadUint8LengthPrefixed(&id) || id.Empty() {
+			return nil, ErrInvalidEncoding
+		}
+		ids = append(ids, AlgorithmID(id))
+	}
+	return ids, nil
+}
+
+// marshalTagged encodes data tagged with the identifier of s:
+//
+//	struct {
+//	  AlgorithmID algorithm;
+//	  opaque data<0..2^16-1>;
+//	} Tagged;
+func marshalTagged(s BlindableScheme, data []byte) ([]byte, error) {
+	id, err := AlgorithmOf(s)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(id))
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(data)
+	})
+	return b.Bytes()
+}
+
+func unmarshalTagged(tagged []byte) (BlindableScheme, []byte, error) {
+	s := cryptobyte.String(tagged)
+	var id, data cryptobyte.String
+	if !s.ReadUint8LengthPrefixed(&id) || !s.ReadUint16LengthPrefixed(&data) || !s.Empty() {
+		return nil, nil, ErrInvalidEncoding
+	}
+	scheme, err := LookupAlgorithm(AlgorithmID(id))
+	if err != nil {
+		return nil, nil, err
+	}
+	return scheme, data, nil
+}
+
+// MarshalPublicKey encodes publicKey, in the encoding of s, tagged with the
+// algorithm identifier of s.
+func MarshalPublicKey(s BlindableScheme, publicKey []byte) ([]byte, error) {
+	return marshalTagged(s, publicKey)
+}
+
+// UnmarshalPublicKey decodes a public key encoded by MarshalPublicKey, and
+// returns it with the scheme registered for its algorithm.
+func UnmarshalPublicKey(data []byte) (BlindableScheme, []byte, error) {
+	return unmarshalTagged(data)
+}
+
+// MarshalSignature encodes signature, in the encoding of s, tagged with the
+// algorithm identifier of s.
+func MarshalSignature(s BlindableScheme, signature []byte) ([]byte, error) {
+	return marshalTagged(s, signature)
+}
+
+// UnmarshalSignature decodes a signature encoded by MarshalSignature, and
+// returns it with the scheme registered for its algorithm.
+func UnmarshalSignature(data []byte) (BlindableScheme, []byte, error) {
+	return unmarshalTagged(data)
+}
+
+// VerifyTagged reports whether signature, encoded by MarshalSignature, is a
+// valid signature of message by publicKey, encoded by MarshalPublicKey. It
+// returns false if the key and the signature are tagged with different
+// algorithms, or with one that is not registered.
+func VerifyTagged(publicKey, message, signature []byte) bool {
+	ks, pk, err := UnmarshalPublicKey(publicKey)
+	if err != nil {
+		return false
+	}
+	ss, sig, err := UnmarshalSignature(signature)
+	if err != nil || ks.Name() != ss.Name() {
+		return false
+	}
+	return ks.Verify(pk, message, sig)
+}
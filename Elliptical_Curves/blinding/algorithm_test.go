@@ -0,0 +1,137 @@
+package blinding
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func TestAlgorithmRegistry(t *testing.T) {
+	testAllSchemes(t, func(t *testing.T, s BlindableScheme) {
+		id, err := AlgorithmOf(s)
+		if err != nil {
+			t.Fatalf("AlgorithmOf error: %s", err)
+		}
+		got, err := LookupAlgorithm(id)
+		if err != nil || got.Name() != s.Name() {
+			t.Errorf("LookupAlgorithm(%s) = %v, %v", id, got, err)
+		}
+	})
+	if id, _ := AlgorithmOf(ECDSA(elliptic.P256(), crypto.SHA256)); id != AlgorithmECDSAP256 {
+		t.Errorf("AlgorithmOf(ECDSA P-256) = %s, want %s", id, AlgorithmECDSAP256)
+	}
+	if _, err := AlgorithmOf(ECDSA(elliptic.P256(), crypto.SHA512)); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("AlgorithmOf of an unregistered scheme: %v", err)
+	}
+	if _, err := LookupAlgorithm("ECDSA-P192-SHA1-BLIND-MUL"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("LookupAlgorithm of an unknown identifier: %v", err)
+	}
+	if err := RegisterAlgorithm("ECDSA-P256-OTHER", ECDSA(elliptic.P256(), crypto.SHA256)); err == nil {
+		t.Errorf("registered a scheme twice")
+	}
+	if err := RegisterAlgorithm(AlgorithmEd25519, ECDSA(elliptic.P256(), crypto.SHA512)); err == nil {
+		t.Errorf("registered an identifier twice")
+	}
+}
+
+func TestBlindHashAlgorithms(t *testing.T) {
+	if s := ECDSAWithBlindHash(elliptic.P256(), crypto.SHA256, ecdsa.BlindHashDefault); s.Name() != ECDSA(elliptic.P256(), crypto.SHA256).Name() {
+		t.Errorf("ECDSAWithBlindHash with the default hash is named %s", s.Name())
+	}
+	sha3 := ECDSAWithBlindHash(elliptic.P256(), crypto.SHA3_256, ecdsa.BlindHashSHA3_256)
+	shake := ECDSAWithBlindHash(elliptic.P256(), crypto.SHA3_256, ecdsa.BlindHashSHAKE128)
+	if id, _ := AlgorithmOf(sha3); id != AlgorithmECDSAP256SHA3 {
+		t.Errorf("AlgorithmOf(%s) = %s, want %s", sha3.Name(), id, AlgorithmECDSAP256SHA3)
+	}
+	if id, _ := AlgorithmOf(shake); id != AlgorithmECDSAP256SHAKE128 {
+		t.Errorf("AlgorithmOf(%s) = %s, want %s", shake.Name(), id, AlgorithmECDSAP256SHAKE128)
+	}
+
+	// The same key and blind give different blinded keys under each hash.
+	pk, _, _ := sha3.GenerateKey(rand.Reader)
+	blind, _ := sha3.GenerateBlind(rand.Reader)
+	a, _ := sha3.BlindPublicKey(pk, blind, nil)
+	b, _ := shake.BlindPublicKey(pk, blind, nil)
+	if reflect.DeepEqual(a, b) {
+		t.Errorf("SHA3-256 and SHAKE128 blinds give the same key")
+	}
+	if unblinded, err := shake.UnblindPublicKey(b, blind, nil); err != nil || !reflect.DeepEqual(unblinded, pk) {
+		t.Errorf("UnblindPublicKey = %v, want the base key", err)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	local := []AlgorithmID{"SCHEME-FROM-THE-FUTURE", AlgorithmRistretto255, AlgorithmECDSAP256}
+	enc, err := MarshalAlgorithms([]AlgorithmID{AlgorithmECDSAP256, "SCHEME-FROM-THE-FUTURE", AlgorithmRistretto255})
+	if err != nil {
+		t.Fatalf("MarshalAlgorithms error: %s", err)
+	}
+	remote, err := UnmarshalAlgorithms(enc)
+	if err != nil {
+		t.Fatalf("UnmarshalAlgorithms error: %s", err)
+	}
+	if !reflect.DeepEqual(remote, []AlgorithmID{AlgorithmECDSAP256, "SCHEME-FROM-THE-FUTURE", AlgorithmRistretto255}) {
+		t.Errorf("UnmarshalAlgorithms(MarshalAlgorithms()) = %v", remote)
+	}
+	// Unregistered identifiers are skipped even if both sides list them.
+	if id, err := Negotiate(local, remote); err != nil || id != AlgorithmRistretto255 {
+		t.Errorf("Negotiate = %s, %v, want %s", id, err, AlgorithmRistretto255)
+	}
+	if _, err := Negotiate(local, []AlgorithmID{AlgorithmEd25519}); !errors.Is(err, ErrNoCommonAlgorithm) {
+		t.Errorf("Negotiate without a common algorithm: %v", err)
+	}
+	if _, err := UnmarshalAlgorithms(enc[:len(enc)-1]); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("UnmarshalAlgorithms of a truncated list: %v", err)
+	}
+}
+
+func TestTaggedEncoding(t *testing.T) {
+	testAllSchemes(t, testTaggedEncoding)
+}
+
+func testTaggedEncoding(t *testing.T, s BlindableScheme) {
+	pk, sk, _ := s.GenerateKey(rand.Reader)
+	blind, _ := s.GenerateBlind(rand.Reader)
+	pkR, _ := s.BlindPublicKey(pk, blind, nil)
+	message := []byte("test message")
+	sig, err := s.BlindKeySign(rand.Reader, sk, blind, message, nil)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+
+	tpk, err := MarshalPublicKey(s, pkR)
+	if err != nil {
+		t.Fatalf("MarshalPublicKey error: %s", err)
+	}
+	tsig, err := MarshalSignature(s, sig)
+	if err != nil {
+		t.Fatalf("MarshalSignature error: %s", err)
+	}
+	if !VerifyTagged(tpk, message, tsig) {
+		t.Errorf("tagged signature failed to verify")
+	}
+	if VerifyTagged(tpk, []byte("other message"), tsig) {
+		t.Errorf("tagged signature of another message verified")
+	}
+	got, key, err := UnmarshalPublicKey(tpk)
+	if err != nil || got.Name() != s.Name() || !reflect.DeepEqual(key, pkR) {
+		t.Errorf("UnmarshalPublicKey(MarshalPublicKey()) = %v, %v", got, err)
+	}
+
+	// A signature tagged with another algorithm is rejected.
+	other := Ristretto255
+	if s.Name() == other.Name() {
+		other = Ed25519
+	}
+	if mistagged, _ := MarshalSignature(other, sig); VerifyTagged(tpk, message, mistagged) {
+		t.Errorf("signature tagged with another algorithm verified")
+	}
+	if _, _, err := UnmarshalSignature(tsig[:len(tsig)-1]); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("UnmarshalSignature of a truncated signature: %v", err)
+	}
+}
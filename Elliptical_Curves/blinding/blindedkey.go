@@ -0,0 +1,215 @@
+package blinding
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	blindedKeyVersion = 1
+	blindedKeyDST     = "BlindedKey v1"
+)
+
+var (
+	// ErrInvalidBlindedKey is returned when a signed BlindedKey is badly
+	// encoded or its signature doesn't verify.
+	ErrInvalidBlindedKey = errors.New("blinding: invalid blinded key")
+
+	// ErrKeyExpired is returned by BlindedKey.Check outside of the validity
+	// period of a blinded key.
+	ErrKeyExpired = errors.New("blinding: blinded key expired or not yet valid")
+)
+
+// BlindedKey is a blinded public key with the metadata a verifier needs to
+// decide whether to accept it: the scheme it belongs to, the epoch it was
+// blinded for, and its validity period. A BlindedKey is serialized with a
+// signature by an authority, which may be the unblinded key or an issuer the
+// verifiers trust, so that the metadata can't be altered.
+type BlindedKey struct {
+	// Scheme is the name of the scheme of PublicKey.
+	Scheme string
+	// PublicKey is the blinded public key.
+	PublicKey []byte
+	// Epoch identifies the period the key was blinded for, for example by
+	// deriving the blind or context from it.
+	Epoch uint64
+	// NotBefore and NotAfter bound the validity period of the key, with a
+	// precision of one second. The zero value leaves the period unbounded on
+	// that side.
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// NewBlindedKey blinds publicKey of the scheme s by blind and context, and
+// returns it with the given epoch and validity period.
+func NewBlindedKey(s BlindableScheme, publicKey, blind, context []byte, epoch uint64, notBefore, notAfter time.Time) (*BlindedKey, error) {
+	pkR, err := s.BlindPublicKey(publicKey, blind, context)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindedKey{
+		Scheme:    s.Name(),
+		PublicKey: pkR,
+		Epoch:     epoch,
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+	}, nil
+}
+
+// Check returns an error wrapping ErrKeyExpired if now is outside of the
+// validity period of k.
+func (k *BlindedKey) Check(now time.Time) error {
+	if !k.NotBefore.IsZero() && now.Before(k.NotBefore) {
+		return fmt.Errorf("%w: valid from %s", ErrKeyExpired, k.NotBefore)
+	}
+	if !k.NotAfter.IsZero() && now.After(k.NotAfter) {
+		return fmt.Errorf("%w: expired at %s", ErrKeyExpired, k.NotAfter)
+	}
+	return nil
+}
+
+func unixSeconds(t time.Time) (uint64, error) {
+	if t.IsZero() {
+		return 0, nil
+	}
+	if t.Unix() <= 0 {
+		return 0, fmt.Errorf("%w: time before 1970", ErrInvalidBlindedKey)
+	}
+	return uint64(t.Unix()), nil
+}
+
+// body encodes k, without a signature.
+func (k *BlindedKey) body() ([]byte, error) {
+	notBefore, err := unixSeconds(k.NotBefore)
+	if err != nil {
+		return nil, err
+	}
+	notAfter, err := unixSeconds(k.NotAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint8(blindedKeyVersion)
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(k.Scheme))
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(k.PublicKey)
+	})
+	var u64 [8]byte
+	for _, v := range []uint64{k.Epoch, notBefore, notAfter} {
+		binary.BigEndian.PutUint64(u64[:], v)
+		b.AddBytes(u64[:])
+	}
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: scheme name or public key too long", ErrInvalidBlindedKey)
+	}
+	return body, nil
+}
+
+// signedMessage returns the message signed by the authority: the body
+// prefixed by a domain separation tag and the name of the authority's scheme,
+// each prefixed by its 1-byte length.
+func signedMessage(authority BlindableScheme, body []byte) []byte {
+	var b cryptobyte.Builder
+	for _, v := range []string{blindedKeyDST, authority.Name()} {
+		v := v
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(v))
+		})
+	}
+	b.AddBytes(body)
+	return b.BytesOrPanic()
+}
+
+// Marshal encodes k, signed by authorityKey of the scheme authority, as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque scheme<1..2^8-1>;
+//	  opaque public_key<1..2^16-1>;
+//	  uint64 epoch;
+//	  uint64 not_before;
+//	  uint64 not_after;
+//	  opaque signature<1..2^16-1>;
+//	} SignedBlindedKey;
+//
+// where not_before and not_after are in seconds since the Unix epoch, or zero
+// if unbounded. The signature covers all the preceding fields and the name of
+// the authority's scheme, which may differ from the scheme of k.
+func (k *BlindedKey) Marshal(rand io.Reader, authority BlindableScheme, authorityKey []byte) ([]byte, error) {
+	body, err := k.body()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := authority.Sign(rand, authorityKey, signedMessage(authority, body))
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(body)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sig)
+	})
+	return b.Bytes()
+}
+
+// UnmarshalBlindedKey decodes a blinded key encoded by BlindedKey.Marshal,
+// and checks that it was signed by authorityPublicKey of the scheme
+// authority. It doesn't check the validity period; see BlindedKey.Check.
+func UnmarshalBlindedKey(authority BlindableScheme, authorityPublicKey, data []byte) (*BlindedKey, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var scheme, pk, sig cryptobyte.String
+	var epoch, notBefore, notAfter uint64
+	if !in.ReadUint8(&version) ||
+		!in.ReadUint8LengthPrefixed(&scheme) ||
+		!in.ReadUint16LengthPrefixed(&pk) ||
+		!readUint64(&in, &epoch) ||
+		!readUint64(&in, &notBefore) ||
+		!readUint64(&in, &notAfter) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidBlindedKey)
+	}
+	body := data[:len(data)-len(in)]
+	if !in.ReadUint16LengthPrefixed(&sig) || !in.Empty() {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidBlindedKey)
+	}
+	if version != blindedKeyVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBlindedKey, version)
+	}
+	if notBefore > 1<<62 || notAfter > 1<<62 {
+		return nil, fmt.Errorf("%w: time out of range", ErrInvalidBlindedKey)
+	}
+	if !authority.Verify(authorityPublicKey, signedMessage(authority, body), sig) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidBlindedKey)
+	}
+
+	k := &BlindedKey{
+		Scheme:    string(scheme),
+		PublicKey: append([]byte{}, pk...),
+		Epoch:     epoch,
+	}
+	if notBefore != 0 {
+		k.NotBefore = time.Unix(int64(notBefore), 0)
+	}
+	if notAfter != 0 {
+		k.NotAfter = time.Unix(int64(notAfter), 0)
+	}
+	return k, nil
+}
+
+func readUint64(s *cryptobyte.String, out *uint64) bool {
+	var v []byte
+	if !s.ReadBytes(&v, 8) {
+		return false
+	}
+	*out = binary.BigEndian.Uint64(v)
+	return true
+}
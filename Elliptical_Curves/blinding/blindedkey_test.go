@@ -0,0 +1,99 @@
+package blinding
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBlindedKey(t *testing.T) {
+	testAllSchemes(t, testBlindedKey)
+}
+
+func testBlindedKey(t *testing.T, s BlindableScheme) {
+	pk, sk, _ := s.GenerateKey(rand.Reader)
+	blind, _ := s.GenerateBlind(rand.Reader)
+	notBefore := time.Unix(1700000000, 0)
+	notAfter := notBefore.Add(24 * time.Hour)
+	key, err := NewBlindedKey(s, pk, blind, []byte("epoch 7"), 7, notBefore, notAfter)
+	if err != nil {
+		t.Fatalf("NewBlindedKey error: %s", err)
+	}
+	message := []byte("test message")
+	sig, _ := s.BlindKeySign(rand.Reader, sk, blind, message, []byte("epoch 7"))
+	if !s.Verify(key.PublicKey, message, sig) {
+		t.Errorf("blinded key does not verify blinded signatures")
+	}
+
+	// The key attests its own blinded key.
+	enc, err := key.Marshal(rand.Reader, s, sk)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	got, err := UnmarshalBlindedKey(s, pk, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalBlindedKey error: %s", err)
+	}
+	if got.Scheme != s.Name() || !bytes.Equal(got.PublicKey, key.PublicKey) || got.Epoch != 7 ||
+		!got.NotBefore.Equal(notBefore) || !got.NotAfter.Equal(notAfter) {
+		t.Errorf("decoded key %+v does not match %+v", got, key)
+	}
+
+	other, _, _ := s.GenerateKey(rand.Reader)
+	if _, err := UnmarshalBlindedKey(s, other, enc); !errors.Is(err, ErrInvalidBlindedKey) {
+		t.Errorf("UnmarshalBlindedKey under another authority: got %v, want ErrInvalidBlindedKey", err)
+	}
+	for i := range enc {
+		tampered := append([]byte{}, enc...)
+		tampered[i] ^= 0x01
+		if _, err := UnmarshalBlindedKey(s, pk, tampered); err == nil {
+			t.Fatalf("UnmarshalBlindedKey accepted byte %d tampered", i)
+		}
+	}
+}
+
+func TestBlindedKeyCheck(t *testing.T) {
+	s := Ristretto255
+	pk, _, _ := s.GenerateKey(rand.Reader)
+	blind, _ := s.GenerateBlind(rand.Reader)
+	now := time.Unix(1700000000, 0)
+	key, _ := NewBlindedKey(s, pk, blind, nil, 1, now, now.Add(time.Hour))
+
+	if err := key.Check(now.Add(time.Minute)); err != nil {
+		t.Errorf("Check within the validity period error: %s", err)
+	}
+	if err := key.Check(now.Add(-time.Second)); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("Check before NotBefore: got %v, want ErrKeyExpired", err)
+	}
+	if err := key.Check(now.Add(2 * time.Hour)); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("Check after NotAfter: got %v, want ErrKeyExpired", err)
+	}
+	unbounded := &BlindedKey{Scheme: s.Name(), PublicKey: key.PublicKey}
+	if err := unbounded.Check(time.Time{}); err != nil {
+		t.Errorf("Check of an unbounded key error: %s", err)
+	}
+
+	// An issuer on another scheme signs the key.
+	issuer := ECDSA(elliptic.P384(), crypto.SHA384)
+	issuerPub, issuerKey, _ := issuer.GenerateKey(rand.Reader)
+	enc, err := unbounded.Marshal(rand.Reader, issuer, issuerKey)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	got, err := UnmarshalBlindedKey(issuer, issuerPub, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalBlindedKey error: %s", err)
+	}
+	if !got.NotBefore.IsZero() || !got.NotAfter.IsZero() {
+		t.Errorf("unbounded key decoded with bounds %s and %s", got.NotBefore, got.NotAfter)
+	}
+
+	early := &BlindedKey{Scheme: s.Name(), PublicKey: key.PublicKey, NotAfter: time.Unix(-1, 0)}
+	if _, err := early.Marshal(rand.Reader, issuer, issuerKey); !errors.Is(err, ErrInvalidBlindedKey) {
+		t.Errorf("Marshal with a time before 1970: got %v, want ErrInvalidBlindedKey", err)
+	}
+}
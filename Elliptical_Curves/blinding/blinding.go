@@ -0,0 +1,64 @@
+// Package blinding defines a common, byte-oriented interface to the signature
+// schemes with key blinding implemented in this module, so that protocols can
+// be written once and instantiated with any of them.
+//
+// New deployments should prefer Ristretto255, which works in a prime-order
+// group and so has no cofactor to clear or account for when blinding keys.
+// Ed25519 and the ECDSA schemes are provided for compatibility with existing
+// keys and verifiers, and BLS12381 for signatures that need to be aggregated.
+package blinding
+
+import (
+	"errors"
+	"io"
+)
+
+// BlindableScheme is a signature scheme with key blinding.
+//
+// Keys, blinds and signatures are opaque byte strings in the encoding of the
+// scheme. A public key blinded with BlindPublicKey verifies exactly the
+// signatures produced by BlindKeySign with the same blind and context string.
+// Blinds are secret and should be generated with GenerateBlind.
+type BlindableScheme interface {
+	// Name returns the name of the scheme.
+	Name() string
+
+	// GenerateKey generates a key pair using entropy from rand.
+	GenerateKey(rand io.Reader) (publicKey, privateKey []byte, err error)
+
+	// PublicKey returns the public key corresponding to privateKey.
+	PublicKey(privateKey []byte) ([]byte, error)
+
+	// GenerateBlind generates a blind using entropy from rand.
+	GenerateBlind(rand io.Reader) ([]byte, error)
+
+	// BlindPublicKey blinds publicKey by blind and context.
+	BlindPublicKey(publicKey, blind, context []byte) ([]byte, error)
+
+	// UnblindPublicKey reverses BlindPublicKey with the same blind and
+	// context.
+	UnblindPublicKey(publicKey, blind, context []byte) ([]byte, error)
+
+	// Sign signs message with privateKey.
+	Sign(rand io.Reader, privateKey, message []byte) ([]byte, error)
+
+	// BlindKeySign signs message with privateKey blinded by blind and
+	// context.
+	BlindKeySign(rand io.Reader, privateKey, blind, message, context []byte) ([]byte, error)
+
+	// Verify reports whether signature is a valid signature of message by
+	// publicKey. It returns false for malformed inputs.
+	Verify(publicKey, message, signature []byte) bool
+}
+
+// Errors returned by the schemes of this package.
+var (
+	// ErrInvalidPublicKey is returned when a public key is badly encoded.
+	ErrInvalidPublicKey = errors.New("blinding: invalid public key")
+
+	// ErrInvalidPrivateKey is returned when a private key is badly encoded.
+	ErrInvalidPrivateKey = errors.New("blinding: invalid private key")
+
+	// ErrInvalidBlind is returned when a blind is badly encoded.
+	ErrInvalidBlind = errors.New("blinding: invalid blind")
+)
@@ -0,0 +1,120 @@
+package blinding
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/ed25519"
+)
+
+func testAllSchemes(t *testing.T, f func(*testing.T, BlindableScheme)) {
+	schemes := []BlindableScheme{
+		Ristretto255,
+		Ed25519,
+		Ed25519WithParams(ed25519.Params{Cofactor: ed25519.CofactorReject}),
+		BLS12381,
+		ECDSA(elliptic.P256(), crypto.SHA256),
+		ECDSA(elliptic.P384(), crypto.SHA384),
+		ECDSA(brainpool.P256r1(), crypto.SHA256),
+		ECDSAWithBlindHash(elliptic.P256(), crypto.SHA3_256, ecdsa.BlindHashSHAKE128),
+	}
+	for _, s := range schemes {
+		t.Run(s.Name(), func(t *testing.T) {
+			f(t, s)
+		})
+	}
+}
+
+func TestBlindKeySign(t *testing.T) {
+	testAllSchemes(t, testBlindKeySign)
+}
+
+func testBlindKeySign(t *testing.T, s BlindableScheme) {
+	pk, sk, err := s.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if pub, err := s.PublicKey(sk); err != nil || !bytes.Equal(pub, pk) {
+		t.Errorf("PublicKey does not match GenerateKey: %v", err)
+	}
+	blind, err := s.GenerateBlind(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateBlind error: %s", err)
+	}
+	context := []byte("context")
+	message := []byte("test message")
+
+	sig, err := s.Sign(rand.Reader, sk, message)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !s.Verify(pk, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+
+	pkR, err := s.BlindPublicKey(pk, blind, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	sigR, err := s.BlindKeySign(rand.Reader, sk, blind, message, context)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	if !s.Verify(pkR, message, sigR) {
+		t.Errorf("valid blinded signature rejected")
+	}
+	if s.Verify(pk, message, sigR) {
+		t.Errorf("blinded signature accepted under the unblinded key")
+	}
+	if s.Verify(pkR, []byte("wrong message"), sigR) {
+		t.Errorf("signature of different message accepted")
+	}
+
+	pkU, err := s.UnblindPublicKey(pkR, blind, context)
+	if err != nil {
+		t.Fatalf("UnblindPublicKey error: %s", err)
+	}
+	if !bytes.Equal(pkU, pk) {
+		t.Errorf("Blind-unblind mismatch")
+	}
+}
+
+func TestMalformedInputs(t *testing.T) {
+	testAllSchemes(t, testMalformedInputs)
+}
+
+func testMalformedInputs(t *testing.T, s BlindableScheme) {
+	pk, sk, err := s.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	blind, err := s.GenerateBlind(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateBlind error: %s", err)
+	}
+	message := []byte("test message")
+
+	if _, err := s.BlindPublicKey(pk[1:], blind, nil); err == nil {
+		t.Errorf("short public key blinded")
+	}
+	if _, err := s.PublicKey(sk[1:]); err == nil {
+		t.Errorf("short private key accepted")
+	}
+	if _, err := s.Sign(rand.Reader, sk[1:], message); err == nil {
+		t.Errorf("short private key used to sign")
+	}
+	if _, err := s.BlindKeySign(rand.Reader, sk, blind[1:], message, nil); err == nil {
+		t.Errorf("short blind used to sign")
+	}
+	if s.Verify(pk[1:], message, make([]byte, 64)) {
+		t.Errorf("signature accepted under a short public key")
+	}
+	if s.Verify(pk, message, nil) {
+		t.Errorf("empty signature accepted")
+	}
+}
@@ -0,0 +1,164 @@
+package blinding
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/quick"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// The tests of this file check algebraic laws of every scheme on inputs
+// generated by testing/quick. Keys and blinds are drawn from a SHAKE256
+// stream seeded by a generated value, so that a failing case can be replayed
+// from the seed quick reports.
+
+// seededReader returns a deterministic stream of bytes derived from seed.
+func seededReader(seed []byte) io.Reader {
+	h := sha3.NewShake256()
+	h.Write(seed)
+	return h
+}
+
+// quickConfig returns the configuration of the property tests, with fewer
+// cases in short mode.
+func quickConfig() *quick.Config {
+	if testing.Short() {
+		return &quick.Config{MaxCount: 5}
+	}
+	return &quick.Config{MaxCount: 25}
+}
+
+// propertyKeys generates a key pair and two blinds of s from seed.
+func propertyKeys(t *testing.T, s BlindableScheme, seed []byte) (pk, sk, b1, b2 []byte) {
+	rand := seededReader(seed)
+	pk, sk, err := s.GenerateKey(rand)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if b1, err = s.GenerateBlind(rand); err != nil {
+		t.Fatalf("GenerateBlind error: %s", err)
+	}
+	if b2, err = s.GenerateBlind(rand); err != nil {
+		t.Fatalf("GenerateBlind error: %s", err)
+	}
+	return pk, sk, b1, b2
+}
+
+func TestPropertyUnblindInvertsBlind(t *testing.T) {
+	testAllSchemes(t, testPropertyUnblindInvertsBlind)
+}
+
+func testPropertyUnblindInvertsBlind(t *testing.T, s BlindableScheme) {
+	f := func(seed, context []byte) bool {
+		pk, _, b, _ := propertyKeys(t, s, seed)
+		pkR, err := s.BlindPublicKey(pk, b, context)
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		pkU, err := s.UnblindPublicKey(pkR, b, context)
+		if err != nil {
+			t.Logf("UnblindPublicKey error: %s", err)
+			return false
+		}
+		return bytes.Equal(pkU, pk)
+	}
+	if err := quick.Check(f, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyBlindCommutes(t *testing.T) {
+	testAllSchemes(t, testPropertyBlindCommutes)
+}
+
+// testPropertyBlindCommutes checks that blinding a key twice gives the same
+// key in either order, and that unblinding the outer blind of a composition
+// gives the key with only the inner blind.
+func testPropertyBlindCommutes(t *testing.T, s BlindableScheme) {
+	f := func(seed, ctx1, ctx2 []byte) bool {
+		pk, _, b1, b2 := propertyKeys(t, s, seed)
+		p1, err := s.BlindPublicKey(pk, b1, ctx1)
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		p12, err := s.BlindPublicKey(p1, b2, ctx2)
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		p2, err := s.BlindPublicKey(pk, b2, ctx2)
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		p21, err := s.BlindPublicKey(p2, b1, ctx1)
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		if !bytes.Equal(p12, p21) {
+			return false
+		}
+		u, err := s.UnblindPublicKey(p12, b2, ctx2)
+		if err != nil {
+			t.Logf("UnblindPublicKey error: %s", err)
+			return false
+		}
+		return bytes.Equal(u, p1)
+	}
+	if err := quick.Check(f, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyBlindKeySignVerifies(t *testing.T) {
+	testAllSchemes(t, testPropertyBlindKeySignVerifies)
+}
+
+// testPropertyBlindKeySignVerifies checks that a blinded signature verifies
+// under the key blinded with the same blind and context, and under no key
+// blinded with another blind or context.
+func testPropertyBlindKeySignVerifies(t *testing.T, s BlindableScheme) {
+	f := func(seed, message, context []byte) bool {
+		pk, sk, b1, b2 := propertyKeys(t, s, seed)
+		sig, err := s.BlindKeySign(seededReader(seed), sk, b1, message, context)
+		if err != nil {
+			t.Logf("BlindKeySign error: %s", err)
+			return false
+		}
+		pkR, err := s.BlindPublicKey(pk, b1, context)
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		if !s.Verify(pkR, message, sig) {
+			t.Logf("blinded signature rejected")
+			return false
+		}
+
+		other, err := s.BlindPublicKey(pk, b2, context)
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		elsewhere, err := s.BlindPublicKey(pk, b1, append([]byte{0}, context...))
+		if err != nil {
+			t.Logf("BlindPublicKey error: %s", err)
+			return false
+		}
+		for _, key := range [][]byte{pk, other, elsewhere} {
+			if s.Verify(key, message, sig) {
+				t.Logf("blinded signature accepted under a mismatched key")
+				return false
+			}
+		}
+		return !s.Verify(pkR, append([]byte{0}, message...), sig)
+	}
+	if err := quick.Check(f, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
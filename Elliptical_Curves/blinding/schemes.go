@@ -0,0 +1,361 @@
+package blinding
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/cloudflare/pat-go/bls"
+	"github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/ed25519"
+	"github.com/cloudflare/pat-go/ristretto255"
+)
+
+var (
+	// Ristretto255 is the Schnorr signature scheme with key blinding over
+	// ristretto255 of the ristretto255 package. It is the recommended scheme.
+	Ristretto255 BlindableScheme = ristretto255Scheme{}
+
+	// Ed25519 is Ed25519 with key blinding, as implemented by the ed25519
+	// package.
+	Ed25519 BlindableScheme = ed25519Scheme{}
+
+	// BLS12381 is BLS with key blinding on BLS12-381, as implemented by the
+	// bls package.
+	BLS12381 BlindableScheme = blsScheme{}
+)
+
+type ristretto255Scheme struct{}
+
+func (ristretto255Scheme) Name() string { return "ristretto255" }
+
+func (ristretto255Scheme) GenerateKey(rand io.Reader) ([]byte, []byte, error) {
+	return ristretto255.GenerateKey(rand)
+}
+
+func (ristretto255Scheme) PublicKey(privateKey []byte) ([]byte, error) {
+	if len(privateKey) != ristretto255.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	return ristretto255.PrivateKey(privateKey).Public().(ristretto255.PublicKey), nil
+}
+
+func (ristretto255Scheme) GenerateBlind(rand io.Reader) ([]byte, error) {
+	return readBlind(rand, ristretto255.BlindSize)
+}
+
+func (ristretto255Scheme) BlindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	if len(publicKey) != ristretto255.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return ristretto255.BlindPublicKeyWithContext(publicKey, blind, context)
+}
+
+func (ristretto255Scheme) UnblindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	if len(publicKey) != ristretto255.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return ristretto255.UnblindPublicKeyWithContext(publicKey, blind, context)
+}
+
+func (ristretto255Scheme) Sign(_ io.Reader, privateKey, message []byte) ([]byte, error) {
+	if len(privateKey) != ristretto255.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	return ristretto255.Sign(privateKey, message), nil
+}
+
+func (ristretto255Scheme) BlindKeySign(_ io.Reader, privateKey, blind, message, context []byte) ([]byte, error) {
+	if len(privateKey) != ristretto255.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	if len(blind) != ristretto255.BlindSize {
+		return nil, ErrInvalidBlind
+	}
+	return ristretto255.BlindKeySignWithContext(privateKey, message, blind, context), nil
+}
+
+func (ristretto255Scheme) Verify(publicKey, message, signature []byte) bool {
+	if len(publicKey) != ristretto255.PublicKeySize {
+		return false
+	}
+	return ristretto255.Verify(publicKey, message, signature)
+}
+
+type ed25519Scheme struct {
+	params ed25519.Params
+}
+
+// Ed25519WithParams returns Ed25519 with the implementation choices of
+// params, such as the handling of points with a small-order component.
+// Ed25519 is Ed25519WithParams with the zero Params.
+func Ed25519WithParams(params ed25519.Params) BlindableScheme {
+	return ed25519Scheme{params: params}
+}
+
+func (ed25519Scheme) Name() string { return "Ed25519" }
+
+func (ed25519Scheme) GenerateKey(rand io.Reader) ([]byte, []byte, error) {
+	return ed25519.GenerateKey(rand)
+}
+
+func (ed25519Scheme) PublicKey(privateKey []byte) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	return ed25519.PrivateKey(privateKey).Public().(ed25519.PublicKey), nil
+}
+
+func (ed25519Scheme) GenerateBlind(rand io.Reader) ([]byte, error) {
+	return readBlind(rand, ed25519.SeedSize)
+}
+
+func (s ed25519Scheme) BlindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return s.params.BlindPublicKeyWithContext(publicKey, blind, context)
+}
+
+func (s ed25519Scheme) UnblindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return s.params.UnblindPublicKeyWithContext(publicKey, blind, context)
+}
+
+func (ed25519Scheme) Sign(_ io.Reader, privateKey, message []byte) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	return ed25519.Sign(privateKey, message), nil
+}
+
+func (ed25519Scheme) BlindKeySign(_ io.Reader, privateKey, blind, message, context []byte) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	if len(blind) != ed25519.SeedSize {
+		return nil, ErrInvalidBlind
+	}
+	return ed25519.BlindKeySignWithContext(privateKey, message, blind, context), nil
+}
+
+func (s ed25519Scheme) Verify(publicKey, message, signature []byte) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return s.params.Verify(publicKey, message, signature)
+}
+
+type blsScheme struct{}
+
+func (blsScheme) Name() string { return "BLS12-381" }
+
+func (blsScheme) GenerateKey(rand io.Reader) ([]byte, []byte, error) {
+	return bls.GenerateKey(rand)
+}
+
+func (blsScheme) PublicKey(privateKey []byte) ([]byte, error) {
+	pk, ok := bls.PrivateKey(privateKey).Public().(bls.PublicKey)
+	if !ok {
+		return nil, ErrInvalidPrivateKey
+	}
+	return pk, nil
+}
+
+func (blsScheme) GenerateBlind(rand io.Reader) ([]byte, error) {
+	return readBlind(rand, bls.BlindSize)
+}
+
+func (blsScheme) BlindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	return bls.BlindPublicKeyWithContext(publicKey, blind, context)
+}
+
+func (blsScheme) UnblindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	return bls.UnblindPublicKeyWithContext(publicKey, blind, context)
+}
+
+func (blsScheme) Sign(_ io.Reader, privateKey, message []byte) ([]byte, error) {
+	return bls.Sign(privateKey, message)
+}
+
+func (blsScheme) BlindKeySign(_ io.Reader, privateKey, blind, message, context []byte) ([]byte, error) {
+	if len(blind) != bls.BlindSize {
+		return nil, ErrInvalidBlind
+	}
+	return bls.BlindKeySignWithContext(privateKey, message, blind, context)
+}
+
+func (blsScheme) Verify(publicKey, message, signature []byte) bool {
+	return bls.Verify(publicKey, message, signature)
+}
+
+func readBlind(rand io.Reader, size int) ([]byte, error) {
+	blind := make([]byte, size)
+	if _, err := io.ReadFull(rand, blind); err != nil {
+		return nil, err
+	}
+	return blind, nil
+}
+
+// ECDSA returns ECDSA with key blinding, as implemented by the ecdsa package,
+// on the curve c with messages hashed by h. Public keys are compressed SEC 1
+// points, private keys and blinds are fixed-length big-endian scalars, and
+// signatures are the fixed-length concatenation of r and s.
+func ECDSA(c elliptic.Curve, h crypto.Hash) BlindableScheme {
+	return ecdsaScheme{c: c, h: h}
+}
+
+// ECDSAWithBlindHash is ECDSA with blinds and contexts hashed with bh rather
+// than the default hash function of the curve. Its name, such as
+// "ECDSA-P-256-SHA3-256-BLIND-SHAKE128", and its algorithm identifier record
+// bh, so that keys tagged with MarshalPublicKey carry the blind derivation
+// they were made with. With ecdsa.BlindHashDefault, it is ECDSA.
+func ECDSAWithBlindHash(c elliptic.Curve, h crypto.Hash, bh ecdsa.BlindHash) BlindableScheme {
+	return ecdsaScheme{c: c, h: h, bh: bh}
+}
+
+type ecdsaScheme struct {
+	c  elliptic.Curve
+	h  crypto.Hash
+	bh ecdsa.BlindHash
+}
+
+func (s ecdsaScheme) Name() string {
+	if s.bh != ecdsa.BlindHashDefault {
+		return fmt.Sprintf("ECDSA-%s-%s-BLIND-%s", s.c.Params().Name, s.h, s.bh)
+	}
+	return fmt.Sprintf("ECDSA-%s-%s", s.c.Params().Name, s.h)
+}
+
+func (s ecdsaScheme) GenerateKey(rand io.Reader) ([]byte, []byte, error) {
+	priv, err := ecdsa.GenerateKey(s.c, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return elliptic.MarshalCompressed(s.c, priv.X, priv.Y), s.scalarBytes(priv.D), nil
+}
+
+func (s ecdsaScheme) PublicKey(privateKey []byte) ([]byte, error) {
+	sk, err := s.privateKey(privateKey, ErrInvalidPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.MarshalCompressed(s.c, sk.X, sk.Y), nil
+}
+
+func (s ecdsaScheme) GenerateBlind(rand io.Reader) ([]byte, error) {
+	_, blind, err := s.GenerateKey(rand)
+	return blind, err
+}
+
+func (s ecdsaScheme) scalarBytes(x *big.Int) []byte {
+	return x.FillBytes(make([]byte, (s.c.Params().N.BitLen()+7)/8))
+}
+
+func (s ecdsaScheme) publicKey(b []byte) (*ecdsa.PublicKey, error) {
+	p, err := ecdsa.NewPoint(s.c, b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	pub, err := ecdsa.NewPublicKey(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	return pub, nil
+}
+
+func (s ecdsaScheme) privateKey(b []byte, sentinel error) (*ecdsa.PrivateKey, error) {
+	d, err := ecdsa.NewScalar(s.c).SetBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", sentinel, err)
+	}
+	priv, err := ecdsa.NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", sentinel, err)
+	}
+	return priv, nil
+}
+
+func (s ecdsaScheme) digest(message []byte) []byte {
+	h := s.h.New()
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+func (s ecdsaScheme) BlindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	pk, err := s.publicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	bk, err := s.privateKey(blind, ErrInvalidBlind)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := ecdsa.BlindPublicKeyWithHash(s.c, pk, bk, context, s.bh)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.MarshalCompressed(s.c, pkR.X, pkR.Y), nil
+}
+
+func (s ecdsaScheme) UnblindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	pk, err := s.publicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	bk, err := s.privateKey(blind, ErrInvalidBlind)
+	if err != nil {
+		return nil, err
+	}
+	pkS, err := ecdsa.UnblindPublicKeyWithHash(s.c, pk, bk, context, s.bh)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.MarshalCompressed(s.c, pkS.X, pkS.Y), nil
+}
+
+func (s ecdsaScheme) Sign(rand io.Reader, privateKey, message []byte) ([]byte, error) {
+	sk, err := s.privateKey(privateKey, ErrInvalidPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	r, ss, err := ecdsa.Sign(rand, sk, s.digest(message))
+	if err != nil {
+		return nil, err
+	}
+	return append(s.scalarBytes(r), s.scalarBytes(ss)...), nil
+}
+
+func (s ecdsaScheme) BlindKeySign(rand io.Reader, privateKey, blind, message, context []byte) ([]byte, error) {
+	sk, err := s.privateKey(privateKey, ErrInvalidPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	bk, err := s.privateKey(blind, ErrInvalidBlind)
+	if err != nil {
+		return nil, err
+	}
+	r, ss, err := ecdsa.BlindKeySignWithHash(rand, sk, bk, s.digest(message), context, s.bh)
+	if err != nil {
+		return nil, err
+	}
+	return append(s.scalarBytes(r), s.scalarBytes(ss)...), nil
+}
+
+func (s ecdsaScheme) Verify(publicKey, message, signature []byte) bool {
+	pk, err := s.publicKey(publicKey)
+	if err != nil {
+		return false
+	}
+	size := (s.c.Params().N.BitLen() + 7) / 8
+	if len(signature) != 2*size {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	ss := new(big.Int).SetBytes(signature[size:])
+	return ecdsa.Verify(pk, s.digest(message), r, ss)
+}
@@ -0,0 +1,169 @@
+package blinding
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// UnlinkabilityThreshold is the value of a statistic of UnlinkabilityResult
+// above which CheckUnlinkability reports blinded keys as linkable. It is large
+// enough that none of the tests of a few thousand bits is expected to exceed
+// it by chance.
+const UnlinkabilityThreshold = 6
+
+// ErrLinkable is returned by CheckUnlinkability when blinded public keys can
+// be told apart from fresh keys, or linked to the keys they were blinded from.
+var ErrLinkable = errors.New("blinding: blinded keys are linkable")
+
+// UnlinkabilityResult holds the statistics computed by CheckUnlinkability.
+// Each is the absolute value of a standard score, which is close to zero
+// when the scheme hides the key blinded keys were derived from.
+type UnlinkabilityResult struct {
+	// Samples is the number of blinded keys.
+	Samples int
+	// BitBias is the largest score of a two-proportion test comparing the
+	// frequency of a bit of the encoding of blinded keys with its frequency
+	// in fresh keys. It catches encodings that reveal that a key is blinded.
+	BitBias float64
+	// BitCorrelation is the largest score of a two-proportion test comparing
+	// how often a bit of a blinded key agrees with the same bit of the key it
+	// was blinded from and with that of an unrelated key. It catches blinding
+	// that preserves part of the key, such as the sign of a coordinate.
+	BitCorrelation float64
+	// Distance is the score of Welch's t-test comparing the Hamming distance
+	// between blinded keys and the keys they were blinded from with the
+	// distance between blinded keys and unrelated keys.
+	Distance float64
+}
+
+// CheckUnlinkability empirically checks that public keys blinded with s look
+// independent of the keys they were blinded from. It blinds samples fresh
+// keys, each with a fresh blind, and runs distinguishers that only use public
+// keys against them. It returns an error wrapping ErrLinkable if a
+// distinguisher does better than chance, which is evidence of a leak, but
+// passing is not a proof of unlinkability.
+func CheckUnlinkability(rand io.Reader, s BlindableScheme, context []byte, samples int) (*UnlinkabilityResult, error) {
+	return CheckUnlinkabilityCtx(stdcontext.Background(), rand, s, context, samples)
+}
+
+// CheckUnlinkabilityCtx is like CheckUnlinkability, but stops generating keys
+// and returns ctx.Err() once ctx is done.
+func CheckUnlinkabilityCtx(ctx stdcontext.Context, rand io.Reader, s BlindableScheme, context []byte, samples int) (*UnlinkabilityResult, error) {
+	if samples < 2 {
+		return nil, fmt.Errorf("blinding: %d samples, need at least 2", samples)
+	}
+	keys := make([][]byte, samples)
+	blinded := make([][]byte, samples)
+	for i := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pk, _, err := s.GenerateKey(rand)
+		if err != nil {
+			return nil, err
+		}
+		blind, err := s.GenerateBlind(rand)
+		if err != nil {
+			return nil, err
+		}
+		pkR, err := s.BlindPublicKey(pk, blind, context)
+		if err != nil {
+			return nil, err
+		}
+		if len(pkR) != len(pk) {
+			return nil, fmt.Errorf("%w: %d-byte blinded key of a %d-byte key", ErrLinkable, len(pkR), len(pk))
+		}
+		if i > 0 && len(pk) != len(keys[0]) {
+			return nil, fmt.Errorf("blinding: keys of %d and %d bytes", len(pk), len(keys[0]))
+		}
+		keys[i], blinded[i] = pk, pkR
+	}
+
+	// Blinded key i is linked to key i, and unrelated to key i+1.
+	unrelated := func(i int) []byte { return keys[(i+1)%samples] }
+	res := &UnlinkabilityResult{Samples: samples}
+	n := float64(samples)
+	for bit := 0; bit < 8*len(keys[0]); bit++ {
+		var inKeys, inBlinded, linked, unlinked float64
+		for i := range keys {
+			b := keyBit(blinded[i], bit)
+			inKeys += float64(keyBit(keys[i], bit))
+			inBlinded += float64(b)
+			if b == keyBit(keys[i], bit) {
+				linked++
+			}
+			if b == keyBit(unrelated(i), bit) {
+				unlinked++
+			}
+		}
+		res.BitBias = math.Max(res.BitBias, proportionScore(inBlinded, inKeys, n))
+		res.BitCorrelation = math.Max(res.BitCorrelation, proportionScore(linked, unlinked, n))
+	}
+
+	var linked, unlinked welch
+	for i := range keys {
+		linked.push(float64(hammingDistance(blinded[i], keys[i])))
+		unlinked.push(float64(hammingDistance(blinded[i], unrelated(i))))
+	}
+	res.Distance = math.Abs(welchT(&linked, &unlinked))
+
+	switch {
+	case res.BitBias > UnlinkabilityThreshold:
+		return res, fmt.Errorf("%w: bits of blinded keys are biased (score %.2f)", ErrLinkable, res.BitBias)
+	case res.BitCorrelation > UnlinkabilityThreshold:
+		return res, fmt.Errorf("%w: bits of blinded keys follow the key (score %.2f)", ErrLinkable, res.BitCorrelation)
+	case res.Distance > UnlinkabilityThreshold:
+		return res, fmt.Errorf("%w: blinded keys are close to the key (score %.2f)", ErrLinkable, res.Distance)
+	}
+	return res, nil
+}
+
+func keyBit(key []byte, bit int) int {
+	return int(key[bit/8]>>(7-bit%8)) & 1
+}
+
+func hammingDistance(a, b []byte) int {
+	d := 0
+	for i := range a {
+		d += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return d
+}
+
+// proportionScore returns the absolute score of the two-proportion z-test of
+// x1 and x2 successes out of n trials each.
+func proportionScore(x1, x2, n float64) float64 {
+	p := (x1 + x2) / (2 * n)
+	if p == 0 || p == 1 {
+		return 0
+	}
+	return math.Abs(x1-x2) / n / math.Sqrt(p*(1-p)*2/n)
+}
+
+// welch accumulates the mean and variance of a sample online.
+type welch struct {
+	n, mean, m2 float64
+}
+
+func (w *welch) push(x float64) {
+	w.n++
+	d := x - w.mean
+	w.mean += d / w.n
+	w.m2 += d * (x - w.mean)
+}
+
+// welchT returns Welch's t statistic of the samples a and b.
+func welchT(a, b *welch) float64 {
+	if a.n < 2 || b.n < 2 {
+		return 0
+	}
+	v := a.m2/(a.n-1)/a.n + b.m2/(b.n-1)/b.n
+	if v == 0 {
+		return 0
+	}
+	return (a.mean - b.mean) / math.Sqrt(v)
+}
@@ -0,0 +1,88 @@
+package blinding
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"testing/quick"
+)
+
+func TestUnlinkability(t *testing.T) {
+	testAllSchemes(t, testUnlinkability)
+}
+
+func testUnlinkability(t *testing.T, s BlindableScheme) {
+	samples := 300
+	if testing.Short() {
+		samples = 100
+	}
+	f := func(context []byte) bool {
+		res, err := CheckUnlinkability(rand.Reader, s, context, samples)
+		if err != nil {
+			t.Logf("CheckUnlinkability error: %s", err)
+			return false
+		}
+		t.Logf("%+v", *res)
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 2}); err != nil {
+		t.Error(err)
+	}
+}
+
+// identityScheme doesn't blind keys at all.
+type identityScheme struct{ BlindableScheme }
+
+func (identityScheme) BlindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	return publicKey, nil
+}
+
+// signBitScheme blinds keys, but copies the last bit of the key into the
+// blinded key.
+type signBitScheme struct{ BlindableScheme }
+
+func (s signBitScheme) BlindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	pkR, err := s.BlindableScheme.BlindPublicKey(publicKey, blind, context)
+	if err != nil {
+		return nil, err
+	}
+	last := len(pkR) - 1
+	pkR[last] = pkR[last]&^1 | publicKey[last]&1
+	return pkR, nil
+}
+
+// markedScheme blinds keys, but clears the last bit of blinded keys.
+type markedScheme struct{ BlindableScheme }
+
+func (s markedScheme) BlindPublicKey(publicKey, blind, context []byte) ([]byte, error) {
+	pkR, err := s.BlindableScheme.BlindPublicKey(publicKey, blind, context)
+	if err != nil {
+		return nil, err
+	}
+	pkR[len(pkR)-1] &^= 1
+	return pkR, nil
+}
+
+func TestUnlinkabilityDetectsLeaks(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		scheme BlindableScheme
+	}{
+		{"Identity", identityScheme{Ristretto255}},
+		{"SignBit", signBitScheme{Ristretto255}},
+		{"Marked", markedScheme{Ristretto255}},
+	} {
+		if _, err := CheckUnlinkability(rand.Reader, test.scheme, nil, 500); !errors.Is(err, ErrLinkable) {
+			t.Errorf("%s: got %v, want ErrLinkable", test.name, err)
+		}
+	}
+}
+
+func TestCheckUnlinkabilityCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CheckUnlinkabilityCtx(ctx, rand.Reader, Ristretto255, nil, 1000); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled CheckUnlinkabilityCtx: got %v, want context.Canceled", err)
+	}
+}
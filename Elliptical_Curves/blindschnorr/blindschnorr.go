@@ -0,0 +1,286 @@
+// Package blindschnorr implements blind signatures for the Schnorr scheme of
+// the ristretto255 package, with the clause technique of Fuchsbauer,
+// Plouviez and Seurin, "Blind Schnorr Signatures and Signed ElGamal
+// Encryption in the Algebraic Group Model" (EUROCRYPT 2020).
+//
+// Key blinding and message blinding are different properties. Key blinding,
+// implemented by the other packages of this module, makes signatures
+// unlinkable to the signer's long-term public key, but the signer sees the
+// messages it signs. Message blinding, implemented by this package, hides
+// the message from the signer, so that it can't link the signatures it
+// issued to the sessions that produced them. A Signer created with
+// NewBlindKeySigner combines both.
+//
+// A signature is produced in three moves:
+//
+//	Signer                                Client
+//	------                                ------
+//	session, commitment := NewSession()
+//	                      commitment ->
+//	                                      state, challenge := Blind(commitment, message)
+//	                      <- challenge
+//	response := session.Respond(challenge)
+//	                      response ->
+//	                                      signature := state.Finalize(response)
+//
+// The output is an ordinary ristretto255 signature, verified by
+// ristretto255.Verify under the public key of the Signer. The signer runs two
+// blind Schnorr sessions in parallel and completes one of them at random,
+// which defeats the ROS attack against plain blind Schnorr signatures with
+// many concurrent sessions. Sessions and client states are single-use.
+package blindschnorr
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"sync"
+
+	"filippo.io/edwards25519"
+	"github.com/cloudflare/pat-go/ristretto255"
+)
+
+const (
+	// CommitmentSize is the size, in bytes, of the commitment of a signer
+	// session.
+	CommitmentSize = 2 * ristretto255.ElementSize
+	// ChallengeSize is the size, in bytes, of the blinded challenge of a
+	// client.
+	ChallengeSize = 64
+	// ResponseSize is the size, in bytes, of the response of a signer session.
+	ResponseSize = 1 + 32
+)
+
+// Errors returned by this package.
+var (
+	// ErrInvalidMessage is returned when a commitment, challenge or response
+	// is badly encoded, or a response doesn't match its challenge.
+	ErrInvalidMessage = errors.New("blindschnorr: invalid protocol message")
+
+	// ErrSessionUsed is returned when a signer session or client state is
+	// used more than once.
+	ErrSessionUsed = errors.New("blindschnorr: session already used")
+)
+
+// randomScalar returns a uniformly random scalar using entropy from rand.
+func randomScalar(rand io.Reader) (*edwards25519.Scalar, error) {
+	var b [64]byte
+	if _, err := io.ReadFull(rand, b[:]); err != nil {
+		return nil, err
+	}
+	return edwards25519.NewScalar().SetUniformBytes(b[:])
+}
+
+// challenge computes the challenge of a ristretto255 signature, SHA-512 of
+// R || A || M reduced modulo the group order.
+func challenge(R, publicKey, message []byte) *edwards25519.Scalar {
+	h := sha512.New()
+	h.Write(R)
+	h.Write(publicKey)
+	h.Write(message)
+	k, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		panic("blindschnorr: internal error: " + err.Error())
+	}
+	return k
+}
+
+// Signer issues blind signatures with a ristretto255 private key.
+type Signer struct {
+	key       *edwards25519.Scalar
+	publicKey ristretto255.PublicKey
+}
+
+// NewSigner returns a Signer for privateKey. It will panic if
+// len(privateKey) is not ristretto255.PrivateKeySize.
+func NewSigner(privateKey ristretto255.PrivateKey) *Signer {
+	s := privateKey.Scalar()
+	return &Signer{
+		key:       s,
+		publicKey: ristretto255.NewElement().ScalarBaseMult(s).Bytes(),
+	}
+}
+
+// NewBlindKeySigner returns a Signer for privateKey blinded by blind and
+// context. Its signatures verify under the public key blinded by
+// ristretto255.BlindPublicKeyWithContext with the same blind and context.
+func NewBlindKeySigner(privateKey ristretto255.PrivateKey, blind, context []byte) (*Signer, error) {
+	s, err := privateKey.BlindScalar(blind, context)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		key:       s,
+		publicKey: ristretto255.NewElement().ScalarBaseMult(s).Bytes(),
+	}, nil
+}
+
+// PublicKey returns the public key that verifies the signatures of s.
+func (s *Signer) PublicKey() ristretto255.PublicKey {
+	return append(ristretto255.PublicKey{}, s.publicKey...)
+}
+
+// SignerSession is the state of the signer in one signing session.
+type SignerSession struct {
+	signer *Signer
+	mu     sync.Mutex
+	r      [2]*edwards25519.Scalar
+}
+
+// NewSession starts a signing session using entropy from rand, and returns
+// it with the commitment to send to the client. If rand is nil,
+// crypto/rand.Reader will be used.
+func (s *Signer) NewSession(rand io.Reader) (*SignerSession, []byte, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	session := &SignerSession{signer: s}
+	commitment := make([]byte, 0, CommitmentSize)
+	for i := range session.r {
+		r, err := randomScalar(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		session.r[i] = r
+		commitment = append(commitment, ristretto255.NewElement().ScalarBaseMult(r).Bytes()...)
+	}
+	return session, commitment, nil
+}
+
+// Respond answers the challenge of the client, completing one of the two
+// clauses of the session chosen with entropy from rand. The nonce of the
+// other clause is discarded. If rand is nil, crypto/rand.Reader will be used.
+func (ss *SignerSession) Respond(rand io.Reader, challenge []byte) ([]byte, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if len(challenge) != ChallengeSize {
+		return nil, ErrInvalidMessage
+	}
+	var c [2]*edwards25519.Scalar
+	for i := range c {
+		var err error
+		c[i], err = edwards25519.NewScalar().SetCanonicalBytes(challenge[32*i : 32*(i+1)])
+		if err != nil {
+			return nil, ErrInvalidMessage
+		}
+	}
+	var bit [1]byte
+	if _, err := io.ReadFull(rand, bit[:]); err != nil {
+		return nil, err
+	}
+	b := bit[0] & 1
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.r[0] == nil {
+		return nil, ErrSessionUsed
+	}
+	s := edwards25519.NewScalar().MultiplyAdd(c[b], ss.signer.key, ss.r[b])
+	ss.r[0], ss.r[1] = nil, nil
+
+	return append([]byte{b}, s.Bytes()...), nil
+}
+
+// Client requests blind signatures from a Signer with a known public key.
+type Client struct {
+	publicKey ristretto255.PublicKey
+	element   *ristretto255.Element
+}
+
+// NewClient returns a Client for signatures under publicKey.
+func NewClient(publicKey ristretto255.PublicKey) (*Client, error) {
+	A, err := ristretto255.NewElement().SetCanonicalBytes(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{publicKey: append(ristretto255.PublicKey{}, publicKey...), element: A}, nil
+}
+
+// ClientState is the state of the client in one signing session.
+type ClientState struct {
+	client  *Client
+	message []byte
+	mu      sync.Mutex
+	used    bool
+
+	// commitment and c are the commitments and challenges exchanged with the
+	// signer, and blindR and alpha the blinded commitments and the blinds of
+	// the responses, for both clauses.
+	commitment [2]*ristretto255.Element
+	c          [2]*edwards25519.Scalar
+	blindR     [2][]byte
+	alpha      [2]*edwards25519.Scalar
+}
+
+// Blind blinds the signing of message in answer to the commitment of the
+// signer, using entropy from rand, and returns the challenge to send to the
+// signer. If rand is nil, crypto/rand.Reader will be used.
+func (c *Client) Blind(rand io.Reader, commitment, message []byte) (*ClientState, []byte, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if len(commitment) != CommitmentSize {
+		return nil, nil, ErrInvalidMessage
+	}
+	st := &ClientState{client: c, message: append([]byte{}, message...)}
+	out := make([]byte, 0, ChallengeSize)
+	for i := range st.commitment {
+		R, err := ristretto255.NewElement().SetCanonicalBytes(commitment[32*i : 32*(i+1)])
+		if err != nil {
+			return nil, nil, ErrInvalidMessage
+		}
+		alpha, err := randomScalar(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		beta, err := randomScalar(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// R' = R + [alpha]B + [beta]A, c = H(R' || A || M) + beta. The
+		// blinds are secret, so they are multiplied in constant time.
+		blindR := ristretto255.NewElement().ScalarBaseMult(alpha)
+		blindR.Add(blindR, ristretto255.NewElement().ScalarMult(beta, c.element))
+		blindR.Add(blindR, R)
+		st.commitment[i] = R
+		st.alpha[i] = alpha
+		st.blindR[i] = blindR.Bytes()
+		st.c[i] = challenge(st.blindR[i], c.publicKey, message)
+		st.c[i].Add(st.c[i], beta)
+		out = append(out, st.c[i].Bytes()...)
+	}
+	return st, out, nil
+}
+
+// Finalize checks the response of the signer and unblinds it into a
+// signature of the message passed to Blind, verified by ristretto255.Verify.
+func (st *ClientState) Finalize(response []byte) ([]byte, error) {
+	if len(response) != ResponseSize || response[0] > 1 {
+		return nil, ErrInvalidMessage
+	}
+	b := response[0]
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(response[1:])
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.used {
+		return nil, ErrSessionUsed
+	}
+
+	// [s]B - [c]A = R
+	minusA := ristretto255.NewElement().Negate(st.client.element)
+	R := ristretto255.NewElement().VarTimeDoubleScalarBaseMult(st.c[b], minusA, s)
+	if R.Equal(st.commitment[b]) != 1 {
+		return nil, ErrInvalidMessage
+	}
+	st.used = true
+
+	s.Add(s, st.alpha[b])
+	return append(append([]byte{}, st.blindR[b]...), s.Bytes()...), nil
+}
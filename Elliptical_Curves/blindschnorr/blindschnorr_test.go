@@ -0,0 +1,132 @@
+package blindschnorr
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/ristretto255"
+)
+
+func blindSign(t *testing.T, signer *Signer, message []byte) []byte {
+	client, err := NewClient(signer.PublicKey())
+	if err != nil {
+		t.Fatalf("NewClient error: %s", err)
+	}
+	session, commitment, err := signer.NewSession(rand.Reader)
+	if err != nil {
+		t.Fatalf("NewSession error: %s", err)
+	}
+	state, challenge, err := client.Blind(rand.Reader, commitment, message)
+	if err != nil {
+		t.Fatalf("Blind error: %s", err)
+	}
+	response, err := session.Respond(rand.Reader, challenge)
+	if err != nil {
+		t.Fatalf("Respond error: %s", err)
+	}
+	sig, err := state.Finalize(response)
+	if err != nil {
+		t.Fatalf("Finalize error: %s", err)
+	}
+	return sig
+}
+
+func TestBlindSignature(t *testing.T) {
+	public, private, _ := ristretto255.GenerateKey(rand.Reader)
+	signer := NewSigner(private)
+	if !signer.PublicKey().Equal(public) {
+		t.Fatalf("signer public key does not match")
+	}
+	message := []byte("testing")
+	for i := 0; i < 8; i++ {
+		sig := blindSign(t, signer, message)
+		if !ristretto255.Verify(public, message, sig) {
+			t.Fatalf("blind signature rejected")
+		}
+		if ristretto255.Verify(public, []byte("other"), sig) {
+			t.Fatalf("blind signature accepted for another message")
+		}
+	}
+}
+
+func TestBlindKeySigner(t *testing.T) {
+	public, private, _ := ristretto255.GenerateKey(rand.Reader)
+	blind := make([]byte, ristretto255.BlindSize)
+	rand.Read(blind)
+	context := []byte("context")
+	signer, err := NewBlindKeySigner(private, blind, context)
+	if err != nil {
+		t.Fatalf("NewBlindKeySigner error: %s", err)
+	}
+	blindedPublic, _ := ristretto255.BlindPublicKeyWithContext(public, blind, context)
+	if !signer.PublicKey().Equal(blindedPublic) {
+		t.Fatalf("signer public key is not the blinded key")
+	}
+	message := []byte("testing")
+	sig := blindSign(t, signer, message)
+	if !ristretto255.Verify(blindedPublic, message, sig) {
+		t.Errorf("blind signature rejected under the blinded key")
+	}
+	if ristretto255.Verify(public, message, sig) {
+		t.Errorf("blind signature accepted under the unblinded key")
+	}
+	if _, err := NewBlindKeySigner(private, blind[1:], context); err == nil {
+		t.Errorf("NewBlindKeySigner accepted a short blind")
+	}
+}
+
+func TestSessionMisuse(t *testing.T) {
+	_, private, _ := ristretto255.GenerateKey(rand.Reader)
+	signer := NewSigner(private)
+	client, _ := NewClient(signer.PublicKey())
+	message := []byte("testing")
+
+	session, commitment, _ := signer.NewSession(rand.Reader)
+	state, challenge, _ := client.Blind(rand.Reader, commitment, message)
+	response, err := session.Respond(rand.Reader, challenge)
+	if err != nil {
+		t.Fatalf("Respond error: %s", err)
+	}
+	if _, err := session.Respond(rand.Reader, challenge); !errors.Is(err, ErrSessionUsed) {
+		t.Errorf("second Respond: got %v, want ErrSessionUsed", err)
+	}
+
+	tampered := append([]byte{}, response...)
+	tampered[0] ^= 1
+	if _, err := state.Finalize(tampered); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Finalize of the other clause: got %v, want ErrInvalidMessage", err)
+	}
+	if _, err := state.Finalize(response); err != nil {
+		t.Fatalf("Finalize error: %s", err)
+	}
+	if _, err := state.Finalize(response); !errors.Is(err, ErrSessionUsed) {
+		t.Errorf("second Finalize: got %v, want ErrSessionUsed", err)
+	}
+
+	if _, _, err := client.Blind(rand.Reader, commitment[1:], message); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Blind of a short commitment: got %v, want ErrInvalidMessage", err)
+	}
+	session, _, _ = signer.NewSession(rand.Reader)
+	if _, err := session.Respond(rand.Reader, bytes.Repeat([]byte{0xff}, ChallengeSize)); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Respond to a non-canonical challenge: got %v, want ErrInvalidMessage", err)
+	}
+}
+
+func TestUnlinkable(t *testing.T) {
+	// The signature must not contain any value the signer saw.
+	_, private, _ := ristretto255.GenerateKey(rand.Reader)
+	signer := NewSigner(private)
+	client, _ := NewClient(signer.PublicKey())
+	session, commitment, _ := signer.NewSession(rand.Reader)
+	state, challenge, _ := client.Blind(rand.Reader, commitment, []byte("testing"))
+	response, _ := session.Respond(rand.Reader, challenge)
+	sig, _ := state.Finalize(response)
+
+	for _, seen := range [][]byte{commitment[:32], commitment[32:], challenge[:32], challenge[32:], response[1:]} {
+		if bytes.Contains(sig, seen) {
+			t.Errorf("signature contains a value seen by the signer")
+		}
+	}
+}
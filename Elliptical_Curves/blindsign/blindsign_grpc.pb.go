@@ -0,0 +1,230 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: blindsign.proto
+
+package blindsign
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BlindSigner_GenerateKey_FullMethodName    = "/blindsign.v1.BlindSigner/GenerateKey"
+	BlindSigner_BlindPublicKey_FullMethodName = "/blindsign.v1.BlindSigner/BlindPublicKey"
+	BlindSigner_BlindKeySign_FullMethodName   = "/blindsign.v1.BlindSigner/BlindKeySign"
+	BlindSigner_Verify_FullMethodName         = "/blindsign.v1.BlindSigner/Verify"
+)
+
+// BlindSignerClient is the client API for BlindSigner service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BlindSignerClient interface {
+	// GenerateKey generates a key pair for a scheme and returns its public key.
+	GenerateKey(ctx context.Context, in *GenerateKeyRequest, opts ...grpc.CallOption) (*GenerateKeyResponse, error)
+	// BlindPublicKey blinds a public key by a blind and a context string.
+	BlindPublicKey(ctx context.Context, in *BlindPublicKeyRequest, opts ...grpc.CallOption) (*BlindPublicKeyResponse, error)
+	// BlindKeySign signs a message with a key blinded by a blind and a context
+	// string.
+	BlindKeySign(ctx context.Context, in *BlindKeySignRequest, opts ...grpc.CallOption) (*BlindKeySignResponse, error)
+	// Verify checks a signature of a message by a public key.
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+}
+
+type blindSignerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBlindSignerClient(cc grpc.ClientConnInterface) BlindSignerClient {
+	return &blindSignerClient{cc}
+}
+
+func (c *blindSignerClient) GenerateKey(ctx context.Context, in *GenerateKeyRequest, opts ...grpc.CallOption) (*GenerateKeyResponse, error) {
+	out := new(GenerateKeyResponse)
+	err := c.cc.Invoke(ctx, BlindSigner_GenerateKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blindSignerClient) BlindPublicKey(ctx context.Context, in *BlindPublicKeyRequest, opts ...grpc.CallOption) (*BlindPublicKeyResponse, error) {
+	out := new(BlindPublicKeyResponse)
+	err := c.cc.Invoke(ctx, BlindSigner_BlindPublicKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blindSignerClient) BlindKeySign(ctx context.Context, in *BlindKeySignRequest, opts ...grpc.CallOption) (*BlindKeySignResponse, error) {
+	out := new(BlindKeySignResponse)
+	err := c.cc.Invoke(ctx, BlindSigner_BlindKeySign_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blindSignerClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, BlindSigner_Verify_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BlindSignerServer is the server API for BlindSigner service.
+// All implementations must embed UnimplementedBlindSignerServer
+// for forward compatibility
+type BlindSignerServer interface {
+	// GenerateKey generates a key pair for a scheme and returns its public key.
+	GenerateKey(context.Context, *GenerateKeyRequest) (*GenerateKeyResponse, error)
+	// BlindPublicKey blinds a public key by a blind and a context string.
+	BlindPublicKey(context.Context, *BlindPublicKeyRequest) (*BlindPublicKeyResponse, error)
+	// BlindKeySign signs a message with a key blinded by a blind and a context
+	// string.
+	BlindKeySign(context.Context, *BlindKeySignRequest) (*BlindKeySignResponse, error)
+	// Verify checks a signature of a message by a public key.
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	mustEmbedUnimplementedBlindSignerServer()
+}
+
+// UnimplementedBlindSignerServer must be embedded to have forward compatible implementations.
+type UnimplementedBlindSignerServer struct {
+}
+
+func (UnimplementedBlindSignerServer) GenerateKey(context.Context, *GenerateKeyRequest) (*GenerateKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateKey not implemented")
+}
+func (UnimplementedBlindSignerServer) BlindPublicKey(context.Context, *BlindPublicKeyRequest) (*BlindPublicKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlindPublicKey not implemented")
+}
+func (UnimplementedBlindSignerServer) BlindKeySign(context.Context, *BlindKeySignRequest) (*BlindKeySignResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlindKeySign not implemented")
+}
+func (UnimplementedBlindSignerServer) Verify(context.Context, *VerifyRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedBlindSignerServer) mustEmbedUnimplementedBlindSignerServer() {}
+
+// UnsafeBlindSignerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BlindSignerServer will
+// result in compilation errors.
+type UnsafeBlindSignerServer interface {
+	mustEmbedUnimplementedBlindSignerServer()
+}
+
+func RegisterBlindSignerServer(s grpc.ServiceRegistrar, srv BlindSignerServer) {
+	s.RegisterService(&BlindSigner_ServiceDesc, srv)
+}
+
+func _BlindSigner_GenerateKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlindSignerServer).GenerateKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlindSigner_GenerateKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlindSignerServer).GenerateKey(ctx, req.(*GenerateKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlindSigner_BlindPublicKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlindPublicKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlindSignerServer).BlindPublicKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlindSigner_BlindPublicKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlindSignerServer).BlindPublicKey(ctx, req.(*BlindPublicKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlindSigner_BlindKeySign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlindKeySignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlindSignerServer).BlindKeySign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlindSigner_BlindKeySign_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlindSignerServer).BlindKeySign(ctx, req.(*BlindKeySignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlindSigner_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlindSignerServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlindSigner_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlindSignerServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BlindSigner_ServiceDesc is the grpc.ServiceDesc for BlindSigner service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BlindSigner_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "blindsign.v1.BlindSigner",
+	HandlerType: (*BlindSignerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GenerateKey",
+			Handler:    _BlindSigner_GenerateKey_Handler,
+		},
+		{
+			MethodName: "BlindPublicKey",
+			Handler:    _BlindSigner_BlindPublicKey_Handler,
+		},
+		{
+			MethodName: "BlindKeySign",
+			Handler:    _BlindSigner_BlindKeySign_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _BlindSigner_Verify_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "blindsign.proto",
+}
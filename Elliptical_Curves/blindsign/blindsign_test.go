@@ -0,0 +1,248 @@
+package blindsign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+type testPKI struct {
+	pool   *x509.CertPool
+	server tls.Certificate
+	client tls.Certificate
+}
+
+func newTestCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (tls.Certificate, *x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate error: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate error: %s", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert, key
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().Add(time.Hour)
+
+	_, ca, caKey := newTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+	server, _, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, ca, caKey)
+	client, _, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &testPKI{pool: pool, server: server, client: client}
+}
+
+func startTestServer(t *testing.T, pki *testPKI) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %s", err)
+	}
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(ServerTLSConfig(pki.server, pki.pool))))
+	RegisterBlindSignerServer(s, NewServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func TestBlindKeySign(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := startTestServer(t, pki)
+	c, err := Dial(addr, ClientTLSConfig(pki.client, pki.pool))
+	if err != nil {
+		t.Fatalf("Dial error: %s", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	message := []byte("test message")
+	context := []byte("context")
+
+	for _, scheme := range DefaultSchemes() {
+		t.Run(scheme.Name(), func(t *testing.T) {
+			keyID, pk, err := c.GenerateKey(ctx, scheme.Name())
+			if err != nil {
+				t.Fatalf("GenerateKey error: %s", err)
+			}
+			blind, err := scheme.GenerateBlind(rand.Reader)
+			if err != nil {
+				t.Fatalf("GenerateBlind error: %s", err)
+			}
+
+			pkR, err := c.BlindPublicKey(ctx, pk, blind, context)
+			if err != nil {
+				t.Fatalf("BlindPublicKey error: %s", err)
+			}
+			sig, err := c.BlindKeySign(ctx, keyID, blind, message, context)
+			if err != nil {
+				t.Fatalf("BlindKeySign error: %s", err)
+			}
+			if !scheme.Verify(pkR.Data, message, sig.Data) {
+				t.Errorf("signature rejected locally")
+			}
+
+			valid, err := c.Verify(ctx, pkR, message, sig)
+			if err != nil {
+				t.Fatalf("Verify error: %s", err)
+			}
+			if !valid {
+				t.Errorf("valid signature rejected")
+			}
+			valid, err = c.Verify(ctx, pk, message, sig)
+			if err != nil {
+				t.Fatalf("Verify error: %s", err)
+			}
+			if valid {
+				t.Errorf("signature verified under the unblinded key")
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := startTestServer(t, pki)
+	c, err := Dial(addr, ClientTLSConfig(pki.client, pki.pool))
+	if err != nil {
+		t.Fatalf("Dial error: %s", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	if _, _, err := c.GenerateKey(ctx, "unknown"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GenerateKey with unknown scheme: got %v, want InvalidArgument", err)
+	}
+	if _, err := c.BlindKeySign(ctx, "unknown", nil, []byte("message"), nil); status.Code(err) != codes.NotFound {
+		t.Errorf("BlindKeySign with unknown key: got %v, want NotFound", err)
+	}
+	keyID, _, err := c.GenerateKey(ctx, "ristretto255")
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if _, err := c.BlindKeySign(ctx, keyID, []byte("short"), []byte("message"), nil); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BlindKeySign with invalid blind: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestClientCertificateRequired(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := startTestServer(t, pki)
+	c, err := Dial(addr, &tls.Config{RootCAs: pki.pool, MinVersion: tls.VersionTLS13})
+	if err != nil {
+		t.Fatalf("Dial error: %s", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, _, err := c.GenerateKey(ctx, "ristretto255"); err == nil {
+		t.Errorf("request without client certificate accepted")
+	}
+}
+
+func TestCancelledRequests(t *testing.T) {
+	s := NewServer()
+	key, err := s.GenerateKey(context.Background(), &GenerateKeyRequest{Scheme: "ristretto255"})
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.GenerateKey(ctx, &GenerateKeyRequest{Scheme: "ristretto255"}); status.Code(err) != codes.Canceled {
+		t.Errorf("cancelled GenerateKey: got %v, want Canceled", err)
+	}
+	if _, err := s.BlindKeySign(ctx, &BlindKeySignRequest{KeyId: key.GetKeyId(), Message: []byte("message")}); status.Code(err) != codes.Canceled {
+		t.Errorf("cancelled BlindKeySign: got %v, want Canceled", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if _, err := s.Verify(ctx, &VerifyRequest{PublicKey: key.GetPublicKey()}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Verify past its deadline: got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestServerKeystore(t *testing.T) {
+	key := make([]byte, keystore.KeySize)
+	rand.Read(key)
+	dir := t.TempDir()
+	keys, err := keystore.NewDir(dir, key)
+	if err != nil {
+		t.Fatalf("NewDir error: %s", err)
+	}
+	ctx := context.Background()
+	generated, err := NewServerWithKeystore(keys).GenerateKey(ctx, &GenerateKeyRequest{Scheme: "ristretto255"})
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+
+	// A server restarted on the same keystore signs with the key.
+	blind, _ := blinding.Ristretto255.GenerateBlind(rand.Reader)
+	req := &BlindKeySignRequest{KeyId: generated.GetKeyId(), Blind: blind, Message: []byte("message")}
+	reopened, _ := keystore.NewDir(dir, key)
+	s := NewServerWithKeystore(reopened)
+	resp, err := s.BlindKeySign(ctx, req)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	if resp.GetSignature().GetScheme() != "ristretto255" {
+		t.Errorf("signature of scheme %q, want ristretto255", resp.GetSignature().GetScheme())
+	}
+
+	// Keys of schemes the server does not serve can't be used.
+	s = NewServerWithKeystore(reopened, blinding.Ed25519)
+	if _, err := s.BlindKeySign(ctx, req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("BlindKeySign with an unserved scheme: got %v, want FailedPrecondition", err)
+	}
+}
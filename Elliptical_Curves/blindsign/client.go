@@ -0,0 +1,82 @@
+package blindsign
+
+import (
+	"context"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Client is a client of the BlindSigner service.
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  BlindSignerClient
+}
+
+// Dial connects to the service at target over TLS with config, which should
+// present a client certificate, as returned by ClientTLSConfig.
+func Dial(target string, config *tls.Config, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(config))}, opts...)
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, rpc: NewBlindSignerClient(conn)}, nil
+}
+
+// Close closes the connection to the service.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// GenerateKey generates a key pair of the named scheme on the service, and
+// returns the ID of its private key and its public key.
+func (c *Client) GenerateKey(ctx context.Context, scheme string) (string, *PublicKey, error) {
+	resp, err := c.rpc.GenerateKey(ctx, &GenerateKeyRequest{Scheme: scheme})
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.GetKeyId(), resp.GetPublicKey(), nil
+}
+
+// BlindPublicKey blinds pk by blind and context.
+func (c *Client) BlindPublicKey(ctx context.Context, pk *PublicKey, blind, context []byte) (*PublicKey, error) {
+	resp, err := c.rpc.BlindPublicKey(ctx, &BlindPublicKeyRequest{
+		PublicKey: pk,
+		Blind:     blind,
+		Context:   context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPublicKey(), nil
+}
+
+// BlindKeySign signs message with the key with ID keyID blinded by blind and
+// context.
+func (c *Client) BlindKeySign(ctx context.Context, keyID string, blind, message, context []byte) (*Signature, error) {
+	resp, err := c.rpc.BlindKeySign(ctx, &BlindKeySignRequest{
+		KeyId:   keyID,
+		Blind:   blind,
+		Message: message,
+		Context: context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetSignature(), nil
+}
+
+// Verify reports whether sig is a valid signature of message by pk.
+func (c *Client) Verify(ctx context.Context, pk *PublicKey, message []byte, sig *Signature) (bool, error) {
+	resp, err := c.rpc.Verify(ctx, &VerifyRequest{
+		PublicKey: pk,
+		Message:   message,
+		Signature: sig,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetValid(), nil
+}
@@ -0,0 +1,225 @@
+// Package blindsign implements BlindSigner, a gRPC service that signs messages
+// with blinded keys, and a Go client for it. It lets clients written in other
+// languages, and services that should not hold signing keys, use the schemes
+// of the blinding package.
+//
+// Private keys are generated by the service and never leave it: clients refer
+// to them by the key ID returned by GenerateKey, and choose the blind and
+// context string of each signature. Keys are held in a keystore.Keystore,
+// in memory unless the server is created with NewServerWithKeystore. The service should only be exposed over
+// mutually authenticated TLS, for instance with the configuration returned by
+// ServerTLSConfig, since any client that can reach it can sign with any key.
+//
+// The protocol buffer definitions of the service are in blindsign.proto.
+package blindsign
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative blindsign.proto
+
+import (
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+// keyIDSize is the size, in bytes, of the random key IDs.
+const keyIDSize = 16
+
+// DefaultSchemes returns the schemes served by a Server created without
+// explicit schemes.
+func DefaultSchemes() []blinding.BlindableScheme {
+	return []blinding.BlindableScheme{
+		blinding.Ristretto255,
+		blinding.Ed25519,
+		blinding.BLS12381,
+		blinding.ECDSA(elliptic.P256(), crypto.SHA256),
+		blinding.ECDSA(elliptic.P384(), crypto.SHA384),
+	}
+}
+
+// Server implements the BlindSigner service. It is safe for concurrent use.
+type Server struct {
+	UnimplementedBlindSignerServer
+
+	rand    io.Reader
+	schemes map[string]blinding.BlindableScheme
+	keys    keystore.Keystore
+}
+
+// NewServer returns a Server for the given schemes, identified by their
+// names, with keys held in memory. If no scheme is given, the server uses
+// DefaultSchemes.
+func NewServer(schemes ...blinding.BlindableScheme) *Server {
+	keys, err := keystore.NewMemory(nil)
+	if err != nil {
+		panic("blindsign: " + err.Error())
+	}
+	return NewServerWithKeystore(keys, schemes...)
+}
+
+// NewServerWithKeystore returns a Server for the given schemes, with keys
+// held in keys, so that they persist across restarts if keys does. Keys of
+// keys with a scheme that is not served can't be used. If no scheme is
+// given, the server uses DefaultSchemes.
+func NewServerWithKeystore(keys keystore.Keystore, schemes ...blinding.BlindableScheme) *Server {
+	if len(schemes) == 0 {
+		schemes = DefaultSchemes()
+	}
+	s := &Server{
+		rand:    rand.Reader,
+		schemes: make(map[string]blinding.BlindableScheme, len(schemes)),
+		keys:    keys,
+	}
+	for _, scheme := range schemes {
+		s.schemes[scheme.Name()] = scheme
+	}
+	return s
+}
+
+func (s *Server) scheme(name string) (blinding.BlindableScheme, error) {
+	scheme, ok := s.schemes[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported scheme %q", name)
+	}
+	return scheme, nil
+}
+
+// checkContext returns the status of ctx.Err() if ctx is done, so that a
+// request cancelled by its client or past its deadline is not served.
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+// GenerateKey implements BlindSignerServer.
+func (s *Server) GenerateKey(ctx context.Context, req *GenerateKeyRequest) (*GenerateKeyResponse, error) {
+	scheme, err := s.scheme(req.GetScheme())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	pk, sk, err := scheme.GenerateKey(s.rand)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "key generation failed: %s", err)
+	}
+	id := make([]byte, keyIDSize)
+	if _, err := io.ReadFull(s.rand, id); err != nil {
+		return nil, status.Errorf(codes.Internal, "key generation failed: %s", err)
+	}
+	keyID := hex.EncodeToString(id)
+
+	err = s.keys.Put(ctx, &keystore.Key{
+		Metadata:   keystore.Metadata{ID: keyID, Scheme: scheme.Name(), PublicKey: pk, Created: time.Now()},
+		PrivateKey: sk,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "storing key: %s", err)
+	}
+
+	return &GenerateKeyResponse{
+		KeyId:     keyID,
+		PublicKey: &PublicKey{Scheme: scheme.Name(), Data: pk},
+	}, nil
+}
+
+// BlindPublicKey implements BlindSignerServer.
+func (s *Server) BlindPublicKey(ctx context.Context, req *BlindPublicKeyRequest) (*BlindPublicKeyResponse, error) {
+	scheme, err := s.scheme(req.GetPublicKey().GetScheme())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	pkR, err := scheme.BlindPublicKey(req.GetPublicKey().GetData(), req.GetBlind(), req.GetContext())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &BlindPublicKeyResponse{
+		PublicKey: &PublicKey{Scheme: scheme.Name(), Data: pkR},
+	}, nil
+}
+
+// BlindKeySign implements BlindSignerServer.
+func (s *Server) BlindKeySign(ctx context.Context, req *BlindKeySignRequest) (*BlindKeySignResponse, error) {
+	if keystore.CheckID(req.GetKeyId()) != nil {
+		return nil, status.Errorf(codes.NotFound, "unknown key %q", req.GetKeyId())
+	}
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	key, err := s.keys.Get(ctx, req.GetKeyId())
+	if errors.Is(err, keystore.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "unknown key %q", req.GetKeyId())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "loading key: %s", err)
+	}
+	scheme, ok := s.schemes[key.Scheme]
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "key %q is of unsupported scheme %q", key.ID, key.Scheme)
+	}
+
+	sig, err := scheme.BlindKeySign(s.rand, key.PrivateKey, req.GetBlind(), req.GetMessage(), req.GetContext())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &BlindKeySignResponse{
+		Signature: &Signature{Scheme: scheme.Name(), Data: sig},
+	}, nil
+}
+
+// Verify implements BlindSignerServer.
+func (s *Server) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error) {
+	scheme, err := s.scheme(req.GetPublicKey().GetScheme())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetSignature().GetScheme() != scheme.Name() {
+		return &VerifyResponse{Valid: false}, nil
+	}
+	valid := scheme.Verify(req.GetPublicKey().GetData(), req.GetMessage(), req.GetSignature().GetData())
+	return &VerifyResponse{Valid: valid}, nil
+}
+
+// ServerTLSConfig returns a TLS configuration for the service that presents
+// cert and requires clients to present a certificate issued by one of
+// clientCAs.
+func ServerTLSConfig(cert tls.Certificate, clientCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS13,
+	}
+}
+
+// ClientTLSConfig returns a TLS configuration for clients of the service that
+// presents cert and trusts servers with a certificate issued by one of
+// rootCAs.
+func ClientTLSConfig(cert tls.Certificate, rootCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS13,
+	}
+}
@@ -0,0 +1,334 @@
+// Package bls implements BLS signatures with key blinding on the BLS12-381
+// curve, in the minimal-signature-size variant of the basic scheme of
+// draft-irtf-cfrg-bls-signature: public keys are points of G2 and signatures
+// are points of G1.
+//
+// Blinding multiplies the secret scalar by a scalar derived from the blind
+// and context string, and the public key in G2 by the same scalar. Blinded
+// public keys are ordinary public keys, so signatures by blinded keys can be
+// aggregated and verified with Aggregate, AggregateVerify and
+// FastAggregateVerify exactly like unblinded ones.
+package bls
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/cloudflare/circl/expander"
+)
+
+const (
+	// PublicKeySize is the size, in bytes, of compressed public keys.
+	PublicKeySize = bls12381.G2SizeCompressed
+	// PrivateKeySize is the size, in bytes, of private keys.
+	PrivateKeySize = bls12381.ScalarSize
+	// SignatureSize is the size, in bytes, of compressed signatures.
+	SignatureSize = bls12381.G1SizeCompressed
+	// BlindSize is the size, in bytes, of blinds.
+	BlindSize = 32
+)
+
+var (
+	// signatureDST is the ciphersuite of the basic scheme with signatures
+	// in G1.
+	signatureDST = []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_")
+
+	blindDST = []byte("BLS12381 Key Blind")
+)
+
+var (
+	errInvalidPublicKey  = errors.New("bls: invalid public key")
+	errInvalidPrivateKey = errors.New("bls: invalid private key")
+	errInvalidSignature  = errors.New("bls: invalid signature")
+	errZeroBlind         = errors.New("bls: blind derived to zero")
+)
+
+// PublicKey is the type of BLS public keys, a compressed point of G2.
+type PublicKey []byte
+
+// Equal reports whether pub and x have the same value.
+func (pub PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(PublicKey)
+	if !ok {
+		return false
+	}
+	return bytes.Equal(pub, xx)
+}
+
+// PrivateKey is the type of BLS private keys, a big-endian scalar.
+type PrivateKey []byte
+
+// Public returns the PublicKey corresponding to priv. It returns nil if priv
+// is not a valid private key.
+func (priv PrivateKey) Public() crypto.PublicKey {
+	sk, err := decodePrivateKey(priv)
+	if err != nil {
+		return nil
+	}
+	return publicKey(sk)
+}
+
+// Equal reports whether priv and x have the same value, in constant time.
+func (priv PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(PrivateKey)
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare(priv, xx) == 1
+}
+
+// GenerateKey generates a public/private key pair using entropy from rand.
+// If rand is nil, crypto/rand.Reader will be used.
+func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	sk := new(bls12381.Scalar)
+	for sk.IsZero() == 1 {
+		if err := sk.Random(rand); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	privateKey, _ := sk.MarshalBinary()
+	return publicKey(sk), privateKey, nil
+}
+
+func publicKey(sk *bls12381.Scalar) PublicKey {
+	pk := new(bls12381.G2)
+	pk.ScalarMult(sk, bls12381.G2Generator())
+	return pk.BytesCompressed()
+}
+
+func decodePrivateKey(privateKey PrivateKey) (*bls12381.Scalar, error) {
+	if len(privateKey) != PrivateKeySize {
+		return nil, errInvalidPrivateKey
+	}
+	sk := new(bls12381.Scalar)
+	if err := sk.UnmarshalBinary(privateKey); err != nil || sk.IsZero() == 1 {
+		return nil, errInvalidPrivateKey
+	}
+	return sk, nil
+}
+
+// decodePublicKey decodes a public key, checking that it is in G2 and is not
+// the identity.
+func decodePublicKey(publicKey PublicKey) (*bls12381.G2, error) {
+	if len(publicKey) != PublicKeySize {
+		return nil, errInvalidPublicKey
+	}
+	pk := new(bls12381.G2)
+	if err := pk.SetBytes(publicKey); err != nil || pk.IsIdentity() {
+		return nil, errInvalidPublicKey
+	}
+	return pk, nil
+}
+
+// decodeSignature decodes a signature, checking that it is in G1.
+func decodeSignature(sig []byte) (*bls12381.G1, error) {
+	if len(sig) != SignatureSize {
+		return nil, errInvalidSignature
+	}
+	s := new(bls12381.G1)
+	if err := s.SetBytes(sig); err != nil {
+		return nil, errInvalidSignature
+	}
+	return s, nil
+}
+
+// hashBlind derives the blinding scalar from a blind and context string.
+func hashBlind(blind, context []byte) (*bls12381.Scalar, error) {
+	blindContext := append(append([]byte{}, blind...), 0x00)
+	blindContext = append(blindContext, context...)
+	xmd := expander.NewExpanderMD(crypto.SHA256, blindDST)
+
+	r := new(bls12381.Scalar)
+	r.SetBytes(xmd.Expand(blindContext, 48))
+	if r.IsZero() == 1 {
+		return nil, errZeroBlind
+	}
+	return r, nil
+}
+
+// BlindPublicKeyWithContext augments the public key by the blind and context string.
+func BlindPublicKeyWithContext(publicKey PublicKey, blind, context []byte) (PublicKey, error) {
+	pk, err := decodePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	r, err := hashBlind(blind, context)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.ScalarMult(r, pk)
+	return pk.BytesCompressed(), nil
+}
+
+// BlindPublicKey augments the public key by the blind.
+func BlindPublicKey(publicKey PublicKey, blind []byte) (PublicKey, error) {
+	return BlindPublicKeyWithContext(publicKey, blind, nil)
+}
+
+// UnblindPublicKeyWithContext unblinds the public key by the blind and context string.
+func UnblindPublicKeyWithContext(publicKey PublicKey, blind, context []byte) (PublicKey, error) {
+	pk, err := decodePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	r, err := hashBlind(blind, context)
+	if err != nil {
+		return nil, err
+	}
+	r.Inv(r)
+
+	pk.ScalarMult(r, pk)
+	return pk.BytesCompressed(), nil
+}
+
+// UnblindPublicKey unblinds the public key by the blind.
+func UnblindPublicKey(publicKey PublicKey, blind []byte) (PublicKey, error) {
+	return UnblindPublicKeyWithContext(publicKey, blind, nil)
+}
+
+// Sign signs the message with privateKey and returns a signature.
+func Sign(privateKey PrivateKey, message []byte) ([]byte, error) {
+	sk, err := decodePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return sign(sk, message), nil
+}
+
+func sign(sk *bls12381.Scalar, message []byte) []byte {
+	sig := new(bls12381.G1)
+	sig.Hash(message, signatureDST)
+	sig.ScalarMult(sk, sig)
+	return sig.BytesCompressed()
+}
+
+// BlindKeySignWithContext signs the message with privateKey blinded by a blind
+// and context string, and returns a signature.
+func BlindKeySignWithContext(privateKey PrivateKey, message, blind, context []byte) ([]byte, error) {
+	sk, err := decodePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	r, err := hashBlind(blind, context)
+	if err != nil {
+		return nil, err
+	}
+
+	sk.Mul(sk, r)
+	return sign(sk, message), nil
+}
+
+// BlindKeySign signs the message with privateKey blinded by a blind, and
+// returns a signature.
+func BlindKeySign(privateKey PrivateKey, message, blind []byte) ([]byte, error) {
+	return BlindKeySignWithContext(privateKey, message, blind, nil)
+}
+
+// Verify reports whether sig is a valid signature of message by publicKey.
+func Verify(publicKey PublicKey, message, sig []byte) bool {
+	return AggregateVerify([]PublicKey{publicKey}, [][]byte{message}, sig)
+}
+
+// Aggregate combines signatures into a single aggregate signature. Each
+// signature must be a valid encoding of a point of G1.
+func Aggregate(sigs [][]byte) ([]byte, error) {
+	if len(sigs) == 0 {
+		return nil, errors.New("bls: no signatures to aggregate")
+	}
+	agg := new(bls12381.G1)
+	agg.SetIdentity()
+	for _, sig := range sigs {
+		s, err := decodeSignature(sig)
+		if err != nil {
+			return nil, err
+		}
+		agg.Add(agg, s)
+	}
+	return agg.BytesCompressed(), nil
+}
+
+// AggregateVerify reports whether sig is a valid aggregate of signatures of
+// messages[i] by publicKeys[i]. As required by the basic scheme, it returns
+// false if the messages are not all distinct.
+func AggregateVerify(publicKeys []PublicKey, messages [][]byte, sig []byte) bool {
+	if len(publicKeys) == 0 || len(publicKeys) != len(messages) {
+		return false
+	}
+	seen := make(map[string]bool, len(messages))
+	for _, m := range messages {
+		if seen[string(m)] {
+			return false
+		}
+		seen[string(m)] = true
+	}
+
+	s, err := decodeSignature(sig)
+	if err != nil {
+		return false
+	}
+
+	// e(sig, G2) * prod e(H(m_i), pk_i)^-1 == 1
+	g1s := []*bls12381.G1{s}
+	g2s := []*bls12381.G2{bls12381.G2Generator()}
+	signs := []int{1}
+	for i := range publicKeys {
+		pk, err := decodePublicKey(publicKeys[i])
+		if err != nil {
+			return false
+		}
+		h := new(bls12381.G1)
+		h.Hash(messages[i], signatureDST)
+		g1s = append(g1s, h)
+		g2s = append(g2s, pk)
+		signs = append(signs, -1)
+	}
+	return bls12381.ProdPairFrac(g1s, g2s, signs).IsIdentity()
+}
+
+// FastAggregateVerify reports whether sig is a valid aggregate of signatures
+// of the same message by each of publicKeys.
+//
+// It is only safe if each public key comes with a proof that its holder
+// knows the corresponding private key, as otherwise an attacker can choose a
+// rogue key that cancels the others. A proof for an unblinded key does not
+// cover the keys blinded from it.
+func FastAggregateVerify(publicKeys []PublicKey, message, sig []byte) bool {
+	if len(publicKeys) == 0 {
+		return false
+	}
+	aggPk := new(bls12381.G2)
+	aggPk.SetIdentity()
+	for _, publicKey := range publicKeys {
+		pk, err := decodePublicKey(publicKey)
+		if err != nil {
+			return false
+		}
+		aggPk.Add(aggPk, pk)
+	}
+	if aggPk.IsIdentity() {
+		return false
+	}
+
+	s, err := decodeSignature(sig)
+	if err != nil {
+		return false
+	}
+	h := new(bls12381.G1)
+	h.Hash(message, signatureDST)
+	return bls12381.ProdPairFrac(
+		[]*bls12381.G1{s, h},
+		[]*bls12381.G2{bls12381.G2Generator(), aggPk},
+		[]int{1, -1},
+	).IsIdentity()
+}
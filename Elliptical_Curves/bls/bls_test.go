@@ -0,0 +1,148 @@
+package bls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	public, private, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if !private.Public().(PublicKey).Equal(public) {
+		t.Errorf("private.Public() is not Equal to public")
+	}
+
+	message := []byte("test message")
+	sig, err := Sign(private, message)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !Verify(public, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+
+	wrongMessage := []byte("wrong message")
+	if Verify(public, wrongMessage, sig) {
+		t.Errorf("signature of different message accepted")
+	}
+}
+
+func TestMaskSignVerify(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+
+	blind := make([]byte, BlindSize)
+	rand.Reader.Read(blind)
+	context := []byte("epoch 1")
+
+	blindedKey, err := BlindPublicKeyWithContext(public, blind, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+
+	message := []byte("test message")
+	sig, err := BlindKeySignWithContext(private, message, blind, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignWithContext error: %s", err)
+	}
+	if !Verify(blindedKey, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+	if Verify(public, message, sig) {
+		t.Errorf("blinded signature accepted under the unblinded key")
+	}
+
+	unblindedKey, err := UnblindPublicKeyWithContext(blindedKey, blind, context)
+	if err != nil {
+		t.Fatalf("UnblindPublicKeyWithContext error: %s", err)
+	}
+	if !bytes.Equal(public, unblindedKey) {
+		t.Fatal("Blind-unblind mismatch")
+	}
+}
+
+func TestAggregateBlindedKeys(t *testing.T) {
+	const n = 3
+	blind := make([]byte, BlindSize)
+	rand.Reader.Read(blind)
+
+	pks := make([]PublicKey, n)
+	sigs := make([][]byte, n)
+	distinctSigs := make([][]byte, n)
+	messages := make([][]byte, n)
+	message := []byte("same message")
+	for i := 0; i < n; i++ {
+		public, private, _ := GenerateKey(rand.Reader)
+		var err error
+		if pks[i], err = BlindPublicKey(public, blind); err != nil {
+			t.Fatalf("BlindPublicKey error: %s", err)
+		}
+		if sigs[i], err = BlindKeySign(private, message, blind); err != nil {
+			t.Fatalf("BlindKeySign error: %s", err)
+		}
+		messages[i] = []byte{byte(i)}
+		if distinctSigs[i], err = BlindKeySign(private, messages[i], blind); err != nil {
+			t.Fatalf("BlindKeySign error: %s", err)
+		}
+	}
+
+	agg, err := Aggregate(sigs)
+	if err != nil {
+		t.Fatalf("Aggregate error: %s", err)
+	}
+	if !FastAggregateVerify(pks, message, agg) {
+		t.Errorf("valid aggregate signature rejected")
+	}
+	if FastAggregateVerify(pks[1:], message, agg) {
+		t.Errorf("aggregate signature accepted with a missing key")
+	}
+
+	agg, err = Aggregate(distinctSigs)
+	if err != nil {
+		t.Fatalf("Aggregate error: %s", err)
+	}
+	if !AggregateVerify(pks, messages, agg) {
+		t.Errorf("valid aggregate signature rejected")
+	}
+	messages[1] = messages[0]
+	if AggregateVerify(pks, messages, agg) {
+		t.Errorf("aggregate signature accepted with duplicate messages")
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+	message := []byte("test message")
+
+	if _, err := Sign(make(PrivateKey, PrivateKeySize), message); err == nil {
+		t.Errorf("zero private key accepted")
+	}
+	if _, err := BlindPublicKey(public[1:], make([]byte, BlindSize)); err == nil {
+		t.Errorf("short public key blinded")
+	}
+	invalid := bytes.Repeat([]byte{0xff}, PublicKeySize)
+	if _, err := BlindPublicKey(invalid, make([]byte, BlindSize)); err == nil {
+		t.Errorf("invalid public key blinded")
+	}
+
+	sig, _ := Sign(private, message)
+	if Verify(public, message, sig[1:]) {
+		t.Errorf("short signature accepted")
+	}
+	if _, err := Aggregate(nil); err == nil {
+		t.Errorf("empty aggregate accepted")
+	}
+}
+
+func BenchmarkBlindKeySign(b *testing.B) {
+	_, priv, _ := GenerateKey(rand.Reader)
+	blind := make([]byte, BlindSize)
+	rand.Reader.Read(blind)
+	message := []byte("Hello, world!")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = BlindKeySign(priv, message, blind)
+	}
+}
@@ -0,0 +1,290 @@
+package brainpool
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func testCurve(t *testing.T, curve elliptic.Curve) {
+	params := curve.Params()
+	if !curve.IsOnCurve(params.Gx, params.Gy) {
+		t.Fatal("base point is not on the curve")
+	}
+	x, y := curve.ScalarBaseMult(params.N.Bytes())
+	if x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("base point does not have order N")
+	}
+
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("test")
+	r, s, err := ecdsa.Sign(rand.Reader, priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.Verify(&priv.PublicKey, msg, r, s) {
+		t.Fatal("signature didn't verify")
+	}
+}
+
+func TestP256t1(t *testing.T) { testCurve(t, P256t1()) }
+func TestP256r1(t *testing.T) { testCurve(t, P256r1()) }
+func TestP384t1(t *testing.T) { testCurve(t, P384t1()) }
+func TestP384r1(t *testing.T) { testCurve(t, P384r1()) }
+func TestP512t1(t *testing.T) { testCurve(t, P512t1()) }
+func TestP512r1(t *testing.T) { testCurve(t, P512r1()) }
+
+func TestUnmarshalCompressed(t *testing.T) {
+	for _, curve := range []elliptic.Curve{P256r1(), P384r1(), P512r1()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			u := curve.(*rcurve)
+			for i := 0; i < 16; i++ {
+				priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				x, y := u.UnmarshalCompressed(elliptic.MarshalCompressed(curve, priv.X, priv.Y))
+				if x == nil || x.Cmp(priv.X) != 0 || y.Cmp(priv.Y) != 0 {
+					t.Fatalf("point does not round-trip through compressed encoding")
+				}
+			}
+
+			enc := elliptic.MarshalCompressed(curve, curve.Params().Gx, curve.Params().Gy)
+			enc[0] = 4
+			if x, _ := u.UnmarshalCompressed(enc); x != nil {
+				t.Errorf("UnmarshalCompressed accepted an invalid prefix")
+			}
+		})
+	}
+}
+
+// rfc5639 holds the domain parameters of the r1 curves, from RFC 5639,
+// Sections 3.4 to 3.7. A and B are only used by the tests, to check points
+// against the r1 curve equation directly rather than through the t1 curve.
+var rfc5639 = []struct {
+	curve            func() elliptic.Curve
+	p, a, b, x, y, n string
+}{
+	{
+		P256r1,
+		"A9FB57DBA1EEA9BC3E660A909D838D726E3BF623D52620282013481D1F6E5377",
+		"7D5A0975FC2C3057EEF67530417AFFE7FB8055C126DC5C6CE94A4B44F330B5D9",
+		"26DC5C6CE94A4B44F330B5D9BBD77CBF958416295CF7E1CE6BCCDC18FF8C07B6",
+		"8BD2AEB9CB7E57CB2C4B482FFC81B7AFB9DE27E1E3BD23C23A4453BD9ACE3262",
+		"547EF835C3DAC4FD97F8461A14611DC9C27745132DED8E545C1D54C72F046997",
+		"A9FB57DBA1EEA9BC3E660A909D838D718C397AA3B561A6F7901E0E82974856A7",
+	},
+	{
+		P384r1,
+		"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B412B1DA197FB71123ACD3A729901D1A71874700133107EC53",
+		"7BC382C63D8C150C3C72080ACE05AFA0C2BEA28E4FB22787139165EFBA91F90F8AA5814A503AD4EB04A8C7DD22CE2826",
+		"04A8C7DD22CE28268B39B55416F0447C2FB77DE107DCD2A62E880EA53EEB62D57CB4390295DBC9943AB78696FA504C11",
+		"1D1C64F068CF45FFA2A63A81B7C13F6B8847A3E77EF14FE3DB7FCAFE0CBD10E8E826E03436D646AAEF87B2E247D4AF1E",
+		"8ABE1D7520F9C2A45CB1EB8E95CFD55262B70B29FEEC5864E19C054FF99129280E4646217791811142820341263C5315",
+		"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B31F166E6CAC0425A7CF3AB6AF6B7FC3103B883202E9046565",
+	},
+	{
+		P512r1,
+		"AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA703308717D4D9B009BC66842AECDA12AE6A380E62881FF2F2D82C68528AA6056583A48F3",
+		"7830A3318B603B89E2327145AC234CC594CBDD8D3DF91610A83441CAEA9863BC2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A72BF2C7B9E7C1AC4D77FC94CA",
+		"3DF91610A83441CAEA9863BC2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A72BF2C7B9E7C1AC4D77FC94CADC083E67984050B75EBAE5DD2809BD638016F723",
+		"81AEE4BDD82ED9645A21322E9C4C6A9385ED9F70B5D916C1B43B62EEF4D0098EFF3B1F78E2D0D48D50D1687B93B97D5F7C6D5047406A5E688B352209BCB9F822",
+		"7DDE385D566332ECC0EABFA9CF7822FDF209F70024A57B1AA000C55B881F8111B2DCDE494A5F485E5BCA4BD88A2763AED1CA2B2FA8F0540678CD1E0F3AD80892",
+		"AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA70330870553E5C414CA92619418661197FAC10471DB1D381085DDADDB58796829CA90069",
+	},
+}
+
+// onR1Curve reports whether (x, y) satisfies y^2 = x^3 + a*x + b mod p.
+func onR1Curve(x, y, p, a, b *big.Int) bool {
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, p)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Add(rhs, a)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, b)
+	rhs.Mod(rhs, p)
+	return lhs.Cmp(rhs) == 0
+}
+
+func TestRFC5639(t *testing.T) {
+	for _, v := range rfc5639 {
+		params := v.curve().Params()
+		for name, got := range map[string]*big.Int{"P": params.P, "Gx": params.Gx, "Gy": params.Gy, "N": params.N} {
+			want := map[string]string{"P": v.p, "Gx": v.x, "Gy": v.y, "N": v.n}[name]
+			if got.Cmp(mustHex(want)) != 0 {
+				t.Errorf("%s: %s = %X, want %s", params.Name, name, got, want)
+			}
+		}
+		p, a, b := mustHex(v.p), mustHex(v.a), mustHex(v.b)
+		if !onR1Curve(params.Gx, params.Gy, p, a, b) {
+			t.Errorf("%s: base point is not on the r1 curve", params.Name)
+		}
+		// Multiples of the base point computed through the t1 curve must be
+		// on the r1 curve.
+		x, y := v.curve().ScalarBaseMult([]byte{0x01, 0x23, 0x45, 0x67, 0x89})
+		if !onR1Curve(x, y, p, a, b) {
+			t.Errorf("%s: multiple of the base point is not on the r1 curve", params.Name)
+		}
+	}
+}
+
+// rfc7027 holds the ECDH test vectors of RFC 7027, Appendix A: the private
+// keys dA and dB, the x-coordinates of their public keys, and the
+// x-coordinate of the shared point Z.
+var rfc7027 = []struct {
+	curve                   func() elliptic.Curve
+	dA, xA, dB, xB, sharedX string
+}{
+	{
+		P256r1,
+		"81DB1EE100150FF2EA338D708271BE38300CB54241D79950F77B063039804F1D",
+		"44106E913F92BC02A1705D9953A8414DB95E1AAA49E81D9E85F929A8E3100BE5",
+		"55E40BC41E37E3E2AD25C3C6654511FFA8474A91A0032087593852D3E7D76BD3",
+		"8D2D688C6CF93E1160AD04CC4429117DC2C41825E1E9FCA0ADDD34E6F1B39F7B",
+		"89AFC39D41D3B327814B80940B042590F96556EC91E6AE7939BCE31F3A18BF2B",
+	},
+	{
+		P384r1,
+		"1E20F5E048A5886F1F157C74E91BDE2B98C8B52D58E5003D57053FC4B0BD65D6F15EB5D1EE1610DF870795143627D042",
+		"68B665DD91C195800650CDD363C625F4E742E8134667B767B1B476793588F885AB698C852D4A6E77A252D6380FCAF068",
+		"032640BC6003C59260F7250C3DB58CE647F98E1260ACCE4ACDA3DD869F74E01F8BA5E0324309DB6A9831497ABAC96670",
+		"4D44326F269A597A5B58BBA565DA5556ED7FD9A8A9EB76C25F46DB69D19DC8CE6AD18E404B15738B2086DF37E71D1EB4",
+		"0BD9D3A7EA0B3D519D09D8E48D0785FB744A6B355E6304BC51C229FBBCE239BBADF6403715C35D4FB2A5444F575D4F42",
+	},
+	{
+		P512r1,
+		"16302FF0DBBB5A8D733DAB7141C1B45ACBC8715939677F6A56850A38BD87BD59B09E80279609FF333EB9D4C061231FB26F92EEB04982A5F1D1764CAD57665422",
+		"0A420517E406AAC0ACDCE90FCD71487718D3B953EFD7FBEC5F7F27E28C6149999397E91E029E06457DB2D3E640668B392C2A7E737A7F0BF04436D11640FD09FD",
+		"230E18E1BCC88A362FA54E4EA3902009292F7F8033624FD471B5D8ACE49D12CFABBC19963DAB8E2F1EBA00BFFB29E4D72D13F2224562F405CB80503666B25429",
+		"9D45F66DE5D67E2E6DB6E93A59CE0BB48106097FF78A081DE781CDB31FCE8CCBAAEA8DD4320C4119F1E9CD437A2EAB3731FA9668AB268D871DEDA55A5473199F",
+		"A7927098655F1F9976FA50A9D566865DC530331846381C87256BAF3226244B76D36403C024D7BBF0AA0803EAFF405D3D24F11A9B5C0BEF679FE1454B21C4CD1F",
+	},
+}
+
+func TestRFC7027(t *testing.T) {
+	for _, v := range rfc7027 {
+		curve := v.curve()
+		name := curve.Params().Name
+		dA, dB := mustHex(v.dA).Bytes(), mustHex(v.dB).Bytes()
+		xA, yA := curve.ScalarBaseMult(dA)
+		xB, yB := curve.ScalarBaseMult(dB)
+		if xA.Cmp(mustHex(v.xA)) != 0 || xB.Cmp(mustHex(v.xB)) != 0 {
+			t.Errorf("%s: public keys = %X, %X, want %s, %s", name, xA, xB, v.xA, v.xB)
+		}
+		zA, _ := curve.ScalarMult(xB, yB, dA)
+		zB, _ := curve.ScalarMult(xA, yA, dB)
+		if zA.Cmp(mustHex(v.sharedX)) != 0 || zB.Cmp(mustHex(v.sharedX)) != 0 {
+			t.Errorf("%s: shared secrets = %X, %X, want %s", name, zA, zB, v.sharedX)
+		}
+	}
+}
+
+// wycheproofECDHCurves maps the Wycheproof curve names and the named curve
+// OIDs of RFC 5639 to the curves of this package.
+var wycheproofECDHCurves = map[string]struct {
+	curve func() elliptic.Curve
+	oid   asn1.ObjectIdentifier
+}{
+	"brainpoolP256r1": {P256r1, asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 7}},
+	"brainpoolP384r1": {P384r1, asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 11}},
+	"brainpoolP512r1": {P512r1, asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 13}},
+}
+
+var oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+// parseECDHPublicKey parses a SubjectPublicKeyInfo with the named curve oid,
+// and returns the point it holds, or nil if it is not valid.
+func parseECDHPublicKey(curve elliptic.Curve, oid asn1.ObjectIdentifier, der []byte) (x, y *big.Int) {
+	input := cryptobyte.String(der)
+	var spki, alg cryptobyte.String
+	var algOID, curveOID asn1.ObjectIdentifier
+	var point asn1.BitString
+	if !input.ReadASN1(&spki, cbasn1.SEQUENCE) || !input.Empty() ||
+		!spki.ReadASN1(&alg, cbasn1.SEQUENCE) ||
+		!alg.ReadASN1ObjectIdentifier(&algOID) || !algOID.Equal(oidECPublicKey) ||
+		!alg.ReadASN1ObjectIdentifier(&curveOID) || !curveOID.Equal(oid) || !alg.Empty() ||
+		!spki.ReadASN1BitString(&point) || !spki.Empty() || point.BitLength%8 != 0 {
+		return nil, nil
+	}
+	if len(point.Bytes) > 0 && point.Bytes[0] == 4 {
+		return elliptic.Unmarshal(curve, point.Bytes)
+	}
+	return curve.(*rcurve).UnmarshalCompressed(point.Bytes)
+}
+
+// TestWycheproofECDH runs the ECDH test vectors of Project Wycheproof, which
+// include public keys that hit edge cases of the isomorphism with the t1
+// curves. Valid and acceptable vectors must give the shared secret if their
+// public key parses, and invalid ones must be rejected.
+func TestWycheproofECDH(t *testing.T) {
+	files, err := filepath.Glob("testdata/wycheproof/ecdh_*_test.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no Wycheproof vectors found: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var root struct {
+			TestGroups []struct {
+				Curve string `json:"curve"`
+				Tests []struct {
+					TcID    int    `json:"tcId"`
+					Comment string `json:"comment"`
+					Public  string `json:"public"`
+					Private string `json:"private"`
+					Shared  string `json:"shared"`
+					Result  string `json:"result"`
+				} `json:"tests"`
+			} `json:"testGroups"`
+		}
+		if err := json.Unmarshal(data, &root); err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		for _, g := range root.TestGroups {
+			c, ok := wycheproofECDHCurves[g.Curve]
+			if !ok {
+				t.Fatalf("%s: unexpected curve %s", file, g.Curve)
+			}
+			curve := c.curve()
+			size := (curve.Params().BitSize + 7) / 8
+			for _, tc := range g.Tests {
+				pub, _ := hex.DecodeString(tc.Public)
+				priv, _ := hex.DecodeString(tc.Private)
+				want, _ := hex.DecodeString(tc.Shared)
+				x, y := parseECDHPublicKey(curve, c.oid, pub)
+				if x == nil {
+					if tc.Result == "valid" {
+						t.Errorf("%s: tcId %d (%s): valid public key rejected", g.Curve, tc.TcID, tc.Comment)
+					}
+					continue
+				}
+				if tc.Result == "invalid" {
+					t.Errorf("%s: tcId %d (%s): invalid public key accepted", g.Curve, tc.TcID, tc.Comment)
+					continue
+				}
+				k := new(big.Int).SetBytes(priv)
+				k.Mod(k, curve.Params().N)
+				sx, _ := curve.ScalarMult(x, y, k.Bytes())
+				if got := sx.FillBytes(make([]byte, size)); !bytes.Equal(got, want) {
+					t.Errorf("%s: tcId %d (%s): shared secret %x, want %x", g.Curve, tc.TcID, tc.Comment, got, want)
+				}
+			}
+		}
+	}
+}
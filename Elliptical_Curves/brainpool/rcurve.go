@@ -0,0 +1,126 @@
+package brainpool
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+var _ elliptic.Curve = (*rcurve)(nil)
+
+// rcurve implements an r1 curve on top of its twisted t1 curve. A point
+// (x, y) on the r1 curve maps to (x*z^2, y*z^3) on the t1 curve, see RFC
+// 5639, Section 2.2.
+type rcurve struct {
+	twisted *elliptic.CurveParams
+	params  *elliptic.CurveParams
+	z2, z3  *big.Int
+	zinv2   *big.Int
+	zinv3   *big.Int
+}
+
+func newRCurve(twisted *elliptic.CurveParams, name string, gx, gy, z *big.Int) *rcurve {
+	P := twisted.P
+	zinv := new(big.Int).ModInverse(z, P)
+	return &rcurve{
+		twisted: twisted,
+		params: &elliptic.CurveParams{
+			Name:    name,
+			P:       P,
+			N:       twisted.N,
+			Gx:      gx,
+			Gy:      gy,
+			BitSize: twisted.BitSize,
+		},
+		z2:    new(big.Int).Exp(z, big.NewInt(2), P),
+		z3:    new(big.Int).Exp(z, big.NewInt(3), P),
+		zinv2: new(big.Int).Exp(zinv, big.NewInt(2), P),
+		zinv3: new(big.Int).Exp(zinv, big.NewInt(3), P),
+	}
+}
+
+func (curve *rcurve) toTwisted(x, y *big.Int) (*big.Int, *big.Int) {
+	tx := new(big.Int).Mul(x, curve.z2)
+	tx.Mod(tx, curve.params.P)
+	ty := new(big.Int).Mul(y, curve.z3)
+	ty.Mod(ty, curve.params.P)
+	return tx, ty
+}
+
+func (curve *rcurve) fromTwisted(tx, ty *big.Int) (*big.Int, *big.Int) {
+	x := new(big.Int).Mul(tx, curve.zinv2)
+	x.Mod(x, curve.params.P)
+	y := new(big.Int).Mul(ty, curve.zinv3)
+	y.Mod(y, curve.params.P)
+	return x, y
+}
+
+// Params returns the parameters of the curve. The B field is not set, as the
+// r1 curves don't have a = -3 and can't be used with the generic
+// elliptic.CurveParams methods.
+func (curve *rcurve) Params() *elliptic.CurveParams {
+	return curve.params
+}
+
+func (curve *rcurve) IsOnCurve(x, y *big.Int) bool {
+	return curve.twisted.IsOnCurve(curve.toTwisted(x, y))
+}
+
+func (curve *rcurve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	tx1, ty1 := curve.toTwisted(x1, y1)
+	tx2, ty2 := curve.toTwisted(x2, y2)
+	return curve.fromTwisted(curve.twisted.Add(tx1, ty1, tx2, ty2))
+}
+
+func (curve *rcurve) Double(x1, y1 *big.Int) (x, y *big.Int) {
+	return curve.fromTwisted(curve.twisted.Double(curve.toTwisted(x1, y1)))
+}
+
+func (curve *rcurve) ScalarMult(x1, y1 *big.Int, scalar []byte) (x, y *big.Int) {
+	tx1, ty1 := curve.toTwisted(x1, y1)
+	return curve.fromTwisted(curve.twisted.ScalarMult(tx1, ty1, scalar))
+}
+
+func (curve *rcurve) ScalarBaseMult(scalar []byte) (x, y *big.Int) {
+	return curve.fromTwisted(curve.twisted.ScalarBaseMult(scalar))
+}
+
+// UnmarshalCompressed converts a point, serialized by
+// elliptic.MarshalCompressed, into an x, y pair. It is an error if the point
+// is not in compressed form, is not on the curve, or is the point at infinity.
+// On error, x = nil.
+//
+// The generic elliptic.UnmarshalCompressed can't be used with the r1 curves,
+// as it assumes a = -3. Instead, the point is decompressed on the twisted
+// curve, and the sign of y is fixed up after mapping it back, since the
+// isomorphism does not preserve it.
+func (curve *rcurve) UnmarshalCompressed(data []byte) (x, y *big.Int) {
+	byteLen := (curve.params.BitSize + 7) / 8
+	if len(data) != 1+byteLen {
+		return nil, nil
+	}
+	if data[0] != 2 && data[0] != 3 {
+		return nil, nil
+	}
+	P := curve.params.P
+	x = new(big.Int).SetBytes(data[1:])
+	if x.Cmp(P) >= 0 {
+		return nil, nil
+	}
+
+	tx, _ := curve.toTwisted(x, new(big.Int))
+	enc := make([]byte, 1+byteLen)
+	enc[0] = 2
+	tx.FillBytes(enc[1:])
+	_, ty := elliptic.UnmarshalCompressed(curve.twisted, enc)
+	if ty == nil {
+		return nil, nil
+	}
+	x, y = curve.fromTwisted(tx, ty)
+	if byte(y.Bit(0)) != data[0]&1 {
+		y.Sub(P, y)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil
+	}
+	return x, y
+}
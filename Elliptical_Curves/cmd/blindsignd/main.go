@@ -0,0 +1,124 @@
+// Command blindsignd serves the BlindSigner gRPC service of the blindsign
+// package over mutually authenticated TLS.
+//
+// Usage:
+//
+//	blindsignd -cert server.pem -key server-key.pem -client-ca clients.pem [-addr :8443] [-selftest] [-keystore dir]
+//
+// With -selftest, the ECDSA implementation is checked against the NIST CAVP
+// SigVer vectors before the service starts.
+//
+// Keys generated by the service are held in memory, and are lost when it
+// exits, unless -keystore names a directory to store them in. Keys in the
+// directory are encrypted with the key given in hex in the
+// BLINDSIGND_KEYSTORE_KEY environment variable.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/cloudflare/pat-go/blindsign"
+	"github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/ecdsa/testvectors"
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+// keystoreKeyEnv is the environment variable holding the hex-encoded key
+// that encrypts the keystore directory.
+const keystoreKeyEnv = "BLINDSIGND_KEYSTORE_KEY"
+
+// selfTest verifies the signatures of the embedded CAVP SigVer vectors, and
+// returns the number of vectors checked.
+func selfTest() (int, error) {
+	vectors := testvectors.SigVer()
+	n := 0
+	for {
+		v, err := vectors.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if v.Curve == nil || v.Hash == 0 {
+			continue
+		}
+		pub := &ecdsa.PublicKey{Curve: v.Curve, X: v.Qx, Y: v.Qy}
+		if ecdsa.Verify(pub, v.Digest(), v.R, v.S) != v.Valid {
+			return n, fmt.Errorf("incorrect result for the vector on line %d", v.Line)
+		}
+		n++
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":8443", "address to listen on")
+	certFile := flag.String("cert", "", "PEM file with the server certificate chain")
+	keyFile := flag.String("key", "", "PEM file with the server private key")
+	clientCAFile := flag.String("client-ca", "", "PEM file with the CA certificates of allowed clients")
+	runSelfTest := flag.Bool("selftest", false, "check ECDSA against the NIST CAVP SigVer vectors before serving")
+	keystoreDir := flag.String("keystore", "", "directory to store keys in, encrypted with the key in $"+keystoreKeyEnv)
+	flag.Parse()
+
+	if *certFile == "" || *keyFile == "" || *clientCAFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *runSelfTest {
+		n, err := selfTest()
+		if err != nil {
+			log.Fatalf("self-test: %s", err)
+		}
+		log.Printf("self-test passed %d CAVP vectors", n)
+	}
+
+	cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("loading server certificate: %s", err)
+	}
+	caPEM, err := os.ReadFile(*clientCAFile)
+	if err != nil {
+		log.Fatalf("reading client CAs: %s", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		log.Fatalf("no certificate found in %s", *clientCAFile)
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("listening on %s: %s", *addr, err)
+	}
+	server := blindsign.NewServer()
+	if *keystoreDir != "" {
+		key, err := hex.DecodeString(os.Getenv(keystoreKeyEnv))
+		if err != nil {
+			log.Fatalf("decoding %s: %s", keystoreKeyEnv, err)
+		}
+		keys, err := keystore.NewDir(*keystoreDir, key)
+		if err != nil {
+			log.Fatalf("opening keystore: %s", err)
+		}
+		server = blindsign.NewServerWithKeystore(keys)
+	}
+
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(blindsign.ServerTLSConfig(cert, clientCAs))))
+	blindsign.RegisterBlindSignerServer(s, server)
+
+	log.Printf("serving on %s", lis.Addr())
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("serving: %s", err)
+	}
+}
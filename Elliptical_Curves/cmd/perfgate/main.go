@@ -0,0 +1,121 @@
+// Command perfgate runs the performance suite of the perf package and
+// compares it with a baseline.
+//
+// Usage:
+//
+//	perfgate [-run regexp] [-benchtime 1s] [-out report.json] [-baseline baseline.json] [-tolerance 0.15]
+//	perfgate -current report.json -baseline baseline.json [-tolerance 0.15]
+//
+// The first form runs the suite, writes its report to -out, and checks the
+// allocation budgets of the cases. With -baseline, the report is also
+// compared with the baseline, and cases that are more than -tolerance slower
+// or that allocate more than -tolerance more are regressions. Allocations are
+// only checked when the reports were made with the same Go release, and
+// without the race detector. The second form only compares a
+// report written earlier. perfgate exits with status 1 on a regression.
+//
+// Baselines must be recorded on the machine that runs the gate, for example
+// with perfgate -out perf/testdata/baseline.json.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/cloudflare/pat-go/perf"
+)
+
+func readReport(name string) (*perf.Report, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return perf.ReadReport(f)
+}
+
+func writeReport(name string, r *perf.Report) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func main() {
+	run := flag.String("run", "", "run only the cases whose name matches this regular expression")
+	benchtime := flag.Duration("benchtime", time.Second, "minimum time each case runs for")
+	out := flag.String("out", "", "file to write the report to")
+	baselineFile := flag.String("baseline", "", "report to compare with")
+	currentFile := flag.String("current", "", "report to compare instead of running the suite")
+	tolerance := flag.Float64("tolerance", 0.15, "relative slowdown and allocation increase allowed against the baseline")
+	flag.Parse()
+
+	if flag.NArg() > 0 || (*currentFile != "" && *baselineFile == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var current *perf.Report
+	var err error
+	if *currentFile != "" {
+		if current, err = readReport(*currentFile); err != nil {
+			log.Fatalf("reading report: %s", err)
+		}
+	} else {
+		opts := &perf.Options{Benchtime: *benchtime}
+		if *run != "" {
+			re, err := regexp.Compile(*run)
+			if err != nil {
+				log.Fatalf("invalid -run: %s", err)
+			}
+			opts.Match = re.MatchString
+		}
+		if current, err = perf.Run(perf.Suite(), opts); err != nil {
+			log.Fatal(err)
+		}
+		for _, r := range current.Results {
+			fmt.Printf("%-60s %10d ns/op %8d allocs/op %10d B/op\n", r.Name, r.NsPerOp, r.AllocsPerOp, r.BytesPerOp)
+		}
+		if *out != "" {
+			if err := writeReport(*out, current); err != nil {
+				log.Fatalf("writing report: %s", err)
+			}
+		}
+	}
+
+	regressed := false
+	if err := current.Check(); err != nil {
+		if !errors.Is(err, perf.ErrRegression) {
+			log.Fatal(err)
+		}
+		fmt.Println(err)
+		regressed = true
+	}
+	if *baselineFile != "" {
+		baseline, err := readReport(*baselineFile)
+		if err != nil {
+			log.Fatalf("reading baseline: %s", err)
+		}
+		deltas, err := perf.Compare(baseline, current, *tolerance)
+		for _, d := range deltas {
+			fmt.Println(d)
+		}
+		if err != nil {
+			fmt.Println(err)
+			regressed = true
+		}
+	}
+	if regressed {
+		os.Exit(1)
+	}
+}
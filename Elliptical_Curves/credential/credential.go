@@ -0,0 +1,426 @@
+// Package credential implements minimal anonymous credentials with selective
+// disclosure on top of the key blinding schemes of the blinding package.
+//
+// An issuer signs a set of attributes for a holder, bound to a blinded public
+// key of the holder. Each attribute is hidden behind a salted hash, as in
+// SD-JWT, so the holder can later present any subset of the attributes to a
+// verifier: the presentation reveals the disclosed attributes and their
+// salts, the hashes of the others, the issuer signature, and a signature by
+// the blinded holder key over a nonce chosen by the verifier, which proves
+// that the presenter holds the credential and prevents replays.
+//
+// Unlike BBS signatures, these credentials are single-show: presenting a
+// credential twice reveals the same blinded key and issuer signature, which
+// links the presentations, and the issuer can link a presentation to the
+// issuance it came from. Holders that need unlinkable presentations obtain a
+// batch of credentials, each bound to a fresh blind of their key, and use
+// each credential once. Since blinded keys are unlinkable to the holder's
+// long-term key, verifiers learn nothing about the holder beyond the
+// disclosed attributes and their number of hidden attributes.
+package credential
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	version         = 1
+	credentialDST   = "Credential v1"
+	presentationDST = "Credential Presentation v1"
+	digestDST       = "Credential Attribute v1"
+
+	// SaltSize is the size, in bytes, of the salts that hide attributes.
+	SaltSize = 16
+)
+
+// Errors returned by this package.
+var (
+	// ErrInvalidCredential is returned when the attributes of a credential
+	// are invalid, or its issuer signature doesn't verify.
+	ErrInvalidCredential = errors.New("credential: invalid credential")
+
+	// ErrInvalidPresentation is returned when a presentation is badly
+	// encoded or doesn't verify.
+	ErrInvalidPresentation = errors.New("credential: invalid presentation")
+
+	// ErrUnknownAttribute is returned when a holder is asked to disclose an
+	// attribute its credential doesn't have.
+	ErrUnknownAttribute = errors.New("credential: unknown attribute")
+)
+
+// Attribute is a named attribute of a credential holder.
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+// Credential is a set of attributes signed by an issuer for the holder of a
+// blinded public key.
+type Credential struct {
+	// Issuer and Holder are the names of the schemes of the issuer key and
+	// of HolderKey.
+	Issuer string
+	Holder string
+	// HolderKey is the blinded public key of the holder.
+	HolderKey []byte
+	// Attributes are the attributes of the holder, and Salts the salts
+	// that hide each of them.
+	Attributes []Attribute
+	Salts      [][]byte
+	// Signature is the signature of the issuer.
+	Signature []byte
+}
+
+// digest hashes an attribute with its salt:
+//
+//	SHA-256(len(DST) || DST || salt || len(name) || name || len(value) || value)
+//
+// where the length of the DST is one byte and the others two bytes.
+func digest(salt []byte, a Attribute) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(digestDST))
+	})
+	b.AddBytes(salt)
+	for _, v := range []string{a.Name, a.Value} {
+		v := v
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(v))
+		})
+	}
+	in, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: attribute %q too long", ErrInvalidCredential, a.Name)
+	}
+	d := sha256.Sum256(in)
+	return d[:], nil
+}
+
+// digests returns the sorted digests of the attributes of c. Sorting hides
+// the position of the disclosed attributes.
+func (c *Credential) digests() ([][]byte, error) {
+	if len(c.Attributes) != len(c.Salts) {
+		return nil, fmt.Errorf("%w: %d attributes and %d salts", ErrInvalidCredential, len(c.Attributes), len(c.Salts))
+	}
+	names := make(map[string]bool, len(c.Attributes))
+	ds := make([][]byte, len(c.Attributes))
+	for i, a := range c.Attributes {
+		if a.Name == "" || names[a.Name] {
+			return nil, fmt.Errorf("%w: empty or duplicate attribute name %q", ErrInvalidCredential, a.Name)
+		}
+		names[a.Name] = true
+		if len(c.Salts[i]) != SaltSize {
+			return nil, fmt.Errorf("%w: salt of %d bytes", ErrInvalidCredential, len(c.Salts[i]))
+		}
+		d, err := digest(c.Salts[i], a)
+		if err != nil {
+			return nil, err
+		}
+		ds[i] = d
+	}
+	sortDigests(ds)
+	return ds, nil
+}
+
+func sortDigests(ds [][]byte) {
+	sort.Slice(ds, func(i, j int) bool { return bytes.Compare(ds[i], ds[j]) < 0 })
+}
+
+// addHeader adds the fields shared by the signed message of the issuer and
+// presentations.
+func addHeader(b *cryptobyte.Builder, issuer, holder string, holderKey []byte, digests [][]byte) {
+	for _, v := range []string{issuer, holder} {
+		v := v
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(v))
+		})
+	}
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(holderKey)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, d := range digests {
+			b.AddBytes(d)
+		}
+	})
+}
+
+// issuerMessage returns the message signed by the issuer.
+func issuerMessage(issuer, holder string, holderKey []byte, digests [][]byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(credentialDST))
+	})
+	addHeader(&b, issuer, holder, holderKey, digests)
+	msg, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: too many attributes, or scheme name or key too long", ErrInvalidCredential)
+	}
+	return msg, nil
+}
+
+// Issue signs attributes for the holder of holderKey, a blinded public key of
+// the scheme holder, with issuerKey of the scheme issuer, using entropy from
+// rand for the salts. The issuer is responsible for checking that the holder
+// is entitled to the attributes, and should issue each credential for a
+// fresh blinded key.
+func Issue(rand io.Reader, issuer blinding.BlindableScheme, issuerKey []byte, holder blinding.BlindableScheme, holderKey []byte, attributes []Attribute) (*Credential, error) {
+	c := &Credential{
+		Issuer:     issuer.Name(),
+		Holder:     holder.Name(),
+		HolderKey:  append([]byte{}, holderKey...),
+		Attributes: append([]Attribute{}, attributes...),
+		Salts:      make([][]byte, len(attributes)),
+	}
+	for i := range c.Salts {
+		c.Salts[i] = make([]byte, SaltSize)
+		if _, err := io.ReadFull(rand, c.Salts[i]); err != nil {
+			return nil, err
+		}
+	}
+	ds, err := c.digests()
+	if err != nil {
+		return nil, err
+	}
+	msg, err := issuerMessage(c.Issuer, c.Holder, c.HolderKey, ds)
+	if err != nil {
+		return nil, err
+	}
+	if c.Signature, err = issuer.Sign(rand, issuerKey, msg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Verify checks that c was issued by issuerPublicKey of the scheme issuer,
+// so that holders can check the credentials they receive. It returns an
+// error wrapping ErrInvalidCredential if it wasn't.
+func (c *Credential) Verify(issuer blinding.BlindableScheme, issuerPublicKey []byte) error {
+	if c.Issuer != issuer.Name() {
+		return fmt.Errorf("%w: issued with %s, not %s", ErrInvalidCredential, c.Issuer, issuer.Name())
+	}
+	ds, err := c.digests()
+	if err != nil {
+		return err
+	}
+	msg, err := issuerMessage(c.Issuer, c.Holder, c.HolderKey, ds)
+	if err != nil {
+		return err
+	}
+	if !issuer.Verify(issuerPublicKey, msg, c.Signature) {
+		return fmt.Errorf("%w: bad issuer signature", ErrInvalidCredential)
+	}
+	return nil
+}
+
+// presentationMessage returns the message signed by the holder: the body of
+// the presentation prefixed by a domain separation tag and the nonce.
+func presentationMessage(nonce, body []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(presentationDST))
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(nonce)
+	})
+	b.AddBytes(body)
+	return b.BytesOrPanic()
+}
+
+// Present discloses the attributes of c named in disclose to a verifier that
+// chose nonce, and signs the presentation with privateKey of the scheme
+// holder, blinded by blind and context as it was for HolderKey. The
+// presentation is encoded as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque issuer<1..2^8-1>;
+//	  opaque holder<1..2^8-1>;
+//	  opaque holder_key<1..2^16-1>;
+//	  opaque digests<0..2^16-1>;
+//	  Disclosure disclosed<0..2^16-1>;
+//	  opaque issuer_signature<1..2^16-1>;
+//	  opaque holder_signature<1..2^16-1>;
+//	} Presentation;
+//
+//	struct {
+//	  opaque salt[16];
+//	  opaque name<1..2^16-1>;
+//	  opaque value<0..2^16-1>;
+//	} Disclosure;
+//
+// where digests is the concatenation of the sorted 32-byte digests of all the
+// attributes of c, and the holder signature covers the nonce and all the
+// preceding fields. Nonces must not be longer than 2^16-1 bytes.
+func (c *Credential) Present(rand io.Reader, holder blinding.BlindableScheme, privateKey, blind, context []byte, disclose []string, nonce []byte) ([]byte, error) {
+	if c.Holder != holder.Name() {
+		return nil, fmt.Errorf("%w: issued for %s, not %s", ErrInvalidCredential, c.Holder, holder.Name())
+	}
+	ds, err := c.digests()
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) > 0xffff {
+		return nil, fmt.Errorf("%w: nonce too long", ErrInvalidPresentation)
+	}
+	pk, err := holder.PublicKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	blinded, err := holder.BlindPublicKey(pk, blind, context)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(blinded, c.HolderKey) {
+		return nil, fmt.Errorf("%w: key and blind don't match the holder key", ErrInvalidPresentation)
+	}
+	disclosed := make([]int, 0, len(disclose))
+	seen := make(map[int]bool, len(disclose))
+	for _, name := range disclose {
+		i := c.index(name)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownAttribute, name)
+		}
+		if seen[i] {
+			return nil, fmt.Errorf("%w: attribute %q disclosed twice", ErrInvalidPresentation, name)
+		}
+		seen[i] = true
+		disclosed = append(disclosed, i)
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint8(version)
+	addHeader(&b, c.Issuer, c.Holder, c.HolderKey, ds)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, i := range disclosed {
+			a := c.Attributes[i]
+			b.AddBytes(c.Salts[i])
+			for _, v := range []string{a.Name, a.Value} {
+				v := v
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					b.AddBytes([]byte(v))
+				})
+			}
+		}
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(c.Signature)
+	})
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: credential too large", ErrInvalidPresentation)
+	}
+
+	sig, err := holder.BlindKeySign(rand, privateKey, blind, presentationMessage(nonce, body), context)
+	if err != nil {
+		return nil, err
+	}
+	b = cryptobyte.Builder{}
+	b.AddBytes(body)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sig)
+	})
+	return b.Bytes()
+}
+
+func (c *Credential) index(name string) int {
+	for i, a := range c.Attributes {
+		if a.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// VerifyPresentation checks a presentation produced by Credential.Present
+// for nonce: that it was issued by issuerPublicKey of the scheme issuer, and
+// signed by the blinded key it was issued for, of the scheme holder. It
+// returns the disclosed attributes. Verifiers must generate a fresh nonce for
+// each presentation, and should check that the attributes they require were
+// disclosed.
+func VerifyPresentation(issuer blinding.BlindableScheme, issuerPublicKey []byte, holder blinding.BlindableScheme, nonce, presentation []byte) ([]Attribute, error) {
+	in := cryptobyte.String(presentation)
+	var v uint8
+	var issuerName, holderName, holderKey, digests, disclosures, issuerSig, holderSig cryptobyte.String
+	if !in.ReadUint8(&v) ||
+		!in.ReadUint8LengthPrefixed(&issuerName) ||
+		!in.ReadUint8LengthPrefixed(&holderName) ||
+		!in.ReadUint16LengthPrefixed(&holderKey) ||
+		!in.ReadUint16LengthPrefixed(&digests) ||
+		!in.ReadUint16LengthPrefixed(&disclosures) ||
+		!in.ReadUint16LengthPrefixed(&issuerSig) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidPresentation)
+	}
+	body := presentation[:len(presentation)-len(in)]
+	if !in.ReadUint16LengthPrefixed(&holderSig) || !in.Empty() {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidPresentation)
+	}
+	if v != version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidPresentation, v)
+	}
+	if string(issuerName) != issuer.Name() || string(holderName) != holder.Name() {
+		return nil, fmt.Errorf("%w: unexpected schemes %s and %s", ErrInvalidPresentation, issuerName, holderName)
+	}
+	if len(digests)%sha256.Size != 0 {
+		return nil, fmt.Errorf("%w: malformed digests", ErrInvalidPresentation)
+	}
+	ds := make([][]byte, len(digests)/sha256.Size)
+	for i := range ds {
+		ds[i] = digests[i*sha256.Size : (i+1)*sha256.Size]
+		if i > 0 && bytes.Compare(ds[i-1], ds[i]) >= 0 {
+			return nil, fmt.Errorf("%w: digests not sorted", ErrInvalidPresentation)
+		}
+	}
+
+	msg, err := issuerMessage(string(issuerName), string(holderName), holderKey, ds)
+	if err != nil {
+		return nil, err
+	}
+	if !issuer.Verify(issuerPublicKey, msg, issuerSig) {
+		return nil, fmt.Errorf("%w: bad issuer signature", ErrInvalidPresentation)
+	}
+	if len(nonce) > 0xffff || !holder.Verify(holderKey, presentationMessage(nonce, body), holderSig) {
+		return nil, fmt.Errorf("%w: bad holder signature", ErrInvalidPresentation)
+	}
+
+	var attributes []Attribute
+	names := make(map[string]bool)
+	for !disclosures.Empty() {
+		var salt []byte
+		var name, value cryptobyte.String
+		if !disclosures.ReadBytes(&salt, SaltSize) ||
+			!disclosures.ReadUint16LengthPrefixed(&name) ||
+			!disclosures.ReadUint16LengthPrefixed(&value) {
+			return nil, fmt.Errorf("%w: malformed disclosure", ErrInvalidPresentation)
+		}
+		a := Attribute{Name: string(name), Value: string(value)}
+		if names[a.Name] {
+			return nil, fmt.Errorf("%w: attribute %q disclosed twice", ErrInvalidPresentation, a.Name)
+		}
+		names[a.Name] = true
+		d, err := digest(salt, a)
+		if err != nil || !contains(ds, d) {
+			return nil, fmt.Errorf("%w: attribute %q was not issued", ErrInvalidPresentation, a.Name)
+		}
+		attributes = append(attributes, a)
+	}
+	return attributes, nil
+}
+
+func contains(ds [][]byte, d []byte) bool {
+	for _, x := range ds {
+		if subtle.ConstantTimeCompare(x, d) == 1 {
+			return true
+		}
+	}
+	return false
+}
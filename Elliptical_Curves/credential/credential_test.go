@@ -0,0 +1,201 @@
+package credential
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+var attributes = []Attribute{
+	{"given_name", "Alice"},
+	{"age_over_18", "true"},
+	{"country", "PT"},
+}
+
+func TestPresentation(t *testing.T) {
+	for _, holder := range []blinding.BlindableScheme{
+		blinding.Ristretto255,
+		blinding.Ed25519,
+		blinding.ECDSA(elliptic.P256(), crypto.SHA256),
+	} {
+		holder := holder
+		t.Run(holder.Name(), func(t *testing.T) {
+			testPresentation(t, blinding.Ristretto255, holder)
+		})
+	}
+}
+
+func testPresentation(t *testing.T, issuer, holder blinding.BlindableScheme) {
+	issuerPublicKey, issuerKey, _ := issuer.GenerateKey(rand.Reader)
+	pk, sk, _ := holder.GenerateKey(rand.Reader)
+	blind, _ := holder.GenerateBlind(rand.Reader)
+	context := []byte("credential 1")
+	pkR, err := holder.BlindPublicKey(pk, blind, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+
+	cred, err := Issue(rand.Reader, issuer, issuerKey, holder, pkR, attributes)
+	if err != nil {
+		t.Fatalf("Issue error: %s", err)
+	}
+	if err := cred.Verify(issuer, issuerPublicKey); err != nil {
+		t.Fatalf("Verify error: %s", err)
+	}
+
+	nonce := []byte("verifier nonce")
+	presentation, err := cred.Present(rand.Reader, holder, sk, blind, context, []string{"age_over_18"}, nonce)
+	if err != nil {
+		t.Fatalf("Present error: %s", err)
+	}
+	got, err := VerifyPresentation(issuer, issuerPublicKey, holder, nonce, presentation)
+	if err != nil {
+		t.Fatalf("VerifyPresentation error: %s", err)
+	}
+	if want := []Attribute{{"age_over_18", "true"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("VerifyPresentation = %v, want %v", got, want)
+	}
+	// Apart from the signatures, the presentation only holds the blinded
+	// key, the digests of all attributes, and the disclosed attribute.
+	fields := decodePresentation(t, presentation)
+	if string(fields.issuer) != issuer.Name() || string(fields.holder) != holder.Name() {
+		t.Errorf("presentation schemes = %s, %s", fields.issuer, fields.holder)
+	}
+	if !bytes.Equal(fields.holderKey, pkR) {
+		t.Errorf("presentation holds key %x, want the blinded key %x", fields.holderKey, pkR)
+	}
+	ds, _ := cred.digests()
+	if !bytes.Equal(fields.digests, bytes.Join(ds, nil)) {
+		t.Errorf("presentation digests differ from those of the credential")
+	}
+	i := cred.index("age_over_18")
+	if want := encodeDisclosure(t, cred.Salts[i], attributes[1]); !bytes.Equal(fields.disclosures, want) {
+		t.Errorf("presentation disclosures = %x, want %x", fields.disclosures, want)
+	}
+
+	if _, err := VerifyPresentation(issuer, issuerPublicKey, holder, []byte("other nonce"), presentation); !errors.Is(err, ErrInvalidPresentation) {
+		t.Errorf("VerifyPresentation with another nonce: got %v, want ErrInvalidPresentation", err)
+	}
+	otherIssuer, _, _ := issuer.GenerateKey(rand.Reader)
+	if _, err := VerifyPresentation(issuer, otherIssuer, holder, nonce, presentation); !errors.Is(err, ErrInvalidPresentation) {
+		t.Errorf("VerifyPresentation under another issuer: got %v, want ErrInvalidPresentation", err)
+	}
+	for i := range presentation {
+		tampered := append([]byte{}, presentation...)
+		tampered[i] ^= 0x01
+		if _, err := VerifyPresentation(issuer, issuerPublicKey, holder, nonce, tampered); err == nil {
+			t.Fatalf("VerifyPresentation accepted byte %d tampered", i)
+		}
+	}
+
+	// Disclosing everything or nothing.
+	presentation, err = cred.Present(rand.Reader, holder, sk, blind, context, []string{"country", "given_name", "age_over_18"}, nonce)
+	if err != nil {
+		t.Fatalf("Present error: %s", err)
+	}
+	if got, err := VerifyPresentation(issuer, issuerPublicKey, holder, nonce, presentation); err != nil || len(got) != 3 {
+		t.Errorf("VerifyPresentation of all attributes = %v, %v", got, err)
+	}
+	presentation, err = cred.Present(rand.Reader, holder, sk, blind, context, nil, nonce)
+	if err != nil {
+		t.Fatalf("Present error: %s", err)
+	}
+	if got, err := VerifyPresentation(issuer, issuerPublicKey, holder, nonce, presentation); err != nil || len(got) != 0 {
+		t.Errorf("VerifyPresentation of no attributes = %v, %v", got, err)
+	}
+
+	if _, err := cred.Present(rand.Reader, holder, sk, blind, context, []string{"email"}, nonce); !errors.Is(err, ErrUnknownAttribute) {
+		t.Errorf("Present of an unknown attribute: got %v, want ErrUnknownAttribute", err)
+	}
+	if _, err := cred.Present(rand.Reader, holder, sk, blind, context, []string{"country", "country"}, nonce); !errors.Is(err, ErrInvalidPresentation) {
+		t.Errorf("Present of an attribute twice: got %v, want ErrInvalidPresentation", err)
+	}
+	if _, err := cred.Present(rand.Reader, holder, sk, blind, []byte("other"), nil, nonce); !errors.Is(err, ErrInvalidPresentation) {
+		t.Errorf("Present with another context: got %v, want ErrInvalidPresentation", err)
+	}
+}
+
+func TestForgedAttribute(t *testing.T) {
+	s := blinding.Ristretto255
+	issuerPublicKey, issuerKey, _ := s.GenerateKey(rand.Reader)
+	pk, sk, _ := s.GenerateKey(rand.Reader)
+	blind, _ := s.GenerateBlind(rand.Reader)
+	pkR, _ := s.BlindPublicKey(pk, blind, nil)
+
+	cred, err := Issue(rand.Reader, s, issuerKey, s, pkR, attributes)
+	if err != nil {
+		t.Fatalf("Issue error: %s", err)
+	}
+	cred.Attributes[1].Value = "false"
+	if err := cred.Verify(s, issuerPublicKey); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("Verify of a modified credential: got %v, want ErrInvalidCredential", err)
+	}
+	presentation, err := cred.Present(rand.Reader, s, sk, blind, nil, []string{"age_over_18"}, nil)
+	if err != nil {
+		t.Fatalf("Present error: %s", err)
+	}
+	if _, err := VerifyPresentation(s, issuerPublicKey, s, nil, presentation); !errors.Is(err, ErrInvalidPresentation) {
+		t.Errorf("VerifyPresentation of a modified attribute: got %v, want ErrInvalidPresentation", err)
+	}
+}
+
+func TestIssueErrors(t *testing.T) {
+	s := blinding.Ristretto255
+	_, issuerKey, _ := s.GenerateKey(rand.Reader)
+	pk, _, _ := s.GenerateKey(rand.Reader)
+	for _, attrs := range [][]Attribute{
+		{{"", "empty name"}},
+		{{"a", "1"}, {"a", "2"}},
+	} {
+		if _, err := Issue(rand.Reader, s, issuerKey, s, pk, attrs); !errors.Is(err, ErrInvalidCredential) {
+			t.Errorf("Issue(%v): got %v, want ErrInvalidCredential", attrs, err)
+		}
+	}
+}
+
+// presentationFields are the fields of an encoded presentation.
+type presentationFields struct {
+	issuer, holder, holderKey, digests, disclosures, issuerSig, holderSig []byte
+}
+
+func decodePresentation(t *testing.T, presentation []byte) presentationFields {
+	t.Helper()
+	in := cryptobyte.String(presentation)
+	var v uint8
+	var issuer, holder, holderKey, digests, disclosures, issuerSig, holderSig cryptobyte.String
+	if !in.ReadUint8(&v) || v != version ||
+		!in.ReadUint8LengthPrefixed(&issuer) ||
+		!in.ReadUint8LengthPrefixed(&holder) ||
+		!in.ReadUint16LengthPrefixed(&holderKey) ||
+		!in.ReadUint16LengthPrefixed(&digests) ||
+		!in.ReadUint16LengthPrefixed(&disclosures) ||
+		!in.ReadUint16LengthPrefixed(&issuerSig) ||
+		!in.ReadUint16LengthPrefixed(&holderSig) || !in.Empty() {
+		t.Fatalf("malformed presentation")
+	}
+	return presentationFields{issuer, holder, holderKey, digests, disclosures, issuerSig, holderSig}
+}
+
+func encodeDisclosure(t *testing.T, salt []byte, a Attribute) []byte {
+	t.Helper()
+	var b cryptobyte.Builder
+	b.AddBytes(salt)
+	for _, v := range []string{a.Name, a.Value} {
+		v := v
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(v))
+		})
+	}
+	out, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,482 @@
+// Package directory builds signed directories of blinded public keys, in the
+// style of the consensus documents of Tor: for each epoch, an authority
+// publishes the public keys of a fleet of base keys, each blinded for that
+// epoch, so that verifiers can check the signatures of any member of the
+// fleet without learning its base key, and can't link its keys across
+// epochs.
+//
+// Keys are blinded with the context string returned by EpochContext, which
+// signers must also use with BlindKeySign. Directories of consecutive epochs
+// can be distributed as an Update, which only carries the entries that
+// changed, and is signed with the digest of the directory it produces.
+package directory
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	directoryVersion = 1
+	directoryDST     = "Directory v1"
+	updateDST        = "DirectoryUpdate v1"
+	epochContextDST  = "Directory epoch"
+)
+
+var (
+	// ErrInvalidDirectory is returned when a directory is badly encoded,
+	// has duplicate entries, or its signature doesn't verify.
+	ErrInvalidDirectory = errors.New("directory: invalid directory")
+
+	// ErrInvalidUpdate is returned when an update is badly encoded, its
+	// signature doesn't verify, or it doesn't apply to a directory.
+	ErrInvalidUpdate = errors.New("directory: invalid update")
+)
+
+// EpochContext returns the context string the keys of a directory are
+// blinded with for epoch.
+func EpochContext(epoch uint64) []byte {
+	ctx := make([]byte, len(epochContextDST)+8)
+	copy(ctx, epochContextDST)
+	binary.BigEndian.PutUint64(ctx[len(epochContextDST):], epoch)
+	return ctx
+}
+
+// Key is a base key of the fleet, with the blind its directory entries are
+// blinded with.
+type Key struct {
+	// ID identifies the key in directories. It is public, and should not
+	// identify the base key itself if that is to remain private.
+	ID string
+	// PublicKey is the base public key.
+	PublicKey []byte
+	// Blind is the secret blind of the key.
+	Blind []byte
+}
+
+// Entry is the blinded public key of a member of the fleet.
+type Entry struct {
+	ID        string
+	PublicKey []byte
+}
+
+// Directory holds the blinded public keys of a fleet for an epoch, sorted by
+// ID.
+type Directory struct {
+	// Scheme is the name of the scheme of the keys.
+	Scheme  string
+	Epoch   uint64
+	Entries []Entry
+}
+
+// Build blinds each of keys of the scheme s for epoch, and returns them in a
+// directory.
+func Build(s blinding.BlindableScheme, epoch uint64, keys []Key) (*Directory, error) {
+	d := &Directory{Scheme: s.Name(), Epoch: epoch}
+	ctx := EpochContext(epoch)
+	for _, k := range keys {
+		pk, err := s.BlindPublicKey(k.PublicKey, k.Blind, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("directory: key %q: %w", k.ID, err)
+		}
+		d.Entries = append(d.Entries, Entry{ID: k.ID, PublicKey: pk})
+	}
+	sortEntries(d.Entries)
+	if err := checkEntries(d.Entries); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDirectory, err)
+	}
+	return d, nil
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+}
+
+// checkEntries checks that sorted entries have distinct, encodable IDs and
+// keys.
+func checkEntries(entries []Entry) error {
+	for i, e := range entries {
+		if len(e.ID) == 0 || len(e.ID) > 255 {
+			return fmt.Errorf("ID %q of invalid length", e.ID)
+		}
+		if len(e.PublicKey) == 0 || len(e.PublicKey) > 0xffff {
+			return fmt.Errorf("public key of %q of invalid length", e.ID)
+		}
+		if i > 0 && entries[i-1].ID >= e.ID {
+			return fmt.Errorf("duplicate or unsorted ID %q", e.ID)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the blinded public key with the given ID.
+func (d *Directory) Lookup(id string) ([]byte, bool) {
+	i := sort.Search(len(d.Entries), func(i int) bool { return d.Entries[i].ID >= id })
+	if i < len(d.Entries) && d.Entries[i].ID == id {
+		return d.Entries[i].PublicKey, true
+	}
+	return nil, false
+}
+
+// body encodes d, without a signature.
+func (d *Directory) body() ([]byte, error) {
+	if err := checkEntries(d.Entries); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDirectory, err)
+	}
+	var b cryptobyte.Builder
+	b.AddUint8(directoryVersion)
+	addString(&b, d.Scheme)
+	addUint64(&b, d.Epoch)
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		addEntries(b, d.Entries)
+	})
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: scheme name or entries too long", ErrInvalidDirectory)
+	}
+	return body, nil
+}
+
+// Digest returns the SHA-256 hash of the encoding of d without its
+// signature, which identifies d in updates.
+func (d *Directory) Digest() ([sha256.Size]byte, error) {
+	body, err := d.body()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(body), nil
+}
+
+// Marshal encodes d, signed by authorityKey of the scheme authority, as:
+//
+//	struct {
+//	  opaque id<1..2^8-1>;
+//	  opaque public_key<1..2^16-1>;
+//	} Entry;
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque scheme<1..2^8-1>;
+//	  uint64 epoch;
+//	  Entry entries<0..2^24-1>;
+//	  opaque signature<1..2^16-1>;
+//	} SignedDirectory;
+//
+// where entries are sorted by ID. The signature covers all the preceding
+// fields and the name of the authority's scheme.
+func (d *Directory) Marshal(rand io.Reader, authority blinding.BlindableScheme, authorityKey []byte) ([]byte, error) {
+	body, err := d.body()
+	if err != nil {
+		return nil, err
+	}
+	return sign(rand, authority, authorityKey, directoryDST, body)
+}
+
+// Unmarshal decodes a directory encoded by Directory.Marshal, and checks that
+// it was signed by authorityPublicKey of the scheme authority.
+func Unmarshal(authority blinding.BlindableScheme, authorityPublicKey, data []byte) (*Directory, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var scheme, entries cryptobyte.String
+	d := new(Directory)
+	if !in.ReadUint8(&version) ||
+		!in.ReadUint8LengthPrefixed(&scheme) ||
+		!readUint64(&in, &d.Epoch) ||
+		!in.ReadUint24LengthPrefixed(&entries) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidDirectory)
+	}
+	body := data[:len(data)-len(in)]
+	if err := verify(authority, authorityPublicKey, directoryDST, body, in); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDirectory, err)
+	}
+	if version != directoryVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidDirectory, version)
+	}
+	d.Scheme = string(scheme)
+	var ok bool
+	if d.Entries, ok = readEntries(entries); !ok {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidDirectory)
+	}
+	if err := checkEntries(d.Entries); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDirectory, err)
+	}
+	return d, nil
+}
+
+// Update turns the directory of an epoch into the directory of a later one.
+type Update struct {
+	Scheme string
+	// BaseEpoch and BaseDigest identify the directory the update applies
+	// to.
+	BaseEpoch  uint64
+	BaseDigest [sha256.Size]byte
+	// Epoch and Digest identify the directory the update produces.
+	Epoch  uint64
+	Digest [sha256.Size]byte
+	// Removed holds the IDs of the entries of the base directory missing
+	// from the new one, and Changed the entries of the new directory that
+	// are not in the base one, sorted by ID.
+	Removed []string
+	Changed []Entry
+}
+
+// Diff returns the update from the directory old to the directory new.
+func Diff(old, new *Directory) (*Update, error) {
+	if old.Scheme != new.Scheme {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidUpdate, new.Scheme, old.Scheme)
+	}
+	baseDigest, err := old.Digest()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := new.Digest()
+	if err != nil {
+		return nil, err
+	}
+	u := &Update{
+		Scheme:     new.Scheme,
+		BaseEpoch:  old.Epoch,
+		BaseDigest: baseDigest,
+		Epoch:      new.Epoch,
+		Digest:     digest,
+	}
+	for _, e := range old.Entries {
+		if _, ok := new.Lookup(e.ID); !ok {
+			u.Removed = append(u.Removed, e.ID)
+		}
+	}
+	for _, e := range new.Entries {
+		if pk, ok := old.Lookup(e.ID); !ok || !bytes.Equal(pk, e.PublicKey) {
+			u.Changed = append(u.Changed, e)
+		}
+	}
+	return u, nil
+}
+
+// Apply returns the directory produced by applying u to d, or an error
+// wrapping ErrInvalidUpdate if u does not apply to d or does not produce the
+// directory it was signed for. d is not modified.
+func (d *Directory) Apply(u *Update) (*Directory, error) {
+	baseDigest, err := d.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if d.Scheme != u.Scheme || d.Epoch != u.BaseEpoch || baseDigest != u.BaseDigest {
+		return nil, fmt.Errorf("%w: update does not apply to epoch %d", ErrInvalidUpdate, d.Epoch)
+	}
+
+	keys := make(map[string][]byte, len(d.Entries))
+	for _, e := range d.Entries {
+		keys[e.ID] = e.PublicKey
+	}
+	for _, id := range u.Removed {
+		if _, ok := keys[id]; !ok {
+			return nil, fmt.Errorf("%w: removed ID %q not in directory", ErrInvalidUpdate, id)
+		}
+		delete(keys, id)
+	}
+	for _, e := range u.Changed {
+		keys[e.ID] = e.PublicKey
+	}
+
+	next := &Directory{Scheme: d.Scheme, Epoch: u.Epoch, Entries: make([]Entry, 0, len(keys))}
+	for id, pk := range keys {
+		next.Entries = append(next.Entries, Entry{ID: id, PublicKey: append([]byte{}, pk...)})
+	}
+	sortEntries(next.Entries)
+	digest, err := next.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if digest != u.Digest {
+		return nil, fmt.Errorf("%w: digest of epoch %d does not match", ErrInvalidUpdate, u.Epoch)
+	}
+	return next, nil
+}
+
+// body encodes u, without a signature.
+func (u *Update) body() ([]byte, error) {
+	if err := checkEntries(u.Changed); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidUpdate, err)
+	}
+	var b cryptobyte.Builder
+	b.AddUint8(directoryVersion)
+	addString(&b, u.Scheme)
+	addUint64(&b, u.BaseEpoch)
+	b.AddBytes(u.BaseDigest[:])
+	addUint64(&b, u.Epoch)
+	b.AddBytes(u.Digest[:])
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, id := range u.Removed {
+			addString(b, id)
+		}
+	})
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		addEntries(b, u.Changed)
+	})
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: scheme name or entries too long", ErrInvalidUpdate)
+	}
+	return body, nil
+}
+
+// Marshal encodes u, signed by authorityKey of the scheme authority, as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque scheme<1..2^8-1>;
+//	  uint64 base_epoch;
+//	  opaque base_digest[32];
+//	  uint64 epoch;
+//	  opaque digest[32];
+//	  opaque removed<0..2^24-1>;
+//	  Entry changed<0..2^24-1>;
+//	  opaque signature<1..2^16-1>;
+//	} SignedUpdate;
+//
+// where removed is a list of IDs, each prefixed by its 1-byte length, and
+// Entry is as in Directory.Marshal. As the signature covers the digest of the
+// directory the update produces, a directory obtained with Apply is as
+// authentic as a signed directory.
+func (u *Update) Marshal(rand io.Reader, authority blinding.BlindableScheme, authorityKey []byte) ([]byte, error) {
+	body, err := u.body()
+	if err != nil {
+		return nil, err
+	}
+	return sign(rand, authority, authorityKey, updateDST, body)
+}
+
+// UnmarshalUpdate decodes an update encoded by Update.Marshal, and checks
+// that it was signed by authorityPublicKey of the scheme authority.
+func UnmarshalUpdate(authority blinding.BlindableScheme, authorityPublicKey, data []byte) (*Update, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var scheme, removed, changed cryptobyte.String
+	var baseDigest, digest []byte
+	u := new(Update)
+	if !in.ReadUint8(&version) ||
+		!in.ReadUint8LengthPrefixed(&scheme) ||
+		!readUint64(&in, &u.BaseEpoch) ||
+		!in.ReadBytes(&baseDigest, sha256.Size) ||
+		!readUint64(&in, &u.Epoch) ||
+		!in.ReadBytes(&digest, sha256.Size) ||
+		!in.ReadUint24LengthPrefixed(&removed) ||
+		!in.ReadUint24LengthPrefixed(&changed) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidUpdate)
+	}
+	body := data[:len(data)-len(in)]
+	if err := verify(authority, authorityPublicKey, updateDST, body, in); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidUpdate, err)
+	}
+	if version != directoryVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidUpdate, version)
+	}
+	u.Scheme = string(scheme)
+	copy(u.BaseDigest[:], baseDigest)
+	copy(u.Digest[:], digest)
+	for !removed.Empty() {
+		var id cryptobyte.String
+		if !removed.ReadUint8LengthPrefixed(&id) || id.Empty() {
+			return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidUpdate)
+		}
+		u.Removed = append(u.Removed, string(id))
+	}
+	var ok bool
+	if u.Changed, ok = readEntries(changed); !ok {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidUpdate)
+	}
+	if err := checkEntries(u.Changed); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidUpdate, err)
+	}
+	return u, nil
+}
+
+func addString(b *cryptobyte.Builder, s string) {
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(s))
+	})
+}
+
+func addUint64(b *cryptobyte.Builder, v uint64) {
+	var u64 [8]byte
+	binary.BigEndian.PutUint64(u64[:], v)
+	b.AddBytes(u64[:])
+}
+
+func readUint64(s *cryptobyte.String, out *uint64) bool {
+	var v []byte
+	if !s.ReadBytes(&v, 8) {
+		return false
+	}
+	*out = binary.BigEndian.Uint64(v)
+	return true
+}
+
+func addEntries(b *cryptobyte.Builder, entries []Entry) {
+	for _, e := range entries {
+		e := e
+		addString(b, e.ID)
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(e.PublicKey)
+		})
+	}
+}
+
+func readEntries(s cryptobyte.String) ([]Entry, bool) {
+	var entries []Entry
+	for !s.Empty() {
+		var id, pk cryptobyte.String
+		if !s.ReadUint8LengthPrefixed(&id) || !s.ReadUint16LengthPrefixed(&pk) {
+			return nil, false
+		}
+		entries = append(entries, Entry{ID: string(id), PublicKey: append([]byte{}, pk...)})
+	}
+	return entries, true
+}
+
+// signedMessage returns the message signed by the authority: the body
+// prefixed by a domain separation tag and the name of the authority's scheme,
+// each prefixed by its 1-byte length.
+func signedMessage(authority blinding.BlindableScheme, dst string, body []byte) []byte {
+	var b cryptobyte.Builder
+	addString(&b, dst)
+	addString(&b, authority.Name())
+	b.AddBytes(body)
+	return b.BytesOrPanic()
+}
+
+// sign appends to body its signature by authorityKey.
+func sign(rand io.Reader, authority blinding.BlindableScheme, authorityKey []byte, dst string, body []byte) ([]byte, error) {
+	sig, err := authority.Sign(rand, authorityKey, signedMessage(authority, dst, body))
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(body)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sig)
+	})
+	return b.Bytes()
+}
+
+// verify checks that rest holds only the signature of body by
+// authorityPublicKey.
+func verify(authority blinding.BlindableScheme, authorityPublicKey []byte, dst string, body []byte, rest cryptobyte.String) error {
+	var sig cryptobyte.String
+	if !rest.ReadUint16LengthPrefixed(&sig) || !rest.Empty() {
+		return errors.New("malformed encoding")
+	}
+	if !authority.Verify(authorityPublicKey, signedMessage(authority, dst, body), sig) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package directory
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+type member struct {
+	key  Key
+	priv []byte
+}
+
+func newFleet(t *testing.T, s blinding.BlindableScheme, n int) []member {
+	fleet := make([]member, n)
+	for i := range fleet {
+		pk, sk, err := s.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey error: %s", err)
+		}
+		blind, _ := s.GenerateBlind(rand.Reader)
+		fleet[i] = member{Key{ID: fmt.Sprintf("relay-%02d", i), PublicKey: pk, Blind: blind}, sk}
+	}
+	return fleet
+}
+
+func keys(fleet []member) []Key {
+	var ks []Key
+	for _, m := range fleet {
+		ks = append(ks, m.key)
+	}
+	return ks
+}
+
+func TestDirectory(t *testing.T) {
+	for _, s := range []blinding.BlindableScheme{
+		blinding.Ristretto255,
+		blinding.Ed25519,
+		blinding.ECDSA(elliptic.P256(), crypto.SHA256),
+	} {
+		t.Run(s.Name(), func(t *testing.T) {
+			testDirectory(t, s)
+		})
+	}
+}
+
+func testDirectory(t *testing.T, s blinding.BlindableScheme) {
+	authPK, authSK, _ := blinding.Ristretto255.GenerateKey(rand.Reader)
+	fleet := newFleet(t, s, 5)
+	d, err := Build(s, 7, keys(fleet))
+	if err != nil {
+		t.Fatalf("Build error: %s", err)
+	}
+	data, err := d.Marshal(rand.Reader, blinding.Ristretto255, authSK)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	got, err := Unmarshal(blinding.Ristretto255, authPK, data)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if got.Scheme != s.Name() || got.Epoch != 7 || len(got.Entries) != 5 {
+		t.Fatalf("got %s directory for epoch %d with %d entries", got.Scheme, got.Epoch, len(got.Entries))
+	}
+
+	m := fleet[3]
+	message := []byte("test message")
+	sig, err := s.BlindKeySign(rand.Reader, m.priv, m.key.Blind, message, EpochContext(7))
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	pk, ok := got.Lookup(m.key.ID)
+	if !ok || !s.Verify(pk, message, sig) {
+		t.Errorf("directory key of %s does not verify its signatures", m.key.ID)
+	}
+	if _, ok := got.Lookup("relay-99"); ok {
+		t.Errorf("Lookup found a missing ID")
+	}
+
+	data[len(data)-1] ^= 1
+	if _, err := Unmarshal(blinding.Ristretto255, authPK, data); !errors.Is(err, ErrInvalidDirectory) {
+		t.Errorf("corrupted signature: got %v, want ErrInvalidDirectory", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s := blinding.Ristretto255
+	authPK, authSK, _ := s.GenerateKey(rand.Reader)
+	fleet := newFleet(t, s, 6)
+	old, err := Build(s, 1, keys(fleet[:4]))
+	if err != nil {
+		t.Fatalf("Build error: %s", err)
+	}
+	// Epoch 2 drops relay-00, adds relay-04 and relay-05, and rotates the
+	// blind of relay-02; the others keep their keys by reusing epoch 1.
+	next := &Directory{Scheme: s.Name(), Epoch: 2, Entries: append([]Entry{}, old.Entries[1:]...)}
+	fleet[2].key.Blind, _ = s.GenerateBlind(rand.Reader)
+	added, _ := Build(s, 1, []Key{fleet[2].key, fleet[4].key, fleet[5].key})
+	next.Entries[1] = added.Entries[0]
+	next.Entries = append(next.Entries, added.Entries[1:]...)
+
+	u, err := Diff(old, next)
+	if err != nil {
+		t.Fatalf("Diff error: %s", err)
+	}
+	if len(u.Removed) != 1 || len(u.Changed) != 3 {
+		t.Errorf("got %d removed and %d changed entries, want 1 and 3", len(u.Removed), len(u.Changed))
+	}
+	data, err := u.Marshal(rand.Reader, s, authSK)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	u, err = UnmarshalUpdate(s, authPK, data)
+	if err != nil {
+		t.Fatalf("UnmarshalUpdate error: %s", err)
+	}
+	applied, err := old.Apply(u)
+	if err != nil {
+		t.Fatalf("Apply error: %s", err)
+	}
+	want, _ := next.Digest()
+	if got, _ := applied.Digest(); got != want {
+		t.Errorf("applied update does not produce the new directory")
+	}
+
+	if _, err := applied.Apply(u); !errors.Is(err, ErrInvalidUpdate) {
+		t.Errorf("update applied twice: got %v, want ErrInvalidUpdate", err)
+	}
+	u.Changed[0].PublicKey = u.Changed[1].PublicKey
+	if _, err := old.Apply(u); !errors.Is(err, ErrInvalidUpdate) {
+		t.Errorf("tampered update: got %v, want ErrInvalidUpdate", err)
+	}
+}
+
+func TestBuildDuplicateID(t *testing.T) {
+	s := blinding.Ristretto255
+	fleet := newFleet(t, s, 2)
+	fleet[1].key.ID = fleet[0].key.ID
+	if _, err := Build(s, 1, keys(fleet)); !errors.Is(err, ErrInvalidDirectory) {
+		t.Errorf("duplicate ID: got %v, want ErrInvalidDirectory", err)
+	}
+}
@@ -0,0 +1,261 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// adversarialTestVectorsOutEnvironmentKey names the file
+// TestAdversarialBlindingVectors writes new vectors to, instead of checking
+// testdata/adversarial.json:
+//
+//	ECDSA_ADVERSARIAL_TEST_VECTORS_OUT=testdata/adversarial.json go test -run TestAdversarialBlindingVectors ./ecdsa
+const adversarialTestVectorsOutEnvironmentKey = "ECDSA_ADVERSARIAL_TEST_VECTORS_OUT"
+
+// adversarialVector is a blinding operation on degenerate inputs. Sk is the
+// private scalar of the base key, for BlindKeySign, and Pk the uncompressed
+// base key, or "00" for the point at infinity; for UnblindPublicKey, Pk is
+// the blinded key. Result is "valid" or the name of the sentinel error the
+// operation must return. A valid vector has the scalar the blind derives and
+// the compressed output key: the blinded key, or the base key for
+// UnblindPublicKey.
+type adversarialVector struct {
+	TcID      int    `json:"tcId"`
+	Comment   string `json:"comment"`
+	Curve     string `json:"curve"`
+	Operation string `json:"operation"`
+	Sk        string `json:"sk,omitempty"`
+	Pk        string `json:"pk"`
+	Blind     string `json:"blind"`
+	Context   string `json:"context"`
+	Result    string `json:"result"`
+	Scalar    string `json:"scalar,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+var adversarialErrors = map[string]error{
+	"ErrZeroBlind":       ErrZeroBlind,
+	"ErrWeakBlind":       ErrWeakBlind,
+	"ErrInvalidScalar":   ErrInvalidScalar,
+	"ErrPointNotOnCurve": ErrPointNotOnCurve,
+}
+
+var adversarialCurves = []elliptic.Curve{
+	elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521(), brainpool.P256r1(),
+}
+
+const (
+	opBlindPublicKey   = "BlindPublicKey"
+	opUnblindPublicKey = "UnblindPublicKey"
+	opBlindKeySign     = "BlindKeySign"
+)
+
+// generateAdversarialVectors covers blinds that are zero, 1 or N-1 modulo
+// N, base keys that are the point at infinity or have an out of range
+// scalar, and a single blind used on every curve. Blinds that derive to 1 or
+// N-1 are rejected as well, but finding one would take a preimage of the
+// hash.
+func generateAdversarialVectors(t *testing.T) []adversarialVector {
+	var vectors []adversarialVector
+	context := []byte("adversarial")
+	seed := func(label string) []byte {
+		s := sha256.Sum256([]byte(label))
+		return s[:]
+	}
+	// The shared blind is larger than the order of every curve but P-521,
+	// so that it is also reduced differently on each.
+	shared, _ := GenerateKeyFromSeed(elliptic.P521(), seed("adversarial shared blind"))
+
+	add := func(c elliptic.Curve, op, comment string, sk *big.Int, pk *PublicKey, blind *big.Int) {
+		v := adversarialVector{
+			TcID:      len(vectors) + 1,
+			Comment:   comment,
+			Curve:     c.Params().Name,
+			Operation: op,
+			Pk:        "00",
+			Blind:     hex.EncodeToString(blind.Bytes()),
+			Context:   hex.EncodeToString(context),
+		}
+		if pk.X.Sign() != 0 || pk.Y.Sign() != 0 {
+			v.Pk = hex.EncodeToString(elliptic.Marshal(c, pk.X, pk.Y))
+		}
+		if sk != nil {
+			v.Sk = hex.EncodeToString(sk.Bytes())
+		}
+		out, err := runAdversarialVector(c, op, sk, pk, blind, context)
+		v.Result = "valid"
+		for name, sentinel := range adversarialErrors {
+			if errors.Is(err, sentinel) {
+				v.Result = name
+			}
+		}
+		if err != nil && v.Result == "valid" {
+			t.Fatalf("%s, %s: unexpected error: %s", v.Curve, comment, err)
+		}
+		if err == nil {
+			k, _ := hashBlind(c, &PrivateKey{D: blind}, context)
+			v.Scalar = hex.EncodeToString(k.FillBytes(make([]byte, scalarSize(c))))
+			p, _ := out.Point()
+			v.Output = hex.EncodeToString(p.BytesCompressed())
+		}
+		vectors = append(vectors, v)
+	}
+
+	for _, c := range adversarialCurves {
+		N := c.Params().N
+		skS, _ := GenerateKeyFromSeed(c, seed("adversarial base key"))
+		skB, _ := GenerateKeyFromSeed(c, seed("adversarial blind"))
+		pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+		identity := &PublicKey{c, new(big.Int), new(big.Int)}
+		G := &PublicKey{c, c.Params().Gx, c.Params().Gy}
+
+		blinds := []struct {
+			comment string
+			blind   *big.Int
+		}{
+			{"blind = 0", new(big.Int)},
+			{"blind = 1", big.NewInt(1)},
+			{"blind = N-1", new(big.Int).Sub(N, one)},
+			{"blind = N", new(big.Int).Set(N)},
+			{"blind = N+1", new(big.Int).Add(N, one)},
+			{"blind = 2N", new(big.Int).Lsh(N, 1)},
+			{"blind = 2N-1", new(big.Int).Sub(new(big.Int).Lsh(N, 1), one)},
+		}
+		for _, b := range blinds {
+			add(c, opBlindPublicKey, b.comment, nil, &skS.PublicKey, b.blind)
+			add(c, opUnblindPublicKey, b.comment, nil, pkR, b.blind)
+			add(c, opBlindKeySign, b.comment, skS.D, &skS.PublicKey, b.blind)
+		}
+		add(c, opBlindPublicKey, "valid blind", nil, &skS.PublicKey, skB.D)
+		add(c, opUnblindPublicKey, "valid blind", nil, pkR, skB.D)
+		add(c, opBlindKeySign, "valid blind", skS.D, &skS.PublicKey, skB.D)
+		add(c, opBlindPublicKey, "pkS = identity", nil, identity, skB.D)
+		add(c, opUnblindPublicKey, "pkR = identity", nil, identity, skB.D)
+		add(c, opBlindKeySign, "skS = 0 with pkS = G", new(big.Int), G, skB.D)
+		add(c, opBlindKeySign, "skS = N with pkS = G", new(big.Int).Set(N), G, skB.D)
+		add(c, opBlindKeySign, "skS = N+1 with pkS = G", new(big.Int).Add(N, one), G, skB.D)
+		add(c, opBlindPublicKey, "blind shared across curves", nil, &skS.PublicKey, shared.D)
+	}
+	return vectors
+}
+
+// runAdversarialVector runs op on the decoded inputs of a vector, and returns
+// its output key.
+func runAdversarialVector(c elliptic.Curve, op string, sk *big.Int, pk *PublicKey, blind *big.Int, context []byte) (*PublicKey, error) {
+	bk := &PrivateKey{PublicKey{Curve: c}, blind}
+	switch op {
+	case opBlindPublicKey:
+		return BlindPublicKeyWithContext(c, pk, bk, context)
+	case opUnblindPublicKey:
+		return UnblindPublicKeyWithContext(c, pk, bk, context)
+	case opBlindKeySign:
+		skS := &PrivateKey{*pk, sk}
+		hashed := testDigest("adversarial")
+		r, s, err := BlindKeySignWithContext(rand.Reader, skS, bk, hashed, context)
+		if err != nil {
+			return nil, err
+		}
+		pkR, err := BlindPublicKeyWithContext(c, pk, bk, context)
+		if err != nil {
+			return nil, err
+		}
+		if err := CheckSignature(pkR, hashed, r, s); err != nil {
+			return nil, err
+		}
+		return pkR, nil
+	}
+	return nil, errors.New("unknown operation " + op)
+}
+
+func TestAdversarialBlindingVectors(t *testing.T) {
+	var vectors []adversarialVector
+	if outputFile := os.Getenv(adversarialTestVectorsOutEnvironmentKey); outputFile != "" {
+		vectors = generateAdversarialVectors(t)
+		encoded, err := json.MarshalIndent(vectors, "", "  ")
+		if err != nil {
+			t.Fatalf("Error producing test vectors: %v", err)
+		}
+		if err := os.WriteFile(outputFile, append(encoded, '\n'), 0644); err != nil {
+			t.Fatalf("Error writing test vectors: %v", err)
+		}
+	} else {
+		data, err := os.ReadFile("testdata/adversarial.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &vectors); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// scalars maps a blind and context used on several curves to the
+	// scalars it derives, which must all differ.
+	scalars := make(map[string]map[string]string)
+	for _, v := range vectors {
+		c, err := CurveByName(v.Curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fipsCheckCurve(c) != nil {
+			continue
+		}
+		pk := &PublicKey{c, new(big.Int), new(big.Int)}
+		if v.Pk != "00" {
+			b, _ := hex.DecodeString(v.Pk)
+			pk.X, pk.Y = elliptic.Unmarshal(c, b)
+			if pk.X == nil {
+				t.Fatalf("tcId %d: invalid public key", v.TcID)
+			}
+		}
+		var sk *big.Int
+		if v.Sk != "" {
+			sk = decodeHexInt(t, v.Sk)
+		}
+		blind := decodeHexInt(t, v.Blind)
+		context, _ := hex.DecodeString(v.Context)
+
+		out, err := runAdversarialVector(c, v.Operation, sk, pk, blind, context)
+		if v.Result != "valid" {
+			sentinel, ok := adversarialErrors[v.Result]
+			if !ok {
+				t.Fatalf("tcId %d: unknown result %q", v.TcID, v.Result)
+			}
+			if !errors.Is(err, sentinel) {
+				t.Errorf("tcId %d, %s, %s, %s: got %v, want %s", v.TcID, v.Curve, v.Operation, v.Comment, err, v.Result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tcId %d, %s, %s, %s: %s", v.TcID, v.Curve, v.Operation, v.Comment, err)
+			continue
+		}
+		p, _ := out.Point()
+		if got := hex.EncodeToString(p.BytesCompressed()); got != v.Output {
+			t.Errorf("tcId %d, %s, %s, %s: output %s, want %s", v.TcID, v.Curve, v.Operation, v.Comment, got, v.Output)
+		}
+		k, err := hashBlind(c, &PrivateKey{D: blind}, context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(k.FillBytes(make([]byte, scalarSize(c)))); got != v.Scalar {
+			t.Errorf("tcId %d, %s: scalar %s, want %s", v.TcID, v.Curve, got, v.Scalar)
+		}
+		key := v.Blind + "/" + v.Context
+		if scalars[key] == nil {
+			scalars[key] = make(map[string]string)
+		}
+		if other, ok := scalars[key][k.String()]; ok && other != v.Curve {
+			t.Errorf("tcId %d: blind derives the same scalar on %s and %s", v.TcID, v.Curve, other)
+		}
+		scalars[key][k.String()] = v.Curve
+	}
+}
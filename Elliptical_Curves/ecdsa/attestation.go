@@ -0,0 +1,167 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const attestationDST = "ECDSA Key Blind Attestation"
+
+// Attestation is a statement by the holder of a signing key that a blinded
+// public key was derived from their key for a given epoch and context string.
+// It lets relying parties that know the unblinded public key accept the
+// blinded key without learning the blind.
+type Attestation struct {
+	BlindedKey *PublicKey
+	Epoch      uint64
+	Context    []byte
+	Signature  *Signature
+}
+
+// attestationDigest hashes the attested tuple with the hash function used for
+// blinding on the curve of the blinded key:
+//
+//	H(len(DST) || DST || len(pkR) || pkR || epoch || len(context) || context)
+//
+// where pkR is the compressed point encoding, epoch is an 8-byte big-endian
+// integer, and lengths are 2-byte big-endian integers.
+func attestationDigest(pkR *PublicKey, epoch uint64, context []byte) ([]byte, error) {
+	h, _, err := blindParams(pkR.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if len(context) > 0xffff {
+		return nil, wrapError(ErrInvalidSignature, "attestation context too long")
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(attestationDST))
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y))
+	})
+	var epochBytes [8]byte
+	binary.BigEndian.PutUint64(epochBytes[:], epoch)
+	b.AddBytes(epochBytes[:])
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(context)
+	})
+
+	md := h.New()
+	md.Write(b.BytesOrPanic())
+	return md.Sum(nil), nil
+}
+
+// CreateAttestation blinds the public key of skS by skB and context, and
+// signs the blinded key, epoch and context with the unblinded key skS.
+func CreateAttestation(rand io.Reader, skS *PrivateKey, skB *PrivateKey, epoch uint64, context []byte) (*Attestation, error) {
+	pkR, err := BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := attestationDigest(pkR, epoch, context)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := Sign(rand, skS, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attestation{
+		BlindedKey: pkR,
+		Epoch:      epoch,
+		Context:    append([]byte{}, context...),
+		Signature:  &Signature{R: r, S: s},
+	}, nil
+}
+
+// VerifyAttestation checks that att was signed by pkS and that its blinded
+// key is a valid key on the curve of pkS. Checking that the epoch and context
+// are acceptable is left to the caller.
+func VerifyAttestation(pkS *PublicKey, att *Attestation) error {
+	if att == nil || att.BlindedKey == nil || att.Signature == nil {
+		return wrapError(ErrInvalidSignature, "incomplete attestation")
+	}
+	if err := ValidatePublicKey(pkS.Curve, att.BlindedKey); err != nil {
+		return err
+	}
+	digest, err := attestationDigest(att.BlindedKey, att.Epoch, att.Context)
+	if err != nil {
+		return err
+	}
+	return CheckSignature(pkS, digest, att.Signature.R, att.Signature.S)
+}
+
+// Marshal encodes att as:
+//
+//	struct {
+//	  opaque blinded_key<1..2^16-1>;
+//	  uint64 epoch;
+//	  opaque context<0..2^16-1>;
+//	  opaque r[Ns];
+//	  opaque s[Ns];
+//	} Attestation;
+//
+// where blinded_key is the compressed point encoding and Ns is the length of
+// a scalar of the curve.
+func (att *Attestation) Marshal() ([]byte, error) {
+	if len(att.Context) > 0xffff {
+		return nil, wrapError(ErrInvalidSignature, "attestation context too long")
+	}
+	pkR := att.BlindedKey
+	size := scalarSize(pkR.Curve)
+
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y))
+	})
+	var epoch [8]byte
+	binary.BigEndian.PutUint64(epoch[:], att.Epoch)
+	b.AddBytes(epoch[:])
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(att.Context)
+	})
+	b.AddBytes(att.Signature.R.FillBytes(make([]byte, size)))
+	b.AddBytes(att.Signature.S.FillBytes(make([]byte, size)))
+	return b.Bytes()
+}
+
+// UnmarshalAttestation decodes an attestation on the curve c encoded by
+// Marshal. It does not verify the attestation.
+func UnmarshalAttestation(c elliptic.Curve, data []byte) (*Attestation, error) {
+	size := scalarSize(c)
+	s := cryptobyte.String(data)
+
+	var enc, context cryptobyte.String
+	var epoch, r, ss []byte
+	if !s.ReadUint16LengthPrefixed(&enc) ||
+		!s.ReadBytes(&epoch, 8) ||
+		!s.ReadUint16LengthPrefixed(&context) ||
+		!s.ReadBytes(&r, size) ||
+		!s.ReadBytes(&ss, size) ||
+		!s.Empty() {
+		return nil, wrapError(ErrInvalidSignature, "malformed attestation")
+	}
+
+	p, err := NewPoint(c, enc)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := NewPublicKey(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attestation{
+		BlindedKey: pkR,
+		Epoch:      binary.BigEndian.Uint64(epoch),
+		Context:    append([]byte{}, context...),
+		Signature:  &Signature{R: new(big.Int).SetBytes(r), S: new(big.Int).SetBytes(ss)},
+	}, nil
+}
@@ -0,0 +1,81 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestAttestation(t *testing.T) {
+	testAllCurves(t, testAttestation)
+}
+
+func testAttestation(t *testing.T, c elliptic.Curve) {
+	skS, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	skB, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	context := []byte("context")
+
+	att, err := CreateAttestation(rand.Reader, skS, skB, 42, context)
+	if err != nil {
+		t.Fatalf("CreateAttestation error: %s", err)
+	}
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	if !att.BlindedKey.Equal(pkR) {
+		t.Errorf("attested key does not match the blinded key")
+	}
+	if err := VerifyAttestation(&skS.PublicKey, att); err != nil {
+		t.Errorf("VerifyAttestation error: %s", err)
+	}
+
+	enc, err := att.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	att2, err := UnmarshalAttestation(c, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalAttestation error: %s", err)
+	}
+	if err := VerifyAttestation(&skS.PublicKey, att2); err != nil {
+		t.Errorf("VerifyAttestation of decoded attestation error: %s", err)
+	}
+	if _, err := UnmarshalAttestation(c, enc[:len(enc)-1]); err == nil {
+		t.Errorf("truncated attestation decoded")
+	}
+
+	att2.Epoch++
+	if err := VerifyAttestation(&skS.PublicKey, att2); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("attestation with a different epoch verified: %v", err)
+	}
+	att2.Epoch--
+	att2.Context = []byte("other context")
+	if err := VerifyAttestation(&skS.PublicKey, att2); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("attestation with a different context verified: %v", err)
+	}
+
+	skO, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if err := VerifyAttestation(&skO.PublicKey, att); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("attestation verified under another key: %v", err)
+	}
+
+	long := make([]byte, 0x10000)
+	if _, err := CreateAttestation(rand.Reader, skS, skB, 42, long); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("CreateAttestation with a too long context: got %v, want ErrInvalidSignature", err)
+	}
+	att.Context = long
+	if _, err := att.Marshal(); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Marshal with a too long context: got %v, want ErrInvalidSignature", err)
+	}
+}
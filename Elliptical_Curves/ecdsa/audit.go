@@ -0,0 +1,292 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const auditDST = "ECDSA Audited Blind"
+
+// AuditedSignature is a signature under a blinded public key, bundled with an
+// encryption of the unblinded public key to a designated auditor. Anyone can
+// verify the signature and that the encrypted key is the one the blinded key
+// was derived from, but only the auditor can decrypt it and link the
+// signature to its signer, which gives compliance teams selective
+// traceability without making blinded keys linkable to everyone else.
+//
+// The unblinded key pkS is encrypted with ElGamal under the auditor key A as
+// (C1, C2) = ([k]G, pkS + [k]A). The proof is a non-interactive zero-knowledge
+// proof of knowledge of k, the blind b and t = b*k such that
+//
+//	C1 = [k]G,  [b]C1 = [t]G,  pkR = [b]C2 - [t]A,
+//
+// which together imply pkR = [b]pkS for the encrypted pkS.
+type AuditedSignature struct {
+	BlindedKey *PublicKey
+	Signature  *Signature
+
+	C1, C2 *Point
+
+	// Challenge and Zk, Zb, Zt are the Fiat-Shamir challenge and responses
+	// of the proof.
+	Challenge, Zk, Zb, Zt *Scalar
+}
+
+// auditChallenge hashes the statement, the commitments T1, T2, T3 and the
+// signed digest to the challenge of the proof, with the hash-to-field
+// parameters used for blinds on the curve of the auditor key:
+//
+//	hash_to_field(A || pkR || C1 || C2 || T1 || T2 || T3 || hash)
+//
+// where each point is the compressed encoding and each value is prefixed with
+// its 2-byte big-endian length.
+func auditChallenge(auditor *Point, pkR, c1, c2, t1, t2, t3 *Point, hash []byte) (*Scalar, error) {
+	c := auditor.Curve()
+	if len(hash) > 0xffff {
+		return nil, wrapError(ErrInvalidSignature, "digest too long")
+	}
+	var b cryptobyte.Builder
+	for _, p := range []*Point{auditor, pkR, c1, c2, t1, t2, t3} {
+		p := p
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(p.BytesCompressed())
+		})
+	}
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(hash)
+	})
+
+	return hashToScalar(c, b.BytesOrPanic(), []byte(auditDST))
+}
+
+// randScalar returns a uniformly random non-zero scalar of the curve c.
+func randScalar(c elliptic.Curve, rand io.Reader) (*Scalar, error) {
+	k, err := randFieldElement(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	return &Scalar{c: c, v: k}, nil
+}
+
+// BlindKeySignAuditedWithContext signs hash with skS blinded by skB and
+// context, as BlindKeySignWithContext does, and encrypts the public key of skS
+// to the auditor key so that only the auditor can link the signature to it.
+func BlindKeySignAuditedWithContext(rand io.Reader, skS *PrivateKey, skB *PrivateKey, auditor *PublicKey, hash []byte, context []byte) (*AuditedSignature, error) {
+	c := skS.Curve
+	if auditor.Curve != c {
+		return nil, wrapError(ErrCurveMismatch, "auditor key is on %s, not %s", auditor.Curve.Params().Name, c.Params().Name)
+	}
+	A, err := auditor.Point()
+	if err != nil {
+		return nil, err
+	}
+	pkS, err := skS.PublicKey.Point()
+	if err != nil {
+		return nil, err
+	}
+	blind, err := hashBlind(c, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	b := &Scalar{c: c, v: blind}
+
+	r, s, err := BlindKeySignWithContext(rand, skS, skB, hash, context)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := blindPublicKey(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	R, _ := pkR.Point()
+
+	var scalars [4]*Scalar
+	for i := range scalars {
+		if scalars[i], err = randScalar(c, rand); err != nil {
+			return nil, err
+		}
+	}
+	k, rk, rb, rt := scalars[0], scalars[1], scalars[2], scalars[3]
+	t := NewScalar(c).Multiply(b, k)
+
+	C1 := NewIdentityPoint(c).ScalarBaseMult(k)
+	C2 := NewIdentityPoint(c).Add(pkS, NewIdentityPoint(c).ScalarMult(k, A))
+
+	// T1 = [rk]G, T2 = [rb]C1 - [rt]G, T3 = [rb]C2 - [rt]A
+	T1 := NewIdentityPoint(c).ScalarBaseMult(rk)
+	rtG := NewIdentityPoint(c).ScalarBaseMult(rt)
+	T2 := NewIdentityPoint(c).Add(NewIdentityPoint(c).ScalarMult(rb, C1), rtG.Negate(rtG))
+	rtA := NewIdentityPoint(c).ScalarMult(rt, A)
+	T3 := NewIdentityPoint(c).Add(NewIdentityPoint(c).ScalarMult(rb, C2), rtA.Negate(rtA))
+
+	e, err := auditChallenge(A, R, C1, C2, T1, T2, T3, hash)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditedSignature{
+		BlindedKey: pkR,
+		Signature:  &Signature{R: r, S: s},
+		C1:         C1,
+		C2:         C2,
+		Challenge:  e,
+		Zk:         NewScalar(c).Add(rk, NewScalar(c).Multiply(e, k)),
+		Zb:         NewScalar(c).Add(rb, NewScalar(c).Multiply(e, b)),
+		Zt:         NewScalar(c).Add(rt, NewScalar(c).Multiply(e, t)),
+	}, nil
+}
+
+// BlindKeySignAudited is like BlindKeySignAuditedWithContext with an empty
+// context string.
+func BlindKeySignAudited(rand io.Reader, skS *PrivateKey, skB *PrivateKey, auditor *PublicKey, hash []byte) (*AuditedSignature, error) {
+	return BlindKeySignAuditedWithContext(rand, skS, skB, auditor, hash, nil)
+}
+
+// VerifyAudited checks that sig is a valid signature of hash under its
+// blinded key, and that its encrypted key, which the auditor can decrypt with
+// OpenAudited, is the key the blinded key was derived from.
+func VerifyAudited(auditor *PublicKey, hash []byte, sig *AuditedSignature) error {
+	if sig == nil || sig.BlindedKey == nil || sig.Signature == nil || sig.C1 == nil || sig.C2 == nil ||
+		sig.Challenge == nil || sig.Zk == nil || sig.Zb == nil || sig.Zt == nil {
+		return wrapError(ErrInvalidSignature, "incomplete audited signature")
+	}
+	c := auditor.Curve
+	A, err := auditor.Point()
+	if err != nil {
+		return err
+	}
+	if sig.BlindedKey.Curve != c || sig.C1.Curve() != c || sig.C2.Curve() != c || sig.Challenge.Curve() != c ||
+		sig.Zk.Curve() != c || sig.Zb.Curve() != c || sig.Zt.Curve() != c {
+		return wrapError(ErrCurveMismatch, "audited signature is not on %s", c.Params().Name)
+	}
+	R, err := sig.BlindedKey.Point()
+	if err != nil {
+		return err
+	}
+	if err := CheckSignature(sig.BlindedKey, hash, sig.Signature.R, sig.Signature.S); err != nil {
+		return err
+	}
+
+	// T1 = [zk]G - [e]C1, T2 = [zb]C1 - [zt]G, T3 = [zb]C2 - [zt]A - [e]pkR
+	e := sig.Challenge
+	eC1 := NewIdentityPoint(c).ScalarMult(e, sig.C1)
+	T1 := NewIdentityPoint(c).Add(NewIdentityPoint(c).ScalarBaseMult(sig.Zk), eC1.Negate(eC1))
+	ztG := NewIdentityPoint(c).ScalarBaseMult(sig.Zt)
+	T2 := NewIdentityPoint(c).Add(NewIdentityPoint(c).ScalarMult(sig.Zb, sig.C1), ztG.Negate(ztG))
+	ztA := NewIdentityPoint(c).ScalarMult(sig.Zt, A)
+	eR := NewIdentityPoint(c).ScalarMult(e, R)
+	T3 := NewIdentityPoint(c).Add(NewIdentityPoint(c).ScalarMult(sig.Zb, sig.C2), ztA.Negate(ztA))
+	T3.Add(T3, eR.Negate(eR))
+
+	want, err := auditChallenge(A, R, sig.C1, sig.C2, T1, T2, T3, hash)
+	if err != nil {
+		return err
+	}
+	if want.Equal(e) != 1 {
+		return wrapError(ErrInvalidSignature, "invalid key encryption proof")
+	}
+	return nil
+}
+
+// OpenAudited decrypts the unblinded public key of an audited signature with
+// the auditor's private key. The signature should be checked with
+// VerifyAudited first.
+func OpenAudited(auditor *PrivateKey, sig *AuditedSignature) (*PublicKey, error) {
+	a, err := auditor.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	if sig == nil || sig.C1 == nil || sig.C2 == nil || sig.C1.Curve() != auditor.Curve || sig.C2.Curve() != auditor.Curve {
+		return nil, wrapError(ErrCurveMismatch, "audited signature is not on %s", auditor.Curve.Params().Name)
+	}
+	aC1 := NewIdentityPoint(auditor.Curve).ScalarMult(a, sig.C1)
+	return NewPublicKey(NewIdentityPoint(auditor.Curve).Add(sig.C2, aC1.Negate(aC1)))
+}
+
+// Marshal encodes sig as:
+//
+//	struct {
+//	  opaque blinded_key<1..2^16-1>;
+//	  opaque r[Ns];
+//	  opaque s[Ns];
+//	  opaque c1<1..2^16-1>;
+//	  opaque c2<1..2^16-1>;
+//	  opaque challenge[Ns];
+//	  opaque zk[Ns];
+//	  opaque zb[Ns];
+//	  opaque zt[Ns];
+//	} AuditedSignature;
+//
+// where points use the compressed encoding and Ns is the length of a scalar
+// of the curve.
+func (sig *AuditedSignature) Marshal() ([]byte, error) {
+	pkR := sig.BlindedKey
+	size := scalarSize(pkR.Curve)
+
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y))
+	})
+	b.AddBytes(sig.Signature.R.FillBytes(make([]byte, size)))
+	b.AddBytes(sig.Signature.S.FillBytes(make([]byte, size)))
+	for _, p := range []*Point{sig.C1, sig.C2} {
+		p := p
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(p.BytesCompressed())
+		})
+	}
+	for _, s := range []*Scalar{sig.Challenge, sig.Zk, sig.Zb, sig.Zt} {
+		b.AddBytes(s.Bytes())
+	}
+	return b.Bytes()
+}
+
+// UnmarshalAuditedSignature decodes an audited signature on the curve c
+// encoded by Marshal. It does not verify the signature.
+func UnmarshalAuditedSignature(c elliptic.Curve, data []byte) (*AuditedSignature, error) {
+	size := scalarSize(c)
+	in := cryptobyte.String(data)
+
+	var enc, c1, c2 cryptobyte.String
+	var r, s []byte
+	var scalars [4][]byte
+	ok := in.ReadUint16LengthPrefixed(&enc) &&
+		in.ReadBytes(&r, size) &&
+		in.ReadBytes(&s, size) &&
+		in.ReadUint16LengthPrefixed(&c1) &&
+		in.ReadUint16LengthPrefixed(&c2)
+	for i := range scalars {
+		ok = ok && in.ReadBytes(&scalars[i], size)
+	}
+	if !ok || !in.Empty() {
+		return nil, wrapError(ErrInvalidSignature, "malformed audited signature")
+	}
+
+	p, err := NewPoint(c, enc)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := NewPublicKey(p)
+	if err != nil {
+		return nil, err
+	}
+	sig := &AuditedSignature{
+		BlindedKey: pkR,
+		Signature:  &Signature{R: new(big.Int).SetBytes(r), S: new(big.Int).SetBytes(s)},
+	}
+	if sig.C1, err = NewPoint(c, c1); err != nil {
+		return nil, err
+	}
+	if sig.C2, err = NewPoint(c, c2); err != nil {
+		return nil, err
+	}
+	out := []**Scalar{&sig.Challenge, &sig.Zk, &sig.Zb, &sig.Zt}
+	for i := range scalars {
+		if *out[i], err = NewScalar(c).SetBytes(scalars[i]); err != nil {
+			return nil, err
+		}
+	}
+	return sig, nil
+}
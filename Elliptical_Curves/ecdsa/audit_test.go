@@ -0,0 +1,81 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestAuditedSignature(t *testing.T) {
+	testAllCurves(t, testAuditedSignature)
+}
+
+func testAuditedSignature(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	auditor, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	hashed := testDigest("testing")
+
+	sig, err := BlindKeySignAuditedWithContext(rand.Reader, skS, skB, &auditor.PublicKey, hashed, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignAuditedWithContext error: %s", err)
+	}
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if !sig.BlindedKey.Equal(pkR) {
+		t.Fatalf("audited signature is not under the blinded key")
+	}
+	if err := VerifyAudited(&auditor.PublicKey, hashed, sig); err != nil {
+		t.Fatalf("VerifyAudited error: %s", err)
+	}
+	pkS, err := OpenAudited(auditor, sig)
+	if err != nil {
+		t.Fatalf("OpenAudited error: %s", err)
+	}
+	if !pkS.Equal(&skS.PublicKey) {
+		t.Errorf("OpenAudited returned the wrong key")
+	}
+
+	other, _ := GenerateKey(c, rand.Reader)
+	if pk, err := OpenAudited(other, sig); err == nil && pk.Equal(&skS.PublicKey) {
+		t.Errorf("another key opened the audited signature")
+	}
+	if err := VerifyAudited(&other.PublicKey, hashed, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyAudited under another auditor: got %v, want ErrInvalidSignature", err)
+	}
+	if err := VerifyAudited(&auditor.PublicKey, testDigest("other"), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyAudited of another digest: got %v, want ErrInvalidSignature", err)
+	}
+
+	// Encrypting another key to the auditor must not go unnoticed.
+	forged := *sig
+	forged.C2 = NewIdentityPoint(c).Add(sig.C2, NewGeneratorPoint(c))
+	if err := VerifyAudited(&auditor.PublicKey, hashed, &forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyAudited with a substituted ciphertext: got %v, want ErrInvalidSignature", err)
+	}
+
+	enc, err := sig.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decoded, err := UnmarshalAuditedSignature(c, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalAuditedSignature error: %s", err)
+	}
+	if err := VerifyAudited(&auditor.PublicKey, hashed, decoded); err != nil {
+		t.Errorf("VerifyAudited of a decoded signature error: %s", err)
+	}
+	if _, err := UnmarshalAuditedSignature(c, enc[:len(enc)-1]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("UnmarshalAuditedSignature of a truncated encoding: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestAuditedSignatureCurveMismatch(t *testing.T) {
+	skS, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	skB, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	auditor, _ := GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := BlindKeySignAudited(rand.Reader, skS, skB, &auditor.PublicKey, testDigest("testing")); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("BlindKeySignAudited with an auditor on another curve: got %v, want ErrCurveMismatch", err)
+	}
+}
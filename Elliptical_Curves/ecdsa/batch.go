@@ -0,0 +1,266 @@
+package ecdsa
+
+import (
+	stdcontext "context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// blindBatchSize is the number of keys a worker of BlindPublicKeysWithContext
+// claims at a time.
+const blindBatchSize = 64
+
+// BlindPublicKeysWithContext blinds each public key in pks using the private
+// key bk and context string, as BlindPublicKeyWithContext does, and returns
+// the blinded keys in the same order.
+//
+// The blind is derived from bk and context once and shared by a pool of
+// GOMAXPROCS workers that blind the keys concurrently, which makes re-blinding
+// a large directory of keys much faster than calling BlindPublicKeyWithContext
+// for each of them. If any key is invalid, BlindPublicKeysWithContext returns
+// the error of the first one, annotated with its index.
+func BlindPublicKeysWithContext(c elliptic.Curve, pks []*PublicKey, bk *PrivateKey, context []byte) ([]*PublicKey, error) {
+	return BlindPublicKeysCtx(stdcontext.Background(), c, pks, bk, context)
+}
+
+// BlindPublicKeysCtx is like BlindPublicKeysWithContext, but stops blinding
+// and returns ctx.Err() once ctx is done. Workers check ctx before each batch
+// of keys they claim.
+func BlindPublicKeysCtx(ctx stdcontext.Context, c elliptic.Curve, pks []*PublicKey, bk *PrivateKey, context []byte) ([]*PublicKey, error) {
+	skBlind, err := hashBlind(c, bk, context)
+	if err != nil {
+		return nil, err
+	}
+	blind := skBlind.FillBytes(make([]byte, scalarSize(c)))
+
+	out := make([]*PublicKey, len(pks))
+	errs := make([]error, len(pks))
+	workers := runtime.GOMAXPROCS(0)
+	if n := (len(pks) + blindBatchSize - 1) / blindBatchSize; n < workers {
+		workers = n
+	}
+
+	var next int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				end := int(atomic.AddInt64(&next, blindBatchSize))
+				start := end - blindBatchSize
+				if start >= len(pks) {
+					return
+				}
+				if end > len(pks) {
+					end = len(pks)
+				}
+				for i := start; i < end; i++ {
+					pk := pks[i]
+					if err := ValidatePublicKey(c, pk); err != nil {
+						errs[i] = err
+						continue
+					}
+					X, Y := c.ScalarMult(pk.X, pk.Y, blind)
+					out[i] = &PublicKey{c, X, Y}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+	}
+	for range out {
+		observeBlind(c)
+	}
+	return out, nil
+}
+
+// BlindPublicKeys blinds each public key in pks using the private key bk and
+// empty context string. See BlindPublicKeysWithContext.
+func BlindPublicKeys(c elliptic.Curve, pks []*PublicKey, bk *PrivateKey) ([]*PublicKey, error) {
+	return BlindPublicKeysWithContext(c, pks, bk, nil)
+}
+
+// SignBatch signs each of hashes with priv, as Sign does, and returns the
+// signatures in the same order.
+//
+// The nonce of each signature is derived from the private key, 256 bits of
+// entropy read once from rand, the index of the hash and the hash itself, and
+// the nonces of the batch are checked to be distinct before any signature is
+// computed. Their inverses are computed with a single modular inversion by
+// Montgomery's trick, which makes large batches faster than calling Sign for
+// each hash. In FIPS mode, the nonces are derived as specified by RFC 6979
+// and rand is not used, so equal hashes get equal signatures.
+func SignBatch(rand io.Reader, priv *PrivateKey, hashes [][]byte) ([]Signature, error) {
+	return SignBatchCtx(stdcontext.Background(), rand, priv, hashes)
+}
+
+// SignBatchCtx is like SignBatch, but stops and returns ctx.Err() once ctx
+// is done. No signature is returned for a cancelled batch.
+func SignBatchCtx(ctx stdcontext.Context, rand io.Reader, priv *PrivateKey, hashes [][]byte) ([]Signature, error) {
+	start := time.Now()
+	c := priv.Curve
+	N := c.Params().N
+	if N.Sign() == 0 {
+		return nil, errZeroParam
+	}
+	for i, hash := range hashes {
+		if err := strictCheckDigest(hash); err != nil {
+			return nil, fmt.Errorf("hash %d: %w", i, err)
+		}
+	}
+	nonces, err := batchNonces(rand, priv, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Draw a nonce for each hash, skipping those with r = 0 and those
+	// already used for a different hash.
+	ks := make([]*big.Int, len(hashes))
+	rs := make([]*big.Int, len(hashes))
+	used := make(map[string]int, len(hashes))
+	for i := range hashes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for {
+			k, err := nonces[i]()
+			if err != nil {
+				return nil, err
+			}
+			if j, ok := used[string(k.Bytes())]; ok && string(hashes[j]) != string(hashes[i]) {
+				continue
+			}
+			r, _ := c.ScalarBaseMult(k.Bytes())
+			r.Mod(r, N)
+			if r.Sign() != 0 {
+				used[string(k.Bytes())] = i
+				ks[i], rs[i] = k, r
+				break
+			}
+		}
+	}
+
+	kInvs := batchInverse(c, ks)
+	sigs := make([]Signature, len(hashes))
+	for i, hash := range hashes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		s := new(big.Int).Mul(priv.D, rs[i])
+		s.Add(s, hashToInt(hash, c))
+		s.Mul(s, kInvs[i])
+		s.Mod(s, N)
+		if s.Sign() == 0 {
+			// Negligible, but the nonce can't be reused; continue with
+			// the next nonce of the same generator.
+			r, s, err := signWithNonces(priv, c, hash, nonces[i])
+			if err != nil {
+				return nil, err
+			}
+			sigs[i] = Signature{R: r, S: s}
+			continue
+		}
+		sigs[i] = Signature{R: rs[i], S: s}
+	}
+	for range sigs {
+		observeSign(c, start)
+	}
+	return sigs, nil
+}
+
+// batchNonces returns a nonce generator for each of hashes, as SignBatch
+// describes.
+func batchNonces(rand io.Reader, priv *PrivateKey, hashes [][]byte) ([]func() (*big.Int, error), error) {
+	c := priv.Curve
+	nonces := make([]func() (*big.Int, error), len(hashes))
+	if FIPSMode() {
+		if err := fipsCheckCurve(c); err != nil {
+			return nil, err
+		}
+		for i, hash := range hashes {
+			nonces[i] = rfc6979Nonces(c, rfc6979Hash(c), priv.D, hash)
+		}
+		return nonces, nil
+	}
+
+	rand = entropySource(rand)
+	MaybeReadByte(rand)
+	entropy := make([]byte, 32)
+	if _, err := io.ReadFull(rand, entropy); err != nil {
+		return nil, entropyError(err)
+	}
+	var index [8]byte
+	for i, hash := range hashes {
+		// As in Sign, the key of an AES-CTR CSPRNG is ChopMD-256(SHA-512)
+		// of the private key, the entropy, and here the index and the hash.
+		binary.BigEndian.PutUint64(index[:], uint64(i))
+		md := sha512.New()
+		md.Write(priv.D.Bytes())
+		md.Write(entropy)
+		md.Write(index[:])
+		md.Write(hash)
+		block, err := aes.NewCipher(md.Sum(nil)[:32])
+		if err != nil {
+			return nil, err
+		}
+		csprng := cipher.StreamReader{
+			R: zeroReader,
+			S: cipher.NewCTR(block, []byte(aesIV)),
+		}
+		nonces[i] = func() (*big.Int, error) {
+			return randFieldElement(c, csprng)
+		}
+	}
+	return nonces, nil
+}
+
+// batchInverse returns the inverses modulo the order of c of ks, which must
+// be non-zero, with a single inversion: the prefix products of ks are
+// inverted at once, and the inverse of each element is peeled off the
+// inverse of the product from the end.
+func batchInverse(c elliptic.Curve, ks []*big.Int) []*big.Int {
+	N := c.Params().N
+	if len(ks) == 0 {
+		return nil
+	}
+	prefix := make([]*big.Int, len(ks))
+	acc := big.NewInt(1)
+	for i, k := range ks {
+		prefix[i] = acc
+		acc = new(big.Int).Mul(acc, k)
+		acc.Mod(acc, N)
+	}
+	var inv *big.Int
+	if in, ok := c.(invertible); ok {
+		inv = in.Inverse(acc)
+	} else {
+		inv = fermatInverse(acc, N)
+	}
+	out := make([]*big.Int, len(ks))
+	for i := len(ks) - 1; i >= 0; i-- {
+		out[i] = new(big.Int).Mul(inv, prefix[i])
+		out[i].Mod(out[i], N)
+		inv.Mul(inv, ks[i])
+		inv.Mod(inv, N)
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package ecdsa
+
+import (
+	stdcontext "context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestBlindPublicKeys(t *testing.T) {
+	testAllCurves(t, testBlindPublicKeys)
+}
+
+func testBlindPublicKeys(t *testing.T, c elliptic.Curve) {
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("epoch 1")
+
+	pks := make([]*PublicKey, 3*blindBatchSize+5)
+	for i := range pks {
+		sk, _ := GenerateKey(c, rand.Reader)
+		pks[i] = &sk.PublicKey
+	}
+	got, err := BlindPublicKeysWithContext(c, pks, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeysWithContext error: %s", err)
+	}
+	if len(got) != len(pks) {
+		t.Fatalf("got %d keys, want %d", len(got), len(pks))
+	}
+	for i, pk := range pks {
+		want, err := BlindPublicKeyWithContext(c, pk, skB, context)
+		if err != nil {
+			t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+		}
+		if !got[i].Equal(want) {
+			t.Fatalf("key %d blinded differently", i)
+		}
+	}
+
+	if got, err := BlindPublicKeys(c, nil, skB); err != nil || len(got) != 0 {
+		t.Errorf("BlindPublicKeys(nil) = %v, %v", got, err)
+	}
+
+	bad := append([]*PublicKey{}, pks...)
+	bad[blindBatchSize+1] = &PublicKey{Curve: c, X: new(big.Int).Set(pks[0].X), Y: new(big.Int).Add(pks[0].Y, one)}
+	if _, err := BlindPublicKeys(c, bad, skB); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("BlindPublicKeys with an invalid key: got %v, want ErrPointNotOnCurve", err)
+	}
+	if _, err := BlindPublicKeys(c, pks, nil); !errors.Is(err, ErrZeroBlind) {
+		t.Errorf("BlindPublicKeys without a blind: got %v, want ErrZeroBlind", err)
+	}
+
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	cancel()
+	if _, err := BlindPublicKeysCtx(ctx, c, pks, skB, context); !errors.Is(err, stdcontext.Canceled) {
+		t.Errorf("cancelled BlindPublicKeysCtx: got %v, want context.Canceled", err)
+	}
+}
+
+func TestSignBatch(t *testing.T) {
+	testAllCurves(t, testSignBatch)
+}
+
+func testSignBatch(t *testing.T, c elliptic.Curve) {
+	priv, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	hashes := make([][]byte, 20)
+	for i := range hashes {
+		hashes[i] = testDigest(fmt.Sprintf("test %d", i%15))
+	}
+	sigs, err := SignBatch(rand.Reader, priv, hashes)
+	if err != nil {
+		t.Fatalf("SignBatch error: %s", err)
+	}
+	if len(sigs) != len(hashes) {
+		t.Fatalf("got %d signatures, want %d", len(sigs), len(hashes))
+	}
+	// Nonces must differ between different hashes. Signatures of the same
+	// hash share their nonce when it is derived deterministically, as in
+	// FIPS mode.
+	seen := make(map[string]string)
+	for i, sig := range sigs {
+		if !Verify(&priv.PublicKey, hashes[i], sig.R, sig.S) {
+			t.Errorf("signature %d failed to verify", i)
+		}
+		if h, ok := seen[sig.R.String()]; ok && (h != string(hashes[i]) || !FIPSMode()) {
+			t.Errorf("signature %d reuses a nonce", i)
+		}
+		seen[sig.R.String()] = string(hashes[i])
+	}
+
+	if sigs, err := SignBatch(rand.Reader, priv, nil); err != nil || len(sigs) != 0 {
+		t.Errorf("SignBatch(nil) = %v, %v", sigs, err)
+	}
+	// FIPS mode ignores rand.
+	if _, err := SignBatch(failingReader{}, priv, hashes); !errors.Is(err, ErrEntropy) && !FIPSMode() {
+		t.Errorf("SignBatch with a failing reader: got %v, want ErrEntropy", err)
+	}
+
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), 0)
+	defer cancel()
+	if _, err := SignBatchCtx(ctx, rand.Reader, priv, hashes); !errors.Is(err, stdcontext.DeadlineExceeded) {
+		t.Errorf("SignBatchCtx past its deadline: got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSignBatchFIPS(t *testing.T) {
+	setFIPSMode(t, true)
+	priv, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	hashes := [][]byte{testDigest("a"), testDigest("b"), testDigest("a")}
+	sigs, err := SignBatch(nil, priv, hashes)
+	if err != nil {
+		t.Fatalf("SignBatch error: %s", err)
+	}
+	for i, hash := range hashes {
+		r, s, err := Sign(nil, priv, hash)
+		if err != nil {
+			t.Fatalf("Sign error: %s", err)
+		}
+		if sigs[i].R.Cmp(r) != 0 || sigs[i].S.Cmp(s) != 0 {
+			t.Errorf("signature %d differs from the RFC 6979 signature", i)
+		}
+	}
+}
+
+func TestBatchInverse(t *testing.T) {
+	c := elliptic.P384()
+	N := c.Params().N
+	ks := make([]*big.Int, 7)
+	for i := range ks {
+		ks[i], _ = randFieldElement(c, rand.Reader)
+	}
+	for i, inv := range batchInverse(c, ks) {
+		if new(big.Int).Mod(new(big.Int).Mul(inv, ks[i]), N).Cmp(one) != 0 {
+			t.Errorf("inverse %d is wrong", i)
+		}
+	}
+}
+
+func BenchmarkSignBatch(b *testing.B) {
+	benchmarkAllCurves(b, func(b *testing.B, curve elliptic.Curve) {
+		priv, _ := GenerateKey(curve, rand.Reader)
+		hashes := make([][]byte, 64)
+		for i := range hashes {
+			hashes[i] = []byte{byte(i)}
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := SignBatch(rand.Reader, priv, hashes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkBlindPublicKeys(b *testing.B) {
+	benchmarkAllCurves(b, func(b *testing.B, curve elliptic.Curve) {
+		skB, _ := GenerateKey(curve, rand.Reader)
+		pks := make([]*PublicKey, 1024)
+		for i := range pks {
+			sk, _ := GenerateKey(curve, rand.Reader)
+			pks[i] = &sk.PublicKey
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := BlindPublicKeys(curve, pks, skB); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
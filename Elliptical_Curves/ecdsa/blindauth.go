@@ -0,0 +1,365 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	blindAuthorizationDST = "ECDSA Authorized Blind Proof"
+
+	// maxBlindSetSize is the maximum number of blinds in a BlindSet.
+	maxBlindSetSize = 0xffff
+)
+
+// BlindSet is a signer's commitment to the blinds it may use with a context:
+// one Pedersen commitment
+//
+//	C_i = b_i * G + r_i * H
+//
+// per permitted blind, where b_i is the scalar that skB_i and the context turn
+// into, as BlindPublicKeyWithContext computes it, G is the base point and H is
+// CommitmentGenerator. Publishing the set bounds the number of blinded keys,
+// that is of pseudonyms, the signer can prove authorized for the context,
+// without revealing the blinds. The curve must be P-256, P-384 or P-521.
+type BlindSet struct {
+	Context     []byte
+	Commitments []*Point
+}
+
+// BlindSetOpening holds the secret blinds and randomness of a BlindSet. It is
+// kept by the signer to produce BlindAuthorizationProofs.
+type BlindSetOpening struct {
+	blinds, randomness []*Scalar
+}
+
+// CommitBlindSet commits to the blinds skB for context, using entropy from
+// rand, and returns the published set and its secret opening.
+func CommitBlindSet(rand io.Reader, c elliptic.Curve, blinds []*PrivateKey, context []byte) (*BlindSet, *BlindSetOpening, error) {
+	if len(blinds) == 0 || len(blinds) > maxBlindSetSize {
+		return nil, nil, wrapError(ErrInvalidCommitment, "set of %d blinds", len(blinds))
+	}
+	set := &BlindSet{Context: append([]byte{}, context...)}
+	opening := &BlindSetOpening{}
+	for _, skB := range blinds {
+		if skB == nil || skB.Curve != c {
+			return nil, nil, wrapError(ErrCurveMismatch, "blind is not on %s", curveName(c))
+		}
+		v, err := hashBlind(c, skB, context)
+		if err != nil {
+			return nil, nil, err
+		}
+		b := &Scalar{c: c, v: v}
+		r, err := randScalar(c, rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		C, err := Commit(b, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		set.Commitments = append(set.Commitments, C)
+		opening.blinds = append(opening.blinds, b)
+		opening.randomness = append(opening.randomness, r)
+	}
+	return set, opening, nil
+}
+
+// Marshal encodes s as:
+//
+//	struct {
+//	  opaque context<0..2^16-1>;
+//	  opaque commitments<0..2^16-1>;
+//	} BlindSet;
+//
+// where commitments holds the compressed encodings of the commitments,
+// concatenated.
+func (s *BlindSet) Marshal() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(s.Context)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, C := range s.Commitments {
+			b.AddBytes(C.BytesCompressed())
+		}
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidCommitment, "blind set too large")
+	}
+	return out, nil
+}
+
+// UnmarshalBlindSet decodes a set of commitments on the curve c encoded by
+// BlindSet.Marshal.
+func UnmarshalBlindSet(c elliptic.Curve, data []byte) (*BlindSet, error) {
+	in := cryptobyte.String(data)
+	var context, commitments cryptobyte.String
+	if !in.ReadUint16LengthPrefixed(&context) || !in.ReadUint16LengthPrefixed(&commitments) || !in.Empty() {
+		return nil, wrapError(ErrInvalidCommitment, "malformed blind set")
+	}
+	pointSize := 1 + coordinateSize(c)
+	if len(commitments) == 0 || len(commitments)%pointSize != 0 {
+		return nil, wrapError(ErrInvalidCommitment, "malformed blind set")
+	}
+	s := &BlindSet{Context: append([]byte{}, context...)}
+	for len(commitments) > 0 {
+		C, err := NewPoint(c, commitments[:pointSize])
+		if err != nil {
+			return nil, err
+		}
+		s.Commitments = append(s.Commitments, C)
+		commitments = commitments[pointSize:]
+	}
+	return s, nil
+}
+
+// BlindAuthorizationProof is a non-interactive zero-knowledge proof that a
+// blinded public key pkR is the public key pkS blinded by one of the blinds
+// committed to in a BlindSet, that is, that for some i
+//
+//	pkR = b * pkS and C_i = b * G + r * H
+//
+// for secret b and r, without revealing i. It is an OR-composition of one
+// Chaum-Pedersen style proof per commitment, in which all but the true one
+// are simulated, and the challenges C sum to the Fiat-Shamir challenge. The
+// proof is bound to a signature under pkR, so it can be exported with the
+// signature to an auditor that knows pkS and the set.
+type BlindAuthorizationProof struct {
+	// C holds the challenge of each commitment of the set, and Zb and Zr the
+	// responses for b and r.
+	C, Zb, Zr []*Scalar
+}
+
+// blindAuthorizationChallenge hashes the statement, the signature and the
+// commitments T1 and T2 of a proof to a scalar, with hash_to_field and the
+// parameters c uses for blinds:
+//
+//	len(pkS) || pkS || len(pkR) || pkR || len(hash) || hash ||
+//	len(sig) || sig || len(set) || set || len(T) || T
+//
+// where keys are compressed points, sig is the raw encoding of r and s, set
+// is the encoding of BlindSet.Marshal, T is T1 and T2 of each commitment,
+// compressed and concatenated, and lengths are 2-byte big-endian integers.
+func blindAuthorizationChallenge(pkS, pkR *Point, hash []byte, sig []byte, set *BlindSet, t1, t2 []*Point) (*Scalar, error) {
+	enc, err := set.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var ts []byte
+	for i := range t1 {
+		ts = append(ts, t1[i].BytesCompressed()...)
+		ts = append(ts, t2[i].BytesCompressed()...)
+	}
+	var b cryptobyte.Builder
+	for _, v := range [][]byte{pkS.BytesCompressed(), pkR.BytesCompressed(), hash, sig, enc, ts} {
+		v := v
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(v)
+		})
+	}
+	transcript, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidProof, "statement too long")
+	}
+	return hashToScalar(pkS.c, transcript, []byte(blindAuthorizationDST))
+}
+
+// blindAuthorizationCommitments returns T1 = Zb * pkS - C * pkR and
+// T2 = Zb * G + Zr * H - C * Ci.
+func blindAuthorizationCommitments(ch, zb, zr *Scalar, pkS, pkR, H, Ci *Point) (*Point, *Point) {
+	c := pkS.Curve()
+	t1 := NewIdentityPoint(c).ScalarMult(zb, pkS)
+	t1.Add(t1, NewIdentityPoint(c).Negate(NewIdentityPoint(c).ScalarMult(ch, pkR)))
+	t2 := NewIdentityPoint(c).ScalarBaseMult(zb)
+	t2.Add(t2, NewIdentityPoint(c).ScalarMult(zr, H))
+	t2.Add(t2, NewIdentityPoint(c).Negate(NewIdentityPoint(c).ScalarMult(ch, Ci)))
+	return t1, t2
+}
+
+// ProveBlindAuthorized proves, using entropy from rand, that the signature r,
+// s of hash verifies under pkR, the public key pkS blinded by one of the
+// blinds committed to in set, whose opening is opening. It returns an error
+// wrapping ErrInvalidSignature if the signature doesn't verify, and
+// ErrInvalidProof if pkR is not blinded by a blind of the set.
+func ProveBlindAuthorized(rand io.Reader, pkS, pkR *PublicKey, hash []byte, r, s *big.Int, set *BlindSet, opening *BlindSetOpening) (*BlindAuthorizationProof, error) {
+	pS, pR, sig, err := blindAuthorizationStatement(pkS, pkR, hash, r, s, set)
+	if err != nil {
+		return nil, err
+	}
+	c := pkS.Curve
+	if opening == nil || len(opening.blinds) != len(set.Commitments) {
+		return nil, wrapError(ErrInvalidProof, "opening does not match the blind set")
+	}
+	H, err := CommitmentGenerator(c)
+	if err != nil {
+		return nil, err
+	}
+
+	pi := -1
+	for i, b := range opening.blinds {
+		if b.c != c {
+			return nil, wrapError(ErrCurveMismatch, "opening is not on %s", curveName(c))
+		}
+		if NewIdentityPoint(c).ScalarMult(b, pS).Equal(pR) == 1 {
+			pi = i
+			break
+		}
+	}
+	if pi < 0 {
+		return nil, wrapError(ErrInvalidProof, "blinded key is not derived with a committed blind")
+	}
+	C, err := Commit(opening.blinds[pi], opening.randomness[pi])
+	if err != nil {
+		return nil, err
+	}
+	if C.Equal(set.Commitments[pi]) != 1 {
+		return nil, wrapError(ErrInvalidProof, "opening does not match the blind set")
+	}
+
+	n := len(set.Commitments)
+	proof := &BlindAuthorizationProof{C: make([]*Scalar, n), Zb: make([]*Scalar, n), Zr: make([]*Scalar, n)}
+	t1, t2 := make([]*Point, n), make([]*Point, n)
+	kb, err := randScalar(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	kr, err := randScalar(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	sum := NewScalar(c)
+	for i := range set.Commitments {
+		if i == pi {
+			t1[i] = NewIdentityPoint(c).ScalarMult(kb, pS)
+			t2[i] = NewIdentityPoint(c).ScalarBaseMult(kb)
+			t2[i].Add(t2[i], NewIdentityPoint(c).ScalarMult(kr, H))
+			continue
+		}
+		for _, v := range []**Scalar{&proof.C[i], &proof.Zb[i], &proof.Zr[i]} {
+			if *v, err = randScalar(c, rand); err != nil {
+				return nil, err
+			}
+		}
+		t1[i], t2[i] = blindAuthorizationCommitments(proof.C[i], proof.Zb[i], proof.Zr[i], pS, pR, H, set.Commitments[i])
+		sum.Add(sum, proof.C[i])
+	}
+	ch, err := blindAuthorizationChallenge(pS, pR, hash, sig, set, t1, t2)
+	if err != nil {
+		return nil, err
+	}
+	proof.C[pi] = NewScalar(c).Subtract(ch, sum)
+	proof.Zb[pi] = NewScalar(c).Add(kb, NewScalar(c).Multiply(proof.C[pi], opening.blinds[pi]))
+	proof.Zr[pi] = NewScalar(c).Add(kr, NewScalar(c).Multiply(proof.C[pi], opening.randomness[pi]))
+	return proof, nil
+}
+
+// blindAuthorizationStatement checks the signature and the set of a proof,
+// and returns the keys as points and the raw encoding of the signature.
+func blindAuthorizationStatement(pkS, pkR *PublicKey, hash []byte, r, s *big.Int, set *BlindSet) (*Point, *Point, []byte, error) {
+	if pkS == nil || pkR == nil || pkS.Curve == nil || pkS.Curve != pkR.Curve {
+		return nil, nil, nil, wrapError(ErrCurveMismatch, "keys on different curves")
+	}
+	c := pkS.Curve
+	pS, err := pkS.Point()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pR, err := pkR.Point()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := CheckSignature(pkR, hash, r, s); err != nil {
+		return nil, nil, nil, err
+	}
+	sig, err := (&Signature{R: r, S: s}).MarshalRaw(c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if set == nil || len(set.Commitments) == 0 || len(set.Commitments) > maxBlindSetSize {
+		return nil, nil, nil, wrapError(ErrInvalidCommitment, "empty blind set")
+	}
+	for _, C := range set.Commitments {
+		if C == nil || C.c != c {
+			return nil, nil, nil, wrapError(ErrCurveMismatch, "blind set is not on %s", curveName(c))
+		}
+	}
+	return pS, pR, sig, nil
+}
+
+// VerifyBlindAuthorized checks that the signature r, s of hash verifies under
+// pkR, and that proof, returned by ProveBlindAuthorized, shows that pkR is
+// pkS blinded by one of the blinds committed to in set. It returns an error
+// wrapping ErrInvalidSignature or ErrInvalidProof if either check fails.
+func VerifyBlindAuthorized(pkS, pkR *PublicKey, hash []byte, r, s *big.Int, set *BlindSet, proof *BlindAuthorizationProof) error {
+	pS, pR, sig, err := blindAuthorizationStatement(pkS, pkR, hash, r, s, set)
+	if err != nil {
+		return err
+	}
+	c := pkS.Curve
+	n := len(set.Commitments)
+	if proof == nil || len(proof.C) != n || len(proof.Zb) != n || len(proof.Zr) != n {
+		return wrapError(ErrInvalidProof, "incomplete proof")
+	}
+	H, err := CommitmentGenerator(c)
+	if err != nil {
+		return err
+	}
+
+	t1, t2 := make([]*Point, n), make([]*Point, n)
+	sum := NewScalar(c)
+	for i, Ci := range set.Commitments {
+		if proof.C[i] == nil || proof.Zb[i] == nil || proof.Zr[i] == nil {
+			return wrapError(ErrInvalidProof, "incomplete proof")
+		}
+		if proof.C[i].c != c || proof.Zb[i].c != c || proof.Zr[i].c != c {
+			return wrapError(ErrCurveMismatch, "proof is not on %s", curveName(c))
+		}
+		t1[i], t2[i] = blindAuthorizationCommitments(proof.C[i], proof.Zb[i], proof.Zr[i], pS, pR, H, Ci)
+		sum.Add(sum, proof.C[i])
+	}
+	ch, err := blindAuthorizationChallenge(pS, pR, hash, sig, set, t1, t2)
+	if err != nil {
+		return err
+	}
+	if ch.Equal(sum) != 1 {
+		return wrapError(ErrInvalidProof, "challenge mismatch")
+	}
+	return nil
+}
+
+// Marshal encodes p as the encodings of C, Zb and Zr for each commitment of
+// the set, in order.
+func (p *BlindAuthorizationProof) Marshal() []byte {
+	var out []byte
+	for i := range p.C {
+		out = append(out, p.C[i].Bytes()...)
+		out = append(out, p.Zb[i].Bytes()...)
+		out = append(out, p.Zr[i].Bytes()...)
+	}
+	return out
+}
+
+// UnmarshalBlindAuthorizationProof decodes a proof for a set of n commitments
+// on the curve c encoded by BlindAuthorizationProof.Marshal.
+func UnmarshalBlindAuthorizationProof(c elliptic.Curve, n int, data []byte) (*BlindAuthorizationProof, error) {
+	size := scalarSize(c)
+	if n <= 0 || n > maxBlindSetSize || len(data) != 3*n*size {
+		return nil, wrapError(ErrInvalidProof, "proof of %d bytes for %d commitments", len(data), n)
+	}
+	p := &BlindAuthorizationProof{C: make([]*Scalar, n), Zb: make([]*Scalar, n), Zr: make([]*Scalar, n)}
+	for i := 0; i < n; i++ {
+		for _, s := range []**Scalar{&p.C[i], &p.Zb[i], &p.Zr[i]} {
+			var err error
+			if *s, err = NewScalar(c).SetBytes(data[:size]); err != nil {
+				return nil, err
+			}
+			data = data[size:]
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,116 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestBlindAuthorized(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		c := c
+		t.Run(c.Params().Name, func(t *testing.T) {
+			t.Parallel()
+			testBlindAuthorized(t, c)
+		})
+	}
+}
+
+func testBlindAuthorized(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	context := []byte("epoch 7")
+	var blinds []*PrivateKey
+	for i := 0; i < 4; i++ {
+		skB, _ := GenerateKey(c, rand.Reader)
+		blinds = append(blinds, skB)
+	}
+	set, opening, err := CommitBlindSet(rand.Reader, c, blinds, context)
+	if err != nil {
+		t.Fatalf("CommitBlindSet error: %s", err)
+	}
+
+	hash := sha256.Sum256([]byte("message"))
+	skB := blinds[2]
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	r, s, err := BlindKeySignWithContext(rand.Reader, skS, skB, hash[:], context)
+	if err != nil {
+		t.Fatalf("BlindKeySignWithContext error: %s", err)
+	}
+
+	proof, err := ProveBlindAuthorized(rand.Reader, &skS.PublicKey, pkR, hash[:], r, s, set, opening)
+	if err != nil {
+		t.Fatalf("ProveBlindAuthorized error: %s", err)
+	}
+	if err := VerifyBlindAuthorized(&skS.PublicKey, pkR, hash[:], r, s, set, proof); err != nil {
+		t.Errorf("VerifyBlindAuthorized error: %s", err)
+	}
+
+	encSet, err := set.Marshal()
+	if err != nil {
+		t.Fatalf("BlindSet.Marshal error: %s", err)
+	}
+	decodedSet, err := UnmarshalBlindSet(c, encSet)
+	if err != nil {
+		t.Fatalf("UnmarshalBlindSet error: %s", err)
+	}
+	decoded, err := UnmarshalBlindAuthorizationProof(c, len(decodedSet.Commitments), proof.Marshal())
+	if err != nil {
+		t.Fatalf("UnmarshalBlindAuthorizationProof error: %s", err)
+	}
+	if err := VerifyBlindAuthorized(&skS.PublicKey, pkR, hash[:], r, s, decodedSet, decoded); err != nil {
+		t.Errorf("VerifyBlindAuthorized of decoded proof error: %s", err)
+	}
+
+	// The proof is bound to the signature.
+	other := sha256.Sum256([]byte("other message"))
+	r2, s2, _ := BlindKeySignWithContext(rand.Reader, skS, skB, other[:], context)
+	if err := VerifyBlindAuthorized(&skS.PublicKey, pkR, other[:], r2, s2, set, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyBlindAuthorized with another signature: got %v, want ErrInvalidProof", err)
+	}
+	if err := VerifyBlindAuthorized(&skS.PublicKey, pkR, other[:], r, s, set, proof); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyBlindAuthorized with another hash: got %v, want ErrInvalidSignature", err)
+	}
+
+	// A proof for another set, context or base key doesn't verify.
+	otherSet, _, _ := CommitBlindSet(rand.Reader, c, blinds, context)
+	if err := VerifyBlindAuthorized(&skS.PublicKey, pkR, hash[:], r, s, otherSet, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyBlindAuthorized with another set: got %v, want ErrInvalidProof", err)
+	}
+	otherKey, _ := GenerateKey(c, rand.Reader)
+	if err := VerifyBlindAuthorized(&otherKey.PublicKey, pkR, hash[:], r, s, set, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyBlindAuthorized with another base key: got %v, want ErrInvalidProof", err)
+	}
+
+	// A key blinded with a blind outside the set can't be proven authorized.
+	unauthorized, _ := GenerateKey(c, rand.Reader)
+	pkU, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, unauthorized, context)
+	rU, sU, _ := BlindKeySignWithContext(rand.Reader, skS, unauthorized, hash[:], context)
+	if _, err := ProveBlindAuthorized(rand.Reader, &skS.PublicKey, pkU, hash[:], rU, sU, set, opening); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("ProveBlindAuthorized with an unauthorized blind: got %v, want ErrInvalidProof", err)
+	}
+	if err := VerifyBlindAuthorized(&skS.PublicKey, pkU, hash[:], rU, sU, set, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyBlindAuthorized with an unauthorized key: got %v, want ErrInvalidProof", err)
+	}
+}
+
+func TestBlindAuthorizedErrors(t *testing.T) {
+	c := elliptic.P256()
+	skB, _ := GenerateKey(c, rand.Reader)
+	if _, _, err := CommitBlindSet(rand.Reader, c, nil, nil); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("CommitBlindSet of no blinds: got %v, want ErrInvalidCommitment", err)
+	}
+	if _, _, err := CommitBlindSet(rand.Reader, elliptic.P384(), []*PrivateKey{skB}, nil); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("CommitBlindSet on another curve: got %v, want ErrCurveMismatch", err)
+	}
+	if _, err := UnmarshalBlindSet(c, []byte{0, 0, 0, 1, 2}); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("UnmarshalBlindSet of a truncated set: got %v, want ErrInvalidCommitment", err)
+	}
+	if _, err := UnmarshalBlindAuthorizationProof(c, 2, make([]byte, 3*32)); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("UnmarshalBlindAuthorizationProof of a short proof: got %v, want ErrInvalidProof", err)
+	}
+}
@@ -0,0 +1,125 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+
+	"github.com/cloudflare/circl/expander"
+	"github.com/cloudflare/circl/xof"
+	_ "golang.org/x/crypto/sha3" // registers the SHA-3 hash functions
+)
+
+// BlindHash selects the hash function that a blind and context are hashed
+// with to derive the blinding scalar, with hash_to_field of RFC 9380.
+// Environments that mandate a hash function, such as CNSA with SHA-384 or
+// SHA-3-only deployments, select it explicitly instead of relying on the
+// hash function registered for the curve.
+//
+// Blinds derived with an explicit BlindHash are domain-separated by the name
+// of the hash function, so a blinded key is bound to the BlindHash it was
+// derived with: the same blind and context derive unrelated keys with
+// different BlindHash values, including BlindHashDefault and the explicit
+// BlindHash of the default hash function of the curve.
+type BlindHash uint8
+
+const (
+	// BlindHashDefault derives blinds with expand_message_xmd and the hash
+	// function registered for the curve with RegisterCurve: SHA-256 for
+	// P-224, P-256 and brainpoolP256r1, SHA-384 for P-384 and
+	// brainpoolP384r1, and SHA-512 for P-521 and brainpoolP512r1. It is the
+	// derivation of the functions that don't take a BlindHash, such as
+	// BlindPublicKeyWithContext.
+	BlindHashDefault BlindHash = iota
+	// BlindHashSHA256, BlindHashSHA384 and BlindHashSHA512 derive blinds
+	// with expand_message_xmd and SHA-2.
+	BlindHashSHA256
+	BlindHashSHA384
+	BlindHashSHA512
+	// BlindHashSHA3_256, BlindHashSHA3_384 and BlindHashSHA3_512 derive
+	// blinds with expand_message_xmd and SHA-3.
+	BlindHashSHA3_256
+	BlindHashSHA3_384
+	BlindHashSHA3_512
+	// BlindHashSHAKE128 and BlindHashSHAKE256 derive blinds with
+	// expand_message_xof and SHAKE, at the 128-bit and 256-bit security
+	// levels.
+	BlindHashSHAKE128
+	BlindHashSHAKE256
+)
+
+// blindDST is the domain separation tag of blinds derived with
+// BlindHashDefault. Explicit hash functions append their name to it.
+const blindDST = "ECDSA Key Blind"
+
+// String returns the name of the hash function, such as "SHA3-256", or
+// "default" for BlindHashDefault.
+func (bh BlindHash) String() string {
+	switch bh {
+	case BlindHashDefault:
+		return "default"
+	case BlindHashSHAKE128:
+		return "SHAKE128"
+	case BlindHashSHAKE256:
+		return "SHAKE256"
+	}
+	if h, ok := bh.hash(); ok {
+		return h.String()
+	}
+	return "unknown"
+}
+
+// ParseBlindHash returns the BlindHash named name by String.
+func ParseBlindHash(name string) (BlindHash, error) {
+	for bh := BlindHashDefault; bh <= BlindHashSHAKE256; bh++ {
+		if bh.String() == name {
+			return bh, nil
+		}
+	}
+	return 0, wrapError(ErrInvalidDigest, "unknown blind hash %q", name)
+}
+
+// hash returns the hash function of an explicit expand_message_xmd
+// BlindHash.
+func (bh BlindHash) hash() (crypto.Hash, bool) {
+	switch bh {
+	case BlindHashSHA256:
+		return crypto.SHA256, true
+	case BlindHashSHA384:
+		return crypto.SHA384, true
+	case BlindHashSHA512:
+		return crypto.SHA512, true
+	case BlindHashSHA3_256:
+		return crypto.SHA3_256, true
+	case BlindHashSHA3_384:
+		return crypto.SHA3_384, true
+	case BlindHashSHA3_512:
+		return crypto.SHA3_512, true
+	}
+	return 0, false
+}
+
+// expander returns the expander that derives blinds on the curve c with bh,
+// and the hash_to_field output length L registered for c.
+func (bh BlindHash) expander(c elliptic.Curve) (expander.Expander, uint, error) {
+	h, L, err := blindParams(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	if bh == BlindHashDefault {
+		return expander.NewExpanderMD(h, []byte(blindDST)), L, nil
+	}
+	dst := []byte(blindDST + " " + bh.String())
+	switch bh {
+	case BlindHashSHAKE128:
+		return expander.NewExpanderXOF(xof.SHAKE128, 128, dst), L, nil
+	case BlindHashSHAKE256:
+		return expander.NewExpanderXOF(xof.SHAKE256, 256, dst), L, nil
+	}
+	if h, ok := bh.hash(); ok {
+		if err := fipsCheckHash(h); err != nil {
+			return nil, 0, err
+		}
+		return expander.NewExpanderMD(h, dst), L, nil
+	}
+	return nil, 0, wrapError(ErrInvalidDigest, "unknown blind hash %d", bh)
+}
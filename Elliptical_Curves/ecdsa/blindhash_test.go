@@ -0,0 +1,106 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+var blindHashes = []BlindHash{
+	BlindHashDefault,
+	BlindHashSHA256, BlindHashSHA384, BlindHashSHA512,
+	BlindHashSHA3_256, BlindHashSHA3_384, BlindHashSHA3_512,
+	BlindHashSHAKE128, BlindHashSHAKE256,
+}
+
+func TestBlindHash(t *testing.T) {
+	testAllCurves(t, testBlindHash)
+}
+
+func testBlindHash(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	digest := sha256.Sum256([]byte("message"))
+
+	seen := make(map[string]BlindHash)
+	for _, bh := range blindHashes {
+		pkR, err := BlindPublicKeyWithHash(c, &skS.PublicKey, skB, context, bh)
+		if err != nil {
+			t.Fatalf("%v: BlindPublicKeyWithHash error: %s", bh, err)
+		}
+		skR, err := BlindPrivateKeyWithHash(skS, skB, context, bh)
+		if err != nil {
+			t.Fatalf("%v: BlindPrivateKeyWithHash error: %s", bh, err)
+		}
+		if !skR.PublicKey.Equal(pkR) {
+			t.Errorf("%v: blinded private key does not match the blinded public key", bh)
+		}
+		r, s, err := BlindKeySignWithHash(rand.Reader, skS, skB, digest[:], context, bh)
+		if err != nil {
+			t.Fatalf("%v: BlindKeySignWithHash error: %s", bh, err)
+		}
+		if !Verify(pkR, digest[:], r, s) {
+			t.Errorf("%v: signature does not verify under the blinded key", bh)
+		}
+		pkS, err := UnblindPublicKeyWithHash(c, pkR, skB, context, bh)
+		if err != nil || !pkS.Equal(&skS.PublicKey) {
+			t.Errorf("%v: UnblindPublicKeyWithHash = %v, want the base key", bh, err)
+		}
+
+		key := hex.EncodeToString(elliptic.MarshalCompressed(c, pkR.X, pkR.Y))
+		if other, ok := seen[key]; ok {
+			t.Errorf("%v and %v derive the same blinded key", bh, other)
+		}
+		seen[key] = bh
+
+		if parsed, err := ParseBlindHash(bh.String()); err != nil || parsed != bh {
+			t.Errorf("ParseBlindHash(%q) = %v, %v", bh.String(), parsed, err)
+		}
+	}
+
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if pkD, _ := BlindPublicKeyWithHash(c, &skS.PublicKey, skB, context, BlindHashDefault); !pkD.Equal(pkR) {
+		t.Errorf("BlindHashDefault does not match BlindPublicKeyWithContext")
+	}
+	if _, err := BlindPublicKeyWithHash(c, &skS.PublicKey, skB, context, BlindHashSHAKE256+1); !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("unknown blind hash: got %v, want ErrInvalidDigest", err)
+	}
+}
+
+// TestBlindHashVectors checks the blinded keys derived with each blind hash
+// from fixed inputs, so that a change of the derivation is detected.
+func TestBlindHashVectors(t *testing.T) {
+	c := elliptic.P256()
+	d, _ := NewScalar(c).SetBytes(append(make([]byte, 31), 2))
+	b, _ := NewScalar(c).SetBytes(append(make([]byte, 31), 3))
+	skS, _ := NewPrivateKey(d)
+	skB, _ := NewPrivateKey(b)
+	for _, tt := range blindHashVectors {
+		pkR, err := BlindPublicKeyWithHash(c, &skS.PublicKey, skB, []byte("context"), tt.bh)
+		if err != nil {
+			t.Fatalf("%v: BlindPublicKeyWithHash error: %s", tt.bh, err)
+		}
+		if got := hex.EncodeToString(elliptic.MarshalCompressed(c, pkR.X, pkR.Y)); got != tt.pkR {
+			t.Errorf("%v: blinded key %s, want %s", tt.bh, got, tt.pkR)
+		}
+	}
+}
+
+var blindHashVectors = []struct {
+	bh  BlindHash
+	pkR string
+}{
+	{BlindHashDefault, "0324d7538dc02eb7ffb47eee9c0c7fdfbdc466d1cacc983346a7a189baa140a36e"},
+	{BlindHashSHA256, "02da6ab60d229fb2bebc9650df725e226a9a0f666f915372144673403f56dc99b5"},
+	{BlindHashSHA384, "02f1f11958c8ac5235bb13896aad6e5b415f5a8d5d746fce83128b3a2c080d5567"},
+	{BlindHashSHA512, "025b37794e4f53bf391fc0d293d2ec4e7ad66874a89b62a59da8a3fbeff293b8f4"},
+	{BlindHashSHA3_256, "0214f2eab8d1359f450e473f7dcd039dd6a6957abfcc7db4093c85a9c300b91e2b"},
+	{BlindHashSHA3_384, "0316817dce951ed88e7a0ce9d2f495ed967861cdd06f1e5f1b08bd06be83815a23"},
+	{BlindHashSHA3_512, "02c52653f652ad0021ef4cbfd2c16824bedc6e257298a4665484c0a3a08db83b8a"},
+	{BlindHashSHAKE128, "03e01144a0d67e5fc2de9ff2e23e5986154b9219018838ad34c1cd3b3d0cbcdae6"},
+	{BlindHashSHAKE256, "03d978179c3459f6f5d093b641cfc9f8534df39686d87c9d671c8d9d88f9656636"},
+}
@@ -0,0 +1,84 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+const boundDST = "ECDSA Key Blind Bound"
+
+// boundDigest binds hash to the public key pub by hashing the encoded key
+// into it with the hash function used for blinding on the curve of pub:
+//
+//	H(len(DST) || DST || len(pub) || pub || hash)
+//
+// where pub is the compressed point encoding and lengths are 2-byte
+// big-endian integers.
+func boundDigest(pub *PublicKey, hash []byte) ([]byte, error) {
+	if err := strictCheckDigest(hash); err != nil {
+		return nil, err
+	}
+	h, _, err := blindParams(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	enc := elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y)
+
+	var length [2]byte
+	md := h.New()
+	binary.BigEndian.PutUint16(length[:], uint16(len(boundDST)))
+	md.Write(length[:])
+	md.Write([]byte(boundDST))
+	binary.BigEndian.PutUint16(length[:], uint16(len(enc)))
+	md.Write(length[:])
+	md.Write(enc)
+	md.Write(hash)
+	return md.Sum(nil), nil
+}
+
+// BlindKeySignBoundWithContext blinds the signing key by a blind, with a
+// context string, and produces a signature over the hashed input bound to the
+// blinded public key.
+//
+// Unlike BlindKeySignWithContext, the signed digest commits to the blinded
+// public key, so a signature can't be transported to a related key, such as
+// the unblinded key or the key under a different blind, by rescaling s. This
+// defeats related-key attacks against the blinded signing oracle. Signatures
+// must be checked with VerifyBound.
+func BlindKeySignBoundWithContext(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte, context []byte) (r, s *big.Int, err error) {
+	skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest, err := boundDigest(&skR.PublicKey, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, s, err = Sign(rand, skR, digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	logIssuance(&skR.PublicKey, hash)
+	return r, s, nil
+}
+
+// BlindKeySignBound blinds the signing key by a blind and empty context
+// string, and produces a signature over the hashed input bound to the blinded
+// public key. Signatures must be checked with VerifyBound.
+func BlindKeySignBound(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	return BlindKeySignBoundWithContext(rand, skS, skB, hash, nil)
+}
+
+// VerifyBound verifies the signature in r, s of hash bound to the public key
+// pub, as produced by BlindKeySignBound. Its return value records whether the
+// signature is valid.
+func VerifyBound(pub *PublicKey, hash []byte, r, s *big.Int) bool {
+	digest, err := boundDigest(pub, hash)
+	if err != nil {
+		return false
+	}
+	return Verify(pub, digest, r, s)
+}
@@ -0,0 +1,69 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestBlindKeySignBound(t *testing.T) {
+	testAllCurves(t, testBlindKeySignBound)
+}
+
+func testBlindKeySignBound(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+
+	hashed := testDigest("testing")
+	r, s, err := BlindKeySignBoundWithContext(rand.Reader, skS, skB, hashed, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignBoundWithContext error: %s", err)
+	}
+
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	if !VerifyBound(pkR, hashed, r, s) {
+		t.Errorf("VerifyBound failed")
+	}
+	if Verify(pkR, hashed, r, s) {
+		t.Errorf("bound signature verified as an unbound signature")
+	}
+
+	hashed[0] ^= 0xff
+	if VerifyBound(pkR, hashed, r, s) {
+		t.Errorf("VerifyBound always works!")
+	}
+}
+
+// The forgery of testRelatedKeySignOracleAttack, applied to bound signatures.
+func TestRelatedKeySignOracleAttackBound(t *testing.T) {
+	testAllCurves(t, testRelatedKeySignOracleAttackBound)
+}
+
+func testRelatedKeySignOracleAttackBound(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	N := c.Params().N
+
+	m := testDigest("m")
+	r, s, err := BlindKeySignBound(rand.Reader, skS, skB, m)
+	if err != nil {
+		t.Fatalf("BlindKeySignBound error: %s", err)
+	}
+
+	// Rescale s by the blind to move the signature back to the unblinded key.
+	blind, err := hashBlind(c, skB, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sForge := new(big.Int).Mul(s, new(big.Int).ModInverse(blind, N))
+	sForge.Mod(sForge, N)
+
+	if VerifyBound(&skS.PublicKey, m, r, sForge) {
+		t.Errorf("VerifyBound accepted a signature transported to the unblinded key")
+	}
+}
@@ -0,0 +1,196 @@
+package ecdsa
+
+import (
+	"context"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+)
+
+// BulkItem is a signature to be checked by a BulkVerifier.
+type BulkItem struct {
+	// PublicKey is the SEC 1 encoding of the public key, compressed or
+	// uncompressed.
+	PublicKey []byte
+	// Hash is the signed digest.
+	Hash []byte
+	// Signature is the ASN.1 encoding of the signature, as produced by
+	// SignASN1.
+	Signature []byte
+}
+
+// BulkVerifier verifies large numbers of signatures, such as the signatures of
+// the blinded keys of an issuer.
+type BulkVerifier interface {
+	// VerifyBulk reports for each of items whether its signature is valid,
+	// as VerifyASN1 would. It returns an error only if the verifier itself
+	// failed, in which case no signature should be accepted, or ctx.Err()
+	// if ctx is done before all the items are verified.
+	VerifyBulk(ctx context.Context, items []BulkItem) ([]bool, error)
+}
+
+// MSM is a multi-scalar multiplication: the sum of Scalars[i] times
+// Points[i].
+type MSM struct {
+	Scalars []*Scalar
+	Points  []*Point
+}
+
+// BulkBackend performs the group operations of the BulkVerifier returned by
+// NewBulkVerifier, so that they can be offloaded to an accelerator, such as a
+// GPU, without changing the API. Parsing, range checks, scalar arithmetic and
+// the final comparisons stay on the CPU.
+//
+// Backends outside this package exchange points through their SEC 1 encoding,
+// with Point.Bytes and NewPoint, and scalars with Scalar.Bytes and
+// Scalar.SetBytes. Backends should return ctx.Err() promptly once ctx is
+// done, so that callers can bound the latency of VerifyBulk.
+type BulkBackend interface {
+	// DecodePoints decodes the SEC 1 encoded public keys on c, compressed
+	// or uncompressed. The point at index i is nil if encoded[i] is not the
+	// encoding of a public key that passes ValidatePublicKey.
+	DecodePoints(ctx context.Context, c elliptic.Curve, encoded [][]byte) ([]*Point, error)
+
+	// MultiScalarMult returns the result of each of msms, whose scalars and
+	// points are all on c.
+	MultiScalarMult(ctx context.Context, c elliptic.Curve, msms []MSM) ([]*Point, error)
+}
+
+// CPUBackend is the reference BulkBackend, which uses the arithmetic of the
+// curves of this package on the CPU.
+type CPUBackend struct{}
+
+// DecodePoints implements BulkBackend.
+func (CPUBackend) DecodePoints(ctx context.Context, c elliptic.Curve, encoded [][]byte) ([]*Point, error) {
+	points := make([]*Point, len(encoded))
+	for i, b := range encoded {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p, err := NewPoint(c, b)
+		if err != nil {
+			continue
+		}
+		pub, err := NewPublicKey(p)
+		if err != nil || ValidatePublicKey(c, pub) != nil {
+			continue
+		}
+		points[i] = p
+	}
+	return points, nil
+}
+
+// MultiScalarMult implements BulkBackend with the package-level
+// MultiScalarMult.
+func (CPUBackend) MultiScalarMult(ctx context.Context, c elliptic.Curve, msms []MSM) ([]*Point, error) {
+	out := make([]*Point, len(msms))
+	for i, m := range msms {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(m.Points) == 0 && len(m.Scalars) == 0 {
+			out[i] = NewIdentityPoint(c)
+			continue
+		}
+		if m.Points[0].c != c {
+			return nil, wrapError(ErrCurveMismatch, "MSM input not on %s", c.Params().Name)
+		}
+		p, err := MultiScalarMult(m.Points, m.Scalars)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = p
+	}
+	return out, nil
+}
+
+type bulkVerifier struct {
+	c       elliptic.Curve
+	backend BulkBackend
+}
+
+// NewBulkVerifier returns a BulkVerifier for signatures on the curve c, which
+// computes its group operations with backend, or with CPUBackend if backend
+// is nil. Each signature costs one point decoding and one multi-scalar
+// multiplication of two terms, and the inversions of all the S values of a
+// call are batched into one.
+func NewBulkVerifier(c elliptic.Curve, backend BulkBackend) (BulkVerifier, error) {
+	if c == nil || c.Params() == nil || c.Params().N.Sign() == 0 {
+		return nil, wrapError(ErrInvalidCurve, "missing curve")
+	}
+	if err := fipsCheckCurve(c); err != nil {
+		return nil, err
+	}
+	if backend == nil {
+		backend = CPUBackend{}
+	}
+	return &bulkVerifier{c: c, backend: backend}, nil
+}
+
+func (bv *bulkVerifier) VerifyBulk(ctx context.Context, items []BulkItem) ([]bool, error) {
+	c := bv.c
+	N := c.Params().N
+	ok := make([]bool, len(items))
+
+	// Parse the signatures, and decode the keys of those in range.
+	var idx []int
+	var rs, ss []*big.Int
+	var keys [][]byte
+	for i, item := range items {
+		r, s, valid := parseASN1Signature(item.Signature)
+		if !valid || strictCheckDigest(item.Hash) != nil || r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+			continue
+		}
+		idx = append(idx, i)
+		rs, ss = append(rs, r), append(ss, s)
+		keys = append(keys, item.PublicKey)
+	}
+	points, err := bv.backend.DecodePoints(ctx, c, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) != len(keys) {
+		return nil, fmt.Errorf("ecdsa: bulk backend decoded %d points, want %d", len(points), len(keys))
+	}
+
+	// Compute u1 = e / s and u2 = r / s, and the point u1 * G + u2 * Q.
+	G := NewGeneratorPoint(c)
+	ws := batchInverse(c, ss)
+	var msms []MSM
+	var pending []int
+	for j, p := range points {
+		if p == nil || p.c != c {
+			continue
+		}
+		e := hashToInt(items[idx[j]].Hash, c)
+		u1 := new(big.Int).Mul(e, ws[j])
+		u2 := new(big.Int).Mul(rs[j], ws[j])
+		msms = append(msms, MSM{
+			Scalars: []*Scalar{{c, u1.Mod(u1, N)}, {c, u2.Mod(u2, N)}},
+			Points:  []*Point{G, p},
+		})
+		pending = append(pending, j)
+	}
+	results, err := bv.backend.MultiScalarMult(ctx, c, msms)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(msms) {
+		return nil, fmt.Errorf("ecdsa: bulk backend returned %d points, want %d", len(results), len(msms))
+	}
+
+	for k, j := range pending {
+		R := results[k]
+		if R == nil || R.IsIdentity() == 1 {
+			continue
+		}
+		x := new(big.Int).Mod(R.x, N)
+		ok[idx[j]] = x.Cmp(rs[j]) == 0
+	}
+	for _, valid := range ok {
+		if !valid {
+			observeVerifyFailure(c)
+		}
+	}
+	return ok, nil
+}
@@ -0,0 +1,133 @@
+package ecdsa
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBulkVerifier(t *testing.T) {
+	testAllCurves(t, testBulkVerifier)
+}
+
+func testBulkVerifier(t *testing.T, c elliptic.Curve) {
+	var items []BulkItem
+	for i := 0; i < 6; i++ {
+		skS, _ := GenerateKey(c, rand.Reader)
+		skB, _ := GenerateKey(c, rand.Reader)
+		skR, err := BlindPrivateKey(skS, skB)
+		if err != nil {
+			t.Fatalf("BlindPrivateKey error: %s", err)
+		}
+		hash := testDigest(fmt.Sprintf("test %d", i))
+		sig, err := SignASN1(rand.Reader, skR, hash)
+		if err != nil {
+			t.Fatalf("SignASN1 error: %s", err)
+		}
+		p, _ := skR.PublicKey.Point()
+		pk := p.Bytes()
+		if i%2 == 1 {
+			pk = p.BytesCompressed()
+		}
+		items = append(items, BulkItem{PublicKey: pk, Hash: hash, Signature: sig})
+	}
+	items[1].Hash = testDigest("other")
+	items[2].Signature = items[3].Signature
+	items[4].PublicKey = []byte{0}
+	items[5].Signature = nil
+	want := []bool{true, false, false, true, false, false}
+
+	bv, err := NewBulkVerifier(c, nil)
+	if err != nil {
+		t.Fatalf("NewBulkVerifier error: %s", err)
+	}
+	got, err := bv.VerifyBulk(context.Background(), items)
+	if err != nil {
+		t.Fatalf("VerifyBulk error: %s", err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("item %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+	if got, err := bv.VerifyBulk(context.Background(), nil); err != nil || len(got) != 0 {
+		t.Errorf("VerifyBulk(nil) = %v, %v", got, err)
+	}
+}
+
+// countingBackend delegates to CPUBackend, counting the operations offloaded
+// to it, or fails if err is set.
+type countingBackend struct {
+	decoded, msms int
+	err           error
+}
+
+func (b *countingBackend) DecodePoints(ctx context.Context, c elliptic.Curve, encoded [][]byte) ([]*Point, error) {
+	b.decoded += len(encoded)
+	return CPUBackend{}.DecodePoints(ctx, c, encoded)
+}
+
+func (b *countingBackend) MultiScalarMult(ctx context.Context, c elliptic.Curve, msms []MSM) ([]*Point, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.msms += len(msms)
+	return CPUBackend{}.MultiScalarMult(ctx, c, msms)
+}
+
+func TestBulkVerifierBackend(t *testing.T) {
+	c := elliptic.P256()
+	priv, _ := GenerateKey(c, rand.Reader)
+	hash := testDigest("testing")
+	sig, _ := SignASN1(rand.Reader, priv, hash)
+	pk := elliptic.Marshal(c, priv.X, priv.Y)
+	items := []BulkItem{{pk, hash, sig}, {pk, hash, sig[:len(sig)-1]}}
+
+	backend := &countingBackend{}
+	bv, _ := NewBulkVerifier(c, backend)
+	got, err := bv.VerifyBulk(context.Background(), items)
+	if err != nil {
+		t.Fatalf("VerifyBulk error: %s", err)
+	}
+	if !got[0] || got[1] {
+		t.Errorf("got %v, want [true false]", got)
+	}
+	if backend.decoded != 1 || backend.msms != 1 {
+		t.Errorf("backend decoded %d points and computed %d MSMs, want 1 and 1", backend.decoded, backend.msms)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := bv.VerifyBulk(ctx, items); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled VerifyBulk: got %v, want context.Canceled", err)
+	}
+
+	backend.err = errors.New("device lost")
+	if _, err := bv.VerifyBulk(context.Background(), items); err != backend.err {
+		t.Errorf("backend failure: got %v, want %v", err, backend.err)
+	}
+}
+
+func BenchmarkBulkVerifier(b *testing.B) {
+	benchmarkAllCurves(b, func(b *testing.B, curve elliptic.Curve) {
+		items := make([]BulkItem, 64)
+		for i := range items {
+			priv, _ := GenerateKey(curve, rand.Reader)
+			hash := []byte{byte(i)}
+			sig, _ := SignASN1(rand.Reader, priv, hash)
+			items[i] = BulkItem{elliptic.MarshalCompressed(curve, priv.X, priv.Y), hash, sig}
+		}
+		bv, _ := NewBulkVerifier(curve, nil)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := bv.VerifyBulk(context.Background(), items); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+)
+
+// The functions of this file take and return keys and signatures as byte
+// slices of a fixed length for each curve, rather than big.Int values, so
+// that there is a single valid encoding of each value, and so that they can
+// be exposed through cgo or RPC without a big integer type:
+//
+//   - private keys and blinds are scalars, encoded in big-endian order on
+//     exactly PrivateKeySize bytes, and must be in the range [1, N-1];
+//   - public keys are points, in the uncompressed SEC 1 encoding of exactly
+//     PublicKeySize bytes;
+//   - signatures are R and S concatenated, each encoded as a scalar, on
+//     exactly SignatureSize bytes, as returned by Signature.MarshalRaw.
+//
+// Digests must be the output of a hash function, as with SignDigest.
+
+// PrivateKeySize returns the length in bytes of the private keys and blinds
+// of c.
+func PrivateKeySize(c elliptic.Curve) int {
+	return scalarSize(c)
+}
+
+// PublicKeySize returns the length in bytes of the public keys of c.
+func PublicKeySize(c elliptic.Curve) int {
+	return 1 + 2*((c.Params().BitSize+7)/8)
+}
+
+// SignatureSize returns the length in bytes of the signatures of c.
+func SignatureSize(c elliptic.Curve) int {
+	return 2 * scalarSize(c)
+}
+
+// parsePrivateKeyBytes decodes a private key or blind of c.
+func parsePrivateKeyBytes(c elliptic.Curve, b []byte) (*PrivateKey, error) {
+	if c == nil || c.Params() == nil {
+		return nil, wrapError(ErrInvalidCurve, "missing curve")
+	}
+	d, err := NewScalar(c).SetBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(d)
+}
+
+// parsePublicKeyBytes decodes a public key of c.
+func parsePublicKeyBytes(c elliptic.Curve, b []byte) (*PublicKey, error) {
+	if c == nil || c.Params() == nil {
+		return nil, wrapError(ErrInvalidCurve, "missing curve")
+	}
+	if len(b) != PublicKeySize(c) || b[0] != 4 {
+		return nil, wrapError(ErrPointNotOnCurve, "public key is not a %d-byte uncompressed point", PublicKeySize(c))
+	}
+	p, err := NewPoint(c, b)
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKey(p)
+}
+
+// GenerateKeyBytes generates a key pair on c using entropy from rand.
+func GenerateKeyBytes(c elliptic.Curve, rand io.Reader) (publicKey, privateKey []byte, err error) {
+	priv, err := GenerateKey(c, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := priv.Scalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	return elliptic.Marshal(c, priv.X, priv.Y), d.Bytes(), nil
+}
+
+// PublicKeyBytes returns the public key of the private key privateKey of c.
+func PublicKeyBytes(c elliptic.Curve, privateKey []byte) ([]byte, error) {
+	priv, err := parsePrivateKeyBytes(c, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.Marshal(c, priv.X, priv.Y), nil
+}
+
+// BlindPublicKeyBytes blinds the public key publicKey of c by blind and
+// context, as BlindPublicKeyWithContext does.
+func BlindPublicKeyBytes(c elliptic.Curve, publicKey, blind, context []byte) ([]byte, error) {
+	pk, err := parsePublicKeyBytes(c, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	bk, err := parsePrivateKeyBytes(c, blind)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := BlindPublicKeyWithContext(c, pk, bk, context)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.Marshal(c, pkR.X, pkR.Y), nil
+}
+
+// UnblindPublicKeyBytes reverses BlindPublicKeyBytes with the same blind and
+// context.
+func UnblindPublicKeyBytes(c elliptic.Curve, publicKey, blind, context []byte) ([]byte, error) {
+	pkR, err := parsePublicKeyBytes(c, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	bk, err := parsePrivateKeyBytes(c, blind)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := UnblindPublicKeyWithContext(c, pkR, bk, context)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.Marshal(c, pk.X, pk.Y), nil
+}
+
+// SignBytes signs digest with the private key privateKey of c, as SignDigest
+// does.
+func SignBytes(rand io.Reader, c elliptic.Curve, privateKey, digest []byte) ([]byte, error) {
+	priv, err := parsePrivateKeyBytes(c, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := SignDigest(rand, priv, digest)
+	if err != nil {
+		return nil, err
+	}
+	return (&Signature{R: r, S: s}).MarshalRaw(c)
+}
+
+// BlindKeySignBytes signs digest with the private key privateKey of c blinded
+// by blind and context, as BlindKeySignDigest does. The signature verifies
+// under the public key returned by BlindPublicKeyBytes.
+func BlindKeySignBytes(rand io.Reader, c elliptic.Curve, privateKey, blind, digest, context []byte) ([]byte, error) {
+	skS, err := parsePrivateKeyBytes(c, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	skB, err := parsePrivateKeyBytes(c, blind)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := BlindKeySignDigest(rand, skS, skB, digest, context)
+	if err != nil {
+		return nil, err
+	}
+	return (&Signature{R: r, S: s}).MarshalRaw(c)
+}
+
+// VerifyBytes reports whether signature is a valid signature of digest under
+// the public key publicKey of c. It returns false for malformed inputs.
+func VerifyBytes(c elliptic.Curve, publicKey, digest, signature []byte) bool {
+	pub, err := parsePublicKeyBytes(c, publicKey)
+	if err != nil {
+		return false
+	}
+	sig, err := UnmarshalRawSignature(c, signature)
+	if err != nil {
+		return false
+	}
+	return VerifyDigest(pub, digest, sig.R, sig.S)
+}
@@ -0,0 +1,69 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestSignBytes(t *testing.T) {
+	testAllCurves(t, testSignBytes)
+}
+
+func testSignBytes(t *testing.T, c elliptic.Curve) {
+	pk, sk, err := GenerateKeyBytes(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyBytes error: %s", err)
+	}
+	if len(pk) != PublicKeySize(c) || len(sk) != PrivateKeySize(c) {
+		t.Fatalf("got %d-byte public key and %d-byte private key, want %d and %d", len(pk), len(sk), PublicKeySize(c), PrivateKeySize(c))
+	}
+	if got, err := PublicKeyBytes(c, sk); err != nil || string(got) != string(pk) {
+		t.Errorf("PublicKeyBytes = %x, %v, want %x", got, err, pk)
+	}
+	_, blind, _ := GenerateKeyBytes(c, rand.Reader)
+	context := []byte("context")
+	pkR, err := BlindPublicKeyBytes(c, pk, blind, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyBytes error: %s", err)
+	}
+	if got, err := UnblindPublicKeyBytes(c, pkR, blind, context); err != nil || string(got) != string(pk) {
+		t.Errorf("UnblindPublicKeyBytes = %x, %v, want %x", got, err, pk)
+	}
+
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := SignBytes(rand.Reader, c, sk, digest[:])
+	if err != nil {
+		t.Fatalf("SignBytes error: %s", err)
+	}
+	if len(sig) != SignatureSize(c) || !VerifyBytes(c, pk, digest[:], sig) {
+		t.Errorf("SignBytes returned a %d-byte signature that does not verify", len(sig))
+	}
+	sig, err = BlindKeySignBytes(rand.Reader, c, sk, blind, digest[:], context)
+	if err != nil {
+		t.Fatalf("BlindKeySignBytes error: %s", err)
+	}
+	if !VerifyBytes(c, pkR, digest[:], sig) || VerifyBytes(c, pk, digest[:], sig) {
+		t.Errorf("blinded signature verifies under the wrong key")
+	}
+
+	// Each value has a single valid encoding.
+	if VerifyBytes(c, pkR, digest[:], append([]byte{0}, sig...)) {
+		t.Errorf("VerifyBytes accepted a padded signature")
+	}
+	p, _ := NewPoint(c, pkR)
+	if VerifyBytes(c, p.BytesCompressed(), digest[:], sig) {
+		t.Errorf("VerifyBytes accepted a compressed public key")
+	}
+	if _, err := SignBytes(rand.Reader, c, sk[1:], digest[:]); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("SignBytes with a short key: got %v, want ErrInvalidScalar", err)
+	}
+	if _, err := SignBytes(rand.Reader, c, c.Params().N.FillBytes(make([]byte, PrivateKeySize(c))), digest[:]); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("SignBytes with an unreduced key: got %v, want ErrInvalidScalar", err)
+	}
+	if _, err := BlindPublicKeyBytes(c, make([]byte, PublicKeySize(c)), blind, context); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("BlindPublicKeyBytes of a zero key: got %v, want ErrPointNotOnCurve", err)
+	}
+}
@@ -0,0 +1,219 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Key type and curve identifiers of the IANA COSE registries.
+const (
+	coseKeyTypeEC2 = 2
+
+	coseCurveP256            = 1
+	coseCurveP384            = 2
+	coseCurveP521            = 3
+	coseCurveBrainpoolP256r1 = 256
+	coseCurveBrainpoolP384r1 = 258
+	coseCurveBrainpoolP512r1 = 259
+)
+
+var coseCurves = map[int]func() elliptic.Curve{
+	coseCurveP256:            elliptic.P256,
+	coseCurveP384:            elliptic.P384,
+	coseCurveP521:            elliptic.P521,
+	coseCurveBrainpoolP256r1: brainpool.P256r1,
+	coseCurveBrainpoolP384r1: brainpool.P384r1,
+	coseCurveBrainpoolP512r1: brainpool.P512r1,
+}
+
+func coseCurveID(c elliptic.Curve) (int, error) {
+	for id, curve := range coseCurves {
+		if curve().Params() == c.Params() {
+			return id, nil
+		}
+	}
+	return 0, wrapError(ErrInvalidCurve, "no COSE identifier for %s", c.Params().Name)
+}
+
+// coseKey is an EC2 COSE_Key, as specified in RFC 9053, section 7.1.1.
+type coseKey struct {
+	Kty int    `cbor:"1,keyasint"`
+	Crv int    `cbor:"-1,keyasint"`
+	X   []byte `cbor:"-2,keyasint"`
+	Y   []byte `cbor:"-3,keyasint"`
+	D   []byte `cbor:"-4,keyasint,omitempty"`
+}
+
+// cborSignature is a Signature as an array of the big-endian encodings of R
+// and S, without leading zeros.
+type cborSignature struct {
+	_ struct{} `cbor:",toarray"`
+	R []byte
+	S []byte
+}
+
+var (
+	cborEncMode cbor.EncMode
+	cborDecMode cbor.DecMode
+)
+
+func init() {
+	var err error
+	if cborEncMode, err = cbor.CoreDetEncOptions().EncMode(); err != nil {
+		panic(err)
+	}
+	cborDecMode, err = cbor.DecOptions{
+		DupMapKey:         cbor.DupMapKeyEnforcedAPF,
+		IndefLength:       cbor.IndefLengthForbidden,
+		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// cborUnmarshal decodes data into v, and checks that data is the
+// deterministic encoding of v, so that each value has a single encoding.
+func cborUnmarshal(data []byte, v interface{}) bool {
+	if err := cborDecMode.Unmarshal(data, v); err != nil {
+		return false
+	}
+	enc, err := cborEncMode.Marshal(v)
+	return err == nil && bytes.Equal(enc, data)
+}
+
+func coordinateSize(c elliptic.Curve) int {
+	return (c.Params().BitSize + 7) / 8
+}
+
+func newCOSEKey(pub *PublicKey) (*coseKey, error) {
+	crv, err := coseCurveID(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	size := coordinateSize(pub.Curve)
+	return &coseKey{
+		Kty: coseKeyTypeEC2,
+		Crv: crv,
+		X:   pub.X.FillBytes(make([]byte, size)),
+		Y:   pub.Y.FillBytes(make([]byte, size)),
+	}, nil
+}
+
+// publicKey returns the public key of k, or an error if it is not a valid
+// key.
+func (k *coseKey) publicKey() (*PublicKey, error) {
+	if k.Kty != coseKeyTypeEC2 {
+		return nil, wrapError(ErrInvalidCurve, "COSE key type %d", k.Kty)
+	}
+	curve, ok := coseCurves[k.Crv]
+	if !ok {
+		return nil, wrapError(ErrInvalidCurve, "unknown COSE curve %d", k.Crv)
+	}
+	c := curve()
+	size := coordinateSize(c)
+	if len(k.X) != size || len(k.Y) != size {
+		return nil, wrapError(ErrPointNotOnCurve, "malformed COSE key")
+	}
+	pub := &PublicKey{c, new(big.Int).SetBytes(k.X), new(big.Int).SetBytes(k.Y)}
+	if err := ValidatePublicKey(c, pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// MarshalCBOR encodes pub as an EC2 COSE_Key (RFC 9052, section 7) with the
+// deterministic encoding of RFC 8949, section 4.2.1. Blinded public keys are
+// encoded like any other key. It returns an error wrapping ErrInvalidCurve
+// for curves without a COSE identifier, such as P-224.
+func (pub *PublicKey) MarshalCBOR() ([]byte, error) {
+	k, err := newCOSEKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return cborEncMode.Marshal(k)
+}
+
+// UnmarshalCBOR decodes a public key encoded by MarshalCBOR, and validates
+// it with ValidatePublicKey. It only accepts the deterministic encoding.
+func (pub *PublicKey) UnmarshalCBOR(data []byte) error {
+	var k coseKey
+	if !cborUnmarshal(data, &k) || k.D != nil {
+		return wrapError(ErrPointNotOnCurve, "malformed COSE key")
+	}
+	p, err := k.publicKey()
+	if err != nil {
+		return err
+	}
+	*pub = *p
+	return nil
+}
+
+// MarshalCBOR encodes priv as an EC2 COSE_Key with its private key, like
+// PublicKey.MarshalCBOR.
+func (priv *PrivateKey) MarshalCBOR() ([]byte, error) {
+	k, err := newCOSEKey(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	k.D = priv.D.FillBytes(make([]byte, scalarSize(priv.Curve)))
+	return cborEncMode.Marshal(k)
+}
+
+// UnmarshalCBOR decodes a private key encoded by MarshalCBOR, and checks
+// that its public key matches its private key. It only accepts the
+// deterministic encoding.
+func (priv *PrivateKey) UnmarshalCBOR(data []byte) error {
+	var k coseKey
+	if !cborUnmarshal(data, &k) || k.D == nil {
+		return wrapError(ErrInvalidScalar, "malformed COSE key")
+	}
+	pub, err := k.publicKey()
+	if err != nil {
+		return err
+	}
+	d, err := NewScalar(pub.Curve).SetBytes(k.D)
+	if err != nil {
+		return err
+	}
+	p, err := NewPrivateKey(d)
+	if err != nil {
+		return err
+	}
+	if !p.PublicKey.Equal(pub) {
+		return wrapError(ErrInvalidScalar, "private key does not match public key")
+	}
+	*priv = *p
+	return nil
+}
+
+// MarshalCBOR encodes sig as an array of two byte strings, the big-endian
+// encodings of R and S without leading zeros, with the deterministic encoding
+// of RFC 8949, section 4.2.1. Unlike the signatures of COSE_Sign1, which
+// MarshalRaw produces, the encoding doesn't depend on the curve.
+func (sig *Signature) MarshalCBOR() ([]byte, error) {
+	if sig.R == nil || sig.S == nil || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return nil, wrapError(ErrInvalidSignature, "incomplete signature")
+	}
+	return cborEncMode.Marshal(&cborSignature{R: sig.R.Bytes(), S: sig.S.Bytes()})
+}
+
+// UnmarshalCBOR decodes a signature encoded by MarshalCBOR. It only accepts
+// the deterministic encoding and positive values without leading zeros.
+func (sig *Signature) UnmarshalCBOR(data []byte) error {
+	var s cborSignature
+	if !cborUnmarshal(data, &s) || !minimalPositive(s.R) || !minimalPositive(s.S) {
+		return wrapError(ErrInvalidSignature, "malformed signature")
+	}
+	sig.R = new(big.Int).SetBytes(s.R)
+	sig.S = new(big.Int).SetBytes(s.S)
+	return nil
+}
+
+func minimalPositive(b []byte) bool {
+	return len(b) > 0 && b[0] != 0
+}
@@ -0,0 +1,127 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestCBOR(t *testing.T) {
+	testAllCurves(t, testCBOR)
+}
+
+func testCBOR(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	pkR, _ := BlindPublicKey(c, &skS.PublicKey, skB)
+	hashed := testDigest("testing")
+	r, s, _ := BlindKeySign(rand.Reader, skS, skB, hashed)
+
+	if _, err := coseCurveID(c); err != nil {
+		// P-224 and registered curves have no COSE identifier.
+		if _, err := pkR.MarshalCBOR(); !errors.Is(err, ErrInvalidCurve) {
+			t.Errorf("MarshalCBOR on %s: got %v, want ErrInvalidCurve", c.Params().Name, err)
+		}
+		return
+	}
+
+	enc, err := cbor.Marshal(pkR)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	pub := new(PublicKey)
+	if err := cbor.Unmarshal(enc, pub); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if !pub.Equal(pkR) {
+		t.Errorf("decoded public key does not match")
+	}
+	if err := new(PrivateKey).UnmarshalCBOR(enc); err == nil {
+		t.Errorf("public key decoded as a private key")
+	}
+
+	enc, err = cbor.Marshal(skS)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	priv := new(PrivateKey)
+	if err := cbor.Unmarshal(enc, priv); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if !priv.Equal(skS) {
+		t.Errorf("decoded private key does not match")
+	}
+	if err := new(PublicKey).UnmarshalCBOR(enc); err == nil {
+		t.Errorf("private key decoded as a public key")
+	}
+
+	enc, err = cbor.Marshal(&Signature{R: r, S: s})
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	sig := new(Signature)
+	if err := cbor.Unmarshal(enc, sig); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if !Verify(pkR, hashed, sig.R, sig.S) {
+		t.Errorf("Verify of a decoded signature failed")
+	}
+}
+
+func TestCBORCanonical(t *testing.T) {
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	enc, _ := sk.PublicKey.MarshalCBOR()
+	// A map of 4 entries, with the key type first.
+	if !bytes.HasPrefix(enc, []byte{0xa4, 0x01, 0x02, 0x20, 0x01}) {
+		t.Errorf("unexpected encoding %x", enc)
+	}
+
+	// The same key with its map entries in another order.
+	var m map[int]interface{}
+	if err := cbor.Unmarshal(enc, &m); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	reordered, _ := cbor.EncOptions{Sort: cbor.SortNone}.EncMode()
+	for i := 0; i < 10; i++ {
+		other, _ := reordered.Marshal(m)
+		if bytes.Equal(other, enc) {
+			continue
+		}
+		if err := new(PublicKey).UnmarshalCBOR(other); !errors.Is(err, ErrPointNotOnCurve) {
+			t.Errorf("UnmarshalCBOR of a non-canonical key: got %v, want ErrPointNotOnCurve", err)
+		}
+		break
+	}
+
+	// An unknown curve.
+	m[-1] = 4
+	unknown, _ := cborEncMode.Marshal(m)
+	if err := new(PublicKey).UnmarshalCBOR(unknown); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("UnmarshalCBOR with an unknown curve: got %v, want ErrInvalidCurve", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		enc  string
+	}{
+		{"leading zero", "824200014101"},
+		{"zero", "82404101"},
+		{"indefinite length", "9f41014101ff"},
+		{"not an array", "a0"},
+	} {
+		data, _ := hex.DecodeString(test.enc)
+		if err := new(Signature).UnmarshalCBOR(data); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("UnmarshalCBOR of %s: got %v, want ErrInvalidSignature", test.name, err)
+		}
+	}
+	enc, _ = (&Signature{R: big.NewInt(1), S: big.NewInt(256)}).MarshalCBOR()
+	if want := "824101420100"; hex.EncodeToString(enc) != want {
+		t.Errorf("got %x, want %s", enc, want)
+	}
+}
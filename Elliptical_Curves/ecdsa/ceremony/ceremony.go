@@ -0,0 +1,488 @@
+// Package ceremony generates a base signing key in a key ceremony, from
+// entropy contributed by several participants, so that the key is uniformly
+// random as long as one of them contributed uniformly random entropy.
+//
+// The ceremony runs in two rounds. Each participant first draws its entropy
+// with NewContribution and broadcasts its commitment; once all commitments
+// are in, each participant reveals its entropy, which is checked against its
+// commitment. Since no participant sees any entropy before committing to its
+// own, none can bias the key. The key is then derived with
+// ecdsa.GenerateKeyFromSeed from the combined seed, the hash of all the
+// entropy, so every participant that saw the reveals derives the same key.
+//
+// The ceremony is recorded in a Transcript, which holds the commitments and
+// the public key, but not the entropy, and is signed by every participant
+// with a long-term identity key after checking that it derived the same
+// public key. The entropy must be disclosed only to the custodians of the
+// key: anyone holding all of it can derive the private key.
+package ceremony
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	transcriptVersion = 1
+	commitmentDST     = "ECDSA Ceremony Commitment v1"
+	seedDST           = "ECDSA Ceremony Seed v1"
+	transcriptDST     = "ECDSA Ceremony Transcript v1"
+
+	// EntropySize is the size, in bytes, of the entropy of a contribution.
+	EntropySize = 32
+)
+
+var (
+	// ErrInvalidParticipant is returned for an unknown or duplicate
+	// participant.
+	ErrInvalidParticipant = errors.New("ceremony: invalid participant")
+
+	// ErrOutOfOrder is returned when a step of the ceremony is taken before
+	// the previous one is complete, or repeated.
+	ErrOutOfOrder = errors.New("ceremony: step out of order")
+
+	// ErrInvalidCommitment is returned when revealed entropy doesn't match
+	// its commitment.
+	ErrInvalidCommitment = errors.New("ceremony: entropy does not match commitment")
+
+	// ErrInvalidTranscript is returned when a transcript is badly encoded or
+	// is missing a valid signature of a participant.
+	ErrInvalidTranscript = errors.New("ceremony: invalid transcript")
+)
+
+// Participant is a participant of a ceremony, identified by its name and the
+// identity key it signs the transcript with.
+type Participant struct {
+	Name      string
+	PublicKey *ecdsa.PublicKey
+}
+
+// Contribution is the secret entropy of a participant.
+type Contribution struct {
+	name    string
+	entropy []byte
+}
+
+// NewContribution draws EntropySize bytes of entropy from rand for the
+// participant name.
+func NewContribution(rand io.Reader, name string) (*Contribution, error) {
+	entropy := make([]byte, EntropySize)
+	if _, err := io.ReadFull(rand, entropy); err != nil {
+		return nil, fmt.Errorf("ceremony: reading entropy: %w", err)
+	}
+	return &Contribution{name: name, entropy: entropy}, nil
+}
+
+// Entropy returns the entropy of c, which is revealed in the second round.
+func (c *Contribution) Entropy() []byte {
+	return append([]byte{}, c.entropy...)
+}
+
+// Commitment returns the commitment to c for the ceremony label, which is
+// broadcast in the first round.
+func (c *Contribution) Commitment(label string) []byte {
+	return commitment(label, c.name, c.entropy)
+}
+
+// commitment returns SHA-256 of the domain separation tag "ECDSA Ceremony
+// Commitment v1", the label of the ceremony, the name of the participant and
+// its entropy, each prefixed by its 2-byte length.
+func commitment(label, name string, entropy []byte) []byte {
+	h := sha256.New()
+	for _, b := range [][]byte{[]byte(commitmentDST), []byte(label), []byte(name), entropy} {
+		h.Write([]byte{byte(len(b) >> 8), byte(len(b))})
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// Ceremony is the state of a ceremony, as seen by one participant or by a
+// coordinator. Each participant should run its own Ceremony, fed with the
+// broadcast messages, rather than trust the coordinator's.
+type Ceremony struct {
+	curve        elliptic.Curve
+	label        string
+	participants []Participant
+	index        map[string]int
+	commitments  [][]byte
+	entropy      [][]byte
+	committed    int
+	revealed     int
+}
+
+// New returns the ceremony label generating a key on the curve c with the
+// given participants, whose names must be distinct. The label should be
+// unique to the ceremony.
+func New(c elliptic.Curve, label string, participants []Participant) (*Ceremony, error) {
+	if _, err := ecdsa.CurveByName(c.Params().Name); err != nil {
+		return nil, err
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("%w: no participants", ErrInvalidParticipant)
+	}
+	cer := &Ceremony{
+		curve:        c,
+		label:        label,
+		participants: append([]Participant{}, participants...),
+		index:        make(map[string]int, len(participants)),
+		commitments:  make([][]byte, len(participants)),
+		entropy:      make([][]byte, len(participants)),
+	}
+	for i, p := range participants {
+		if _, ok := cer.index[p.Name]; ok || p.Name == "" || p.PublicKey == nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidParticipant, p.Name)
+		}
+		cer.index[p.Name] = i
+	}
+	return cer, nil
+}
+
+func (c *Ceremony) participant(name string) (int, error) {
+	i, ok := c.index[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown participant %q", ErrInvalidParticipant, name)
+	}
+	return i, nil
+}
+
+// Commit records the commitment of the participant name, in the first
+// round.
+func (c *Ceremony) Commit(name string, commitment []byte) error {
+	i, err := c.participant(name)
+	if err != nil {
+		return err
+	}
+	if c.commitments[i] != nil {
+		return fmt.Errorf("%w: %q already committed", ErrOutOfOrder, name)
+	}
+	if len(commitment) != sha256.Size {
+		return fmt.Errorf("%w: commitment of %d bytes", ErrInvalidCommitment, len(commitment))
+	}
+	c.commitments[i] = append([]byte{}, commitment...)
+	c.committed++
+	return nil
+}
+
+// Reveal records the entropy of the participant name, in the second round,
+// after checking it against its commitment. It returns an error wrapping
+// ErrOutOfOrder if some participants haven't committed yet.
+func (c *Ceremony) Reveal(name string, entropy []byte) error {
+	i, err := c.participant(name)
+	if err != nil {
+		return err
+	}
+	if c.committed < len(c.participants) {
+		return fmt.Errorf("%w: %d of %d participants committed", ErrOutOfOrder, c.committed, len(c.participants))
+	}
+	if c.entropy[i] != nil {
+		return fmt.Errorf("%w: %q already revealed", ErrOutOfOrder, name)
+	}
+	if len(entropy) != EntropySize || subtle.ConstantTimeCompare(commitment(c.label, name, entropy), c.commitments[i]) != 1 {
+		return fmt.Errorf("%w: participant %q", ErrInvalidCommitment, name)
+	}
+	c.entropy[i] = append([]byte{}, entropy...)
+	c.revealed++
+	return nil
+}
+
+// seed returns SHA-512 of the domain separation tag "ECDSA Ceremony Seed v1",
+// the label, the name of the curve and the name and entropy of each
+// participant in order, each prefixed by its 2-byte length.
+func (c *Ceremony) seed() []byte {
+	h := sha512.New()
+	write := func(b []byte) {
+		h.Write([]byte{byte(len(b) >> 8), byte(len(b))})
+		h.Write(b)
+	}
+	write([]byte(seedDST))
+	write([]byte(c.label))
+	write([]byte(c.curve.Params().Name))
+	for i, p := range c.participants {
+		write([]byte(p.Name))
+		write(c.entropy[i])
+	}
+	return h.Sum(nil)
+}
+
+// Finish derives the key of the ceremony from the combined seed, and returns
+// it with the unsigned transcript of the ceremony. It returns an error
+// wrapping ErrOutOfOrder if some participants haven't revealed their
+// entropy yet.
+func (c *Ceremony) Finish() (*ecdsa.PrivateKey, *Transcript, error) {
+	if c.revealed < len(c.participants) {
+		return nil, nil, fmt.Errorf("%w: %d of %d participants revealed", ErrOutOfOrder, c.revealed, len(c.participants))
+	}
+	priv, err := ecdsa.GenerateKeyFromSeed(c.curve, c.seed())
+	if err != nil {
+		return nil, nil, err
+	}
+	t := &Transcript{
+		Curve:        c.curve,
+		Label:        c.label,
+		Participants: append([]Participant{}, c.participants...),
+		Commitments:  append([][]byte{}, c.commitments...),
+		PublicKey:    &priv.PublicKey,
+		Signatures:   make([][]byte, len(c.participants)),
+	}
+	return priv, t, nil
+}
+
+// Transcript is the public record of a ceremony.
+type Transcript struct {
+	Curve        elliptic.Curve
+	Label        string
+	Participants []Participant
+	// Commitments holds the commitment of each participant, in order.
+	Commitments [][]byte
+	// PublicKey is the generated public key.
+	PublicKey *ecdsa.PublicKey
+	// Signatures holds the ASN.1 signature of each participant over the
+	// transcript, or nil if it hasn't signed yet.
+	Signatures [][]byte
+}
+
+// body encodes t without its signatures.
+func (t *Transcript) body() ([]byte, error) {
+	if len(t.Commitments) != len(t.Participants) || len(t.Signatures) != len(t.Participants) {
+		return nil, fmt.Errorf("%w: %d participants, %d commitments and %d signatures", ErrInvalidTranscript, len(t.Participants), len(t.Commitments), len(t.Signatures))
+	}
+	if t.PublicKey == nil || t.PublicKey.Curve != t.Curve {
+		return nil, fmt.Errorf("%w: missing public key", ErrInvalidTranscript)
+	}
+	var b cryptobyte.Builder
+	b.AddUint8(transcriptVersion)
+	addBytes8(&b, []byte(t.Curve.Params().Name))
+	addBytes16(&b, []byte(t.Label))
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for i, p := range t.Participants {
+			addBytes8(b, []byte(p.Name))
+			addPublicKey(b, p.PublicKey)
+			addBytes8(b, t.Commitments[i])
+		}
+	})
+	addPublicKey(&b, t.PublicKey)
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTranscript, err)
+	}
+	return body, nil
+}
+
+// digest returns the digest of t signed by the participants: SHA-256 of the
+// domain separation tag "ECDSA Ceremony Transcript v1" followed by the body.
+func (t *Transcript) digest() ([]byte, error) {
+	body, err := t.body()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write([]byte(transcriptDST))
+	h.Write(body)
+	return h.Sum(nil), nil
+}
+
+// Sign adds the signature of the participant name, with its identity key
+// priv, to t. Participants should only sign a transcript after checking that
+// its public key is the one their own Ceremony derived.
+func (t *Transcript) Sign(rand io.Reader, name string, priv *ecdsa.PrivateKey) error {
+	i := -1
+	for j, p := range t.Participants {
+		if p.Name == name {
+			i = j
+		}
+	}
+	if i < 0 || !t.Participants[i].PublicKey.Equal(&priv.PublicKey) {
+		return fmt.Errorf("%w: %q with this key", ErrInvalidParticipant, name)
+	}
+	digest, err := t.digest()
+	if err != nil {
+		return err
+	}
+	sig, err := priv.Sign(rand, digest, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	t.Signatures[i] = sig
+	return nil
+}
+
+// Verify checks that t was signed by all its participants.
+func (t *Transcript) Verify() error {
+	digest, err := t.digest()
+	if err != nil {
+		return err
+	}
+	for i, p := range t.Participants {
+		if !ecdsa.VerifyASN1(p.PublicKey, digest, t.Signatures[i]) {
+			return fmt.Errorf("%w: missing or bad signature of %q", ErrInvalidTranscript, p.Name)
+		}
+	}
+	return nil
+}
+
+// Marshal encodes t as:
+//
+//	struct {
+//	  opaque name<1..2^8-1>;
+//	  opaque public_key<1..2^8-1>;
+//	} PublicKey;
+//
+//	struct {
+//	  opaque name<1..2^8-1>;
+//	  PublicKey identity;
+//	  opaque commitment<32>;
+//	} Participant;
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque curve<1..2^8-1>;
+//	  opaque label<0..2^16-1>;
+//	  Participant participants<1..2^16-1>;
+//	  PublicKey public_key;
+//	  opaque signatures<0..2^16-1>;
+//	} Transcript;
+//
+// where public keys are encoded as the name of their curve and their
+// uncompressed SEC 1 encoding, and signatures holds the ASN.1 signature of
+// each participant, prefixed by its 1-byte length, and empty if it hasn't
+// signed. Participants sign SHA-256 of "ECDSA Ceremony Transcript v1"
+// followed by all the fields but the signatures.
+func (t *Transcript) Marshal() ([]byte, error) {
+	body, err := t.body()
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(body)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, sig := range t.Signatures {
+			addBytes8(b, sig)
+		}
+	})
+	return b.Bytes()
+}
+
+// Unmarshal decodes a transcript encoded by Transcript.Marshal. It does not
+// check its signatures.
+func Unmarshal(data []byte) (*Transcript, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var curve, label, participants, signatures cryptobyte.String
+	t := new(Transcript)
+	if !in.ReadUint8(&version) ||
+		!in.ReadUint8LengthPrefixed(&curve) ||
+		!in.ReadUint16LengthPrefixed(&label) ||
+		!in.ReadUint16LengthPrefixed(&participants) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidTranscript)
+	}
+	if version != transcriptVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidTranscript, version)
+	}
+	var err error
+	if t.Curve, err = ecdsa.CurveByName(string(curve)); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTranscript, err)
+	}
+	t.Label = string(label)
+	for !participants.Empty() {
+		var name, commitment cryptobyte.String
+		var p Participant
+		if !participants.ReadUint8LengthPrefixed(&name) {
+			return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidTranscript)
+		}
+		p.Name = string(name)
+		if p.PublicKey, err = readPublicKey(&participants); err != nil {
+			return nil, err
+		}
+		if !participants.ReadUint8LengthPrefixed(&commitment) {
+			return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidTranscript)
+		}
+		t.Participants = append(t.Participants, p)
+		t.Commitments = append(t.Commitments, append([]byte{}, commitment...))
+	}
+	if t.PublicKey, err = readPublicKey(&in); err != nil {
+		return nil, err
+	}
+	if t.PublicKey.Curve != t.Curve {
+		return nil, fmt.Errorf("%w: public key not on %s", ErrInvalidTranscript, t.Curve.Params().Name)
+	}
+	if !in.ReadUint16LengthPrefixed(&signatures) || !in.Empty() {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidTranscript)
+	}
+	for !signatures.Empty() {
+		var sig cryptobyte.String
+		if !signatures.ReadUint8LengthPrefixed(&sig) {
+			return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidTranscript)
+		}
+		var s []byte
+		if len(sig) > 0 {
+			s = append(s, sig...)
+		}
+		t.Signatures = append(t.Signatures, s)
+	}
+	if len(t.Participants) == 0 || len(t.Signatures) != len(t.Participants) {
+		return nil, fmt.Errorf("%w: %d participants and %d signatures", ErrInvalidTranscript, len(t.Participants), len(t.Signatures))
+	}
+	return t, nil
+}
+
+// Equal reports whether t and u record the same ceremony and public key,
+// regardless of their signatures.
+func (t *Transcript) Equal(u *Transcript) bool {
+	tb, err := t.body()
+	if err != nil {
+		return false
+	}
+	ub, err := u.body()
+	return err == nil && bytes.Equal(tb, ub)
+}
+
+func addBytes8(b *cryptobyte.Builder, v []byte) {
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(v)
+	})
+}
+
+func addBytes16(b *cryptobyte.Builder, v []byte) {
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(v)
+	})
+}
+
+func addPublicKey(b *cryptobyte.Builder, pub *ecdsa.PublicKey) {
+	if pub == nil || pub.Curve == nil {
+		b.SetError(errors.New("missing public key"))
+		return
+	}
+	addBytes8(b, []byte(pub.Curve.Params().Name))
+	addBytes8(b, elliptic.Marshal(pub.Curve, pub.X, pub.Y))
+}
+
+func readPublicKey(s *cryptobyte.String) (*ecdsa.PublicKey, error) {
+	var name, point cryptobyte.String
+	if !s.ReadUint8LengthPrefixed(&name) || !s.ReadUint8LengthPrefixed(&point) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidTranscript)
+	}
+	c, err := ecdsa.CurveByName(string(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTranscript, err)
+	}
+	p, err := ecdsa.NewPoint(c, point)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTranscript, err)
+	}
+	pub, err := ecdsa.NewPublicKey(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTranscript, err)
+	}
+	return pub, nil
+}
@@ -0,0 +1,128 @@
+package ceremony
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+type custodian struct {
+	name     string
+	identity *ecdsa.PrivateKey
+	contrib  *Contribution
+}
+
+func newCustodians(t *testing.T, names ...string) ([]custodian, []Participant) {
+	var cs []custodian
+	var ps []Participant
+	for _, name := range names {
+		id, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		contrib, err := NewContribution(rand.Reader, name)
+		if err != nil {
+			t.Fatalf("NewContribution error: %s", err)
+		}
+		cs = append(cs, custodian{name, id, contrib})
+		ps = append(ps, Participant{Name: name, PublicKey: &id.PublicKey})
+	}
+	return cs, ps
+}
+
+func TestCeremony(t *testing.T) {
+	const label = "root key 2026"
+	cs, ps := newCustodians(t, "alice", "bob", "carol")
+
+	// Each custodian runs the ceremony on its own.
+	var keys []*ecdsa.PrivateKey
+	var transcripts []*Transcript
+	for range cs {
+		cer, err := New(elliptic.P384(), label, ps)
+		if err != nil {
+			t.Fatalf("New error: %s", err)
+		}
+		if err := cer.Reveal("alice", cs[0].contrib.Entropy()); !errors.Is(err, ErrOutOfOrder) {
+			t.Errorf("Reveal before all commitments: got %v, want ErrOutOfOrder", err)
+		}
+		for _, c := range cs {
+			if err := cer.Commit(c.name, c.contrib.Commitment(label)); err != nil {
+				t.Fatalf("Commit error: %s", err)
+			}
+		}
+		if _, _, err := cer.Finish(); !errors.Is(err, ErrOutOfOrder) {
+			t.Errorf("Finish before all reveals: got %v, want ErrOutOfOrder", err)
+		}
+		for _, c := range cs {
+			if err := cer.Reveal(c.name, c.contrib.Entropy()); err != nil {
+				t.Fatalf("Reveal error: %s", err)
+			}
+		}
+		priv, tr, err := cer.Finish()
+		if err != nil {
+			t.Fatalf("Finish error: %s", err)
+		}
+		keys = append(keys, priv)
+		transcripts = append(transcripts, tr)
+	}
+	if !keys[0].Equal(keys[1]) || !keys[0].Equal(keys[2]) {
+		t.Fatal("custodians derived different keys")
+	}
+
+	tr := transcripts[0]
+	if err := tr.Verify(); !errors.Is(err, ErrInvalidTranscript) {
+		t.Errorf("Verify of an unsigned transcript: got %v, want ErrInvalidTranscript", err)
+	}
+	for i, c := range cs {
+		if !transcripts[i].Equal(tr) {
+			t.Fatalf("%s derived another transcript", c.name)
+		}
+		if err := tr.Sign(rand.Reader, c.name, c.identity); err != nil {
+			t.Fatalf("Sign error: %s", err)
+		}
+	}
+	if err := tr.Sign(rand.Reader, "alice", cs[1].identity); !errors.Is(err, ErrInvalidParticipant) {
+		t.Errorf("Sign with another identity: got %v, want ErrInvalidParticipant", err)
+	}
+
+	data, err := tr.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if err := got.Verify(); err != nil {
+		t.Errorf("Verify error: %s", err)
+	}
+	if !got.PublicKey.Equal(&keys[0].PublicKey) {
+		t.Errorf("transcript holds another public key")
+	}
+
+	got.Label = "another ceremony"
+	if err := got.Verify(); !errors.Is(err, ErrInvalidTranscript) {
+		t.Errorf("Verify of a modified transcript: got %v, want ErrInvalidTranscript", err)
+	}
+}
+
+func TestRevealChecksCommitment(t *testing.T) {
+	cs, ps := newCustodians(t, "alice", "bob")
+	cer, _ := New(elliptic.P256(), "label", ps)
+	for _, c := range cs {
+		cer.Commit(c.name, c.contrib.Commitment("label"))
+	}
+	if err := cer.Commit("alice", cs[0].contrib.Commitment("label")); !errors.Is(err, ErrOutOfOrder) {
+		t.Errorf("second Commit: got %v, want ErrOutOfOrder", err)
+	}
+	// Bob can't switch to entropy chosen after seeing Alice's.
+	if err := cer.Reveal("bob", cs[0].contrib.Entropy()); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("Reveal of other entropy: got %v, want ErrInvalidCommitment", err)
+	}
+	if err := cer.Reveal("mallory", cs[0].contrib.Entropy()); !errors.Is(err, ErrInvalidParticipant) {
+		t.Errorf("Reveal by an unknown participant: got %v, want ErrInvalidParticipant", err)
+	}
+	if _, err := New(elliptic.P256(), "label", append(ps, ps[0])); !errors.Is(err, ErrInvalidParticipant) {
+		t.Errorf("New with a duplicate participant: got %v, want ErrInvalidParticipant", err)
+	}
+}
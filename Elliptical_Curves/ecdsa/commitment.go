@@ -0,0 +1,100 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+)
+
+const (
+	commitmentGeneratorDST = "ECDSA Pedersen Commitment Generator"
+	commitmentKeyDST       = "ECDSA Pedersen Commitment Key"
+)
+
+// CommitmentGenerator returns the second generator H of the Pedersen
+// commitments on c, which is HashToCurve of the curve name with the domain
+// separation tag "ECDSA Pedersen Commitment Generator". Nobody knows the
+// discrete logarithm of H with respect to the base point, so commitments are
+// binding. The curve must be P-256, P-384 or P-521.
+func CommitmentGenerator(c elliptic.Curve) (*Point, error) {
+	return HashToCurve(c, []byte(c.Params().Name), []byte(commitmentGeneratorDST))
+}
+
+// Commit returns the Pedersen commitment m * G + r * H to m with the
+// randomness r, where G is the base point of their curve and H is
+// CommitmentGenerator. The commitment hides m as long as r is uniformly
+// random and kept secret until the commitment is opened, and it binds the
+// committer to m.
+func Commit(m, r *Scalar) (*Point, error) {
+	if m.c != r.c {
+		return nil, wrapError(ErrCurveMismatch, "commitment to a %s scalar with %s randomness", curveName(m.c), curveName(r.c))
+	}
+	h, err := CommitmentGenerator(m.c)
+	if err != nil {
+		return nil, err
+	}
+	mG := NewIdentityPoint(m.c).ScalarBaseMult(m)
+	rH := NewIdentityPoint(m.c).ScalarMult(r, h)
+	return mG.Add(mG, rH), nil
+}
+
+// VerifyCommitment checks that commitment, returned by Commit, opens to m
+// with the randomness r. It returns an error wrapping ErrInvalidCommitment if
+// it does not.
+func VerifyCommitment(commitment *Point, m, r *Scalar) error {
+	if commitment.c != m.c {
+		return wrapError(ErrCurveMismatch, "%s commitment to a %s scalar", curveName(commitment.c), curveName(m.c))
+	}
+	want, err := Commit(m, r)
+	if err != nil {
+		return err
+	}
+	if want.Equal(commitment) != 1 {
+		return wrapError(ErrInvalidCommitment, "commitment does not open to the value")
+	}
+	return nil
+}
+
+// publicKeyScalar maps a public key to the scalar its commitments commit to,
+// by hashing its compressed encoding.
+func publicKeyScalar(pk *PublicKey) (*Scalar, error) {
+	p, err := pk.Point()
+	if err != nil {
+		return nil, err
+	}
+	return HashToScalar(pk.Curve, p.BytesCompressed(), []byte(commitmentKeyDST))
+}
+
+// CommitPublicKey commits to the public key pk, typically a blinded key, so
+// that a client can announce it before the issuance it is used for without
+// revealing it, and can't switch to another key afterwards. It returns the
+// commitment, which is published, and the randomness that opens it, which
+// is kept secret until the key is revealed. The key is committed to as
+// HashToScalar of its compressed encoding with the domain separation tag
+// "ECDSA Pedersen Commitment Key". The curve must be P-256, P-384 or P-521.
+func CommitPublicKey(rand io.Reader, pk *PublicKey) (commitment *Point, opening *Scalar, err error) {
+	m, err := publicKeyScalar(pk)
+	if err != nil {
+		return nil, nil, err
+	}
+	k, err := randFieldElement(pk.Curve, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &Scalar{c: pk.Curve, v: k}
+	commitment, err = Commit(m, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commitment, r, nil
+}
+
+// VerifyPublicKeyCommitment checks that commitment, returned by
+// CommitPublicKey, opens to pk with the randomness opening. It returns an
+// error wrapping ErrInvalidCommitment if it does not.
+func VerifyPublicKeyCommitment(commitment *Point, pk *PublicKey, opening *Scalar) error {
+	m, err := publicKeyScalar(pk)
+	if err != nil {
+		return err
+	}
+	return VerifyCommitment(commitment, m, opening)
+}
@@ -0,0 +1,168 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// TestCommitmentVectors checks the generator, a commitment to a scalar, and
+// a commitment to the public key of the private key d, all with the same
+// randomness r. Points are compressed.
+func TestCommitmentVectors(t *testing.T) {
+	for _, v := range []struct {
+		c                  elliptic.Curve
+		h, m, r, commit    string
+		d, commitPublicKey string
+	}{
+		{
+			c:               elliptic.P256(),
+			h:               "03fcef3239475b3154851bccd47540bffe640f2a9738824cd13e05800c4740b8d6",
+			m:               "afc2090fa7f8c09fd529f8534958504fa5ea925a89215ef5bea6bff8261240a5",
+			r:               "5e88d48f6dae3b2f38faa38abc2467094e35aa374e860621a8c6165f812b2eb0",
+			commit:          "031879368d54cc45d2b4cbbdbde7113261ddb129017d23bb1ece0f52ac066b29d5",
+			d:               "c9a0d0163d19f13d3effd01777bea3030d0f5945707fb0ea9b49cd4cfa454ef2",
+			commitPublicKey: "03127c0e539a144ecbaf8a06f33bfa7e9dfb126d1fcd6218e9c5a73b056a5ee0fa",
+		},
+		{
+			c:               elliptic.P384(),
+			h:               "02c28065453228e66207ee5ae659e0797e748591cc37e93a3a8a10e71a79e41beaa9d203c85451a9623e34f54e086efed4",
+			m:               "3435ad5730b19c3dd059dc0db3e3232e12cc747a1ee5595fb4f2f43ff05d4c4dd9481255a208b68a8fe87701fa7cb7a7",
+			r:               "e14dea24d65849f428186cbcd233855e716c1fc2c6b4eb5d217576607b9faaf29fade8297c842aec09957c4f9326bde0",
+			commit:          "026c252a2b78b5a34368416d6c340b56a3b88d388202dc0a76cbf37232d08dc018b1ea3608a9b76ba2b713eb73b2feab66",
+			d:               "e0231160f1beea4ee20f488baff1bb9e85a39f3543774f62ac34da8444c2ae3805708494f89d77a016bee0bbb51798bf",
+			commitPublicKey: "032db52bb9a5f865c5f9556d31d7aae897b57ec973dfe0be4847649556b28cc4dde5a96607f99403d982c6c26927e79108",
+		},
+		{
+			c:               elliptic.P521(),
+			h:               "030068ad74e905bebcd33647bd750df45d2b9676232e003e7798efb29260ba49715c1054471939cd5d622ac4f7066bc7a1ef198e6b7c78240204c7ff7bd033451ed6a9",
+			m:               "01edab32b3d08a802e8177be2cb4a5bed74a7a5b1a429d9d78be7780b67e110bf262bf3c77e3d064f23a7b586d14350136707a638be66dddef6bb1f4e5adcac1753a",
+			r:               "00d2b3a366f5b9e96774fdded235647f6d44b1898643ebf5aeb12b7c379c97b3d978c17b4a711afc962042f33a97d5c5d7638e8a52e9cbbbea0bace8b3d360376118",
+			commit:          "02014f3ad37f35d591794ff619be547894936a3cf8325e64333439b1891427c96c592a099af5566dc4fc7e4fb06af270bb9c295f25f67e2f22c48ecd699dd25fee4903",
+			d:               "010106265ad4bfb3e3ba5c2dbb5343129e40d69fb736ed276e9d036ab6faf7f4d037eac0efaaecf1cf20f7606129fa6287c1eca05c1ba9c9dedd507fad34acb80eb4",
+			commitPublicKey: "0201af542ee48be1f6a7fe625a51d9fe8d8d0ed1ef42a1512ae7985eb73f5c25824dbc2d2219a58bc8acce14c646cea5d5796d685779ddff2feb1809ce2c349c74d279",
+		},
+	} {
+		name := v.c.Params().Name
+		scalar := func(s string) *Scalar {
+			b, _ := hex.DecodeString(s)
+			x, err := NewScalar(v.c).SetBytes(b)
+			if err != nil {
+				t.Fatalf("%s: SetBytes error: %s", name, err)
+			}
+			return x
+		}
+		point := func(s string) *Point {
+			b, _ := hex.DecodeString(s)
+			p, err := NewPoint(v.c, b)
+			if err != nil {
+				t.Fatalf("%s: NewPoint error: %s", name, err)
+			}
+			return p
+		}
+		m, r := scalar(v.m), scalar(v.r)
+
+		h, err := CommitmentGenerator(v.c)
+		if err != nil {
+			t.Fatalf("%s: CommitmentGenerator error: %s", name, err)
+		}
+		if got := hex.EncodeToString(h.BytesCompressed()); got != v.h {
+			t.Errorf("%s: CommitmentGenerator = %s, want %s", name, got, v.h)
+		}
+
+		commit, err := Commit(m, r)
+		if err != nil {
+			t.Fatalf("%s: Commit error: %s", name, err)
+		}
+		if got := hex.EncodeToString(commit.BytesCompressed()); got != v.commit {
+			t.Errorf("%s: Commit = %s, want %s", name, got, v.commit)
+		}
+		if err := VerifyCommitment(point(v.commit), m, r); err != nil {
+			t.Errorf("%s: VerifyCommitment error: %s", name, err)
+		}
+
+		sk, err := NewPrivateKey(scalar(v.d))
+		if err != nil {
+			t.Fatalf("%s: NewPrivateKey error: %s", name, err)
+		}
+		if err := VerifyPublicKeyCommitment(point(v.commitPublicKey), &sk.PublicKey, r); err != nil {
+			t.Errorf("%s: VerifyPublicKeyCommitment error: %s", name, err)
+		}
+	}
+}
+
+func TestCommitPublicKey(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		c := c
+		t.Run(c.Params().Name, func(t *testing.T) {
+			t.Parallel()
+			testCommitPublicKey(t, c)
+		})
+	}
+}
+
+func testCommitPublicKey(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, []byte("issuance"))
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+
+	commitment, opening, err := CommitPublicKey(rand.Reader, pkR)
+	if err != nil {
+		t.Fatalf("CommitPublicKey error: %s", err)
+	}
+	if err := VerifyPublicKeyCommitment(commitment, pkR, opening); err != nil {
+		t.Errorf("VerifyPublicKeyCommitment error: %s", err)
+	}
+
+	// The commitment hides the key: committing again gives another point.
+	again, _, err := CommitPublicKey(rand.Reader, pkR)
+	if err != nil {
+		t.Fatalf("CommitPublicKey error: %s", err)
+	}
+	if again.Equal(commitment) == 1 {
+		t.Errorf("CommitPublicKey returned the same commitment twice")
+	}
+
+	if err := VerifyPublicKeyCommitment(commitment, &skS.PublicKey, opening); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("VerifyPublicKeyCommitment with another key: got %v, want ErrInvalidCommitment", err)
+	}
+	other := NewScalar(c).Add(opening, NewScalar(c).SetUniformBytes([]byte{1}))
+	if err := VerifyPublicKeyCommitment(commitment, pkR, other); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("VerifyPublicKeyCommitment with another opening: got %v, want ErrInvalidCommitment", err)
+	}
+}
+
+func TestCommitmentErrors(t *testing.T) {
+	skP256, err := GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	// The generator, since keys can't be generated on brainpoolP256r1 in FIPS
+	// mode.
+	bp := brainpool.P256r1()
+	pkBrainpool := &PublicKey{bp, bp.Params().Gx, bp.Params().Gy}
+
+	if _, _, err := CommitPublicKey(rand.Reader, pkBrainpool); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("CommitPublicKey on brainpoolP256r1: got %v, want ErrInvalidCurve", err)
+	}
+	if _, _, err := CommitPublicKey(failingReader{}, &skP256.PublicKey); !errors.Is(err, ErrEntropy) {
+		t.Errorf("CommitPublicKey with a failing reader: got %v, want ErrEntropy", err)
+	}
+
+	m := NewScalar(elliptic.P256()).SetUniformBytes([]byte{1})
+	r := NewScalar(elliptic.P384()).SetUniformBytes([]byte{1})
+	if _, err := Commit(m, r); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("Commit on mixed curves: got %v, want ErrCurveMismatch", err)
+	}
+	commitment := NewGeneratorPoint(elliptic.P384())
+	if err := VerifyCommitment(commitment, m, m); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("VerifyCommitment on mixed curves: got %v, want ErrCurveMismatch", err)
+	}
+}
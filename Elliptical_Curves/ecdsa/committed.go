@@ -0,0 +1,200 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	messageCommitmentDST  = "ECDSA Message Commitment"
+	committedSignatureDST = "ECDSA Committed Signature"
+)
+
+// messageScalar maps the digest of a message to the scalar its commitments
+// commit to. In strict mode, hash must be as long as the output of a hash
+// function.
+func messageScalar(c elliptic.Curve, hash []byte) (*Scalar, error) {
+	if err := strictCheckDigest(hash); err != nil {
+		return nil, err
+	}
+	return HashToScalar(c, hash, []byte(messageCommitmentDST))
+}
+
+// CommitMessage commits to hash, the digest of a message, so that a client
+// can have it signed by an issuer that only sees the commitment, and
+// therefore can't refuse to sign based on the content of the message. It
+// returns the commitment, which is sent to the issuer, and the randomness
+// that opens it, which the client keeps to build a CommittedSignature. The
+// digest is committed to as HashToScalar with the domain separation tag
+// "ECDSA Message Commitment". The curve must be P-256, P-384 or P-521, and
+// the same as the curve of the issuer key.
+func CommitMessage(rand io.Reader, c elliptic.Curve, hash []byte) (commitment *Point, opening *Scalar, err error) {
+	m, err := messageScalar(c, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	k, err := randFieldElement(c, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &Scalar{c: c, v: k}
+	commitment, err = Commit(m, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commitment, r, nil
+}
+
+// committedDigest hashes a commitment with the hash function used for
+// blinding on its curve:
+//
+//	H(len(DST) || DST || len(C) || C)
+//
+// where C is the compressed point encoding and lengths are 2-byte big-endian
+// integers.
+func committedDigest(commitment *Point) ([]byte, error) {
+	h, _, err := blindParams(commitment.c)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(committedSignatureDST))
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(commitment.BytesCompressed())
+	})
+	md := h.New()
+	md.Write(b.BytesOrPanic())
+	return md.Sum(nil), nil
+}
+
+func checkCommitment(c elliptic.Curve, commitment *Point) error {
+	if commitment == nil || commitment.c != c {
+		return wrapError(ErrCurveMismatch, "commitment is not on %s", curveName(c))
+	}
+	if commitment.IsIdentity() == 1 {
+		return wrapError(ErrInvalidCommitment, "commitment is the point at infinity")
+	}
+	return nil
+}
+
+// SignCommitted signs commitment, returned by CommitMessage, with priv. The
+// signature is over a digest of the commitment, and only verifies with
+// VerifyCommitted, which also checks the opening of the commitment to the
+// message.
+func SignCommitted(rand io.Reader, priv *PrivateKey, commitment *Point) (r, s *big.Int, err error) {
+	if err := checkCommitment(priv.Curve, commitment); err != nil {
+		return nil, nil, err
+	}
+	digest, err := committedDigest(commitment)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signDigest(rand, priv, digest)
+}
+
+// BlindKeySignCommitted signs commitment, returned by CommitMessage, with skS
+// blinded by skB and context, as SignCommitted does with an unblinded key.
+func BlindKeySignCommitted(rand io.Reader, skS, skB *PrivateKey, commitment *Point, context []byte) (r, s *big.Int, err error) {
+	if err := checkCommitment(skS.Curve, commitment); err != nil {
+		return nil, nil, err
+	}
+	digest, err := committedDigest(commitment)
+	if err != nil {
+		return nil, nil, err
+	}
+	return BlindKeySignWithContext(rand, skS, skB, digest, context)
+}
+
+// CommittedSignature is a signature of a commitment to a message, along with
+// the opening of the commitment, which binds the signature to the message.
+type CommittedSignature struct {
+	Commitment *Point
+	Opening    *Scalar
+	Signature  *Signature
+}
+
+// VerifyCommitted checks that sig is a signature under pub of a commitment
+// that opens to hash. It returns an error wrapping ErrInvalidCommitment if
+// the commitment does not open to hash, and wrapping ErrInvalidSignature if
+// the signature does not verify.
+func VerifyCommitted(pub *PublicKey, hash []byte, sig *CommittedSignature) error {
+	if sig == nil || sig.Opening == nil || sig.Signature == nil {
+		return wrapError(ErrInvalidSignature, "incomplete committed signature")
+	}
+	if err := checkCommitment(pub.Curve, sig.Commitment); err != nil {
+		return err
+	}
+	m, err := messageScalar(pub.Curve, hash)
+	if err != nil {
+		return err
+	}
+	if err := VerifyCommitment(sig.Commitment, m, sig.Opening); err != nil {
+		return err
+	}
+	digest, err := committedDigest(sig.Commitment)
+	if err != nil {
+		return err
+	}
+	return CheckSignature(pub, digest, sig.Signature.R, sig.Signature.S)
+}
+
+// Marshal encodes sig as:
+//
+//	struct {
+//	  opaque commitment[Np];
+//	  opaque opening[Ns];
+//	  opaque r[Ns];
+//	  opaque s[Ns];
+//	} CommittedSignature;
+//
+// where commitment is the compressed point encoding, of length Np, and Ns is
+// the length of a scalar of the curve.
+func (sig *CommittedSignature) Marshal() ([]byte, error) {
+	if sig.Commitment == nil || sig.Opening == nil || sig.Signature == nil {
+		return nil, wrapError(ErrInvalidSignature, "incomplete committed signature")
+	}
+	c := sig.Commitment.c
+	size := scalarSize(c)
+	if sig.Signature.R.Sign() < 0 || sig.Signature.R.BitLen() > size*8 ||
+		sig.Signature.S.Sign() < 0 || sig.Signature.S.BitLen() > size*8 {
+		return nil, wrapError(ErrInvalidSignature, "signature values out of range")
+	}
+	b := sig.Commitment.BytesCompressed()
+	b = append(b, sig.Opening.Bytes()...)
+	b = append(b, sig.Signature.R.FillBytes(make([]byte, size))...)
+	b = append(b, sig.Signature.S.FillBytes(make([]byte, size))...)
+	return b, nil
+}
+
+// UnmarshalCommittedSignature decodes a committed signature on the curve c
+// encoded by CommittedSignature.Marshal. It does not verify the signature.
+func UnmarshalCommittedSignature(c elliptic.Curve, data []byte) (*CommittedSignature, error) {
+	size := scalarSize(c)
+	s := cryptobyte.String(data)
+	var enc, opening, r, ss []byte
+	if !s.ReadBytes(&enc, coordinateSize(c)+1) ||
+		!s.ReadBytes(&opening, size) ||
+		!s.ReadBytes(&r, size) ||
+		!s.ReadBytes(&ss, size) ||
+		!s.Empty() {
+		return nil, wrapError(ErrInvalidSignature, "malformed committed signature")
+	}
+	commitment, err := NewPoint(c, enc)
+	if err != nil {
+		return nil, err
+	}
+	o, err := NewScalar(c).SetBytes(opening)
+	if err != nil {
+		return nil, err
+	}
+	return &CommittedSignature{
+		Commitment: commitment,
+		Opening:    o,
+		Signature:  &Signature{R: new(big.Int).SetBytes(r), S: new(big.Int).SetBytes(ss)},
+	}, nil
+}
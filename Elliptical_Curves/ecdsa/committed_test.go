@@ -0,0 +1,95 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+func TestCommittedSignature(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(c.Params().Name, func(t *testing.T) {
+			testCommittedSignature(t, c)
+		})
+	}
+}
+
+func testCommittedSignature(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("committed")
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	hash := sha256.Sum256([]byte("a message the issuer does not see"))
+
+	commitment, opening, err := CommitMessage(rand.Reader, c, hash[:])
+	if err != nil {
+		t.Fatalf("CommitMessage error: %s", err)
+	}
+	r, s, err := BlindKeySignCommitted(rand.Reader, skS, skB, commitment, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignCommitted error: %s", err)
+	}
+	sig := &CommittedSignature{Commitment: commitment, Opening: opening, Signature: &Signature{R: r, S: s}}
+	if err := VerifyCommitted(pkR, hash[:], sig); err != nil {
+		t.Errorf("VerifyCommitted error: %s", err)
+	}
+
+	enc, err := sig.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decoded, err := UnmarshalCommittedSignature(c, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalCommittedSignature error: %s", err)
+	}
+	if err := VerifyCommitted(pkR, hash[:], decoded); err != nil {
+		t.Errorf("VerifyCommitted of the decoded signature error: %s", err)
+	}
+	if _, err := UnmarshalCommittedSignature(c, enc[1:]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("UnmarshalCommittedSignature of a truncated signature: got %v, want ErrInvalidSignature", err)
+	}
+
+	other := sha256.Sum256([]byte("another message"))
+	if err := VerifyCommitted(pkR, other[:], sig); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("VerifyCommitted of another message: got %v, want ErrInvalidCommitment", err)
+	}
+	if err := VerifyCommitted(&skS.PublicKey, hash[:], sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyCommitted under the unblinded key: got %v, want ErrInvalidSignature", err)
+	}
+	// The signature is of the commitment, not of the message: it does not
+	// verify as a plain signature of the digest.
+	if Verify(pkR, hash[:], r, s) {
+		t.Error("committed signature verifies as a signature of the digest")
+	}
+
+	r, s, err = SignCommitted(rand.Reader, skS, commitment)
+	if err != nil {
+		t.Fatalf("SignCommitted error: %s", err)
+	}
+	sig.Signature = &Signature{R: r, S: s}
+	if err := VerifyCommitted(&skS.PublicKey, hash[:], sig); err != nil {
+		t.Errorf("VerifyCommitted of SignCommitted error: %s", err)
+	}
+}
+
+func TestCommittedSignatureErrors(t *testing.T) {
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	hash := sha256.Sum256([]byte("message"))
+	commitment, _, _ := CommitMessage(rand.Reader, elliptic.P384(), hash[:])
+	if _, _, err := SignCommitted(rand.Reader, sk, commitment); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("SignCommitted of a P-384 commitment with a P-256 key: got %v, want ErrCurveMismatch", err)
+	}
+	if _, _, err := SignCommitted(rand.Reader, sk, NewIdentityPoint(elliptic.P256())); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("SignCommitted of the identity: got %v, want ErrInvalidCommitment", err)
+	}
+	if _, _, err := CommitMessage(rand.Reader, brainpool.P256r1(), hash[:]); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("CommitMessage on brainpoolP256r1: got %v, want ErrInvalidCurve", err)
+	}
+	if err := VerifyCommitted(&sk.PublicKey, hash[:], &CommittedSignature{}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyCommitted of an empty signature: got %v, want ErrInvalidSignature", err)
+	}
+}
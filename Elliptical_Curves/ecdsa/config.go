@@ -0,0 +1,136 @@
+package ecdsa
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// healthCheckMinRead is the shortest read that health checks apply to. Shorter
+// reads, such as the single byte read by MaybeReadByte, are all zeros or
+// repeat too often by chance to be tested.
+const healthCheckMinRead = 16
+
+// Config holds the randomness settings of the package. The zero value uses
+// crypto/rand.Reader for nil readers and performs no health checks.
+type Config struct {
+	// Rand is the randomness source used by the functions of the package
+	// that are passed a nil io.Reader. If Rand is nil, crypto/rand.Reader is
+	// used.
+	Rand io.Reader
+
+	// HealthChecks enables continuous tests of every randomness source, in
+	// the spirit of the health tests of NIST SP 800-90B: a read of 16 bytes
+	// or more fails with an *EntropyError if it is all zeros, or if it
+	// repeats the previous such read from any source. Readers that are
+	// deterministic on purpose, for example in tests, fail these checks.
+	HealthChecks bool
+}
+
+var config struct {
+	sync.Mutex
+	c Config
+	// last is the hash of the last read checked, for the repetition test.
+	last    [sha256.Size]byte
+	hasLast bool
+}
+
+// SetConfig sets the randomness settings of the package, replacing any
+// previous ones, and resets the state of the health checks.
+func SetConfig(c Config) {
+	config.Lock()
+	defer config.Unlock()
+	config.c = c
+	config.hasLast = false
+}
+
+// EntropyError is the error returned when a random draw fails, either
+// because the randomness source returned an error or because its output
+// failed a health check. It matches ErrEntropy with errors.Is.
+type EntropyError struct {
+	// Err is the error returned by the randomness source, or nil if the
+	// output failed a health check.
+	Err error
+	// Reason describes the failed health check, if Err is nil.
+	Reason string
+}
+
+func (e *EntropyError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", ErrEntropy, e.Err)
+	}
+	return fmt.Sprintf("%s: health check failed: %s", ErrEntropy, e.Reason)
+}
+
+// Is reports whether target is ErrEntropy.
+func (e *EntropyError) Is(target error) bool {
+	return target == ErrEntropy
+}
+
+// Unwrap returns the error of the randomness source, if any.
+func (e *EntropyError) Unwrap() error {
+	return e.Err
+}
+
+// entropySource returns the reader every random draw of the package goes
+// through: rand, or the configured source if rand is nil, wrapped in the
+// health checks if they are enabled.
+func entropySource(rand io.Reader) io.Reader {
+	config.Lock()
+	c := config.c
+	config.Unlock()
+	if rand == nil {
+		rand = c.Rand
+	}
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if c.HealthChecks {
+		return healthReader{rand}
+	}
+	return rand
+}
+
+// entropyError records a failed random draw and returns err as an
+// *EntropyError.
+func entropyError(err error) error {
+	observeRandomnessError()
+	var e *EntropyError
+	if errors.As(err, &e) {
+		return e
+	}
+	return &EntropyError{Err: err}
+}
+
+type healthReader struct {
+	r io.Reader
+}
+
+func (h healthReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if n < healthCheckMinRead {
+		return n, err
+	}
+	out := p[:n]
+
+	var acc byte
+	for _, b := range out {
+		acc |= b
+	}
+	if acc == 0 {
+		return 0, &EntropyError{Reason: "all-zero output"}
+	}
+
+	sum := sha256.Sum256(out)
+	config.Lock()
+	repeated := config.hasLast && sum == config.last
+	config.last, config.hasLast = sum, true
+	config.Unlock()
+	if repeated {
+		return 0, &EntropyError{Reason: "repeated output"}
+	}
+	return n, err
+}
@@ -0,0 +1,73 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// repeatingReader returns the same bytes on every read.
+type repeatingReader struct{}
+
+func (repeatingReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(i) + 1
+	}
+	return len(p), nil
+}
+
+func TestHealthChecks(t *testing.T) {
+	SetConfig(Config{HealthChecks: true})
+	t.Cleanup(func() { SetConfig(Config{}) })
+	c := elliptic.P256()
+
+	priv, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if _, _, err := Sign(rand.Reader, priv, testDigest("testing")); err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+
+	_, err = GenerateKey(c, zeroReader)
+	var e *EntropyError
+	if !errors.As(err, &e) || e.Reason == "" || !errors.Is(err, ErrEntropy) {
+		t.Errorf("GenerateKey with an all-zero source: got %v, want an *EntropyError", err)
+	}
+	if _, _, err := Sign(zeroReader, priv, testDigest("testing")); !errors.Is(err, ErrEntropy) && !FIPSMode() {
+		t.Errorf("Sign with an all-zero source: got %v, want ErrEntropy", err)
+	}
+
+	if _, err := GenerateKey(c, repeatingReader{}); err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if _, err := GenerateKey(c, repeatingReader{}); !errors.Is(err, ErrEntropy) {
+		t.Errorf("GenerateKey with a repeating source: got %v, want ErrEntropy", err)
+	}
+
+	_, err = GenerateKey(c, failingReader{})
+	if !errors.As(err, &e) || e.Err == nil {
+		t.Errorf("GenerateKey with a failing source: got %v, want an *EntropyError wrapping the read error", err)
+	}
+}
+
+func TestConfigRand(t *testing.T) {
+	var buf bytes.Buffer
+	SetConfig(Config{Rand: io.TeeReader(rand.Reader, &buf)})
+	t.Cleanup(func() { SetConfig(Config{}) })
+
+	if _, err := GenerateKey(elliptic.P256(), nil); err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	if buf.Len() == 0 {
+		t.Errorf("GenerateKey with a nil reader did not use Config.Rand")
+	}
+
+	SetConfig(Config{})
+	if _, err := GenerateKey(elliptic.P256(), nil); err != nil {
+		t.Errorf("GenerateKey with a nil reader and no Config.Rand error: %s", err)
+	}
+}
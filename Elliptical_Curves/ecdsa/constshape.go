@@ -0,0 +1,125 @@
+package ecdsa
+
+import (
+	"crypto/subtle"
+	"math/big"
+)
+
+// VerifyConstantShape is like Verify, for verifiers that check signatures
+// chosen by an attacker and must not reveal through timing which check
+// rejected a signature. Verify returns as soon as a check fails, so a
+// malformed signature is rejected faster than one that is well-formed but
+// doesn't match, and VerifyDetailed even reports which check failed.
+// VerifyConstantShape instead always runs the same sequence of operations:
+// out-of-range or missing r and s values are replaced with one, the full
+// verification equation is computed, and the outcomes of all the checks are
+// combined in constant time at the end.
+//
+// The shape of the computation is constant, not its running time: big.Int
+// arithmetic and the scalar multiplications of some curves still take time
+// that depends on the values, which the attacker already knows. The public
+// key is trusted input: VerifyConstantShape returns false at once if it
+// doesn't pass ValidatePublicKey, or if the mode of the package refuses its
+// curve.
+func VerifyConstantShape(pub *PublicKey, hash []byte, r, s *big.Int) bool {
+	return verifyConstantShape(pub, hash, r, s, 1)
+}
+
+// VerifyASN1ConstantShape is like VerifyASN1, with the constant-shape
+// verification of VerifyConstantShape. A signature that is not a valid ASN.1
+// encoding runs the same verification as a well-formed one.
+func VerifyASN1ConstantShape(pub *PublicKey, hash, sig []byte) bool {
+	r, s, ok := parseASN1Signature(sig)
+	valid := 1
+	if !ok {
+		r, s, valid = nil, nil, 0
+	}
+	return verifyConstantShape(pub, hash, r, s, valid)
+}
+
+// verifyConstantShape verifies r, s under pub, and combines the result with
+// valid, which is 1 if the checks done by the caller passed and 0 otherwise.
+func verifyConstantShape(pub *PublicKey, hash []byte, r, s *big.Int, valid int) bool {
+	if pub == nil || pub.Curve == nil || ValidatePublicKey(pub.Curve, pub) != nil || fipsCheckCurve(pub.Curve) != nil {
+		return false
+	}
+	c := pub.Curve
+	N := c.Params().N
+	size := scalarSize(c)
+	if strictCheckDigest(hash) != nil {
+		valid = 0
+	}
+
+	rb, rOK := constantShapeScalar(r, N, size)
+	sb, sOK := constantShapeScalar(s, N, size)
+	valid &= rOK & sOK
+
+	// u1 = e * s^-1 and u2 = r * s^-1, with the fixed-exponent inverse so
+	// that it takes the same steps for every s.
+	sInt := new(big.Int).SetBytes(sb)
+	var w *big.Int
+	if in, ok := c.(invertible); ok {
+		w = in.Inverse(sInt)
+	} else {
+		w = fermatInverse(sInt, N)
+	}
+	u1 := new(big.Int).Mul(hashToInt(hash, c), w)
+	u1.Mod(u1, N)
+	u2 := new(big.Int).Mul(new(big.Int).SetBytes(rb), w)
+	u2.Mod(u2, N)
+
+	// The two multiplications are done separately, since the combined
+	// multiplication of some curves runs in variable time.
+	x1, y1 := c.ScalarBaseMult(u1.FillBytes(make([]byte, size)))
+	x2, y2 := c.ScalarMult(pub.X, pub.Y, u2.FillBytes(make([]byte, size)))
+	x, _ := c.Add(x1, y1, x2, y2)
+
+	// The point at infinity has a zero x-coordinate, which never matches r
+	// since r is at least one.
+	x.Mod(x, N)
+	valid &= subtle.ConstantTimeCompare(x.FillBytes(make([]byte, size)), rb)
+	if valid != 1 {
+		observeVerifyFailure(c)
+		return false
+	}
+	return true
+}
+
+// constantShapeScalar returns the size-byte encoding of k and 1 if k is in
+// [1, N-1], and the encoding of one and 0 otherwise. Values in the range of
+// the encoding are checked in constant time.
+func constantShapeScalar(k, N *big.Int, size int) ([]byte, int) {
+	buf := make([]byte, size)
+	ok := 1
+	if k == nil || k.Sign() < 0 || k.BitLen() > size*8 {
+		ok = 0
+	} else {
+		k.FillBytes(buf)
+	}
+	zero := make([]byte, size)
+	ok &= 1 ^ subtle.ConstantTimeCompare(buf, zero)
+	ok &= constantTimeLess(buf, N.FillBytes(make([]byte, size)))
+
+	oneBytes := one.FillBytes(zero)
+	subtle.ConstantTimeCopy(1^ok, buf, oneBytes)
+	return buf, ok
+}
+
+// constantTimeLess returns 1 if the big-endian integer a is less than b,
+// which has the same length, and 0 otherwise, in time that only depends on
+// the length.
+func constantTimeLess(a, b []byte) int {
+	// Scanning from the least significant byte, the result is decided by
+	// the most significant differing byte.
+	less := 0
+	for i := len(a) - 1; i >= 0; i-- {
+		x, y := int(a[i]), int(b[i])
+		lt := int(uint(x-y) >> (intSize - 1))
+		eq := subtle.ConstantTimeByteEq(a[i], b[i])
+		less = subtle.ConstantTimeSelect(eq, less, lt)
+	}
+	return less
+}
+
+// intSize is the size of an int in bits.
+const intSize = 32 << (^uint(0) >> 63)
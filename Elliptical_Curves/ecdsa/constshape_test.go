@@ -0,0 +1,128 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyConstantShape(t *testing.T) {
+	testAllCurves(t, testVerifyConstantShape)
+}
+
+func testVerifyConstantShape(t *testing.T, c elliptic.Curve) {
+	priv, _ := GenerateKey(c, rand.Reader)
+	pub := &priv.PublicKey
+	hash := sha256.Sum256([]byte("testing"))
+	r, s, err := Sign(rand.Reader, priv, hash[:])
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	other := sha256.Sum256([]byte("other"))
+	N := c.Params().N
+
+	for _, tt := range []struct {
+		name string
+		hash []byte
+		r, s *big.Int
+		want bool
+	}{
+		{"valid", hash[:], r, s, true},
+		{"other hash", other[:], r, s, false},
+		{"r = 0", hash[:], new(big.Int), s, false},
+		{"r = N", hash[:], N, s, false},
+		{"s = N", hash[:], r, N, false},
+		{"s = N + s", hash[:], r, new(big.Int).Add(N, s), false},
+		{"negative s", hash[:], r, new(big.Int).Neg(s), false},
+		{"oversized r", hash[:], new(big.Int).Lsh(r, 1024), s, false},
+		{"nil s", hash[:], r, nil, false},
+	} {
+		if got := VerifyConstantShape(pub, tt.hash, tt.r, tt.s); got != tt.want {
+			t.Errorf("%s: VerifyConstantShape = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	sig, _ := SignASN1(rand.Reader, priv, hash[:])
+	if !VerifyASN1ConstantShape(pub, hash[:], sig) {
+		t.Errorf("VerifyASN1ConstantShape rejected a valid signature")
+	}
+	if VerifyASN1ConstantShape(pub, hash[:], sig[:len(sig)-1]) {
+		t.Errorf("VerifyASN1ConstantShape accepted a truncated signature")
+	}
+	offCurve := &PublicKey{Curve: c, X: pub.X, Y: new(big.Int).Add(pub.Y, one)}
+	if VerifyConstantShape(offCurve, hash[:], r, s) {
+		t.Errorf("VerifyConstantShape accepted an off-curve key")
+	}
+}
+
+func TestConstantTimeLess(t *testing.T) {
+	for _, tt := range []struct {
+		a, b []byte
+		want int
+	}{
+		{[]byte{0, 0}, []byte{0, 1}, 1},
+		{[]byte{0, 1}, []byte{0, 1}, 0},
+		{[]byte{1, 0}, []byte{0, 0xff}, 0},
+		{[]byte{0, 0xff}, []byte{1, 0}, 1},
+		{[]byte{0xff, 0xff}, []byte{0xff, 0xfe}, 0},
+	} {
+		if got := constantTimeLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("constantTimeLess(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// countingCurve is P-256 counting the point operations it does.
+type countingCurve struct {
+	elliptic.Curve
+	ops *int
+}
+
+func (cc countingCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	*cc.ops++
+	return cc.Curve.ScalarBaseMult(k)
+}
+
+func (cc countingCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	*cc.ops++
+	return cc.Curve.ScalarMult(x, y, k)
+}
+
+func (cc countingCurve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	*cc.ops++
+	return cc.Curve.Add(x1, y1, x2, y2)
+}
+
+// TestVerifyConstantShapeOps checks that rejected signatures take the same
+// point operations as valid ones, whichever check rejects them.
+func TestVerifyConstantShapeOps(t *testing.T) {
+	if FIPSMode() {
+		t.Skip("countingCurve is not an approved curve")
+	}
+	priv, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	hash := sha256.Sum256([]byte("testing"))
+	sig, _ := SignASN1(rand.Reader, priv, hash[:])
+	var ops int
+	pub := &PublicKey{Curve: countingCurve{elliptic.P256(), &ops}, X: priv.X, Y: priv.Y}
+
+	want := -1
+	for name, sig := range map[string][]byte{
+		"valid":     sig,
+		"malformed": {1, 2, 3},
+		"r = 0":     {0x30, 0x06, 0x02, 0x01, 0x00, 0x02, 0x01, 0x01},
+		"mismatch":  {0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01},
+	} {
+		ops = 0
+		if got := VerifyASN1ConstantShape(pub, hash[:], sig); got != (name == "valid") {
+			t.Errorf("%s: VerifyASN1ConstantShape = %v", name, got)
+		}
+		if want == -1 {
+			want = ops
+		}
+		if ops != want {
+			t.Errorf("%s: %d point operations, want %d", name, ops, want)
+		}
+	}
+}
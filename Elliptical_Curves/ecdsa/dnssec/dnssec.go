@@ -0,0 +1,439 @@
+// Package dnssec produces DNSSEC and DANE record data for blinded ECDSA
+// public keys, and signs and verifies RRsets with blinded keys, so that a
+// zone can be signed with a key that is unlinkable to the keys of the other
+// zones of its operator.
+//
+// Keys are on P-256 or P-384, which are DNSSEC algorithms 13
+// (ECDSAP256SHA256) and 14 (ECDSAP384SHA384) as specified in RFC 6605.
+// Records are handled as RDATA in uncompressed wire format, and names as
+// fully qualified presentation format strings without escapes, such as
+// "www.example.net.".
+package dnssec
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// DNSSEC algorithm numbers of the supported curves.
+const (
+	AlgorithmECDSAP256SHA256 = 13
+	AlgorithmECDSAP384SHA384 = 14
+)
+
+// DNSKEY flags.
+const (
+	// FlagZone marks a zone key, which may sign RRsets.
+	FlagZone = 0x0100
+	// FlagSEP marks a secure entry point, usually a key-signing key.
+	FlagSEP = 0x0001
+)
+
+// TLSA certificate usages.
+const (
+	// UsageDANETA is a trust anchor, here the blinded key of a CA.
+	UsageDANETA = 2
+	// UsageDANEEE is an end entity key, pinned without PKIX validation.
+	UsageDANEEE = 3
+)
+
+const (
+	protocolDNSSEC = 3
+	digestSHA256   = 2
+	selectorSPKI   = 1
+	matchingSHA256 = 1
+)
+
+// Errors returned by this package.
+var (
+	// ErrUnsupportedAlgorithm is returned when a key is on a curve, or a
+	// record uses an algorithm, other than ECDSAP256SHA256 and
+	// ECDSAP384SHA384.
+	ErrUnsupportedAlgorithm = errors.New("dnssec: unsupported algorithm")
+
+	// ErrInvalidRecord is returned when a record or a name is malformed.
+	ErrInvalidRecord = errors.New("dnssec: invalid record")
+
+	// ErrInvalidSignature is returned when an RRSIG fails to verify, or is
+	// not valid at the time of verification.
+	ErrInvalidSignature = errors.New("dnssec: invalid signature")
+)
+
+type algorithm struct {
+	id    uint8
+	curve elliptic.Curve
+	hash  crypto.Hash
+	size  int
+}
+
+func algorithmForCurve(c elliptic.Curve) (algorithm, error) {
+	switch c {
+	case elliptic.P256():
+		return algorithm{AlgorithmECDSAP256SHA256, c, crypto.SHA256, 32}, nil
+	case elliptic.P384():
+		return algorithm{AlgorithmECDSAP384SHA384, c, crypto.SHA384, 48}, nil
+	}
+	return algorithm{}, fmt.Errorf("%w: curve %s", ErrUnsupportedAlgorithm, c.Params().Name)
+}
+
+func algorithmByID(id uint8) (algorithm, error) {
+	switch id {
+	case AlgorithmECDSAP256SHA256:
+		return algorithmForCurve(elliptic.P256())
+	case AlgorithmECDSAP384SHA384:
+		return algorithmForCurve(elliptic.P384())
+	}
+	return algorithm{}, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, id)
+}
+
+// DNSKEY is the data of a DNSKEY record.
+type DNSKEY struct {
+	Flags     uint16
+	Protocol  uint8
+	Algorithm uint8
+	// PublicKey is the concatenation of the coordinates of the key.
+	PublicKey []byte
+}
+
+// NewDNSKEY returns the DNSKEY record data of pk, which must be on P-256 or
+// P-384, with the given flags, usually FlagZone or FlagZone|FlagSEP.
+func NewDNSKEY(pk *ecdsa.PublicKey, flags uint16) (*DNSKEY, error) {
+	if pk == nil || pk.Curve == nil {
+		return nil, fmt.Errorf("%w: missing public key", ErrInvalidRecord)
+	}
+	alg, err := algorithmForCurve(pk.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if err := ecdsa.ValidatePublicKey(pk.Curve, pk); err != nil {
+		return nil, err
+	}
+	key := make([]byte, 2*alg.size)
+	pk.X.FillBytes(key[:alg.size])
+	pk.Y.FillBytes(key[alg.size:])
+	return &DNSKEY{Flags: flags, Protocol: protocolDNSSEC, Algorithm: alg.id, PublicKey: key}, nil
+}
+
+// ParseDNSKEY parses DNSKEY record data in wire format.
+func ParseDNSKEY(rdata []byte) (*DNSKEY, error) {
+	if len(rdata) < 4 {
+		return nil, fmt.Errorf("%w: DNSKEY of %d bytes", ErrInvalidRecord, len(rdata))
+	}
+	return &DNSKEY{
+		Flags:     binary.BigEndian.Uint16(rdata),
+		Protocol:  rdata[2],
+		Algorithm: rdata[3],
+		PublicKey: append([]byte{}, rdata[4:]...),
+	}, nil
+}
+
+// RDATA returns the record data of k in wire format.
+func (k *DNSKEY) RDATA() []byte {
+	b := []byte{byte(k.Flags >> 8), byte(k.Flags), k.Protocol, k.Algorithm}
+	return append(b, k.PublicKey...)
+}
+
+// String returns the record data of k in presentation format, such as
+// "257 3 13 GojIhhXU...".
+func (k *DNSKEY) String() string {
+	return fmt.Sprintf("%d %d %d %s", k.Flags, k.Protocol, k.Algorithm, base64.StdEncoding.EncodeToString(k.PublicKey))
+}
+
+// KeyTag returns the key tag of k, as computed in RFC 4034, Appendix B.
+func (k *DNSKEY) KeyTag() uint16 {
+	var ac uint32
+	for i, b := range k.RDATA() {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16 & 0xffff
+	return uint16(ac)
+}
+
+// ECDSAPublicKey returns the public key of k, after validating it.
+func (k *DNSKEY) ECDSAPublicKey() (*ecdsa.PublicKey, error) {
+	alg, err := algorithmByID(k.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if k.Protocol != protocolDNSSEC || len(k.PublicKey) != 2*alg.size {
+		return nil, fmt.Errorf("%w: malformed DNSKEY", ErrInvalidRecord)
+	}
+	pk := &ecdsa.PublicKey{
+		Curve: alg.curve,
+		X:     new(big.Int).SetBytes(k.PublicKey[:alg.size]),
+		Y:     new(big.Int).SetBytes(k.PublicKey[alg.size:]),
+	}
+	if err := ecdsa.ValidatePublicKey(alg.curve, pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// DS returns the data of the DS record delegating to k from the parent of
+// the zone owner, with a SHA-256 digest.
+func (k *DNSKEY) DS(owner string) ([]byte, error) {
+	name, err := canonicalName(owner)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(name)
+	h.Write(k.RDATA())
+	tag := k.KeyTag()
+	b := []byte{byte(tag >> 8), byte(tag), k.Algorithm, digestSHA256}
+	return h.Sum(b), nil
+}
+
+// TLSA returns the data of a TLSA record with the given certificate usage,
+// usually UsageDANEEE, matching the SHA-256 digest of the
+// SubjectPublicKeyInfo of pk, which must be on P-256 or P-384.
+func TLSA(pk *ecdsa.PublicKey, usage uint8) ([]byte, error) {
+	if pk == nil || pk.Curve == nil {
+		return nil, fmt.Errorf("%w: missing public key", ErrInvalidRecord)
+	}
+	if _, err := algorithmForCurve(pk.Curve); err != nil {
+		return nil, err
+	}
+	if err := ecdsa.ValidatePublicKey(pk.Curve, pk); err != nil {
+		return nil, err
+	}
+	spki, err := x509.MarshalPKIXPublicKey(ecdsa.ToStdPublicKey(pk))
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(spki)
+	return append([]byte{usage, selectorSPKI, matchingSHA256}, digest[:]...), nil
+}
+
+// canonicalName returns the canonical wire format of the fully qualified
+// name, with its ASCII letters in lower case.
+func canonicalName(name string) ([]byte, error) {
+	if !strings.HasSuffix(name, ".") || strings.Contains(name, `\`) {
+		return nil, fmt.Errorf("%w: name %q is not fully qualified or has escapes", ErrInvalidRecord, name)
+	}
+	if name == "." {
+		return []byte{0}, nil
+	}
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("%w: bad label in name %q", ErrInvalidRecord, name)
+		}
+		b = append(b, byte(len(label)))
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			b = append(b, c)
+		}
+	}
+	b = append(b, 0)
+	if len(b) > 255 {
+		return nil, fmt.Errorf("%w: name %q too long", ErrInvalidRecord, name)
+	}
+	return b, nil
+}
+
+// labels returns the number of labels of the fully qualified name, not
+// counting the root and a leading wildcard.
+func labels(name string) uint8 {
+	name = strings.TrimPrefix(strings.TrimSuffix(name, "."), "*")
+	name = strings.TrimPrefix(name, ".")
+	if name == "" {
+		return 0
+	}
+	return uint8(strings.Count(name, ".") + 1)
+}
+
+// RRset is a set of resource records with the same owner name, class and
+// type.
+type RRset struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	// RDATA is the record data of each record in canonical wire format,
+	// with any names it contains in lower case and uncompressed.
+	RDATA [][]byte
+}
+
+// RRSIG is the data of an RRSIG record.
+type RRSIG struct {
+	TypeCovered uint16
+	Algorithm   uint8
+	Labels      uint8
+	OriginalTTL uint32
+	// Expiration and Inception are in seconds since the epoch, modulo
+	// 2^32, as in the record.
+	Expiration uint32
+	Inception  uint32
+	KeyTag     uint16
+	SignerName string
+	// Signature is the concatenation of the R and S values.
+	Signature []byte
+}
+
+// header returns the RDATA of sig without the signature.
+func (sig *RRSIG) header() ([]byte, error) {
+	signer, err := canonicalName(sig.SignerName)
+	if err != nil {
+		return nil, err
+	}
+	b := []byte{byte(sig.TypeCovered >> 8), byte(sig.TypeCovered), sig.Algorithm, sig.Labels}
+	b = appendUint32(b, sig.OriginalTTL)
+	b = appendUint32(b, sig.Expiration)
+	b = appendUint32(b, sig.Inception)
+	b = append(b, byte(sig.KeyTag>>8), byte(sig.KeyTag))
+	return append(b, signer...), nil
+}
+
+// RDATA returns the record data of sig in wire format.
+func (sig *RRSIG) RDATA() ([]byte, error) {
+	b, err := sig.header()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, sig.Signature...), nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// signedData returns the data signed by sig over rrset, as specified in RFC
+// 4034, Section 3.1.8.1, with the records sorted in canonical order.
+func signedData(sig *RRSIG, rrset *RRset) ([]byte, error) {
+	b, err := sig.header()
+	if err != nil {
+		return nil, err
+	}
+	// The owner name of records synthesized from a wildcard is the
+	// wildcard.
+	name := rrset.Name
+	if n := labels(name); sig.Labels < n {
+		parts := strings.Split(strings.TrimSuffix(name, "."), ".")
+		name = "*." + strings.Join(parts[int(n-sig.Labels):], ".") + "."
+	}
+	owner, err := canonicalName(name)
+	if err != nil {
+		return nil, err
+	}
+	rdata := make([][]byte, len(rrset.RDATA))
+	copy(rdata, rrset.RDATA)
+	sort.Slice(rdata, func(i, j int) bool { return bytes.Compare(rdata[i], rdata[j]) < 0 })
+	for i, rd := range rdata {
+		if i > 0 && bytes.Equal(rd, rdata[i-1]) {
+			continue
+		}
+		if len(rd) > 0xffff {
+			return nil, fmt.Errorf("%w: RDATA of %d bytes", ErrInvalidRecord, len(rd))
+		}
+		b = append(b, owner...)
+		b = append(b, byte(rrset.Type>>8), byte(rrset.Type), byte(rrset.Class>>8), byte(rrset.Class))
+		b = appendUint32(b, sig.OriginalTTL)
+		b = append(b, byte(len(rd)>>8), byte(len(rd)))
+		b = append(b, rd...)
+	}
+	return b, nil
+}
+
+// Sign signs rrset with skS blinded by skB and context, whose DNSKEY is in
+// the zone signer, and returns the RRSIG valid from inception to expiration.
+func Sign(rand io.Reader, skS, skB *ecdsa.PrivateKey, context []byte, signer string, rrset *RRset, inception, expiration time.Time) (*RRSIG, error) {
+	if skS == nil {
+		return nil, fmt.Errorf("%w: missing private key", ErrInvalidRecord)
+	}
+	pkR, err := ecdsa.BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewDNSKEY(pkR, FlagZone)
+	if err != nil {
+		return nil, err
+	}
+	alg, _ := algorithmByID(key.Algorithm)
+	sig := &RRSIG{
+		TypeCovered: rrset.Type,
+		Algorithm:   alg.id,
+		Labels:      labels(rrset.Name),
+		OriginalTTL: rrset.TTL,
+		Expiration:  uint32(expiration.Unix()),
+		Inception:   uint32(inception.Unix()),
+		KeyTag:      key.KeyTag(),
+		SignerName:  signer,
+	}
+	data, err := signedData(sig, rrset)
+	if err != nil {
+		return nil, err
+	}
+	h := alg.hash.New()
+	h.Write(data)
+	r, s, err := ecdsa.BlindKeySignWithContext(rand, skS, skB, h.Sum(nil), context)
+	if err != nil {
+		return nil, err
+	}
+	sig.Signature = make([]byte, 2*alg.size)
+	r.FillBytes(sig.Signature[:alg.size])
+	s.FillBytes(sig.Signature[alg.size:])
+	return sig, nil
+}
+
+// Verify verifies that sig is a valid signature of rrset by key at time now.
+// It checks that the algorithm, key tag and covered type of sig match, and
+// that now is between the inception and expiration times of sig, using the
+// serial number arithmetic of RFC 1982.
+func Verify(key *DNSKEY, sig *RRSIG, rrset *RRset, now time.Time) error {
+	pk, err := key.ECDSAPublicKey()
+	if err != nil {
+		return err
+	}
+	if key.Flags&FlagZone == 0 {
+		return fmt.Errorf("%w: DNSKEY is not a zone key", ErrInvalidSignature)
+	}
+	if sig.Algorithm != key.Algorithm || sig.KeyTag != key.KeyTag() || sig.TypeCovered != rrset.Type {
+		return fmt.Errorf("%w: RRSIG does not match the key or the RRset", ErrInvalidSignature)
+	}
+	if sig.Labels > labels(rrset.Name) {
+		return fmt.Errorf("%w: RRSIG has %d labels for %s", ErrInvalidSignature, sig.Labels, rrset.Name)
+	}
+	t := uint32(now.Unix())
+	if int32(t-sig.Inception) < 0 || int32(sig.Expiration-t) < 0 {
+		return fmt.Errorf("%w: RRSIG not valid at %v", ErrInvalidSignature, now)
+	}
+
+	alg, _ := algorithmByID(key.Algorithm)
+	if len(sig.Signature) != 2*alg.size {
+		return fmt.Errorf("%w: signature of %d bytes", ErrInvalidSignature, len(sig.Signature))
+	}
+	data, err := signedData(sig, rrset)
+	if err != nil {
+		return err
+	}
+	h := alg.hash.New()
+	h.Write(data)
+	r := new(big.Int).SetBytes(sig.Signature[:alg.size])
+	s := new(big.Int).SetBytes(sig.Signature[alg.size:])
+	if !ecdsa.Verify(pk, h.Sum(nil), r, s) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package dnssec
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func mustDecode(t *testing.T, s string) []byte {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func mustTime(t *testing.T, s string) uint32 {
+	tt, err := time.Parse("20060102150405", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uint32(tt.Unix())
+}
+
+// TestRFC6605 checks the ECDSAP256SHA256 example of RFC 6605, Section 6.1.
+func TestRFC6605(t *testing.T) {
+	key := &DNSKEY{
+		Flags:     FlagZone | FlagSEP,
+		Protocol:  3,
+		Algorithm: AlgorithmECDSAP256SHA256,
+		PublicKey: mustDecode(t, "GojIhhXUN/u4v54ZQqGSnyhWJwaubCvTmeexv7bR6edbkrSqQpF64cYbcB7wNcP+e+MAnLr+Wi9xMWyQLc8NAA=="),
+	}
+	if tag := key.KeyTag(); tag != 55648 {
+		t.Errorf("KeyTag = %d, want 55648", tag)
+	}
+	ds, err := key.DS("example.net.")
+	if err != nil {
+		t.Fatalf("DS error: %s", err)
+	}
+	if want := "d9600d02b4c8c1fe2e7477127b27115656ad6256f424625bf5c1e2770ce6d6e37df61d17"; hex.EncodeToString(ds) != want {
+		t.Errorf("DS = %x, want %s", ds, want)
+	}
+
+	sig := &RRSIG{
+		TypeCovered: 1,
+		Algorithm:   AlgorithmECDSAP256SHA256,
+		Labels:      3,
+		OriginalTTL: 3600,
+		Expiration:  mustTime(t, "20100909100439"),
+		Inception:   mustTime(t, "20100812100439"),
+		KeyTag:      55648,
+		SignerName:  "example.net.",
+		Signature:   mustDecode(t, "qx6wLYqmh+l9oCKTN6qIc+bw6ya+KJ8oMz0YP107epXAyGmt+3SNruPFKG7tZoLBLlUzGGus7ZwmwWep666VCw=="),
+	}
+	rrset := &RRset{Name: "WWW.example.net.", Type: 1, Class: 1, TTL: 3600, RDATA: [][]byte{{192, 0, 2, 1}}}
+	now := time.Unix(int64(sig.Inception), 0).Add(24 * time.Hour)
+	if err := Verify(key, sig, rrset, now); err != nil {
+		t.Errorf("Verify error: %s", err)
+	}
+	if err := Verify(key, sig, rrset, time.Unix(int64(sig.Expiration), 0).Add(time.Second)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of an expired RRSIG: got %v, want ErrInvalidSignature", err)
+	}
+	rrset.RDATA = [][]byte{{192, 0, 2, 2}}
+	if err := Verify(key, sig, rrset, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of another RRset: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+		skS, _ := ecdsa.GenerateKey(c, rand.Reader)
+		skB, _ := ecdsa.GenerateKey(c, rand.Reader)
+		zone := "example.org."
+		pkR, err := ecdsa.BlindPublicKeyWithContext(c, &skS.PublicKey, skB, []byte(zone))
+		if err != nil {
+			t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+		}
+		key, err := NewDNSKEY(pkR, FlagZone)
+		if err != nil {
+			t.Fatalf("NewDNSKEY error: %s", err)
+		}
+		parsed, err := ParseDNSKEY(key.RDATA())
+		if err != nil {
+			t.Fatalf("ParseDNSKEY error: %s", err)
+		}
+		if pk, err := parsed.ECDSAPublicKey(); err != nil || !pk.Equal(pkR) {
+			t.Errorf("ECDSAPublicKey = %v, %v, want the blinded key", pk, err)
+		}
+
+		rrset := &RRset{
+			Name:  "*.example.org.",
+			Type:  16,
+			Class: 1,
+			TTL:   300,
+			RDATA: [][]byte{[]byte("\x05hello"), []byte("\x03bye")},
+		}
+		now := time.Now()
+		sig, err := Sign(rand.Reader, skS, skB, []byte(zone), zone, rrset, now.Add(-time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Sign error: %s", err)
+		}
+		if sig.Labels != 2 || sig.KeyTag != key.KeyTag() {
+			t.Errorf("RRSIG has %d labels and key tag %d, want 2 and %d", sig.Labels, sig.KeyTag, key.KeyTag())
+		}
+
+		// The records of the set are signed in canonical order, and a
+		// name synthesized from the wildcard verifies.
+		rrset.Name = "a.b.example.org."
+		rrset.RDATA[0], rrset.RDATA[1] = rrset.RDATA[1], rrset.RDATA[0]
+		if err := Verify(key, sig, rrset, now); err != nil {
+			t.Errorf("%s: Verify error: %s", c.Params().Name, err)
+		}
+		if err := Verify(key, sig, rrset, now.Add(2*time.Hour)); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Verify after expiration: got %v, want ErrInvalidSignature", c.Params().Name, err)
+		}
+
+		// A key blinded for another zone doesn't verify the RRSIG.
+		other, _ := ecdsa.BlindPublicKeyWithContext(c, &skS.PublicKey, skB, []byte("example.com."))
+		otherKey, _ := NewDNSKEY(other, FlagZone)
+		if err := Verify(otherKey, sig, rrset, now); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Verify with another zone's key: got %v, want ErrInvalidSignature", c.Params().Name, err)
+		}
+	}
+}
+
+func TestTLSA(t *testing.T) {
+	sk, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tlsa, err := TLSA(&sk.PublicKey, UsageDANEEE)
+	if err != nil {
+		t.Fatalf("TLSA error: %s", err)
+	}
+	spki, _ := x509.MarshalPKIXPublicKey(ecdsa.ToStdPublicKey(&sk.PublicKey))
+	digest := sha256.Sum256(spki)
+	if want := append([]byte{3, 1, 1}, digest[:]...); !bytes.Equal(tlsa, want) {
+		t.Errorf("TLSA = %x, want %x", tlsa, want)
+	}
+
+	sk, _ = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if _, err := TLSA(&sk.PublicKey, UsageDANEEE); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("TLSA on P-521: got %v, want ErrUnsupportedAlgorithm", err)
+	}
+	if _, err := NewDNSKEY(&sk.PublicKey, FlagZone); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("NewDNSKEY on P-521: got %v, want ErrUnsupportedAlgorithm", err)
+	}
+	if _, err := canonicalName("example.org"); !errors.Is(err, ErrInvalidRecord) {
+		t.Errorf("canonicalName of a relative name: got %v, want ErrInvalidRecord", err)
+	}
+}
@@ -0,0 +1,48 @@
+package ecdsa
+
+// ECDH performs an elliptic curve Diffie-Hellman key agreement between priv
+// and the peer public key peer, and returns the shared secret, which is the
+// fixed-length big-endian encoding of the x-coordinate of the shared point,
+// as specified in SEC 1, Version 2.0, Section 3.3.1. The shared secret should
+// be passed through a key derivation function before use.
+//
+// It returns an error if peer is not on the curve of priv, or if the result
+// is the point at infinity.
+func ECDH(priv *PrivateKey, peer *PublicKey) ([]byte, error) {
+	if priv.Curve != peer.Curve {
+		return nil, wrapError(ErrCurveMismatch, "ECDH peer key is on %s, not %s", peer.Curve.Params().Name, priv.Curve.Params().Name)
+	}
+	if _, err := peer.Point(); err != nil {
+		return nil, err
+	}
+	d, err := priv.Scalar()
+	if err != nil {
+		return nil, err
+	}
+
+	x, y := priv.Curve.ScalarMult(peer.X, peer.Y, d.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, wrapError(ErrPointNotOnCurve, "ECDH produced the point at infinity")
+	}
+	size := (priv.Curve.Params().BitSize + 7) / 8
+	return x.FillBytes(make([]byte, size)), nil
+}
+
+// BlindECDHWithContext performs an ECDH key agreement between skS blinded by a
+// blind, with a context string, and the peer public key peer. The peer obtains
+// the same shared secret by running ECDH with its private key and the blinded
+// public key returned by BlindPublicKeyWithContext, so the key agreement
+// authenticates the blinded identity without revealing the public key of skS.
+func BlindECDHWithContext(skS *PrivateKey, skB *PrivateKey, peer *PublicKey, context []byte) ([]byte, error) {
+	skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	return ECDH(skR, peer)
+}
+
+// BlindECDH performs an ECDH key agreement between skS blinded by a blind and
+// empty context string, and the peer public key peer.
+func BlindECDH(skS *PrivateKey, skB *PrivateKey, peer *PublicKey) ([]byte, error) {
+	return BlindECDHWithContext(skS, skB, peer, nil)
+}
@@ -0,0 +1,61 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestBlindECDH(t *testing.T) {
+	testAllCurves(t, testBlindECDH)
+}
+
+func testBlindECDH(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	peer, _ := GenerateKey(c, rand.Reader)
+	context := []byte("handshake")
+
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+
+	ours, err := BlindECDHWithContext(skS, skB, &peer.PublicKey, context)
+	if err != nil {
+		t.Fatalf("BlindECDHWithContext error: %s", err)
+	}
+	theirs, err := ECDH(peer, pkR)
+	if err != nil {
+		t.Fatalf("ECDH error: %s", err)
+	}
+	if !bytes.Equal(ours, theirs) {
+		t.Errorf("blinded ECDH shared secrets do not match")
+	}
+
+	unblinded, err := ECDH(peer, &skS.PublicKey)
+	if err != nil {
+		t.Fatalf("ECDH error: %s", err)
+	}
+	if bytes.Equal(ours, unblinded) {
+		t.Errorf("blinded ECDH shared secret matches the unblinded one")
+	}
+
+	plain, err := BlindECDH(skS, skB, &peer.PublicKey)
+	if err != nil {
+		t.Fatalf("BlindECDH error: %s", err)
+	}
+	if bytes.Equal(ours, plain) {
+		t.Errorf("blinded ECDH shared secret does not depend on the context")
+	}
+
+	other := elliptic.P256()
+	if c == other {
+		other = elliptic.P384()
+	}
+	otherKey, _ := GenerateKey(other, rand.Reader)
+	if _, err := ECDH(skS, &otherKey.PublicKey); err == nil {
+		t.Errorf("ECDH accepted a peer key on a different curve")
+	}
+}
@@ -13,6 +13,53 @@
 // [Coron], the AES-CTR stream is indifferentiable from a random oracle
 // under standard cryptographic assumptions (see [Larsson] for examples).
 //
+// # Signing
+//
+// New code should sign with SignMessage and verify with VerifyMessage, which
+// hash the message themselves. Sign and Verify take a digest and silently
+// truncate a message passed by mistake; SetStrictMode makes them, and every
+// other function taking a digest, reject inputs that are not as long as one.
+// SignerOpts selects how digests longer than the curve order are handled:
+// truncated as ANSI X9.62 specifies, reduced with HashReduce, or refused with
+// HashRejectOversized.
+//
+// Issuers can guard against a faulty randomness source by signing through a
+// NonceGuard, which withholds any signature that reuses the nonce of an
+// earlier one for a different message. Precomputed SignerSession nonces and
+// guards survive restarts through SnapshotSignerSessions and
+// NonceGuard.Snapshot; RestoreSignerSessions restores a session snapshot only
+// once, as completing its sessions twice would reuse their nonces.
+// SignWithNonceShare splits nonce generation between two parties, such as an
+// HSM and its host, so that neither chooses the nonce.
+//
+// # Verification
+//
+// VerifyDetailed reports why a signature was rejected, for debugging and
+// failure metrics. VerifyConstantShape runs the full verification equation
+// for every input, so that timing doesn't reveal which check rejected an
+// attacker-chosen signature. A BulkVerifier checks large batches with
+// multi-scalar multiplications run by a BulkBackend, which can offload them
+// to an accelerator.
+//
+// # Blinds
+//
+// Blinds that are, or derive to, 0, 1 or N-1 modulo the curve order are
+// rejected with ErrZeroBlind or ErrWeakBlind, since they would make the
+// blinded key linkable to its base key. A Keyring tells which base key a
+// blinded key came from. EscrowBlind splits a blind among trustees, a
+// threshold of which unblind keys through an UnblindSession without
+// reconstructing it. DeriveBlindFromPassword derives a blind from a
+// passphrase, and WrapBlinds moves blinds between devices.
+//
+// # Tokens and credentials
+//
+// BlindKeySignCommitted signs a committed digest, so an issuer can't refuse
+// messages by their content. BlindKeySignTagged adds a double-spend tag to a
+// signature, proven to be derived from the blinded key, which Redeem records
+// in a SpendStore. CreateWebAuthnCredential backs unlinkable FIDO credentials
+// with a hardware key, and GeneratePoP proves control of both the base key
+// and the blind of a blinded key.
+//
 // References:
 //
 //	[Coron]
@@ -31,14 +78,17 @@ import (
 	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/sha256"
 	"crypto/sha512"
-	"errors"
+	"crypto/subtle"
 	"fmt"
 	"io"
 	"math/big"
+	"sync"
+	"time"
 
-	"github.com/cloudflare/circl/expander"
 	"github.com/cloudflare/circl/group"
 	"golang.org/x/crypto/cryptobyte"
 	"golang.org/x/crypto/cryptobyte/asn1"
@@ -60,6 +110,11 @@ const (
 )
 
 // PublicKey represents an ECDSA public key.
+//
+// The X and Y fields are kept for compatibility, so a PublicKey can hold a
+// point that is not on its curve; functions of this package that take one
+// validate it with ValidatePublicKey. New code should construct public keys
+// with NewPublicKey, which only accepts a validated Point.
 type PublicKey struct {
 	elliptic.Curve
 	X, Y *big.Int
@@ -78,7 +133,7 @@ func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
 	if !ok {
 		return false
 	}
-	return pub.X.Cmp(xx.X) == 0 && pub.Y.Cmp(xx.Y) == 0 &&
+	return bigIntEqual(pub.X, xx.X) && bigIntEqual(pub.Y, xx.Y) &&
 		// Standard library Curve implementations are singletons, so this check
 		// will work for those. Other Curves might be equivalent even if not
 		// singletons, but there is no definitive way to check for that, and
@@ -86,7 +141,20 @@ func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
 		pub.Curve == xx.Curve
 }
 
+// Fingerprint returns the SHA-256 digest of the compressed encoding of pub.
+// Unlike the key itself, the fingerprint is comparable, so it can be used as
+// a map key, and it is safe to log.
+func (pub *PublicKey) Fingerprint() [sha256.Size]byte {
+	return sha256.Sum256(elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y))
+}
+
 // PrivateKey represents an ECDSA private key.
+//
+// The D field is kept for compatibility, so a PrivateKey can hold a scalar
+// that is out of range, or that doesn't match its public key. New code should
+// construct private keys with NewPrivateKey, which only accepts a validated
+// Scalar, and get the scalar back with the Scalar method, which checks its
+// range.
 type PrivateKey struct {
 	PublicKey
 	D *big.Int
@@ -105,18 +173,37 @@ func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
 	if !ok {
 		return false
 	}
-	return priv.PublicKey.Equal(&xx.PublicKey) && priv.D.Cmp(xx.D) == 0
+	return priv.PublicKey.Equal(&xx.PublicKey) && bigIntEqual(priv.D, xx.D)
+}
+
+// bigIntEqual reports whether a and b are equal leaking only their bit length
+// and sign through timing side-channels. A nil value is not equal to anything,
+// so that keys and signatures that were never set don't compare equal.
+func bigIntEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return a.Sign() == b.Sign() && subtle.ConstantTimeCompare(a.Bytes(), b.Bytes()) == 1
 }
 
-// Sign signs digest with priv, reading randomness from rand. The opts argument
-// is not currently used but, in keeping with the crypto.Signer interface,
-// should be the hash function used to digest the message.
+// Sign signs digest with priv, reading randomness from rand. In keeping with
+// the crypto.Signer interface, opts should be the hash function used to digest
+// the message. It is only checked against the digest if it is a *SignerOpts,
+// whose HashMode is then honored.
 //
 // This method implements crypto.Signer, which is an interface to support keys
 // where the private part is kept in, for example, a hardware module. Common
 // uses should use the Sign function in this package directly.
 func (priv *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
-	r, s, err := Sign(rand, priv, digest)
+	var r, s *big.Int
+	var err error
+	if o, ok := opts.(*SignerOpts); ok {
+		r, s, err = SignWithOptions(rand, priv, digest, o)
+	} else if err = fipsCheckOpts(opts); err != nil {
+		return nil, err
+	} else {
+		r, s, err = Sign(rand, priv, digest)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -136,8 +223,9 @@ var one = new(big.Int).SetInt64(1)
 func randFieldElement(c elliptic.Curve, rand io.Reader) (k *big.Int, err error) {
 	params := c.Params()
 	b := make([]byte, params.BitSize/8+8)
-	_, err = io.ReadFull(rand, b)
+	_, err = io.ReadFull(entropySource(rand), b)
 	if err != nil {
+		err = entropyError(err)
 		return
 	}
 
@@ -161,6 +249,9 @@ func CreateKey(c elliptic.Curve, privateKeyBytes []byte) (*PrivateKey, error) {
 
 // GenerateKey generates a public and private key pair.
 func GenerateKey(c elliptic.Curve, rand io.Reader) (*PrivateKey, error) {
+	if err := fipsCheckCurve(c); err != nil {
+		return nil, err
+	}
 	k, err := randFieldElement(c, rand)
 	if err != nil {
 		return nil, err
@@ -171,41 +262,96 @@ func GenerateKey(c elliptic.Curve, rand io.Reader) (*PrivateKey, error) {
 	priv.D = k
 	priv.PublicKey.X, priv.PublicKey.Y = c.ScalarBaseMult(k.Bytes())
 
+	if FIPSMode() {
+		if err := fipsPairwiseTest(priv); err != nil {
+			return nil, err
+		}
+	}
 	return priv, nil
 }
 
+// blindParams returns the hash function and the hash-to-field output length L
+// used to derive blinds for the curve c, as registered with RegisterCurve.
+func blindParams(c elliptic.Curve) (h crypto.Hash, L uint, err error) {
+	p, ok := registeredCurve(c)
+	if !ok {
+		return 0, 0, wrapError(ErrInvalidCurve, "unsupported curve %s", c.Params().Name)
+	}
+	return p.hash, p.L, nil
+}
+
+// hashBlind derives the blinding scalar of the blind sk and context on the
+// curve c. Blinds congruent to zero, or to one of the guessable values 1 and
+// N-1, modulo the order N of c are rejected, as are blinds that derive to
+// zero, 1 or N-1, which would make the blinded key the identity, the base key
+// or its negation. A blind may be used on any curve, and derives unrelated
+// scalars on each.
 func hashBlind(c elliptic.Curve, sk *PrivateKey, context []byte) (*big.Int, error) {
-	var h crypto.Hash
-	var L uint
-	switch c.Params().Name {
-	case "P-224":
-		h = crypto.SHA256
-		L = 32
-	case "P-256":
-		h = crypto.SHA256
-		L = 48
-	case "P-384":
-		h = crypto.SHA384
-		L = 72
-	case "P-521":
-		h = crypto.SHA512
-		L = 98
-	default:
-		return nil, fmt.Errorf("Unsupported curve")
-	}
-	xmd := expander.NewExpanderMD(h, []byte("ECDSA Key Blind"))
+	return hashBlindWith(c, sk, context, BlindHashDefault)
+}
+
+// hashBlindWith is hashBlind with the hash function bh.
+func hashBlindWith(c elliptic.Curve, sk *PrivateKey, context []byte, bh BlindHash) (*big.Int, error) {
+	if sk == nil || sk.D == nil {
+		return nil, wrapError(ErrZeroBlind, "missing blind")
+	}
+	if sk.D.Sign() < 0 {
+		// FillBytes would encode the absolute value, so that D and -D
+		// would silently derive the same blind.
+		return nil, wrapError(ErrInvalidScalar, "negative blind")
+	}
+	xmd, L, err := bh.expander(c)
+	if err != nil {
+		return nil, err
+	}
+	N := c.Params().N
+	nMinus1 := new(big.Int).Sub(N, one)
+	switch d := new(big.Int).Mod(sk.D, N); {
+	case d.Sign() == 0:
+		return nil, wrapError(ErrZeroBlind, "blind is zero modulo the order of %s", c.Params().Name)
+	case d.Cmp(one) == 0 || d.Cmp(nMinus1) == 0:
+		return nil, wrapError(ErrWeakBlind, "blind is 1 or N-1 modulo the order of %s", c.Params().Name)
+	}
 	var u [1]big.Int
 	scalarBytes := make([]byte, (sk.D.BitLen()+7)>>3)
 	sk.D.FillBytes(scalarBytes)
 	blindContext := append(scalarBytes, 0x00)
 	blindContext = append(blindContext, context...)
 	group.HashToField(u[:], blindContext, xmd, c.Params().N, L)
+	if u[0].Sign() == 0 {
+		return nil, wrapError(ErrZeroBlind, "blind derived to zero")
+	}
+	if u[0].Cmp(one) == 0 || u[0].Cmp(nMinus1) == 0 {
+		return nil, wrapError(ErrWeakBlind, "blind derived to 1 or N-1")
+	}
 	return new(big.Int).Set(&u[0]), nil
 }
 
 // BlindPublicKeyWithContext blinds a public key using a private key pair and context string.
 func BlindPublicKeyWithContext(c elliptic.Curve, pk *PublicKey, bk *PrivateKey, context []byte) (*PublicKey, error) {
-	skBlind, err := hashBlind(c, bk, context)
+	return BlindPublicKeyWithHash(c, pk, bk, context, BlindHashDefault)
+}
+
+// BlindPublicKeyWithHash is BlindPublicKeyWithContext with the blind and
+// context hashed with bh.
+func BlindPublicKeyWithHash(c elliptic.Curve, pk *PublicKey, bk *PrivateKey, context []byte, bh BlindHash) (*PublicKey, error) {
+	pkR, err := blindPublicKeyWith(c, pk, bk, context, bh)
+	if err != nil {
+		return nil, err
+	}
+	observeBlind(c)
+	return pkR, nil
+}
+
+func blindPublicKey(c elliptic.Curve, pk *PublicKey, bk *PrivateKey, context []byte) (*PublicKey, error) {
+	return blindPublicKeyWith(c, pk, bk, context, BlindHashDefault)
+}
+
+func blindPublicKeyWith(c elliptic.Curve, pk *PublicKey, bk *PrivateKey, context []byte, bh BlindHash) (*PublicKey, error) {
+	if err := ValidatePublicKey(c, pk); err != nil {
+		return nil, err
+	}
+	skBlind, err := hashBlindWith(c, bk, context, bh)
 	if err != nil {
 		return nil, err
 	}
@@ -221,16 +367,36 @@ func BlindPublicKey(c elliptic.Curve, pk *PublicKey, bk *PrivateKey) (*PublicKey
 }
 
 // UnblindPublicKeyWithContext unblinds a public key using a private key pair and context string.
+//
+// The blinded key pk is fully validated with ValidatePublicKey, and a negative
+// blind is rejected with ErrInvalidScalar. The unblinded key is validated as well, so
+// that a fault in the curve arithmetic results in an error wrapping
+// ErrPointNotOnCurve rather than in an incorrect key.
 func UnblindPublicKeyWithContext(c elliptic.Curve, pk *PublicKey, bk *PrivateKey, context []byte) (*PublicKey, error) {
-	skBlind, err := hashBlind(c, bk, context)
+	return UnblindPublicKeyWithHash(c, pk, bk, context, BlindHashDefault)
+}
+
+// UnblindPublicKeyWithHash is UnblindPublicKeyWithContext with the blind and
+// context hashed with bh.
+func UnblindPublicKeyWithHash(c elliptic.Curve, pk *PublicKey, bk *PrivateKey, context []byte, bh BlindHash) (*PublicKey, error) {
+	if err := ValidatePublicKey(c, pk); err != nil {
+		return nil, err
+	}
+	skBlind, err := hashBlindWith(c, bk, context, bh)
 	if err != nil {
 		return nil, err
 	}
 	kInv := new(big.Int).ModInverse(skBlind, c.Params().N)
+	if kInv == nil {
+		return nil, wrapError(ErrInvalidCurve, "blind not invertible modulo the order of %s", c.Params().Name)
+	}
 	X, Y := c.ScalarMult(pk.X, pk.Y, kInv.Bytes())
-	return &PublicKey{
-		c, X, Y,
-	}, nil
+	pkS := &PublicKey{c, X, Y}
+	if X == nil || Y == nil || ValidatePublicKey(c, pkS) != nil {
+		return nil, wrapError(ErrPointNotOnCurve, "unblinded key is not a valid point of %s", c.Params().Name)
+	}
+	observeUnblind(c)
+	return pkS, nil
 }
 
 // UnblindPublicKey unblinds a public key using a private key pair and empty context string.
@@ -238,25 +404,84 @@ func UnblindPublicKey(c elliptic.Curve, pk *PublicKey, bk *PrivateKey) (*PublicK
 	return UnblindPublicKeyWithContext(c, pk, bk, nil)
 }
 
+// BlindPrivateKeyWithContext blinds the private key skS by a blind, with a context string. The public
+// key of the result matches BlindPublicKeyWithContext applied to the public key of skS.
+func BlindPrivateKeyWithContext(skS *PrivateKey, skB *PrivateKey, context []byte) (*PrivateKey, error) {
+	return BlindPrivateKeyWithHash(skS, skB, context, BlindHashDefault)
+}
+
+// BlindPrivateKeyWithHash is BlindPrivateKeyWithContext with the blind and
+// context hashed with bh.
+func BlindPrivateKeyWithHash(skS *PrivateKey, skB *PrivateKey, context []byte, bh BlindHash) (*PrivateKey, error) {
+	Db, err := blindPrivateScalarWith(skS, skB, context, bh)
+	if err != nil {
+		return nil, err
+	}
+	// The blinded public key is Db times the base point, which uses the
+	// precomputed tables of the base point and is several times faster than
+	// blinding the public key of skS with a variable-base multiplication.
+	c := skS.Curve
+	X, Y := c.ScalarBaseMult(Db.FillBytes(make([]byte, scalarSize(c))))
+	return &PrivateKey{
+		PublicKey{c, X, Y},
+		Db,
+	}, nil
+}
+
+// blindPrivateScalar returns the private scalar of skS blinded by skB and
+// context.
+func blindPrivateScalar(skS *PrivateKey, skB *PrivateKey, context []byte) (*big.Int, error) {
+	return blindPrivateScalarWith(skS, skB, context, BlindHashDefault)
+}
+
+func blindPrivateScalarWith(skS *PrivateKey, skB *PrivateKey, context []byte, bh BlindHash) (*big.Int, error) {
+	c := skS.Curve
+	if err := ValidatePublicKey(c, &skS.PublicKey); err != nil {
+		return nil, err
+	}
+	if skS.D == nil || skS.D.Sign() <= 0 || skS.D.Cmp(c.Params().N) >= 0 {
+		return nil, wrapError(ErrInvalidScalar, "private key out of range")
+	}
+	skBlind, err := hashBlindWith(c, skB, context, bh)
+	if err != nil {
+		return nil, err
+	}
+	Db := new(big.Int).Mul(skS.D, skBlind)
+	return Db.Mod(Db, c.Params().N), nil
+}
+
+// BlindPrivateKey blinds the private key skS by a blind and empty context string.
+func BlindPrivateKey(skS *PrivateKey, skB *PrivateKey) (*PrivateKey, error) {
+	return BlindPrivateKeyWithContext(skS, skB, nil)
+}
+
 // BlindKeySignWithContext blinds the signing key by a blind, with a context string, and then produces a signature over the hashed input.
 func BlindKeySignWithContext(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte, context []byte) (r, s *big.Int, err error) {
-	pkB, err := BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	return BlindKeySignWithHash(rand, skS, skB, hash, context, BlindHashDefault)
+}
+
+// BlindKeySignWithHash is BlindKeySignWithContext with the blind and context
+// hashed with bh. The signature verifies under the key returned by
+// BlindPublicKeyWithHash with the same bh.
+func BlindKeySignWithHash(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte, context []byte, bh BlindHash) (r, s *big.Int, err error) {
+	Db, err := blindPrivateScalarWith(skS, skB, context, bh)
 	if err != nil {
 		return nil, nil, err
 	}
-	skBlind, err := hashBlind(skS.Curve, skB, context)
+
+	// Signing only needs the private scalar, so the blinded public key is
+	// only computed for the issuance logger.
+	c := skS.Curve
+	skR := &PrivateKey{PublicKey{Curve: c}, Db}
+	r, s, err = Sign(rand, skR, hash)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	Db := new(big.Int).Mul(skS.D, skBlind)
-	Db.Mod(Db, skS.Curve.Params().N)
-	skR := &PrivateKey{
-		*pkB,
-		Db,
+	if issuanceLogging() {
+		skR.X, skR.Y = c.ScalarBaseMult(Db.FillBytes(make([]byte, scalarSize(c))))
+		logIssuance(&skR.PublicKey, hash)
 	}
-
-	return Sign(rand, skR, hash)
+	return r, s, nil
 }
 
 // BlindKeySign blinds the signing key by a blind and then produces a signature over the hashed input.
@@ -269,15 +494,21 @@ func BlindKeySign(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte)
 // manner, but [SECG] truncates the hash to the bit-length of the curve order
 // first. We follow [SECG] because that's what OpenSSL does. Additionally,
 // OpenSSL right shifts excess bits from the number if the hash is too large
-// and we mirror that too.
+// and we mirror that too. This is HashTruncate, the conversion of ANSI X9.62;
+// SignWithOptions and VerifyWithOptions select the other HashMode values.
 func hashToInt(hash []byte, c elliptic.Curve) *big.Int {
+	return setHashToInt(new(big.Int), hash, c)
+}
+
+// setHashToInt sets ret to the value of hashToInt(hash, c), and returns ret.
+func setHashToInt(ret *big.Int, hash []byte, c elliptic.Curve) *big.Int {
 	orderBits := c.Params().N.BitLen()
 	orderBytes := (orderBits + 7) / 8
 	if len(hash) > orderBytes {
 		hash = hash[:orderBytes]
 	}
 
-	ret := new(big.Int).SetBytes(hash)
+	ret.SetBytes(hash)
 	excess := len(hash)*8 - orderBits
 	if excess > 0 {
 		ret.Rsh(ret, uint(excess))
@@ -295,20 +526,44 @@ func fermatInverse(k, N *big.Int) *big.Int {
 	return new(big.Int).Exp(k, nMinus2, N)
 }
 
-var errZeroParam = errors.New("zero parameter")
+var errZeroParam = fmt.Errorf("%w: zero parameter", ErrInvalidCurve)
 
 // Sign signs a hash (which should be the result of hashing a larger message)
 // using the private key, priv. If the hash is longer than the bit-length of the
 // private key's curve order, the hash will be truncated to that length. It
 // returns the signature as a pair of integers. The security of the private key
-// depends on the entropy of rand.
+// depends on the entropy of rand. In FIPS mode, the nonce is derived as
+// specified by RFC 6979 and rand is not used. In strict mode, hash must be as
+// long as the output of a hash function.
+//
+// Deprecated: Sign takes a digest, and silently signs unhashed messages
+// passed by mistake. Use SignMessage, which hashes the message, or
+// SignDigest, which is Sign under an explicit name.
 func Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	if err := strictCheckDigest(hash); err != nil {
+		return nil, nil, err
+	}
+	return signDigest(rand, priv, hash)
+}
+
+// signDigest is Sign without the strict mode check.
+func signDigest(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	start := time.Now()
+	if FIPSMode() {
+		r, s, err = signFIPS(priv, hash)
+		if err == nil {
+			observeSign(priv.Curve, start)
+		}
+		return
+	}
+	rand = entropySource(rand)
 	MaybeReadByte(rand)
 
 	// Get 256 bits of entropy from rand.
 	entropy := make([]byte, 32)
 	_, err = io.ReadFull(rand, entropy)
 	if err != nil {
+		err = entropyError(err)
 		return
 	}
 
@@ -335,10 +590,22 @@ func Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err err
 
 	// See [NSA] 3.4.1
 	c := priv.PublicKey.Curve
-	return sign(priv, &csprng, c, hash)
+	r, s, err = sign(priv, &csprng, c, hash)
+	if err == nil {
+		observeSign(c, start)
+	}
+	return
 }
 
 func signGeneric(priv *PrivateKey, csprng *cipher.StreamReader, c elliptic.Curve, hash []byte) (r, s *big.Int, err error) {
+	return signWithNonces(priv, c, hash, func() (*big.Int, error) {
+		return randFieldElement(c, *csprng)
+	})
+}
+
+// signWithNonces signs hash with priv, drawing a nonce from nonce until the
+// signature is valid.
+func signWithNonces(priv *PrivateKey, c elliptic.Curve, hash []byte, nonce func() (*big.Int, error)) (r, s *big.Int, err error) {
 	N := c.Params().N
 	if N.Sign() == 0 {
 		return nil, nil, errZeroParam
@@ -346,7 +613,7 @@ func signGeneric(priv *PrivateKey, csprng *cipher.StreamReader, c elliptic.Curve
 	var k, kInv *big.Int
 	for {
 		for {
-			k, err = randFieldElement(c, *csprng)
+			k, err = nonce()
 			if err != nil {
 				r = nil
 				return
@@ -388,13 +655,30 @@ func SignASN1(rand io.Reader, priv *PrivateKey, hash []byte) ([]byte, error) {
 }
 
 // Verify verifies the signature in r, s of hash using the public key, pub. Its
-// return value records whether the signature is valid.
+// return value records whether the signature is valid. Signatures are always
+// rejected if pub does not pass ValidatePublicKey and, in strict mode, if hash
+// is not as long as the output of a hash function.
+//
+// Deprecated: Verify takes a digest, and silently verifies unhashed messages
+// passed by mistake. Use VerifyMessage, which hashes the message, or
+// VerifyDigest, which is Verify under an explicit name.
 func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
+	if strictCheckDigest(hash) != nil || !verifyRange(pub, hash, r, s) {
+		observeVerifyFailure(pub.Curve)
+		return false
+	}
+	return true
+}
+
+func verifyRange(pub *PublicKey, hash []byte, r, s *big.Int) bool {
 	// See [NSA] 3.4.2
 	c := pub.Curve
+	if ValidatePublicKey(c, pub) != nil || fipsCheckCurve(c) != nil {
+		return false
+	}
 	N := c.Params().N
 
-	if r.Sign() <= 0 || s.Sign() <= 0 {
+	if r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 {
 		return false
 	}
 	if r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
@@ -403,28 +687,105 @@ func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
 	return verify(pub, c, hash, r, s)
 }
 
+// CheckSignature verifies the signature in r, s of hash using the public key,
+// pub. Unlike Verify, it returns an error that distinguishes an invalid public
+// key, which wraps ErrPointNotOnCurve, from a signature that does not verify,
+// which wraps ErrInvalidSignature. It returns nil if the signature is valid.
+// In strict mode, it returns an error wrapping ErrInvalidDigest if hash is not
+// as long as the output of a hash function.
+func CheckSignature(pub *PublicKey, hash []byte, r, s *big.Int) error {
+	err := strictCheckDigest(hash)
+	if err == nil {
+		err = checkSignature(pub, hash, r, s)
+	}
+	if err != nil {
+		observeVerifyFailure(pub.Curve)
+	}
+	return err
+}
+
+func checkSignature(pub *PublicKey, hash []byte, r, s *big.Int) error {
+	if _, err := pub.Point(); err != nil {
+		return err
+	}
+	if err := fipsCheckCurve(pub.Curve); err != nil {
+		return err
+	}
+	if r == nil || s == nil {
+		return wrapError(ErrInvalidSignature, "missing signature value")
+	}
+	N := pub.Curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return wrapError(ErrInvalidSignature, "signature value out of range")
+	}
+	if !verify(pub, pub.Curve, hash, r, s) {
+		return wrapError(ErrInvalidSignature, "signature mismatch")
+	}
+	return nil
+}
+
+// verifyScratch holds the temporary values of verifyGeneric, which are
+// recycled through verifyPool to reduce allocations.
+type verifyScratch struct {
+	e, w, u1, u2 big.Int
+	b1, b2       []byte
+}
+
+var verifyPool = sync.Pool{
+	New: func() interface{} { return new(verifyScratch) },
+}
+
+// scalarBytes returns the fixed-length encoding of k in buf, growing buf if
+// needed.
+func scalarBytes(buf *[]byte, k *big.Int, size int) []byte {
+	if cap(*buf) < size {
+		*buf = make([]byte, size)
+	}
+	return k.FillBytes((*buf)[:size])
+}
+
 func verifyGeneric(pub *PublicKey, c elliptic.Curve, hash []byte, r, s *big.Int) bool {
-	e := hashToInt(hash, c)
+	if isStdCurve(c) {
+		return ecdsa.Verify(&ecdsa.PublicKey{Curve: c, X: pub.X, Y: pub.Y}, hash, r, s)
+	}
+
+	return verifyScaled(pub, c, hash, r, s, nil)
+}
+
+// verifyScaled verifies the signature in r, s of hash under the public key
+// blind * pub, without computing that key: the multiplication by blind is
+// folded into the scalar applied to pub. A nil blind stands for one.
+func verifyScaled(pub *PublicKey, c elliptic.Curve, hash []byte, r, s, blind *big.Int) bool {
+	v := verifyPool.Get().(*verifyScratch)
+	defer verifyPool.Put(v)
+
+	e := setHashToInt(&v.e, hash, c)
 	var w *big.Int
 	N := c.Params().N
 	if in, ok := c.(invertible); ok {
 		w = in.Inverse(s)
 	} else {
-		w = new(big.Int).ModInverse(s, N)
+		w = v.w.ModInverse(s, N)
 	}
 
-	u1 := e.Mul(e, w)
+	u1 := v.u1.Mul(e, w)
 	u1.Mod(u1, N)
-	u2 := w.Mul(r, w)
+	u2 := v.u2.Mul(r, w)
+	if blind != nil {
+		u2.Mod(u2, N)
+		u2.Mul(u2, blind)
+	}
 	u2.Mod(u2, N)
+	size := scalarSize(c)
+	b1, b2 := scalarBytes(&v.b1, u1, size), scalarBytes(&v.b2, u2, size)
 
 	// Check if implements S1*g + S2*p
 	var x, y *big.Int
 	if opt, ok := c.(combinedMult); ok {
-		x, y = opt.CombinedMult(pub.X, pub.Y, u1.Bytes(), u2.Bytes())
+		x, y = opt.CombinedMult(pub.X, pub.Y, b1, b2)
 	} else {
-		x1, y1 := c.ScalarBaseMult(u1.Bytes())
-		x2, y2 := c.ScalarMult(pub.X, pub.Y, u2.Bytes())
+		x1, y1 := c.ScalarBaseMult(b1)
+		x2, y2 := c.ScalarMult(pub.X, pub.Y, b2)
 		x, y = c.Add(x1, y1, x2, y2)
 	}
 
@@ -435,22 +796,69 @@ func verifyGeneric(pub *PublicKey, c elliptic.Curve, hash []byte, r, s *big.Int)
 	return x.Cmp(r) == 0
 }
 
+// VerifyWithBlindWithContext verifies the signature in r, s of hash under the
+// public key pkS blinded by skB and context, as produced by
+// BlindKeySignWithContext. It is equivalent to Verify with the result of
+// BlindPublicKeyWithContext, but saves a scalar multiplication and doesn't
+// allocate the blinded key, which matters to verifiers that check many
+// signatures under the same key and blind.
+func VerifyWithBlindWithContext(pkS *PublicKey, skB *PrivateKey, hash, context []byte, r, s *big.Int) bool {
+	c := pkS.Curve
+	if !verifyWithBlind(pkS, skB, hash, context, r, s) {
+		observeVerifyFailure(c)
+		return false
+	}
+	return true
+}
+
+func verifyWithBlind(pkS *PublicKey, skB *PrivateKey, hash, context []byte, r, s *big.Int) bool {
+	c := pkS.Curve
+	if ValidatePublicKey(c, pkS) != nil || fipsCheckCurve(c) != nil || strictCheckDigest(hash) != nil {
+		return false
+	}
+	if r == nil || s == nil {
+		return false
+	}
+	N := c.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+	blind, err := hashBlind(c, skB, context)
+	if err != nil {
+		return false
+	}
+	return verifyScaled(pkS, c, hash, r, s, blind)
+}
+
+// VerifyWithBlind verifies the signature in r, s of hash under the public key
+// pkS blinded by skB and empty context string, as produced by BlindKeySign.
+func VerifyWithBlind(pkS *PublicKey, skB *PrivateKey, hash []byte, r, s *big.Int) bool {
+	return VerifyWithBlindWithContext(pkS, skB, hash, nil, r, s)
+}
+
 // VerifyASN1 verifies the ASN.1 encoded signature, sig, of hash using the
 // public key, pub. Its return value records whether the signature is valid.
 func VerifyASN1(pub *PublicKey, hash, sig []byte) bool {
-	var (
-		r, s  = &big.Int{}, &big.Int{}
-		inner cryptobyte.String
-	)
+	r, s, ok := parseASN1Signature(sig)
+	if !ok {
+		return false
+	}
+	return Verify(pub, hash, r, s)
+}
+
+// parseASN1Signature decodes an ASN.1 DER signature into r and s.
+func parseASN1Signature(sig []byte) (r, s *big.Int, ok bool) {
+	var inner cryptobyte.String
+	r, s = &big.Int{}, &big.Int{}
 	input := cryptobyte.String(sig)
 	if !input.ReadASN1(&inner, asn1.SEQUENCE) ||
 		!input.Empty() ||
 		!inner.ReadASN1Integer(r) ||
 		!inner.ReadASN1Integer(s) ||
 		!inner.Empty() {
-		return false
+		return nil, nil, false
 	}
-	return Verify(pub, hash, r, s)
+	return r, s, true
 }
 
 type zr struct {
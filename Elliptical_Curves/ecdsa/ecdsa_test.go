@@ -5,38 +5,34 @@
 package ecdsa
 
 import (
-	"bufio"
-	"compress/bzip2"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/sha1"
 	"crypto/sha256"
-	"crypto/sha512"
-	"encoding/hex"
-	"hash"
 	"io"
 	"math/big"
-	"os"
-	"strings"
 	"testing"
+
+	"github.com/cloudflare/pat-go/ecdsa/testvectors"
 )
 
+// testDigest returns the SHA-256 digest of msg, which the tests sign rather
+// than msg itself so that they also pass in strict mode.
+func testDigest(msg string) []byte {
+	h := sha256.Sum256([]byte(msg))
+	return h[:]
+}
+
 func testAllCurves(t *testing.T, f func(*testing.T, elliptic.Curve)) {
-	tests := []struct {
-		name  string
-		curve elliptic.Curve
-	}{
-		{"P256", elliptic.P256()},
-		{"P224", elliptic.P224()},
-		{"P384", elliptic.P384()},
-		{"P521", elliptic.P521()},
-	}
+	curves := RegisteredCurves()
 	if testing.Short() {
-		tests = tests[:1]
+		curves = curves[:1]
 	}
-	for _, test := range tests {
-		curve := test.curve
-		t.Run(test.name, func(t *testing.T) {
+	for _, curve := range curves {
+		curve := curve
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			if err := fipsCheckCurve(curve); err != nil {
+				t.Skip(err)
+			}
 			t.Parallel()
 			f(t, curve)
 		})
@@ -62,8 +58,8 @@ func testKeyGeneration(t *testing.T, c elliptic.Curve) {
 func testRelatedKeySignOracleAttack(t *testing.T, c elliptic.Curve) {
 	skS, _ := GenerateKey(c, rand.Reader)
 
-	m0 := []byte("m0")
-	m1 := []byte("m1")
+	m0 := testDigest("m0")
+	m1 := testDigest("m1")
 	z0 := hashToInt(m0, c)
 	z1 := hashToInt(m1, c)
 	z0Inv := new(big.Int).ModInverse(z0, c.Params().N)
@@ -103,7 +99,7 @@ func testBlindKeySign(t *testing.T, c elliptic.Curve) {
 	skS, _ := GenerateKey(c, rand.Reader)
 	skB, _ := GenerateKey(c, rand.Reader)
 
-	hashed := []byte("testing")
+	hashed := testDigest("testing")
 	r, s, err := BlindKeySign(rand.Reader, skS, skB, hashed)
 	if err != nil {
 		t.Errorf("BlindKeySign error: %s", err)
@@ -119,6 +115,68 @@ func testBlindKeySign(t *testing.T, c elliptic.Curve) {
 	if !Verify(pkR, hashed, r, s) {
 		t.Errorf("Verify failed")
 	}
+
+	skR, err := BlindPrivateKey(skS, skB)
+	if err != nil {
+		t.Fatalf("BlindPrivateKey error: %s", err)
+	}
+	if !skR.PublicKey.Equal(pkR) {
+		t.Errorf("BlindPrivateKey public key does not match BlindPublicKey")
+	}
+}
+
+func TestVerifyWithBlind(t *testing.T) {
+	testAllCurves(t, testVerifyWithBlind)
+}
+
+func testVerifyWithBlind(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	other, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+
+	hashed := testDigest("testing")
+	r, s, err := BlindKeySignWithContext(rand.Reader, skS, skB, hashed, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignWithContext error: %s", err)
+	}
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	if !Verify(pkR, hashed, r, s) {
+		t.Fatalf("Verify failed")
+	}
+
+	if !VerifyWithBlindWithContext(&skS.PublicKey, skB, hashed, context, r, s) {
+		t.Errorf("VerifyWithBlindWithContext failed")
+	}
+	if VerifyWithBlind(&skS.PublicKey, skB, hashed, r, s) {
+		t.Errorf("VerifyWithBlind accepted a signature under another context")
+	}
+	if VerifyWithBlindWithContext(&skS.PublicKey, other, hashed, context, r, s) {
+		t.Errorf("VerifyWithBlindWithContext accepted a signature under another blind")
+	}
+	if VerifyWithBlindWithContext(&other.PublicKey, skB, hashed, context, r, s) {
+		t.Errorf("VerifyWithBlindWithContext accepted a signature under another key")
+	}
+	if VerifyWithBlindWithContext(&skS.PublicKey, skB, []byte("other"), context, r, s) {
+		t.Errorf("VerifyWithBlindWithContext accepted a signature of another hash")
+	}
+	if VerifyWithBlindWithContext(&skS.PublicKey, skB, hashed, context, s, r) {
+		t.Errorf("VerifyWithBlindWithContext accepted a swapped signature")
+	}
+	if VerifyWithBlindWithContext(&skS.PublicKey, nil, hashed, context, r, s) {
+		t.Errorf("VerifyWithBlindWithContext accepted a missing blind")
+	}
+
+	r, s, err = BlindKeySign(rand.Reader, skS, skB, hashed)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	if !VerifyWithBlind(&skS.PublicKey, skB, hashed, r, s) {
+		t.Errorf("VerifyWithBlind failed")
+	}
 }
 
 func TestBlindPublicKey(t *testing.T) {
@@ -153,7 +211,7 @@ func TestSignAndVerify(t *testing.T) {
 func testSignAndVerify(t *testing.T, c elliptic.Curve) {
 	priv, _ := GenerateKey(c, rand.Reader)
 
-	hashed := []byte("testing")
+	hashed := testDigest("testing")
 	r, s, err := Sign(rand.Reader, priv, hashed)
 	if err != nil {
 		t.Errorf("error signing: %s", err)
@@ -177,7 +235,7 @@ func TestSignAndVerifyASN1(t *testing.T) {
 func testSignAndVerifyASN1(t *testing.T, c elliptic.Curve) {
 	priv, _ := GenerateKey(c, rand.Reader)
 
-	hashed := []byte("testing")
+	hashed := testDigest("testing")
 	sig, err := SignASN1(rand.Reader, priv, hashed)
 	if err != nil {
 		t.Errorf("error signing: %s", err)
@@ -201,14 +259,14 @@ func TestNonceSafety(t *testing.T) {
 func testNonceSafety(t *testing.T, c elliptic.Curve) {
 	priv, _ := GenerateKey(c, rand.Reader)
 
-	hashed := []byte("testing")
+	hashed := testDigest("testing")
 	r0, s0, err := Sign(zeroReader, priv, hashed)
 	if err != nil {
 		t.Errorf("error signing: %s", err)
 		return
 	}
 
-	hashed = []byte("testing...")
+	hashed = testDigest("testing...")
 	r1, s1, err := Sign(zeroReader, priv, hashed)
 	if err != nil {
 		t.Errorf("error signing: %s", err)
@@ -230,9 +288,12 @@ func TestINDCCA(t *testing.T) {
 }
 
 func testINDCCA(t *testing.T, c elliptic.Curve) {
+	if FIPSMode() {
+		t.Skip("signatures are deterministic in FIPS mode")
+	}
 	priv, _ := GenerateKey(c, rand.Reader)
 
-	hashed := []byte("testing")
+	hashed := testDigest("testing")
 	r0, s0, err := Sign(rand.Reader, priv, hashed)
 	if err != nil {
 		t.Errorf("error signing: %s", err)
@@ -254,131 +315,30 @@ func testINDCCA(t *testing.T, c elliptic.Curve) {
 	}
 }
 
-func fromHex(s string) *big.Int {
-	r, ok := new(big.Int).SetString(s, 16)
-	if !ok {
-		panic("bad hex")
-	}
-	return r
-}
-
-// cut slices s around the first instance of sep,
-// returning the text before and after sep.
-// The found result reports whether sep appears in s.
-// If sep does not appear in s, cut returns s, "", false.
-func cut(s, sep string) (before, after string, found bool) {
-	if i := strings.Index(s, sep); i >= 0 {
-		return s[:i], s[i+len(sep):], true
-	}
-	return s, "", false
-}
-
 func TestVectors(t *testing.T) {
 	// This test runs the full set of NIST test vectors from
 	// https://csrc.nist.gov/groups/STM/cavp/documents/dss/186-3ecdsatestvectors.zip
-	//
-	// The SigVer.rsp file has been edited to remove test vectors for
-	// unsupported algorithms and has been compressed.
+	// embedded in the testvectors package.
 
 	if testing.Short() {
 		return
 	}
 
-	f, err := os.Open("testdata/SigVer.rsp.bz2")
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	buf := bufio.NewReader(bzip2.NewReader(f))
-
-	lineNo := 1
-	var h hash.Hash
-	var msg []byte
-	var hashed []byte
-	var r, s *big.Int
-	pub := new(PublicKey)
-
+	vectors := testvectors.SigVer()
 	for {
-		line, err := buf.ReadString('\n')
-		if len(line) == 0 {
-			if err == io.EOF {
-				break
-			}
-			t.Fatalf("error reading from input: %s", err)
+		v, err := vectors.Next()
+		if err == io.EOF {
+			break
 		}
-		lineNo++
-		// Need to remove \r\n from the end of the line.
-		if !strings.HasSuffix(line, "\r\n") {
-			t.Fatalf("bad line ending (expected \\r\\n) on line %d", lineNo)
-		}
-		line = line[:len(line)-2]
-
-		if len(line) == 0 || line[0] == '#' {
-			continue
-		}
-
-		if line[0] == '[' {
-			line = line[1 : len(line)-1]
-			curve, hash, _ := cut(line, ",")
-
-			switch curve {
-			case "P-224":
-				pub.Curve = elliptic.P224()
-			case "P-256":
-				pub.Curve = elliptic.P256()
-			case "P-384":
-				pub.Curve = elliptic.P384()
-			case "P-521":
-				pub.Curve = elliptic.P521()
-			default:
-				pub.Curve = nil
-			}
-
-			switch hash {
-			case "SHA-1":
-				h = sha1.New()
-			case "SHA-224":
-				h = sha256.New224()
-			case "SHA-256":
-				h = sha256.New()
-			case "SHA-384":
-				h = sha512.New384()
-			case "SHA-512":
-				h = sha512.New()
-			default:
-				h = nil
-			}
-
-			continue
+		if err != nil {
+			t.Fatal(err)
 		}
-
-		if h == nil || pub.Curve == nil {
+		if v.Curve == nil || v.Hash == 0 {
 			continue
 		}
-
-		switch {
-		case strings.HasPrefix(line, "Msg = "):
-			if msg, err = hex.DecodeString(line[6:]); err != nil {
-				t.Fatalf("failed to decode message on line %d: %s", lineNo, err)
-			}
-		case strings.HasPrefix(line, "Qx = "):
-			pub.X = fromHex(line[5:])
-		case strings.HasPrefix(line, "Qy = "):
-			pub.Y = fromHex(line[5:])
-		case strings.HasPrefix(line, "R = "):
-			r = fromHex(line[4:])
-		case strings.HasPrefix(line, "S = "):
-			s = fromHex(line[4:])
-		case strings.HasPrefix(line, "Result = "):
-			expected := line[9] == 'P'
-			h.Reset()
-			h.Write(msg)
-			hashed := h.Sum(hashed[:0])
-			if Verify(pub, hashed, r, s) != expected {
-				t.Fatalf("incorrect result on line %d", lineNo)
-			}
-		default:
-			t.Fatalf("unknown variable on line %d: %s", lineNo, line)
+		pub := &PublicKey{Curve: v.Curve, X: v.Qx, Y: v.Qy}
+		if Verify(pub, v.Digest(), v.R, v.S) != v.Valid {
+			t.Fatalf("incorrect result on line %d", v.Line)
 		}
 	}
 }
@@ -401,6 +361,9 @@ func testNegativeInputs(t *testing.T, curve elliptic.Curve) {
 	if Verify(&key.PublicKey, hash[:], r, r) {
 		t.Errorf("bogus signature accepted")
 	}
+	if Verify(&key.PublicKey, hash[:], nil, nil) {
+		t.Errorf("missing signature accepted")
+	}
 }
 
 func TestZeroHashSignature(t *testing.T) {
@@ -451,7 +414,7 @@ func BenchmarkSign(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
-		hashed := []byte("testing")
+		hashed := testDigest("testing")
 
 		b.ReportAllocs()
 		b.ResetTimer()
@@ -472,7 +435,7 @@ func BenchmarkVerify(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
-		hashed := []byte("testing")
+		hashed := testDigest("testing")
 		r, s, err := Sign(rand.Reader, priv, hashed)
 		if err != nil {
 			b.Fatal(err)
@@ -549,7 +512,7 @@ func BenchmarkBlindKeySign(b *testing.B) {
 	benchmarkAllCurves(b, func(b *testing.B, curve elliptic.Curve) {
 		skS, _ := GenerateKey(curve, rand.Reader)
 		skB, _ := GenerateKey(curve, rand.Reader)
-		hashed := []byte("testing")
+		hashed := testDigest("testing")
 		r, s, err := BlindKeySign(rand.Reader, skS, skB, hashed)
 		if err != nil {
 			b.Fatal(err)
@@ -564,6 +527,34 @@ func BenchmarkBlindKeySign(b *testing.B) {
 	})
 }
 
+func BenchmarkVerifyWithBlind(b *testing.B) {
+	benchmarkAllCurves(b, func(b *testing.B, curve elliptic.Curve) {
+		skS, _ := GenerateKey(curve, rand.Reader)
+		skB, _ := GenerateKey(curve, rand.Reader)
+		hashed := testDigest("testing")
+		r, s, err := BlindKeySign(rand.Reader, skS, skB, hashed)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run("BlindPublicKey", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				pkR, _ := BlindPublicKey(curve, &skS.PublicKey, skB)
+				if !Verify(pkR, hashed, r, s) {
+					b.Fatal("Verify failed")
+				}
+			}
+		})
+		b.Run("VerifyWithBlind", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if !VerifyWithBlind(&skS.PublicKey, skB, hashed, r, s) {
+					b.Fatal("VerifyWithBlind failed")
+				}
+			}
+		})
+	})
+}
 
 func benchmarkAllCurves(t *testing.B, f func(*testing.B, elliptic.Curve)) {
 	tests := []struct {
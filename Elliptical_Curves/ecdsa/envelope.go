@@ -0,0 +1,220 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	envelopeDST = "ECDSA Envelope"
+
+	// EnvelopeVersion is the version of the detached signature and envelope
+	// formats produced by this package.
+	EnvelopeVersion = 1
+)
+
+// envelopeCurves maps the curve identifiers of the envelope format to curves.
+// Identifiers are never reused.
+var envelopeCurves = map[uint8]func() elliptic.Curve{
+	0x01: elliptic.P256,
+	0x02: elliptic.P384,
+	0x03: elliptic.P521,
+	0x04: elliptic.P224,
+	0x05: brainpool.P256r1,
+	0x06: brainpool.P384r1,
+	0x07: brainpool.P512r1,
+}
+
+func envelopeCurveID(c elliptic.Curve) (uint8, error) {
+	for id, curve := range envelopeCurves {
+		if curve().Params() == c.Params() {
+			return id, nil
+		}
+	}
+	return 0, wrapError(ErrInvalidCurve, "unsupported curve %s", c.Params().Name)
+}
+
+// envelopeDigest hashes a message and the header of its signature with the
+// hash function used for blinding on the curve of the blinded key:
+//
+//	H(len(DST) || DST || version || curve_id || len(pkR) || pkR || message)
+//
+// where pkR is the compressed point encoding and lengths are 2-byte
+// big-endian integers. Binding the header prevents a signature from being
+// presented under another version or curve.
+func envelopeDigest(id uint8, pkR *PublicKey, message []byte) ([]byte, error) {
+	h, _, err := blindParams(pkR.Curve)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(envelopeDST))
+	})
+	b.AddUint8(EnvelopeVersion)
+	b.AddUint8(id)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y))
+	})
+
+	md := h.New()
+	md.Write(b.BytesOrPanic())
+	md.Write(message)
+	return md.Sum(nil), nil
+}
+
+// CreateDetachedSignature signs message with skS blinded by skB and context,
+// and encodes the signature along with the blinded public key that verifies
+// it as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  uint8 curve_id;
+//	  opaque blinded_key<1..2^16-1>;
+//	  opaque r[Ns];
+//	  opaque s[Ns];
+//	} DetachedSignature;
+//
+// where blinded_key is the compressed point encoding and Ns is the length of
+// a scalar of the curve. The curve identifiers are 1 for P-256, 2 for P-384,
+// 3 for P-521, 4 for P-224, and 5, 6 and 7 for brainpoolP256r1,
+// brainpoolP384r1 and brainpoolP512r1. The message is hashed with the hash
+// function used for blinding on the curve, so callers pass the message
+// itself rather than a digest.
+func CreateDetachedSignature(rand io.Reader, skS, skB *PrivateKey, message, context []byte) ([]byte, error) {
+	id, err := envelopeCurveID(skS.Curve)
+	if err != nil {
+		return nil, err
+	}
+	skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := envelopeDigest(id, &skR.PublicKey, message)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := Sign(rand, skR, digest)
+	if err != nil {
+		return nil, err
+	}
+	logIssuance(&skR.PublicKey, digest)
+
+	size := scalarSize(skR.Curve)
+	var b cryptobyte.Builder
+	b.AddUint8(EnvelopeVersion)
+	b.AddUint8(id)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(skR.Curve, skR.X, skR.Y))
+	})
+	b.AddBytes(r.FillBytes(make([]byte, size)))
+	b.AddBytes(s.FillBytes(make([]byte, size)))
+	return b.Bytes()
+}
+
+// readDetachedSignature decodes a DetachedSignature from in, leaving the rest
+// of in unread.
+func readDetachedSignature(in *cryptobyte.String) (id uint8, pkR *PublicKey, sig *Signature, err error) {
+	var version uint8
+	var enc cryptobyte.String
+	if !in.ReadUint8(&version) || !in.ReadUint8(&id) || !in.ReadUint16LengthPrefixed(&enc) {
+		return 0, nil, nil, wrapError(ErrInvalidSignature, "malformed detached signature")
+	}
+	if version != EnvelopeVersion {
+		return 0, nil, nil, wrapError(ErrInvalidSignature, "unsupported version %d", version)
+	}
+	curve, ok := envelopeCurves[id]
+	if !ok {
+		return 0, nil, nil, wrapError(ErrInvalidCurve, "unknown curve identifier %d", id)
+	}
+	c := curve()
+	p, err := NewPoint(c, enc)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if pkR, err = NewPublicKey(p); err != nil {
+		return 0, nil, nil, err
+	}
+	size := scalarSize(c)
+	var r, s []byte
+	if !in.ReadBytes(&r, size) || !in.ReadBytes(&s, size) {
+		return 0, nil, nil, wrapError(ErrInvalidSignature, "malformed detached signature")
+	}
+	return id, pkR, &Signature{R: new(big.Int).SetBytes(r), S: new(big.Int).SetBytes(s)}, nil
+}
+
+// VerifyDetachedSignature checks that sig, encoded by CreateDetachedSignature,
+// is a valid signature of message, and returns the blinded public key that
+// produced it. The signature only proves that the message was signed by the
+// returned key: callers must check that they trust that key, for example with
+// an Attestation by the unblinded key.
+func VerifyDetachedSignature(sig, message []byte) (*PublicKey, error) {
+	in := cryptobyte.String(sig)
+	id, pkR, s, err := readDetachedSignature(&in)
+	if err != nil {
+		return nil, err
+	}
+	if !in.Empty() {
+		return nil, wrapError(ErrInvalidSignature, "malformed detached signature")
+	}
+	digest, err := envelopeDigest(id, pkR, message)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckSignature(pkR, digest, s.R, s.S); err != nil {
+		return nil, err
+	}
+	return pkR, nil
+}
+
+// CreateEnvelope signs message like CreateDetachedSignature, and returns the
+// signed message as:
+//
+//	struct {
+//	  DetachedSignature signature;
+//	  opaque message<0..2^32-1>;
+//	} Envelope;
+func CreateEnvelope(rand io.Reader, skS, skB *PrivateKey, message, context []byte) ([]byte, error) {
+	sig, err := CreateDetachedSignature(rand, skS, skB, message, context)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(sig)
+	b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(message)
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidDigest, "message too long")
+	}
+	return out, nil
+}
+
+// OpenEnvelope verifies an envelope encoded by CreateEnvelope, and returns
+// the message it holds and the blinded public key that signed it. As with
+// VerifyDetachedSignature, callers must check that they trust that key.
+func OpenEnvelope(envelope []byte) (pkR *PublicKey, message []byte, err error) {
+	in := cryptobyte.String(envelope)
+	id, pkR, sig, err := readDetachedSignature(&in)
+	if err != nil {
+		return nil, nil, err
+	}
+	var n uint32
+	var msg []byte
+	if !in.ReadUint32(&n) || !in.ReadBytes(&msg, int(n)) || !in.Empty() {
+		return nil, nil, wrapError(ErrInvalidSignature, "malformed envelope")
+	}
+	digest, err := envelopeDigest(id, pkR, msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := CheckSignature(pkR, digest, sig.R, sig.S); err != nil {
+		return nil, nil, err
+	}
+	return pkR, append([]byte{}, msg...), nil
+}
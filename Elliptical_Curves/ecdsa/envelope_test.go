@@ -0,0 +1,95 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	testAllCurves(t, testEnvelope)
+}
+
+func testEnvelope(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	message := []byte("hello, world")
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+
+	if _, err := envelopeCurveID(c); err != nil {
+		// Registered curves have no envelope identifier.
+		if _, err := CreateEnvelope(rand.Reader, skS, skB, message, context); !errors.Is(err, ErrInvalidCurve) {
+			t.Errorf("CreateEnvelope on %s: got %v, want ErrInvalidCurve", c.Params().Name, err)
+		}
+		return
+	}
+
+	sig, err := CreateDetachedSignature(rand.Reader, skS, skB, message, context)
+	if err != nil {
+		t.Fatalf("CreateDetachedSignature error: %s", err)
+	}
+	pk, err := VerifyDetachedSignature(sig, message)
+	if err != nil {
+		t.Fatalf("VerifyDetachedSignature error: %s", err)
+	}
+	if !pk.Equal(pkR) {
+		t.Errorf("detached signature is not under the blinded key")
+	}
+	if _, err := VerifyDetachedSignature(sig, []byte("other")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyDetachedSignature of another message: got %v, want ErrInvalidSignature", err)
+	}
+
+	envelope, err := CreateEnvelope(rand.Reader, skS, skB, message, context)
+	if err != nil {
+		t.Fatalf("CreateEnvelope error: %s", err)
+	}
+	pk, got, err := OpenEnvelope(envelope)
+	if err != nil {
+		t.Fatalf("OpenEnvelope error: %s", err)
+	}
+	if !pk.Equal(pkR) || !bytes.Equal(got, message) {
+		t.Errorf("OpenEnvelope returned %x under another key or message", got)
+	}
+	if _, _, err := OpenEnvelope(envelope[:len(envelope)-1]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("OpenEnvelope of a truncated envelope: got %v, want ErrInvalidSignature", err)
+	}
+	if _, _, err := OpenEnvelope(append(envelope, 0)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("OpenEnvelope with trailing data: got %v, want ErrInvalidSignature", err)
+	}
+	for i := range envelope {
+		tampered := append([]byte{}, envelope...)
+		tampered[i] ^= 0x01
+		if _, _, err := OpenEnvelope(tampered); err == nil {
+			t.Fatalf("OpenEnvelope accepted byte %d tampered", i)
+		}
+	}
+}
+
+func TestEnvelopeHeader(t *testing.T) {
+	skS, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	skB, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	envelope, err := CreateEnvelope(rand.Reader, skS, skB, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateEnvelope error: %s", err)
+	}
+	if envelope[0] != EnvelopeVersion || envelope[1] != 0x01 {
+		t.Errorf("got version %d and curve %d, want %d and 1", envelope[0], envelope[1], EnvelopeVersion)
+	}
+	if _, _, err := OpenEnvelope(envelope); err != nil {
+		t.Errorf("OpenEnvelope of an empty message error: %s", err)
+	}
+
+	version := append([]byte{}, envelope...)
+	version[0] = 2
+	if _, _, err := OpenEnvelope(version); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("OpenEnvelope of version 2: got %v, want ErrInvalidSignature", err)
+	}
+	curve := append([]byte{}, envelope...)
+	curve[1] = 0xff
+	if _, _, err := OpenEnvelope(curve); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("OpenEnvelope with an unknown curve: got %v, want ErrInvalidCurve", err)
+	}
+}
@@ -0,0 +1,129 @@
+package ecdsa
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by this package. Errors are wrapped with additional context,
+// so they should be compared with errors.Is.
+//
+// ErrInvalidCurve, ErrPointNotOnCurve, ErrInvalidScalar, ErrZeroBlind,
+// ErrWeakBlind, ErrCurveMismatch, ErrInvalidShare, ErrInvalidSeed,
+// ErrInvalidDigest, ErrInvalidKeyring, ErrInvalidSnapshot,
+// ErrInvalidStructuredData and ErrInvalidWrap report invalid inputs,
+// ErrInvalidSignature, ErrInvalidCommitment and ErrInvalidProof report a
+// signature, commitment or proof that failed to verify, ErrEntropy reports a
+// failure of the randomness source, and ErrRateLimited, ErrPolicy, ErrFIPS,
+// ErrSessionClosed, ErrNonceReuse, ErrShowLimit and ErrDoubleSpend report a
+// refused operation.
+var (
+	// ErrInvalidCurve is returned when a curve is not supported by an
+	// operation, or its parameters are invalid.
+	ErrInvalidCurve = errors.New("ecdsa: invalid curve")
+
+	// ErrPointNotOnCurve is returned when a point or public key is not on
+	// its curve, is badly encoded, or is the point at infinity.
+	ErrPointNotOnCurve = errors.New("ecdsa: point not on curve")
+
+	// ErrInvalidScalar is returned when a scalar or private key is out of
+	// range for its curve, or is badly encoded.
+	ErrInvalidScalar = errors.New("ecdsa: invalid scalar")
+
+	// ErrZeroBlind is returned when a blind is missing or derives to zero.
+	ErrZeroBlind = errors.New("ecdsa: zero blind")
+
+	// ErrWeakBlind is returned when a blind is 1 or N-1, or derives to 1 or
+	// N-1, so that the blinded key is linkable to the base key.
+	ErrWeakBlind = errors.New("ecdsa: weak blind")
+
+	// ErrInvalidSignature is returned when a signature fails to verify.
+	ErrInvalidSignature = errors.New("ecdsa: invalid signature")
+
+	// ErrInvalidCommitment is returned when a commitment does not open to
+	// the claimed value.
+	ErrInvalidCommitment = errors.New("ecdsa: invalid commitment")
+
+	// ErrInvalidProof is returned when a zero-knowledge proof is badly
+	// encoded or fails to verify.
+	ErrInvalidProof = errors.New("ecdsa: invalid proof")
+
+	// ErrCurveMismatch is returned when the inputs of an operation are on
+	// different curves.
+	ErrCurveMismatch = errors.New("ecdsa: curve mismatch")
+
+	// ErrEntropy is returned when reading from the randomness source fails,
+	// or when its output fails a health check enabled with SetConfig. The
+	// returned error is an *EntropyError.
+	ErrEntropy = errors.New("ecdsa: failed to read randomness")
+
+	// ErrRateLimited is returned by RateLimitedSigner when a signature is
+	// refused because a rate limit was exceeded. The returned error is a
+	// *RateLimitError.
+	ErrRateLimited = errors.New("ecdsa: rate limited")
+
+	// ErrInvalidShare is returned when a secret share of a private key is
+	// corrupted, or when shares are inconsistent or too few to recover the
+	// key.
+	ErrInvalidShare = errors.New("ecdsa: invalid key share")
+
+	// ErrInvalidSeed is returned when a seed is too short to derive a key
+	// from, or when the parameters to derive a blind from a password are
+	// invalid.
+	ErrInvalidSeed = errors.New("ecdsa: invalid seed")
+
+	// ErrInvalidDigest is returned when a digest does not match the hash
+	// function declared for it.
+	ErrInvalidDigest = errors.New("ecdsa: invalid digest")
+
+	// ErrInvalidKeyring is returned when a keyring file is malformed or
+	// can't be decrypted with the given key.
+	ErrInvalidKeyring = errors.New("ecdsa: invalid keyring")
+
+	// ErrInvalidSnapshot is returned when a snapshot of signer state is
+	// malformed, of an unsupported version, can't be authenticated with the
+	// given key, or doesn't match the keys it is restored with.
+	ErrInvalidSnapshot = errors.New("ecdsa: invalid snapshot")
+
+	// ErrInvalidStructuredData is returned when structured data can't be
+	// encoded canonically by its Codec, for example JSON with duplicate
+	// object keys or a number that is not finite.
+	ErrInvalidStructuredData = errors.New("ecdsa: invalid structured data")
+
+	// ErrInvalidWrap is returned when wrapped blinds are malformed, expired,
+	// not for the given recipient key, or fail to authenticate.
+	ErrInvalidWrap = errors.New("ecdsa: invalid wrapped blinds")
+
+	// ErrPolicy is returned by PolicyKey when a signature is refused because
+	// it is outside of the policy of the key, and when a policy can't be
+	// encoded or doesn't match its key.
+	ErrPolicy = errors.New("ecdsa: key policy violated")
+
+	// ErrSessionClosed is returned when a SignerSession is used after it
+	// was completed or aborted.
+	ErrSessionClosed = errors.New("ecdsa: signer session closed")
+
+	// ErrNonceReuse is returned by NonceGuard when a signature is withheld
+	// because its key already used its nonce for a different digest, by
+	// split nonces when a nonce share was already used, and by
+	// RestoreSignerSessions when a snapshot was already restored.
+	ErrNonceReuse = errors.New("ecdsa: nonce reused")
+
+	// ErrFIPS is returned in FIPS mode when an operation uses a curve or
+	// hash function that is not approved, and when a self-test or a
+	// pairwise consistency test fails.
+	ErrFIPS = errors.New("ecdsa: not allowed in FIPS mode")
+
+	// ErrShowLimit is returned by ShowLimiter when a show repeats the tag of
+	// an earlier one, which means its signer exceeded its limit of shows.
+	ErrShowLimit = errors.New("ecdsa: show limit exceeded")
+
+	// ErrDoubleSpend is returned by a SpendStore when a double-spend tag was
+	// already redeemed at the same verifier.
+	ErrDoubleSpend = errors.New("ecdsa: credential already redeemed")
+)
+
+// wrapError annotates one of the sentinel errors above with a detail message.
+func wrapError(err error, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", err, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,74 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestSentinelErrors(t *testing.T) {
+	testAllCurves(t, testSentinelErrors)
+}
+
+func testSentinelErrors(t *testing.T, c elliptic.Curve) {
+	skS, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	skB, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+
+	if _, err := GenerateKey(c, failingReader{}); !errors.Is(err, ErrEntropy) {
+		t.Errorf("GenerateKey error = %v, want ErrEntropy", err)
+	}
+	// FIPS mode ignores rand when signing.
+	if _, _, err := Sign(failingReader{}, skS, testDigest("testing")); !errors.Is(err, ErrEntropy) && !FIPSMode() {
+		t.Errorf("Sign error = %v, want ErrEntropy", err)
+	}
+
+	if _, err := BlindPublicKey(c, &skS.PublicKey, nil); !errors.Is(err, ErrZeroBlind) {
+		t.Errorf("BlindPublicKey error = %v, want ErrZeroBlind", err)
+	}
+	if _, err := BlindPublicKey(elliptic.P256().Params(), &skS.PublicKey, skB); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("BlindPublicKey error = %v, want ErrCurveMismatch", err)
+	}
+	// A copy of the P-256 parameters under another name is a valid curve,
+	// but not one blinding is defined for.
+	params := *elliptic.P256().Params()
+	params.Name = "custom"
+	pkC := &PublicKey{&params, params.Gx, params.Gy}
+	if _, err := BlindPublicKey(&params, pkC, skB); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("BlindPublicKey error = %v, want ErrInvalidCurve", err)
+	}
+
+	hashed := testDigest("testing")
+	r, s, err := Sign(rand.Reader, skS, hashed)
+	if err != nil {
+		t.Fatalf("error signing: %s", err)
+	}
+	if err := CheckSignature(&skS.PublicKey, hashed, r, s); err != nil {
+		t.Errorf("CheckSignature error = %v, want nil", err)
+	}
+	if err := CheckSignature(&skS.PublicKey, hashed, r, new(big.Int)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("CheckSignature error = %v, want ErrInvalidSignature", err)
+	}
+	hashed[0] ^= 0xff
+	if err := CheckSignature(&skS.PublicKey, hashed, r, s); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("CheckSignature error = %v, want ErrInvalidSignature", err)
+	}
+	offCurve := &PublicKey{c, skS.X, new(big.Int).Add(skS.Y, big.NewInt(1))}
+	if err := CheckSignature(offCurve, hashed, r, s); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("CheckSignature error = %v, want ErrPointNotOnCurve", err)
+	}
+}
@@ -0,0 +1,454 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	escrowProofDST = "ECDSA Unblind Escrow Proof"
+
+	// UnblindSessionIDSize is the size, in bytes, of the identifier of an
+	// UnblindSession.
+	UnblindSessionIDSize = 16
+)
+
+// UnblindEscrow is the public description of a blind escrowed with
+// EscrowBlind: the blind itself is never held in one place again, but any
+// Threshold of the trustees holding its shares can jointly unblind the keys
+// it blinded in Context, and so link them to their base keys.
+//
+// The blind scalar of a context is derived from the blinding key by a hash,
+// which can't be evaluated on shares, so a blind is escrowed per context: the
+// trustees hold shares of the inverse of the blind scalar of Context, and
+// unblinding a key pkR is the threshold computation of that inverse times
+// pkR. Each trustee proves that its part is computed with its share, so that
+// a faulty or malicious trustee is detected rather than corrupting the
+// result.
+type UnblindEscrow struct {
+	Curve   elliptic.Curve
+	Context []byte
+	// Threshold is the number of trustees needed to unblind a key.
+	Threshold uint8
+	// Fingerprint is the KeyFingerprint of the shares of the trustees.
+	Fingerprint [sha256.Size]byte
+	// VerificationKeys are the public keys of the shares: the value of the
+	// share of index i times the base point is VerificationKeys[i-1].
+	VerificationKeys []*Point
+}
+
+// EscrowBlind splits the blind of bk in context into n shares, any t of which
+// can unblind the keys blinded by bk and context through an UnblindSession,
+// and returns them with the public description of the escrow. It requires
+// 1 <= t <= n <= 255.
+//
+// EscrowBlind is run by a dealer, which hands each share to one trustee, for
+// example marshaled with Share.Marshal, publishes the escrow, and then erases
+// bk if it must not be able to unblind keys on its own.
+func EscrowBlind(rand io.Reader, bk *PrivateKey, context []byte, n, t int) (*UnblindEscrow, []*Share, error) {
+	if bk == nil {
+		return nil, nil, wrapError(ErrZeroBlind, "missing blind")
+	}
+	c := bk.Curve
+	blind, err := hashBlind(c, bk, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	inv, err := NewPrivateKey(&Scalar{c: c, v: new(big.Int).ModInverse(blind, c.Params().N)})
+	if err != nil {
+		return nil, nil, err
+	}
+	shares, err := SplitPrivateKey(rand, inv, n, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	e := &UnblindEscrow{
+		Curve:            c,
+		Context:          append([]byte(nil), context...),
+		Threshold:        uint8(t),
+		Fingerprint:      inv.PublicKey.Fingerprint(),
+		VerificationKeys: make([]*Point, n),
+	}
+	for i, s := range shares {
+		e.VerificationKeys[i] = NewIdentityPoint(c).ScalarBaseMult(shareScalar(s))
+	}
+	return e, shares, nil
+}
+
+func shareScalar(s *Share) *Scalar {
+	return &Scalar{c: s.Curve, v: s.Value}
+}
+
+// verificationKey returns the verification key of the share of the given
+// index.
+func (e *UnblindEscrow) verificationKey(index uint8) (*Point, error) {
+	if index == 0 || int(index) > len(e.VerificationKeys) {
+		return nil, wrapError(ErrInvalidShare, "no trustee of index %d", index)
+	}
+	return e.VerificationKeys[index-1], nil
+}
+
+// Marshal encodes e as:
+//
+//	struct {
+//	  opaque curve<1..2^8-1>;
+//	  opaque context<0..2^16-1>;
+//	  uint8 threshold;
+//	  opaque fingerprint[32];
+//	  opaque verification_keys<0..2^16-1>;
+//	} UnblindEscrow;
+//
+// where curve is the name of the curve and verification_keys are the
+// compressed encodings of the verification keys.
+func (e *UnblindEscrow) Marshal() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(e.Curve.Params().Name))
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(e.Context)
+	})
+	b.AddUint8(e.Threshold)
+	b.AddBytes(e.Fingerprint[:])
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, p := range e.VerificationKeys {
+			b.AddBytes(p.BytesCompressed())
+		}
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidShare, "escrow too large to encode: %v", err)
+	}
+	return out, nil
+}
+
+// UnmarshalUnblindEscrow decodes an escrow encoded by UnblindEscrow.Marshal.
+func UnmarshalUnblindEscrow(data []byte) (*UnblindEscrow, error) {
+	s := cryptobyte.String(data)
+	var name, context, keys cryptobyte.String
+	e := new(UnblindEscrow)
+	if !s.ReadUint8LengthPrefixed(&name) || !s.ReadUint16LengthPrefixed(&context) ||
+		!s.ReadUint8(&e.Threshold) || !s.CopyBytes(e.Fingerprint[:]) ||
+		!s.ReadUint16LengthPrefixed(&keys) || !s.Empty() {
+		return nil, wrapError(ErrInvalidShare, "malformed escrow")
+	}
+	c, err := CurveByName(string(name))
+	if err != nil {
+		return nil, err
+	}
+	e.Curve = c
+	e.Context = append([]byte(nil), context...)
+	pointSize := 1 + coordinateSize(c)
+	for !keys.Empty() {
+		var enc []byte
+		if !keys.ReadBytes(&enc, pointSize) {
+			return nil, wrapError(ErrInvalidShare, "malformed escrow")
+		}
+		p, err := NewPoint(c, enc)
+		if err != nil {
+			return nil, err
+		}
+		e.VerificationKeys = append(e.VerificationKeys, p)
+	}
+	if e.Threshold == 0 || int(e.Threshold) > len(e.VerificationKeys) {
+		return nil, wrapError(ErrInvalidShare, "threshold %d of %d trustees", e.Threshold, len(e.VerificationKeys))
+	}
+	return e, nil
+}
+
+// UnblindRequest is the message an UnblindSession sends to the trustees.
+type UnblindRequest struct {
+	// ID identifies the session, which only accepts responses to it.
+	ID [UnblindSessionIDSize]byte
+	// PublicKey is the blinded key to unblind.
+	PublicKey *Point
+}
+
+// Marshal encodes r as its ID followed by the compressed encoding of its
+// public key.
+func (r *UnblindRequest) Marshal() []byte {
+	return append(r.ID[:], r.PublicKey.BytesCompressed()...)
+}
+
+// UnmarshalUnblindRequest decodes a request for a key on the curve c encoded
+// by UnblindRequest.Marshal.
+func UnmarshalUnblindRequest(c elliptic.Curve, data []byte) (*UnblindRequest, error) {
+	if len(data) != UnblindSessionIDSize+1+coordinateSize(c) {
+		return nil, wrapError(ErrInvalidShare, "request of %d bytes", len(data))
+	}
+	r := new(UnblindRequest)
+	copy(r.ID[:], data)
+	p, err := NewPoint(c, data[UnblindSessionIDSize:])
+	if err != nil {
+		return nil, err
+	}
+	r.PublicKey = p
+	return r, nil
+}
+
+// UnblindResponse is the message a trustee sends back to an UnblindSession:
+// the share of the trustee times the blinded key, with a proof that it was
+// computed with the share of the verification key of Index.
+type UnblindResponse struct {
+	ID      [UnblindSessionIDSize]byte
+	Index   uint8
+	Partial *Point
+	// C is the Fiat-Shamir challenge and Z the response of a proof of
+	// equality of the discrete logarithms of the verification key to the
+	// base point and of Partial to the blinded key.
+	C, Z *Scalar
+}
+
+// Marshal encodes r as its ID, its index, the compressed encoding of its
+// partial result, and the encodings of C and Z.
+func (r *UnblindResponse) Marshal() []byte {
+	out := append(r.ID[:], r.Index)
+	out = append(out, r.Partial.BytesCompressed()...)
+	out = append(out, r.C.Bytes()...)
+	return append(out, r.Z.Bytes()...)
+}
+
+// UnmarshalUnblindResponse decodes a response for a key on the curve c
+// encoded by UnblindResponse.Marshal.
+func UnmarshalUnblindResponse(c elliptic.Curve, data []byte) (*UnblindResponse, error) {
+	pointSize, size := 1+coordinateSize(c), scalarSize(c)
+	if len(data) != UnblindSessionIDSize+1+pointSize+2*size {
+		return nil, wrapError(ErrInvalidShare, "response of %d bytes", len(data))
+	}
+	r := &UnblindResponse{Index: data[UnblindSessionIDSize]}
+	copy(r.ID[:], data)
+	data = data[UnblindSessionIDSize+1:]
+	p, err := NewPoint(c, data[:pointSize])
+	if err != nil {
+		return nil, err
+	}
+	r.Partial = p
+	data = data[pointSize:]
+	for _, s := range []**Scalar{&r.C, &r.Z} {
+		if *s, err = NewScalar(c).SetBytes(data[:size]); err != nil {
+			return nil, err
+		}
+		data = data[size:]
+	}
+	return r, nil
+}
+
+// escrowChallenge hashes the statement and the commitments of the proof of
+// a response to a scalar, with hash_to_field and the parameters c uses for
+// blinds:
+//
+//	id || index || len(Y) || Y || len(P) || P || len(D) || D ||
+//	len(A1) || A1 || len(A2) || A2
+//
+// where Y is the verification key, P the blinded key, D the partial result,
+// points are compressed and lengths are 2-byte big-endian integers.
+func escrowChallenge(id [UnblindSessionIDSize]byte, index uint8, Y, P, D, A1, A2 *Point) (*Scalar, error) {
+	var b cryptobyte.Builder
+	b.AddBytes(id[:])
+	b.AddUint8(index)
+	for _, p := range []*Point{Y, P, D, A1, A2} {
+		p := p
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(p.BytesCompressed())
+		})
+	}
+	return hashToScalar(Y.c, b.BytesOrPanic(), []byte(escrowProofDST))
+}
+
+// UnblindTrustee is the state of a trustee of an escrowed blind. A trustee
+// answers each UnblindRequest it approves with Respond; it keeps no state
+// between requests, so requests can be answered concurrently and in any
+// order.
+type UnblindTrustee struct {
+	escrow *UnblindEscrow
+	share  *Share
+	key    *Point
+}
+
+// NewUnblindTrustee returns the trustee holding share of escrow. It returns
+// an error wrapping ErrInvalidShare if share is not one of the shares of
+// escrow.
+func NewUnblindTrustee(escrow *UnblindEscrow, share *Share) (*UnblindTrustee, error) {
+	if share == nil || share.Curve != escrow.Curve {
+		return nil, wrapError(ErrCurveMismatch, "share is not on the curve of the escrow")
+	}
+	if share.KeyFingerprint != escrow.Fingerprint || share.Threshold != escrow.Threshold {
+		return nil, wrapError(ErrInvalidShare, "share of another escrow")
+	}
+	if share.Value == nil || share.Value.Sign() < 0 || share.Value.Cmp(escrow.Curve.Params().N) >= 0 {
+		return nil, wrapError(ErrInvalidShare, "share value out of range")
+	}
+	key, err := escrow.verificationKey(share.Index)
+	if err != nil {
+		return nil, err
+	}
+	if NewIdentityPoint(escrow.Curve).ScalarBaseMult(shareScalar(share)).Equal(key) != 1 {
+		return nil, wrapError(ErrInvalidShare, "share does not match its verification key")
+	}
+	return &UnblindTrustee{escrow: escrow, share: share, key: key}, nil
+}
+
+// Respond computes the part of the trustee in unblinding the key of req,
+// with a proof drawn with entropy from rand.
+func (t *UnblindTrustee) Respond(rand io.Reader, req *UnblindRequest) (*UnblindResponse, error) {
+	c := t.escrow.Curve
+	if req == nil || req.PublicKey == nil || req.PublicKey.c != c {
+		return nil, wrapError(ErrCurveMismatch, "request is not on the curve of the escrow")
+	}
+	P := req.PublicKey
+	if _, err := NewPublicKey(P); err != nil {
+		return nil, err
+	}
+	x := shareScalar(t.share)
+	k, err := randFieldElement(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	nonce := &Scalar{c: c, v: k}
+	D := NewIdentityPoint(c).ScalarMult(x, P)
+	A1 := NewIdentityPoint(c).ScalarBaseMult(nonce)
+	A2 := NewIdentityPoint(c).ScalarMult(nonce, P)
+	ch, err := escrowChallenge(req.ID, t.share.Index, t.key, P, D, A1, A2)
+	if err != nil {
+		return nil, err
+	}
+	return &UnblindResponse{
+		ID:      req.ID,
+		Index:   t.share.Index,
+		Partial: D,
+		C:       ch,
+		Z:       NewScalar(c).Subtract(nonce, NewScalar(c).Multiply(ch, x)),
+	}, nil
+}
+
+// UnblindSession is the state of the coordinator unblinding one key with the
+// trustees of an escrow. It sends the request returned by NewUnblindSession
+// to the trustees, passes each response it gets to Receive, and calls Result
+// once Done reports that enough responses were accepted.
+type UnblindSession struct {
+	escrow   *UnblindEscrow
+	request  *UnblindRequest
+	partials map[uint8]*Point
+}
+
+// NewUnblindSession starts unblinding pkR, a key blinded in the context of
+// escrow, and returns the session with the request for the trustees. The
+// session ID is drawn from rand.
+func NewUnblindSession(rand io.Reader, escrow *UnblindEscrow, pkR *PublicKey) (*UnblindSession, *UnblindRequest, error) {
+	if pkR == nil || pkR.Curve != escrow.Curve {
+		return nil, nil, wrapError(ErrCurveMismatch, "key is not on the curve of the escrow")
+	}
+	P, err := pkR.Point()
+	if err != nil {
+		return nil, nil, err
+	}
+	req := &UnblindRequest{PublicKey: P}
+	if _, err := io.ReadFull(entropySource(rand), req.ID[:]); err != nil {
+		return nil, nil, entropyError(err)
+	}
+	return &UnblindSession{escrow: escrow, request: req, partials: make(map[uint8]*Point)}, req, nil
+}
+
+// Receive checks resp and records it. It returns an error wrapping
+// ErrInvalidProof if the proof of the trustee doesn't verify, and wrapping
+// ErrInvalidShare if resp answers another session or repeats a trustee.
+// Responses received once the session is done are checked but not used.
+func (s *UnblindSession) Receive(resp *UnblindResponse) error {
+	c := s.escrow.Curve
+	if resp == nil || resp.Partial == nil || resp.C == nil || resp.Z == nil {
+		return wrapError(ErrInvalidProof, "incomplete response")
+	}
+	if resp.Partial.c != c || resp.C.c != c || resp.Z.c != c {
+		return wrapError(ErrCurveMismatch, "response is not on %s", curveName(c))
+	}
+	if subtle.ConstantTimeCompare(resp.ID[:], s.request.ID[:]) != 1 {
+		return wrapError(ErrInvalidShare, "response to another session")
+	}
+	if _, ok := s.partials[resp.Index]; ok {
+		return wrapError(ErrInvalidShare, "repeated response of trustee %d", resp.Index)
+	}
+	Y, err := s.escrow.verificationKey(resp.Index)
+	if err != nil {
+		return err
+	}
+
+	// A1 = Z * G + C * Y and A2 = Z * P + C * D.
+	P, D := s.request.PublicKey, resp.Partial
+	scalars := []*Scalar{resp.Z, resp.C}
+	A1, err := MultiScalarMult([]*Point{NewGeneratorPoint(c), Y}, scalars)
+	if err != nil {
+		return err
+	}
+	A2, err := MultiScalarMult([]*Point{P, D}, scalars)
+	if err != nil {
+		return err
+	}
+	ch, err := escrowChallenge(resp.ID, resp.Index, Y, P, D, A1, A2)
+	if err != nil {
+		return err
+	}
+	if ch.Equal(resp.C) != 1 {
+		return wrapError(ErrInvalidProof, "invalid proof of trustee %d", resp.Index)
+	}
+	s.partials[resp.Index] = D
+	return nil
+}
+
+// Done reports whether the session received enough valid responses for
+// Result.
+func (s *UnblindSession) Done() bool {
+	return len(s.partials) >= int(s.escrow.Threshold)
+}
+
+// Result combines the responses received so far into the unblinded key. It
+// returns an error wrapping ErrInvalidShare if the session is not done.
+func (s *UnblindSession) Result() (*PublicKey, error) {
+	c := s.escrow.Curve
+	t := int(s.escrow.Threshold)
+	if len(s.partials) < t {
+		return nil, wrapError(ErrInvalidShare, "got %d responses, need %d", len(s.partials), t)
+	}
+	indices := make([]uint8, 0, t)
+	for i := 1; i <= len(s.escrow.VerificationKeys) && len(indices) < t; i++ {
+		if _, ok := s.partials[uint8(i)]; ok {
+			indices = append(indices, uint8(i))
+		}
+	}
+
+	// pkS = sum_i lambda_i * D_i, with the Lagrange coefficients at 0
+	// lambda_i = prod_{j != i} x_j / (x_j - x_i).
+	N := c.Params().N
+	points := make([]*Point, t)
+	lambdas := make([]*Scalar, t)
+	for i, xi := range indices {
+		num, den := big.NewInt(1), big.NewInt(1)
+		for j, xj := range indices {
+			if i == j {
+				continue
+			}
+			num.Mod(num.Mul(num, big.NewInt(int64(xj))), N)
+			den.Mod(den.Mul(den, big.NewInt(int64(xj)-int64(xi))), N)
+		}
+		num.Mod(num.Mul(num, new(big.Int).ModInverse(den, N)), N)
+		points[i] = s.partials[xi]
+		lambdas[i] = &Scalar{c: c, v: num}
+	}
+	sum, err := MultiScalarMult(points, lambdas)
+	if err != nil {
+		return nil, err
+	}
+	pkS, err := NewPublicKey(sum)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidatePublicKey(c, pkS); err != nil {
+		return nil, err
+	}
+	observeUnblind(c)
+	return pkS, nil
+}
@@ -0,0 +1,97 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestEscrowBlind(t *testing.T) {
+	testAllCurves(t, testEscrowBlind)
+}
+
+func testEscrowBlind(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("escrow")
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+
+	escrow, shares, err := EscrowBlind(rand.Reader, skB, context, 5, 3)
+	if err != nil {
+		t.Fatalf("EscrowBlind error: %s", err)
+	}
+	// The escrow and the shares travel to the trustees encoded.
+	enc, err := escrow.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	if escrow, err = UnmarshalUnblindEscrow(enc); err != nil {
+		t.Fatalf("UnmarshalUnblindEscrow error: %s", err)
+	}
+	trustees := make([]*UnblindTrustee, len(shares))
+	for i, s := range shares {
+		share, err := UnmarshalShare(c, s.Marshal())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trustees[i], err = NewUnblindTrustee(escrow, share); err != nil {
+			t.Fatalf("NewUnblindTrustee error: %s", err)
+		}
+	}
+
+	// Trustees 5, 2 and 4 unblind the key; trustee 1 cheats.
+	session, req, err := NewUnblindSession(rand.Reader, escrow, pkR)
+	if err != nil {
+		t.Fatalf("NewUnblindSession error: %s", err)
+	}
+	if req, err = UnmarshalUnblindRequest(c, req.Marshal()); err != nil {
+		t.Fatalf("UnmarshalUnblindRequest error: %s", err)
+	}
+	cheat, _ := trustees[0].Respond(rand.Reader, req)
+	cheat.Partial.Add(cheat.Partial, NewGeneratorPoint(c))
+	if err := session.Receive(cheat); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("Receive(tampered response) error = %v", err)
+	}
+	if _, err := session.Result(); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("Result before enough responses: error = %v", err)
+	}
+	for _, i := range []int{4, 1, 3} {
+		resp, err := trustees[i].Respond(rand.Reader, req)
+		if err != nil {
+			t.Fatalf("Respond error: %s", err)
+		}
+		if resp, err = UnmarshalUnblindResponse(c, resp.Marshal()); err != nil {
+			t.Fatalf("UnmarshalUnblindResponse error: %s", err)
+		}
+		if err := session.Receive(resp); err != nil {
+			t.Fatalf("Receive error: %s", err)
+		}
+		if err := session.Receive(resp); !errors.Is(err, ErrInvalidShare) {
+			t.Errorf("Receive(repeated response) error = %v", err)
+		}
+	}
+	if !session.Done() {
+		t.Fatal("session not done after 3 responses")
+	}
+	pkS, err := session.Result()
+	if err != nil {
+		t.Fatalf("Result error: %s", err)
+	}
+	if !pkS.Equal(&skS.PublicKey) {
+		t.Error("threshold unblinding does not recover the base key")
+	}
+
+	// Responses to another session are rejected.
+	other, _, _ := NewUnblindSession(rand.Reader, escrow, pkR)
+	resp, _ := trustees[0].Respond(rand.Reader, req)
+	if err := other.Receive(resp); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("Receive(response to another session) error = %v", err)
+	}
+
+	// A share of another escrow is rejected.
+	_, foreign, _ := EscrowBlind(rand.Reader, skS, context, 5, 3)
+	if _, err := NewUnblindTrustee(escrow, foreign[0]); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("NewUnblindTrustee(foreign share) error = %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"sync/atomic"
+)
+
+// fipsMode is 1 when FIPS mode is enabled. It is only ever set, never
+// cleared.
+var fipsMode int32
+
+// EnableFIPSMode runs the self-tests of the package and, if they pass,
+// switches it to FIPS mode for the rest of the life of the process. Building
+// with the fips build tag enables FIPS mode at init, and panics if the
+// self-tests fail.
+//
+// In FIPS mode:
+//
+//   - keys may only be generated, used to sign, and verified on the curves
+//     approved by FIPS 186-5: P-224, P-256, P-384 and P-521;
+//   - SignerOpts and PrivateKey.Sign only accept the SHA-2 and SHA-3 hash
+//     functions;
+//   - Sign derives its nonces deterministically from the private key and
+//     the digest, as specified by RFC 6979 and approved by FIPS 186-5, and
+//     ignores rand. The HMAC_DRBG of RFC 6979 uses the SHA-2 function sized
+//     for the curve rather than the hash function of the message, which Sign
+//     doesn't know, so signatures match the test vectors of RFC 6979 only
+//     for the matching pairs: P-256 with SHA-256, P-384 with SHA-384 and
+//     P-521 with SHA-512;
+//   - GenerateKey checks every new key pair by signing and verifying a test
+//     digest.
+//
+// Failures return an error wrapping ErrFIPS. EnableFIPSMode does not make the
+// package a validated cryptographic module.
+func EnableFIPSMode() error {
+	if err := fipsSelfTest(); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&fipsMode, 1)
+	return nil
+}
+
+// FIPSMode reports whether FIPS mode is enabled.
+func FIPSMode() bool {
+	return atomic.LoadInt32(&fipsMode) == 1
+}
+
+// fipsCheckCurve returns an error if FIPS mode is enabled and c is not an
+// approved curve.
+func fipsCheckCurve(c elliptic.Curve) error {
+	if !FIPSMode() {
+		return nil
+	}
+	switch c {
+	case elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return nil
+	}
+	return wrapError(ErrFIPS, "curve %s is not approved", curveName(c))
+}
+
+// fipsCheckHash returns an error if FIPS mode is enabled and h is not an
+// approved hash function for signatures.
+func fipsCheckHash(h crypto.Hash) error {
+	if !FIPSMode() {
+		return nil
+	}
+	switch h {
+	case crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512,
+		crypto.SHA512_224, crypto.SHA512_256,
+		crypto.SHA3_224, crypto.SHA3_256, crypto.SHA3_384, crypto.SHA3_512:
+		return nil
+	}
+	return wrapError(ErrFIPS, "hash function %v is not approved", h)
+}
+
+// fipsCheckOpts is fipsCheckHash for the hash function of opts, if any.
+func fipsCheckOpts(opts crypto.SignerOpts) error {
+	if opts == nil || opts.HashFunc() == 0 {
+		return nil
+	}
+	return fipsCheckHash(opts.HashFunc())
+}
+
+// rfc6979Hash returns the hash function of the HMAC_DRBG that derives nonces
+// on the curve c, matched to the size of its order.
+//
+// This deviates from RFC 6979, which instantiates HMAC_DRBG with the hash
+// function of the message. Sign only receives a digest, which doesn't say what
+// hashed it, so the function is picked from the curve instead: SHA-256 up to
+// 256-bit orders, SHA-384 up to 384-bit orders and SHA-512 above. Nonces, and
+// so signatures, match the test vectors of RFC 6979 only when the message was
+// hashed with that function, for example P-256 with SHA-256. Nonces derived
+// with another function are just as secure, as RFC 6979, section 3.6, allows.
+func rfc6979Hash(c elliptic.Curve) crypto.Hash {
+	switch bits := c.Params().N.BitLen(); {
+	case bits <= 256:
+		return crypto.SHA256
+	case bits <= 384:
+		return crypto.SHA384
+	default:
+		return crypto.SHA512
+	}
+}
+
+// rfc6979Nonces returns the generator of the nonces of RFC 6979, section
+// 3.2, for the private key x and the digest h1 on the curve c, with
+// HMAC_DRBG instantiated with hash.
+func rfc6979Nonces(c elliptic.Curve, hash crypto.Hash, x *big.Int, h1 []byte) func() (*big.Int, error) {
+	N := c.Params().N
+	qlen := N.BitLen()
+	rlen := (qlen + 7) / 8
+
+	bits2int := func(b []byte) *big.Int {
+		v := new(big.Int).SetBytes(b)
+		if excess := len(b)*8 - qlen; excess > 0 {
+			v.Rsh(v, uint(excess))
+		}
+		return v
+	}
+	z := bits2int(h1)
+	if z.Cmp(N) >= 0 {
+		z.Sub(z, N)
+	}
+	seed := append(x.FillBytes(make([]byte, rlen)), z.FillBytes(make([]byte, rlen))...)
+
+	mac := func(key []byte, data ...[]byte) []byte {
+		m := hmac.New(hash.New, key)
+		for _, d := range data {
+			m.Write(d)
+		}
+		return m.Sum(nil)
+	}
+	V := bytes.Repeat([]byte{0x01}, hash.Size())
+	K := make([]byte, hash.Size())
+	K = mac(K, V, []byte{0x00}, seed)
+	V = mac(K, V)
+	K = mac(K, V, []byte{0x01}, seed)
+	V = mac(K, V)
+
+	first := true
+	return func() (*big.Int, error) {
+		for {
+			if !first {
+				K = mac(K, V, []byte{0x00})
+				V = mac(K, V)
+			}
+			first = false
+			var T []byte
+			for len(T) < rlen {
+				V = mac(K, V)
+				T = append(T, V...)
+			}
+			k := bits2int(T[:rlen])
+			if k.Sign() > 0 && k.Cmp(N) < 0 {
+				return k, nil
+			}
+		}
+	}
+}
+
+// signFIPS signs hash with priv with the deterministic nonces of RFC 6979.
+func signFIPS(priv *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	c := priv.Curve
+	if err := fipsCheckCurve(c); err != nil {
+		return nil, nil, err
+	}
+	return signWithNonces(priv, c, hash, rfc6979Nonces(c, rfc6979Hash(c), priv.D, hash))
+}
+
+// fipsPairwiseTest signs and verifies a test digest with a new key pair, as
+// required by FIPS 140-3 after key generation.
+func fipsPairwiseTest(priv *PrivateKey) error {
+	digest := sha256.Sum256([]byte("ECDSA pairwise consistency test"))
+	r, s, err := signFIPS(priv, digest[:])
+	if err != nil {
+		return err
+	}
+	if !verifyRange(&priv.PublicKey, digest[:], r, s) {
+		return wrapError(ErrFIPS, "pairwise consistency test failed")
+	}
+	return nil
+}
+
+// fipsSelfTest runs the known-answer test of RFC 6979, appendix A.2.5, for
+// P-256 with SHA-256 and the message "sample", and checks that the expected
+// signature verifies and a corrupted one doesn't.
+func fipsSelfTest() error {
+	c := elliptic.P256()
+	fromHex := func(s string) *big.Int {
+		v, _ := new(big.Int).SetString(s, 16)
+		return v
+	}
+	priv := new(PrivateKey)
+	priv.Curve = c
+	priv.D = fromHex("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	priv.X, priv.Y = c.ScalarBaseMult(priv.D.Bytes())
+	wantR := fromHex("efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716")
+	wantS := fromHex("f7cb1c942d657c41d436c7a1b6e29f65f3e900dbb9aff4064dc4ab2f843acda8")
+	digest := sha256.Sum256([]byte("sample"))
+
+	r, s, err := signWithNonces(priv, c, digest[:], rfc6979Nonces(c, crypto.SHA256, priv.D, digest[:]))
+	if err != nil {
+		return wrapError(ErrFIPS, "self-test failed: %v", err)
+	}
+	if r.Cmp(wantR) != 0 || s.Cmp(wantS) != 0 {
+		return wrapError(ErrFIPS, "self-test failed: signature %s%s", hex.EncodeToString(r.Bytes()), hex.EncodeToString(s.Bytes()))
+	}
+	if !verifyRange(&priv.PublicKey, digest[:], r, s) {
+		return wrapError(ErrFIPS, "self-test failed: known signature rejected")
+	}
+	digest[0] ^= 1
+	if verifyRange(&priv.PublicKey, digest[:], r, s) {
+		return wrapError(ErrFIPS, "self-test failed: corrupted signature accepted")
+	}
+	return nil
+}
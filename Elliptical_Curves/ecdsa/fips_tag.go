@@ -0,0 +1,9 @@
+//go:build fips
+
+package ecdsa
+
+func init() {
+	if err := EnableFIPSMode(); err != nil {
+		panic(err)
+	}
+}
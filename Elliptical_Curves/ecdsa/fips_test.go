@@ -0,0 +1,124 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// setFIPSMode enables or disables FIPS mode until the end of the test, which
+// must not run in parallel with others.
+func setFIPSMode(t *testing.T, enabled bool) {
+	prev := atomic.LoadInt32(&fipsMode)
+	t.Cleanup(func() { atomic.StoreInt32(&fipsMode, prev) })
+	if enabled {
+		atomic.StoreInt32(&fipsMode, 1)
+	} else {
+		atomic.StoreInt32(&fipsMode, 0)
+	}
+}
+
+func TestFIPSMode(t *testing.T) {
+	setFIPSMode(t, true)
+	if !FIPSMode() {
+		t.Fatalf("FIPS mode not enabled")
+	}
+	c := elliptic.P384()
+	priv, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	digest := sha256.Sum256([]byte("testing"))
+
+	// Nonces are deterministic, so rand is not read.
+	r0, s0, err := Sign(failingReader{}, priv, digest[:])
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	r1, s1, _ := Sign(rand.Reader, priv, digest[:])
+	if r0.Cmp(r1) != 0 || s0.Cmp(s1) != 0 {
+		t.Errorf("signatures of the same digest differ")
+	}
+	if !Verify(&priv.PublicKey, digest[:], r0, s0) {
+		t.Errorf("Verify failed")
+	}
+	if _, _, err := SignWithOptions(rand.Reader, priv, digest[:20], &SignerOpts{Hash: crypto.SHA1}); !errors.Is(err, ErrFIPS) {
+		t.Errorf("SignWithOptions with SHA-1: got %v, want ErrFIPS", err)
+	}
+	if _, err := priv.Sign(rand.Reader, digest[:16], crypto.MD5); !errors.Is(err, ErrFIPS) {
+		t.Errorf("PrivateKey.Sign with MD5: got %v, want ErrFIPS", err)
+	}
+	if _, err := priv.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Errorf("PrivateKey.Sign with SHA-256 error: %s", err)
+	}
+
+	if _, err := GenerateKey(brainpool.P256r1(), rand.Reader); !errors.Is(err, ErrFIPS) {
+		t.Errorf("GenerateKey on brainpoolP256r1: got %v, want ErrFIPS", err)
+	}
+}
+
+func TestFIPSModeRejectsUnapprovedKeys(t *testing.T) {
+	setFIPSMode(t, false)
+	priv, _ := GenerateKey(brainpool.P256r1(), rand.Reader)
+	digest := sha256.Sum256([]byte("testing"))
+	r, s, _ := Sign(rand.Reader, priv, digest[:])
+	vs, err := NewVerifierSession(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("NewVerifierSession error: %s", err)
+	}
+
+	setFIPSMode(t, true)
+	if _, _, err := Sign(rand.Reader, priv, digest[:]); !errors.Is(err, ErrFIPS) {
+		t.Errorf("Sign on brainpoolP256r1: got %v, want ErrFIPS", err)
+	}
+	if Verify(&priv.PublicKey, digest[:], r, s) {
+		t.Errorf("Verify on brainpoolP256r1 succeeded")
+	}
+	if err := CheckSignature(&priv.PublicKey, digest[:], r, s); !errors.Is(err, ErrFIPS) {
+		t.Errorf("CheckSignature on brainpoolP256r1: got %v, want ErrFIPS", err)
+	}
+	if _, err := NewVerifierSession(&priv.PublicKey); !errors.Is(err, ErrFIPS) {
+		t.Errorf("NewVerifierSession on brainpoolP256r1: got %v, want ErrFIPS", err)
+	}
+	if vs.Verify(digest[:], r, s) {
+		t.Errorf("VerifierSession.Verify on brainpoolP256r1 succeeded")
+	}
+}
+
+func TestRFC6979Nonce(t *testing.T) {
+	// RFC 6979, appendix A.2.5: P-256, SHA-256, message "sample".
+	c := elliptic.P256()
+	x, _ := new(big.Int).SetString("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721", 16)
+	for _, v := range []struct {
+		hash crypto.Hash
+		want string
+	}{
+		{crypto.SHA256, "a6e3c57dd01abe90086538398355dd4c3b17aa873382b0f24d6129493d8aad60"},
+		{crypto.SHA384, "09f634b188cefd98e7ec88b1aa9852d734d0bc272f7d2a47decc6ebeb375aad4"},
+		{crypto.SHA512, "5fa81c63109badb88c1f367b47da606da28cad69aa22c4fe6ad7df73a7173aa5"},
+	} {
+		h := v.hash.New()
+		h.Write([]byte("sample"))
+		k, err := rfc6979Nonces(c, v.hash, x, h.Sum(nil))()
+		if err != nil {
+			t.Fatalf("%v: nonce error: %s", v.hash, err)
+		}
+		if got := hex.EncodeToString(k.FillBytes(make([]byte, 32))); got != v.want {
+			t.Errorf("%v: nonce %s, want %s", v.hash, got, v.want)
+		}
+	}
+	if h := rfc6979Hash(c); h != crypto.SHA256 {
+		t.Errorf("rfc6979Hash(P-256) = %v, want SHA-256", h)
+	}
+	if err := fipsSelfTest(); err != nil {
+		t.Errorf("self-test error: %s", err)
+	}
+}
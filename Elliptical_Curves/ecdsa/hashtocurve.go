@@ -0,0 +1,73 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/cloudflare/circl/expander"
+	"github.com/cloudflare/circl/group"
+)
+
+// hashToCurveGroup returns the circl group implementing the RFC 9380 suites
+// of c, which must be P-256, P-384 or P-521.
+func hashToCurveGroup(c elliptic.Curve) (group.Group, error) {
+	switch c {
+	case elliptic.P256():
+		return group.P256, nil
+	case elliptic.P384():
+		return group.P384, nil
+	case elliptic.P521():
+		return group.P521, nil
+	default:
+		return nil, wrapError(ErrInvalidCurve, "no hash-to-curve suite for %s", c.Params().Name)
+	}
+}
+
+// HashToCurve hashes msg to a point of c with the domain separation tag dst,
+// using the random-oracle hash_to_curve suites of RFC 9380:
+// P256_XMD:SHA-256_SSWU_RO_, P384_XMD:SHA-384_SSWU_RO_ and
+// P521_XMD:SHA-512_SSWU_RO_. The curve must be P-256, P-384 or P-521, and
+// dst should be unique to the protocol and usage, and not empty.
+//
+// Nobody knows the discrete logarithm of the result with respect to the
+// generator, or to any other output of HashToCurve.
+func HashToCurve(c elliptic.Curve, msg, dst []byte) (*Point, error) {
+	g, err := hashToCurveGroup(c)
+	if err != nil {
+		return nil, err
+	}
+	e := g.HashToElement(msg, dst)
+	if e.IsIdentity() {
+		return NewIdentityPoint(c), nil
+	}
+	enc, err := e.MarshalBinaryCompress()
+	if err != nil {
+		return nil, err
+	}
+	return NewPoint(c, enc)
+}
+
+// HashToScalar hashes msg to a scalar of c with the domain separation tag
+// dst, using hash_to_field of RFC 9380, section 5, with the modulus N and the
+// expand_message_xmd hash and output length of the hash_to_curve suite of c.
+// The curve must be P-256, P-384 or P-521. This is the HashToScalar function
+// of RFC 9497 for the matching suites.
+func HashToScalar(c elliptic.Curve, msg, dst []byte) (*Scalar, error) {
+	if _, err := hashToCurveGroup(c); err != nil {
+		return nil, err
+	}
+	return hashToScalar(c, msg, dst)
+}
+
+// hashToScalar is HashToScalar for every curve supported by key blinding,
+// with the hash function and output length c uses for blinds.
+func hashToScalar(c elliptic.Curve, msg, dst []byte) (*Scalar, error) {
+	h, L, err := blindParams(c)
+	if err != nil {
+		return nil, err
+	}
+	xmd := expander.NewExpanderMD(h, dst)
+	var u [1]big.Int
+	group.HashToField(u[:], msg, xmd, c.Params().N, L)
+	return &Scalar{c: c, v: new(big.Int).Set(&u[0])}, nil
+}
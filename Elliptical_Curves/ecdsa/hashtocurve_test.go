@@ -0,0 +1,95 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// TestHashToCurveVectors checks test vectors of RFC 9380, appendix J.
+func TestHashToCurveVectors(t *testing.T) {
+	for _, v := range []struct {
+		c    elliptic.Curve
+		dst  string
+		msg  string
+		x, y string
+	}{
+		{
+			elliptic.P256(), "QUUX-V01-CS02-with-P256_XMD:SHA-256_SSWU_RO_", "",
+			"2c15230b26dbc6fc9a37051158c95b79656e17a1a920b11394ca91c44247d3e4",
+			"8a7a74985cc5c776cdfe4b1f19884970453912e9d31528c060be9ab5c43e8415",
+		},
+		{
+			elliptic.P256(), "QUUX-V01-CS02-with-P256_XMD:SHA-256_SSWU_RO_", "abc",
+			"0bb8b87485551aa43ed54f009230450b492fead5f1cc91658775dac4a3388a0f",
+			"5c41b3d0731a27a7b14bc0bf0ccded2d8751f83493404c84a88e71ffd424212e",
+		},
+		{
+			elliptic.P384(), "QUUX-V01-CS02-with-P384_XMD:SHA-384_SSWU_RO_", "",
+			"eb9fe1b4f4e14e7140803c1d99d0a93cd823d2b024040f9c067a8eca1f5a2eeac9ad604973527a356f3fa3aeff0e4d83",
+			"0c21708cff382b7f4643c07b105c2eaec2cead93a917d825601e63c8f21f6abd9abc22c93c2bed6f235954b25048bb1a",
+		},
+		{
+			elliptic.P384(), "QUUX-V01-CS02-with-P384_XMD:SHA-384_SSWU_RO_", "abc",
+			"e02fc1a5f44a7519419dd314e29863f30df55a514da2d655775a81d413003c4d4e7fd59af0826dfaad4200ac6f60abe1",
+			"01f638d04d98677d65bef99aef1a12a70a4cbb9270ec55248c04530d8bc1f8f90f8a6a859a7c1f1ddccedf8f96d675f6",
+		},
+		{
+			elliptic.P521(), "QUUX-V01-CS02-with-P521_XMD:SHA-512_SSWU_RO_", "",
+			"00fd767cebb2452030358d0e9cf907f525f50920c8f607889a6a35680727f64f4d66b161fafeb2654bea0d35086bec0a10b30b14adef3556ed9f7f1bc23cecc9c088",
+			"0169ba78d8d851e930680322596e39c78f4fe31b97e57629ef6460ddd68f8763fd7bd767a4e94a80d3d21a3c2ee98347e024fc73ee1c27166dc3fe5eeef782be411d",
+		},
+		{
+			elliptic.P521(), "QUUX-V01-CS02-with-P521_XMD:SHA-512_SSWU_RO_", "abc",
+			"002f89a1677b28054b50d15e1f81ed6669b5a2158211118ebdef8a6efc77f8ccaa528f698214e4340155abc1fa08f8f613ef14a043717503d57e267d57155cf784a4",
+			"010e0be5dc8e753da8ce51091908b72396d3deed14ae166f66d8ebf0a4e7059ead169ea4bead0232e9b700dd380b316e9361cfdba55a08c73545563a80966ecbb86d",
+		},
+	} {
+		p, err := HashToCurve(v.c, []byte(v.msg), []byte(v.dst))
+		if err != nil {
+			t.Fatalf("HashToCurve error: %s", err)
+		}
+		want := "04" + v.x + v.y
+		if got := hex.EncodeToString(p.Bytes()); got != want {
+			t.Errorf("%s: HashToCurve(%q) = %s, want %s", v.c.Params().Name, v.msg, got, want)
+		}
+	}
+}
+
+func TestHashToScalar(t *testing.T) {
+	// The expected values are hash_to_field(msg) with the parameters of the
+	// curve's RFC 9380 suite, computed with an independent implementation.
+	for _, v := range []struct {
+		c    elliptic.Curve
+		dst  string
+		want string
+	}{
+		{
+			elliptic.P256(), "QUUX-V01-CS02-with-P256_XMD:SHA-256_SSWU_RO_",
+			"fc85b6dac2e8be7343454b82c1bd5dad62cf42331f3fa060ff7407d79e15be6b",
+		},
+		{
+			elliptic.P384(), "QUUX-V01-CS02-with-P384_XMD:SHA-384_SSWU_RO_",
+			"fc34f24a4fb2f7bc762e2569901db79e27799e6b4070a1ca64e9792a8e47f0c1f26b312d07f263fc60cfd2385fb06385",
+		},
+	} {
+		s, err := HashToScalar(v.c, []byte("abc"), []byte(v.dst))
+		if err != nil {
+			t.Fatalf("HashToScalar error: %s", err)
+		}
+		if got := hex.EncodeToString(s.Bytes()); got != v.want {
+			t.Errorf("%s: HashToScalar = %s, want %s", v.c.Params().Name, got, v.want)
+		}
+	}
+}
+
+func TestHashToCurveUnsupported(t *testing.T) {
+	c := elliptic.P224()
+	if _, err := HashToCurve(c, []byte("abc"), []byte("dst")); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("HashToCurve on P-224: got %v, want ErrInvalidCurve", err)
+	}
+	if _, err := HashToScalar(c, []byte("abc"), []byte("dst")); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("HashToScalar on P-224: got %v, want ErrInvalidCurve", err)
+	}
+}
@@ -0,0 +1,196 @@
+//go:build interop
+
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+)
+
+// The interop tests check this package against the JSON test vectors
+// published alongside the CFRG key blinding draft, and generate vectors for
+// the curves the draft does not cover. Run them with:
+//
+//	CFRG_ECDSA_BLINDING_TEST_VECTORS_IN=vectors.json go test -tags=interop -run TestInterop ./ecdsa
+//
+// The input file must come from the draft or another implementation: the
+// vectors in tokens/type3 were generated by this package, so checking against
+// them would only compare it with itself. Without an input file, the test is
+// skipped.
+const (
+	inputCFRGECDSABlindingTestVectorEnvironmentKey  = "CFRG_ECDSA_BLINDING_TEST_VECTORS_IN"
+	outputCFRGECDSABlindingTestVectorEnvironmentKey = "CFRG_ECDSA_BLINDING_TEST_VECTORS_OUT"
+)
+
+type rawCFRGECDSABlindingTestVector struct {
+	Curve          string `json:"Curve"`
+	Hash           string `json:"Hash"`
+	PrivateKey     string `json:"skS"`
+	PublicKey      string `json:"pkS"`
+	PrivateBlind   string `json:"bk"`
+	BlindPublicKey string `json:"pkR"`
+	Message        string `json:"message"`
+	Context        string `json:"context"`
+	Signature      string `json:"signature"`
+}
+
+func interopCurve(name string) (elliptic.Curve, crypto.Hash, error) {
+	switch name {
+	case "P-224":
+		return elliptic.P224(), crypto.SHA224, nil
+	case "P-256":
+		return elliptic.P256(), crypto.SHA256, nil
+	case "P-384":
+		return elliptic.P384(), crypto.SHA384, nil
+	case "P-521":
+		return elliptic.P521(), crypto.SHA512, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported curve: %s", name)
+}
+
+func mustUnhex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("Unhex failed: %v", err)
+	}
+	return b
+}
+
+func verifyCFRGECDSABlindingTestVector(t *testing.T, raw rawCFRGECDSABlindingTestVector) {
+	c, h, err := interopCurve(raw.Curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Hash != h.String() {
+		t.Fatalf("unexpected hash %s for curve %s", raw.Hash, raw.Curve)
+	}
+
+	skS, err := CreateKey(c, mustUnhex(t, raw.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkS := elliptic.MarshalCompressed(c, skS.X, skS.Y); hex.EncodeToString(pkS) != raw.PublicKey {
+		t.Fatalf("public key mismatch: got %x, want %s", pkS, raw.PublicKey)
+	}
+	skB, err := CreateKey(c, mustUnhex(t, raw.PrivateBlind))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := mustUnhex(t, raw.Context)
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc := elliptic.MarshalCompressed(c, pkR.X, pkR.Y); hex.EncodeToString(enc) != raw.BlindPublicKey {
+		t.Fatalf("blinded public key mismatch: got %x, want %s", enc, raw.BlindPublicKey)
+	}
+
+	pkO, err := UnblindPublicKeyWithContext(c, pkR, skB, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pkO.Equal(&skS.PublicKey) {
+		t.Fatal("unblinded public key mismatch")
+	}
+
+	digester := h.New()
+	digester.Write(mustUnhex(t, raw.Message))
+	digest := digester.Sum(nil)
+
+	sig := mustUnhex(t, raw.Signature)
+	scalarLen := len(sig) / 2
+	r := new(big.Int).SetBytes(sig[:scalarLen])
+	s := new(big.Int).SetBytes(sig[scalarLen:])
+	if !Verify(pkR, digest, r, s) {
+		t.Fatal("signature with blinded key verification failed")
+	}
+}
+
+func TestInteropVerifyECDSABlinding(t *testing.T) {
+	inputFile := os.Getenv(inputCFRGECDSABlindingTestVectorEnvironmentKey)
+	if len(inputFile) == 0 {
+		t.Skipf("%s not set; no external test vectors to check against", inputCFRGECDSABlindingTestVectorEnvironmentKey)
+	}
+	encoded, err := os.ReadFile(inputFile)
+	if err != nil {
+		t.Fatalf("Failed reading test vectors: %v", err)
+	}
+
+	var vectors []rawCFRGECDSABlindingTestVector
+	if err := json.Unmarshal(encoded, &vectors); err != nil {
+		t.Fatalf("Error decoding test vector string: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("no test vectors found")
+	}
+	for i, vector := range vectors {
+		t.Run(fmt.Sprintf("%s/%d", vector.Curve, i), func(t *testing.T) {
+			verifyCFRGECDSABlindingTestVector(t, vector)
+		})
+	}
+}
+
+// TestInteropGenerateECDSABlinding generates vectors in the draft format for
+// every curve supported by this package, filling the gaps in the published
+// vectors, which only cover P-384.
+func TestInteropGenerateECDSABlinding(t *testing.T) {
+	var vectors []rawCFRGECDSABlindingTestVector
+	for _, name := range []string{"P-224", "P-256", "P-384", "P-521"} {
+		c, h, _ := interopCurve(name)
+		for _, contextLen := range []int{0, 32} {
+			skS, _ := GenerateKey(c, rand.Reader)
+			skB, _ := GenerateKey(c, rand.Reader)
+			context := make([]byte, contextLen)
+			rand.Read(context)
+
+			pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			message := []byte("hello world")
+			digester := h.New()
+			digester.Write(message)
+			r, s, err := BlindKeySignWithContext(rand.Reader, skS, skB, digester.Sum(nil), context)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scalarLen := (c.Params().BitSize + 7) / 8
+			sig := append(r.FillBytes(make([]byte, scalarLen)), s.FillBytes(make([]byte, scalarLen))...)
+			vectors = append(vectors, rawCFRGECDSABlindingTestVector{
+				Curve:          name,
+				Hash:           h.String(),
+				PrivateKey:     hex.EncodeToString(skS.D.FillBytes(make([]byte, scalarLen))),
+				PublicKey:      hex.EncodeToString(elliptic.MarshalCompressed(c, skS.X, skS.Y)),
+				PrivateBlind:   hex.EncodeToString(skB.D.FillBytes(make([]byte, scalarLen))),
+				BlindPublicKey: hex.EncodeToString(elliptic.MarshalCompressed(c, pkR.X, pkR.Y)),
+				Message:        hex.EncodeToString(message),
+				Context:        hex.EncodeToString(context),
+				Signature:      hex.EncodeToString(sig),
+			})
+		}
+	}
+
+	for _, vector := range vectors {
+		verifyCFRGECDSABlindingTestVector(t, vector)
+	}
+
+	if outputFile := os.Getenv(outputCFRGECDSABlindingTestVectorEnvironmentKey); len(outputFile) > 0 {
+		encoded, err := json.Marshal(vectors)
+		if err != nil {
+			t.Fatalf("Error producing test vectors: %v", err)
+		}
+		if err := os.WriteFile(outputFile, encoded, 0644); err != nil {
+			t.Fatalf("Error writing test vectors: %v", err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// jwk is an elliptic curve JSON Web Key, as specified in RFC 7518, section
+// 6.2. Other members of the key, such as kid or use, are ignored.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d,omitempty"`
+}
+
+func newJWK(pub *PublicKey) (*jwk, error) {
+	crv := curveName(pub.Curve)
+	if _, err := CurveByName(crv); err != nil {
+		return nil, err
+	}
+	size := coordinateSize(pub.Curve)
+	return &jwk{
+		Kty: "EC",
+		Crv: crv,
+		X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+	}, nil
+}
+
+// publicKey returns the public key of k, or an error if it is not a valid
+// key.
+func (k *jwk) publicKey() (*PublicKey, error) {
+	if k.Kty != "EC" {
+		return nil, wrapError(ErrInvalidCurve, "JWK key type %q", k.Kty)
+	}
+	c, err := CurveByName(k.Crv)
+	if err != nil {
+		return nil, err
+	}
+	size := coordinateSize(c)
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, wrapError(ErrPointNotOnCurve, "malformed JWK")
+	}
+	pub := &PublicKey{c, new(big.Int).SetBytes(x), new(big.Int).SetBytes(y)}
+	if err := ValidatePublicKey(c, pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// MarshalJSON encodes pub as an elliptic curve JSON Web Key (RFC 7517), with
+// the kty, crv, x and y members of RFC 7518, section 6.2.1. Blinded public
+// keys are encoded like any other key. The crv member is the name of the
+// curve in the registry of RegisterCurve; of those, only P-256, P-384 and
+// P-521 are registered for JOSE, and other names are only understood by this
+// package.
+func (pub *PublicKey) MarshalJSON() ([]byte, error) {
+	k, err := newJWK(pub)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(k)
+}
+
+// UnmarshalJSON decodes a public key encoded by MarshalJSON, and validates it
+// with ValidatePublicKey. Members other than kty, crv, x and y are ignored,
+// and keys with a private key member are refused.
+func (pub *PublicKey) UnmarshalJSON(data []byte) error {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil || k.D != "" {
+		return wrapError(ErrPointNotOnCurve, "malformed JWK")
+	}
+	p, err := k.publicKey()
+	if err != nil {
+		return err
+	}
+	*pub = *p
+	return nil
+}
+
+// MarshalJSON encodes priv as an elliptic curve JSON Web Key, with the d
+// member of RFC 7518, section 6.2.2, in addition to the members of
+// PublicKey.MarshalJSON.
+func (priv *PrivateKey) MarshalJSON() ([]byte, error) {
+	k, err := newJWK(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	k.D = base64.RawURLEncoding.EncodeToString(priv.D.FillBytes(make([]byte, scalarSize(priv.Curve))))
+	return json.Marshal(k)
+}
+
+// UnmarshalJSON decodes a private key encoded by MarshalJSON, and checks
+// that its public key matches its private key.
+func (priv *PrivateKey) UnmarshalJSON(data []byte) error {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil || k.D == "" {
+		return wrapError(ErrInvalidScalar, "malformed JWK")
+	}
+	pub, err := k.publicKey()
+	if err != nil {
+		return err
+	}
+	d, err := base64.RawURLEncoding.DecodeString(k.D)
+	if err != nil {
+		return wrapError(ErrInvalidScalar, "malformed JWK")
+	}
+	s, err := NewScalar(pub.Curve).SetBytes(d)
+	if err != nil {
+		return err
+	}
+	p, err := NewPrivateKey(s)
+	if err != nil {
+		return err
+	}
+	if !p.PublicKey.Equal(pub) {
+		return wrapError(ErrInvalidScalar, "private key does not match public key")
+	}
+	*priv = *p
+	return nil
+}
+
+// MarshalRaw encodes sig for the curve c as R and S concatenated, each
+// padded to the length of a scalar of c. This is the encoding of ECDSA
+// signatures of JWS (RFC 7518, section 3.4), COSE and WebCrypto.
+func (sig *Signature) MarshalRaw(c elliptic.Curve) ([]byte, error) {
+	size := scalarSize(c)
+	if sig.R == nil || sig.S == nil || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 ||
+		len(sig.R.Bytes()) > size || len(sig.S.Bytes()) > size {
+		return nil, wrapError(ErrInvalidSignature, "signature out of range for %s", curveName(c))
+	}
+	out := make([]byte, 2*size)
+	sig.R.FillBytes(out[:size])
+	sig.S.FillBytes(out[size:])
+	return out, nil
+}
+
+// UnmarshalRawSignature decodes a signature on the curve c encoded by
+// MarshalRaw. It does not verify the signature.
+func UnmarshalRawSignature(c elliptic.Curve, data []byte) (*Signature, error) {
+	size := scalarSize(c)
+	if len(data) != 2*size {
+		return nil, wrapError(ErrInvalidSignature, "raw signature of %d bytes, want %d", len(data), 2*size)
+	}
+	return &Signature{R: new(big.Int).SetBytes(data[:size]), S: new(big.Int).SetBytes(data[size:])}, nil
+}
+
+// MarshalJSON encodes sig as a JSON string holding the base64url encoding,
+// without padding, of R and S concatenated, each padded to the length of the
+// longer of the two. It matches MarshalRaw, and so JWS, whenever R or S has
+// the full length of a scalar, which fails with probability about 2^-16 on
+// P-256. Use MarshalRaw with the curve of the key when the exact JWS length
+// is required.
+func (sig *Signature) MarshalJSON() ([]byte, error) {
+	if sig.R == nil || sig.S == nil || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return nil, wrapError(ErrInvalidSignature, "incomplete signature")
+	}
+	size := len(sig.R.Bytes())
+	if n := len(sig.S.Bytes()); n > size {
+		size = n
+	}
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(raw))
+}
+
+// UnmarshalJSON decodes a signature encoded by MarshalJSON or by MarshalRaw
+// for any curve, in a base64url JSON string.
+func (sig *Signature) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return wrapError(ErrInvalidSignature, "malformed signature")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(raw) == 0 || len(raw)%2 != 0 {
+		return wrapError(ErrInvalidSignature, "malformed signature")
+	}
+	size := len(raw) / 2
+	sig.R = new(big.Int).SetBytes(raw[:size])
+	sig.S = new(big.Int).SetBytes(raw[size:])
+	return nil
+}
@@ -0,0 +1,127 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestJWK(t *testing.T) {
+	testAllCurves(t, testJWK)
+}
+
+func testJWK(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	pkR, _ := BlindPublicKey(c, &skS.PublicKey, skB)
+	hashed := testDigest("testing")
+	r, s, _ := BlindKeySign(rand.Reader, skS, skB, hashed)
+
+	enc, err := json.Marshal(pkR)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	pub := new(PublicKey)
+	if err := json.Unmarshal(enc, pub); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if !pub.Equal(pkR) {
+		t.Errorf("decoded public key does not match")
+	}
+	if err := json.Unmarshal(enc, new(PrivateKey)); err == nil {
+		t.Errorf("public key decoded as a private key")
+	}
+
+	enc, err = json.Marshal(skS)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	priv := new(PrivateKey)
+	if err := json.Unmarshal(enc, priv); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if !priv.Equal(skS) {
+		t.Errorf("decoded private key does not match")
+	}
+	if err := json.Unmarshal(enc, new(PublicKey)); err == nil {
+		t.Errorf("private key decoded as a public key")
+	}
+
+	sig := &Signature{R: r, S: s}
+	enc, err = json.Marshal(sig)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decoded := new(Signature)
+	if err := json.Unmarshal(enc, decoded); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if !Verify(pkR, hashed, decoded.R, decoded.S) {
+		t.Errorf("Verify of a decoded signature failed")
+	}
+
+	raw, err := sig.MarshalRaw(c)
+	if err != nil {
+		t.Fatalf("MarshalRaw error: %s", err)
+	}
+	if len(raw) != 2*scalarSize(c) {
+		t.Errorf("raw signature of %d bytes, want %d", len(raw), 2*scalarSize(c))
+	}
+	if decoded, err = UnmarshalRawSignature(c, raw); err != nil || !decoded.Equal(sig) {
+		t.Errorf("UnmarshalRawSignature error: %v", err)
+	}
+	if _, err := UnmarshalRawSignature(c, raw[1:]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("UnmarshalRawSignature of a short signature: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestJWKExample(t *testing.T) {
+	// The example private key of RFC 7517, appendix A.2.
+	const key = `{"kty":"EC",
+		"crv":"P-256",
+		"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+		"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+		"d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE",
+		"use":"enc",
+		"kid":"1"}`
+	priv := new(PrivateKey)
+	if err := json.Unmarshal([]byte(key), priv); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	enc, _ := json.Marshal(&priv.PublicKey)
+	want := `{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"}`
+	if string(enc) != want {
+		t.Errorf("got %s, want %s", enc, want)
+	}
+
+	for _, test := range []struct {
+		name string
+		old  string
+		new  string
+		want error
+	}{
+		{"key type", `"kty":"EC"`, `"kty":"RSA"`, ErrInvalidCurve},
+		{"curve", `"crv":"P-256"`, `"crv":"P-384"`, ErrPointNotOnCurve},
+		{"point", `"y":"4Etl`, `"y":"5Etl`, ErrPointNotOnCurve},
+		{"private key", `"d":"870M`, `"d":"970M`, ErrInvalidScalar},
+	} {
+		err := json.Unmarshal([]byte(strings.Replace(key, test.old, test.new, 1)), new(PrivateKey))
+		if !errors.Is(err, test.want) {
+			t.Errorf("Unmarshal with another %s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	// JWS requires signatures of twice the length of a scalar, which a
+	// signature with short R and S only meets with MarshalRaw.
+	short := &Signature{R: big.NewInt(1), S: big.NewInt(2)}
+	if enc, _ := json.Marshal(short); string(enc) != `"AQI"` {
+		t.Errorf("got %s, want \"AQI\"", enc)
+	}
+	if raw, _ := short.MarshalRaw(elliptic.P256()); len(raw) != 64 {
+		t.Errorf("raw signature of %d bytes, want 64", len(raw))
+	}
+}
@@ -0,0 +1,371 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	keyringDST = "ECDSA Keyring v1"
+
+	// KeyringKeySize is the size, in bytes, of the keys that encrypt
+	// keyring files.
+	KeyringKeySize = 32
+)
+
+// KeyOrigin describes how a blinded key of a Keyring was derived.
+type KeyOrigin struct {
+	// Base is the base key that was blinded.
+	Base *PublicKey
+	// Blind and Context are the blind and context string it was blinded
+	// with.
+	Blind   *PrivateKey
+	Context []byte
+}
+
+type keyringBlind struct {
+	bk      *PrivateKey
+	context []byte
+}
+
+// Keyring is a set of base public keys and of blinds, which indexes every
+// key by its fingerprint, including the blinded keys derived from each base
+// key with each blind, so that the base key of a blinded key can be looked
+// up. It is safe for concurrent use.
+//
+// Blinds are secret, so a keyring can only be persisted encrypted, with
+// Save and LoadKeyring.
+type Keyring struct {
+	mu      sync.RWMutex
+	base    map[[sha256.Size]byte]*PublicKey
+	blinds  []keyringBlind
+	blinded map[[sha256.Size]byte]*blindedKey
+}
+
+type blindedKey struct {
+	pub    *PublicKey
+	origin KeyOrigin
+}
+
+// NewKeyring returns an empty keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{
+		base:    make(map[[sha256.Size]byte]*PublicKey),
+		blinded: make(map[[sha256.Size]byte]*blindedKey),
+	}
+}
+
+// AddKey adds the base key pk, which must be valid and on a registered
+// curve, blinds it with every blind of kr, and returns its fingerprint.
+// Adding a key already in kr has no effect.
+func (kr *Keyring) AddKey(pk *PublicKey) ([sha256.Size]byte, error) {
+	if pk == nil || pk.Curve == nil {
+		return [sha256.Size]byte{}, wrapError(ErrPointNotOnCurve, "missing public key or curve")
+	}
+	if err := ValidatePublicKey(pk.Curve, pk); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	if _, ok := registeredCurve(pk.Curve); !ok {
+		return [sha256.Size]byte{}, wrapError(ErrInvalidCurve, "unsupported curve %s", pk.Curve.Params().Name)
+	}
+	fp := pk.Fingerprint()
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, ok := kr.base[fp]; ok {
+		return fp, nil
+	}
+	pks := []*PublicKey{pk}
+	for _, b := range kr.blinds {
+		if err := kr.index(pk.Curve, pks, b); err != nil {
+			return [sha256.Size]byte{}, err
+		}
+	}
+	kr.base[fp] = pk
+	return fp, nil
+}
+
+// AddBlind adds the blind bk with the given context string, and indexes the
+// blinded keys derived with it from every base key of kr. Adding a blind
+// already in kr has no effect.
+func (kr *Keyring) AddBlind(bk *PrivateKey, context []byte) error {
+	if bk == nil || bk.D == nil || bk.D.Sign() <= 0 {
+		return wrapError(ErrZeroBlind, "missing blind")
+	}
+	if bk.Curve == nil {
+		return wrapError(ErrInvalidCurve, "blind has no curve")
+	}
+	if _, ok := registeredCurve(bk.Curve); !ok {
+		return wrapError(ErrInvalidCurve, "unsupported curve %s", bk.Curve.Params().Name)
+	}
+	b := keyringBlind{bk, append([]byte{}, context...)}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	for _, other := range kr.blinds {
+		if other.bk.Curve == bk.Curve && other.bk.D.Cmp(bk.D) == 0 && bytes.Equal(other.context, b.context) {
+			return nil
+		}
+	}
+
+	// Blind the base keys of each curve together.
+	byCurve := make(map[string][]*PublicKey)
+	for _, pk := range kr.sortedKeys() {
+		name := pk.Curve.Params().Name
+		byCurve[name] = append(byCurve[name], pk)
+	}
+	for _, pks := range byCurve {
+		if err := kr.index(pks[0].Curve, pks, b); err != nil {
+			return err
+		}
+	}
+	kr.blinds = append(kr.blinds, b)
+	return nil
+}
+
+// index blinds pks, which are on c, with b and indexes the results. It must
+// be called with kr.mu held.
+func (kr *Keyring) index(c elliptic.Curve, pks []*PublicKey, b keyringBlind) error {
+	pkRs, err := BlindPublicKeysWithContext(c, pks, b.bk, b.context)
+	if err != nil {
+		return err
+	}
+	for i, pkR := range pkRs {
+		kr.blinded[pkR.Fingerprint()] = &blindedKey{pkR, KeyOrigin{pks[i], b.bk, b.context}}
+	}
+	return nil
+}
+
+// Key returns the base or blinded key of kr with the given fingerprint.
+func (kr *Keyring) Key(fingerprint [sha256.Size]byte) (*PublicKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	if pk, ok := kr.base[fingerprint]; ok {
+		return pk, true
+	}
+	if bk, ok := kr.blinded[fingerprint]; ok {
+		return bk.pub, true
+	}
+	return nil, false
+}
+
+// Origin returns the base key, blind and context string that pkR was
+// derived with, if pkR is a blinded key of kr.
+func (kr *Keyring) Origin(pkR *PublicKey) (KeyOrigin, bool) {
+	if pkR == nil || pkR.Curve == nil || pkR.X == nil || pkR.Y == nil {
+		return KeyOrigin{}, false
+	}
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	bk, ok := kr.blinded[pkR.Fingerprint()]
+	if !ok || !bk.pub.Equal(pkR) {
+		return KeyOrigin{}, false
+	}
+	return bk.origin, true
+}
+
+// Keys returns the base keys of kr, sorted by fingerprint.
+func (kr *Keyring) Keys() []*PublicKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.sortedKeys()
+}
+
+func (kr *Keyring) sortedKeys() []*PublicKey {
+	fps := make([][sha256.Size]byte, 0, len(kr.base))
+	for fp := range kr.base {
+		fps = append(fps, fp)
+	}
+	sort.Slice(fps, func(i, j int) bool { return bytes.Compare(fps[i][:], fps[j][:]) < 0 })
+	pks := make([]*PublicKey, len(fps))
+	for i, fp := range fps {
+		pks[i] = kr.base[fp]
+	}
+	return pks
+}
+
+// Len returns the number of base keys and blinds of kr.
+func (kr *Keyring) Len() (keys, blinds int) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return len(kr.base), len(kr.blinds)
+}
+
+// marshal encodes the base keys and blinds of kr:
+//
+//	struct {
+//	    opaque curve<1..2^8-1>;
+//	    opaque point<1..2^8-1>;
+//	} Key;
+//
+//	struct {
+//	    opaque curve<1..2^8-1>;
+//	    opaque blind<1..2^16-1>;
+//	    opaque context<0..2^16-1>;
+//	} Blind;
+//
+//	struct {
+//	    Key keys<0..2^24-1>;
+//	    Blind blinds<0..2^24-1>;
+//	} Keyring;
+//
+// where curve is the registered name of a curve and point is compressed.
+func (kr *Keyring) marshal() ([]byte, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	var b cryptobyte.Builder
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, pk := range kr.sortedKeys() {
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(pk.Curve.Params().Name))
+			})
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(elliptic.MarshalCompressed(pk.Curve, pk.X, pk.Y))
+			})
+		}
+	})
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, bl := range kr.blinds {
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(bl.bk.Curve.Params().Name))
+			})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(bl.bk.D.Bytes())
+			})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(bl.context)
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+func unmarshalKeyring(data []byte) (*Keyring, error) {
+	kr := NewKeyring()
+	s := cryptobyte.String(data)
+	var keys, blinds cryptobyte.String
+	if !s.ReadUint24LengthPrefixed(&keys) || !s.ReadUint24LengthPrefixed(&blinds) || !s.Empty() {
+		return nil, wrapError(ErrInvalidKeyring, "malformed keyring")
+	}
+	for !keys.Empty() {
+		var name, point cryptobyte.String
+		if !keys.ReadUint8LengthPrefixed(&name) || !keys.ReadUint8LengthPrefixed(&point) {
+			return nil, wrapError(ErrInvalidKeyring, "malformed key")
+		}
+		c, err := CurveByName(string(name))
+		if err != nil {
+			return nil, err
+		}
+		p, err := NewPoint(c, point)
+		if err != nil {
+			return nil, err
+		}
+		pk, err := NewPublicKey(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := kr.AddKey(pk); err != nil {
+			return nil, err
+		}
+	}
+	for !blinds.Empty() {
+		var name, blind, context cryptobyte.String
+		if !blinds.ReadUint8LengthPrefixed(&name) || !blinds.ReadUint16LengthPrefixed(&blind) ||
+			!blinds.ReadUint16LengthPrefixed(&context) {
+			return nil, wrapError(ErrInvalidKeyring, "malformed blind")
+		}
+		c, err := CurveByName(string(name))
+		if err != nil {
+			return nil, err
+		}
+		bk, err := CreateKey(c, blind)
+		if err != nil {
+			return nil, err
+		}
+		if err := kr.AddBlind(bk, context); err != nil {
+			return nil, err
+		}
+	}
+	return kr, nil
+}
+
+func keyringAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeyringKeySize {
+		return nil, wrapError(ErrInvalidKeyring, "key of %d bytes, want %d", len(key), KeyringKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Save writes kr to the file at path, encrypted with AES-256-GCM under key,
+// which must be KeyringKeySize bytes long. The file is replaced atomically,
+// and is only readable by its owner.
+func (kr *Keyring) Save(path string, key []byte) error {
+	aead, err := keyringAEAD(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := kr.marshal()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(entropySource(nil), nonce); err != nil {
+		return entropyError(err)
+	}
+	data := aead.Seal(nonce, nonce, plaintext, []byte(keyringDST))
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadKeyring reads a keyring written by Keyring.Save from the file at path,
+// and decrypts it with key. It returns an error wrapping ErrInvalidKeyring if
+// the file can't be decrypted with key or is malformed.
+func LoadKeyring(path string, key []byte) (*Keyring, error) {
+	aead, err := keyringAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, wrapError(ErrInvalidKeyring, "file too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyringDST))
+	if err != nil {
+		return nil, wrapError(ErrInvalidKeyring, "decryption failed")
+	}
+	return unmarshalKeyring(plaintext)
+}
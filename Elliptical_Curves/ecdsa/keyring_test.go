@@ -0,0 +1,167 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	kr := NewKeyring()
+	curves := []elliptic.Curve{elliptic.P256(), elliptic.P384()}
+
+	var pks []*PublicKey
+	for _, c := range curves {
+		sk, _ := GenerateKey(c, rand.Reader)
+		fp, err := kr.AddKey(&sk.PublicKey)
+		if err != nil {
+			t.Fatalf("AddKey error: %s", err)
+		}
+		if pk, ok := kr.Key(fp); !ok || !pk.Equal(&sk.PublicKey) {
+			t.Errorf("Key did not return the base key")
+		}
+		pks = append(pks, &sk.PublicKey)
+	}
+
+	// Blinds added before and after a key both apply to it.
+	bk1, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	bk2, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	if err := kr.AddBlind(bk1, []byte("epoch 1")); err != nil {
+		t.Fatalf("AddBlind error: %s", err)
+	}
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := kr.AddKey(&sk.PublicKey); err != nil {
+		t.Fatalf("AddKey error: %s", err)
+	}
+	pks = append(pks, &sk.PublicKey)
+	if err := kr.AddBlind(bk2, nil); err != nil {
+		t.Fatalf("AddBlind error: %s", err)
+	}
+	if err := kr.AddBlind(bk2, nil); err != nil {
+		t.Fatalf("AddBlind error for a known blind: %s", err)
+	}
+	if keys, blinds := kr.Len(); keys != 3 || blinds != 2 {
+		t.Errorf("Len = %d, %d, want 3, 2", keys, blinds)
+	}
+
+	for _, pk := range pks {
+		pkR, err := BlindPublicKeyWithContext(pk.Curve, pk, bk1, []byte("epoch 1"))
+		if err != nil {
+			t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+		}
+		origin, ok := kr.Origin(pkR)
+		if !ok {
+			t.Fatalf("Origin did not find the blinded key")
+		}
+		if !origin.Base.Equal(pk) || origin.Blind != bk1 || string(origin.Context) != "epoch 1" {
+			t.Errorf("Origin = %+v, want the base key, blind and context", origin)
+		}
+		if got, ok := kr.Key(pkR.Fingerprint()); !ok || !got.Equal(pkR) {
+			t.Errorf("Key did not return the blinded key")
+		}
+	}
+
+	unknown, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	if _, ok := kr.Origin(&unknown.PublicKey); ok {
+		t.Errorf("Origin found an unknown key")
+	}
+	if _, ok := kr.Origin(pks[0]); ok {
+		t.Errorf("Origin found a base key")
+	}
+
+	if _, err := kr.AddKey(&PublicKey{elliptic.P256(), pks[0].X, pks[0].X}); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("AddKey with an invalid key: got %v, want ErrPointNotOnCurve", err)
+	}
+	if err := kr.AddBlind(nil, nil); !errors.Is(err, ErrZeroBlind) {
+		t.Errorf("AddBlind without a blind: got %v, want ErrZeroBlind", err)
+	}
+}
+
+func TestKeyringSave(t *testing.T) {
+	kr := NewKeyring()
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P521()} {
+		sk, _ := GenerateKey(c, rand.Reader)
+		if _, err := kr.AddKey(&sk.PublicKey); err != nil {
+			t.Fatalf("AddKey error: %s", err)
+		}
+	}
+	bk, _ := GenerateKey(elliptic.P384(), rand.Reader)
+	if err := kr.AddBlind(bk, []byte("context")); err != nil {
+		t.Fatalf("AddBlind error: %s", err)
+	}
+
+	key := make([]byte, KeyringKeySize)
+	rand.Read(key)
+	path := filepath.Join(t.TempDir(), "keyring")
+	if err := kr.Save(path, key); err != nil {
+		t.Fatalf("Save error: %s", err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("keyring file mode = %v, %v, want 0600", fi.Mode().Perm(), err)
+	}
+
+	loaded, err := LoadKeyring(path, key)
+	if err != nil {
+		t.Fatalf("LoadKeyring error: %s", err)
+	}
+	want, got := kr.Keys(), loaded.Keys()
+	if len(got) != len(want) {
+		t.Fatalf("loaded %d keys, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("key %d differs after loading", i)
+		}
+	}
+	pkR, _ := BlindPublicKeyWithContext(want[0].Curve, want[0], bk, []byte("context"))
+	if origin, ok := loaded.Origin(pkR); !ok || !origin.Base.Equal(want[0]) || origin.Blind.D.Cmp(bk.D) != 0 {
+		t.Errorf("loaded keyring lost the blinded keys")
+	}
+
+	wrong := make([]byte, KeyringKeySize)
+	if _, err := LoadKeyring(path, wrong); !errors.Is(err, ErrInvalidKeyring) {
+		t.Errorf("LoadKeyring with the wrong key: got %v, want ErrInvalidKeyring", err)
+	}
+	if _, err := LoadKeyring(path, key[1:]); !errors.Is(err, ErrInvalidKeyring) {
+		t.Errorf("LoadKeyring with a short key: got %v, want ErrInvalidKeyring", err)
+	}
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 1
+	os.WriteFile(path, data, 0o600)
+	if _, err := LoadKeyring(path, key); !errors.Is(err, ErrInvalidKeyring) {
+		t.Errorf("LoadKeyring of a corrupted file: got %v, want ErrInvalidKeyring", err)
+	}
+}
+
+func TestKeyringConcurrent(t *testing.T) {
+	kr := NewKeyring()
+	bk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	if err := kr.AddBlind(bk, nil); err != nil {
+		t.Fatalf("AddBlind error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+			if _, err := kr.AddKey(&sk.PublicKey); err != nil {
+				t.Errorf("AddKey error: %s", err)
+				return
+			}
+			pkR, _ := BlindPublicKey(elliptic.P256(), &sk.PublicKey, bk)
+			if _, ok := kr.Origin(pkR); !ok {
+				t.Errorf("Origin did not find a concurrently added key")
+			}
+		}()
+	}
+	wg.Wait()
+	if keys, _ := kr.Len(); keys != 8 {
+		t.Errorf("Len = %d, want 8", keys)
+	}
+}
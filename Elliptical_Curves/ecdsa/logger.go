@@ -0,0 +1,63 @@
+package ecdsa
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// IssuanceEntry records a signature produced with a blinded key. It holds the
+// fingerprint of the blinded public key rather than the key itself, and
+// never any of the secret inputs.
+type IssuanceEntry struct {
+	// BlindedKey is the Fingerprint of the blinded public key.
+	BlindedKey [sha256.Size]byte
+	// Digest is the signed hash, as passed to BlindKeySign.
+	Digest []byte
+	// Time is the time at which the signature was produced.
+	Time time.Time
+}
+
+// An IssuanceLogger is notified of each signature produced by
+// BlindKeySignWithContext and BlindKeySignBoundWithContext, for example to
+// append it to a transparency log. LogIssuance is called synchronously after
+// the signature is produced, and may be called concurrently.
+type IssuanceLogger interface {
+	LogIssuance(entry IssuanceEntry)
+}
+
+var issuanceLogger struct {
+	sync.RWMutex
+	l IssuanceLogger
+}
+
+// SetIssuanceLogger sets the logger notified of blinded signatures, replacing
+// any previous one. A nil logger disables logging, which is the default.
+func SetIssuanceLogger(l IssuanceLogger) {
+	issuanceLogger.Lock()
+	defer issuanceLogger.Unlock()
+	issuanceLogger.l = l
+}
+
+// issuanceLogging reports whether an issuance logger is set.
+func issuanceLogging() bool {
+	issuanceLogger.RLock()
+	defer issuanceLogger.RUnlock()
+	return issuanceLogger.l != nil
+}
+
+// logIssuance notifies the issuance logger, if any, of a signature of digest
+// by the blinded key pkR.
+func logIssuance(pkR *PublicKey, digest []byte) {
+	issuanceLogger.RLock()
+	l := issuanceLogger.l
+	issuanceLogger.RUnlock()
+	if l == nil {
+		return
+	}
+	l.LogIssuance(IssuanceEntry{
+		BlindedKey: pkR.Fingerprint(),
+		Digest:     append([]byte{}, digest...),
+		Time:       time.Now(),
+	})
+}
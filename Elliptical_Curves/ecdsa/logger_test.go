@@ -0,0 +1,103 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"sync"
+	"testing"
+)
+
+type recordingLogger struct {
+	sync.Mutex
+	entries []IssuanceEntry
+}
+
+func (l *recordingLogger) LogIssuance(entry IssuanceEntry) {
+	l.Lock()
+	defer l.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// entriesFor returns the entries of the blinded key with fingerprint fp, so
+// that a logger can be shared by the parallel subtests of testAllCurves.
+func (l *recordingLogger) entriesFor(fp [sha256.Size]byte) []IssuanceEntry {
+	l.Lock()
+	defer l.Unlock()
+	var entries []IssuanceEntry
+	for _, e := range l.entries {
+		if e.BlindedKey == fp {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (l *recordingLogger) len() int {
+	l.Lock()
+	defer l.Unlock()
+	return len(l.entries)
+}
+
+func TestIssuanceLogger(t *testing.T) {
+	l := new(recordingLogger)
+	SetIssuanceLogger(l)
+	t.Cleanup(func() { SetIssuanceLogger(nil) })
+	testAllCurves(t, func(t *testing.T, c elliptic.Curve) {
+		testIssuanceLogger(t, c, l)
+	})
+}
+
+func testIssuanceLogger(t *testing.T, c elliptic.Curve, l *recordingLogger) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	hashed := testDigest("testing")
+
+	if _, _, err := Sign(rand.Reader, skS, hashed); err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if entries := l.entriesFor(skS.PublicKey.Fingerprint()); len(entries) != 0 {
+		t.Errorf("unblinded signature logged")
+	}
+	if _, _, err := BlindKeySignWithContext(rand.Reader, skS, skB, hashed, context); err != nil {
+		t.Fatalf("BlindKeySignWithContext error: %s", err)
+	}
+	if _, _, err := BlindKeySignBoundWithContext(rand.Reader, skS, skB, hashed, context); err != nil {
+		t.Fatalf("BlindKeySignBoundWithContext error: %s", err)
+	}
+
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	entries := l.entriesFor(pkR.Fingerprint())
+	if len(entries) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(entries))
+	}
+	for i, e := range entries {
+		if !bytes.Equal(e.Digest, hashed) {
+			t.Errorf("entry %d: digest mismatch", i)
+		}
+		if e.Time.IsZero() {
+			t.Errorf("entry %d: missing time", i)
+		}
+	}
+}
+
+func TestIssuanceLoggerRemoved(t *testing.T) {
+	l := new(recordingLogger)
+	SetIssuanceLogger(l)
+	SetIssuanceLogger(nil)
+
+	c := elliptic.P256()
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	if _, _, err := BlindKeySign(rand.Reader, skS, skB, testDigest("testing")); err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	if l.len() != 0 {
+		t.Errorf("signature logged after the logger was removed")
+	}
+}
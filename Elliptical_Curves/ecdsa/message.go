@@ -0,0 +1,115 @@
+package ecdsa
+
+import (
+	"crypto"
+	"io"
+	"math/big"
+	"sync/atomic"
+)
+
+// strictMode is 1 when strict mode is enabled.
+var strictMode int32
+
+// SetStrictMode enables or disables strict mode. In strict mode, every
+// function of this package that takes a digest, from Sign, Verify and
+// CheckSignature to SignBatch, SignerSession.Complete, VerifierSession and
+// BulkVerifier, only accepts digests as long as the output of a hash
+// function: 20, 28, 32, 48 or 64 bytes. This catches most callers that pass
+// a message instead of its digest, which these functions would otherwise
+// silently truncate and sign. Functions that take a message and hash it, such
+// as SignMessage, are not affected.
+func SetStrictMode(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&strictMode, 1)
+	} else {
+		atomic.StoreInt32(&strictMode, 0)
+	}
+}
+
+// StrictMode reports whether strict mode is enabled.
+func StrictMode() bool {
+	return atomic.LoadInt32(&strictMode) == 1
+}
+
+// strictCheckDigest returns an error wrapping ErrInvalidDigest if strict mode
+// is enabled and digest is not as long as the output of a hash function.
+func strictCheckDigest(digest []byte) error {
+	if !StrictMode() {
+		return nil
+	}
+	switch len(digest) {
+	case 20, 28, 32, 48, 64:
+		return nil
+	}
+	return wrapError(ErrInvalidDigest, "%d-byte input is not a digest", len(digest))
+}
+
+// hashMessage returns the digest of msg with h.
+func hashMessage(msg []byte, h crypto.Hash) ([]byte, error) {
+	switch {
+	case h == 0 || h > crypto.BLAKE2b_512 || !h.Available():
+		return nil, wrapError(ErrInvalidDigest, "hash function %v not available", h)
+	case h == crypto.MD4 || h == crypto.MD5 || h == crypto.MD5SHA1:
+		return nil, wrapError(ErrInvalidDigest, "hash function %v not suitable for signatures", h)
+	}
+	if err := fipsCheckHash(h); err != nil {
+		return nil, err
+	}
+	hh := h.New()
+	hh.Write(msg)
+	return hh.Sum(nil), nil
+}
+
+// SignMessage hashes msg with h and signs the digest with priv, drawing the
+// nonce from the randomness source set with SetConfig, crypto/rand.Reader by
+// default. Unlike Sign, it can't be passed an unhashed message by mistake.
+func SignMessage(priv *PrivateKey, msg []byte, h crypto.Hash) (r, s *big.Int, err error) {
+	digest, err := hashMessage(msg, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signDigest(nil, priv, digest)
+}
+
+// BlindKeySignMessage hashes msg with h and signs the digest with skS
+// blinded by skB and context, as BlindKeySignWithContext does. The signature
+// verifies with VerifyMessage under the public key returned by
+// BlindPublicKeyWithContext.
+func BlindKeySignMessage(skS, skB *PrivateKey, msg []byte, h crypto.Hash, context []byte) (r, s *big.Int, err error) {
+	digest, err := hashMessage(msg, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	return BlindKeySignWithContext(nil, skS, skB, digest, context)
+}
+
+// VerifyMessage reports whether r, s is a valid signature of msg hashed with
+// h under pub.
+func VerifyMessage(pub *PublicKey, msg []byte, h crypto.Hash, r, s *big.Int) bool {
+	digest, err := hashMessage(msg, h)
+	if err != nil {
+		return false
+	}
+	return VerifyDigest(pub, digest, r, s)
+}
+
+// SignDigest signs digest, the output of a hash function, with priv. It is
+// Sign under a name that makes clear that the message must already be
+// hashed.
+func SignDigest(rand io.Reader, priv *PrivateKey, digest []byte) (r, s *big.Int, err error) {
+	return Sign(rand, priv, digest)
+}
+
+// BlindKeySignDigest signs digest, the output of a hash function, with skS
+// blinded by skB and context. It is BlindKeySignWithContext under a name
+// that makes clear that the message must already be hashed.
+func BlindKeySignDigest(rand io.Reader, skS, skB *PrivateKey, digest, context []byte) (r, s *big.Int, err error) {
+	return BlindKeySignWithContext(rand, skS, skB, digest, context)
+}
+
+// VerifyDigest reports whether r, s is a valid signature of digest, the
+// output of a hash function, under pub. It is Verify under a name that makes
+// clear that the message must already be hashed.
+func VerifyDigest(pub *PublicKey, digest []byte, r, s *big.Int) bool {
+	return Verify(pub, digest, r, s)
+}
@@ -0,0 +1,301 @@
+package ecdsa
+
+import (
+	stdcontext "context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestSignMessage(t *testing.T) {
+	testAllCurves(t, testSignMessage)
+}
+
+func testSignMessage(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	msg := []byte("testing")
+
+	r, s, err := SignMessage(skS, msg, crypto.SHA384)
+	if err != nil {
+		t.Fatalf("SignMessage error: %s", err)
+	}
+	if !VerifyMessage(&skS.PublicKey, msg, crypto.SHA384, r, s) {
+		t.Errorf("VerifyMessage failed")
+	}
+	if VerifyMessage(&skS.PublicKey, msg, crypto.SHA256, r, s) {
+		t.Errorf("VerifyMessage with another hash function succeeded")
+	}
+	digest := sha256.Sum256(msg)
+	if VerifyDigest(&skS.PublicKey, msg, r, s) || VerifyDigest(&skS.PublicKey, digest[:], r, s) {
+		t.Errorf("VerifyDigest of the message or of another digest succeeded")
+	}
+
+	r, s, err = BlindKeySignMessage(skS, skB, msg, crypto.SHA256, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignMessage error: %s", err)
+	}
+	if !VerifyMessage(pkR, msg, crypto.SHA256, r, s) || !VerifyDigest(pkR, digest[:], r, s) {
+		t.Errorf("blinded signature of a message does not verify")
+	}
+}
+
+func TestSignMessageHash(t *testing.T) {
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	for _, h := range []crypto.Hash{0, crypto.MD5, crypto.Hash(100)} {
+		if _, _, err := SignMessage(sk, []byte("testing"), h); !errors.Is(err, ErrInvalidDigest) {
+			t.Errorf("SignMessage with hash %v: got %v, want ErrInvalidDigest", h, err)
+		}
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	SetStrictMode(true)
+	defer SetStrictMode(false)
+	if !StrictMode() {
+		t.Fatal("StrictMode() = false after SetStrictMode(true)")
+	}
+
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	msg := []byte("testing")
+	if _, _, err := Sign(rand.Reader, sk, msg); !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("Sign of a message in strict mode: got %v, want ErrInvalidDigest", err)
+	}
+	if _, err := SignASN1(rand.Reader, sk, msg); !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("SignASN1 of a message in strict mode: got %v, want ErrInvalidDigest", err)
+	}
+
+	// A signature of the message made outside of strict mode is rejected.
+	SetStrictMode(false)
+	r, s, err := Sign(rand.Reader, sk, msg)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	SetStrictMode(true)
+	if Verify(&sk.PublicKey, msg, r, s) {
+		t.Errorf("Verify of a message in strict mode succeeded")
+	}
+	if err := CheckSignature(&sk.PublicKey, msg, r, s); !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("CheckSignature of a message in strict mode: got %v, want ErrInvalidDigest", err)
+	}
+
+	digest := sha256.Sum256(msg)
+	r, s, err = SignDigest(rand.Reader, sk, digest[:])
+	if err != nil {
+		t.Fatalf("SignDigest error: %s", err)
+	}
+	if !VerifyDigest(&sk.PublicKey, digest[:], r, s) {
+		t.Errorf("VerifyDigest failed in strict mode")
+	}
+	if r, s, err := SignMessage(sk, msg, crypto.SHA256); err != nil || !VerifyMessage(&sk.PublicKey, msg, crypto.SHA256, r, s) {
+		t.Errorf("SignMessage in strict mode: %v", err)
+	}
+}
+
+// TestStrictModeEntryPoints checks that every function taking a digest
+// rejects a message in strict mode, and every function verifying a digest
+// rejects a signature of a message made outside of it.
+func TestStrictModeEntryPoints(t *testing.T) {
+	c := elliptic.P256()
+	skS, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, _ := GenerateKey(c, rand.Reader)
+	auditor, _ := GenerateKey(c, rand.Reader)
+	pkS := &skS.PublicKey
+	context := []byte("context")
+	pkR, err := BlindPublicKeyWithContext(c, pkS, skB, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("testing")
+	privBytes := skS.D.FillBytes(make([]byte, PrivateKeySize(c)))
+	pubBytes, _ := PublicKeyBytes(c, privBytes)
+
+	// Signatures of the message, made outside of strict mode.
+	r, s, _ := Sign(rand.Reader, skS, msg)
+	asn, _ := SignASN1(rand.Reader, skS, msg)
+	rb, sb, _ := BlindKeySignWithContext(rand.Reader, skS, skB, msg, context)
+	rBound, sBound, _ := BlindKeySignBoundWithContext(rand.Reader, skS, skB, msg, context)
+	audited, _ := BlindKeySignAuditedWithContext(rand.Reader, skS, skB, &auditor.PublicKey, msg, context)
+	commitment, opening, _ := CommitMessage(rand.Reader, c, msg)
+	rc, sc, _ := BlindKeySignCommitted(rand.Reader, skS, skB, commitment, context)
+	committed := &CommittedSignature{Commitment: commitment, Opening: opening, Signature: &Signature{R: rc, S: sc}}
+	set, setOpening, _ := CommitBlindSet(rand.Reader, c, []*PrivateKey{skB}, context)
+	proof, _ := ProveBlindAuthorized(rand.Reader, pkS, pkR, msg, rb, sb, set, setOpening)
+	compact, _ := SignWithProfile(rand.Reader, skS, msg, ProfileCompact)
+	raw, _ := SignBytes(rand.Reader, c, privBytes, msg)
+	vs, _ := NewVerifierSession(pkS)
+	bv, _ := NewBulkVerifier(c, nil)
+
+	// SignWithOptions and VerifyWithOptions are left out: SignerOpts already
+	// require a digest of the declared hash function.
+	verifiers := map[string]func() bool{
+		"Verify":          func() bool { return Verify(pkS, msg, r, s) },
+		"VerifyASN1":      func() bool { return VerifyASN1(pkS, msg, asn) },
+		"VerifyDigest":    func() bool { return VerifyDigest(pkS, msg, r, s) },
+		"CheckSignature":  func() bool { return CheckSignature(pkS, msg, r, s) == nil },
+		"VerifyDetailed":  func() bool { return VerifyDetailed(pkS, msg, asn) == VerifyOK },
+		"VerifyWithBlind": func() bool { return VerifyWithBlindWithContext(pkS, skB, msg, context, rb, sb) },
+		"VerifyBound":     func() bool { return VerifyBound(pkR, msg, rBound, sBound) },
+		"VerifyConstantShape": func() bool {
+			return VerifyConstantShape(pkS, msg, r, s)
+		},
+		"VerifyASN1ConstantShape": func() bool {
+			return VerifyASN1ConstantShape(pkS, msg, asn)
+		},
+		"VerifyWithProfile": func() bool { return VerifyWithProfile(pkS, msg, compact, ProfileCompact) },
+		"RecoverPublicKey": func() bool {
+			pub, err := RecoverPublicKey(c, msg, compact)
+			return err == nil && pub.Equal(pkS)
+		},
+		"VerifierSession.Verify":     func() bool { return vs.Verify(msg, r, s) },
+		"VerifierSession.VerifyASN1": func() bool { return vs.VerifyASN1(msg, asn) },
+		"VerifyAgainstChain": func() bool {
+			_, ok := VerifyAgainstChainWithContext(pkS, []*PrivateKey{skB}, msg, context, rb, sb)
+			return ok
+		},
+		"VerifyAgainstBlindedKeys": func() bool {
+			_, ok := VerifyAgainstBlindedKeys(pkS, []*PublicKey{pkR}, msg, rb, sb)
+			return ok
+		},
+		"BulkVerifier.VerifyBulk": func() bool {
+			ok, err := bv.VerifyBulk(stdcontext.Background(), []BulkItem{{PublicKey: elliptic.Marshal(c, pkS.X, pkS.Y), Hash: msg, Signature: asn}})
+			return err == nil && ok[0]
+		},
+		"VerifyAudited":   func() bool { return VerifyAudited(&auditor.PublicKey, msg, audited) == nil },
+		"VerifyCommitted": func() bool { return VerifyCommitted(pkR, msg, committed) == nil },
+		"VerifyBlindAuthorized": func() bool {
+			return VerifyBlindAuthorized(pkS, pkR, msg, rb, sb, set, proof) == nil
+		},
+		"VerifyBytes": func() bool { return VerifyBytes(c, pubBytes, msg, raw) },
+	}
+	for name, verify := range verifiers {
+		if !verify() {
+			t.Errorf("%s rejected a signature outside of strict mode", name)
+		}
+	}
+
+	signers := map[string]func() error{
+		"Sign": func() error { _, _, err := Sign(rand.Reader, skS, msg); return err },
+		"SignASN1": func() error {
+			_, err := SignASN1(rand.Reader, skS, msg)
+			return err
+		},
+		"SignDigest": func() error { _, _, err := SignDigest(rand.Reader, skS, msg); return err },
+		"PrivateKey.Sign": func() error {
+			_, err := skS.Sign(rand.Reader, msg, crypto.SHA256)
+			return err
+		},
+		"BlindKeySign": func() error {
+			_, _, err := BlindKeySignWithContext(rand.Reader, skS, skB, msg, context)
+			return err
+		},
+		"BlindKeySignWithHash": func() error {
+			_, _, err := BlindKeySignWithHash(rand.Reader, skS, skB, msg, context, BlindHashSHA256)
+			return err
+		},
+		"BlindKeySignDigest": func() error {
+			_, _, err := BlindKeySignDigest(rand.Reader, skS, skB, msg, context)
+			return err
+		},
+		"BlindKeySignBound": func() error {
+			_, _, err := BlindKeySignBoundWithContext(rand.Reader, skS, skB, msg, context)
+			return err
+		},
+		"BlindKeySignAudited": func() error {
+			_, err := BlindKeySignAuditedWithContext(rand.Reader, skS, skB, &auditor.PublicKey, msg, context)
+			return err
+		},
+		"CommitMessage": func() error { _, _, err := CommitMessage(rand.Reader, c, msg); return err },
+		"ProveBlindAuthorized": func() error {
+			_, err := ProveBlindAuthorized(rand.Reader, pkS, pkR, msg, rb, sb, set, setOpening)
+			return err
+		},
+		"SignBatch": func() error {
+			_, err := SignBatchCtx(stdcontext.Background(), rand.Reader, skS, [][]byte{msg})
+			return err
+		},
+		"SignerSession.Complete": func() error {
+			ss, err := NewSignerSession(rand.Reader, skS)
+			if err != nil {
+				return err
+			}
+			defer ss.Abort()
+			_, _, err = ss.Complete(msg)
+			return err
+		},
+		"SignWithProfile": func() error {
+			_, err := SignWithProfile(rand.Reader, skS, msg, ProfileDER)
+			return err
+		},
+		"BlindKeySignWithProfile": func() error {
+			_, err := BlindKeySignWithProfile(rand.Reader, skS, skB, msg, context, ProfileDER)
+			return err
+		},
+		"NonceGuard.Sign": func() error {
+			_, _, err := NewNonceGuard(NewMemoryNonceStore(16)).Sign(rand.Reader, skS, msg)
+			return err
+		},
+		"PolicyKey.Sign": func() error {
+			_, _, err := NewPolicyKey(skS, Policy{}).Sign(rand.Reader, msg)
+			return err
+		},
+		"RateLimitedSigner.BlindKeySign": func() error {
+			_, _, err := NewRateLimitedSigner(RateLimit{}, RateLimit{}).BlindKeySignWithContext(rand.Reader, skS, skB, msg, context)
+			return err
+		},
+		"RemoteBlindSigner.Sign": func() error {
+			rs, err := NewRemoteBlindSigner(skS, skB, context)
+			if err != nil {
+				return err
+			}
+			_, err = rs.Sign(rand.Reader, msg, crypto.SHA256)
+			return err
+		},
+		"SignBytes": func() error {
+			_, err := SignBytes(rand.Reader, c, privBytes, msg)
+			return err
+		},
+	}
+	if !FIPSMode() {
+		signers["SignWithNonceShare"] = func() error {
+			internal, _ := GenerateNonceShare(c, rand.Reader)
+			external, _ := GenerateNonceShare(c, rand.Reader)
+			_, _, err := SignWithNonceShare(skS, msg, internal, external.Scalar(), external.Commitment())
+			return err
+		}
+	}
+
+	SetStrictMode(true)
+	defer SetStrictMode(false)
+	for name, verify := range verifiers {
+		if verify() {
+			t.Errorf("%s accepted a signature of a message in strict mode", name)
+		}
+	}
+	for name, sign := range signers {
+		if err := sign(); !errors.Is(err, ErrInvalidDigest) {
+			t.Errorf("%s of a message in strict mode: got %v, want ErrInvalidDigest", name, err)
+		}
+	}
+
+	// A rejected message doesn't close a signer session.
+	ss, err := NewSignerSession(rand.Reader, skS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ss.Complete(msg); !errors.Is(err, ErrInvalidDigest) {
+		t.Fatalf("SignerSession.Complete of a message in strict mode: got %v, want ErrInvalidDigest", err)
+	}
+	digest := sha256.Sum256(msg)
+	if r, s, err := ss.Complete(digest[:]); err != nil || !Verify(pkS, digest[:], r, s) {
+		t.Errorf("SignerSession.Complete of a digest after a rejected message: %v", err)
+	}
+}
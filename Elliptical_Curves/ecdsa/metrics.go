@@ -0,0 +1,88 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of the hot paths of the package, for example
+// to export them to a monitoring system. Curves are identified by the Name of
+// their parameters. Methods are called synchronously, and may be called
+// concurrently, so they should be fast and safe for concurrent use.
+type Metrics interface {
+	// ObserveSign is called with the duration of each signature produced by
+	// Sign, including the signatures produced with blinded keys.
+	ObserveSign(curve string, d time.Duration)
+
+	// VerifyFailed is called for each signature rejected by Verify or
+	// CheckSignature.
+	VerifyFailed(curve string)
+
+	// KeyBlinded is called for each public key blinded by
+	// BlindPublicKeyWithContext, and KeyUnblinded for each public key
+	// unblinded by UnblindPublicKeyWithContext.
+	KeyBlinded(curve string)
+	KeyUnblinded(curve string)
+
+	// RandomnessError is called each time reading from the random source
+	// fails.
+	RandomnessError()
+}
+
+var metrics struct {
+	sync.RWMutex
+	m Metrics
+}
+
+// SetMetrics sets the receiver of the measurements of the package, replacing
+// any previous one. A nil receiver disables measurements, which is the
+// default.
+func SetMetrics(m Metrics) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.m = m
+}
+
+func currentMetrics() Metrics {
+	metrics.RLock()
+	defer metrics.RUnlock()
+	return metrics.m
+}
+
+func curveName(c elliptic.Curve) string {
+	if c == nil {
+		return ""
+	}
+	return c.Params().Name
+}
+
+func observeSign(c elliptic.Curve, start time.Time) {
+	if m := currentMetrics(); m != nil {
+		m.ObserveSign(curveName(c), time.Since(start))
+	}
+}
+
+func observeVerifyFailure(c elliptic.Curve) {
+	if m := currentMetrics(); m != nil {
+		m.VerifyFailed(curveName(c))
+	}
+}
+
+func observeBlind(c elliptic.Curve) {
+	if m := currentMetrics(); m != nil {
+		m.KeyBlinded(curveName(c))
+	}
+}
+
+func observeUnblind(c elliptic.Curve) {
+	if m := currentMetrics(); m != nil {
+		m.KeyUnblinded(curveName(c))
+	}
+}
+
+func observeRandomnessError() {
+	if m := currentMetrics(); m != nil {
+		m.RandomnessError()
+	}
+}
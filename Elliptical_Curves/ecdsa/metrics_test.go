@@ -0,0 +1,138 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+type curveCounts struct {
+	signs, verifyFailures, blinds, unblinds int
+}
+
+// countingMetrics counts measurements by curve, so that it can be shared by
+// the parallel subtests of testAllCurves.
+type countingMetrics struct {
+	sync.Mutex
+	curves    map[string]*curveCounts
+	rngErrors int
+}
+
+func newCountingMetrics() *countingMetrics {
+	return &countingMetrics{curves: make(map[string]*curveCounts)}
+}
+
+func (m *countingMetrics) record(curve string, f func(*curveCounts)) {
+	m.Lock()
+	defer m.Unlock()
+	if m.curves[curve] == nil {
+		m.curves[curve] = new(curveCounts)
+	}
+	f(m.curves[curve])
+}
+
+func (m *countingMetrics) counts(curve string) curveCounts {
+	m.Lock()
+	defer m.Unlock()
+	if m.curves[curve] == nil {
+		return curveCounts{}
+	}
+	return *m.curves[curve]
+}
+
+func (m *countingMetrics) ObserveSign(curve string, d time.Duration) {
+	m.record(curve, func(c *curveCounts) { c.signs++ })
+}
+
+func (m *countingMetrics) VerifyFailed(curve string) {
+	m.record(curve, func(c *curveCounts) { c.verifyFailures++ })
+}
+
+func (m *countingMetrics) KeyBlinded(curve string) {
+	m.record(curve, func(c *curveCounts) { c.blinds++ })
+}
+
+func (m *countingMetrics) KeyUnblinded(curve string) {
+	m.record(curve, func(c *curveCounts) { c.unblinds++ })
+}
+
+func (m *countingMetrics) RandomnessError() {
+	m.Lock()
+	defer m.Unlock()
+	m.rngErrors++
+}
+
+func TestMetrics(t *testing.T) {
+	m := newCountingMetrics()
+	SetMetrics(m)
+	t.Cleanup(func() { SetMetrics(nil) })
+	testAllCurves(t, func(t *testing.T, c elliptic.Curve) {
+		testMetrics(t, c, m)
+	})
+}
+
+func testMetrics(t *testing.T, c elliptic.Curve, m *countingMetrics) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	hashed := testDigest("testing")
+
+	r, s, err := BlindKeySignWithContext(rand.Reader, skS, skB, hashed, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignWithContext error: %s", err)
+	}
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	if _, err := UnblindPublicKeyWithContext(c, pkR, skB, context); err != nil {
+		t.Fatalf("UnblindPublicKeyWithContext error: %s", err)
+	}
+	if !Verify(pkR, hashed, r, s) {
+		t.Fatalf("valid signature rejected")
+	}
+	if Verify(pkR, hashed, s, r) {
+		t.Fatalf("swapped signature accepted")
+	}
+	if CheckSignature(pkR, hashed, new(big.Int), s) == nil {
+		t.Fatalf("zero r accepted")
+	}
+
+	got := m.counts(c.Params().Name)
+	if got.signs != 1 {
+		t.Errorf("got %d signatures, want 1", got.signs)
+	}
+	if got.verifyFailures != 2 {
+		t.Errorf("got %d verification failures, want 2", got.verifyFailures)
+	}
+	if got.blinds != 1 || got.unblinds != 1 {
+		t.Errorf("got %d blinds and %d unblinds, want 1 and 1", got.blinds, got.unblinds)
+	}
+}
+
+func TestMetricsRandomnessError(t *testing.T) {
+	if FIPSMode() {
+		t.Skip("Sign ignores rand in FIPS mode")
+	}
+	m := newCountingMetrics()
+	SetMetrics(m)
+	defer SetMetrics(nil)
+
+	c := elliptic.P256()
+	skS, _ := GenerateKey(c, rand.Reader)
+	if _, _, err := Sign(failingReader{}, skS, testDigest("testing")); err == nil {
+		t.Fatalf("Sign succeeded without entropy")
+	}
+	if _, err := GenerateKey(c, failingReader{}); err == nil {
+		t.Fatalf("GenerateKey succeeded without entropy")
+	}
+	if m.rngErrors != 2 {
+		t.Errorf("got %d randomness errors, want 2", m.rngErrors)
+	}
+	if got := m.counts(c.Params().Name); got.signs != 0 {
+		t.Errorf("got %d signatures, want 0", got.signs)
+	}
+}
@@ -0,0 +1,148 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"math/bits"
+)
+
+// pippengerThreshold is the number of terms from which MultiScalarMult
+// switches from one scalar multiplication per term to Pippenger's algorithm,
+// on curves other than those of crypto/elliptic.
+const pippengerThreshold = 32
+
+// MultiScalarMult returns the sum of scalars[i] times points[i], the
+// multi-scalar multiplication used by batch verification and by the
+// verification of batched proofs. Points and scalars must all be on the same
+// curve.
+//
+// Like the other arithmetic of Point, MultiScalarMult is built on the
+// elliptic.Curve implementation of the curve. On the curves of
+// crypto/elliptic, whose scalar multiplications use dedicated field
+// arithmetic, it computes one scalar multiplication per term, with the
+// precomputed tables of the base point for terms on it: each generic point
+// addition costs a field inversion, which makes bucket methods slower there.
+// On other curves, such as the Brainpool curves, batches of
+// pippengerThreshold terms or more are computed with Pippenger's bucket
+// method, whose cost grows with n / log(n) point additions per bit of the
+// scalars instead of n scalar multiplications.
+//
+// MultiScalarMult is not constant time: its running time depends on the
+// scalars. It must only be used with public values, such as those of a
+// signature or proof being verified, and never with private keys or nonces.
+func MultiScalarMult(points []*Point, scalars []*Scalar) (*Point, error) {
+	if len(points) != len(scalars) {
+		return nil, wrapError(ErrCurveMismatch, "%d scalars for %d points", len(scalars), len(points))
+	}
+	if len(points) == 0 {
+		return nil, wrapError(ErrInvalidCurve, "empty multi-scalar multiplication")
+	}
+	c := points[0].c
+	for i, p := range points {
+		if p.c != c || scalars[i].c != c {
+			return nil, wrapError(ErrCurveMismatch, "multi-scalar multiplication input not on %s", curveName(c))
+		}
+	}
+	if isStdCurve(c) || len(points) < pippengerThreshold {
+		return straightMSM(c, points, scalars), nil
+	}
+	return pippengerMSM(c, points, scalars), nil
+}
+
+// straightMSM computes a multi-scalar multiplication with one scalar
+// multiplication per term.
+func straightMSM(c elliptic.Curve, points []*Point, scalars []*Scalar) *Point {
+	G := NewGeneratorPoint(c)
+	acc := NewIdentityPoint(c)
+	t := NewIdentityPoint(c)
+	for i, p := range points {
+		if p.Equal(G) == 1 {
+			t.ScalarBaseMult(scalars[i])
+		} else {
+			t.ScalarMult(scalars[i], p)
+		}
+		acc.Add(acc, t)
+	}
+	return acc
+}
+
+// msmWindow returns the window size, in bits, of Pippenger's algorithm for n
+// terms, which balances the n additions into buckets of each window against
+// the 2^w additions needed to sum the buckets.
+func msmWindow(n int) int {
+	w := bits.Len(uint(n)) - 2
+	if w < 2 {
+		return 2
+	}
+	if w > 16 {
+		return 16
+	}
+	return w
+}
+
+// pippengerMSM computes a multi-scalar multiplication with Pippenger's bucket
+// method. The scalars are cut into windows of w bits, from the most
+// significant. In each window, every point is added to the bucket of its
+// digit, and the buckets are summed weighted by their digit with a running
+// sum, which takes 2^(w+1) additions whatever the number of points.
+func pippengerMSM(c elliptic.Curve, points []*Point, scalars []*Scalar) *Point {
+	nbits := c.Params().N.BitLen()
+	w := msmWindow(len(points))
+	digits := make([]*big.Int, len(scalars))
+	for i, s := range scalars {
+		digits[i] = s.BigInt()
+	}
+
+	acc := NewIdentityPoint(c)
+	buckets := make([]*Point, 1<<w)
+	windows := (nbits + w - 1) / w
+	for win := windows - 1; win >= 0; win-- {
+		if acc.IsIdentity() == 0 {
+			for i := 0; i < w; i++ {
+				acc.double(acc)
+			}
+		}
+		for i := range buckets {
+			buckets[i] = nil
+		}
+		for i, d := range digits {
+			digit := windowDigit(d, win*w, w)
+			if digit == 0 {
+				continue
+			}
+			if buckets[digit] == nil {
+				buckets[digit] = NewIdentityPoint(c).Set(points[i])
+			} else {
+				buckets[digit].Add(buckets[digit], points[i])
+			}
+		}
+		sum := NewIdentityPoint(c)
+		total := NewIdentityPoint(c)
+		for digit := len(buckets) - 1; digit > 0; digit-- {
+			if buckets[digit] != nil {
+				sum.Add(sum, buckets[digit])
+			}
+			if sum.IsIdentity() == 0 {
+				total.Add(total, sum)
+			}
+		}
+		acc.Add(acc, total)
+	}
+	return acc
+}
+
+// windowDigit returns the w bits of d starting at bit offset.
+func windowDigit(d *big.Int, offset, w int) int {
+	digit := 0
+	for i := w - 1; i >= 0; i-- {
+		digit = digit<<1 | int(d.Bit(offset+i))
+	}
+	return digit
+}
+
+// double sets p = 2 * q, and returns p.
+func (p *Point) double(q *Point) *Point {
+	p.c = q.c
+	p.x, p.y = q.c.Double(q.x, q.y)
+	return p
+}
@@ -0,0 +1,86 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+func randomMSM(t testing.TB, c elliptic.Curve, n int) ([]*Point, []*Scalar) {
+	points := make([]*Point, n)
+	scalars := make([]*Scalar, n)
+	for i := range points {
+		k, err := GenerateKey(c, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points[i], _ = k.PublicKey.Point()
+		scalars[i], _ = k.Scalar()
+	}
+	return points, scalars
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	testAllCurves(t, testMultiScalarMult)
+}
+
+func testMultiScalarMult(t *testing.T, c elliptic.Curve) {
+	for _, n := range []int{1, 3, pippengerThreshold + 1} {
+		points, scalars := randomMSM(t, c, n)
+		// Edge cases: the base point, the identity, a zero scalar and the
+		// largest scalar.
+		points[0] = NewGeneratorPoint(c)
+		if n > 2 {
+			points[1] = NewIdentityPoint(c)
+			scalars[2] = NewScalar(c)
+			scalars[n-1], _ = NewScalar(c).SetBytes(new(big.Int).Sub(c.Params().N, big.NewInt(1)).FillBytes(make([]byte, scalarSize(c))))
+		}
+		got, err := MultiScalarMult(points, scalars)
+		if err != nil {
+			t.Fatalf("n = %d: MultiScalarMult error: %s", n, err)
+		}
+		if want := straightMSM(c, points, scalars); got.Equal(want) != 1 {
+			t.Errorf("n = %d: MultiScalarMult differs from the sum of scalar multiplications", n)
+		}
+		if want := pippengerMSM(c, points, scalars); got.Equal(want) != 1 {
+			t.Errorf("n = %d: MultiScalarMult differs from Pippenger's algorithm", n)
+		}
+	}
+}
+
+func TestMultiScalarMultErrors(t *testing.T) {
+	points, scalars := randomMSM(t, elliptic.P256(), 2)
+	if _, err := MultiScalarMult(points, scalars[:1]); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("length mismatch: error = %v", err)
+	}
+	if _, err := MultiScalarMult(nil, nil); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("empty: error = %v", err)
+	}
+	scalars[1] = NewScalar(elliptic.P384())
+	if _, err := MultiScalarMult(points, scalars); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("curve mismatch: error = %v", err)
+	}
+}
+
+func BenchmarkMultiScalarMult(b *testing.B) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), brainpool.P256r1()} {
+		for _, n := range []int{16, 64, 256, 1024} {
+			points, scalars := randomMSM(b, c, n)
+			b.Run(fmt.Sprintf("%s/straight/%d", c.Params().Name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					straightMSM(c, points, scalars)
+				}
+			})
+			b.Run(fmt.Sprintf("%s/pippenger/%d", c.Params().Name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					pippengerMSM(c, points, scalars)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package noiseblind adapts blinded keys to the Noise Protocol Framework: a
+// party uses a new blinding of its long-term identity key as the static key
+// of every Noise session, so that the static public key sent in an IK or XX
+// handshake can't be linked across sessions, and proves to a peer that knows
+// its identity that the static key is a blinding of it.
+//
+// DH is a Noise DH function on P-256, P-384 or P-521. Its methods have the
+// signatures of the DH functions of Noise libraries such as
+// github.com/flynn/noise, so it plugs into their cipher suites with a
+// conversion of the key pair type. Public keys are compressed SEC 1 points,
+// and DH outputs are the x-coordinate of the shared point, as ecdsa.ECDH
+// returns.
+//
+// The proof of a static key is an ecdsa.Attestation, which the party sends
+// in the encrypted payload of the handshake message that carries its static
+// key: message 1 of IK for the initiator, and message 2 or 3 of XX for the
+// responder or the initiator. In XX, the initiator sends its proof after it
+// authenticated the responder, so that only the intended peer learns its
+// identity.
+package noiseblind
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+var (
+	// ErrUnsupportedCurve is returned when a curve is not P-256, P-384 or
+	// P-521, or when a key is on another curve than the DH function.
+	ErrUnsupportedCurve = errors.New("noiseblind: unsupported curve")
+
+	// ErrInvalidProof is returned when the proof of a static key is
+	// malformed, is not signed by the expected identity, or is for another
+	// key or context.
+	ErrInvalidProof = errors.New("noiseblind: invalid static key proof")
+)
+
+// Keypair is a Noise key pair: the private key is the fixed-length
+// big-endian encoding of a scalar, and the public key the compressed encoding
+// of a point.
+type Keypair struct {
+	Private []byte
+	Public  []byte
+}
+
+// DH is a Noise DH function on an elliptic curve.
+type DH struct {
+	curve elliptic.Curve
+	name  string
+}
+
+// NewDH returns the DH function on c, which must be P-256, P-384 or P-521.
+// Its Noise name is "P256", "P384" or "P521".
+func NewDH(c elliptic.Curve) (*DH, error) {
+	switch c {
+	case elliptic.P256():
+		return &DH{c, "P256"}, nil
+	case elliptic.P384():
+		return &DH{c, "P384"}, nil
+	case elliptic.P521():
+		return &DH{c, "P521"}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurve, c.Params().Name)
+}
+
+// GenerateKeypair returns a new key pair, for example an ephemeral key, with
+// entropy from rand.
+func (d *DH) GenerateKeypair(rand io.Reader) (Keypair, error) {
+	priv, err := ecdsa.GenerateKey(d.curve, rand)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return keypair(priv)
+}
+
+func keypair(priv *ecdsa.PrivateKey) (Keypair, error) {
+	sk, err := priv.Scalar()
+	if err != nil {
+		return Keypair{}, err
+	}
+	pk, err := priv.PublicKey.Point()
+	if err != nil {
+		return Keypair{}, err
+	}
+	return Keypair{Private: sk.Bytes(), Public: pk.BytesCompressed()}, nil
+}
+
+// DH returns the result of a Diffie-Hellman key agreement between the
+// private key privkey and the public key pubkey.
+func (d *DH) DH(privkey, pubkey []byte) ([]byte, error) {
+	sk, err := ecdsa.NewScalar(d.curve).SetBytes(privkey)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := ecdsa.NewPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := d.publicKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.ECDH(priv, pub)
+}
+
+func (d *DH) publicKey(b []byte) (*ecdsa.PublicKey, error) {
+	p, err := ecdsa.NewPoint(d.curve, b)
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.NewPublicKey(p)
+}
+
+// DHLen returns the length of public keys.
+func (d *DH) DHLen() int {
+	return (d.curve.Params().BitSize+7)/8 + 1
+}
+
+// DHName returns the Noise name of the DH function.
+func (d *DH) DHName() string {
+	return d.name
+}
+
+// Static is the blinded static key of a Noise session, and the proof that it
+// is a blinding of an identity key.
+type Static struct {
+	Keypair Keypair
+	// Proof is the encoding of an ecdsa.Attestation of the blinded key by
+	// the identity key, with epoch zero.
+	Proof []byte
+}
+
+// NewStatic blinds identity with a new blind from rand and context, such as
+// the Noise protocol name of the application, and returns the blinded key
+// pair and its proof. A Static should be used for a single session.
+func (d *DH) NewStatic(rand io.Reader, identity *ecdsa.PrivateKey, context []byte) (*Static, error) {
+	if identity == nil || identity.Curve != d.curve {
+		return nil, fmt.Errorf("%w: identity key is not on %s", ErrUnsupportedCurve, d.curve.Params().Name)
+	}
+	skB, err := ecdsa.GenerateKey(d.curve, rand)
+	if err != nil {
+		return nil, err
+	}
+	skR, err := ecdsa.BlindPrivateKeyWithContext(identity, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	kp, err := keypair(skR)
+	if err != nil {
+		return nil, err
+	}
+	att, err := ecdsa.CreateAttestation(rand, identity, skB, 0, context)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := att.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &Static{Keypair: kp, Proof: proof}, nil
+}
+
+// VerifyStatic checks that proof, received in a handshake payload, shows that
+// remoteStatic, the static public key of the peer, is a blinding of identity
+// with context.
+func (d *DH) VerifyStatic(identity *ecdsa.PublicKey, remoteStatic, proof, context []byte) error {
+	if identity == nil || identity.Curve != d.curve {
+		return fmt.Errorf("%w: identity key is not on %s", ErrUnsupportedCurve, d.curve.Params().Name)
+	}
+	pub, err := d.publicKey(remoteStatic)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	att, err := ecdsa.UnmarshalAttestation(d.curve, proof)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if att.Epoch != 0 || !bytes.Equal(att.Context, context) || !att.BlindedKey.Equal(pub) {
+		return fmt.Errorf("%w: proof is for another key or context", ErrInvalidProof)
+	}
+	if err := ecdsa.VerifyAttestation(identity, att); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package noiseblind
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func TestDH(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		d, err := NewDH(c)
+		if err != nil {
+			t.Fatalf("NewDH(%s) error: %s", c.Params().Name, err)
+		}
+		a, _ := d.GenerateKeypair(rand.Reader)
+		b, _ := d.GenerateKeypair(rand.Reader)
+		if len(a.Public) != d.DHLen() {
+			t.Errorf("%s: public key of %d bytes, DHLen %d", d.DHName(), len(a.Public), d.DHLen())
+		}
+		ab, err := d.DH(a.Private, b.Public)
+		if err != nil {
+			t.Fatalf("%s: DH error: %s", d.DHName(), err)
+		}
+		ba, _ := d.DH(b.Private, a.Public)
+		if !bytes.Equal(ab, ba) {
+			t.Errorf("%s: DH outputs differ", d.DHName())
+		}
+		if _, err := d.DH(a.Private, a.Public[1:]); err == nil {
+			t.Errorf("%s: DH with a malformed public key succeeded", d.DHName())
+		}
+	}
+	if _, err := NewDH(brainpool.P256r1()); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("NewDH(brainpoolP256r1): got %v, want ErrUnsupportedCurve", err)
+	}
+}
+
+func TestStatic(t *testing.T) {
+	d, _ := NewDH(elliptic.P256())
+	identity, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	context := []byte("Noise_XX_P256_ChaChaPoly_SHA256")
+
+	s1, err := d.NewStatic(rand.Reader, identity, context)
+	if err != nil {
+		t.Fatalf("NewStatic error: %s", err)
+	}
+	s2, _ := d.NewStatic(rand.Reader, identity, context)
+	if bytes.Equal(s1.Keypair.Public, s2.Keypair.Public) {
+		t.Error("sessions share a static key")
+	}
+	pub, _ := identity.PublicKey.Point()
+	if bytes.Equal(s1.Keypair.Public, pub.BytesCompressed()) {
+		t.Error("static key is the identity key")
+	}
+
+	// The DH of the static key with an ephemeral key of the peer, as in the
+	// se and es tokens, agrees on both sides.
+	e, _ := d.GenerateKeypair(rand.Reader)
+	se, err := d.DH(s1.Keypair.Private, e.Public)
+	if err != nil {
+		t.Fatalf("DH error: %s", err)
+	}
+	es, _ := d.DH(e.Private, s1.Keypair.Public)
+	if !bytes.Equal(se, es) {
+		t.Error("DH with the static key does not agree")
+	}
+
+	if err := d.VerifyStatic(&identity.PublicKey, s1.Keypair.Public, s1.Proof, context); err != nil {
+		t.Errorf("VerifyStatic error: %s", err)
+	}
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	for name, err := range map[string]error{
+		"other identity": d.VerifyStatic(&other.PublicKey, s1.Keypair.Public, s1.Proof, context),
+		"other key":      d.VerifyStatic(&identity.PublicKey, s2.Keypair.Public, s1.Proof, context),
+		"other context":  d.VerifyStatic(&identity.PublicKey, s1.Keypair.Public, s1.Proof, []byte("other")),
+		"truncated":      d.VerifyStatic(&identity.PublicKey, s1.Keypair.Public, s1.Proof[1:], context),
+		"bad key":        d.VerifyStatic(&identity.PublicKey, s1.Keypair.Public[1:], s1.Proof, context),
+	} {
+		if !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("%s: got %v, want ErrInvalidProof", name, err)
+		}
+	}
+
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := d.NewStatic(rand.Reader, p384, context); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("NewStatic with a P-384 identity: got %v, want ErrUnsupportedCurve", err)
+	}
+}
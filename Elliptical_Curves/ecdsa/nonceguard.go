@@ -0,0 +1,170 @@
+package ecdsa
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// NonceStore records the R values of the signatures released by a
+// NonceGuard. Stores backed by shared storage, such as Redis, let the
+// signing processes of an issuer share one guard.
+type NonceStore interface {
+	// Record records that the key with the given fingerprint signed digest
+	// with a signature whose R value is r, and reports whether r was
+	// already recorded for the key with a different digest. It must be
+	// atomic: of concurrent calls for the same key and r with different
+	// digests, at most one reports false. With Redis, this is SET with NX
+	// on a key derived from fingerprint and r, followed on failure by a GET
+	// comparing the stored digest.
+	Record(fingerprint [sha256.Size]byte, r, digest []byte) (reused bool, err error)
+}
+
+type nonceEntry struct {
+	fingerprint [sha256.Size]byte
+	r           string
+	digest      []byte
+}
+
+// MemoryNonceStore is a NonceStore that keeps the most recently recorded R
+// values in memory, evicting the least recently recorded ones. It is safe
+// for concurrent use.
+type MemoryNonceStore struct {
+	size int
+
+	mu      sync.Mutex
+	entries *list.List
+	index   map[[sha256.Size]byte]map[string]*list.Element
+}
+
+// NewMemoryNonceStore returns a store that keeps at most size R values.
+func NewMemoryNonceStore(size int) *MemoryNonceStore {
+	if size < 1 {
+		size = 1
+	}
+	return &MemoryNonceStore{
+		size:    size,
+		entries: list.New(),
+		index:   make(map[[sha256.Size]byte]map[string]*list.Element),
+	}
+}
+
+// Record implements NonceStore.
+func (ms *MemoryNonceStore) Record(fingerprint [sha256.Size]byte, r, digest []byte) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if el, ok := ms.index[fingerprint][string(r)]; ok {
+		ms.entries.MoveToFront(el)
+		return !bytes.Equal(el.Value.(*nonceEntry).digest, digest), nil
+	}
+
+	if ms.entries.Len() >= ms.size {
+		oldest := ms.entries.Back().Value.(*nonceEntry)
+		ms.entries.Remove(ms.entries.Back())
+		delete(ms.index[oldest.fingerprint], oldest.r)
+		if len(ms.index[oldest.fingerprint]) == 0 {
+			delete(ms.index, oldest.fingerprint)
+		}
+	}
+	e := &nonceEntry{fingerprint, string(r), append([]byte(nil), digest...)}
+	if ms.index[fingerprint] == nil {
+		ms.index[fingerprint] = make(map[string]*list.Element)
+	}
+	ms.index[fingerprint][e.r] = ms.entries.PushFront(e)
+	return false, nil
+}
+
+// Len returns the number of R values in the store.
+func (ms *MemoryNonceStore) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.entries.Len()
+}
+
+// NonceGuard wraps the signing functions of this package, recording the R
+// value of each signature in a NonceStore under the fingerprint of the
+// signing key, and withholding a signature whose R value the key already
+// used for a different digest. Signing two digests with the same nonce
+// reveals the private key, so this only catches a failure of the nonce
+// generation, such as a broken randomness source or a fault, before its
+// signature leaves the issuer. Signing the same digest again with the same
+// nonce reveals nothing and is allowed. It is safe for concurrent use.
+type NonceGuard struct {
+	store NonceStore
+
+	mu         sync.Mutex
+	signatures uint64
+	refused    uint64
+}
+
+// NewNonceGuard returns a guard recording R values in store.
+func NewNonceGuard(store NonceStore) *NonceGuard {
+	return &NonceGuard{store: store}
+}
+
+// Signatures returns the number of signatures released by g.
+func (g *NonceGuard) Signatures() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.signatures
+}
+
+// Refused returns the number of signatures withheld by g because of a
+// reused nonce.
+func (g *NonceGuard) Refused() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.refused
+}
+
+// check records the signature (r, s) of pub over hash, and returns it, or
+// an error wrapping ErrNonceReuse if r was already used.
+func (g *NonceGuard) check(pub *PublicKey, hash []byte, r, s *big.Int) (*big.Int, *big.Int, error) {
+	reused, err := g.store.Record(pub.Fingerprint(), r.FillBytes(make([]byte, scalarSize(pub.Curve))), hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if reused {
+		g.refused++
+		return nil, nil, wrapError(ErrNonceReuse, "R value already used by key %x", pub.Fingerprint())
+	}
+	g.signatures++
+	return r, s, nil
+}
+
+// Sign is like the package-level Sign, but returns an error wrapping
+// ErrNonceReuse instead of the signature if priv already used its nonce.
+func (g *NonceGuard) Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	r, s, err = Sign(rand, priv, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.check(&priv.PublicKey, hash, r, s)
+}
+
+// BlindKeySignWithContext is like the package-level BlindKeySignWithContext,
+// but returns an error wrapping ErrNonceReuse instead of the signature if the
+// blinded key already used its nonce.
+func (g *NonceGuard) BlindKeySignWithContext(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte, context []byte) (r, s *big.Int, err error) {
+	pkR, err := BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, s, err = BlindKeySignWithContext(rand, skS, skB, hash, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.check(pkR, hash, r, s)
+}
+
+// BlindKeySign is like the package-level BlindKeySign, but returns an error
+// wrapping ErrNonceReuse instead of the signature if the blinded key already
+// used its nonce.
+func (g *NonceGuard) BlindKeySign(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	return g.BlindKeySignWithContext(rand, skS, skB, hash, nil)
+}
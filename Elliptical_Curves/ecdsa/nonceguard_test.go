@@ -0,0 +1,80 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestNonceGuard(t *testing.T) {
+	g := NewNonceGuard(NewMemoryNonceStore(16))
+	c := elliptic.P256()
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	hashed := testDigest("testing")
+
+	r, s, err := g.BlindKeySign(rand.Reader, skS, skB, hashed)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	pkR, _ := BlindPublicKey(c, &skS.PublicKey, skB)
+	if !Verify(pkR, hashed, r, s) {
+		t.Fatal("signature failed to verify")
+	}
+
+	// Signing the same digest with the same nonce reveals nothing.
+	if _, _, err := g.check(pkR, hashed, r, s); err != nil {
+		t.Errorf("same digest and nonce: %s", err)
+	}
+	// A faulty nonce generator reusing r for another digest is caught.
+	if _, _, err := g.check(pkR, []byte("other"), r, s); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("reused nonce: got %v, want ErrNonceReuse", err)
+	}
+	// The same r under another key is not a reuse.
+	if _, _, err := g.check(&skS.PublicKey, []byte("other"), r, s); err != nil {
+		t.Errorf("same r under another key: %s", err)
+	}
+	if got := g.Signatures(); got != 3 {
+		t.Errorf("Signatures() = %d, want 3", got)
+	}
+	if got := g.Refused(); got != 1 {
+		t.Errorf("Refused() = %d, want 1", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := g.Sign(repeatingReader{}, skS, []byte{byte(i)}); err != nil {
+			t.Fatalf("Sign error: %s", err)
+		}
+	}
+}
+
+func TestMemoryNonceStoreEviction(t *testing.T) {
+	ms := NewMemoryNonceStore(2)
+	fp := sha256.Sum256([]byte("key"))
+	record := func(r, digest string) bool {
+		reused, err := ms.Record(fp, []byte(r), []byte(digest))
+		if err != nil {
+			t.Fatalf("Record error: %s", err)
+		}
+		return reused
+	}
+
+	record("r1", "a")
+	record("r2", "b")
+	if !record("r1", "c") {
+		t.Error("reuse of r1 not detected")
+	}
+	// r1 was used last, so r2 is evicted.
+	record("r3", "d")
+	if ms.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", ms.Len())
+	}
+	if record("r2", "e") {
+		t.Error("evicted r2 still recorded")
+	}
+	if !record("r3", "f") {
+		t.Error("reuse of r3 not detected")
+	}
+}
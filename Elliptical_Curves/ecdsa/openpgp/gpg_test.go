@@ -0,0 +1,58 @@
+//go:build interop
+
+package openpgp
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// TestInteropGnuPG imports blinded keys into GnuPG and verifies detached
+// signatures with it. Run it with:
+//
+//	go test -tags=interop -run TestInterop ./ecdsa/openpgp
+//
+// GnuPG 2.2 only supports version 4 keys.
+func TestInteropGnuPG(t *testing.T) {
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		t.Skip("gpg not found")
+	}
+	home := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command(gpg, append([]string{"--homedir", home, "--batch", "--no-tty"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("gpg %v: %s\n%s", args, err, out)
+		}
+	}
+
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521(), brainpool.P256r1()} {
+		s := newSigner(t, V4, c)
+		tpk, err := s.Certify(rand.Reader, "Blinded "+c.Params().Name, created)
+		if err != nil {
+			t.Fatalf("Certify error: %s", err)
+		}
+		message := []byte("a message signed by a blinded key")
+		sig, err := s.SignDetached(rand.Reader, message, time.Now())
+		if err != nil {
+			t.Fatalf("SignDetached error: %s", err)
+		}
+
+		dir := t.TempDir()
+		for name, data := range map[string][]byte{"key.pgp": tpk, "message": message, "message.sig": sig} {
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run("--import", filepath.Join(dir, "key.pgp"))
+		run("--verify", filepath.Join(dir, "message.sig"), filepath.Join(dir, "message"))
+	}
+}
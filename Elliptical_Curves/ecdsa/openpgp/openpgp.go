@@ -0,0 +1,519 @@
+// Package openpgp encodes blinded ECDSA public keys and signatures as OpenPGP
+// packets, so that blinded identities can be published on keyservers and
+// their signatures verified by OpenPGP implementations such as GnuPG and
+// Sequoia.
+//
+// ECDSA is OpenPGP public key algorithm 19, defined for the NIST and
+// Brainpool curves, so a blinded key is an ordinary OpenPGP key: nothing in
+// its packets reveals that it was blinded. Both version 4 keys and
+// signatures, as specified in RFC 4880 and RFC 6637, and version 6 keys and
+// signatures, as specified in RFC 9580, are supported. Messages are hashed
+// with SHA-256, SHA-384 or SHA-512, matching the size of the curve.
+package openpgp
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// Versions of the key and signature packets.
+const (
+	V4 = 4
+	V6 = 6
+)
+
+const (
+	tagSignature = 2
+	tagPublicKey = 6
+	tagUserID    = 13
+
+	algoECDSA = 19
+
+	// Signature types.
+	sigBinary   = 0x00
+	sigPositive = 0x13
+
+	// Signature subpacket types.
+	subCreationTime      = 2
+	subIssuerKeyID       = 16
+	subKeyFlags          = 27
+	subIssuerFingerprint = 33
+
+	// Key flags of a self-certified key: it may certify and sign.
+	keyFlagsCertifySign = 0x03
+)
+
+// Errors returned by this package.
+var (
+	// ErrUnsupportedCurve is returned when a key is on a curve OpenPGP
+	// defines no ECDSA OID for.
+	ErrUnsupportedCurve = errors.New("openpgp: unsupported curve")
+
+	// ErrInvalidPacket is returned when a packet is badly encoded or of an
+	// unsupported version or algorithm.
+	ErrInvalidPacket = errors.New("openpgp: invalid packet")
+
+	// ErrInvalidSignature is returned when a signature fails to verify.
+	ErrInvalidSignature = errors.New("openpgp: invalid signature")
+)
+
+type curveInfo struct {
+	oid  []byte
+	hash crypto.Hash
+	id   uint8
+}
+
+// curveParams returns the OID of c and the hash function and OpenPGP hash
+// algorithm ID used with it.
+func curveParams(c elliptic.Curve) (curveInfo, error) {
+	switch c.Params() {
+	case elliptic.P256().Params():
+		return curveInfo{[]byte{0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}, crypto.SHA256, 8}, nil
+	case elliptic.P384().Params():
+		return curveInfo{[]byte{0x2b, 0x81, 0x04, 0x00, 0x22}, crypto.SHA384, 9}, nil
+	case elliptic.P521().Params():
+		return curveInfo{[]byte{0x2b, 0x81, 0x04, 0x00, 0x23}, crypto.SHA512, 10}, nil
+	case brainpool.P256r1().Params():
+		return curveInfo{[]byte{0x2b, 0x24, 0x03, 0x03, 0x02, 0x08, 0x01, 0x01, 0x07}, crypto.SHA256, 8}, nil
+	case brainpool.P384r1().Params():
+		return curveInfo{[]byte{0x2b, 0x24, 0x03, 0x03, 0x02, 0x08, 0x01, 0x01, 0x0b}, crypto.SHA384, 9}, nil
+	case brainpool.P512r1().Params():
+		return curveInfo{[]byte{0x2b, 0x24, 0x03, 0x03, 0x02, 0x08, 0x01, 0x01, 0x0d}, crypto.SHA512, 10}, nil
+	}
+	return curveInfo{}, fmt.Errorf("%w: %s", ErrUnsupportedCurve, c.Params().Name)
+}
+
+// hashByID returns the hash function with the given OpenPGP ID.
+func hashByID(id uint8) (crypto.Hash, bool) {
+	switch id {
+	case 8:
+		return crypto.SHA256, true
+	case 9:
+		return crypto.SHA384, true
+	case 10:
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// saltSize returns the size of the salt of version 6 signatures using h.
+func saltSize(h crypto.Hash) int {
+	switch h {
+	case crypto.SHA384:
+		return 24
+	case crypto.SHA512:
+		return 32
+	}
+	return 16
+}
+
+// PublicKey is an OpenPGP public key packet of an ECDSA key. It must be
+// created with NewPublicKey.
+type PublicKey struct {
+	// Version is V4 or V6.
+	Version int
+	// Created is the creation time of the key, which is part of its
+	// fingerprint. It is stored with a precision of one second.
+	Created time.Time
+	// Key is the ECDSA public key.
+	Key *ecdsa.PublicKey
+
+	curve curveInfo
+}
+
+func timestamp(t time.Time) (uint32, error) {
+	if t.Unix() < 0 || t.Unix() > math.MaxUint32 {
+		return 0, fmt.Errorf("%w: time %v out of range", ErrInvalidPacket, t)
+	}
+	return uint32(t.Unix()), nil
+}
+
+// NewPublicKey returns the public key packet of the given version for pk,
+// which must be on one of the NIST or Brainpool curves, created at the given
+// time.
+func NewPublicKey(version int, pk *ecdsa.PublicKey, created time.Time) (*PublicKey, error) {
+	if version != V4 && version != V6 {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidPacket, version)
+	}
+	if pk == nil || pk.Curve == nil {
+		return nil, fmt.Errorf("%w: missing public key", ErrInvalidPacket)
+	}
+	if err := ecdsa.ValidatePublicKey(pk.Curve, pk); err != nil {
+		return nil, err
+	}
+	ci, err := curveParams(pk.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := timestamp(created); err != nil {
+		return nil, err
+	}
+	return &PublicKey{Version: version, Created: time.Unix(created.Unix(), 0), Key: pk, curve: ci}, nil
+}
+
+// body returns the body of the public key packet.
+func (pk *PublicKey) body() []byte {
+	var material []byte
+	material = append(material, byte(len(pk.curve.oid)))
+	material = append(material, pk.curve.oid...)
+	material = appendMPI(material, elliptic.Marshal(pk.Key.Curve, pk.Key.X, pk.Key.Y))
+
+	t, _ := timestamp(pk.Created)
+	b := []byte{byte(pk.Version)}
+	b = appendUint32(b, t)
+	b = append(b, algoECDSA)
+	if pk.Version == V6 {
+		b = appendUint32(b, uint32(len(material)))
+	}
+	return append(b, material...)
+}
+
+// hashPrefix returns the bytes hashed for the key in fingerprints and
+// certifications.
+func (pk *PublicKey) hashPrefix() []byte {
+	body := pk.body()
+	var b []byte
+	if pk.Version == V6 {
+		b = append([]byte{0x9b}, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[1:], uint32(len(body)))
+	} else {
+		b = append([]byte{0x99}, 0, 0)
+		binary.BigEndian.PutUint16(b[1:], uint16(len(body)))
+	}
+	return append(b, body...)
+}
+
+// Serialize returns the public key packet.
+func (pk *PublicKey) Serialize() []byte {
+	return appendPacket(nil, tagPublicKey, pk.body())
+}
+
+// Fingerprint returns the fingerprint of the key: 20 bytes for a version 4
+// key, and 32 bytes for a version 6 key.
+func (pk *PublicKey) Fingerprint() []byte {
+	if pk.Version == V6 {
+		fp := sha256.Sum256(pk.hashPrefix())
+		return fp[:]
+	}
+	fp := sha1.Sum(pk.hashPrefix())
+	return fp[:]
+}
+
+// KeyID returns the key ID of the key: the low 64 bits of the fingerprint of
+// a version 4 key, and the high 64 bits of the fingerprint of a version 6
+// key.
+func (pk *PublicKey) KeyID() uint64 {
+	fp := pk.Fingerprint()
+	if pk.Version == V6 {
+		return binary.BigEndian.Uint64(fp[:8])
+	}
+	return binary.BigEndian.Uint64(fp[len(fp)-8:])
+}
+
+// Signer signs with a blinded key, producing OpenPGP signatures that verify
+// under the public key packet of the blinded key.
+type Signer struct {
+	// PublicKey is the public key packet of the blinded key.
+	PublicKey *PublicKey
+
+	skS, skB *ecdsa.PrivateKey
+	context  []byte
+}
+
+// NewSigner returns a Signer producing signatures of the given version with
+// skS blinded by skB and context, whose public key packet was created at
+// the given time.
+func NewSigner(version int, skS, skB *ecdsa.PrivateKey, context []byte, created time.Time) (*Signer, error) {
+	if skS == nil {
+		return nil, fmt.Errorf("%w: missing private key", ErrInvalidPacket)
+	}
+	pkR, err := ecdsa.BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := NewPublicKey(version, pkR, created)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{PublicKey: pk, skS: skS, skB: skB, context: append([]byte{}, context...)}, nil
+}
+
+// sign returns a signature packet of type sigType over the given data,
+// created at t.
+func (s *Signer) sign(rand io.Reader, sigType byte, data []byte, t time.Time, flags []byte) ([]byte, error) {
+	pk := s.PublicKey
+	created, err := timestamp(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashed []byte
+	hashed = appendSubpacket(hashed, subCreationTime, appendUint32(nil, created))
+	hashed = appendSubpacket(hashed, subIssuerFingerprint, append([]byte{byte(pk.Version)}, pk.Fingerprint()...))
+	if flags != nil {
+		hashed = appendSubpacket(hashed, subKeyFlags, flags)
+	}
+	var unhashed []byte
+	if pk.Version == V4 {
+		unhashed = appendSubpacket(unhashed, subIssuerKeyID, appendUint64(nil, pk.KeyID()))
+	}
+
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	var salt []byte
+	if pk.Version == V6 {
+		salt = make([]byte, saltSize(pk.curve.hash))
+		if _, err := io.ReadFull(rand, salt); err != nil {
+			return nil, err
+		}
+	}
+	header := signatureHeader(pk.Version, sigType, pk.curve.id, hashed)
+	digest := signatureDigest(pk.Version, pk.curve.hash, salt, data, header)
+
+	r, ss, err := ecdsa.BlindKeySignWithContext(rand, s.skS, s.skB, digest, s.context)
+	if err != nil {
+		return nil, err
+	}
+
+	body := append([]byte{}, header...)
+	body = appendLength(body, pk.Version, len(unhashed))
+	body = append(body, unhashed...)
+	body = append(body, digest[:2]...)
+	if pk.Version == V6 {
+		body = append(body, byte(len(salt)))
+		body = append(body, salt...)
+	}
+	body = appendMPI(body, r.Bytes())
+	body = appendMPI(body, ss.Bytes())
+	return appendPacket(nil, tagSignature, body), nil
+}
+
+// SignDetached returns a detached signature packet over message, as a
+// binary document, created at t. It is the content of the .sig file of
+// gpg --detach-sign.
+func (s *Signer) SignDetached(rand io.Reader, message []byte, t time.Time) ([]byte, error) {
+	return s.sign(rand, sigBinary, message, t, nil)
+}
+
+// Certify returns a transferable public key for the blinded key and userID,
+// which can be imported by OpenPGP implementations or published on a
+// keyserver: the public key packet, the user ID packet, and a positive
+// certification of the user ID by the key, created at t.
+func (s *Signer) Certify(rand io.Reader, userID string, t time.Time) ([]byte, error) {
+	data := append(s.PublicKey.hashPrefix(), userIDPrefix(userID)...)
+	sig, err := s.sign(rand, sigPositive, data, t, []byte{keyFlagsCertifySign})
+	if err != nil {
+		return nil, err
+	}
+	out := s.PublicKey.Serialize()
+	out = appendPacket(out, tagUserID, []byte(userID))
+	return append(out, sig...), nil
+}
+
+// userIDPrefix returns the bytes hashed for a user ID in certifications.
+func userIDPrefix(userID string) []byte {
+	b := []byte{0xb4}
+	b = appendUint32(b, uint32(len(userID)))
+	return append(b, userID...)
+}
+
+// signatureHeader returns the hashed fields of a signature packet.
+func signatureHeader(version int, sigType, hashID byte, hashed []byte) []byte {
+	b := []byte{byte(version), sigType, algoECDSA, hashID}
+	b = appendLength(b, version, len(hashed))
+	return append(b, hashed...)
+}
+
+// signatureDigest returns the digest signed by a signature with the given
+// header over data.
+func signatureDigest(version int, h crypto.Hash, salt, data, header []byte) []byte {
+	hh := h.New()
+	hh.Write(salt)
+	hh.Write(data)
+	hh.Write(header)
+	trailer := []byte{byte(version), 0xff}
+	hh.Write(appendUint32(trailer, uint32(len(header))))
+	return hh.Sum(nil)
+}
+
+// appendLength appends the length of a subpacket area, which is a 2-byte
+// integer in version 4 signatures and a 4-byte integer in version 6
+// signatures.
+func appendLength(b []byte, version, n int) []byte {
+	if version == V6 {
+		return appendUint32(b, uint32(n))
+	}
+	return appendUint16(b, uint16(n))
+}
+
+// appendPacket appends a packet with the given tag and body, with a new
+// format header.
+func appendPacket(b []byte, tag byte, body []byte) []byte {
+	b = append(b, 0xc0|tag)
+	b = appendBodyLength(b, len(body))
+	return append(b, body...)
+}
+
+// appendBodyLength appends a new format packet or subpacket length.
+func appendBodyLength(b []byte, n int) []byte {
+	switch {
+	case n < 192:
+		return append(b, byte(n))
+	case n < 8384:
+		n -= 192
+		return append(b, byte(n>>8)+192, byte(n))
+	default:
+		return appendUint32(append(b, 0xff), uint32(n))
+	}
+}
+
+func appendSubpacket(b []byte, typ byte, data []byte) []byte {
+	b = appendBodyLength(b, len(data)+1)
+	b = append(b, typ)
+	return append(b, data...)
+}
+
+// appendMPI appends the multiprecision integer with big-endian value v.
+func appendMPI(b []byte, v []byte) []byte {
+	n := new(big.Int).SetBytes(v)
+	b = appendUint16(b, uint16(n.BitLen()))
+	return append(b, n.Bytes()...)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
+
+// reader reads the fields of a packet.
+type reader struct {
+	b   []byte
+	err bool
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err || n < 0 || len(r.b) < n {
+		r.err = true
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) uint8() byte {
+	if v := r.bytes(1); v != nil {
+		return v[0]
+	}
+	return 0
+}
+
+func (r *reader) length(version int) int {
+	if version == V6 {
+		if v := r.bytes(4); v != nil && binary.BigEndian.Uint32(v) <= math.MaxInt32 {
+			return int(binary.BigEndian.Uint32(v))
+		}
+		r.err = true
+		return 0
+	}
+	if v := r.bytes(2); v != nil {
+		return int(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (r *reader) mpi() *big.Int {
+	v := r.bytes(2)
+	if v == nil {
+		return nil
+	}
+	b := r.bytes((int(binary.BigEndian.Uint16(v)) + 7) / 8)
+	if b == nil {
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// readPacket reads a new format packet with the given tag.
+func readPacket(data []byte, tag byte) ([]byte, error) {
+	r := &reader{b: data}
+	if r.uint8() != 0xc0|tag {
+		return nil, fmt.Errorf("%w: not a packet with tag %d", ErrInvalidPacket, tag)
+	}
+	n := int(r.uint8())
+	switch {
+	case n >= 192 && n < 224:
+		n = (n-192)<<8 + int(r.uint8()) + 192
+	case n == 0xff:
+		if v := r.bytes(4); v != nil && binary.BigEndian.Uint32(v) <= math.MaxInt32 {
+			n = int(binary.BigEndian.Uint32(v))
+		}
+	case n >= 224:
+		return nil, fmt.Errorf("%w: partial body length", ErrInvalidPacket)
+	}
+	body := r.bytes(n)
+	if r.err || len(r.b) != 0 {
+		return nil, fmt.Errorf("%w: bad packet length", ErrInvalidPacket)
+	}
+	return body, nil
+}
+
+// VerifyDetached verifies a detached signature packet produced by
+// Signer.SignDetached over message, under pk.
+func VerifyDetached(pk *PublicKey, message, sig []byte) error {
+	body, err := readPacket(sig, tagSignature)
+	if err != nil {
+		return err
+	}
+	r := &reader{b: body}
+	version := int(r.uint8())
+	if version != pk.Version {
+		return fmt.Errorf("%w: version %d signature for a version %d key", ErrInvalidPacket, version, pk.Version)
+	}
+	sigType, algo, hashID := r.uint8(), r.uint8(), r.uint8()
+	hashed := r.bytes(r.length(version))
+	r.bytes(r.length(version))
+	left := r.bytes(2)
+	var salt []byte
+	if version == V6 {
+		salt = r.bytes(int(r.uint8()))
+	}
+	R, S := r.mpi(), r.mpi()
+	if r.err || len(r.b) != 0 {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidPacket)
+	}
+	h, ok := hashByID(hashID)
+	if sigType != sigBinary || algo != algoECDSA || !ok {
+		return fmt.Errorf("%w: unsupported signature type %d, algorithm %d or hash %d", ErrInvalidPacket, sigType, algo, hashID)
+	}
+	if version == V6 && len(salt) != saltSize(h) {
+		return fmt.Errorf("%w: salt of %d bytes", ErrInvalidPacket, len(salt))
+	}
+
+	digest := signatureDigest(version, h, salt, message, signatureHeader(version, sigType, hashID, hashed))
+	if digest[0] != left[0] || digest[1] != left[1] || !ecdsa.Verify(pk.Key, digest, R, S) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+	xpacket "golang.org/x/crypto/openpgp/packet" //nolint:staticcheck // only used to check interoperability
+)
+
+var created = time.Unix(1700000000, 0)
+
+func newSigner(t *testing.T, version int, c elliptic.Curve) *Signer {
+	t.Helper()
+	skS, err := ecdsa.GenerateKey(c, rand.Reader)
+	if errors.Is(err, ecdsa.ErrFIPS) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	skB, err := ecdsa.GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	s, err := NewSigner(version, skS, skB, []byte("epoch 1"), created)
+	if err != nil {
+		t.Fatalf("NewSigner error: %s", err)
+	}
+	return s
+}
+
+// TestInteropV4 checks that version 4 keys and signatures are accepted by
+// golang.org/x/crypto/openpgp, which supports ECDSA on the NIST curves.
+func TestInteropV4(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(c.Params().Name, func(t *testing.T) {
+			s := newSigner(t, V4, c)
+			tpk, err := s.Certify(rand.Reader, "Blinded <blinded@example.com>", created)
+			if err != nil {
+				t.Fatalf("Certify error: %s", err)
+			}
+
+			r := xpacket.NewReader(bytes.NewReader(tpk))
+			p, err := r.Next()
+			if err != nil {
+				t.Fatalf("reading public key packet: %s", err)
+			}
+			pk, ok := p.(*xpacket.PublicKey)
+			if !ok {
+				t.Fatalf("got %T, want a public key packet", p)
+			}
+			if !bytes.Equal(pk.Fingerprint[:], s.PublicKey.Fingerprint()) || pk.KeyId != s.PublicKey.KeyID() {
+				t.Errorf("fingerprint %x, want %x", pk.Fingerprint, s.PublicKey.Fingerprint())
+			}
+			if !pk.CreationTime.Equal(created) {
+				t.Errorf("creation time %v, want %v", pk.CreationTime, created)
+			}
+			p, _ = r.Next()
+			uid, ok := p.(*xpacket.UserId)
+			if !ok {
+				t.Fatalf("got %T, want a user ID packet", p)
+			}
+			p, _ = r.Next()
+			sig, ok := p.(*xpacket.Signature)
+			if !ok {
+				t.Fatalf("got %T, want a signature packet", p)
+			}
+			if err := pk.VerifyUserIdSignature(uid.Id, pk, sig); err != nil {
+				t.Errorf("user ID certification failed to verify: %s", err)
+			}
+
+			message := []byte("a message signed by a blinded key")
+			detached, err := s.SignDetached(rand.Reader, message, created.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("SignDetached error: %s", err)
+			}
+			p, err = xpacket.Read(bytes.NewReader(detached))
+			if err != nil {
+				t.Fatalf("reading signature packet: %s", err)
+			}
+			sig = p.(*xpacket.Signature)
+			if *sig.IssuerKeyId != s.PublicKey.KeyID() {
+				t.Errorf("issuer key ID %x, want %x", *sig.IssuerKeyId, s.PublicKey.KeyID())
+			}
+			h := sig.Hash.New()
+			h.Write(message)
+			if err := pk.VerifySignature(h, sig); err != nil {
+				t.Errorf("detached signature failed to verify: %s", err)
+			}
+			if err := VerifyDetached(s.PublicKey, message, detached); err != nil {
+				t.Errorf("VerifyDetached error: %s", err)
+			}
+		})
+	}
+}
+
+func TestSignDetached(t *testing.T) {
+	curves := []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521(), brainpool.P256r1()}
+	for _, version := range []int{V4, V6} {
+		for _, c := range curves {
+			s := newSigner(t, version, c)
+			message := []byte("message")
+			sig, err := s.SignDetached(rand.Reader, message, created)
+			if err != nil {
+				t.Fatalf("SignDetached error: %s", err)
+			}
+			if err := VerifyDetached(s.PublicKey, message, sig); err != nil {
+				t.Errorf("v%d %s: VerifyDetached error: %s", version, c.Params().Name, err)
+			}
+			if err := VerifyDetached(s.PublicKey, []byte("other"), sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("v%d %s: VerifyDetached of another message: got %v, want ErrInvalidSignature", version, c.Params().Name, err)
+			}
+			if err := VerifyDetached(s.PublicKey, message, sig[:len(sig)-1]); !errors.Is(err, ErrInvalidPacket) {
+				t.Errorf("v%d %s: VerifyDetached of a truncated packet: got %v, want ErrInvalidPacket", version, c.Params().Name, err)
+			}
+		}
+	}
+}
+
+func TestPublicKeyV6(t *testing.T) {
+	s := newSigner(t, V6, elliptic.P256())
+	pk := s.PublicKey
+	fp := pk.Fingerprint()
+	if len(fp) != 32 {
+		t.Fatalf("fingerprint of %d bytes, want 32", len(fp))
+	}
+	packet := pk.Serialize()
+	if packet[0] != 0xc0|tagPublicKey || packet[2] != V6 {
+		t.Errorf("packet header %x, want a version 6 public key packet", packet[:3])
+	}
+	// After the version, creation time and algorithm, the key material is
+	// prefixed with its length: a 1-byte OID length, the 8-byte OID of
+	// P-256, and the 515-bit uncompressed point.
+	if got := packet[2+6 : 2+10]; !bytes.Equal(got, []byte{0, 0, 0, 1 + 8 + 2 + 65}) {
+		t.Errorf("key material length %x", got)
+	}
+
+	pkR, _ := NewPublicKey(V6, s.PublicKey.Key, created.Add(time.Second))
+	if bytes.Equal(pkR.Fingerprint(), fp) {
+		t.Errorf("fingerprint does not depend on the creation time")
+	}
+}
+
+func TestNewPublicKeyErrors(t *testing.T) {
+	sk, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if _, err := NewPublicKey(V4, &sk.PublicKey, created); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("NewPublicKey on P-224: got %v, want ErrUnsupportedCurve", err)
+	}
+	sk, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := NewPublicKey(5, &sk.PublicKey, created); !errors.Is(err, ErrInvalidPacket) {
+		t.Errorf("NewPublicKey of version 5: got %v, want ErrInvalidPacket", err)
+	}
+	if _, err := NewPublicKey(V4, &sk.PublicKey, time.Unix(-1, 0)); !errors.Is(err, ErrInvalidPacket) {
+		t.Errorf("NewPublicKey before 1970: got %v, want ErrInvalidPacket", err)
+	}
+}
@@ -0,0 +1,111 @@
+//go:build interop
+
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// opensslCmd runs openssl with args in dir, and skips the test if openssl is
+// not installed.
+func opensslCmd(t *testing.T, dir string, args ...string) []byte {
+	t.Helper()
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not found")
+	}
+	cmd := exec.Command(openssl, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl %v: %s\n%s", args, err, out)
+	}
+	return out
+}
+
+// TestInteropOpenSSL cross-verifies signatures with OpenSSL: keys generated
+// by OpenSSL sign through a blind, and OpenSSL verifies the signatures under
+// the blinded key; OpenSSL verifies signatures of keys generated here, and
+// signatures made by OpenSSL verify here. Run it with:
+//
+//	go test -tags=interop -run TestInteropOpenSSL ./ecdsa
+func TestInteropOpenSSL(t *testing.T) {
+	message := []byte("a message signed by a blinded key")
+	digest := sha256.Sum256(message)
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521(), brainpool.P256r1()} {
+		dir := t.TempDir()
+		write := func(name string, data []byte) {
+			t.Helper()
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		read := func(name string) []byte {
+			t.Helper()
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data
+		}
+		write("message", message)
+		name := c.Params().Name
+
+		// OpenSSL key, blinded here, verified by OpenSSL.
+		opensslCmd(t, dir, "genpkey", "-algorithm", "EC", "-pkeyopt", "ec_paramgen_curve:"+name, "-out", "skS.pem")
+		skS, err := ParsePrivateKeyPEM(read("skS.pem"))
+		if err != nil {
+			t.Fatalf("%s: ParsePrivateKeyPEM error: %s", name, err)
+		}
+		skB, _ := GenerateKey(c, rand.Reader)
+		context := []byte("openssl interop")
+		pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+		if err != nil {
+			t.Fatalf("%s: BlindPublicKeyWithContext error: %s", name, err)
+		}
+		r, s, err := BlindKeySignWithContext(rand.Reader, skS, skB, digest[:], context)
+		if err != nil {
+			t.Fatalf("%s: BlindKeySignWithContext error: %s", name, err)
+		}
+		sig, _ := EncodeSignature(pkR, digest[:], r, s, ProfileDER)
+		pkRPEM, err := MarshalPublicKeyPEM(pkR)
+		if err != nil {
+			t.Fatalf("%s: MarshalPublicKeyPEM error: %s", name, err)
+		}
+		write("pkR.pem", pkRPEM)
+		write("blinded.sig", sig)
+		opensslCmd(t, dir, "dgst", "-sha256", "-verify", "pkR.pem", "-signature", "blinded.sig", "message")
+		if pkS, err := UnblindPublicKeyWithContext(c, pkR, skB, context); err != nil || !pkS.Equal(&skS.PublicKey) {
+			t.Errorf("%s: unblinded key does not match the OpenSSL key", name)
+		}
+
+		// Key generated here, signature verified by OpenSSL.
+		priv, _ := GenerateKey(c, rand.Reader)
+		privPEM, err := MarshalPrivateKeyPEM(priv)
+		if err != nil {
+			t.Fatalf("%s: MarshalPrivateKeyPEM error: %s", name, err)
+		}
+		write("priv.pem", privPEM)
+		opensslCmd(t, dir, "pkey", "-in", "priv.pem", "-pubout", "-out", "pub.pem")
+		if pub, err := ParsePublicKeyPEM(read("pub.pem")); err != nil || !pub.Equal(&priv.PublicKey) {
+			t.Fatalf("%s: OpenSSL derived a different public key: %v", name, err)
+		}
+		sig, _ = SignASN1(rand.Reader, priv, digest[:])
+		write("priv.sig", sig)
+		opensslCmd(t, dir, "dgst", "-sha256", "-verify", "pub.pem", "-signature", "priv.sig", "message")
+
+		// Signature made by OpenSSL, verified here.
+		opensslCmd(t, dir, "dgst", "-sha256", "-sign", "priv.pem", "-out", "openssl.sig", "message")
+		if !VerifyASN1(&priv.PublicKey, digest[:], read("openssl.sig")) {
+			t.Errorf("%s: OpenSSL signature does not verify", name)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"io"
+	"math/big"
+)
+
+// HashMode selects how a digest is converted to the integer that is signed.
+type HashMode int
+
+const (
+	// HashTruncate keeps the leftmost bits of the digest, as many as the
+	// bit length of the curve order. This is bits2int of RFC 6979, section
+	// 2.3.2, which is also the conversion of SEC 1 and FIPS 186, and the one
+	// used by Sign and Verify.
+	HashTruncate HashMode = iota
+
+	// HashReduce interprets the whole digest as a big-endian integer and
+	// reduces it modulo the curve order. It only differs from HashTruncate
+	// for digests longer than the order, and is expected by some
+	// implementations that don't truncate.
+	HashReduce
+
+	// HashRejectOversized refuses digests with more bits than the curve
+	// order, such as SHA-512 digests on P-256, with an error wrapping
+	// ErrInvalidDigest, and converts other digests as HashTruncate does. It
+	// suits verifiers that must not accept a signature whose digest was
+	// silently shortened.
+	HashRejectOversized
+)
+
+// String returns the name of the mode.
+func (m HashMode) String() string {
+	switch m {
+	case HashTruncate:
+		return "truncate"
+	case HashReduce:
+		return "reduce"
+	case HashRejectOversized:
+		return "reject-oversized"
+	default:
+		return "unknown"
+	}
+}
+
+// SignerOpts binds a signature to the hash function that produced the digest
+// and to the conversion of the digest to an integer, so that a signer and a
+// verifier can't silently disagree on either. It implements
+// crypto.SignerOpts, and is honored by PrivateKey.Sign.
+type SignerOpts struct {
+	// Hash is the hash function that produced the digest. It must be set,
+	// and the digest must be exactly Hash.Size() bytes long.
+	Hash crypto.Hash
+	// HashMode is the conversion of the digest to an integer. The zero
+	// value is HashTruncate, which matches Sign and Verify.
+	HashMode HashMode
+}
+
+// HashFunc returns opts.Hash.
+func (opts *SignerOpts) HashFunc() crypto.Hash {
+	return opts.Hash
+}
+
+// digest checks that digest was produced by opts.Hash, and returns the digest
+// that Sign and Verify convert to the integer opts.HashMode selects.
+func (opts *SignerOpts) digest(c elliptic.Curve, digest []byte) ([]byte, error) {
+	if opts == nil || opts.Hash == 0 || opts.Hash > crypto.BLAKE2b_512 {
+		return nil, wrapError(ErrInvalidDigest, "hash function not declared")
+	}
+	if err := fipsCheckHash(opts.Hash); err != nil {
+		return nil, err
+	}
+	if len(digest) != opts.Hash.Size() {
+		return nil, wrapError(ErrInvalidDigest, "digest of %d bytes does not match %v", len(digest), opts.Hash)
+	}
+
+	switch opts.HashMode {
+	case HashTruncate:
+		return digest, nil
+	case HashRejectOversized:
+		if bits := len(digest) * 8; bits > c.Params().N.BitLen() {
+			return nil, wrapError(ErrInvalidDigest, "%v digest of %d bits is longer than the %d-bit curve order", opts.Hash, bits, c.Params().N.BitLen())
+		}
+		return digest, nil
+	case HashReduce:
+		// Encode e = digest mod N so that hashToInt returns it: shifted
+		// left by the bits hashToInt drops from a digest as long as N.
+		N := c.Params().N
+		size := scalarSize(c)
+		e := new(big.Int).SetBytes(digest)
+		e.Mod(e, N)
+		e.Lsh(e, uint(size*8-N.BitLen()))
+		return e.FillBytes(make([]byte, size)), nil
+	default:
+		return nil, wrapError(ErrInvalidDigest, "unknown hash mode %d", opts.HashMode)
+	}
+}
+
+// SignWithOptions signs digest with priv like Sign, after checking that
+// digest was produced by opts.Hash, and converting it to an integer as
+// opts.HashMode selects.
+func SignWithOptions(rand io.Reader, priv *PrivateKey, digest []byte, opts *SignerOpts) (r, s *big.Int, err error) {
+	d, err := opts.digest(priv.Curve, digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signDigest(rand, priv, d)
+}
+
+// VerifyWithOptions verifies the signature in r, s of digest under pub like
+// CheckSignature, after checking that digest was produced by opts.Hash, and
+// converting it to an integer as opts.HashMode selects. It returns an error
+// wrapping ErrInvalidDigest if the digest doesn't match opts.
+func VerifyWithOptions(pub *PublicKey, digest []byte, r, s *big.Int, opts *SignerOpts) error {
+	if pub == nil || pub.Curve == nil {
+		return wrapError(ErrPointNotOnCurve, "missing public key or curve")
+	}
+	d, err := opts.digest(pub.Curve, digest)
+	if err != nil {
+		return err
+	}
+	if err := checkSignature(pub, d, r, s); err != nil {
+		observeVerifyFailure(pub.Curve)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// verifyInteger checks the signature r, s of the integer e under pub with the
+// textbook equation, independently of hashToInt.
+func verifyInteger(pub *PublicKey, e, r, s *big.Int) bool {
+	c := pub.Curve
+	N := c.Params().N
+	w := new(big.Int).ModInverse(s, N)
+	u1 := new(big.Int).Mul(e, w)
+	u1.Mod(u1, N)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, N)
+	x1, y1 := c.ScalarBaseMult(u1.Bytes())
+	x2, y2 := c.ScalarMult(pub.X, pub.Y, u2.Bytes())
+	x, _ := c.Add(x1, y1, x2, y2)
+	return x.Mod(x, N).Cmp(r) == 0
+}
+
+func TestSignWithOptions(t *testing.T) {
+	testAllCurves(t, testSignWithOptions)
+}
+
+func testSignWithOptions(t *testing.T, c elliptic.Curve) {
+	sk, _ := GenerateKey(c, rand.Reader)
+	N := c.Params().N
+	d256 := sha256.Sum256([]byte("testing"))
+	d512 := sha512.Sum512([]byte("testing"))
+
+	for _, tt := range []struct {
+		digest []byte
+		hash   crypto.Hash
+	}{
+		{d256[:], crypto.SHA256},
+		{d512[:], crypto.SHA512},
+	} {
+		truncate := &SignerOpts{Hash: tt.hash}
+		reduce := &SignerOpts{Hash: tt.hash, HashMode: HashReduce}
+
+		r, s, err := SignWithOptions(rand.Reader, sk, tt.digest, truncate)
+		if err != nil {
+			t.Fatalf("SignWithOptions error: %s", err)
+		}
+		if err := VerifyWithOptions(&sk.PublicKey, tt.digest, r, s, truncate); err != nil {
+			t.Errorf("%v, truncate: VerifyWithOptions error: %s", tt.hash, err)
+		}
+		if !Verify(&sk.PublicKey, tt.digest, r, s) {
+			t.Errorf("%v, truncate: signature does not match Verify", tt.hash)
+		}
+
+		r, s, err = SignWithOptions(rand.Reader, sk, tt.digest, reduce)
+		if err != nil {
+			t.Fatalf("SignWithOptions error: %s", err)
+		}
+		if err := VerifyWithOptions(&sk.PublicKey, tt.digest, r, s, reduce); err != nil {
+			t.Errorf("%v, reduce: VerifyWithOptions error: %s", tt.hash, err)
+		}
+		e := new(big.Int).SetBytes(tt.digest)
+		if !verifyInteger(&sk.PublicKey, e.Mod(e, N), r, s) {
+			t.Errorf("%v, reduce: signature is not of the reduced digest", tt.hash)
+		}
+		// The modes only differ for digests longer than the order.
+		agree := len(tt.digest)*8 <= N.BitLen()
+		if err := VerifyWithOptions(&sk.PublicKey, tt.digest, r, s, truncate); (err == nil) != agree {
+			t.Errorf("%v: modes agree = %v, want %v", tt.hash, err == nil, agree)
+		}
+
+		if err := VerifyWithOptions(&sk.PublicKey, tt.digest, r, s, &SignerOpts{Hash: crypto.SHA384, HashMode: HashReduce}); !errors.Is(err, ErrInvalidDigest) {
+			t.Errorf("VerifyWithOptions with another hash: got %v, want ErrInvalidDigest", err)
+		}
+	}
+}
+
+func TestSignerOpts(t *testing.T) {
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	digest := sha256.Sum256([]byte("testing"))
+
+	for _, opts := range []*SignerOpts{
+		nil,
+		{},
+		{Hash: crypto.SHA512},
+		{Hash: crypto.Hash(200)},
+		{Hash: crypto.SHA256, HashMode: HashMode(7)},
+		{Hash: crypto.SHA512, HashMode: HashRejectOversized},
+	} {
+		if _, _, err := SignWithOptions(rand.Reader, sk, digest[:], opts); !errors.Is(err, ErrInvalidDigest) {
+			t.Errorf("SignWithOptions(%+v): got %v, want ErrInvalidDigest", opts, err)
+		}
+	}
+
+	sig, err := sk.Sign(rand.Reader, digest[:], &SignerOpts{Hash: crypto.SHA256})
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !VerifyASN1(&sk.PublicKey, digest[:], sig) {
+		t.Errorf("VerifyASN1 failed")
+	}
+	if _, err := sk.Sign(rand.Reader, []byte("testing"), &SignerOpts{Hash: crypto.SHA256}); !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("Sign with an undeclared digest: got %v, want ErrInvalidDigest", err)
+	}
+}
+
+// hashModeTestVectorsOutEnvironmentKey names the file TestHashModeVectors
+// writes new vectors to, instead of checking testdata/hashmodes.json:
+//
+//	ECDSA_HASH_MODE_TEST_VECTORS_OUT=testdata/hashmodes.json go test -run TestHashModeVectors ./ecdsa
+const hashModeTestVectorsOutEnvironmentKey = "ECDSA_HASH_MODE_TEST_VECTORS_OUT"
+
+// hashModeVector is a signature of a digest in every HashMode. The integer E
+// that a mode signs is given reduced modulo the curve order, and a mode that
+// refuses the digest has Rejected set and no signature.
+type hashModeVector struct {
+	Curve      string              `json:"curve"`
+	Hash       string              `json:"hash"`
+	Qx         string              `json:"qx"`
+	Qy         string              `json:"qy"`
+	Msg        string              `json:"msg"`
+	Digest     string              `json:"digest"`
+	Signatures []hashModeSignature `json:"signatures"`
+}
+
+type hashModeSignature struct {
+	Mode     string `json:"mode"`
+	E        string `json:"e,omitempty"`
+	R        string `json:"r,omitempty"`
+	S        string `json:"s,omitempty"`
+	Rejected bool   `json:"rejected,omitempty"`
+}
+
+var hashModes = []HashMode{HashTruncate, HashReduce, HashRejectOversized}
+
+func generateHashModeVectors(t *testing.T) []hashModeVector {
+	var vectors []hashModeVector
+	for _, tt := range []struct {
+		curve elliptic.Curve
+		hash  crypto.Hash
+	}{
+		{elliptic.P224(), crypto.SHA256},
+		{elliptic.P256(), crypto.SHA256},
+		{elliptic.P256(), crypto.SHA384},
+		{elliptic.P256(), crypto.SHA512},
+		{elliptic.P384(), crypto.SHA512},
+		{elliptic.P521(), crypto.SHA512},
+		{brainpool.P256r1(), crypto.SHA512},
+	} {
+		sk, err := GenerateKey(tt.curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := []byte("hash mode test vector")
+		h := tt.hash.New()
+		h.Write(msg)
+		digest := h.Sum(nil)
+		size := scalarSize(tt.curve)
+		v := hashModeVector{
+			Curve:  tt.curve.Params().Name,
+			Hash:   tt.hash.String(),
+			Qx:     hex.EncodeToString(sk.X.FillBytes(make([]byte, coordinateSize(tt.curve)))),
+			Qy:     hex.EncodeToString(sk.Y.FillBytes(make([]byte, coordinateSize(tt.curve)))),
+			Msg:    hex.EncodeToString(msg),
+			Digest: hex.EncodeToString(digest),
+		}
+		for _, mode := range hashModes {
+			sig := hashModeSignature{Mode: mode.String()}
+			opts := &SignerOpts{Hash: tt.hash, HashMode: mode}
+			r, s, err := SignWithOptions(rand.Reader, sk, digest, opts)
+			if errors.Is(err, ErrInvalidDigest) {
+				sig.Rejected = true
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				d, _ := opts.digest(tt.curve, digest)
+				e := hashToInt(d, tt.curve)
+				e.Mod(e, tt.curve.Params().N)
+				sig.E = hex.EncodeToString(e.FillBytes(make([]byte, size)))
+				sig.R = hex.EncodeToString(r.FillBytes(make([]byte, size)))
+				sig.S = hex.EncodeToString(s.FillBytes(make([]byte, size)))
+			}
+			v.Signatures = append(v.Signatures, sig)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors
+}
+
+func decodeHexInt(t *testing.T, s string) *big.Int {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+func TestHashModeVectors(t *testing.T) {
+	var vectors []hashModeVector
+	if outputFile := os.Getenv(hashModeTestVectorsOutEnvironmentKey); outputFile != "" {
+		vectors = generateHashModeVectors(t)
+		encoded, err := json.MarshalIndent(vectors, "", "  ")
+		if err != nil {
+			t.Fatalf("Error producing test vectors: %v", err)
+		}
+		if err := os.WriteFile(outputFile, append(encoded, '\n'), 0644); err != nil {
+			t.Fatalf("Error writing test vectors: %v", err)
+		}
+	} else {
+		data, err := os.ReadFile("testdata/hashmodes.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &vectors); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, v := range vectors {
+		c, err := CurveByName(v.Curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fipsCheckCurve(c) != nil {
+			continue
+		}
+		hash := wycheproofHashes[v.Hash]
+		pub := &PublicKey{Curve: c, X: decodeHexInt(t, v.Qx), Y: decodeHexInt(t, v.Qy)}
+		digest, _ := hex.DecodeString(v.Digest)
+		oversized := len(digest)*8 > c.Params().N.BitLen()
+		if len(v.Signatures) != len(hashModes) {
+			t.Fatalf("%s, %s: %d signatures, want %d", v.Curve, v.Hash, len(v.Signatures), len(hashModes))
+		}
+		for i, sig := range v.Signatures {
+			mode := hashModes[i]
+			if sig.Mode != mode.String() {
+				t.Fatalf("%s, %s: signature %d is for mode %q, want %q", v.Curve, v.Hash, i, sig.Mode, mode)
+			}
+			opts := &SignerOpts{Hash: hash, HashMode: mode}
+			if want := mode == HashRejectOversized && oversized; sig.Rejected != want {
+				t.Errorf("%s, %s, %s: rejected = %v, want %v", v.Curve, v.Hash, mode, sig.Rejected, want)
+			}
+			if sig.Rejected {
+				if err := VerifyWithOptions(pub, digest, big.NewInt(1), big.NewInt(1), opts); !errors.Is(err, ErrInvalidDigest) {
+					t.Errorf("%s, %s, %s: got %v, want ErrInvalidDigest", v.Curve, v.Hash, mode, err)
+				}
+				continue
+			}
+			e, r, s := decodeHexInt(t, sig.E), decodeHexInt(t, sig.R), decodeHexInt(t, sig.S)
+			if err := VerifyWithOptions(pub, digest, r, s, opts); err != nil {
+				t.Errorf("%s, %s, %s: VerifyWithOptions error: %s", v.Curve, v.Hash, mode, err)
+			}
+			if !verifyInteger(pub, e, r, s) {
+				t.Errorf("%s, %s, %s: signature is not of e", v.Curve, v.Hash, mode)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const ownershipDST = "ECDSA Key Blind Ownership Proof"
+
+// OwnershipProof is a non-interactive zero-knowledge proof that a blinded
+// public key pkR was derived by a party that knows both a private key skS and
+// the blind b that turned its public key into pkR, that is, that
+//
+//	B = b * G and pkR = skS * B
+//
+// for the published point B and the base point G. The unblinded public key
+// skS * G is not revealed: linking pkR to it from B is as hard as the
+// decisional Diffie-Hellman problem on the curve. B is specific to the blind
+// and context, and reveals nothing about skB beyond what pkR does.
+//
+// A proof doesn't show that pkR was blinded from any particular key; use an
+// Attestation for relying parties that know the unblinded key.
+type OwnershipProof struct {
+	// B is the blind b times the base point.
+	B *Point
+	// C is the Fiat-Shamir challenge, and Zb and Zs are the responses for b
+	// and skS.
+	C, Zb, Zs *Scalar
+}
+
+// ownershipChallenge hashes the statement and the commitments of a proof to a
+// scalar, with hash_to_field, the parameters c uses for blinds and the domain
+// separation tag dst:
+//
+//	len(pkR) || pkR || len(B) || B || len(T1) || T1 || len(T2) || T2 ||
+//	len(context) || context [|| len(bound) || bound ...]
+//
+// where points are compressed and lengths are 2-byte big-endian integers.
+// The optional bound values extend the statement the proof is bound to.
+func ownershipChallenge(dst string, pkR, B, t1, t2 *Point, context []byte, bound ...[]byte) (*Scalar, error) {
+	var b cryptobyte.Builder
+	fields := [][]byte{pkR.BytesCompressed(), B.BytesCompressed(), t1.BytesCompressed(), t2.BytesCompressed(), context}
+	for _, v := range append(fields, bound...) {
+		v := v
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(v)
+		})
+	}
+	transcript, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidProof, "context too long")
+	}
+	return hashToScalar(pkR.c, transcript, []byte(dst))
+}
+
+// ProveOwnership proves, using entropy from rand, that pkR is the public key
+// of skS blinded by skB and context, without revealing the public key of
+// skS. It returns an error wrapping ErrInvalidProof if pkR is not that key.
+func ProveOwnership(rand io.Reader, skS *PrivateKey, pkR *PublicKey, skB *PrivateKey, context []byte) (*OwnershipProof, error) {
+	return proveOwnership(rand, ownershipDST, skS, pkR, skB, context)
+}
+
+// proveOwnership is ProveOwnership with the domain separation tag dst, for a
+// statement extended with bound.
+func proveOwnership(rand io.Reader, dst string, skS *PrivateKey, pkR *PublicKey, skB *PrivateKey, context []byte, bound ...[]byte) (*OwnershipProof, error) {
+	c := skS.Curve
+	s, err := skS.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	blinded, err := blindPublicKey(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	if !blinded.Equal(pkR) {
+		return nil, wrapError(ErrInvalidProof, "blinded key is not derived from the private key and blind")
+	}
+	pR, err := pkR.Point()
+	if err != nil {
+		return nil, err
+	}
+	blind, err := hashBlind(c, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	b := &Scalar{c: c, v: blind}
+
+	kb, err := randFieldElement(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := randFieldElement(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	nb, ns := &Scalar{c: c, v: kb}, &Scalar{c: c, v: ks}
+
+	B := NewIdentityPoint(c).ScalarBaseMult(b)
+	t1 := NewIdentityPoint(c).ScalarBaseMult(nb)
+	t2 := NewIdentityPoint(c).ScalarMult(ns, B)
+	ch, err := ownershipChallenge(dst, pR, B, t1, t2, context, bound...)
+	if err != nil {
+		return nil, err
+	}
+	return &OwnershipProof{
+		B:  B,
+		C:  ch,
+		Zb: NewScalar(c).Subtract(nb, NewScalar(c).Multiply(ch, b)),
+		Zs: NewScalar(c).Subtract(ns, NewScalar(c).Multiply(ch, s)),
+	}, nil
+}
+
+// VerifyOwnership checks proof, returned by ProveOwnership for pkR and
+// context. It returns an error wrapping ErrInvalidProof if the proof doesn't
+// verify.
+func VerifyOwnership(pkR *PublicKey, context []byte, proof *OwnershipProof) error {
+	return verifyOwnership(ownershipDST, pkR, context, proof)
+}
+
+// verifyOwnership is VerifyOwnership with the domain separation tag dst, for
+// a statement extended with bound.
+func verifyOwnership(dst string, pkR *PublicKey, context []byte, proof *OwnershipProof, bound ...[]byte) error {
+	pR, err := pkR.Point()
+	if err != nil {
+		return err
+	}
+	c := pkR.Curve
+	if proof == nil || proof.B == nil || proof.C == nil || proof.Zb == nil || proof.Zs == nil {
+		return wrapError(ErrInvalidProof, "incomplete proof")
+	}
+	if proof.B.c != c || proof.C.c != c || proof.Zb.c != c || proof.Zs.c != c {
+		return wrapError(ErrCurveMismatch, "proof is not on %s", curveName(c))
+	}
+	if proof.B.IsIdentity() == 1 {
+		return wrapError(ErrInvalidProof, "blind point is the point at infinity")
+	}
+
+	// T1 = Zb * G + C * B and T2 = Zs * B + C * pkR.
+	t1 := NewIdentityPoint(c).ScalarBaseMult(proof.Zb)
+	t1.Add(t1, NewIdentityPoint(c).ScalarMult(proof.C, proof.B))
+	t2 := NewIdentityPoint(c).ScalarMult(proof.Zs, proof.B)
+	t2.Add(t2, NewIdentityPoint(c).ScalarMult(proof.C, pR))
+	ch, err := ownershipChallenge(dst, pR, proof.B, t1, t2, context, bound...)
+	if err != nil {
+		return err
+	}
+	if ch.Equal(proof.C) != 1 {
+		return wrapError(ErrInvalidProof, "challenge mismatch")
+	}
+	return nil
+}
+
+// Marshal encodes p as the compressed encoding of B followed by the
+// encodings of C, Zb and Zs.
+func (p *OwnershipProof) Marshal() []byte {
+	out := p.B.BytesCompressed()
+	out = append(out, p.C.Bytes()...)
+	out = append(out, p.Zb.Bytes()...)
+	return append(out, p.Zs.Bytes()...)
+}
+
+// UnmarshalOwnershipProof decodes a proof for a key on the curve c encoded by
+// OwnershipProof.Marshal.
+func UnmarshalOwnershipProof(c elliptic.Curve, data []byte) (*OwnershipProof, error) {
+	pointSize, size := 1+coordinateSize(c), scalarSize(c)
+	if len(data) != pointSize+3*size {
+		return nil, wrapError(ErrInvalidProof, "proof of %d bytes, want %d", len(data), pointSize+3*size)
+	}
+	B, err := NewPoint(c, data[:pointSize])
+	if err != nil {
+		return nil, err
+	}
+	p := &OwnershipProof{B: B}
+	data = data[pointSize:]
+	for _, s := range []**Scalar{&p.C, &p.Zb, &p.Zs} {
+		if *s, err = NewScalar(c).SetBytes(data[:size]); err != nil {
+			return nil, err
+		}
+		data = data[size:]
+	}
+	return p, nil
+}
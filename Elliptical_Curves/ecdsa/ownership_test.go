@@ -0,0 +1,82 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestOwnershipProof(t *testing.T) {
+	testAllCurves(t, testOwnershipProof)
+}
+
+func testOwnershipProof(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+
+	proof, err := ProveOwnership(rand.Reader, skS, pkR, skB, context)
+	if err != nil {
+		t.Fatalf("ProveOwnership error: %s", err)
+	}
+	if err := VerifyOwnership(pkR, context, proof); err != nil {
+		t.Errorf("VerifyOwnership error: %s", err)
+	}
+
+	enc := proof.Marshal()
+	decoded, err := UnmarshalOwnershipProof(c, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalOwnershipProof error: %s", err)
+	}
+	if err := VerifyOwnership(pkR, context, decoded); err != nil {
+		t.Errorf("VerifyOwnership of decoded proof error: %s", err)
+	}
+
+	pkS, _ := skS.PublicKey.Point()
+	if proof.B.Equal(pkS) == 1 {
+		t.Errorf("proof reveals the unblinded public key")
+	}
+
+	if err := VerifyOwnership(pkR, []byte("other"), proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyOwnership with another context: got %v, want ErrInvalidProof", err)
+	}
+	if err := VerifyOwnership(&skS.PublicKey, context, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyOwnership with another key: got %v, want ErrInvalidProof", err)
+	}
+	tampered := *proof
+	tampered.B = NewGeneratorPoint(c)
+	if err := VerifyOwnership(pkR, context, &tampered); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyOwnership with another blind point: got %v, want ErrInvalidProof", err)
+	}
+	tampered = *proof
+	tampered.B = NewIdentityPoint(c)
+	if err := VerifyOwnership(pkR, context, &tampered); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyOwnership with the point at infinity: got %v, want ErrInvalidProof", err)
+	}
+	tampered = *proof
+	tampered.Zs = proof.Zb
+	if err := VerifyOwnership(pkR, context, &tampered); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyOwnership with swapped responses: got %v, want ErrInvalidProof", err)
+	}
+
+	if _, err := ProveOwnership(rand.Reader, skS, &skB.PublicKey, skB, context); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("ProveOwnership for an unrelated key: got %v, want ErrInvalidProof", err)
+	}
+	if _, err := UnmarshalOwnershipProof(c, enc[1:]); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("UnmarshalOwnershipProof of a truncated proof: got %v, want ErrInvalidProof", err)
+	}
+}
+
+func TestOwnershipProofEntropy(t *testing.T) {
+	skS, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	skB, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	pkR, _ := BlindPublicKey(elliptic.P256(), &skS.PublicKey, skB)
+	if _, err := ProveOwnership(failingReader{}, skS, pkR, skB, nil); !errors.Is(err, ErrEntropy) {
+		t.Errorf("ProveOwnership with a failing reader: got %v, want ErrEntropy", err)
+	}
+}
@@ -0,0 +1,150 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	// passwordBlindVersion is the version of the PasswordBlind encoding and
+	// derivation.
+	passwordBlindVersion = 1
+
+	// passwordBlindLabel is appended to the Argon2id output before it is
+	// passed to GenerateKeyFromSeed, so that the blind is unrelated to other
+	// keys derived from the same password and salt.
+	passwordBlindLabel = "ECDSA Password Blind"
+
+	// MinSaltSize is the minimum length, in bytes, of the salt of a
+	// PasswordBlind.
+	MinSaltSize = 16
+
+	// maxPasswordMemory is the largest Argon2id memory parameter, in KiB,
+	// accepted when decoding a PasswordBlind, so that an untrusted encoding
+	// can't make DeriveBlindFromPassword allocate more than 4 GiB.
+	maxPasswordMemory = 4 << 20
+)
+
+// PasswordParams are the Argon2id parameters of RFC 9106 used to derive a
+// blind from a password: the number of passes, the memory size in KiB, and
+// the degree of parallelism.
+type PasswordParams struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// DefaultPasswordParams are the second recommended option of RFC 9106,
+// section 4: 3 passes over 64 MiB with 4 lanes, for environments where the
+// first, 2 GiB of memory, is too much.
+var DefaultPasswordParams = PasswordParams{Time: 3, Memory: 64 << 10, Threads: 4}
+
+// check returns an error wrapping ErrInvalidSeed if p is not valid for
+// Argon2id or exceeds the limits of DeriveBlindFromPassword.
+func (p PasswordParams) check() error {
+	if p.Time == 0 || p.Threads == 0 || p.Memory < 8*uint32(p.Threads) || p.Memory > maxPasswordMemory {
+		return wrapError(ErrInvalidSeed, "invalid Argon2id parameters t=%d m=%d p=%d", p.Time, p.Memory, p.Threads)
+	}
+	return nil
+}
+
+// PasswordBlind holds the public parameters needed to derive a blind from a
+// password again: the Argon2id parameters and a random salt. It is stored
+// next to the identity it blinds, or published, so that the identity can be
+// recovered from the password alone on another device. It reveals nothing
+// about the password beyond allowing offline guesses, which the parameters
+// make expensive.
+type PasswordBlind struct {
+	Params PasswordParams
+	Salt   []byte
+}
+
+// NewPasswordBlind returns a PasswordBlind with the parameters params and a
+// fresh 16-byte salt read from rand.
+func NewPasswordBlind(rand io.Reader, params PasswordParams) (*PasswordBlind, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+	salt := make([]byte, MinSaltSize)
+	if _, err := io.ReadFull(entropySource(rand), salt); err != nil {
+		return nil, entropyError(err)
+	}
+	return &PasswordBlind{Params: params, Salt: salt}, nil
+}
+
+// DeriveBlindFromPassword derives a blind on the curve c from password with
+// the parameters and salt of pb. The same password, curve and PasswordBlind
+// always give the same blind. The seed of the blind is
+//
+//	Argon2id(password, salt, t, m, p, 64) || "ECDSA Password Blind"
+//
+// passed to GenerateKeyFromSeed.
+func DeriveBlindFromPassword(c elliptic.Curve, password []byte, pb *PasswordBlind) (*PrivateKey, error) {
+	if pb == nil {
+		return nil, wrapError(ErrInvalidSeed, "missing password blind parameters")
+	}
+	if err := pb.Params.check(); err != nil {
+		return nil, err
+	}
+	if len(pb.Salt) < MinSaltSize {
+		return nil, wrapError(ErrInvalidSeed, "salt of %d bytes is shorter than %d", len(pb.Salt), MinSaltSize)
+	}
+	if len(password) == 0 {
+		return nil, wrapError(ErrInvalidSeed, "empty password")
+	}
+	seed := argon2.IDKey(password, pb.Salt, pb.Params.Time, pb.Params.Memory, pb.Params.Threads, 64)
+	return GenerateKeyFromSeed(c, append(seed, passwordBlindLabel...))
+}
+
+// Marshal encodes pb as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  uint32 time;
+//	  uint32 memory;
+//	  uint8 threads;
+//	  opaque salt<16..2^8-1>;
+//	} PasswordBlind;
+func (pb *PasswordBlind) Marshal() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint8(passwordBlindVersion)
+	b.AddUint32(pb.Params.Time)
+	b.AddUint32(pb.Params.Memory)
+	b.AddUint8(pb.Params.Threads)
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(pb.Salt)
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidSeed, "salt too long")
+	}
+	return out, nil
+}
+
+// UnmarshalPasswordBlind decodes a PasswordBlind encoded by
+// PasswordBlind.Marshal. It rejects unknown versions and parameters that
+// DeriveBlindFromPassword would refuse.
+func UnmarshalPasswordBlind(data []byte) (*PasswordBlind, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var salt cryptobyte.String
+	pb := &PasswordBlind{}
+	if !in.ReadUint8(&version) || !in.ReadUint32(&pb.Params.Time) || !in.ReadUint32(&pb.Params.Memory) ||
+		!in.ReadUint8(&pb.Params.Threads) || !in.ReadUint8LengthPrefixed(&salt) || !in.Empty() {
+		return nil, wrapError(ErrInvalidSeed, "malformed password blind parameters")
+	}
+	if version != passwordBlindVersion {
+		return nil, wrapError(ErrInvalidSeed, "unknown password blind version %d", version)
+	}
+	if err := pb.Params.check(); err != nil {
+		return nil, err
+	}
+	if len(salt) < MinSaltSize {
+		return nil, wrapError(ErrInvalidSeed, "salt of %d bytes is shorter than %d", len(salt), MinSaltSize)
+	}
+	pb.Salt = append([]byte{}, salt...)
+	return pb, nil
+}
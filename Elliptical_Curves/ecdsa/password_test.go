@@ -0,0 +1,95 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// testPasswordParams are cheap Argon2id parameters for tests.
+var testPasswordParams = PasswordParams{Time: 1, Memory: 64, Threads: 1}
+
+func TestDeriveBlindFromPassword(t *testing.T) {
+	testAllCurves(t, testDeriveBlindFromPassword)
+}
+
+func testDeriveBlindFromPassword(t *testing.T, c elliptic.Curve) {
+	pb, err := NewPasswordBlind(rand.Reader, testPasswordParams)
+	if err != nil {
+		t.Fatalf("NewPasswordBlind error: %s", err)
+	}
+	password := []byte("correct horse battery staple")
+	skB, err := DeriveBlindFromPassword(c, password, pb)
+	if err != nil {
+		t.Fatalf("DeriveBlindFromPassword error: %s", err)
+	}
+
+	// The blind is recovered from the password and the encoded parameters.
+	enc, err := pb.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decoded, err := UnmarshalPasswordBlind(enc)
+	if err != nil {
+		t.Fatalf("UnmarshalPasswordBlind error: %s", err)
+	}
+	if decoded.Params != pb.Params || !bytes.Equal(decoded.Salt, pb.Salt) {
+		t.Errorf("UnmarshalPasswordBlind returned %+v, want %+v", decoded, pb)
+	}
+	again, err := DeriveBlindFromPassword(c, password, decoded)
+	if err != nil {
+		t.Fatalf("DeriveBlindFromPassword error: %s", err)
+	}
+	if !again.Equal(skB) {
+		t.Errorf("DeriveBlindFromPassword is not deterministic")
+	}
+
+	other, _ := DeriveBlindFromPassword(c, []byte("Tr0ub4dor&3"), pb)
+	if other.Equal(skB) {
+		t.Errorf("different passwords derived the same blind")
+	}
+	salted, _ := NewPasswordBlind(rand.Reader, testPasswordParams)
+	if resalted, _ := DeriveBlindFromPassword(c, password, salted); resalted.Equal(skB) {
+		t.Errorf("different salts derived the same blind")
+	}
+	if seeded, _ := GenerateKeyFromSeed(c, append([]byte{}, password...)); seeded != nil && seeded.Equal(skB) {
+		t.Errorf("password blind equals a key generated from the password")
+	}
+}
+
+func TestPasswordBlindErrors(t *testing.T) {
+	c := elliptic.P256()
+	for _, params := range []PasswordParams{
+		{Time: 0, Memory: 64, Threads: 1},
+		{Time: 1, Memory: 64, Threads: 0},
+		{Time: 1, Memory: 7, Threads: 1},
+		{Time: 1, Memory: maxPasswordMemory + 1, Threads: 1},
+	} {
+		if _, err := NewPasswordBlind(rand.Reader, params); !errors.Is(err, ErrInvalidSeed) {
+			t.Errorf("NewPasswordBlind(%+v): got %v, want ErrInvalidSeed", params, err)
+		}
+	}
+	if _, err := NewPasswordBlind(failingReader{}, testPasswordParams); !errors.Is(err, ErrEntropy) {
+		t.Errorf("NewPasswordBlind with a failing reader: got %v, want ErrEntropy", err)
+	}
+
+	pb, _ := NewPasswordBlind(rand.Reader, testPasswordParams)
+	if _, err := DeriveBlindFromPassword(c, nil, pb); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("DeriveBlindFromPassword of an empty password: got %v, want ErrInvalidSeed", err)
+	}
+	short := &PasswordBlind{Params: testPasswordParams, Salt: []byte("salt")}
+	if _, err := DeriveBlindFromPassword(c, []byte("password"), short); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("DeriveBlindFromPassword with a short salt: got %v, want ErrInvalidSeed", err)
+	}
+
+	enc, _ := pb.Marshal()
+	enc[0] = 2
+	if _, err := UnmarshalPasswordBlind(enc); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("UnmarshalPasswordBlind of an unknown version: got %v, want ErrInvalidSeed", err)
+	}
+	if _, err := UnmarshalPasswordBlind(enc[:5]); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("UnmarshalPasswordBlind of a truncated encoding: got %v, want ErrInvalidSeed", err)
+	}
+}
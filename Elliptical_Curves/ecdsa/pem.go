@@ -0,0 +1,258 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	encasn1 "encoding/asn1"
+	"encoding/pem"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Object identifiers of EC keys and of the named curves of RFC 5480 and
+// RFC 5639.
+var (
+	oidPublicKeyEC = encasn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+	curveOIDs = map[string]encasn1.ObjectIdentifier{
+		"P-224":           {1, 3, 132, 0, 33},
+		"P-256":           {1, 2, 840, 10045, 3, 1, 7},
+		"P-384":           {1, 3, 132, 0, 34},
+		"P-521":           {1, 3, 132, 0, 35},
+		"brainpoolP256r1": {1, 3, 36, 3, 3, 2, 8, 1, 1, 7},
+		"brainpoolP384r1": {1, 3, 36, 3, 3, 2, 8, 1, 1, 11},
+		"brainpoolP512r1": {1, 3, 36, 3, 3, 2, 8, 1, 1, 13},
+	}
+)
+
+func curveOID(c elliptic.Curve) (encasn1.ObjectIdentifier, error) {
+	oid, ok := curveOIDs[c.Params().Name]
+	if !ok {
+		return nil, wrapError(ErrInvalidCurve, "no object identifier for %s", c.Params().Name)
+	}
+	return oid, nil
+}
+
+func curveFromOID(oid encasn1.ObjectIdentifier) (elliptic.Curve, error) {
+	for name, o := range curveOIDs {
+		if o.Equal(oid) {
+			return CurveByName(name)
+		}
+	}
+	return nil, wrapError(ErrInvalidCurve, "unknown curve %s", oid)
+}
+
+// addAlgorithmIdentifier adds the AlgorithmIdentifier of EC keys on the
+// named curve oid.
+func addAlgorithmIdentifier(b *cryptobyte.Builder, oid encasn1.ObjectIdentifier) {
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oidPublicKeyEC)
+		b.AddASN1ObjectIdentifier(oid)
+	})
+}
+
+// readAlgorithmIdentifier reads the AlgorithmIdentifier of an EC key and
+// returns its curve.
+func readAlgorithmIdentifier(s *cryptobyte.String) (elliptic.Curve, error) {
+	var algo cryptobyte.String
+	var alg, oid encasn1.ObjectIdentifier
+	if !s.ReadASN1(&algo, asn1.SEQUENCE) || !algo.ReadASN1ObjectIdentifier(&alg) {
+		return nil, wrapError(ErrInvalidCurve, "malformed algorithm identifier")
+	}
+	if !alg.Equal(oidPublicKeyEC) {
+		return nil, wrapError(ErrInvalidCurve, "not an EC key: algorithm %s", alg)
+	}
+	if !algo.ReadASN1ObjectIdentifier(&oid) || !algo.Empty() {
+		return nil, wrapError(ErrInvalidCurve, "EC key without a named curve")
+	}
+	return curveFromOID(oid)
+}
+
+// MarshalPublicKeyPEM encodes pub as a PEM "PUBLIC KEY" block holding its
+// SubjectPublicKeyInfo, as written by openssl pkey -pubout. Blinded keys are
+// encoded like any other key.
+func MarshalPublicKeyPEM(pub *PublicKey) ([]byte, error) {
+	oid, err := curveOID(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		addAlgorithmIdentifier(b, oid)
+		b.AddASN1BitString(elliptic.Marshal(pub.Curve, pub.X, pub.Y))
+	})
+	der, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM decodes the first PEM "PUBLIC KEY" block of data, and
+// validates the key with ValidatePublicKey. It supports the curves with an
+// object identifier: P-224, P-256, P-384, P-521 and the Brainpool curves.
+func ParsePublicKeyPEM(data []byte) (*PublicKey, error) {
+	block := findPEMBlock(data, "PUBLIC KEY")
+	if block == nil {
+		return nil, wrapError(ErrPointNotOnCurve, "no PUBLIC KEY PEM block")
+	}
+	s := cryptobyte.String(block.Bytes)
+	var spki cryptobyte.String
+	if !s.ReadASN1(&spki, asn1.SEQUENCE) || !s.Empty() {
+		return nil, wrapError(ErrPointNotOnCurve, "malformed SubjectPublicKeyInfo")
+	}
+	c, err := readAlgorithmIdentifier(&spki)
+	if err != nil {
+		return nil, err
+	}
+	var point encasn1.BitString
+	if !spki.ReadASN1BitString(&point) || point.BitLength%8 != 0 || !spki.Empty() {
+		return nil, wrapError(ErrPointNotOnCurve, "malformed SubjectPublicKeyInfo")
+	}
+	return parsePublicPoint(c, point.Bytes)
+}
+
+func parsePublicPoint(c elliptic.Curve, enc []byte) (*PublicKey, error) {
+	p, err := NewPoint(c, enc)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := NewPublicKey(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidatePublicKey(c, pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// MarshalPrivateKeyPEM encodes priv as a PEM "PRIVATE KEY" block holding its
+// PKCS #8 encoding, as written by openssl genpkey.
+func MarshalPrivateKeyPEM(priv *PrivateKey) ([]byte, error) {
+	oid, err := curveOID(priv.Curve)
+	if err != nil {
+		return nil, err
+	}
+	c := priv.Curve
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(0)
+		addAlgorithmIdentifier(b, oid)
+		b.AddASN1(asn1.OCTET_STRING, func(b *cryptobyte.Builder) {
+			b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1Int64(1)
+				b.AddASN1OctetString(priv.D.FillBytes(make([]byte, scalarSize(c))))
+				b.AddASN1(asn1.Tag(1).ContextSpecific().Constructed(), func(b *cryptobyte.Builder) {
+					b.AddASN1BitString(elliptic.Marshal(c, priv.X, priv.Y))
+				})
+			})
+		})
+	})
+	der, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM decodes the first PEM "PRIVATE KEY" block of data, in
+// the PKCS #8 encoding written by openssl genpkey, or the first
+// "EC PRIVATE KEY" block, in the SEC 1 encoding written by openssl ecparam
+// -genkey, skipping other blocks such as "EC PARAMETERS". It checks that the
+// public key of the encoding, if any, matches the private key.
+func ParsePrivateKeyPEM(data []byte) (*PrivateKey, error) {
+	if block := findPEMBlock(data, "PRIVATE KEY"); block != nil {
+		s := cryptobyte.String(block.Bytes)
+		var pkcs8, inner cryptobyte.String
+		var version int64
+		if !s.ReadASN1(&pkcs8, asn1.SEQUENCE) || !s.Empty() ||
+			!pkcs8.ReadASN1Integer(&version) || version != 0 {
+			return nil, wrapError(ErrInvalidScalar, "malformed PKCS #8 private key")
+		}
+		c, err := readAlgorithmIdentifier(&pkcs8)
+		if err != nil {
+			return nil, err
+		}
+		if !pkcs8.ReadASN1(&inner, asn1.OCTET_STRING) {
+			return nil, wrapError(ErrInvalidScalar, "malformed PKCS #8 private key")
+		}
+		return parseECPrivateKey(c, inner)
+	}
+	if block := findPEMBlock(data, "EC PRIVATE KEY"); block != nil {
+		return parseECPrivateKey(nil, block.Bytes)
+	}
+	return nil, wrapError(ErrInvalidScalar, "no PRIVATE KEY or EC PRIVATE KEY PEM block")
+}
+
+// parseECPrivateKey decodes an ECPrivateKey of RFC 5915 on the curve c, or
+// on the curve of its parameters if c is nil.
+func parseECPrivateKey(c elliptic.Curve, der []byte) (*PrivateKey, error) {
+	s := cryptobyte.String(der)
+	var key, d, params, pubBits cryptobyte.String
+	var version int64
+	var hasParams, hasPub bool
+	if !s.ReadASN1(&key, asn1.SEQUENCE) || !s.Empty() ||
+		!key.ReadASN1Integer(&version) || version != 1 ||
+		!key.ReadASN1(&d, asn1.OCTET_STRING) ||
+		!key.ReadOptionalASN1(&params, &hasParams, asn1.Tag(0).ContextSpecific().Constructed()) ||
+		!key.ReadOptionalASN1(&pubBits, &hasPub, asn1.Tag(1).ContextSpecific().Constructed()) ||
+		!key.Empty() {
+		return nil, wrapError(ErrInvalidScalar, "malformed EC private key")
+	}
+	if hasParams {
+		var oid encasn1.ObjectIdentifier
+		if !params.ReadASN1ObjectIdentifier(&oid) || !params.Empty() {
+			return nil, wrapError(ErrInvalidCurve, "EC private key without a named curve")
+		}
+		pc, err := curveFromOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil && pc != c {
+			return nil, wrapError(ErrCurveMismatch, "EC private key parameters disagree with its algorithm")
+		}
+		c = pc
+	}
+	if c == nil {
+		return nil, wrapError(ErrInvalidCurve, "EC private key without a curve")
+	}
+	size := scalarSize(c)
+	if len(d) > size {
+		return nil, wrapError(ErrInvalidScalar, "private key of %d bytes", len(d))
+	}
+	scalar, err := NewScalar(c).SetBytes(new(big.Int).SetBytes(d).FillBytes(make([]byte, size)))
+	if err != nil {
+		return nil, err
+	}
+	priv, err := NewPrivateKey(scalar)
+	if err != nil {
+		return nil, err
+	}
+	if hasPub {
+		var point encasn1.BitString
+		if !pubBits.ReadASN1BitString(&point) || point.BitLength%8 != 0 || !pubBits.Empty() {
+			return nil, wrapError(ErrPointNotOnCurve, "malformed EC public key")
+		}
+		pub, err := parsePublicPoint(c, point.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if !priv.PublicKey.Equal(pub) {
+			return nil, wrapError(ErrInvalidScalar, "private key does not match public key")
+		}
+	}
+	return priv, nil
+}
+
+// findPEMBlock returns the first PEM block of data of the given type.
+func findPEMBlock(data []byte, typ string) *pem.Block {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil || block.Type == typ {
+			return block
+		}
+	}
+}
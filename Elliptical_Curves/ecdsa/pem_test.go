@@ -0,0 +1,136 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// The keys and signatures of testdata/openssl were generated with OpenSSL
+// 3.0:
+//
+//	openssl ecparam -name prime256v1 -genkey -out p256-sec1.pem
+//	openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:P-384 -out p384-pkcs8.pem
+//	openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:brainpoolP256r1 -out bp256-pkcs8.pem
+//	openssl pkey -in p256-sec1.pem -pubout -out p256-pub.pem
+//	openssl dgst -sha256 -sign p256-sec1.pem -out p256.sig message.txt
+func readOpenSSLFile(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "openssl", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseOpenSSLKeys(t *testing.T) {
+	message := readOpenSSLFile(t, "message.txt")
+	d256, d384 := sha256.Sum256(message), sha512.Sum384(message)
+	for _, tt := range []struct {
+		key, pub, sig string
+		curve         string
+		digest        []byte
+	}{
+		{"p256-sec1.pem", "p256-pub.pem", "p256.sig", "P-256", d256[:]},
+		{"p384-pkcs8.pem", "p384-pub.pem", "p384.sig", "P-384", d384[:]},
+		{"bp256-pkcs8.pem", "bp256-pub.pem", "bp256.sig", brainpool.P256r1().Params().Name, d256[:]},
+	} {
+		priv, err := ParsePrivateKeyPEM(readOpenSSLFile(t, tt.key))
+		if err != nil {
+			t.Fatalf("%s: ParsePrivateKeyPEM error: %s", tt.key, err)
+		}
+		pub, err := ParsePublicKeyPEM(readOpenSSLFile(t, tt.pub))
+		if err != nil {
+			t.Fatalf("%s: ParsePublicKeyPEM error: %s", tt.pub, err)
+		}
+		if priv.Curve.Params().Name != tt.curve || !priv.PublicKey.Equal(pub) {
+			t.Fatalf("%s: parsed key does not match %s", tt.key, tt.pub)
+		}
+		if fipsCheckCurve(priv.Curve) != nil {
+			continue
+		}
+		if !VerifyASN1(pub, tt.digest, readOpenSSLFile(t, tt.sig)) {
+			t.Errorf("%s: OpenSSL signature does not verify", tt.sig)
+		}
+
+		// Signatures of blinded keys verify under the blinded key, and
+		// unblinding it gives back the OpenSSL key.
+		c := priv.Curve
+		skB, err := GenerateKey(c, rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey error: %s", err)
+		}
+		context := []byte("openssl")
+		pkR, err := BlindPublicKeyWithContext(c, pub, skB, context)
+		if err != nil {
+			t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+		}
+		r, s, err := BlindKeySignWithContext(rand.Reader, priv, skB, tt.digest, context)
+		if err != nil {
+			t.Fatalf("BlindKeySignWithContext error: %s", err)
+		}
+		if !Verify(pkR, tt.digest, r, s) {
+			t.Errorf("%s: blinded signature does not verify", tt.key)
+		}
+		pkS, err := UnblindPublicKeyWithContext(c, pkR, skB, context)
+		if err != nil || !pkS.Equal(pub) {
+			t.Errorf("%s: unblinded key does not match the OpenSSL key", tt.key)
+		}
+	}
+}
+
+func TestPEMRoundTrip(t *testing.T) {
+	testAllCurves(t, func(t *testing.T, c elliptic.Curve) {
+		if _, ok := curveOIDs[c.Params().Name]; !ok {
+			t.Skip("no object identifier")
+		}
+		priv, _ := GenerateKey(c, rand.Reader)
+		enc, err := MarshalPrivateKeyPEM(priv)
+		if err != nil {
+			t.Fatalf("MarshalPrivateKeyPEM error: %s", err)
+		}
+		parsed, err := ParsePrivateKeyPEM(enc)
+		if err != nil || parsed.D.Cmp(priv.D) != 0 || !parsed.PublicKey.Equal(&priv.PublicKey) {
+			t.Fatalf("ParsePrivateKeyPEM = %v, %v", parsed, err)
+		}
+		enc, err = MarshalPublicKeyPEM(&priv.PublicKey)
+		if err != nil {
+			t.Fatalf("MarshalPublicKeyPEM error: %s", err)
+		}
+		pub, err := ParsePublicKeyPEM(enc)
+		if err != nil || !pub.Equal(&priv.PublicKey) {
+			t.Fatalf("ParsePublicKeyPEM = %v, %v", pub, err)
+		}
+	})
+}
+
+func TestParsePEMErrors(t *testing.T) {
+	if _, err := ParsePublicKeyPEM([]byte("not PEM")); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("ParsePublicKeyPEM(garbage) error = %v", err)
+	}
+	if _, err := ParsePrivateKeyPEM(readOpenSSLFile(t, "p256-pub.pem")); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("ParsePrivateKeyPEM(public key) error = %v", err)
+	}
+
+	// A public key with a corrupted point is rejected.
+	pubPEM := readOpenSSLFile(t, "p256-pub.pem")
+	priv, _ := ParsePrivateKeyPEM(readOpenSSLFile(t, "p256-sec1.pem"))
+	enc, _ := MarshalPublicKeyPEM(&priv.PublicKey)
+	if !bytes.Equal(enc, pubPEM) {
+		t.Errorf("MarshalPublicKeyPEM differs from openssl pkey -pubout")
+	}
+	block := findPEMBlock(pubPEM, "PUBLIC KEY")
+	block.Bytes[len(block.Bytes)-1] ^= 1
+	if _, err := ParsePublicKeyPEM(pem.EncodeToMemory(block)); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("ParsePublicKeyPEM(corrupted point) error = %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/subtle"
+	"math/big"
+)
+
+// compressedUnmarshaler is implemented by curves, such as the Brainpool r1
+// curves, that can't use the generic elliptic.UnmarshalCompressed because
+// they don't have a = -3.
+type compressedUnmarshaler interface {
+	UnmarshalCompressed(data []byte) (x, y *big.Int)
+}
+
+// Point is a point on an elliptic curve, which is either on the curve or the
+// point at infinity.
+//
+// Unlike the X and Y fields of PublicKey, a Point can only be obtained through
+// constructors that check that it is on its curve. Arithmetic is delegated to
+// the elliptic.Curve implementation through big.Int coordinates. The curves
+// returned by crypto/elliptic multiply in constant time internally, but the
+// conversions to and from big.Int are not, and other curves, such as the
+// Brainpool curves, use variable-time arithmetic throughout. The zero value
+// is not usable; use NewPoint or NewIdentityPoint instead.
+type Point struct {
+	c    elliptic.Curve
+	x, y *big.Int
+}
+
+// NewIdentityPoint returns a new Point set to the point at infinity of c.
+func NewIdentityPoint(c elliptic.Curve) *Point {
+	return &Point{c: c, x: new(big.Int), y: new(big.Int)}
+}
+
+// NewGeneratorPoint returns a new Point set to the base point of c.
+func NewGeneratorPoint(c elliptic.Curve) *Point {
+	params := c.Params()
+	return &Point{c: c, x: new(big.Int).Set(params.Gx), y: new(big.Int).Set(params.Gy)}
+}
+
+// NewPoint decodes a SEC 1 encoded point, in compressed or uncompressed form,
+// on the curve c. It returns an error if the encoding is invalid or if the
+// point is not on the curve. The point at infinity, encoded as a single zero
+// byte, is accepted.
+func NewPoint(c elliptic.Curve, b []byte) (*Point, error) {
+	if len(b) == 1 && b[0] == 0 {
+		return NewIdentityPoint(c), nil
+	}
+	var x, y *big.Int
+	if len(b) > 0 && b[0] == 4 {
+		x, y = elliptic.Unmarshal(c, b)
+	} else if u, ok := c.(compressedUnmarshaler); ok {
+		x, y = u.UnmarshalCompressed(b)
+	} else {
+		x, y = elliptic.UnmarshalCompressed(c, b)
+	}
+	if x == nil {
+		return nil, wrapError(ErrPointNotOnCurve, "invalid point encoding")
+	}
+	return &Point{c: c, x: x, y: y}, nil
+}
+
+// Curve returns the curve p is defined on.
+func (p *Point) Curve() elliptic.Curve {
+	return p.c
+}
+
+// Set sets p = q, and returns p.
+func (p *Point) Set(q *Point) *Point {
+	p.c = q.c
+	p.x, p.y = new(big.Int).Set(q.x), new(big.Int).Set(q.y)
+	return p
+}
+
+// IsIdentity returns 1 if p is the point at infinity, and 0 otherwise.
+func (p *Point) IsIdentity() int {
+	// By convention, (0, 0) is the point at infinity in crypto/elliptic.
+	if p.x.Sign() == 0 && p.y.Sign() == 0 {
+		return 1
+	}
+	return 0
+}
+
+// Bytes returns the uncompressed SEC 1 encoding of p, or a single zero byte
+// if p is the point at infinity.
+func (p *Point) Bytes() []byte {
+	if p.IsIdentity() == 1 {
+		return []byte{0}
+	}
+	return elliptic.Marshal(p.c, p.x, p.y)
+}
+
+// BytesCompressed returns the compressed SEC 1 encoding of p, or a single
+// zero byte if p is the point at infinity.
+func (p *Point) BytesCompressed() []byte {
+	if p.IsIdentity() == 1 {
+		return []byte{0}
+	}
+	return elliptic.MarshalCompressed(p.c, p.x, p.y)
+}
+
+// Equal returns 1 if p and q are the same point on the same curve, and 0
+// otherwise.
+func (p *Point) Equal(q *Point) int {
+	if p.c != q.c {
+		return 0
+	}
+	return subtle.ConstantTimeCompare(p.Bytes(), q.Bytes())
+}
+
+// Add sets p = q + r, and returns p.
+func (p *Point) Add(q, r *Point) *Point {
+	p.c = q.c
+	p.x, p.y = q.c.Add(q.x, q.y, r.x, r.y)
+	return p
+}
+
+// Negate sets p = -q, and returns p.
+func (p *Point) Negate(q *Point) *Point {
+	P := q.c.Params().P
+	y := new(big.Int).Neg(q.y)
+	p.c, p.x, p.y = q.c, new(big.Int).Set(q.x), y.Mod(y, P)
+	return p
+}
+
+// ScalarMult sets p = s * q, and returns p.
+func (p *Point) ScalarMult(s *Scalar, q *Point) *Point {
+	p.c = q.c
+	p.x, p.y = q.c.ScalarMult(q.x, q.y, s.Bytes())
+	return p
+}
+
+// ScalarBaseMult sets p = s * G, where G is the base point of the curve of s,
+// and returns p.
+func (p *Point) ScalarBaseMult(s *Scalar) *Point {
+	p.c = s.c
+	p.x, p.y = s.c.ScalarBaseMult(s.Bytes())
+	return p
+}
+
+// NewPublicKey returns the public key corresponding to the point p, which
+// must not be the point at infinity.
+func NewPublicKey(p *Point) (*PublicKey, error) {
+	if p.IsIdentity() == 1 {
+		return nil, wrapError(ErrPointNotOnCurve, "public key is the point at infinity")
+	}
+	return &PublicKey{p.c, new(big.Int).Set(p.x), new(big.Int).Set(p.y)}, nil
+}
+
+// Point returns the point of pub. It returns an error if pub does not pass
+// ValidatePublicKey for its curve.
+func (pub *PublicKey) Point() (*Point, error) {
+	if err := ValidatePublicKey(pub.Curve, pub); err != nil {
+		return nil, err
+	}
+	return &Point{c: pub.Curve, x: new(big.Int).Set(pub.X), y: new(big.Int).Set(pub.Y)}, nil
+}
@@ -0,0 +1,59 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPoint(t *testing.T) {
+	testAllCurves(t, testPoint)
+}
+
+func testPoint(t *testing.T, c elliptic.Curve) {
+	priv, _ := GenerateKey(c, rand.Reader)
+	d, _ := priv.Scalar()
+
+	P := NewIdentityPoint(c).ScalarBaseMult(d)
+	Q, err := priv.Point()
+	if err != nil {
+		t.Fatalf("Point error: %s", err)
+	}
+	if P.Equal(Q) != 1 {
+		t.Errorf("d * G does not match the public key")
+	}
+	if R := NewIdentityPoint(c).ScalarMult(d, NewGeneratorPoint(c)); P.Equal(R) != 1 {
+		t.Errorf("ScalarMult(d, G) does not match ScalarBaseMult(d)")
+	}
+
+	for _, enc := range [][]byte{P.Bytes(), P.BytesCompressed()} {
+		R, err := NewPoint(c, enc)
+		if err != nil {
+			t.Fatalf("NewPoint error: %s", err)
+		}
+		if P.Equal(R) != 1 {
+			t.Errorf("point does not round-trip through encoding %x", enc)
+		}
+	}
+
+	sum := NewIdentityPoint(c).Add(P, NewIdentityPoint(c).Negate(P))
+	if sum.IsIdentity() != 1 {
+		t.Errorf("P + -P is not the identity")
+	}
+	if _, err := NewPublicKey(sum); err == nil {
+		t.Errorf("NewPublicKey accepted the point at infinity")
+	}
+
+	enc := P.Bytes()
+	enc[len(enc)-1] ^= 1
+	if _, err := NewPoint(c, enc); err == nil {
+		t.Errorf("NewPoint accepted a point off the curve")
+	}
+
+	pub := &PublicKey{c, priv.X, priv.Y}
+	pub.Y = new(big.Int).Add(pub.Y, big.NewInt(1))
+	if _, err := pub.Point(); err == nil {
+		t.Errorf("Point accepted a public key off the curve")
+	}
+}
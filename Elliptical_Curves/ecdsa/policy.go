@@ -0,0 +1,311 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const policyDST = "ECDSA Key Policy"
+
+// Policy constrains the use of a signing key. The zero value allows any use.
+type Policy struct {
+	// MaxSignatures is the number of signatures the key may produce. Zero
+	// means no limit.
+	MaxSignatures uint64
+	// NotAfter is the time after which the key must not sign. The zero
+	// value means the key doesn't expire. It is serialized with a precision
+	// of one second.
+	NotAfter time.Time
+	// Contexts lists the context strings the key may sign with when blinded.
+	// An empty list allows any context. Contexts don't restrict unblinded
+	// signatures.
+	Contexts [][]byte
+}
+
+func (p *Policy) allowsContext(context []byte) bool {
+	if len(p.Contexts) == 0 {
+		return true
+	}
+	for _, c := range p.Contexts {
+		if bytes.Equal(c, context) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyKey is a private key that refuses to sign outside of its Policy.
+// It is safe for concurrent use.
+type PolicyKey struct {
+	key    *PrivateKey
+	policy Policy
+	now    func() time.Time
+
+	mu         sync.Mutex
+	signatures uint64
+}
+
+// NewPolicyKey returns sk constrained by policy.
+func NewPolicyKey(sk *PrivateKey, policy Policy) *PolicyKey {
+	return newPolicyKey(sk, policy, time.Now)
+}
+
+func newPolicyKey(sk *PrivateKey, policy Policy, now func() time.Time) *PolicyKey {
+	return &PolicyKey{key: sk, policy: policy, now: now}
+}
+
+// PublicKey returns the public key of pk.
+func (pk *PolicyKey) PublicKey() *PublicKey {
+	return &pk.key.PublicKey
+}
+
+// Policy returns the policy of pk.
+func (pk *PolicyKey) Policy() Policy {
+	return pk.policy
+}
+
+// Signatures returns the number of signatures pk has produced.
+func (pk *PolicyKey) Signatures() uint64 {
+	pk.mu.Lock()
+	defer pk.mu.Unlock()
+	return pk.signatures
+}
+
+// reserve counts a signature against the policy, or returns an error if the
+// policy forbids it. release undoes reserve for a signature that failed.
+func (pk *PolicyKey) reserve(blinded bool, context []byte) error {
+	if !pk.policy.NotAfter.IsZero() && pk.now().After(pk.policy.NotAfter) {
+		return wrapError(ErrPolicy, "key expired at %s", pk.policy.NotAfter)
+	}
+	if blinded && !pk.policy.allowsContext(context) {
+		return wrapError(ErrPolicy, "context not allowed")
+	}
+	pk.mu.Lock()
+	defer pk.mu.Unlock()
+	if pk.policy.MaxSignatures != 0 && pk.signatures >= pk.policy.MaxSignatures {
+		return wrapError(ErrPolicy, "key exhausted after %d signatures", pk.signatures)
+	}
+	pk.signatures++
+	return nil
+}
+
+func (pk *PolicyKey) release() {
+	pk.mu.Lock()
+	defer pk.mu.Unlock()
+	pk.signatures--
+}
+
+// Sign is like the package-level Sign, but returns an error wrapping
+// ErrPolicy if the policy of pk forbids the signature.
+func (pk *PolicyKey) Sign(rand io.Reader, hash []byte) (r, s *big.Int, err error) {
+	if err := pk.reserve(false, nil); err != nil {
+		return nil, nil, err
+	}
+	r, s, err = Sign(rand, pk.key, hash)
+	if err != nil {
+		pk.release()
+	}
+	return r, s, err
+}
+
+// BlindKeySignWithContext is like the package-level BlindKeySignWithContext
+// with pk as the signing key, but returns an error wrapping ErrPolicy if the
+// policy of pk forbids the signature.
+func (pk *PolicyKey) BlindKeySignWithContext(rand io.Reader, skB *PrivateKey, hash []byte, context []byte) (r, s *big.Int, err error) {
+	if err := pk.reserve(true, context); err != nil {
+		return nil, nil, err
+	}
+	r, s, err = BlindKeySignWithContext(rand, pk.key, skB, hash, context)
+	if err != nil {
+		pk.release()
+	}
+	return r, s, err
+}
+
+// BlindKeySign is like BlindKeySignWithContext with an empty context string.
+func (pk *PolicyKey) BlindKeySign(rand io.Reader, skB *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	return pk.BlindKeySignWithContext(rand, skB, hash, nil)
+}
+
+// policyBody encodes the policy of the key pub, without a signature.
+func policyBody(pub *PublicKey, policy Policy) ([]byte, error) {
+	var notAfter uint64
+	if !policy.NotAfter.IsZero() {
+		if policy.NotAfter.Unix() <= 0 {
+			return nil, wrapError(ErrPolicy, "expiry time before 1970")
+		}
+		notAfter = uint64(policy.NotAfter.Unix())
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y))
+	})
+	var u64 [8]byte
+	binary.BigEndian.PutUint64(u64[:], policy.MaxSignatures)
+	b.AddBytes(u64[:])
+	binary.BigEndian.PutUint64(u64[:], notAfter)
+	b.AddBytes(u64[:])
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, c := range policy.Contexts {
+			c := c
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(c)
+			})
+		}
+	})
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrPolicy, "contexts too long")
+	}
+	return body, nil
+}
+
+// policyDigest hashes a policy body with the hash function used for blinding
+// on the curve c of the authority key:
+//
+//	H(len(DST) || DST || body)
+//
+// where the length is a 2-byte big-endian integer.
+func policyDigest(c elliptic.Curve, body []byte) ([]byte, error) {
+	h, _, err := blindParams(c)
+	if err != nil {
+		return nil, err
+	}
+	var dst [2]byte
+	binary.BigEndian.PutUint16(dst[:], uint16(len(policyDST)))
+	md := h.New()
+	md.Write(dst[:])
+	md.Write([]byte(policyDST))
+	md.Write(body)
+	return md.Sum(nil), nil
+}
+
+// MarshalPolicy encodes policy for the key pub, signed by authority, as:
+//
+//	struct {
+//	  opaque public_key<1..2^16-1>;
+//	  uint64 max_signatures;
+//	  uint64 not_after;
+//	  opaque contexts<0..2^16-1>;
+//	  opaque r[Ns];
+//	  opaque s[Ns];
+//	} SignedPolicy;
+//
+// where public_key is the compressed point encoding, not_after is in seconds
+// since the Unix epoch or zero, contexts is a sequence of opaque
+// context<0..2^16-1> values, and r, s is the signature by authority, whose
+// scalars are Ns bytes long. The authority may be the key itself, which only
+// lets importers detect accidental changes, or a key they trust to issue
+// policies.
+func MarshalPolicy(rand io.Reader, authority *PrivateKey, pub *PublicKey, policy Policy) ([]byte, error) {
+	body, err := policyBody(pub, policy)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := policyDigest(authority.Curve, body)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := Sign(rand, authority, digest)
+	if err != nil {
+		return nil, err
+	}
+	size := scalarSize(authority.Curve)
+	out := append(body, r.FillBytes(make([]byte, size))...)
+	return append(out, s.FillBytes(make([]byte, size))...), nil
+}
+
+// UnmarshalPolicy decodes a policy for a key on the curve c encoded by
+// MarshalPolicy, and checks that it was signed by authority.
+func UnmarshalPolicy(authority *PublicKey, c elliptic.Curve, data []byte) (*PublicKey, Policy, error) {
+	size := scalarSize(authority.Curve)
+	if len(data) < 2*size {
+		return nil, Policy{}, wrapError(ErrInvalidSignature, "malformed policy")
+	}
+	body := data[:len(data)-2*size]
+	r := new(big.Int).SetBytes(data[len(data)-2*size : len(data)-size])
+	s := new(big.Int).SetBytes(data[len(data)-size:])
+	digest, err := policyDigest(authority.Curve, body)
+	if err != nil {
+		return nil, Policy{}, err
+	}
+	if err := CheckSignature(authority, digest, r, s); err != nil {
+		return nil, Policy{}, err
+	}
+
+	in := cryptobyte.String(body)
+	var enc, contexts cryptobyte.String
+	var maxSignatures, notAfter uint64
+	var u64 []byte
+	ok := in.ReadUint16LengthPrefixed(&enc) && in.ReadBytes(&u64, 8)
+	if ok {
+		maxSignatures = binary.BigEndian.Uint64(u64)
+		ok = in.ReadBytes(&u64, 8)
+	}
+	if ok {
+		notAfter = binary.BigEndian.Uint64(u64)
+		ok = in.ReadUint16LengthPrefixed(&contexts) && in.Empty()
+	}
+	if !ok || notAfter > 1<<62 {
+		return nil, Policy{}, wrapError(ErrInvalidSignature, "malformed policy")
+	}
+
+	p, err := NewPoint(c, enc)
+	if err != nil {
+		return nil, Policy{}, err
+	}
+	pub, err := NewPublicKey(p)
+	if err != nil {
+		return nil, Policy{}, err
+	}
+	policy := Policy{MaxSignatures: maxSignatures}
+	if notAfter != 0 {
+		policy.NotAfter = time.Unix(int64(notAfter), 0)
+	}
+	for !contexts.Empty() {
+		var context cryptobyte.String
+		if !contexts.ReadUint16LengthPrefixed(&context) {
+			return nil, Policy{}, wrapError(ErrInvalidSignature, "malformed policy")
+		}
+		policy.Contexts = append(policy.Contexts, append([]byte{}, context...))
+	}
+	return pub, policy, nil
+}
+
+// MarshalPolicy encodes the policy of pk with MarshalPolicy, signed by
+// authority, so that it can be exported along with the key. The encoded
+// MaxSignatures is the number of signatures pk has left, so that importing
+// the key doesn't reset its count.
+func (pk *PolicyKey) MarshalPolicy(rand io.Reader, authority *PrivateKey) ([]byte, error) {
+	policy := pk.policy
+	if policy.MaxSignatures != 0 {
+		used := pk.Signatures()
+		if used >= policy.MaxSignatures {
+			return nil, wrapError(ErrPolicy, "key exhausted after %d signatures", used)
+		}
+		policy.MaxSignatures -= used
+	}
+	return MarshalPolicy(rand, authority, &pk.key.PublicKey, policy)
+}
+
+// ImportPolicyKey returns sk constrained by the policy encoded in
+// signedPolicy by MarshalPolicy. It returns an error if the policy was not
+// signed by authority or is not for the public key of sk.
+func ImportPolicyKey(sk *PrivateKey, authority *PublicKey, signedPolicy []byte) (*PolicyKey, error) {
+	pub, policy, err := UnmarshalPolicy(authority, sk.Curve, signedPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if !pub.Equal(&sk.PublicKey) {
+		return nil, wrapError(ErrPolicy, "policy is for another key")
+	}
+	return NewPolicyKey(sk, policy), nil
+}
@@ -0,0 +1,129 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicyKey(t *testing.T) {
+	testAllCurves(t, testPolicyKey)
+}
+
+func testPolicyKey(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	hashed := testDigest("testing")
+	now := time.Unix(1700000000, 0)
+	policy := Policy{
+		MaxSignatures: 3,
+		NotAfter:      now.Add(time.Hour),
+		Contexts:      [][]byte{[]byte("a"), []byte("b")},
+	}
+	pk := newPolicyKey(skS, policy, func() time.Time { return now })
+
+	r, s, err := pk.BlindKeySignWithContext(rand.Reader, skB, hashed, []byte("a"))
+	if err != nil {
+		t.Fatalf("BlindKeySignWithContext error: %s", err)
+	}
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, []byte("a"))
+	if !Verify(pkR, hashed, r, s) {
+		t.Errorf("Verify failed")
+	}
+	if _, _, err := pk.BlindKeySignWithContext(rand.Reader, skB, hashed, []byte("c")); !errors.Is(err, ErrPolicy) {
+		t.Errorf("BlindKeySignWithContext with a forbidden context: got %v, want ErrPolicy", err)
+	}
+	if _, _, err := pk.BlindKeySign(rand.Reader, skB, hashed); !errors.Is(err, ErrPolicy) {
+		t.Errorf("BlindKeySign with an empty context: got %v, want ErrPolicy", err)
+	}
+	// FIPS mode ignores rand, and the signature would count.
+	if !FIPSMode() {
+		if _, _, err := pk.Sign(failingReader{}, hashed); !errors.Is(err, ErrEntropy) {
+			t.Errorf("Sign without entropy: got %v, want ErrEntropy", err)
+		}
+	}
+	if _, _, err := pk.Sign(rand.Reader, hashed); err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if got := pk.Signatures(); got != 2 {
+		t.Errorf("got %d signatures, want 2", got)
+	}
+
+	// The exported policy carries the remaining signatures.
+	exported, err := pk.MarshalPolicy(rand.Reader, skS)
+	if err != nil {
+		t.Fatalf("MarshalPolicy error: %s", err)
+	}
+	imported, err := ImportPolicyKey(skS, &skS.PublicKey, exported)
+	if err != nil {
+		t.Fatalf("ImportPolicyKey error: %s", err)
+	}
+	got := imported.Policy()
+	if got.MaxSignatures != 1 || !got.NotAfter.Equal(policy.NotAfter) || len(got.Contexts) != 2 ||
+		!bytes.Equal(got.Contexts[0], []byte("a")) || !bytes.Equal(got.Contexts[1], []byte("b")) {
+		t.Errorf("imported policy %+v does not match %+v", got, policy)
+	}
+
+	if _, _, err := pk.Sign(rand.Reader, hashed); err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if _, _, err := pk.Sign(rand.Reader, hashed); !errors.Is(err, ErrPolicy) {
+		t.Errorf("Sign beyond MaxSignatures: got %v, want ErrPolicy", err)
+	}
+	if _, err := pk.MarshalPolicy(rand.Reader, skS); !errors.Is(err, ErrPolicy) {
+		t.Errorf("MarshalPolicy of an exhausted key: got %v, want ErrPolicy", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	expired := newPolicyKey(skS, Policy{NotAfter: policy.NotAfter}, func() time.Time { return now })
+	if _, _, err := expired.Sign(rand.Reader, hashed); !errors.Is(err, ErrPolicy) {
+		t.Errorf("Sign after NotAfter: got %v, want ErrPolicy", err)
+	}
+}
+
+func TestSignedPolicy(t *testing.T) {
+	testAllCurves(t, testSignedPolicy)
+}
+
+func testSignedPolicy(t *testing.T, c elliptic.Curve) {
+	sk, _ := GenerateKey(c, rand.Reader)
+	other, _ := GenerateKey(c, rand.Reader)
+	authority, _ := GenerateKey(elliptic.P384(), rand.Reader)
+	policy := Policy{Contexts: [][]byte{[]byte("context"), {}}}
+
+	data, err := MarshalPolicy(rand.Reader, authority, &sk.PublicKey, policy)
+	if err != nil {
+		t.Fatalf("MarshalPolicy error: %s", err)
+	}
+	pub, got, err := UnmarshalPolicy(&authority.PublicKey, c, data)
+	if err != nil {
+		t.Fatalf("UnmarshalPolicy error: %s", err)
+	}
+	if !pub.Equal(&sk.PublicKey) || got.MaxSignatures != 0 || !got.NotAfter.IsZero() || len(got.Contexts) != 2 {
+		t.Errorf("decoded policy %+v does not match %+v", got, policy)
+	}
+
+	pk, err := ImportPolicyKey(sk, &authority.PublicKey, data)
+	if err != nil {
+		t.Fatalf("ImportPolicyKey error: %s", err)
+	}
+	if _, _, err := pk.BlindKeySign(rand.Reader, other, testDigest("testing")); err != nil {
+		t.Errorf("BlindKeySign with an allowed empty context: %s", err)
+	}
+	if _, err := ImportPolicyKey(other, &authority.PublicKey, data); !errors.Is(err, ErrPolicy) {
+		t.Errorf("ImportPolicyKey for another key: got %v, want ErrPolicy", err)
+	}
+	if _, err := ImportPolicyKey(sk, &other.PublicKey, data); err == nil {
+		t.Errorf("ImportPolicyKey accepted a policy of another authority")
+	}
+	for i := range data {
+		tampered := append([]byte{}, data...)
+		tampered[i] ^= 0x01
+		if _, _, err := UnmarshalPolicy(&authority.PublicKey, c, tampered); err == nil {
+			t.Fatalf("UnmarshalPolicy accepted byte %d tampered", i)
+		}
+	}
+}
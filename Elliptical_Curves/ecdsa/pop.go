@@ -0,0 +1,132 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const popDST = "ECDSA Key Blind Proof of Possession"
+
+// PoP is a proof of possession of a blinded key, sent by its holder to a CA
+// or another registration authority when enrolling the key. It proves that
+// the holder controls both the private key skS and the blind the key was
+// blinded with, at the time of a challenge chosen by the authority. A
+// signature by the blinded key only proves control of the blinded private
+// key, which can be delegated without the base key or blind.
+//
+// The proof is an OwnershipProof bound to the challenge and the time of the
+// proof, with its own domain separation tag, so it can't be replayed for
+// another challenge or taken for a plain OwnershipProof.
+type PoP struct {
+	// BlindedKey is the blinded public key being enrolled.
+	BlindedKey *PublicKey
+	// Timestamp is the time the proof was generated, at second precision.
+	Timestamp time.Time
+	// Proof proves knowledge of skS and the blind of BlindedKey.
+	Proof *OwnershipProof
+}
+
+// popStatement returns the values a proof of possession is bound to:
+//
+//	challenge, timestamp
+//
+// where timestamp is an 8-byte big-endian number of seconds since the Unix
+// epoch.
+func popStatement(challenge []byte, timestamp time.Time) [][]byte {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(timestamp.Unix()))
+	return [][]byte{challenge, ts[:]}
+}
+
+// GeneratePoP proves possession of skS and skB for the public key of skS
+// blinded by skB, in answer to challenge, drawing randomness from the source
+// set with SetConfig. It is GeneratePoPWithContext with an empty context.
+func GeneratePoP(skS, skB *PrivateKey, challenge []byte) (*PoP, error) {
+	return GeneratePoPWithContext(skS, skB, challenge, nil)
+}
+
+// GeneratePoPWithContext proves possession of skS and skB for the public key
+// of skS blinded by skB and context, in answer to challenge, drawing
+// randomness from the source set with SetConfig.
+func GeneratePoPWithContext(skS, skB *PrivateKey, challenge, context []byte) (*PoP, error) {
+	pkR, err := BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Unix(time.Now().Unix(), 0)
+	proof, err := proveOwnership(nil, popDST, skS, pkR, skB, context, popStatement(challenge, now)...)
+	if err != nil {
+		return nil, err
+	}
+	return &PoP{BlindedKey: pkR, Timestamp: now, Proof: proof}, nil
+}
+
+// VerifyPoP checks that pop proves possession of the base key and blind of
+// its blinded key for challenge and context, and that it is fresh: generated
+// at most maxAge before now, and not after now. Clock skew should be allowed
+// for in maxAge. It returns an error wrapping ErrInvalidProof if the proof
+// doesn't verify or is stale. Checking that the challenge was issued by the
+// caller, and that it is used once, is left to the caller.
+func VerifyPoP(pop *PoP, challenge, context []byte, now time.Time, maxAge time.Duration) error {
+	if pop == nil || pop.BlindedKey == nil {
+		return wrapError(ErrInvalidProof, "incomplete proof of possession")
+	}
+	if pop.Timestamp.After(now) || now.Sub(pop.Timestamp) > maxAge {
+		return wrapError(ErrInvalidProof, "proof of possession generated at %v is not fresh", pop.Timestamp)
+	}
+	return verifyOwnership(popDST, pop.BlindedKey, context, pop.Proof, popStatement(challenge, pop.Timestamp)...)
+}
+
+// Marshal encodes pop as:
+//
+//	struct {
+//	  opaque blinded_key<1..2^16-1>;
+//	  uint64 timestamp;
+//	  opaque proof<1..2^16-1>;
+//	} PoP;
+//
+// where blinded_key is the compressed point encoding, timestamp is in
+// seconds since the Unix epoch, and proof is the encoding of the
+// OwnershipProof.
+func (pop *PoP) Marshal() ([]byte, error) {
+	pkR := pop.BlindedKey
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y))
+	})
+	b.AddUint64(uint64(pop.Timestamp.Unix()))
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(pop.Proof.Marshal())
+	})
+	return b.Bytes()
+}
+
+// UnmarshalPoP decodes a proof of possession of a key on the curve c encoded
+// by PoP.Marshal. It does not verify the proof.
+func UnmarshalPoP(c elliptic.Curve, data []byte) (*PoP, error) {
+	s := cryptobyte.String(data)
+	var enc, proof cryptobyte.String
+	var ts uint64
+	if !s.ReadUint16LengthPrefixed(&enc) ||
+		!s.ReadUint64(&ts) ||
+		!s.ReadUint16LengthPrefixed(&proof) ||
+		!s.Empty() {
+		return nil, wrapError(ErrInvalidProof, "malformed proof of possession")
+	}
+	p, err := NewPoint(c, enc)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := NewPublicKey(p)
+	if err != nil {
+		return nil, err
+	}
+	op, err := UnmarshalOwnershipProof(c, proof)
+	if err != nil {
+		return nil, err
+	}
+	return &PoP{BlindedKey: pkR, Timestamp: time.Unix(int64(ts), 0), Proof: op}, nil
+}
@@ -0,0 +1,71 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoP(t *testing.T) {
+	testAllCurves(t, testPoP)
+}
+
+func testPoP(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	challenge := []byte("challenge")
+	context := []byte("context")
+
+	pop, err := GeneratePoPWithContext(skS, skB, challenge, context)
+	if err != nil {
+		t.Fatalf("GeneratePoPWithContext error: %s", err)
+	}
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if !pop.BlindedKey.Equal(pkR) {
+		t.Errorf("PoP is for another key")
+	}
+	now := pop.Timestamp.Add(time.Second)
+	if err := VerifyPoP(pop, challenge, context, now, time.Minute); err != nil {
+		t.Errorf("VerifyPoP error: %s", err)
+	}
+
+	enc, err := pop.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decoded, err := UnmarshalPoP(c, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalPoP error: %s", err)
+	}
+	if err := VerifyPoP(decoded, challenge, context, now, time.Minute); err != nil {
+		t.Errorf("VerifyPoP of decoded proof error: %s", err)
+	}
+	if _, err := UnmarshalPoP(c, enc[:len(enc)-1]); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("UnmarshalPoP of a truncated proof: got %v, want ErrInvalidProof", err)
+	}
+
+	for name, err := range map[string]error{
+		"another challenge": VerifyPoP(pop, []byte("other"), context, now, time.Minute),
+		"another context":   VerifyPoP(pop, challenge, []byte("other"), now, time.Minute),
+		"stale":             VerifyPoP(pop, challenge, context, now.Add(time.Minute), time.Minute),
+		"from the future":   VerifyPoP(pop, challenge, context, pop.Timestamp.Add(-time.Second), time.Minute),
+		"another timestamp": VerifyPoP(&PoP{pop.BlindedKey, pop.Timestamp.Add(-time.Second), pop.Proof}, challenge, context, now, time.Minute),
+		"ownership proof":   VerifyOwnership(pkR, context, pop.Proof),
+	} {
+		if !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("%s: got %v, want ErrInvalidProof", name, err)
+		}
+	}
+
+	// An ownership proof, which is not bound to a challenge, is not a PoP.
+	proof, _ := ProveOwnership(rand.Reader, skS, pkR, skB, context)
+	if err := VerifyPoP(&PoP{pkR, pop.Timestamp, proof}, challenge, context, now, time.Minute); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyPoP of an ownership proof: got %v, want ErrInvalidProof", err)
+	}
+
+	if p, _ := GeneratePoP(skS, skB, challenge); p.BlindedKey.Equal(pkR) {
+		t.Errorf("GeneratePoP blinds with the context")
+	}
+}
@@ -0,0 +1,224 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// SignatureProfile is a wire encoding of signatures, as required by a
+// downstream protocol.
+type SignatureProfile int
+
+const (
+	// ProfileDER is the ASN.1 DER encoding of SEQUENCE { r INTEGER, s
+	// INTEGER }, of X.509, TLS and SignASN1.
+	ProfileDER SignatureProfile = iota
+
+	// ProfileP1363 is the IEEE P1363 encoding: R and S concatenated, each
+	// padded to the length of a scalar of the curve, as Signature.MarshalRaw
+	// returns it for JWS, COSE and WebCrypto.
+	ProfileP1363
+
+	// ProfileCompact is a recovery byte followed by the P1363 encoding, as in
+	// Bitcoin compact signatures: the byte is 27 plus the recovery ID, which
+	// lets RecoverPublicKey recompute the public key from the signature and
+	// the digest.
+	ProfileCompact
+)
+
+// compactRecoveryOffset is added to the recovery ID in the first byte of a
+// compact signature.
+const compactRecoveryOffset = 27
+
+// String returns the name of the profile.
+func (p SignatureProfile) String() string {
+	switch p {
+	case ProfileDER:
+		return "der"
+	case ProfileP1363:
+		return "p1363"
+	case ProfileCompact:
+		return "compact"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSignatureProfile returns the profile named name, as returned by
+// SignatureProfile.String.
+func ParseSignatureProfile(name string) (SignatureProfile, error) {
+	for _, p := range []SignatureProfile{ProfileDER, ProfileP1363, ProfileCompact} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, wrapError(ErrInvalidSignature, "unknown signature profile %q", name)
+}
+
+// recoverPoint returns the public key of c for which r, s is a signature of
+// hash, given the recovery ID: bit 0 is the parity of the y-coordinate of the
+// nonce point R, and bit 1 is set if its x-coordinate is r + N.
+func recoverPoint(c elliptic.Curve, hash []byte, r, s *big.Int, recid byte) (*Point, error) {
+	params := c.Params()
+	N := params.N
+	if recid > 3 || r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return nil, wrapError(ErrInvalidSignature, "signature out of range for %s", curveName(c))
+	}
+	x := new(big.Int).Set(r)
+	if recid&2 != 0 {
+		x.Add(x, N)
+	}
+	if x.Cmp(params.P) >= 0 {
+		return nil, wrapError(ErrInvalidSignature, "recovery ID out of range")
+	}
+	enc := append([]byte{2 | recid&1}, x.FillBytes(make([]byte, coordinateSize(c)))...)
+	R, err := NewPoint(c, enc)
+	if err != nil {
+		return nil, wrapError(ErrInvalidSignature, "no point for the recovery ID")
+	}
+
+	// Q = r^-1 * (s * R - e * G).
+	e := hashToInt(hash, c)
+	e.Mod(e, N)
+	rInv := new(big.Int).ModInverse(r, N)
+	u1 := &Scalar{c: c, v: new(big.Int).Mod(new(big.Int).Mul(new(big.Int).Neg(e), rInv), N)}
+	u2 := &Scalar{c: c, v: new(big.Int).Mod(new(big.Int).Mul(s, rInv), N)}
+	Q := NewIdentityPoint(c).ScalarBaseMult(u1)
+	Q.Add(Q, NewIdentityPoint(c).ScalarMult(u2, R))
+	if Q.IsIdentity() == 1 {
+		return nil, wrapError(ErrInvalidSignature, "recovered the point at infinity")
+	}
+	return Q, nil
+}
+
+// recoveryID returns the recovery ID of the signature r, s of hash under pub.
+func recoveryID(pub *PublicKey, hash []byte, r, s *big.Int) (byte, error) {
+	pk, err := pub.Point()
+	if err != nil {
+		return 0, err
+	}
+	for recid := byte(0); recid < 4; recid++ {
+		Q, err := recoverPoint(pub.Curve, hash, r, s, recid)
+		if err == nil && Q.Equal(pk) == 1 {
+			return recid, nil
+		}
+	}
+	return 0, wrapError(ErrInvalidSignature, "signature does not verify under the public key")
+}
+
+// EncodeSignature encodes the signature r, s of hash under pub in the profile
+// p. The public key and digest are only used to compute the recovery byte of
+// ProfileCompact, which fails if the signature doesn't verify.
+func EncodeSignature(pub *PublicKey, hash []byte, r, s *big.Int, p SignatureProfile) ([]byte, error) {
+	switch p {
+	case ProfileDER:
+		if r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 {
+			return nil, wrapError(ErrInvalidSignature, "incomplete signature")
+		}
+		var b cryptobyte.Builder
+		b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1BigInt(r)
+			b.AddASN1BigInt(s)
+		})
+		return b.Bytes()
+	case ProfileP1363:
+		return (&Signature{R: r, S: s}).MarshalRaw(pub.Curve)
+	case ProfileCompact:
+		raw, err := (&Signature{R: r, S: s}).MarshalRaw(pub.Curve)
+		if err != nil {
+			return nil, err
+		}
+		recid, err := recoveryID(pub, hash, r, s)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{compactRecoveryOffset + recid}, raw...), nil
+	default:
+		return nil, wrapError(ErrInvalidSignature, "unknown signature profile %d", p)
+	}
+}
+
+// ParseSignature decodes a signature on the curve c in any profile, and
+// returns the profile it was encoded in. Inputs that parse as ASN.1 DER are
+// DER signatures; otherwise, the profile is told by the length of the input:
+// a P1363 signature is twice as long as a scalar, and a compact signature is
+// one byte longer. It does not verify the signature.
+func ParseSignature(c elliptic.Curve, data []byte) (*Signature, SignatureProfile, error) {
+	if r, s, ok := parseASN1Signature(data); ok {
+		return &Signature{R: r, S: s}, ProfileDER, nil
+	}
+	size := scalarSize(c)
+	switch len(data) {
+	case 2 * size:
+		sig, err := UnmarshalRawSignature(c, data)
+		return sig, ProfileP1363, err
+	case 2*size + 1:
+		if data[0] < compactRecoveryOffset || data[0] > compactRecoveryOffset+3 {
+			return nil, 0, wrapError(ErrInvalidSignature, "invalid recovery byte %d", data[0])
+		}
+		sig, err := UnmarshalRawSignature(c, data[1:])
+		return sig, ProfileCompact, err
+	default:
+		return nil, 0, wrapError(ErrInvalidSignature, "signature of %d bytes in no known profile", len(data))
+	}
+}
+
+// RecoverPublicKey returns the public key of c under which sig, a signature
+// of hash encoded in ProfileCompact, verifies.
+func RecoverPublicKey(c elliptic.Curve, hash, sig []byte) (*PublicKey, error) {
+	if err := strictCheckDigest(hash); err != nil {
+		return nil, err
+	}
+	parsed, p, err := ParseSignature(c, sig)
+	if err != nil {
+		return nil, err
+	}
+	if p != ProfileCompact {
+		return nil, wrapError(ErrInvalidSignature, "%s signature has no recovery byte", p)
+	}
+	Q, err := recoverPoint(c, hash, parsed.R, parsed.S, sig[0]-compactRecoveryOffset)
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKey(Q)
+}
+
+// SignWithProfile signs hash with priv, as SignASN1 does, and encodes the
+// signature in the profile p.
+func SignWithProfile(rand io.Reader, priv *PrivateKey, hash []byte, p SignatureProfile) ([]byte, error) {
+	r, s, err := Sign(rand, priv, hash)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeSignature(&priv.PublicKey, hash, r, s, p)
+}
+
+// BlindKeySignWithProfile signs hash with skS blinded by skB and context, as
+// BlindKeySignWithContext does, and encodes the signature in the profile p.
+func BlindKeySignWithProfile(rand io.Reader, skS, skB *PrivateKey, hash, context []byte, p SignatureProfile) ([]byte, error) {
+	skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := BlindKeySignWithContext(rand, skS, skB, hash, context)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeSignature(&skR.PublicKey, hash, r, s, p)
+}
+
+// VerifyWithProfile reports whether sig, encoded in the profile p, is a valid
+// signature of hash under pub. Use ParseSignature and Verify to accept any
+// profile.
+func VerifyWithProfile(pub *PublicKey, hash, sig []byte, p SignatureProfile) bool {
+	parsed, got, err := ParseSignature(pub.Curve, sig)
+	if err != nil || got != p {
+		observeVerifyFailure(pub.Curve)
+		return false
+	}
+	return Verify(pub, hash, parsed.R, parsed.S)
+}
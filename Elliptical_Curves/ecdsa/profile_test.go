@@ -0,0 +1,84 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestSignatureProfiles(t *testing.T) {
+	testAllCurves(t, testSignatureProfiles)
+}
+
+func testSignatureProfiles(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	hash := sha256.Sum256([]byte("testing"))
+
+	for _, p := range []SignatureProfile{ProfileDER, ProfileP1363, ProfileCompact} {
+		if got, err := ParseSignatureProfile(p.String()); err != nil || got != p {
+			t.Errorf("ParseSignatureProfile(%q) = %v, %v", p, got, err)
+		}
+
+		sig, err := SignWithProfile(rand.Reader, skS, hash[:], p)
+		if err != nil {
+			t.Fatalf("SignWithProfile(%s) error: %s", p, err)
+		}
+		if !VerifyWithProfile(&skS.PublicKey, hash[:], sig, p) {
+			t.Errorf("VerifyWithProfile(%s) failed", p)
+		}
+		parsed, got, err := ParseSignature(c, sig)
+		if err != nil || got != p {
+			t.Fatalf("ParseSignature of a %s signature = %v, %v", p, got, err)
+		}
+		if !Verify(&skS.PublicKey, hash[:], parsed.R, parsed.S) {
+			t.Errorf("Verify of a parsed %s signature failed", p)
+		}
+		if p == ProfileDER && !VerifyASN1(&skS.PublicKey, hash[:], sig) {
+			t.Errorf("VerifyASN1 of a DER signature failed")
+		}
+
+		blinded, err := BlindKeySignWithProfile(rand.Reader, skS, skB, hash[:], context, p)
+		if err != nil {
+			t.Fatalf("BlindKeySignWithProfile(%s) error: %s", p, err)
+		}
+		if !VerifyWithProfile(pkR, hash[:], blinded, p) {
+			t.Errorf("VerifyWithProfile(%s) of a blinded signature failed", p)
+		}
+		if p == ProfileCompact {
+			pk, err := RecoverPublicKey(c, hash[:], blinded)
+			if err != nil {
+				t.Fatalf("RecoverPublicKey error: %s", err)
+			}
+			if !pk.Equal(pkR) {
+				t.Errorf("RecoverPublicKey returned another key")
+			}
+		}
+	}
+
+	der, _ := SignWithProfile(rand.Reader, skS, hash[:], ProfileDER)
+	if VerifyWithProfile(&skS.PublicKey, hash[:], der, ProfileP1363) {
+		t.Errorf("VerifyWithProfile accepted a DER signature as P1363")
+	}
+	if _, err := RecoverPublicKey(c, hash[:], der); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("RecoverPublicKey of a DER signature: got %v, want ErrInvalidSignature", err)
+	}
+	compact, _ := SignWithProfile(rand.Reader, skS, hash[:], ProfileCompact)
+	compact[0] = 31
+	if _, _, err := ParseSignature(c, compact); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseSignature with an invalid recovery byte: got %v, want ErrInvalidSignature", err)
+	}
+	if _, _, err := ParseSignature(c, []byte{1, 2, 3}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseSignature of a short input: got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := ParseSignatureProfile("pem"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseSignatureProfile of an unknown name: got %v, want ErrInvalidSignature", err)
+	}
+}
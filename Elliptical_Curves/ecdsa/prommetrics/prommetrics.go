@@ -0,0 +1,109 @@
+// Package prommetrics exports the measurements of the ecdsa package as
+// Prometheus metrics.
+//
+// A Metrics is both an ecdsa.Metrics and a prometheus.Collector:
+//
+//	m := prommetrics.New("issuer")
+//	prometheus.MustRegister(m)
+//	ecdsa.SetMetrics(m)
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+const subsystem = "ecdsa"
+
+// Metrics collects the measurements of the ecdsa package. It implements
+// ecdsa.Metrics and prometheus.Collector.
+type Metrics struct {
+	signDuration   *prometheus.HistogramVec
+	verifyFailures *prometheus.CounterVec
+	blindings      *prometheus.CounterVec
+	rngErrors      prometheus.Counter
+}
+
+var _ ecdsa.Metrics = (*Metrics)(nil)
+
+// New returns metrics named with the given namespace, which may be empty:
+//
+//   - ecdsa_sign_duration_seconds, a histogram of signing latencies by curve;
+//   - ecdsa_verify_failures_total, the number of rejected signatures by curve;
+//   - ecdsa_key_blindings_total, the number of public keys blinded and
+//     unblinded, by curve and operation ("blind" or "unblind");
+//   - ecdsa_rng_errors_total, the number of failed reads from the random
+//     source.
+func New(namespace string) *Metrics {
+	return &Metrics{
+		signDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "sign_duration_seconds",
+			Help:      "Time taken to produce a signature.",
+			Buckets:   prometheus.ExponentialBuckets(1e-5, 2, 16),
+		}, []string{"curve"}),
+		verifyFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "verify_failures_total",
+			Help:      "Number of signatures rejected by verification.",
+		}, []string{"curve"}),
+		blindings: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "key_blindings_total",
+			Help:      "Number of public keys blinded or unblinded.",
+		}, []string{"curve", "operation"}),
+		rngErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rng_errors_total",
+			Help:      "Number of failed reads from the random source.",
+		}),
+	}
+}
+
+// ObserveSign implements ecdsa.Metrics.
+func (m *Metrics) ObserveSign(curve string, d time.Duration) {
+	m.signDuration.WithLabelValues(curve).Observe(d.Seconds())
+}
+
+// VerifyFailed implements ecdsa.Metrics.
+func (m *Metrics) VerifyFailed(curve string) {
+	m.verifyFailures.WithLabelValues(curve).Inc()
+}
+
+// KeyBlinded implements ecdsa.Metrics.
+func (m *Metrics) KeyBlinded(curve string) {
+	m.blindings.WithLabelValues(curve, "blind").Inc()
+}
+
+// KeyUnblinded implements ecdsa.Metrics.
+func (m *Metrics) KeyUnblinded(curve string) {
+	m.blindings.WithLabelValues(curve, "unblind").Inc()
+}
+
+// RandomnessError implements ecdsa.Metrics.
+func (m *Metrics) RandomnessError() {
+	m.rngErrors.Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.signDuration.Describe(ch)
+	m.verifyFailures.Describe(ch)
+	m.blindings.Describe(ch)
+	m.rngErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.signDuration.Collect(ch)
+	m.verifyFailures.Collect(ch)
+	m.blindings.Collect(ch)
+	m.rngErrors.Collect(ch)
+}
@@ -0,0 +1,59 @@
+package prommetrics
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New("test")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(m); err != nil {
+		t.Fatalf("Register error: %s", err)
+	}
+	ecdsa.SetMetrics(m)
+	defer ecdsa.SetMetrics(nil)
+
+	c := elliptic.P256()
+	skS, _ := ecdsa.GenerateKey(c, rand.Reader)
+	skB, _ := ecdsa.GenerateKey(c, rand.Reader)
+	hashed := sha256.Sum256([]byte("testing"))
+
+	r, s, err := ecdsa.BlindKeySign(rand.Reader, skS, skB, hashed[:])
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	pkR, err := ecdsa.BlindPublicKey(c, &skS.PublicKey, skB)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	if ecdsa.Verify(pkR, hashed[:], s, r) {
+		t.Fatalf("swapped signature accepted")
+	}
+
+	if n := testutil.CollectAndCount(m, "test_ecdsa_sign_duration_seconds"); n != 1 {
+		t.Errorf("got %d sign duration series, want 1", n)
+	}
+	expected := `
+# HELP test_ecdsa_key_blindings_total Number of public keys blinded or unblinded.
+# TYPE test_ecdsa_key_blindings_total counter
+test_ecdsa_key_blindings_total{curve="P-256",operation="blind"} 1
+# HELP test_ecdsa_verify_failures_total Number of signatures rejected by verification.
+# TYPE test_ecdsa_verify_failures_total counter
+test_ecdsa_verify_failures_total{curve="P-256"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "test_ecdsa_key_blindings_total", "test_ecdsa_verify_failures_total"); err != nil {
+		t.Errorf("unexpected metrics: %s", err)
+	}
+	if v := testutil.ToFloat64(m.rngErrors); v != 0 {
+		t.Errorf("got %v randomness errors, want 0", v)
+	}
+}
@@ -0,0 +1,166 @@
+package ecdsa
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// RateLimit configures a token bucket: Rate tokens are added per second, up
+// to Burst tokens. A zero Rate disables the limit. A Burst below 1 is treated
+// as 1, since a bucket that can't hold a token would refuse every signature.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// withMinBurst returns l with a Burst of at least 1.
+func (l RateLimit) withMinBurst() RateLimit {
+	if l.Burst < 1 {
+		l.Burst = 1
+	}
+	return l
+}
+
+// RateLimitError is the error returned when a signature is refused by a
+// RateLimitedSigner. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	// Global is true if the global limit was exceeded, and false if the
+	// limit of the blinded key was.
+	Global bool
+	// RetryAfter is the time until a token is available again.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	scope := "blinded key"
+	if e.Global {
+		scope = "global"
+	}
+	return fmt.Sprintf("%s: %s limit exceeded, retry after %s", ErrRateLimited, scope, e.RetryAfter)
+}
+
+// Is reports whether target is ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (b *tokenBucket) refill(l RateLimit, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * l.Rate
+	if b.tokens > float64(l.Burst) {
+		b.tokens = float64(l.Burst)
+	}
+	b.last = now
+}
+
+// wait returns the time until the bucket holds a token.
+func (b *tokenBucket) wait(l RateLimit) time.Duration {
+	return time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+}
+
+// maxIdleBuckets is the number of per-key buckets above which full buckets,
+// which are equivalent to missing ones, are dropped.
+const maxIdleBuckets = 4096
+
+// RateLimitedSigner wraps BlindKeySignWithContext with a global limit on the
+// rate of signatures and a limit per blinded public key, enforced with token
+// buckets. It is safe for concurrent use.
+type RateLimitedSigner struct {
+	global RateLimit
+	perKey RateLimit
+	now    func() time.Time
+
+	mu         sync.Mutex
+	globalBkt  tokenBucket
+	keyBuckets map[[sha256.Size]byte]*tokenBucket
+}
+
+// NewRateLimitedSigner returns a signer enforcing the global limit on all
+// signatures and the perKey limit on the signatures of each blinded key.
+func NewRateLimitedSigner(global, perKey RateLimit) *RateLimitedSigner {
+	return newRateLimitedSigner(global, perKey, time.Now)
+}
+
+func newRateLimitedSigner(global, perKey RateLimit, now func() time.Time) *RateLimitedSigner {
+	global, perKey = global.withMinBurst(), perKey.withMinBurst()
+	return &RateLimitedSigner{
+		global:     global,
+		perKey:     perKey,
+		now:        now,
+		globalBkt:  tokenBucket{tokens: float64(global.Burst), last: now()},
+		keyBuckets: make(map[[sha256.Size]byte]*tokenBucket),
+	}
+}
+
+// take consumes a token from the global bucket and from the bucket of the
+// blinded key fp, or from neither if either is empty.
+func (rs *RateLimitedSigner) take(fp [sha256.Size]byte) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	now := rs.now()
+
+	var kb *tokenBucket
+	if rs.perKey.Rate > 0 {
+		kb = rs.keyBuckets[fp]
+		if kb == nil {
+			if len(rs.keyBuckets) >= maxIdleBuckets {
+				rs.pruneLocked(now)
+			}
+			kb = &tokenBucket{tokens: float64(rs.perKey.Burst), last: now}
+			rs.keyBuckets[fp] = kb
+		}
+		kb.refill(rs.perKey, now)
+		if kb.tokens < 1 {
+			return &RateLimitError{RetryAfter: kb.wait(rs.perKey)}
+		}
+	}
+	if rs.global.Rate > 0 {
+		rs.globalBkt.refill(rs.global, now)
+		if rs.globalBkt.tokens < 1 {
+			return &RateLimitError{Global: true, RetryAfter: rs.globalBkt.wait(rs.global)}
+		}
+		rs.globalBkt.tokens--
+	}
+	if kb != nil {
+		kb.tokens--
+	}
+	return nil
+}
+
+// pruneLocked drops the per-key buckets that have refilled completely.
+func (rs *RateLimitedSigner) pruneLocked(now time.Time) {
+	for fp, b := range rs.keyBuckets {
+		b.refill(rs.perKey, now)
+		if b.tokens >= float64(rs.perKey.Burst) {
+			delete(rs.keyBuckets, fp)
+		}
+	}
+}
+
+// BlindKeySignWithContext is like the package-level BlindKeySignWithContext,
+// but returns a *RateLimitError instead of signing if a limit is exceeded.
+func (rs *RateLimitedSigner) BlindKeySignWithContext(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte, context []byte) (r, s *big.Int, err error) {
+	pkR, err := BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := rs.take(pkR.Fingerprint()); err != nil {
+		return nil, nil, err
+	}
+	return BlindKeySignWithContext(rand, skS, skB, hash, context)
+}
+
+// BlindKeySign is like the package-level BlindKeySign, but returns a
+// *RateLimitError instead of signing if a limit is exceeded.
+func (rs *RateLimitedSigner) BlindKeySign(rand io.Reader, skS *PrivateKey, skB *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	return rs.BlindKeySignWithContext(rand, skS, skB, hash, nil)
+}
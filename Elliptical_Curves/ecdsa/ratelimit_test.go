@@ -0,0 +1,94 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestRateLimitedSigner(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	rs := newRateLimitedSigner(RateLimit{Rate: 10, Burst: 3}, RateLimit{Rate: 1, Burst: 2}, clock.now)
+
+	c := elliptic.P256()
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB1, _ := GenerateKey(c, rand.Reader)
+	skB2, _ := GenerateKey(c, rand.Reader)
+	hashed := testDigest("testing")
+
+	sign := func(skB *PrivateKey) error {
+		_, _, err := rs.BlindKeySign(rand.Reader, skS, skB, hashed)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := sign(skB1); err != nil {
+			t.Fatalf("signature %d refused: %s", i, err)
+		}
+	}
+	err := sign(skB1)
+	var rlErr *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rlErr) || rlErr.Global {
+		t.Fatalf("per-key limit not enforced: %v", err)
+	}
+	if rlErr.RetryAfter != time.Second {
+		t.Errorf("got RetryAfter %s, want 1s", rlErr.RetryAfter)
+	}
+
+	// Another blinded key has its own bucket, but shares the global one.
+	if err := sign(skB2); err != nil {
+		t.Fatalf("signature with another blinded key refused: %s", err)
+	}
+	err = sign(skB2)
+	if !errors.As(err, &rlErr) || !rlErr.Global {
+		t.Fatalf("global limit not enforced: %v", err)
+	}
+
+	clock.t = clock.t.Add(time.Second)
+	if err := sign(skB1); err != nil {
+		t.Errorf("signature refused after refill: %s", err)
+	}
+}
+
+func TestRateLimitedSignerUnlimited(t *testing.T) {
+	rs := NewRateLimitedSigner(RateLimit{}, RateLimit{})
+	c := elliptic.P256()
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	for i := 0; i < 10; i++ {
+		if _, _, err := rs.BlindKeySign(rand.Reader, skS, skB, testDigest("testing")); err != nil {
+			t.Fatalf("signature %d refused: %s", i, err)
+		}
+	}
+}
+
+func TestRateLimitedSignerZeroBurst(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	rs := newRateLimitedSigner(RateLimit{}, RateLimit{Rate: 1}, clock.now)
+	c := elliptic.P256()
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	hashed := testDigest("testing")
+
+	// A zero Burst allows one signature per refill.
+	if _, _, err := rs.BlindKeySign(rand.Reader, skS, skB, hashed); err != nil {
+		t.Fatalf("signature refused with a zero Burst: %s", err)
+	}
+	if _, _, err := rs.BlindKeySign(rand.Reader, skS, skB, hashed); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second signature not refused: %v", err)
+	}
+	clock.t = clock.t.Add(time.Second)
+	if _, _, err := rs.BlindKeySign(rand.Reader, skS, skB, hashed); err != nil {
+		t.Errorf("signature refused after RetryAfter: %s", err)
+	}
+}
@@ -0,0 +1,102 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"sync"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// curveParams are the parameters of a registered curve.
+type curveParams struct {
+	curve elliptic.Curve
+	// hash and L are the hash function and the output length of
+	// hash_to_field used to derive blinds.
+	hash crypto.Hash
+	L    uint
+}
+
+var curveRegistry = struct {
+	sync.RWMutex
+	names  []string
+	params map[string]curveParams
+}{
+	names: []string{
+		"P-256", "P-224", "P-384", "P-521",
+		"brainpoolP256r1", "brainpoolP384r1", "brainpoolP512r1",
+	},
+	params: map[string]curveParams{
+		"P-224":           {elliptic.P224(), crypto.SHA256, 32},
+		"P-256":           {elliptic.P256(), crypto.SHA256, 48},
+		"P-384":           {elliptic.P384(), crypto.SHA384, 72},
+		"P-521":           {elliptic.P521(), crypto.SHA512, 98},
+		"brainpoolP256r1": {brainpool.P256r1(), crypto.SHA256, 48},
+		"brainpoolP384r1": {brainpool.P384r1(), crypto.SHA384, 72},
+		"brainpoolP512r1": {brainpool.P512r1(), crypto.SHA512, 96},
+	},
+}
+
+// RegisterCurve makes the curve c available to the functions of this package
+// that derive values from hashes, such as key blinding, under name, which
+// must be the name of its parameters. Blinds are derived with hash_to_field
+// of RFC 9380 using expand_message_xmd with h, and output length L, which
+// must be at least ceil((ceil(log2(N)) + 128) / 8) bytes for the order N of
+// c to keep the bias of the blinds negligible.
+//
+// c must implement its own arithmetic if it isn't a short Weierstrass curve
+// with a = -3, which the generic implementation of elliptic.CurveParams
+// assumes; this is the case of secp256k1. Public keys on registered curves
+// are checked to be in the subgroup generated by the base point. Curves
+// can't be registered twice, and the curves implemented by this package are
+// registered by default.
+func RegisterCurve(name string, c elliptic.Curve, h crypto.Hash, L uint) error {
+	if c == nil || c.Params() == nil || name == "" || c.Params().Name != name {
+		return wrapError(ErrInvalidCurve, "curve name %q does not match its parameters", name)
+	}
+	if !h.Available() {
+		return wrapError(ErrInvalidCurve, "hash function %v unavailable", h)
+	}
+	if min := uint(c.Params().N.BitLen()+128+7) / 8; L < min {
+		return wrapError(ErrInvalidCurve, "hash_to_field length %d shorter than %d", L, min)
+	}
+
+	curveRegistry.Lock()
+	defer curveRegistry.Unlock()
+	if _, ok := curveRegistry.params[name]; ok {
+		return wrapError(ErrInvalidCurve, "curve %s already registered", name)
+	}
+	curveRegistry.names = append(curveRegistry.names, name)
+	curveRegistry.params[name] = curveParams{c, h, L}
+	return nil
+}
+
+// RegisteredCurves returns the registered curves, in the order they were
+// registered.
+func RegisteredCurves() []elliptic.Curve {
+	curveRegistry.RLock()
+	defer curveRegistry.RUnlock()
+	curves := make([]elliptic.Curve, len(curveRegistry.names))
+	for i, name := range curveRegistry.names {
+		curves[i] = curveRegistry.params[name].curve
+	}
+	return curves
+}
+
+// CurveByName returns the registered curve with the given name.
+func CurveByName(name string) (elliptic.Curve, error) {
+	curveRegistry.RLock()
+	defer curveRegistry.RUnlock()
+	p, ok := curveRegistry.params[name]
+	if !ok {
+		return nil, wrapError(ErrInvalidCurve, "unknown curve %s", name)
+	}
+	return p.curve, nil
+}
+
+func registeredCurve(c elliptic.Curve) (curveParams, bool) {
+	curveRegistry.RLock()
+	defer curveRegistry.RUnlock()
+	p, ok := curveRegistry.params[c.Params().Name]
+	return p, ok
+}
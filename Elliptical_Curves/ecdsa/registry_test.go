@@ -0,0 +1,85 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// testCurve is P-256 under another name, which this package only knows
+// through the registry. It runs every test over all curves as an external
+// curve would.
+type testCurve struct {
+	elliptic.Curve
+	params *elliptic.CurveParams
+}
+
+func (c testCurve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+func newTestCurve() elliptic.Curve {
+	params := *elliptic.P256().Params()
+	params.Name = "testP256"
+	return testCurve{elliptic.P256(), &params}
+}
+
+func init() {
+	if err := RegisterCurve("testP256", newTestCurve(), crypto.SHA256, 48); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterCurve(t *testing.T) {
+	c, err := CurveByName("testP256")
+	if err != nil {
+		t.Fatalf("CurveByName error: %s", err)
+	}
+	curves := RegisteredCurves()
+	if curves[0] != elliptic.P256() || curves[len(curves)-1] != c {
+		t.Errorf("curves not in registration order")
+	}
+
+	// Blinds on the test curve are derived like on P-256, so blinded keys
+	// have the same coordinates. The keys are generated on P-256, since the
+	// test curve is not approved in FIPS mode.
+	p256 := elliptic.P256()
+	skS, err := GenerateKey(p256, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	skB, err := GenerateKey(p256, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	pkR, err := BlindPublicKey(c, &PublicKey{c, skS.X, skS.Y}, &PrivateKey{PublicKey{c, skB.X, skB.Y}, skB.D})
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	want, _ := BlindPublicKey(p256, &skS.PublicKey, skB)
+	if pkR.X.Cmp(want.X) != 0 || pkR.Y.Cmp(want.Y) != 0 {
+		t.Errorf("blinded key on the test curve differs from P-256")
+	}
+
+	for _, test := range []struct {
+		name  string
+		curve elliptic.Curve
+		hash  crypto.Hash
+		L     uint
+	}{
+		{"testP256", newTestCurve(), crypto.SHA256, 48},
+		{"P-256", elliptic.P256(), crypto.SHA256, 48},
+		{"other", newTestCurve(), crypto.SHA256, 48},
+		{"testP256", newTestCurve(), crypto.SHA256, 47},
+		{"testP256", newTestCurve(), crypto.Hash(0), 48},
+	} {
+		if err := RegisterCurve(test.name, test.curve, test.hash, test.L); !errors.Is(err, ErrInvalidCurve) {
+			t.Errorf("RegisterCurve(%s, %s, %v, %d): got %v, want ErrInvalidCurve", test.name, test.curve.Params().Name, test.hash, test.L, err)
+		}
+	}
+	if _, err := CurveByName("secp256k1"); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("CurveByName of an unregistered curve: got %v, want ErrInvalidCurve", err)
+	}
+}
@@ -0,0 +1,117 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// RemoteBlindSigner signs with a blinding of a base key held by a
+// crypto.Signer that can't export it, such as a hardware module or a cloud
+// key management service, as long as the signer signs digests without
+// hashing them again, and converts them to integers as ANSI X9.62 does. It
+// implements crypto.Signer.
+//
+// An ECDSA signature (r, s) of the integer e under the blinded private key
+// skR = b*skS satisfies s = k^-1 * (e + r*b*skS), so it is b times a
+// signature of e*b^-1 under skS. The base signer is therefore asked to sign
+// a digest encoding e*b^-1, and its s is multiplied by the blind b locally,
+// so that the base private key never leaves the signer. The base signer learns
+// the r value of every signature it contributes to, so it can link them to
+// the base key; blinding only hides the base key from verifiers.
+type RemoteBlindSigner struct {
+	base  crypto.Signer
+	blind *big.Int
+	pkR   *PublicKey
+}
+
+// NewRemoteBlindSigner returns a signer for the public key of base, which
+// must be an ECDSA key, blinded by skB and context, as
+// BlindPublicKeyWithContext blinds it.
+func NewRemoteBlindSigner(base crypto.Signer, skB *PrivateKey, context []byte) (*RemoteBlindSigner, error) {
+	var pkS *PublicKey
+	switch pub := base.Public().(type) {
+	case *PublicKey:
+		pkS = pub
+	case *ecdsa.PublicKey:
+		var err error
+		if pkS, err = FromStdPublicKey(pub); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, wrapError(ErrInvalidCurve, "base signer has a %T public key", pub)
+	}
+	c := pkS.Curve
+	if err := ValidatePublicKey(c, pkS); err != nil {
+		return nil, err
+	}
+	blind, err := hashBlind(c, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	X, Y := c.ScalarMult(pkS.X, pkS.Y, blind.Bytes())
+	return &RemoteBlindSigner{base: base, blind: blind, pkR: &PublicKey{c, X, Y}}, nil
+}
+
+// Public returns the blinded public key, as a *crypto/ecdsa.PublicKey so that
+// it can be used with crypto/x509.
+func (rs *RemoteBlindSigner) Public() crypto.PublicKey {
+	return ToStdPublicKey(rs.pkR)
+}
+
+// BlindedKey returns the blinded public key.
+func (rs *RemoteBlindSigner) BlindedKey() *PublicKey {
+	return rs.pkR
+}
+
+// Sign signs digest with the blinded key, and returns the ASN.1 encoded
+// signature. The digest must be at least as long as the curve order, which
+// is the case for the hash functions usually paired with each curve, except
+// SHA-512 on P-521. The digest and opts are passed to the base signer along
+// with rand, and the signature it returns is checked before it is adjusted.
+func (rs *RemoteBlindSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	c := rs.pkR.Curve
+	N := c.Params().N
+	orderBits := N.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(digest) < orderBytes {
+		return nil, wrapError(ErrInvalidDigest, "digest of %d bytes is shorter than the order of %s", len(digest), c.Params().Name)
+	}
+	if opts != nil && opts.HashFunc() != 0 && opts.HashFunc().Size() != len(digest) {
+		return nil, wrapError(ErrInvalidDigest, "digest of %d bytes for %v", len(digest), opts.HashFunc())
+	}
+
+	// Encode e*b^-1 so that the base signer converts it back with
+	// hashToInt: in the leading bits of the leading orderBytes bytes.
+	e := hashToInt(digest, c)
+	e.Mul(e, new(big.Int).ModInverse(rs.blind, N))
+	e.Mod(e, N)
+	e.Lsh(e, uint(orderBytes*8-orderBits))
+	remote := make([]byte, len(digest))
+	e.FillBytes(remote[:orderBytes])
+
+	sig, err := rs.base.Sign(rand, remote, opts)
+	if err != nil {
+		return nil, err
+	}
+	r, s, ok := parseASN1Signature(sig)
+	if !ok {
+		return nil, wrapError(ErrInvalidSignature, "malformed signature from the base signer")
+	}
+	s.Mul(s, rs.blind)
+	s.Mod(s, N)
+	if err := CheckSignature(rs.pkR, digest, r, s); err != nil {
+		return nil, wrapError(ErrInvalidSignature, "base signer returned an invalid signature")
+	}
+
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.Bytes()
+}
@@ -0,0 +1,83 @@
+package ecdsa
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// corruptSigner returns signatures of another digest.
+type corruptSigner struct {
+	crypto.Signer
+}
+
+func (cs corruptSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	other := append([]byte{}, digest...)
+	other[0] ^= 1
+	return cs.Signer.Sign(rand, other, opts)
+}
+
+func TestRemoteBlindSigner(t *testing.T) {
+	for _, tt := range []struct {
+		curve elliptic.Curve
+		hash  crypto.Hash
+	}{
+		{elliptic.P256(), crypto.SHA256},
+		{elliptic.P256(), crypto.SHA512},
+		{elliptic.P384(), crypto.SHA384},
+		{elliptic.P521(), crypto.SHA512},
+	} {
+		skS, _ := GenerateKey(tt.curve, rand.Reader)
+		skB, _ := GenerateKey(tt.curve, rand.Reader)
+		context := []byte("remote")
+		pkR, _ := BlindPublicKeyWithContext(tt.curve, &skS.PublicKey, skB, context)
+		h := tt.hash.New()
+		h.Write([]byte("message"))
+		digest := h.Sum(nil)
+
+		// Base signers of this package and of crypto/ecdsa.
+		for _, base := range []crypto.Signer{skS, ToStdPrivateKey(skS)} {
+			rs, err := NewRemoteBlindSigner(base, skB, context)
+			if err != nil {
+				t.Fatalf("NewRemoteBlindSigner error: %s", err)
+			}
+			if !rs.BlindedKey().Equal(pkR) {
+				t.Errorf("%s: blinded key differs from BlindPublicKeyWithContext", tt.curve.Params().Name)
+			}
+			sig, err := rs.Sign(rand.Reader, digest, tt.hash)
+			if tt.curve == elliptic.P521() {
+				if !errors.Is(err, ErrInvalidDigest) {
+					t.Errorf("P-521 with %v: got %v, want ErrInvalidDigest", tt.hash, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s, %v: Sign error: %s", tt.curve.Params().Name, tt.hash, err)
+			}
+			if !VerifyASN1(pkR, digest, sig) {
+				t.Errorf("%s, %v: signature does not verify under the blinded key", tt.curve.Params().Name, tt.hash)
+			}
+			if !stdecdsa.VerifyASN1(rs.Public().(*stdecdsa.PublicKey), digest, sig) {
+				t.Errorf("%s, %v: crypto/ecdsa does not verify the signature", tt.curve.Params().Name, tt.hash)
+			}
+		}
+	}
+
+	skS, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	skB, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	rs, _ := NewRemoteBlindSigner(corruptSigner{skS}, skB, nil)
+	digest := make([]byte, 32)
+	if _, err := rs.Sign(rand.Reader, digest, crypto.SHA256); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Sign with a faulty base signer: got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := rs.Sign(rand.Reader, digest, crypto.SHA384); !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("Sign with a digest of the wrong size: got %v, want ErrInvalidDigest", err)
+	}
+	if _, err := NewRemoteBlindSigner(skS, &PrivateKey{D: one}, nil); !errors.Is(err, ErrWeakBlind) {
+		t.Errorf("NewRemoteBlindSigner with a weak blind: got %v, want ErrWeakBlind", err)
+	}
+}
@@ -0,0 +1,339 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"github.com/cloudflare/circl/expander"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	ringDST     = "ECDSA Ring Signature"
+	ringBaseDST = "ECDSA Ring Signature Base"
+
+	// maxRingSize is the maximum number of keys in a Ring.
+	maxRingSize = 0xffff
+)
+
+// Ring is a set of public keys, typically blinded keys of issued identities,
+// among which a RingSignature hides its signer.
+type Ring []*PublicKey
+
+// curve returns the curve of the keys of r, or an error if r is empty or its
+// keys are invalid or on different curves.
+func (r Ring) curve() (elliptic.Curve, error) {
+	if len(r) == 0 || len(r) > maxRingSize {
+		return nil, wrapError(ErrInvalidSignature, "ring of %d keys", len(r))
+	}
+	if r[0] == nil {
+		return nil, wrapError(ErrPointNotOnCurve, "missing public key")
+	}
+	c := r[0].Curve
+	for _, pk := range r {
+		if err := ValidatePublicKey(c, pk); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Marshal encodes r as:
+//
+//	struct {
+//	  opaque key<1..2^16-1>;
+//	} RingKey;
+//
+//	RingKey keys<0..2^16-1>;
+//
+// where each key is the compressed point encoding.
+func (r Ring) Marshal() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, pk := range r {
+			pk := pk
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(elliptic.MarshalCompressed(pk.Curve, pk.X, pk.Y))
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+// UnmarshalRing decodes a ring of public keys on the curve c encoded by
+// Ring.Marshal.
+func UnmarshalRing(c elliptic.Curve, data []byte) (Ring, error) {
+	in := cryptobyte.String(data)
+	var keys cryptobyte.String
+	if !in.ReadUint16LengthPrefixed(&keys) || !in.Empty() {
+		return nil, wrapError(ErrInvalidSignature, "malformed ring")
+	}
+	var r Ring
+	for !keys.Empty() {
+		var enc cryptobyte.String
+		if !keys.ReadUint16LengthPrefixed(&enc) {
+			return nil, wrapError(ErrInvalidSignature, "malformed ring")
+		}
+		p, err := NewPoint(c, enc)
+		if err != nil {
+			return nil, err
+		}
+		pk, err := NewPublicKey(p)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, pk)
+	}
+	return r, nil
+}
+
+// RingSignature is a linkable ring signature: it proves that the signer holds
+// the private key of one of the keys of a Ring without revealing which one.
+// Signatures by the same key with the same scope have the same Tag, so they
+// can be linked without identifying the signer.
+type RingSignature struct {
+	// Tag is the linkability tag [x]H(scope), where x is the private key of
+	// the signer.
+	Tag *Point
+	// C is the first challenge of the ring, and S holds one response per
+	// key of the ring.
+	C *Scalar
+	S []*Scalar
+}
+
+// ringBase hashes scope to a point of c whose discrete logarithm is unknown.
+func ringBase(c elliptic.Curve, scope []byte) (*Point, error) {
+	return hashToBase(c, scope, []byte(ringBaseDST))
+}
+
+// hashToBase hashes scope with the domain separation tag dst to a point of c
+// whose discrete logarithm is unknown, by trying successive counters until
+// the hash is the x-coordinate of a point. The scope is public, so the
+// variable running time leaks nothing.
+func hashToBase(c elliptic.Curve, scope, dst []byte) (*Point, error) {
+	h, _, err := blindParams(c)
+	if err != nil {
+		return nil, err
+	}
+	P := c.Params().P
+	size := (P.BitLen() + 7) / 8
+	xmd := expander.NewExpanderMD(h, dst)
+	for ctr := 0; ctr < 256; ctr++ {
+		msg := append(append([]byte{}, scope...), byte(ctr))
+		x := new(big.Int).SetBytes(xmd.Expand(msg, uint(size+16)))
+		x.Mod(x, P)
+		enc := append([]byte{2}, x.FillBytes(make([]byte, size))...)
+		if p, err := NewPoint(c, enc); err == nil {
+			return p, nil
+		}
+	}
+	return nil, wrapError(ErrInvalidCurve, "failed to hash to %s", c.Params().Name)
+}
+
+// ringTranscript returns the prefix of the hashes of the challenges, which
+// binds them to the ring, scope, tag and message.
+func ringTranscript(ring Ring, scope []byte, tag *Point, message []byte) ([]byte, error) {
+	enc, err := ring.Marshal()
+	if err != nil {
+		return nil, wrapError(ErrInvalidSignature, "ring too large")
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(enc)
+	for _, v := range [][]byte{scope, tag.BytesCompressed()} {
+		v := v
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(v)
+		})
+	}
+	b.AddBytes(message)
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidSignature, "scope too long")
+	}
+	return out, nil
+}
+
+// ringChallenge hashes the transcript and the commitments L and R of a ring
+// member to the challenge of the next member:
+//
+//	hash_to_field(L || R || transcript)
+//
+// with the hash-to-field parameters used for blinds on the curve.
+func ringChallenge(c elliptic.Curve, transcript []byte, L, R *Point) (*Scalar, error) {
+	msg := append(append(L.BytesCompressed(), R.BytesCompressed()...), transcript...)
+	return hashToScalar(c, msg, []byte(ringDST))
+}
+
+// ringCommitments returns L = [s]G + [c]P and R = [s]H + [c]I.
+func ringCommitments(s, c *Scalar, P, H, I *Point) (*Point, *Point) {
+	curve := P.Curve()
+	L := NewIdentityPoint(curve).ScalarBaseMult(s)
+	L.Add(L, NewIdentityPoint(curve).ScalarMult(c, P))
+	R := NewIdentityPoint(curve).ScalarMult(s, H)
+	R.Add(R, NewIdentityPoint(curve).ScalarMult(c, I))
+	return L, R
+}
+
+// SignRing signs message with priv as an anonymous member of ring, which must
+// contain the public key of priv. To sign as the holder of a blinded key,
+// priv is the blinded private key returned by BlindPrivateKeyWithContext.
+// The linkability tag of the signature only depends on priv and scope.
+func SignRing(rand io.Reader, priv *PrivateKey, ring Ring, message, scope []byte) (*RingSignature, error) {
+	c, err := ring.curve()
+	if err != nil {
+		return nil, err
+	}
+	if priv.Curve != c {
+		return nil, wrapError(ErrCurveMismatch, "private key is not on %s", c.Params().Name)
+	}
+	x, err := priv.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	pi := -1
+	for i, pk := range ring {
+		if pk.Equal(&priv.PublicKey) {
+			pi = i
+			break
+		}
+	}
+	if pi < 0 {
+		return nil, wrapError(ErrInvalidSignature, "signing key not in the ring")
+	}
+
+	H, err := ringBase(c, scope)
+	if err != nil {
+		return nil, err
+	}
+	tag := NewIdentityPoint(c).ScalarMult(x, H)
+	transcript, err := ringTranscript(ring, scope, tag, message)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(ring)
+	s := make([]*Scalar, n)
+	cs := make([]*Scalar, n)
+	alpha, err := randScalar(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	L := NewIdentityPoint(c).ScalarBaseMult(alpha)
+	R := NewIdentityPoint(c).ScalarMult(alpha, H)
+	for j := 1; j < n; j++ {
+		i := (pi + j) % n
+		if cs[i], err = ringChallenge(c, transcript, L, R); err != nil {
+			return nil, err
+		}
+		if s[i], err = randScalar(c, rand); err != nil {
+			return nil, err
+		}
+		P, _ := ring[i].Point()
+		L, R = ringCommitments(s[i], cs[i], P, H, tag)
+	}
+	if cs[pi], err = ringChallenge(c, transcript, L, R); err != nil {
+		return nil, err
+	}
+	s[pi] = NewScalar(c).Subtract(alpha, NewScalar(c).Multiply(cs[pi], x))
+
+	return &RingSignature{Tag: tag, C: cs[0], S: s}, nil
+}
+
+// VerifyRing checks that sig is a signature of message with scope by the
+// private key of one of the keys of ring.
+func VerifyRing(ring Ring, message, scope []byte, sig *RingSignature) error {
+	c, err := ring.curve()
+	if err != nil {
+		return err
+	}
+	if sig == nil || sig.Tag == nil || sig.C == nil || len(sig.S) != len(ring) {
+		return wrapError(ErrInvalidSignature, "incomplete ring signature")
+	}
+	if sig.Tag.Curve() != c || sig.C.Curve() != c {
+		return wrapError(ErrCurveMismatch, "ring signature is not on %s", c.Params().Name)
+	}
+	if sig.Tag.IsIdentity() == 1 {
+		return wrapError(ErrInvalidSignature, "identity linkability tag")
+	}
+	for _, s := range sig.S {
+		if s == nil || s.Curve() != c {
+			return wrapError(ErrCurveMismatch, "ring signature is not on %s", c.Params().Name)
+		}
+	}
+
+	H, err := ringBase(c, scope)
+	if err != nil {
+		return err
+	}
+	transcript, err := ringTranscript(ring, scope, sig.Tag, message)
+	if err != nil {
+		return err
+	}
+	ci := sig.C
+	for i, pk := range ring {
+		P, _ := pk.Point()
+		L, R := ringCommitments(sig.S[i], ci, P, H, sig.Tag)
+		if ci, err = ringChallenge(c, transcript, L, R); err != nil {
+			return err
+		}
+	}
+	if ci.Equal(sig.C) != 1 {
+		return wrapError(ErrInvalidSignature, "ring signature mismatch")
+	}
+	return nil
+}
+
+// Linked reports whether sig and x were produced by the same private key with
+// the same scope. Both signatures should be verified first.
+func (sig *RingSignature) Linked(x *RingSignature) bool {
+	return sig.Tag.Equal(x.Tag) == 1
+}
+
+// Marshal encodes sig as:
+//
+//	struct {
+//	  opaque tag<1..2^16-1>;
+//	  opaque c[Ns];
+//	  opaque s[Ns][n];
+//	} RingSignature;
+//
+// where tag is the compressed point encoding, Ns is the length of a scalar of
+// the curve, and n is the number of keys of the ring.
+func (sig *RingSignature) Marshal() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sig.Tag.BytesCompressed())
+	})
+	b.AddBytes(sig.C.Bytes())
+	for _, s := range sig.S {
+		b.AddBytes(s.Bytes())
+	}
+	return b.Bytes()
+}
+
+// UnmarshalRingSignature decodes a signature encoded by RingSignature.Marshal
+// for a ring of n keys on the curve c. It does not verify the signature.
+func UnmarshalRingSignature(c elliptic.Curve, n int, data []byte) (*RingSignature, error) {
+	size := scalarSize(c)
+	in := cryptobyte.String(data)
+	var tag cryptobyte.String
+	if !in.ReadUint16LengthPrefixed(&tag) || len(in) != (n+1)*size {
+		return nil, wrapError(ErrInvalidSignature, "malformed ring signature")
+	}
+	p, err := NewPoint(c, tag)
+	if err != nil {
+		return nil, err
+	}
+	sig := &RingSignature{Tag: p, S: make([]*Scalar, n)}
+	if sig.C, err = NewScalar(c).SetBytes(in[:size]); err != nil {
+		return nil, err
+	}
+	for i := range sig.S {
+		off := (i + 1) * size
+		if sig.S[i], err = NewScalar(c).SetBytes(in[off : off+size]); err != nil {
+			return nil, err
+		}
+	}
+	return sig, nil
+}
@@ -0,0 +1,114 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestRingSignature(t *testing.T) {
+	testAllCurves(t, testRingSignature)
+}
+
+func testRingSignature(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	var ring Ring
+	var signer *PrivateKey
+	for i := 0; i < 4; i++ {
+		skB, _ := GenerateKey(c, rand.Reader)
+		skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+		if err != nil {
+			t.Fatalf("BlindPrivateKeyWithContext error: %s", err)
+		}
+		ring = append(ring, &skR.PublicKey)
+		if i == 2 {
+			signer = skR
+		}
+	}
+	message := []byte("testing")
+	scope := []byte("scope")
+
+	sig, err := SignRing(rand.Reader, signer, ring, message, scope)
+	if err != nil {
+		t.Fatalf("SignRing error: %s", err)
+	}
+	if err := VerifyRing(ring, message, scope, sig); err != nil {
+		t.Fatalf("VerifyRing error: %s", err)
+	}
+	if err := VerifyRing(ring, []byte("other"), scope, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyRing of another message: got %v, want ErrInvalidSignature", err)
+	}
+	if err := VerifyRing(ring, message, []byte("other"), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyRing with another scope: got %v, want ErrInvalidSignature", err)
+	}
+	reordered := Ring{ring[1], ring[0], ring[2], ring[3]}
+	if err := VerifyRing(reordered, message, scope, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyRing with another ring: got %v, want ErrInvalidSignature", err)
+	}
+
+	// Signatures by the same key are linked within a scope only.
+	again, _ := SignRing(rand.Reader, signer, ring, []byte("again"), scope)
+	if !sig.Linked(again) {
+		t.Errorf("signatures by the same key are not linked")
+	}
+	elsewhere, _ := SignRing(rand.Reader, signer, ring, message, []byte("other"))
+	if sig.Linked(elsewhere) {
+		t.Errorf("signatures with different scopes are linked")
+	}
+	other, _ := GenerateKey(c, rand.Reader)
+	otherRing := append(Ring{&other.PublicKey}, ring...)
+	byOther, err := SignRing(rand.Reader, other, otherRing, message, scope)
+	if err != nil {
+		t.Fatalf("SignRing error: %s", err)
+	}
+	if sig.Linked(byOther) {
+		t.Errorf("signatures by different keys are linked")
+	}
+
+	if _, err := SignRing(rand.Reader, other, ring, message, scope); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("SignRing with a key outside the ring: got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := SignRing(failingReader{}, signer, ring, message, scope); !errors.Is(err, ErrEntropy) {
+		t.Errorf("SignRing without entropy: got %v, want ErrEntropy", err)
+	}
+
+	encRing, err := ring.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decodedRing, err := UnmarshalRing(c, encRing)
+	if err != nil {
+		t.Fatalf("UnmarshalRing error: %s", err)
+	}
+	enc, err := sig.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decoded, err := UnmarshalRingSignature(c, len(decodedRing), enc)
+	if err != nil {
+		t.Fatalf("UnmarshalRingSignature error: %s", err)
+	}
+	if err := VerifyRing(decodedRing, message, scope, decoded); err != nil {
+		t.Errorf("VerifyRing of a decoded signature error: %s", err)
+	}
+	if _, err := UnmarshalRingSignature(c, len(ring), enc[:len(enc)-1]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("UnmarshalRingSignature of a truncated encoding: got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := UnmarshalRing(c, encRing[:len(encRing)-1]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("UnmarshalRing of a truncated encoding: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestRingCurveMismatch(t *testing.T) {
+	a, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	b, _ := GenerateKey(elliptic.P384(), rand.Reader)
+	ring := Ring{&a.PublicKey, &b.PublicKey}
+	if _, err := SignRing(rand.Reader, a, ring, []byte("testing"), nil); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("SignRing with a mixed ring: got %v, want ErrCurveMismatch", err)
+	}
+	if _, err := SignRing(rand.Reader, a, nil, []byte("testing"), nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("SignRing with an empty ring: got %v, want ErrInvalidSignature", err)
+	}
+}
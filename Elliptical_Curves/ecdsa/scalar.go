@@ -0,0 +1,154 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/subtle"
+	"math/big"
+)
+
+// Scalar is an integer modulo the order N of a curve's base point.
+//
+// Unlike the D field of PrivateKey, a Scalar is always reduced and can only be
+// obtained through constructors that validate their input, so it can't hold
+// a value that is out of range for its curve. The zero value is not usable;
+// use NewScalar instead.
+//
+// A Scalar is a validated wrapper around a big.Int, not a fixed-width field
+// element: its arithmetic uses math/big and is not constant time. A constant
+// time backend, which crypto/elliptic doesn't provide for every curve this
+// package supports, is out of scope.
+type Scalar struct {
+	c elliptic.Curve
+	v *big.Int
+}
+
+// NewScalar returns a new Scalar set to zero for the curve c.
+func NewScalar(c elliptic.Curve) *Scalar {
+	return &Scalar{c: c, v: new(big.Int)}
+}
+
+// scalarSize returns the length in bytes of a canonical scalar encoding for c.
+func scalarSize(c elliptic.Curve) int {
+	return (c.Params().N.BitLen() + 7) / 8
+}
+
+// Curve returns the curve the scalar is defined for.
+func (s *Scalar) Curve() elliptic.Curve {
+	return s.c
+}
+
+// Set sets s = x, and returns s.
+func (s *Scalar) Set(x *Scalar) *Scalar {
+	s.c = x.c
+	s.v = new(big.Int).Set(x.v)
+	return s
+}
+
+// SetBytes sets s to the big-endian value of x, and returns s. If x is not
+// exactly as long as the order of the curve, or if it encodes a value that is
+// not less than the order, SetBytes returns nil and an error, and s is
+// unchanged.
+func (s *Scalar) SetBytes(x []byte) (*Scalar, error) {
+	if len(x) != scalarSize(s.c) {
+		return nil, wrapError(ErrInvalidScalar, "invalid scalar length %d", len(x))
+	}
+	v := new(big.Int).SetBytes(x)
+	if v.Cmp(s.c.Params().N) >= 0 {
+		return nil, wrapError(ErrInvalidScalar, "scalar not reduced modulo the curve order")
+	}
+	s.v = v
+	return s, nil
+}
+
+// SetUniformBytes sets s to the big-endian value of x reduced modulo the order
+// of the curve, and returns s. To keep the bias of the result negligible, x
+// should be at least 128 bits longer than the order.
+func (s *Scalar) SetUniformBytes(x []byte) *Scalar {
+	s.v = new(big.Int).SetBytes(x)
+	s.v.Mod(s.v, s.c.Params().N)
+	return s
+}
+
+// Bytes returns the fixed-length big-endian encoding of s.
+func (s *Scalar) Bytes() []byte {
+	out := make([]byte, scalarSize(s.c))
+	return s.v.FillBytes(out)
+}
+
+// BigInt returns a copy of the value of s as a big.Int, for interoperability
+// with APIs that still take big.Int values.
+func (s *Scalar) BigInt() *big.Int {
+	return new(big.Int).Set(s.v)
+}
+
+// Equal returns 1 if s and t are equal, and 0 otherwise. The encodings are
+// compared with crypto/subtle, but producing them from big.Int values is not
+// constant time.
+func (s *Scalar) Equal(t *Scalar) int {
+	if s.c != t.c {
+		return 0
+	}
+	return subtle.ConstantTimeCompare(s.Bytes(), t.Bytes())
+}
+
+// IsZero returns 1 if s is zero, and 0 otherwise.
+func (s *Scalar) IsZero() int {
+	return s.Equal(NewScalar(s.c))
+}
+
+// Add sets s = x + y mod N, and returns s.
+func (s *Scalar) Add(x, y *Scalar) *Scalar {
+	v := new(big.Int).Add(x.v, y.v)
+	s.c, s.v = x.c, v.Mod(v, x.c.Params().N)
+	return s
+}
+
+// Subtract sets s = x - y mod N, and returns s.
+func (s *Scalar) Subtract(x, y *Scalar) *Scalar {
+	v := new(big.Int).Sub(x.v, y.v)
+	s.c, s.v = x.c, v.Mod(v, x.c.Params().N)
+	return s
+}
+
+// Negate sets s = -x mod N, and returns s.
+func (s *Scalar) Negate(x *Scalar) *Scalar {
+	v := new(big.Int).Neg(x.v)
+	s.c, s.v = x.c, v.Mod(v, x.c.Params().N)
+	return s
+}
+
+// Multiply sets s = x * y mod N, and returns s.
+func (s *Scalar) Multiply(x, y *Scalar) *Scalar {
+	v := new(big.Int).Mul(x.v, y.v)
+	s.c, s.v = x.c, v.Mod(v, x.c.Params().N)
+	return s
+}
+
+// Invert sets s = 1 / x mod N, and returns s. If x is zero, s is set to zero.
+func (s *Scalar) Invert(x *Scalar) *Scalar {
+	N := x.c.Params().N
+	s.c, s.v = x.c, fermatInverse(x.v, N)
+	return s
+}
+
+// NewPrivateKey returns the private key with the secret scalar d, which must
+// not be zero, and computes the corresponding public key.
+func NewPrivateKey(d *Scalar) (*PrivateKey, error) {
+	if d.IsZero() == 1 {
+		return nil, wrapError(ErrInvalidScalar, "private key scalar is zero")
+	}
+	priv := new(PrivateKey)
+	priv.PublicKey.Curve = d.c
+	priv.D = d.BigInt()
+	priv.PublicKey.X, priv.PublicKey.Y = d.c.ScalarBaseMult(d.Bytes())
+	return priv, nil
+}
+
+// Scalar returns the secret scalar of priv. It returns an error if D is not
+// in the range [1, N-1] for the curve of priv.
+func (priv *PrivateKey) Scalar() (*Scalar, error) {
+	if priv.Curve == nil || priv.D == nil || priv.D.Sign() <= 0 || priv.D.Cmp(priv.Curve.Params().N) >= 0 {
+		return nil, wrapError(ErrInvalidScalar, "private key scalar out of range")
+	}
+	return &Scalar{c: priv.Curve, v: new(big.Int).Set(priv.D)}, nil
+}
@@ -0,0 +1,66 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestScalar(t *testing.T) {
+	testAllCurves(t, testScalar)
+}
+
+func testScalar(t *testing.T, c elliptic.Curve) {
+	N := c.Params().N
+	size := scalarSize(c)
+
+	if _, err := NewScalar(c).SetBytes(make([]byte, size+1)); err == nil {
+		t.Errorf("SetBytes accepted an encoding of the wrong length")
+	}
+	if _, err := NewScalar(c).SetBytes(N.FillBytes(make([]byte, size))); err == nil {
+		t.Errorf("SetBytes accepted N")
+	}
+
+	priv, _ := GenerateKey(c, rand.Reader)
+	d, err := priv.Scalar()
+	if err != nil {
+		t.Fatalf("Scalar error: %s", err)
+	}
+	e, err := NewScalar(c).SetBytes(d.Bytes())
+	if err != nil {
+		t.Fatalf("SetBytes error: %s", err)
+	}
+	if d.Equal(e) != 1 {
+		t.Errorf("scalar does not round-trip through Bytes")
+	}
+
+	dInv := NewScalar(c).Invert(d)
+	if one := NewScalar(c).Multiply(d, dInv); one.BigInt().Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("d * 1/d != 1")
+	}
+	if zero := NewScalar(c).Add(d, NewScalar(c).Negate(d)); zero.IsZero() != 1 {
+		t.Errorf("d + -d != 0")
+	}
+	if diff := NewScalar(c).Subtract(d, e); diff.IsZero() != 1 {
+		t.Errorf("d - d != 0")
+	}
+
+	if _, err := NewPrivateKey(NewScalar(c)); err == nil {
+		t.Errorf("NewPrivateKey accepted a zero scalar")
+	}
+	priv2, err := NewPrivateKey(d)
+	if err != nil {
+		t.Fatalf("NewPrivateKey error: %s", err)
+	}
+	if !priv.Equal(priv2) {
+		t.Errorf("NewPrivateKey did not reconstruct the original key")
+	}
+
+	// The blind constructed by the related-key attack can't be turned into
+	// a Scalar once it is out of range.
+	bad := &PrivateKey{PublicKey: PublicKey{Curve: c}, D: new(big.Int).Add(N, big.NewInt(1))}
+	if _, err := bad.Scalar(); err == nil {
+		t.Errorf("Scalar accepted an out of range D")
+	}
+}
@@ -0,0 +1,35 @@
+package ecdsa
+
+import "crypto/elliptic"
+
+// seedDST is the domain separation tag of GenerateKeyFromSeed. It differs from
+// the "ECDSA Key Blind" tag used to derive blinds, so a key generated from a
+// seed is unrelated to any blind derived from the same bytes.
+const seedDST = "ECDSA Key Seed"
+
+// MinSeedSize is the minimum length, in bytes, of a seed accepted by
+// GenerateKeyFromSeed.
+const MinSeedSize = 32
+
+// GenerateKeyFromSeed deterministically derives a key pair on the curve c from
+// seed, which must be at least MinSeedSize bytes of secret, uniformly random
+// data. The same curve and seed always produce the same key.
+//
+// The private key is hash_to_field(seed) as defined in RFC 9380, section 5,
+// using expand_message_xmd with the hash function and output length c uses
+// for blinds and the domain separation tag "ECDSA Key Seed". To derive several
+// keys from a master seed, append a distinct, unambiguous label or path to it
+// for each key.
+func GenerateKeyFromSeed(c elliptic.Curve, seed []byte) (*PrivateKey, error) {
+	if len(seed) < MinSeedSize {
+		return nil, wrapError(ErrInvalidSeed, "seed of %d bytes is shorter than %d", len(seed), MinSeedSize)
+	}
+	k, err := hashToScalar(c, seed, []byte(seedDST))
+	if err != nil {
+		return nil, err
+	}
+	if k.IsZero() == 1 {
+		return nil, wrapError(ErrInvalidSeed, "seed derived to zero")
+	}
+	return NewPrivateKey(k)
+}
@@ -0,0 +1,75 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestGenerateKeyFromSeed(t *testing.T) {
+	testAllCurves(t, testGenerateKeyFromSeed)
+}
+
+func testGenerateKeyFromSeed(t *testing.T, c elliptic.Curve) {
+	seed := make([]byte, MinSeedSize)
+	rand.Read(seed)
+
+	sk1, err := GenerateKeyFromSeed(c, seed)
+	if err != nil {
+		t.Fatalf("GenerateKeyFromSeed error: %s", err)
+	}
+	if sk1.D.Sign() <= 0 || sk1.D.Cmp(c.Params().N) >= 0 {
+		t.Fatalf("derived key out of range")
+	}
+	sk2, _ := GenerateKeyFromSeed(c, seed)
+	if !sk1.Equal(sk2) {
+		t.Errorf("same seed produced different keys")
+	}
+
+	child, _ := GenerateKeyFromSeed(c, append(seed, "child/0"...))
+	if sk1.Equal(child) {
+		t.Errorf("different seeds produced the same key")
+	}
+
+	// The key derived from a seed is unrelated to the blind derived from
+	// the same bytes.
+	bk, _ := CreateKey(c, seed)
+	blind, err := hashBlind(c, bk, nil)
+	if err != nil {
+		t.Fatalf("hashBlind error: %s", err)
+	}
+	if blind.Cmp(sk1.D) == 0 {
+		t.Errorf("seed derivation collides with blind derivation")
+	}
+
+	hashed := testDigest("testing")
+	r, s, err := Sign(rand.Reader, sk1, hashed)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !Verify(&sk2.PublicKey, hashed, r, s) {
+		t.Errorf("Verify failed with the regenerated key")
+	}
+
+	if _, err := GenerateKeyFromSeed(c, seed[:MinSeedSize-1]); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("GenerateKeyFromSeed with a short seed: got %v, want ErrInvalidSeed", err)
+	}
+}
+
+func TestGenerateKeyFromSeedVector(t *testing.T) {
+	seed := make([]byte, MinSeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	sk, err := GenerateKeyFromSeed(elliptic.P256(), seed)
+	if err != nil {
+		t.Fatalf("GenerateKeyFromSeed error: %s", err)
+	}
+	want, _ := hex.DecodeString("16992e17b408df4cd1badb4f80db7b15ca92148976c3aa23860d833fa86261b2")
+	if got := sk.D.FillBytes(make([]byte, 32)); !bytes.Equal(got, want) {
+		t.Errorf("got key %x, want %x", got, want)
+	}
+}
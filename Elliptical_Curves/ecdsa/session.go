@@ -0,0 +1,116 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// SignerSession produces a single signature in two phases: the nonce point R
+// is fixed and published when the session is created, and the signature is
+// only completed once the message is known. Between the two phases, a monitor
+// can inspect Commitment, for example to detect a nonce that was used before
+// or nonces biased by a fault, and abort the session before any signature
+// exposing the key is released.
+//
+// To sign with a blinded key, create the session with the result of
+// BlindPrivateKeyWithContext. A session is single-use: it signs at most one
+// hash, and it forgets its nonce when it completes or is aborted. It is safe
+// for concurrent use.
+type SignerSession struct {
+	mu    sync.Mutex
+	priv  *PrivateKey
+	k     *big.Int
+	nonce *Point
+}
+
+// NewSignerSession starts a signing session for priv, drawing its nonce from
+// rand.
+func NewSignerSession(rand io.Reader, priv *PrivateKey) (*SignerSession, error) {
+	c := priv.Curve
+	if err := fipsCheckCurve(c); err != nil {
+		return nil, err
+	}
+	if _, err := priv.Scalar(); err != nil {
+		return nil, err
+	}
+	N := c.Params().N
+	for {
+		k, err := randFieldElement(c, rand)
+		if err != nil {
+			return nil, err
+		}
+		x, y := c.ScalarBaseMult(k.Bytes())
+		if new(big.Int).Mod(x, N).Sign() != 0 {
+			return &SignerSession{priv: priv, k: k, nonce: &Point{c: c, x: x, y: y}}, nil
+		}
+	}
+}
+
+// Commitment returns the nonce point R of the session. The r value of the
+// signature is the x-coordinate of R reduced modulo the order of the curve.
+func (ss *SignerSession) Commitment() *Point {
+	return NewIdentityPoint(ss.nonce.c).Set(ss.nonce)
+}
+
+// Complete signs hash with the nonce committed to by the session, and closes
+// the session. It returns an error wrapping ErrSessionClosed if the session
+// was already completed or aborted. In the negligible case where the
+// signature would have a zero s value, it returns an error wrapping
+// ErrInvalidSignature, and a new session must be started. A hash rejected in
+// strict mode doesn't close the session.
+func (ss *SignerSession) Complete(hash []byte) (r, s *big.Int, err error) {
+	start := time.Now()
+	if err := strictCheckDigest(hash); err != nil {
+		return nil, nil, err
+	}
+	ss.mu.Lock()
+	k := ss.k
+	ss.k = nil
+	ss.mu.Unlock()
+	if k == nil {
+		return nil, nil, wrapError(ErrSessionClosed, "session already completed or aborted")
+	}
+
+	c := ss.priv.Curve
+	r, s, err = completeSignature(ss.priv, c, ss.nonce, k, hash)
+	k.SetInt64(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	observeSign(c, start)
+	logIssuance(&ss.priv.PublicKey, hash)
+	return r, s, nil
+}
+
+func completeSignature(priv *PrivateKey, c elliptic.Curve, R *Point, k *big.Int, hash []byte) (r, s *big.Int, err error) {
+	N := c.Params().N
+	var kInv *big.Int
+	if in, ok := c.(invertible); ok {
+		kInv = in.Inverse(k)
+	} else {
+		kInv = fermatInverse(k, N)
+	}
+	r = new(big.Int).Mod(R.x, N)
+	s = new(big.Int).Mul(priv.D, r)
+	s.Add(s, hashToInt(hash, c))
+	s.Mul(s, kInv)
+	s.Mod(s, N)
+	if s.Sign() == 0 {
+		return nil, nil, wrapError(ErrInvalidSignature, "signature has a zero s value")
+	}
+	return r, s, nil
+}
+
+// Abort closes the session without signing, and forgets its nonce. It is safe
+// to call Abort after Complete, or more than once.
+func (ss *SignerSession) Abort() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.k != nil {
+		ss.k.SetInt64(0)
+		ss.k = nil
+	}
+}
@@ -0,0 +1,103 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSignerSession(t *testing.T) {
+	testAllCurves(t, testSignerSession)
+}
+
+func testSignerSession(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	skR, err := BlindPrivateKeyWithContext(skS, skB, []byte("context"))
+	if err != nil {
+		t.Fatalf("BlindPrivateKeyWithContext error: %s", err)
+	}
+
+	ss, err := NewSignerSession(rand.Reader, skR)
+	if err != nil {
+		t.Fatalf("NewSignerSession error: %s", err)
+	}
+	R := ss.Commitment()
+	hashed := testDigest("testing")
+	r, s, err := ss.Complete(hashed)
+	if err != nil {
+		t.Fatalf("Complete error: %s", err)
+	}
+	if !Verify(&skR.PublicKey, hashed, r, s) {
+		t.Errorf("Verify failed")
+	}
+	if want := new(big.Int).Mod(R.x, c.Params().N); r.Cmp(want) != 0 {
+		t.Errorf("signature r = %x, want the committed %x", r, want)
+	}
+
+	if _, _, err := ss.Complete(hashed); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("second Complete: got %v, want ErrSessionClosed", err)
+	}
+	ss.Abort()
+
+	ss, err = NewSignerSession(rand.Reader, skR)
+	if err != nil {
+		t.Fatalf("NewSignerSession error: %s", err)
+	}
+	if ss.Commitment().Equal(R) == 1 {
+		t.Errorf("two sessions committed to the same nonce")
+	}
+	ss.Abort()
+	if _, _, err := ss.Complete(hashed); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("Complete after Abort: got %v, want ErrSessionClosed", err)
+	}
+}
+
+// TestSignerSessionMonitor checks that a monitor inspecting commitments can
+// refuse a nonce before a signature is released.
+func TestSignerSessionMonitor(t *testing.T) {
+	priv, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	seen := make(map[string]bool)
+	monitor := func(ss *SignerSession) bool {
+		enc := string(ss.Commitment().BytesCompressed())
+		if seen[enc] {
+			return false
+		}
+		seen[enc] = true
+		return true
+	}
+
+	// A faulty randomness source returns the same nonce twice.
+	for i := 0; i < 2; i++ {
+		ss, err := NewSignerSession(repeatingReader{}, priv)
+		if err != nil {
+			t.Fatalf("NewSignerSession error: %s", err)
+		}
+		if !monitor(ss) {
+			if i == 0 {
+				t.Fatalf("monitor refused a fresh nonce")
+			}
+			ss.Abort()
+			continue
+		}
+		if i == 1 {
+			t.Fatalf("monitor accepted a reused nonce")
+		}
+		if _, _, err := ss.Complete(testDigest("testing")); err != nil {
+			t.Fatalf("Complete error: %s", err)
+		}
+	}
+}
+
+func TestSignerSessionErrors(t *testing.T) {
+	priv, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := NewSignerSession(failingReader{}, priv); !errors.Is(err, ErrEntropy) {
+		t.Errorf("NewSignerSession with a failing reader: got %v, want ErrEntropy", err)
+	}
+	zero := &PrivateKey{PublicKey: priv.PublicKey, D: new(big.Int)}
+	if _, err := NewSignerSession(rand.Reader, zero); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("NewSignerSession with a zero key: got %v, want ErrInvalidScalar", err)
+	}
+}
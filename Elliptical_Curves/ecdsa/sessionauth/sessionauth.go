@@ -0,0 +1,460 @@
+// Package sessionauth implements a challenge-response protocol for
+// unlinkable authentication with blinded keys.
+//
+// A Verifier sends a Challenge with a fresh nonce. The Prover answers with a
+// Response: a public key blinded from its identity key with a new blind, and
+// a signature of the transcript of the session, which binds the name of the
+// verifier, the nonce and the blinded key, by the blinded key. The blind
+// context of the session is derived from the verifier name and the nonce,
+// so every session uses another key, and responses of a prover can't be
+// linked to each other or to its identity key.
+//
+// On its own, a response only proves possession of the private key of the
+// blinded key, which can for example be bound to the channel or tokens of
+// the session. A verifier with enrolled identity keys also requires a proof
+// of descent: an ecdsa.Attestation of the blinded key by the identity key of
+// the prover, for the context of the session. The proof identifies the
+// prover to the verifier, and to anyone else who knows the enrolled keys, so
+// responses carrying proofs should only be sent over a confidential channel
+// to the verifier.
+//
+// Challenges are valid for a replay window, and each can be answered once:
+// a Verifier accepts a response only if it issued its nonce, the window
+// hasn't elapsed, and no response to the nonce was verified before.
+package sessionauth
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+var (
+	// ErrUnsupportedCurve is returned when a curve is not P-256, P-384 or
+	// P-521, or when a key is on another curve than the verifier.
+	ErrUnsupportedCurve = errors.New("sessionauth: unsupported curve")
+
+	// ErrInvalidResponse is returned when a response is malformed, its
+	// signature doesn't verify, or its proof of descent is missing or
+	// doesn't verify under an enrolled key.
+	ErrInvalidResponse = errors.New("sessionauth: invalid response")
+
+	// ErrReplay is returned when a response answers a challenge that the
+	// verifier didn't issue, that expired, or that was already answered.
+	ErrReplay = errors.New("sessionauth: unknown, expired or answered challenge")
+
+	// ErrTooManyChallenges is returned by Verifier.Challenge when the
+	// maximum number of pending challenges is reached.
+	ErrTooManyChallenges = errors.New("sessionauth: too many pending challenges")
+)
+
+// NonceSize is the size of the nonce of a challenge.
+const NonceSize = 32
+
+// DefaultWindow is the replay window of a Verifier created with a zero
+// window.
+const DefaultWindow = 2 * time.Minute
+
+// DefaultMaxPending is the default maximum number of pending challenges of a
+// Verifier.
+const DefaultMaxPending = 1 << 16
+
+const transcriptDST = "pat-go sessionauth v1"
+
+// hashes maps the supported curves to the hash function of transcripts.
+var hashes = map[elliptic.Curve]crypto.Hash{
+	elliptic.P256(): crypto.SHA256,
+	elliptic.P384(): crypto.SHA384,
+	elliptic.P521(): crypto.SHA512,
+}
+
+// Challenge is the first message of a session, from the verifier.
+type Challenge struct {
+	// Verifier is the name of the verifier, which the prover signs.
+	Verifier string
+	// Nonce is the random nonce of the session.
+	Nonce []byte
+	// Expires is the end of the replay window of the challenge. Responses
+	// received after it are rejected.
+	Expires time.Time
+}
+
+// Marshal encodes ch as:
+//
+//	struct {
+//	  opaque verifier<1..2^8-1>;
+//	  opaque nonce[32];
+//	  uint64 expires;
+//	} Challenge;
+//
+// where expires is in seconds since the Unix epoch.
+func (ch *Challenge) Marshal() ([]byte, error) {
+	if len(ch.Verifier) == 0 || len(ch.Verifier) > 0xff || len(ch.Nonce) != NonceSize {
+		return nil, errors.New("sessionauth: malformed challenge")
+	}
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(ch.Verifier))
+	})
+	b.AddBytes(ch.Nonce)
+	var expires [8]byte
+	binary.BigEndian.PutUint64(expires[:], uint64(ch.Expires.Unix()))
+	b.AddBytes(expires[:])
+	return b.Bytes()
+}
+
+// UnmarshalChallenge decodes a challenge encoded by Challenge.Marshal.
+func UnmarshalChallenge(data []byte) (*Challenge, error) {
+	s := cryptobyte.String(data)
+	var verifier cryptobyte.String
+	var nonce []byte
+	var expires uint64
+	if !s.ReadUint8LengthPrefixed(&verifier) || len(verifier) == 0 ||
+		!s.ReadBytes(&nonce, NonceSize) ||
+		!s.ReadUint64(&expires) ||
+		!s.Empty() {
+		return nil, errors.New("sessionauth: malformed challenge")
+	}
+	return &Challenge{
+		Verifier: string(verifier),
+		Nonce:    append([]byte{}, nonce...),
+		Expires:  time.Unix(int64(expires), 0),
+	}, nil
+}
+
+// context returns the blind context of the session of ch:
+//
+//	len(DST) || DST || len(verifier) || verifier || nonce
+//
+// where lengths are 1-byte integers.
+func (ch *Challenge) context() []byte {
+	out := append([]byte{byte(len(transcriptDST))}, transcriptDST...)
+	out = append(out, byte(len(ch.Verifier)))
+	out = append(out, ch.Verifier...)
+	return append(out, ch.Nonce...)
+}
+
+// transcript returns the message signed by the blinded key pkR in the
+// session of ch: its blind context followed by the compressed encoding of
+// pkR.
+func (ch *Challenge) transcript(pkR *ecdsa.PublicKey) []byte {
+	return append(ch.context(), elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y)...)
+}
+
+// Response is the answer of a prover to a challenge.
+type Response struct {
+	// Nonce is the nonce of the challenge.
+	Nonce []byte
+	// BlindedKey is the blinded public key of the session.
+	BlindedKey *ecdsa.PublicKey
+	// Signature is the signature of the transcript by BlindedKey.
+	Signature *ecdsa.Signature
+	// Proof is the proof of descent of BlindedKey from the identity key of
+	// the prover, or nil.
+	Proof *ecdsa.Attestation
+}
+
+func scalarSize(c elliptic.Curve) int {
+	return (c.Params().N.BitLen() + 7) / 8
+}
+
+// Marshal encodes resp as:
+//
+//	struct {
+//	  opaque nonce[32];
+//	  opaque blinded_key<1..2^8-1>;
+//	  opaque r[Ns];
+//	  opaque s[Ns];
+//	  opaque proof<0..2^16-1>;
+//	} Response;
+//
+// where blinded_key is the compressed point encoding, Ns is the length of a
+// scalar of the curve, and proof is the encoding of the attestation, or
+// empty.
+func (resp *Response) Marshal() ([]byte, error) {
+	if len(resp.Nonce) != NonceSize || resp.BlindedKey == nil || resp.Signature == nil {
+		return nil, errors.New("sessionauth: malformed response")
+	}
+	var proof []byte
+	if resp.Proof != nil {
+		var err error
+		if proof, err = resp.Proof.Marshal(); err != nil {
+			return nil, err
+		}
+	}
+	pkR := resp.BlindedKey
+	size := scalarSize(pkR.Curve)
+
+	var b cryptobyte.Builder
+	b.AddBytes(resp.Nonce)
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y))
+	})
+	b.AddBytes(resp.Signature.R.FillBytes(make([]byte, size)))
+	b.AddBytes(resp.Signature.S.FillBytes(make([]byte, size)))
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(proof)
+	})
+	return b.Bytes()
+}
+
+// UnmarshalResponse decodes a response for a verifier on the curve c encoded
+// by Response.Marshal. It does not verify the response.
+func UnmarshalResponse(c elliptic.Curve, data []byte) (*Response, error) {
+	size := scalarSize(c)
+	s := cryptobyte.String(data)
+	var nonce, r, ss []byte
+	var enc, proof cryptobyte.String
+	if !s.ReadBytes(&nonce, NonceSize) ||
+		!s.ReadUint8LengthPrefixed(&enc) ||
+		!s.ReadBytes(&r, size) ||
+		!s.ReadBytes(&ss, size) ||
+		!s.ReadUint16LengthPrefixed(&proof) ||
+		!s.Empty() {
+		return nil, fmt.Errorf("%w: malformed response", ErrInvalidResponse)
+	}
+	p, err := ecdsa.NewPoint(c, enc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	pkR, err := ecdsa.NewPublicKey(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	resp := &Response{
+		Nonce:      append([]byte{}, nonce...),
+		BlindedKey: pkR,
+		Signature:  &ecdsa.Signature{R: new(big.Int).SetBytes(r), S: new(big.Int).SetBytes(ss)},
+	}
+	if len(proof) > 0 {
+		if resp.Proof, err = ecdsa.UnmarshalAttestation(c, proof); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+		}
+	}
+	return resp, nil
+}
+
+// Respond answers ch with a new blinding of identity, drawing the blind from
+// rand. With prove, the response carries a proof of descent from identity,
+// which identifies the prover to the verifier.
+func Respond(rand io.Reader, identity *ecdsa.PrivateKey, ch *Challenge, prove bool) (*Response, error) {
+	if identity == nil {
+		return nil, fmt.Errorf("%w: no identity key", ErrUnsupportedCurve)
+	}
+	c := identity.Curve
+	h, ok := hashes[c]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurve, c.Params().Name)
+	}
+	if len(ch.Nonce) != NonceSize || len(ch.Verifier) == 0 || len(ch.Verifier) > 0xff {
+		return nil, errors.New("sessionauth: malformed challenge")
+	}
+	skB, err := ecdsa.GenerateKey(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	context := ch.context()
+	pkR, err := ecdsa.BlindPublicKeyWithContext(c, &identity.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := ecdsa.BlindKeySignMessage(identity, skB, ch.transcript(pkR), h, context)
+	if err != nil {
+		return nil, err
+	}
+	resp := &Response{
+		Nonce:      append([]byte{}, ch.Nonce...),
+		BlindedKey: pkR,
+		Signature:  &ecdsa.Signature{R: r, S: s},
+	}
+	if prove {
+		if resp.Proof, err = ecdsa.CreateAttestation(rand, identity, skB, 0, context); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Transcript is the record of a verified session.
+type Transcript struct {
+	// Challenge is the challenge of the session.
+	Challenge Challenge
+	// BlindedKey is the blinded key of the prover in the session.
+	BlindedKey *ecdsa.PublicKey
+	// Message is the transcript signed by BlindedKey.
+	Message []byte
+	// Signature is the signature of Message.
+	Signature *ecdsa.Signature
+	// Identity is the enrolled key the prover proved descent from, or nil
+	// for an anonymous session.
+	Identity *ecdsa.PublicKey
+	// Verified is the time the response was verified.
+	Verified time.Time
+}
+
+// Verifier issues challenges and verifies the responses to them. It is safe
+// for concurrent use.
+type Verifier struct {
+	name       string
+	curve      elliptic.Curve
+	hash       crypto.Hash
+	window     time.Duration
+	enrolled   []*ecdsa.PublicKey
+	maxPending int
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]time.Time
+}
+
+// NewVerifier returns a verifier named name for provers on the curve c,
+// whose challenges are valid for window, or DefaultWindow if window is zero.
+// If enrolled keys are given, responses must prove descent from one of them;
+// otherwise sessions are anonymous and proofs are ignored.
+func NewVerifier(c elliptic.Curve, name string, window time.Duration, enrolled ...*ecdsa.PublicKey) (*Verifier, error) {
+	h, ok := hashes[c]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurve, c.Params().Name)
+	}
+	if len(name) == 0 || len(name) > 0xff {
+		return nil, errors.New("sessionauth: verifier name must be 1 to 255 bytes")
+	}
+	if window < 0 {
+		return nil, errors.New("sessionauth: negative replay window")
+	}
+	if window == 0 {
+		window = DefaultWindow
+	}
+	for _, pk := range enrolled {
+		if pk == nil || pk.Curve != c {
+			return nil, fmt.Errorf("%w: enrolled key is not on %s", ErrUnsupportedCurve, c.Params().Name)
+		}
+	}
+	return &Verifier{
+		name:       name,
+		curve:      c,
+		hash:       h,
+		window:     window,
+		enrolled:   append([]*ecdsa.PublicKey{}, enrolled...),
+		maxPending: DefaultMaxPending,
+		Now:        time.Now,
+		pending:    make(map[string]time.Time),
+	}, nil
+}
+
+// SetMaxPending sets the maximum number of challenges that can be pending at
+// once. It defaults to DefaultMaxPending.
+func (v *Verifier) SetMaxPending(n int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	v.maxPending = n
+}
+
+// prune forgets the expired challenges. v.mu must be held.
+func (v *Verifier) prune(now time.Time) {
+	for nonce, expires := range v.pending {
+		if !now.Before(expires) {
+			delete(v.pending, nonce)
+		}
+	}
+}
+
+// Challenge issues a challenge with a nonce drawn from rand. It returns
+// ErrTooManyChallenges if the maximum number of challenges are pending.
+func (v *Verifier) Challenge(rand io.Reader) (*Challenge, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, err
+	}
+	now := v.Now()
+	expires := now.Add(v.window)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.pending) >= v.maxPending {
+		v.prune(now)
+		if len(v.pending) >= v.maxPending {
+			return nil, ErrTooManyChallenges
+		}
+	}
+	v.pending[string(nonce)] = expires
+	return &Challenge{Verifier: v.name, Nonce: nonce, Expires: expires}, nil
+}
+
+// consume removes the pending challenge with nonce, and returns its expiry.
+func (v *Verifier) consume(nonce []byte) (time.Time, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	expires, ok := v.pending[string(nonce)]
+	delete(v.pending, string(nonce))
+	return expires, ok
+}
+
+// Verify checks resp, and returns the transcript of its session. The
+// challenge of resp is consumed even if resp doesn't verify, so that each
+// challenge gets a single attempt. It returns an error wrapping ErrReplay if
+// the challenge is unknown, expired or answered, and ErrInvalidResponse if
+// the response doesn't verify.
+func (v *Verifier) Verify(resp *Response) (*Transcript, error) {
+	if resp == nil || resp.BlindedKey == nil || resp.Signature == nil ||
+		resp.Signature.R == nil || resp.Signature.S == nil {
+		return nil, fmt.Errorf("%w: incomplete response", ErrInvalidResponse)
+	}
+	expires, ok := v.consume(resp.Nonce)
+	now := v.Now()
+	if !ok {
+		return nil, ErrReplay
+	}
+	if !now.Before(expires) {
+		return nil, fmt.Errorf("%w: challenge expired at %v", ErrReplay, expires)
+	}
+
+	pkR := resp.BlindedKey
+	if err := ecdsa.ValidatePublicKey(v.curve, pkR); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	ch := Challenge{Verifier: v.name, Nonce: append([]byte{}, resp.Nonce...), Expires: expires}
+	message := ch.transcript(pkR)
+	if !ecdsa.VerifyMessage(pkR, message, v.hash, resp.Signature.R, resp.Signature.S) {
+		return nil, fmt.Errorf("%w: signature does not verify", ErrInvalidResponse)
+	}
+	t := &Transcript{
+		Challenge:  ch,
+		BlindedKey: pkR,
+		Message:    message,
+		Signature:  resp.Signature,
+		Verified:   now,
+	}
+	if len(v.enrolled) == 0 {
+		return t, nil
+	}
+
+	att := resp.Proof
+	if att == nil {
+		return nil, fmt.Errorf("%w: no proof of descent", ErrInvalidResponse)
+	}
+	if att.Epoch != 0 || string(att.Context) != string(ch.context()) || !att.BlindedKey.Equal(pkR) {
+		return nil, fmt.Errorf("%w: proof is for another key or session", ErrInvalidResponse)
+	}
+	for _, pkS := range v.enrolled {
+		if ecdsa.VerifyAttestation(pkS, att) == nil {
+			t.Identity = pkS
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: proof does not verify under an enrolled key", ErrInvalidResponse)
+}
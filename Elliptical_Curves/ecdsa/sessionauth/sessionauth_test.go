@@ -0,0 +1,159 @@
+package sessionauth
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func TestAnonymousSession(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		identity, _ := ecdsa.GenerateKey(c, rand.Reader)
+		v, err := NewVerifier(c, "example.com", 0)
+		if err != nil {
+			t.Fatalf("NewVerifier(%s) error: %s", c.Params().Name, err)
+		}
+
+		var keys []*ecdsa.PublicKey
+		for i := 0; i < 2; i++ {
+			ch, err := v.Challenge(rand.Reader)
+			if err != nil {
+				t.Fatalf("Challenge error: %s", err)
+			}
+			enc, _ := ch.Marshal()
+			ch, err = UnmarshalChallenge(enc)
+			if err != nil {
+				t.Fatalf("UnmarshalChallenge error: %s", err)
+			}
+			resp, err := Respond(rand.Reader, identity, ch, false)
+			if err != nil {
+				t.Fatalf("Respond error: %s", err)
+			}
+			enc, _ = resp.Marshal()
+			resp, err = UnmarshalResponse(c, enc)
+			if err != nil {
+				t.Fatalf("UnmarshalResponse error: %s", err)
+			}
+			tr, err := v.Verify(resp)
+			if err != nil {
+				t.Fatalf("%s: Verify error: %s", c.Params().Name, err)
+			}
+			if tr.Identity != nil || !tr.BlindedKey.Equal(resp.BlindedKey) || tr.Challenge.Verifier != "example.com" {
+				t.Errorf("%s: transcript %+v does not match the session", c.Params().Name, tr)
+			}
+			if !ecdsa.VerifyMessage(tr.BlindedKey, tr.Message, hashes[c], tr.Signature.R, tr.Signature.S) {
+				t.Errorf("%s: transcript signature does not verify", c.Params().Name)
+			}
+			if _, err := v.Verify(resp); !errors.Is(err, ErrReplay) {
+				t.Errorf("%s: replayed response: got %v, want ErrReplay", c.Params().Name, err)
+			}
+			keys = append(keys, resp.BlindedKey)
+		}
+		if keys[0].Equal(keys[1]) || keys[0].Equal(&identity.PublicKey) {
+			t.Errorf("%s: sessions share a key", c.Params().Name)
+		}
+	}
+}
+
+func TestEnrolledSession(t *testing.T) {
+	c := elliptic.P256()
+	alice, _ := ecdsa.GenerateKey(c, rand.Reader)
+	bob, _ := ecdsa.GenerateKey(c, rand.Reader)
+	mallory, _ := ecdsa.GenerateKey(c, rand.Reader)
+	v, _ := NewVerifier(c, "example.com", time.Minute, &alice.PublicKey, &bob.PublicKey)
+
+	ch, _ := v.Challenge(rand.Reader)
+	resp, _ := Respond(rand.Reader, bob, ch, true)
+	enc, _ := resp.Marshal()
+	resp, err := UnmarshalResponse(c, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalResponse error: %s", err)
+	}
+	tr, err := v.Verify(resp)
+	if err != nil {
+		t.Fatalf("Verify error: %s", err)
+	}
+	if !tr.Identity.Equal(&bob.PublicKey) {
+		t.Errorf("Identity is not the key of the prover")
+	}
+
+	for name, prover := range map[string]func(*Challenge) *Response{
+		"no proof": func(ch *Challenge) *Response {
+			resp, _ := Respond(rand.Reader, alice, ch, false)
+			return resp
+		},
+		"not enrolled": func(ch *Challenge) *Response {
+			resp, _ := Respond(rand.Reader, mallory, ch, true)
+			return resp
+		},
+		"proof of another session": func(ch *Challenge) *Response {
+			other, _ := v.Challenge(rand.Reader)
+			old, _ := Respond(rand.Reader, alice, other, true)
+			resp, _ := Respond(rand.Reader, alice, ch, true)
+			resp.Proof = old.Proof
+			return resp
+		},
+		"other verifier": func(ch *Challenge) *Response {
+			other := *ch
+			other.Verifier = "evil.example"
+			resp, _ := Respond(rand.Reader, alice, &other, true)
+			return resp
+		},
+	} {
+		ch, _ := v.Challenge(rand.Reader)
+		if _, err := v.Verify(prover(ch)); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("%s: got %v, want ErrInvalidResponse", name, err)
+		}
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	c := elliptic.P256()
+	identity, _ := ecdsa.GenerateKey(c, rand.Reader)
+	v, _ := NewVerifier(c, "example.com", time.Minute)
+	now := time.Unix(1767225600, 0)
+	v.Now = func() time.Time { return now }
+
+	ch, _ := v.Challenge(rand.Reader)
+	if !ch.Expires.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expires = %v, want %v", ch.Expires, now.Add(time.Minute))
+	}
+	resp, _ := Respond(rand.Reader, identity, ch, false)
+	now = now.Add(time.Minute)
+	if _, err := v.Verify(resp); !errors.Is(err, ErrReplay) {
+		t.Errorf("expired challenge: got %v, want ErrReplay", err)
+	}
+
+	forged := *ch
+	forged.Nonce = make([]byte, NonceSize)
+	resp, _ = Respond(rand.Reader, identity, &forged, false)
+	if _, err := v.Verify(resp); !errors.Is(err, ErrReplay) {
+		t.Errorf("unknown challenge: got %v, want ErrReplay", err)
+	}
+
+	v.SetMaxPending(2)
+	v.Challenge(rand.Reader)
+	v.Challenge(rand.Reader)
+	if _, err := v.Challenge(rand.Reader); !errors.Is(err, ErrTooManyChallenges) {
+		t.Errorf("third pending challenge: got %v, want ErrTooManyChallenges", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := v.Challenge(rand.Reader); err != nil {
+		t.Errorf("Challenge after the pending ones expired: %s", err)
+	}
+}
+
+func TestUnsupportedCurve(t *testing.T) {
+	if _, err := NewVerifier(brainpool.P256r1(), "example.com", 0); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("NewVerifier(brainpoolP256r1): got %v, want ErrUnsupportedCurve", err)
+	}
+	other, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := NewVerifier(elliptic.P256(), "example.com", 0, &other.PublicKey); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("NewVerifier with a P-384 enrolled key: got %v, want ErrUnsupportedCurve", err)
+	}
+}
@@ -0,0 +1,210 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"math/big"
+)
+
+const (
+	shareDST = "ECDSA Key Share"
+
+	// shareChecksumSize is the size, in bytes, of the checksum that ends a
+	// marshaled share.
+	shareChecksumSize = 8
+)
+
+// Share is a secret share of a private key, produced by SplitPrivateKey. Any
+// Threshold shares of the same key recover it with CombineShares, while fewer
+// shares reveal nothing about it.
+//
+// Shares can back up the base signing key as well as blinding keys, which are
+// private keys of the same curve.
+type Share struct {
+	Curve elliptic.Curve
+	// Index is the non-zero point at which the sharing polynomial was
+	// evaluated. Shares of the same key have distinct indices.
+	Index uint8
+	// Threshold is the number of shares needed to recover the key.
+	Threshold uint8
+	// KeyFingerprint is the Fingerprint of the public key of the shared
+	// private key, which CombineShares checks the recovered key against.
+	KeyFingerprint [sha256.Size]byte
+	// Value is the evaluation of the sharing polynomial at Index.
+	Value *big.Int
+}
+
+// SplitPrivateKey splits sk into n shares using Shamir secret sharing over
+// the order of its curve, such that any t of them recover sk. The random
+// coefficients of the sharing polynomial are read from rand. It requires
+// 1 <= t <= n <= 255.
+func SplitPrivateKey(rand io.Reader, sk *PrivateKey, n, t int) ([]*Share, error) {
+	if t < 1 || n < t || n > 255 {
+		return nil, wrapError(ErrInvalidShare, "invalid threshold %d of %d shares", t, n)
+	}
+	c := sk.Curve
+	N := c.Params().N
+	if sk.D == nil || sk.D.Sign() <= 0 || sk.D.Cmp(N) >= 0 {
+		return nil, wrapError(ErrInvalidScalar, "private key out of range")
+	}
+
+	// f(x) = D + a_1 x + ... + a_{t-1} x^{t-1}
+	coeffs := make([]*big.Int, t)
+	coeffs[0] = sk.D
+	for i := 1; i < t; i++ {
+		a, err := randFieldElement(c, rand)
+		if err != nil {
+			return nil, err
+		}
+		coeffs[i] = a
+	}
+
+	fp := sk.PublicKey.Fingerprint()
+	shares := make([]*Share, n)
+	for i := range shares {
+		x := big.NewInt(int64(i + 1))
+		y := new(big.Int)
+		for j := t - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coeffs[j])
+			y.Mod(y, N)
+		}
+		shares[i] = &Share{
+			Curve:          c,
+			Index:          uint8(i + 1),
+			Threshold:      uint8(t),
+			KeyFingerprint: fp,
+			Value:          y,
+		}
+	}
+	return shares, nil
+}
+
+// CombineShares recovers a private key from at least Threshold of its
+// shares. It returns an error wrapping ErrInvalidShare if the shares are too
+// few, belong to different keys, or don't recover the key they were split
+// from.
+func CombineShares(shares []*Share) (*PrivateKey, error) {
+	if len(shares) == 0 {
+		return nil, wrapError(ErrInvalidShare, "no shares")
+	}
+	first := shares[0]
+	c := first.Curve
+	N := c.Params().N
+	t := int(first.Threshold)
+	if t < 1 {
+		return nil, wrapError(ErrInvalidShare, "invalid threshold")
+	}
+
+	seen := make(map[uint8]bool)
+	for _, s := range shares {
+		if s.Curve != c {
+			return nil, ErrCurveMismatch
+		}
+		if s.Threshold != first.Threshold || s.KeyFingerprint != first.KeyFingerprint {
+			return nil, wrapError(ErrInvalidShare, "shares of different keys")
+		}
+		if s.Index == 0 || seen[s.Index] {
+			return nil, wrapError(ErrInvalidShare, "invalid or repeated share index %d", s.Index)
+		}
+		if s.Value == nil || s.Value.Sign() < 0 || s.Value.Cmp(N) >= 0 {
+			return nil, wrapError(ErrInvalidShare, "share value out of range")
+		}
+		seen[s.Index] = true
+	}
+	if len(shares) < t {
+		return nil, wrapError(ErrInvalidShare, "got %d shares, need %d", len(shares), t)
+	}
+	shares = shares[:t]
+
+	// D = f(0) = sum_i y_i prod_{j != i} x_j / (x_j - x_i)
+	d := new(big.Int)
+	for i, si := range shares {
+		num, den := big.NewInt(1), big.NewInt(1)
+		xi := big.NewInt(int64(si.Index))
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			xj := big.NewInt(int64(sj.Index))
+			num.Mul(num, xj)
+			num.Mod(num, N)
+			den.Mul(den, new(big.Int).Sub(xj, xi))
+			den.Mod(den, N)
+		}
+		l := num.Mul(num, new(big.Int).ModInverse(den, N))
+		l.Mul(l, si.Value)
+		d.Add(d, l)
+		d.Mod(d, N)
+	}
+	if d.Sign() == 0 {
+		return nil, wrapError(ErrInvalidShare, "recovered key does not match the shares")
+	}
+
+	sk := new(PrivateKey)
+	sk.Curve = c
+	sk.D = d
+	sk.X, sk.Y = c.ScalarBaseMult(d.FillBytes(make([]byte, scalarSize(c))))
+	if fp := sk.PublicKey.Fingerprint(); subtle.ConstantTimeCompare(fp[:], first.KeyFingerprint[:]) != 1 {
+		return nil, wrapError(ErrInvalidShare, "recovered key does not match the shares")
+	}
+	return sk, nil
+}
+
+// shareChecksum returns the checksum of a marshaled share body.
+func shareChecksum(body []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(shareDST))
+	h.Write(body)
+	return h.Sum(nil)[:shareChecksumSize]
+}
+
+// Marshal encodes s as:
+//
+//	struct {
+//	  uint8 index;
+//	  uint8 threshold;
+//	  opaque key_fingerprint[32];
+//	  opaque value[Ns];
+//	  opaque checksum[8];
+//	} Share;
+//
+// where Ns is the length of a scalar of the curve, and checksum is the first
+// 8 bytes of SHA-256("ECDSA Key Share" || the preceding fields). The checksum
+// detects accidental corruption of a stored share; the key fingerprint lets
+// CombineShares detect shares that were tampered with.
+func (s *Share) Marshal() []byte {
+	size := scalarSize(s.Curve)
+	out := make([]byte, 0, 2+sha256.Size+size+shareChecksumSize)
+	out = append(out, s.Index, s.Threshold)
+	out = append(out, s.KeyFingerprint[:]...)
+	out = append(out, s.Value.FillBytes(make([]byte, size))...)
+	return append(out, shareChecksum(out)...)
+}
+
+// UnmarshalShare decodes a share of a private key on the curve c encoded by
+// Marshal, and checks its checksum.
+func UnmarshalShare(c elliptic.Curve, data []byte) (*Share, error) {
+	size := scalarSize(c)
+	if len(data) != 2+sha256.Size+size+shareChecksumSize {
+		return nil, wrapError(ErrInvalidShare, "invalid share length %d", len(data))
+	}
+	body, sum := data[:len(data)-shareChecksumSize], data[len(data)-shareChecksumSize:]
+	if subtle.ConstantTimeCompare(sum, shareChecksum(body)) != 1 {
+		return nil, wrapError(ErrInvalidShare, "checksum mismatch")
+	}
+
+	s := &Share{
+		Curve:     c,
+		Index:     body[0],
+		Threshold: body[1],
+		Value:     new(big.Int).SetBytes(body[2+sha256.Size:]),
+	}
+	copy(s.KeyFingerprint[:], body[2:2+sha256.Size])
+	if s.Index == 0 || s.Threshold == 0 || s.Value.Cmp(c.Params().N) >= 0 {
+		return nil, wrapError(ErrInvalidShare, "share out of range")
+	}
+	return s, nil
+}
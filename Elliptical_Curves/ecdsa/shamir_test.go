@@ -0,0 +1,125 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSplitPrivateKey(t *testing.T) {
+	testAllCurves(t, testSplitPrivateKey)
+}
+
+func testSplitPrivateKey(t *testing.T, c elliptic.Curve) {
+	sk, _ := GenerateKey(c, rand.Reader)
+	shares, err := SplitPrivateKey(rand.Reader, sk, 5, 3)
+	if err != nil {
+		t.Fatalf("SplitPrivateKey error: %s", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("got %d shares, want 5", len(shares))
+	}
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var picked []*Share
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		got, err := CombineShares(picked)
+		if err != nil {
+			t.Fatalf("CombineShares(%v) error: %s", subset, err)
+		}
+		if !got.Equal(sk) {
+			t.Errorf("CombineShares(%v) recovered a different key", subset)
+		}
+	}
+
+	if _, err := CombineShares(shares[:2]); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("CombineShares with too few shares: got %v, want ErrInvalidShare", err)
+	}
+	if _, err := CombineShares([]*Share{shares[0], shares[0], shares[1]}); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("CombineShares with a repeated share: got %v, want ErrInvalidShare", err)
+	}
+
+	tampered := *shares[1]
+	tampered.Value = new(big.Int).Add(tampered.Value, one)
+	tampered.Value.Mod(tampered.Value, c.Params().N)
+	if _, err := CombineShares([]*Share{shares[0], &tampered, shares[2]}); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("CombineShares with a tampered share: got %v, want ErrInvalidShare", err)
+	}
+
+	other, _ := GenerateKey(c, rand.Reader)
+	otherShares, err := SplitPrivateKey(rand.Reader, other, 5, 3)
+	if err != nil {
+		t.Fatalf("SplitPrivateKey error: %s", err)
+	}
+	if _, err := CombineShares([]*Share{shares[0], shares[1], otherShares[2]}); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("CombineShares with shares of different keys: got %v, want ErrInvalidShare", err)
+	}
+}
+
+func TestShareMarshal(t *testing.T) {
+	testAllCurves(t, testShareMarshal)
+}
+
+func testShareMarshal(t *testing.T, c elliptic.Curve) {
+	sk, _ := GenerateKey(c, rand.Reader)
+	shares, err := SplitPrivateKey(rand.Reader, sk, 3, 2)
+	if err != nil {
+		t.Fatalf("SplitPrivateKey error: %s", err)
+	}
+
+	var decoded []*Share
+	for _, s := range shares {
+		enc := s.Marshal()
+		d, err := UnmarshalShare(c, enc)
+		if err != nil {
+			t.Fatalf("UnmarshalShare error: %s", err)
+		}
+		if d.Index != s.Index || d.Threshold != s.Threshold || d.KeyFingerprint != s.KeyFingerprint || d.Value.Cmp(s.Value) != 0 {
+			t.Errorf("share %d does not round-trip", s.Index)
+		}
+		decoded = append(decoded, d)
+
+		for i := range enc {
+			corrupted := append([]byte{}, enc...)
+			corrupted[i] ^= 0x01
+			if _, err := UnmarshalShare(c, corrupted); !errors.Is(err, ErrInvalidShare) {
+				t.Fatalf("share with byte %d corrupted: got %v, want ErrInvalidShare", i, err)
+			}
+		}
+		if _, err := UnmarshalShare(c, enc[:len(enc)-1]); !errors.Is(err, ErrInvalidShare) {
+			t.Errorf("truncated share: got %v, want ErrInvalidShare", err)
+		}
+	}
+
+	got, err := CombineShares(decoded[1:])
+	if err != nil {
+		t.Fatalf("CombineShares error: %s", err)
+	}
+	if !got.Equal(sk) {
+		t.Errorf("CombineShares recovered a different key")
+	}
+}
+
+func TestSplitPrivateKeyParameters(t *testing.T) {
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	for _, p := range []struct{ n, t int }{{3, 0}, {2, 3}, {256, 2}} {
+		if _, err := SplitPrivateKey(rand.Reader, sk, p.n, p.t); !errors.Is(err, ErrInvalidShare) {
+			t.Errorf("SplitPrivateKey(%d, %d): got %v, want ErrInvalidShare", p.n, p.t, err)
+		}
+	}
+	if _, err := SplitPrivateKey(failingReader{}, sk, 3, 2); !errors.Is(err, ErrEntropy) {
+		t.Errorf("SplitPrivateKey without entropy: got %v, want ErrEntropy", err)
+	}
+
+	shares, err := SplitPrivateKey(rand.Reader, sk, 1, 1)
+	if err != nil {
+		t.Fatalf("SplitPrivateKey error: %s", err)
+	}
+	if shares[0].Value.Cmp(sk.D) != 0 {
+		t.Errorf("single share is not the key")
+	}
+}
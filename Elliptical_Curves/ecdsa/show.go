@@ -0,0 +1,248 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	showDST     = "ECDSA k-Show Signature"
+	showBaseDST = "ECDSA k-Show Signature Base"
+)
+
+// The functions of this file limit the number of anonymous shows of a
+// credential per scope, typically an origin, to k, as in k-times anonymous
+// authentication: a show is a RingSignature whose linkability tag is
+//
+//	Tag = [x]H(scope, counter)
+//
+// where x is the private key of the signer, usually a blinded key, and
+// counter is in [0, k). The tag is a pseudorandom function of the key and
+// counter, so shows with distinct counters are unlinkable, and the signature
+// proves that the counter is below k without revealing it. A signer that
+// shows k+1 times in a scope must repeat a counter, and therefore a tag,
+// which links the two shows; a ShowLimiter detects it.
+
+// showBases returns H(scope, counter) for each counter in [0, k).
+func showBases(c elliptic.Curve, scope []byte, k int) ([]*Point, error) {
+	if k <= 0 || k > maxRingSize {
+		return nil, wrapError(ErrInvalidSignature, "show limit of %d", k)
+	}
+	bases := make([]*Point, k)
+	for j := range bases {
+		var b cryptobyte.Builder
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(scope)
+		})
+		b.AddUint16(uint16(j))
+		msg, err := b.Bytes()
+		if err != nil {
+			return nil, wrapError(ErrInvalidSignature, "scope too long")
+		}
+		if bases[j], err = hashToBase(c, msg, []byte(showBaseDST)); err != nil {
+			return nil, err
+		}
+	}
+	return bases, nil
+}
+
+// showTranscript returns the prefix of the hashes of the challenges, which
+// binds them to the ring, limit, scope, tag and message.
+func showTranscript(ring Ring, k int, scope []byte, tag *Point, message []byte) ([]byte, error) {
+	enc, err := ring.Marshal()
+	if err != nil {
+		return nil, wrapError(ErrInvalidSignature, "ring too large")
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(enc)
+	b.AddUint16(uint16(k))
+	for _, v := range [][]byte{scope, tag.BytesCompressed()} {
+		v := v
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(v)
+		})
+	}
+	b.AddBytes(message)
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidSignature, "scope too long")
+	}
+	return out, nil
+}
+
+// showChallenge hashes the transcript and the commitments L and R of a ring
+// member and counter to the challenge of the next one, as ringChallenge does
+// with another domain separation tag.
+func showChallenge(c elliptic.Curve, transcript []byte, L, R *Point) (*Scalar, error) {
+	msg := append(append(L.BytesCompressed(), R.BytesCompressed()...), transcript...)
+	return hashToScalar(c, msg, []byte(showDST))
+}
+
+// SignShow signs message with priv as an anonymous member of ring, which must
+// contain the public key of priv, for the counter-th of at most k shows in
+// scope. The signature is a ring over every key of ring and every counter
+// below k, so it has len(ring)*k responses, and signing and verifying take
+// time proportional to that number. The caller must use each counter at most
+// once per scope: shows with the same counter are Linked.
+func SignShow(rand io.Reader, priv *PrivateKey, ring Ring, message, scope []byte, k, counter int) (*RingSignature, error) {
+	c, err := ring.curve()
+	if err != nil {
+		return nil, err
+	}
+	if priv.Curve != c {
+		return nil, wrapError(ErrCurveMismatch, "private key is not on %s", c.Params().Name)
+	}
+	if len(ring)*k > maxRingSize {
+		return nil, wrapError(ErrInvalidSignature, "ring of %d keys with a limit of %d shows", len(ring), k)
+	}
+	if counter < 0 || counter >= k {
+		return nil, wrapError(ErrInvalidSignature, "counter %d is not below the limit of %d shows", counter, k)
+	}
+	x, err := priv.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	signer := -1
+	for i, pk := range ring {
+		if pk.Equal(&priv.PublicKey) {
+			signer = i
+			break
+		}
+	}
+	if signer < 0 {
+		return nil, wrapError(ErrInvalidSignature, "signing key not in the ring")
+	}
+
+	bases, err := showBases(c, scope, k)
+	if err != nil {
+		return nil, err
+	}
+	tag := NewIdentityPoint(c).ScalarMult(x, bases[counter])
+	transcript, err := showTranscript(ring, k, scope, tag, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Member b of the ring is the key b / k with the counter b % k.
+	n := len(ring) * k
+	pi := signer*k + counter
+	s := make([]*Scalar, n)
+	cs := make([]*Scalar, n)
+	alpha, err := randScalar(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	L := NewIdentityPoint(c).ScalarBaseMult(alpha)
+	R := NewIdentityPoint(c).ScalarMult(alpha, bases[counter])
+	for j := 1; j < n; j++ {
+		b := (pi + j) % n
+		if cs[b], err = showChallenge(c, transcript, L, R); err != nil {
+			return nil, err
+		}
+		if s[b], err = randScalar(c, rand); err != nil {
+			return nil, err
+		}
+		P, _ := ring[b/k].Point()
+		L, R = ringCommitments(s[b], cs[b], P, bases[b%k], tag)
+	}
+	if cs[pi], err = showChallenge(c, transcript, L, R); err != nil {
+		return nil, err
+	}
+	s[pi] = NewScalar(c).Subtract(alpha, NewScalar(c).Multiply(cs[pi], x))
+
+	return &RingSignature{Tag: tag, C: cs[0], S: s}, nil
+}
+
+// VerifyShow checks that sig is a signature of message by the private key of
+// one of the keys of ring, for one of at most k shows in scope. Shows over
+// the limit verify, but are Linked to an earlier show.
+func VerifyShow(ring Ring, message, scope []byte, k int, sig *RingSignature) error {
+	c, err := ring.curve()
+	if err != nil {
+		return err
+	}
+	if len(ring)*k > maxRingSize {
+		return wrapError(ErrInvalidSignature, "ring of %d keys with a limit of %d shows", len(ring), k)
+	}
+	if sig == nil || sig.Tag == nil || sig.C == nil || len(sig.S) != len(ring)*k {
+		return wrapError(ErrInvalidSignature, "incomplete show signature")
+	}
+	if sig.Tag.Curve() != c || sig.C.Curve() != c {
+		return wrapError(ErrCurveMismatch, "show signature is not on %s", c.Params().Name)
+	}
+	if sig.Tag.IsIdentity() == 1 {
+		return wrapError(ErrInvalidSignature, "identity linkability tag")
+	}
+	for _, s := range sig.S {
+		if s == nil || s.Curve() != c {
+			return wrapError(ErrCurveMismatch, "show signature is not on %s", c.Params().Name)
+		}
+	}
+
+	bases, err := showBases(c, scope, k)
+	if err != nil {
+		return err
+	}
+	transcript, err := showTranscript(ring, k, scope, sig.Tag, message)
+	if err != nil {
+		return err
+	}
+	cb := sig.C
+	for b, s := range sig.S {
+		P, _ := ring[b/k].Point()
+		L, R := ringCommitments(s, cb, P, bases[b%k], sig.Tag)
+		if cb, err = showChallenge(c, transcript, L, R); err != nil {
+			return err
+		}
+	}
+	if cb.Equal(sig.C) != 1 {
+		return wrapError(ErrInvalidSignature, "show signature mismatch")
+	}
+	return nil
+}
+
+// ShowLimiter records the tags of the verified shows of each scope, and
+// refuses shows whose tag was already seen, which come from a signer that
+// exceeded its limit. It keeps every tag in memory, so scopes should be
+// rotated, for example by including an epoch. It is safe for concurrent use.
+type ShowLimiter struct {
+	mu   sync.Mutex
+	seen map[string]map[string]bool
+}
+
+// NewShowLimiter returns an empty ShowLimiter.
+func NewShowLimiter() *ShowLimiter {
+	return &ShowLimiter{seen: make(map[string]map[string]bool)}
+}
+
+// Record records the tag of sig, a show in scope that was checked with
+// VerifyShow. It returns an error wrapping ErrShowLimit if a show with the
+// same tag was already recorded in scope.
+func (l *ShowLimiter) Record(scope []byte, sig *RingSignature) error {
+	if sig == nil || sig.Tag == nil {
+		return wrapError(ErrInvalidSignature, "incomplete show signature")
+	}
+	tag := string(sig.Tag.BytesCompressed())
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tags, ok := l.seen[string(scope)]
+	if !ok {
+		tags = make(map[string]bool)
+		l.seen[string(scope)] = tags
+	}
+	if tags[tag] {
+		return wrapError(ErrShowLimit, "tag already shown in scope %q", scope)
+	}
+	tags[tag] = true
+	return nil
+}
+
+// Forget drops the tags recorded for scope, once it expired.
+func (l *ShowLimiter) Forget(scope []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.seen, string(scope))
+}
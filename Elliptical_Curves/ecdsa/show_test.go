@@ -0,0 +1,103 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestShow(t *testing.T) {
+	testAllCurves(t, testShow)
+}
+
+func testShow(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	var ring Ring
+	var signer *PrivateKey
+	for i := 0; i < 3; i++ {
+		skB, _ := GenerateKey(c, rand.Reader)
+		skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+		if err != nil {
+			t.Fatalf("BlindPrivateKeyWithContext error: %s", err)
+		}
+		ring = append(ring, &skR.PublicKey)
+		if i == 1 {
+			signer = skR
+		}
+	}
+	scope := []byte("origin.example")
+	const k = 2
+
+	limiter := NewShowLimiter()
+	var shows []*RingSignature
+	for counter := 0; counter < k; counter++ {
+		sig, err := SignShow(rand.Reader, signer, ring, []byte("testing"), scope, k, counter)
+		if err != nil {
+			t.Fatalf("SignShow error: %s", err)
+		}
+		if err := VerifyShow(ring, []byte("testing"), scope, k, sig); err != nil {
+			t.Fatalf("VerifyShow error: %s", err)
+		}
+		if err := limiter.Record(scope, sig); err != nil {
+			t.Errorf("Record of show %d error: %s", counter, err)
+		}
+		shows = append(shows, sig)
+	}
+	if shows[0].Linked(shows[1]) {
+		t.Errorf("shows with different counters are linked")
+	}
+
+	// The show over the limit repeats a counter, and is linked.
+	over, err := SignShow(rand.Reader, signer, ring, []byte("again"), scope, k, 0)
+	if err != nil {
+		t.Fatalf("SignShow error: %s", err)
+	}
+	if err := VerifyShow(ring, []byte("again"), scope, k, over); err != nil {
+		t.Fatalf("VerifyShow error: %s", err)
+	}
+	if !over.Linked(shows[0]) {
+		t.Errorf("shows with the same counter are not linked")
+	}
+	if err := limiter.Record(scope, over); !errors.Is(err, ErrShowLimit) {
+		t.Errorf("Record of a show over the limit: got %v, want ErrShowLimit", err)
+	}
+	if err := limiter.Record([]byte("other.example"), over); err != nil {
+		t.Errorf("Record in another scope error: %s", err)
+	}
+	limiter.Forget(scope)
+	if err := limiter.Record(scope, over); err != nil {
+		t.Errorf("Record after Forget error: %s", err)
+	}
+
+	if err := VerifyShow(ring, []byte("other"), scope, k, over); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyShow of another message: got %v, want ErrInvalidSignature", err)
+	}
+	if err := VerifyShow(ring, []byte("again"), []byte("other.example"), k, over); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyShow with another scope: got %v, want ErrInvalidSignature", err)
+	}
+	if err := VerifyShow(ring, []byte("again"), scope, k+1, over); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyShow with another limit: got %v, want ErrInvalidSignature", err)
+	}
+	// A show is not a ring signature with the same tag.
+	if err := VerifyRing(ring, []byte("again"), scope, over); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyRing of a show: got %v, want ErrInvalidSignature", err)
+	}
+
+	if _, err := SignShow(rand.Reader, signer, ring, []byte("testing"), scope, k, k); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("SignShow with a counter over the limit: got %v, want ErrInvalidSignature", err)
+	}
+
+	enc, err := over.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	decoded, err := UnmarshalRingSignature(c, len(ring)*k, enc)
+	if err != nil {
+		t.Fatalf("UnmarshalRingSignature error: %s", err)
+	}
+	if err := VerifyShow(ring, []byte("again"), scope, k, decoded); err != nil {
+		t.Errorf("VerifyShow of decoded signature error: %s", err)
+	}
+}
@@ -0,0 +1,217 @@
+//go:build sidechannel
+
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// The side-channel tests look for timing leaks of secret keys and blinds,
+// following dudect (Reparaz, Balasch and Verbauwhede, "Dude, is my code
+// constant time?"): an operation is timed on inputs of two classes, a fixed
+// secret and fresh random secrets, interleaved at random, and Welch's t-test
+// checks whether the two timing distributions can be told apart. Run them
+// with:
+//
+//	go test -tags=sidechannel -run TestSideChannel ./ecdsa
+//
+// ECDSA_SIDECHANNEL_MEASUREMENTS sets the number of measurements per
+// operation. Only the NIST curves are tested: the brainpool curves use the
+// generic implementation of crypto/elliptic, which is not constant time.
+const (
+	sidechannelMeasurementsEnvironmentKey = "ECDSA_SIDECHANNEL_MEASUREMENTS"
+
+	defaultSidechannelMeasurements = 20000
+
+	// sidechannelThreshold is the value of |t| above which the classes are
+	// distinguishable. dudect reports a definite leak above 10, and a
+	// potential one above 4.5, which is only logged.
+	sidechannelThreshold        = 10
+	sidechannelWarningThreshold = 4.5
+)
+
+// welchTest accumulates the mean and variance of the timings of each class
+// online.
+type welchTest struct {
+	n, mean, m2 [2]float64
+}
+
+func (w *welchTest) push(class int, x float64) {
+	w.n[class]++
+	d := x - w.mean[class]
+	w.mean[class] += d / w.n[class]
+	w.m2[class] += d * (x - w.mean[class])
+}
+
+// t returns Welch's t statistic of the two classes.
+func (w *welchTest) t() float64 {
+	if w.n[0] < 2 || w.n[1] < 2 {
+		return 0
+	}
+	v0 := w.m2[0] / (w.n[0] - 1)
+	v1 := w.m2[1] / (w.n[1] - 1)
+	if v0 == 0 && v1 == 0 {
+		return 0
+	}
+	return (w.mean[0] - w.mean[1]) / math.Sqrt(v0/w.n[0]+v1/w.n[1])
+}
+
+// maxT returns the largest |t| over the measurements and their crops below
+// several percentiles, which remove the long tail that interrupts and
+// scheduling add to the timings.
+func maxT(classes []int, timings []float64) float64 {
+	sorted := append([]float64{}, timings...)
+	sort.Float64s(sorted)
+	max := 0.0
+	for _, p := range []float64{1, 0.99, 0.9, 0.75, 0.5} {
+		cutoff := sorted[int(p*float64(len(sorted)-1))]
+		var w welchTest
+		for i, x := range timings {
+			if x <= cutoff {
+				w.push(classes[i], x)
+			}
+		}
+		if t := math.Abs(w.t()); t > max {
+			max = t
+		}
+	}
+	return max
+}
+
+func sidechannelMeasurements(t *testing.T) int {
+	n := defaultSidechannelMeasurements
+	if v := os.Getenv(sidechannelMeasurementsEnvironmentKey); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 100 {
+			t.Fatalf("invalid %s: %q", sidechannelMeasurementsEnvironmentKey, v)
+		}
+	}
+	if testing.Short() {
+		n /= 10
+	}
+	return n
+}
+
+// testTiming times the operations returned by prepare for inputs of the
+// fixed class 0 and the random class 1, and fails if their timings are
+// distinguishable. The inputs are prepared before any measurement, so that
+// generating them isn't timed.
+func testTiming(t *testing.T, prepare func(class int) func()) {
+	n := sidechannelMeasurements(t)
+	coins := make([]byte, n)
+	if _, err := rand.Read(coins); err != nil {
+		t.Fatalf("rand.Read error: %s", err)
+	}
+	classes := make([]int, n)
+	ops := make([]func(), n)
+	for i := range ops {
+		classes[i] = int(coins[i] & 1)
+		ops[i] = prepare(classes[i])
+	}
+
+	timings := make([]float64, n)
+	for i, op := range ops {
+		start := time.Now()
+		op()
+		timings[i] = float64(time.Since(start))
+	}
+
+	// Drop the first measurements, taken while caches warm up.
+	warmup := n / 10
+	tt := maxT(classes[warmup:], timings[warmup:])
+	switch {
+	case tt > sidechannelThreshold:
+		t.Errorf("timings of fixed and random secrets are distinguishable: |t| = %.2f", tt)
+	case tt > sidechannelWarningThreshold:
+		t.Logf("timings of fixed and random secrets may be distinguishable: |t| = %.2f", tt)
+	default:
+		t.Logf("|t| = %.2f over %d measurements", tt, n-warmup)
+	}
+}
+
+func testSideChannelCurves(t *testing.T, f func(*testing.T, elliptic.Curve)) {
+	tests := []struct {
+		name  string
+		curve elliptic.Curve
+	}{
+		{"P256", elliptic.P256()},
+		{"P224", elliptic.P224()},
+		{"P384", elliptic.P384()},
+		{"P521", elliptic.P521()},
+	}
+	if testing.Short() {
+		tests = tests[:1]
+	}
+	// The curves are tested one after the other, as concurrent measurements
+	// would add noise to each other.
+	for _, test := range tests {
+		curve := test.curve
+		t.Run(test.name, func(t *testing.T) {
+			f(t, curve)
+		})
+	}
+}
+
+// sidechannelKey returns a fixed private key for the fixed class, or a fresh
+// one for the random class.
+func sidechannelKey(t *testing.T, c elliptic.Curve, fixed *PrivateKey, class int) *PrivateKey {
+	if class == 0 {
+		return fixed
+	}
+	sk, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	return sk
+}
+
+func TestSideChannelBlindPublicKey(t *testing.T) {
+	testSideChannelCurves(t, testSideChannelBlindPublicKey)
+}
+
+func testSideChannelBlindPublicKey(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	fixed, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	testTiming(t, func(class int) func() {
+		skB := sidechannelKey(t, c, fixed, class)
+		return func() {
+			BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+		}
+	})
+}
+
+func TestSideChannelBlindKeySign(t *testing.T) {
+	testSideChannelCurves(t, testSideChannelBlindKeySign)
+}
+
+func testSideChannelBlindKeySign(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	hashed := testDigest("testing")
+	context := []byte("context")
+
+	t.Run("Blind", func(t *testing.T) {
+		testTiming(t, func(class int) func() {
+			skB := sidechannelKey(t, c, skB, class)
+			return func() {
+				BlindKeySignWithContext(rand.Reader, skS, skB, hashed, context)
+			}
+		})
+	})
+	t.Run("SigningKey", func(t *testing.T) {
+		testTiming(t, func(class int) func() {
+			skS := sidechannelKey(t, c, skS, class)
+			return func() {
+				BlindKeySignWithContext(rand.Reader, skS, skB, hashed, context)
+			}
+		})
+	})
+}
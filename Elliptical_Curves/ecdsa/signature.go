@@ -0,0 +1,19 @@
+package ecdsa
+
+import (
+	"math/big"
+)
+
+// Signature is an ECDSA signature, as produced by Sign and BlindKeySign.
+type Signature struct {
+	R, S *big.Int
+}
+
+// Equal reports whether sig and x hold the same values of R and S, leaking
+// only their bit lengths through timing side-channels.
+func (sig *Signature) Equal(x *Signature) bool {
+	if sig == nil || x == nil {
+		return sig == x
+	}
+	return bigIntEqual(sig.R, x.R) && bigIntEqual(sig.S, x.S)
+}
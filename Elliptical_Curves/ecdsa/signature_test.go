@@ -0,0 +1,92 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestSignatureEqual(t *testing.T) {
+	testAllCurves(t, testSignatureEqual)
+}
+
+func testSignatureEqual(t *testing.T, c elliptic.Curve) {
+	priv, _ := GenerateKey(c, rand.Reader)
+
+	hashed := testDigest("testing")
+	r, s, err := Sign(rand.Reader, priv, hashed)
+	if err != nil {
+		t.Fatalf("error signing: %s", err)
+	}
+	sig := &Signature{r, s}
+	if !sig.Equal(&Signature{new(big.Int).Set(r), new(big.Int).Set(s)}) {
+		t.Errorf("signature is not equal to a copy of itself")
+	}
+
+	// Another message, since signatures of the same one are equal in FIPS
+	// mode.
+	r1, s1, err := Sign(rand.Reader, priv, testDigest("testing again"))
+	if err != nil {
+		t.Fatalf("error signing: %s", err)
+	}
+	if sig.Equal(&Signature{r1, s1}) {
+		t.Errorf("different signatures are Equal")
+	}
+	if sig.Equal(&Signature{r, s1}) {
+		t.Errorf("signatures with different S values are Equal")
+	}
+	if sig.Equal(nil) {
+		t.Errorf("signature is Equal to nil")
+	}
+	if sig.Equal(&Signature{r, new(big.Int).Neg(s)}) {
+		t.Errorf("signatures with opposite S values are Equal")
+	}
+	if sig.Equal(&Signature{r, nil}) {
+		t.Errorf("signature is Equal to one with a nil S")
+	}
+}
+
+func TestPrivateKeyEqual(t *testing.T) {
+	priv, err := GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	neg := &PrivateKey{PublicKey: priv.PublicKey, D: new(big.Int).Neg(priv.D)}
+	if priv.Equal(neg) || neg.Equal(priv) {
+		t.Errorf("keys with opposite D values are Equal")
+	}
+	unset := &PrivateKey{PublicKey: priv.PublicKey}
+	if priv.Equal(unset) || unset.Equal(priv) || unset.Equal(unset) {
+		t.Errorf("a key with a nil D is Equal to a key")
+	}
+	if !priv.Equal(&PrivateKey{PublicKey: priv.PublicKey, D: new(big.Int).Set(priv.D)}) {
+		t.Errorf("key is not Equal to a copy of itself")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	testAllCurves(t, testFingerprint)
+}
+
+func testFingerprint(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+
+	pkR, err := BlindPublicKey(c, &skS.PublicKey, skB)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	pkO, err := UnblindPublicKey(c, pkR, skB)
+	if err != nil {
+		t.Fatalf("UnblindPublicKey error: %s", err)
+	}
+
+	seen := map[[32]byte]bool{skS.Fingerprint(): true}
+	if !seen[pkO.Fingerprint()] {
+		t.Errorf("unblinded key has a different fingerprint than the original key")
+	}
+	if seen[pkR.Fingerprint()] {
+		t.Errorf("blinded key has the same fingerprint as the original key")
+	}
+}
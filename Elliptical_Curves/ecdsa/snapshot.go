@@ -0,0 +1,336 @@
+package ecdsa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"sync"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// SnapshotKeySize is the size, in bytes, of the keys that authenticate and
+// encrypt snapshots of signer state.
+const SnapshotKeySize = 32
+
+const (
+	snapshotDST     = "ECDSA Signer Snapshot"
+	snapshotVersion = 1
+	snapshotIDSize  = 16
+
+	snapshotSignerSessions = 1
+	snapshotNonceGuard     = 2
+)
+
+func snapshotAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != SnapshotKeySize {
+		return nil, wrapError(ErrInvalidSnapshot, "key of %d bytes, want %d", len(key), SnapshotKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealSnapshot wraps payload in a snapshot of the given kind:
+//
+//	struct {
+//	    uint8 version = 1;
+//	    uint8 kind;
+//	    opaque nonce[12];
+//	} SnapshotHeader;
+//
+// followed by payload encrypted with AES-256-GCM under key, with the DST,
+// version and kind as additional data, so that the GCM tag authenticates the
+// whole snapshot.
+func sealSnapshot(key []byte, kind uint8, payload []byte) ([]byte, error) {
+	aead, err := snapshotAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(entropySource(nil), nonce); err != nil {
+		return nil, entropyError(err)
+	}
+	header := []byte{snapshotVersion, kind}
+	ad := append([]byte(snapshotDST), header...)
+	return aead.Seal(append(header, nonce...), nonce, payload, ad), nil
+}
+
+// openSnapshot authenticates and decrypts a snapshot of the given kind
+// sealed by sealSnapshot.
+func openSnapshot(key []byte, kind uint8, data []byte) ([]byte, error) {
+	aead, err := snapshotAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2+aead.NonceSize() {
+		return nil, wrapError(ErrInvalidSnapshot, "snapshot too short")
+	}
+	if data[0] != snapshotVersion {
+		return nil, wrapError(ErrInvalidSnapshot, "unsupported version %d", data[0])
+	}
+	if data[1] != kind {
+		return nil, wrapError(ErrInvalidSnapshot, "snapshot of kind %d, want %d", data[1], kind)
+	}
+	nonce, ciphertext := data[2:2+aead.NonceSize()], data[2+aead.NonceSize():]
+	payload, err := aead.Open(nil, nonce, ciphertext, append([]byte(snapshotDST), data[:2]...))
+	if err != nil {
+		return nil, wrapError(ErrInvalidSnapshot, "authentication failed")
+	}
+	return payload, nil
+}
+
+// SnapshotStore records the snapshots of signer sessions that were restored,
+// so that each is restored at most once. Implementations backed by shared
+// storage let every process that may restore a snapshot refuse one that was
+// restored elsewhere.
+type SnapshotStore interface {
+	// Consume records id as restored. It returns an error wrapping
+	// ErrNonceReuse if id was already recorded, and must do the check and
+	// the update atomically.
+	Consume(id []byte) error
+}
+
+// MemorySnapshotStore is a SnapshotStore in memory, which only prevents a
+// snapshot from being restored twice by the same process. It is safe for
+// concurrent use.
+type MemorySnapshotStore struct {
+	mu       sync.Mutex
+	restored map[string]bool
+}
+
+// NewMemorySnapshotStore returns an empty MemorySnapshotStore.
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{restored: make(map[string]bool)}
+}
+
+// Consume records id as restored, and returns an error wrapping
+// ErrNonceReuse if it already was.
+func (m *MemorySnapshotStore) Consume(id []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.restored[string(id)] {
+		return wrapError(ErrNonceReuse, "snapshot %x already restored", id)
+	}
+	m.restored[string(id)] = true
+	return nil
+}
+
+// SnapshotSignerSessions saves the open sessions among sessions, such as a
+// pool of sessions precomputed ahead of a burst of signatures, in a snapshot
+// encrypted and authenticated under key, which must be SnapshotKeySize bytes
+// long. The snapshot holds a random identifier and the nonce of each
+// session, but not its private key, which is given again to
+// RestoreSignerSessions.
+//
+// SnapshotSignerSessions closes the sessions it saves, so that each nonce
+// is held by a single process at a time. Completing two sessions restored
+// from the same snapshot with different hashes would reveal the private key,
+// so RestoreSignerSessions consumes the identifier of the snapshot in a
+// SnapshotStore and refuses to restore it again.
+func SnapshotSignerSessions(key []byte, sessions ...*SignerSession) ([]byte, error) {
+	if _, err := snapshotAEAD(key); err != nil {
+		return nil, err
+	}
+	id := make([]byte, snapshotIDSize)
+	if _, err := io.ReadFull(entropySource(nil), id); err != nil {
+		return nil, entropyError(err)
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(id)
+	var taken []*big.Int
+	for _, ss := range sessions {
+		ss.mu.Lock()
+		k := ss.k
+		ss.k = nil
+		ss.mu.Unlock()
+		if k == nil {
+			continue
+		}
+		taken = append(taken, k)
+		c := ss.priv.Curve
+		fp := ss.priv.PublicKey.Fingerprint()
+		b.AddBytes(fp[:])
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(c.Params().Name))
+		})
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(k.FillBytes(make([]byte, scalarSize(c))))
+		})
+	}
+	defer func() {
+		for _, k := range taken {
+			k.SetInt64(0)
+		}
+	}()
+	payload, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return sealSnapshot(key, snapshotSignerSessions, payload)
+}
+
+// RestoreSignerSessions restores the sessions of a snapshot made by
+// SnapshotSignerSessions with key, in the order they were saved. privs are
+// the private keys of the sessions; it returns an error wrapping
+// ErrInvalidSnapshot if a session is for a key not among them.
+//
+// Before returning the sessions, RestoreSignerSessions consumes the
+// identifier of the snapshot in store, which is required, and returns an
+// error wrapping ErrNonceReuse if the snapshot was already restored. A
+// snapshot that may be restored by several processes needs a store shared by
+// all of them.
+func RestoreSignerSessions(key, data []byte, store SnapshotStore, privs ...*PrivateKey) ([]*SignerSession, error) {
+	if store == nil {
+		return nil, wrapError(ErrInvalidSnapshot, "missing snapshot store")
+	}
+	payload, err := openSnapshot(key, snapshotSignerSessions, data)
+	if err != nil {
+		return nil, err
+	}
+	byFingerprint := make(map[[sha256.Size]byte]*PrivateKey, len(privs))
+	for _, priv := range privs {
+		byFingerprint[priv.PublicKey.Fingerprint()] = priv
+	}
+
+	var id []byte
+	var sessions []*SignerSession
+	s := cryptobyte.String(payload)
+	if !s.ReadBytes(&id, snapshotIDSize) {
+		return nil, wrapError(ErrInvalidSnapshot, "missing snapshot identifier")
+	}
+	for !s.Empty() {
+		var fp []byte
+		var name, k cryptobyte.String
+		if !s.ReadBytes(&fp, sha256.Size) || !s.ReadUint8LengthPrefixed(&name) || !s.ReadUint8LengthPrefixed(&k) {
+			return nil, wrapError(ErrInvalidSnapshot, "malformed session")
+		}
+		var fingerprint [sha256.Size]byte
+		copy(fingerprint[:], fp)
+		priv, ok := byFingerprint[fingerprint]
+		if !ok {
+			return nil, wrapError(ErrInvalidSnapshot, "session for unknown key %x", fingerprint)
+		}
+		c := priv.Curve
+		if c.Params().Name != string(name) || len(k) != scalarSize(c) {
+			return nil, wrapError(ErrInvalidSnapshot, "malformed session")
+		}
+		kk := new(big.Int).SetBytes(k)
+		if kk.Sign() == 0 || kk.Cmp(c.Params().N) >= 0 {
+			return nil, wrapError(ErrInvalidSnapshot, "nonce out of range")
+		}
+		x, y := c.ScalarBaseMult(k)
+		sessions = append(sessions, &SignerSession{priv: priv, k: kk, nonce: &Point{c: c, x: x, y: y}})
+	}
+	if err := store.Consume(id); err != nil {
+		for _, ss := range sessions {
+			ss.Abort()
+		}
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// snapshotEntries returns the size of ms and its entries, from the least to
+// the most recently recorded.
+func (ms *MemoryNonceStore) snapshotEntries() (int, []*nonceEntry) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	entries := make([]*nonceEntry, 0, ms.entries.Len())
+	for el := ms.entries.Back(); el != nil; el = el.Prev() {
+		entries = append(entries, el.Value.(*nonceEntry))
+	}
+	return ms.size, entries
+}
+
+// Snapshot saves the counters of g in a snapshot encrypted and authenticated
+// under key, which must be SnapshotKeySize bytes long, along with the R
+// values of its store if it is a MemoryNonceStore. Stores backed by shared
+// storage persist their own state, and are given again to RestoreNonceGuard.
+func (g *NonceGuard) Snapshot(key []byte) ([]byte, error) {
+	g.mu.Lock()
+	var counters [16]byte
+	binary.BigEndian.PutUint64(counters[:8], g.signatures)
+	binary.BigEndian.PutUint64(counters[8:], g.refused)
+	g.mu.Unlock()
+
+	var b cryptobyte.Builder
+	b.AddBytes(counters[:])
+	ms, ok := g.store.(*MemoryNonceStore)
+	if !ok {
+		b.AddUint8(0)
+	} else {
+		size, entries := ms.snapshotEntries()
+		b.AddUint8(1)
+		b.AddUint32(uint32(size))
+		b.AddUint32(uint32(len(entries)))
+		for _, e := range entries {
+			e := e
+			b.AddBytes(e.fingerprint[:])
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(e.r))
+			})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(e.digest)
+			})
+		}
+	}
+	payload, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return sealSnapshot(key, snapshotNonceGuard, payload)
+}
+
+// RestoreNonceGuard restores a guard from a snapshot made by
+// NonceGuard.Snapshot with key. If the snapshot holds a MemoryNonceStore,
+// the guard records R values in a new MemoryNonceStore with its contents,
+// and store must be nil. Otherwise, store is the store of the guard.
+func RestoreNonceGuard(key, data []byte, store NonceStore) (*NonceGuard, error) {
+	payload, err := openSnapshot(key, snapshotNonceGuard, data)
+	if err != nil {
+		return nil, err
+	}
+	s := cryptobyte.String(payload)
+	var counters []byte
+	var memory uint8
+	if !s.ReadBytes(&counters, 16) || !s.ReadUint8(&memory) {
+		return nil, wrapError(ErrInvalidSnapshot, "malformed nonce guard")
+	}
+	switch {
+	case memory == 1 && store == nil:
+		var size, n uint32
+		if !s.ReadUint32(&size) || !s.ReadUint32(&n) || n > size {
+			return nil, wrapError(ErrInvalidSnapshot, "malformed nonce store")
+		}
+		ms := NewMemoryNonceStore(int(size))
+		for i := uint32(0); i < n; i++ {
+			var fp []byte
+			var r, digest cryptobyte.String
+			if !s.ReadBytes(&fp, sha256.Size) || !s.ReadUint8LengthPrefixed(&r) ||
+				!s.ReadUint16LengthPrefixed(&digest) {
+				return nil, wrapError(ErrInvalidSnapshot, "malformed nonce store")
+			}
+			var fingerprint [sha256.Size]byte
+			copy(fingerprint[:], fp)
+			ms.Record(fingerprint, r, digest)
+		}
+		store = ms
+	case memory == 0 && store != nil:
+	default:
+		return nil, wrapError(ErrInvalidSnapshot, "snapshot and store disagree on the nonce store")
+	}
+	if !s.Empty() {
+		return nil, wrapError(ErrInvalidSnapshot, "trailing data")
+	}
+	return &NonceGuard{
+		store:      store,
+		signatures: binary.BigEndian.Uint64(counters[:8]),
+		refused:    binary.BigEndian.Uint64(counters[8:]),
+	}, nil
+}
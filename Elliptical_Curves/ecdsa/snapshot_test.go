@@ -0,0 +1,146 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestSnapshotSignerSessions(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, SnapshotKeySize)
+	sk1, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	sk2, _ := GenerateKey(elliptic.P384(), rand.Reader)
+	s1, _ := NewSignerSession(rand.Reader, sk1)
+	s2, _ := NewSignerSession(rand.Reader, sk2)
+	s3, _ := NewSignerSession(rand.Reader, sk1)
+	s3.Abort()
+	commitments := []*Point{s1.Commitment(), s2.Commitment()}
+
+	data, err := SnapshotSignerSessions(key, s1, s2, s3)
+	if err != nil {
+		t.Fatalf("SnapshotSignerSessions error: %s", err)
+	}
+	if _, _, err := s1.Complete(testDigest("testing")); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("snapshotted session completed: %v", err)
+	}
+
+	store := NewMemorySnapshotStore()
+	sessions, err := RestoreSignerSessions(key, data, store, sk1, sk2)
+	if err != nil {
+		t.Fatalf("RestoreSignerSessions error: %s", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("restored %d sessions, want 2", len(sessions))
+	}
+	for i, ss := range sessions {
+		if ss.Commitment().Equal(commitments[i]) != 1 {
+			t.Errorf("session %d restored with another nonce", i)
+		}
+		hash := testDigest("testing")
+		r, s, err := ss.Complete(hash)
+		if err != nil {
+			t.Fatalf("Complete error: %s", err)
+		}
+		if !Verify(&ss.priv.PublicKey, hash, r, s) {
+			t.Errorf("signature of restored session %d failed to verify", i)
+		}
+	}
+
+	// A snapshot is restored at most once, since two sessions with the
+	// same nonce would reveal the key.
+	if _, err := RestoreSignerSessions(key, data, store, sk1, sk2); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("restored a snapshot twice: %v", err)
+	}
+	if _, err := RestoreSignerSessions(key, data, nil, sk1, sk2); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored without a snapshot store: %v", err)
+	}
+
+	store = NewMemorySnapshotStore()
+	if _, err := RestoreSignerSessions(key, data, store, sk1); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored without the key of a session: %v", err)
+	}
+	if _, err := RestoreSignerSessions(bytes.Repeat([]byte{2}, SnapshotKeySize), data, store, sk1, sk2); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored with another snapshot key: %v", err)
+	}
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := RestoreSignerSessions(key, tampered, store, sk1, sk2); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored a tampered snapshot: %v", err)
+	}
+	tampered = append([]byte{}, data...)
+	tampered[0]++
+	if _, err := RestoreSignerSessions(key, tampered, store, sk1, sk2); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored a snapshot of another version: %v", err)
+	}
+	if _, err := RestoreNonceGuard(key, data, nil); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored a nonce guard from a session snapshot: %v", err)
+	}
+	if _, err := SnapshotSignerSessions(key[:16]); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("snapshot with a short key: %v", err)
+	}
+	// Failed restores don't consume the snapshot.
+	if _, err := RestoreSignerSessions(key, data, store, sk1, sk2); err != nil {
+		t.Errorf("RestoreSignerSessions after failed restores error: %s", err)
+	}
+}
+
+// sharedNonceStore stands for a store that persists its own state.
+type sharedNonceStore struct {
+	*MemoryNonceStore
+}
+
+func TestSnapshotNonceGuard(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, SnapshotKeySize)
+	c := elliptic.P256()
+	skS, _ := GenerateKey(c, rand.Reader)
+	hash := testDigest("testing")
+
+	g := NewNonceGuard(NewMemoryNonceStore(16))
+	r, s, err := g.Sign(rand.Reader, skS, hash)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	g.check(&skS.PublicKey, []byte("other"), r, s)
+	data, err := g.Snapshot(key)
+	if err != nil {
+		t.Fatalf("Snapshot error: %s", err)
+	}
+
+	if _, err := RestoreNonceGuard(key, data, NewMemoryNonceStore(16)); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored a guard with a memory store into another store: %v", err)
+	}
+	g2, err := RestoreNonceGuard(key, data, nil)
+	if err != nil {
+		t.Fatalf("RestoreNonceGuard error: %s", err)
+	}
+	if g2.Signatures() != 1 || g2.Refused() != 1 {
+		t.Errorf("restored counters %d, %d, want 1, 1", g2.Signatures(), g2.Refused())
+	}
+	if ms := g2.store.(*MemoryNonceStore); ms.Len() != 1 || ms.size != 16 {
+		t.Errorf("restored store of %d entries and size %d, want 1 and 16", ms.Len(), ms.size)
+	}
+	// The nonce recorded before the restart is still caught.
+	if _, _, err := g2.check(&skS.PublicKey, []byte("other"), r, s); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("reused nonce after restore: got %v, want ErrNonceReuse", err)
+	}
+
+	shared := sharedNonceStore{NewMemoryNonceStore(16)}
+	g = NewNonceGuard(shared)
+	g.Sign(rand.Reader, skS, hash)
+	data, err = g.Snapshot(key)
+	if err != nil {
+		t.Fatalf("Snapshot error: %s", err)
+	}
+	if _, err := RestoreNonceGuard(key, data, nil); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("restored a guard with a shared store without the store: %v", err)
+	}
+	g2, err = RestoreNonceGuard(key, data, shared)
+	if err != nil {
+		t.Fatalf("RestoreNonceGuard error: %s", err)
+	}
+	if g2.Signatures() != 1 || g2.store != NonceStore(shared) {
+		t.Errorf("guard with a shared store restored with %d signatures", g2.Signatures())
+	}
+}
@@ -0,0 +1,225 @@
+package ecdsa
+
+import (
+	"crypto"
+	"io"
+	"math/big"
+	"sync"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	spendTagDST        = "ECDSA Key Blind Double-Spend Tag"
+	spendTagMessageDST = "ECDSA Key Blind Tagged Signature"
+)
+
+// The functions of this file let a verifier detect that a blinded key is
+// redeemed twice at the same verifier. Each signature carries the tag
+//
+//	Tag = [x]H(verifier_id)
+//
+// where x is the blinded private key, with a proof that Tag and the blinded
+// public key have the same discrete logarithm: a RingSignature over the ring
+// of the blinded key alone, whose linkability tag is Tag. As with a VRF, the
+// tag of a key at a verifier is unique, so a holder can't pick another one
+// to redeem a key twice, and the tags of a key at different verifiers are
+// unlinkable without the key.
+//
+// A credential is its blinded key: the same base key and blind under another
+// context give another key, and so another tag.
+
+// spendScope returns the scope of the proofs of the tags at verifierID,
+// which separates them from the scopes of SignRing:
+//
+//	DST || len(verifier_id) || verifier_id
+//
+// where the length is a 2-byte big-endian integer.
+func spendScope(verifierID []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddBytes([]byte(spendTagDST))
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(verifierID)
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidSignature, "verifier identifier too long")
+	}
+	return out, nil
+}
+
+// SpendTag returns the double-spend tag of the blinded private key skR at the
+// verifier identified by verifierID, as a compressed point.
+func SpendTag(skR *PrivateKey, verifierID []byte) ([]byte, error) {
+	if skR == nil {
+		return nil, wrapError(ErrInvalidScalar, "missing private key")
+	}
+	x, err := skR.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	scope, err := spendScope(verifierID)
+	if err != nil {
+		return nil, err
+	}
+	H, err := ringBase(skR.Curve, scope)
+	if err != nil {
+		return nil, err
+	}
+	return NewIdentityPoint(skR.Curve).ScalarMult(x, H).BytesCompressed(), nil
+}
+
+// TaggedSignature is a signature by a blinded key together with the proof of
+// its double-spend tag at the verifier it is redeemed at.
+type TaggedSignature struct {
+	Proof *RingSignature
+	R, S  *big.Int
+}
+
+// Tag returns the double-spend tag of sig, as SpendTag does, or nil if sig
+// has no proof.
+func (sig *TaggedSignature) Tag() []byte {
+	if sig == nil || sig.Proof == nil || sig.Proof.Tag == nil {
+		return nil
+	}
+	return sig.Proof.Tag.BytesCompressed()
+}
+
+// taggedMessage returns the message signed for msg with tag at verifierID:
+//
+//	DST || len(verifier_id) || verifier_id || len(tag) || tag || msg
+//
+// where lengths are 2-byte big-endian integers.
+func taggedMessage(verifierID, tag, msg []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddBytes([]byte(spendTagMessageDST))
+	for _, v := range [][]byte{verifierID, tag} {
+		v := v
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(v)
+		})
+	}
+	b.AddBytes(msg)
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, wrapError(ErrInvalidSignature, "verifier identifier too long")
+	}
+	return out, nil
+}
+
+// BlindKeySignTagged signs msg hashed with h with skS blinded by skB and
+// context, as BlindKeySignMessage does, for redemption at the verifier
+// identified by verifierID. The signature and the proof of the tag, whose
+// randomness is read from rand, both cover the verifier identifier and the
+// tag.
+func BlindKeySignTagged(rand io.Reader, skS, skB *PrivateKey, msg []byte, h crypto.Hash, context, verifierID []byte) (*TaggedSignature, error) {
+	skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := SpendTag(skR, verifierID)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := spendScope(verifierID)
+	if err != nil {
+		return nil, err
+	}
+	m, err := taggedMessage(verifierID, tag, msg)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := SignRing(rand, skR, Ring{&skR.PublicKey}, m, scope)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := BlindKeySignMessage(skS, skB, m, h, context)
+	if err != nil {
+		return nil, err
+	}
+	return &TaggedSignature{Proof: proof, R: r, S: s}, nil
+}
+
+// VerifyTagged checks that sig is a signature of msg hashed with h under the
+// blinded key pkR, made by BlindKeySignTagged for the verifier identified by
+// verifierID, and that its tag is the one of pkR at verifierID. It returns an
+// error wrapping ErrInvalidSignature if it isn't. It doesn't check whether
+// the tag was seen before; see Redeem.
+func VerifyTagged(pkR *PublicKey, msg []byte, h crypto.Hash, verifierID []byte, sig *TaggedSignature) error {
+	tag := sig.Tag()
+	if tag == nil {
+		return wrapError(ErrInvalidSignature, "missing double-spend tag proof")
+	}
+	scope, err := spendScope(verifierID)
+	if err != nil {
+		return err
+	}
+	m, err := taggedMessage(verifierID, tag, msg)
+	if err != nil {
+		return err
+	}
+	if err := VerifyRing(Ring{pkR}, m, scope, sig.Proof); err != nil {
+		return err
+	}
+	if !VerifyMessage(pkR, m, h, sig.R, sig.S) {
+		return wrapError(ErrInvalidSignature, "tagged signature does not verify")
+	}
+	return nil
+}
+
+// SpendStore records the double-spend tags redeemed at verifiers.
+// Implementations backed by a shared database let several servers of a
+// verifier detect a credential redeemed at more than one of them.
+type SpendStore interface {
+	// Spend records tag as redeemed at verifierID. It returns an error
+	// wrapping ErrDoubleSpend if tag was already recorded for verifierID,
+	// and must do the check and the update atomically.
+	Spend(verifierID, tag []byte) error
+}
+
+// Redeem verifies sig with VerifyTagged and records its tag in store. It
+// returns an error wrapping ErrDoubleSpend if the credential was already
+// redeemed at verifierID.
+func Redeem(store SpendStore, pkR *PublicKey, msg []byte, h crypto.Hash, verifierID []byte, sig *TaggedSignature) error {
+	if err := VerifyTagged(pkR, msg, h, verifierID, sig); err != nil {
+		return err
+	}
+	return store.Spend(verifierID, sig.Tag())
+}
+
+// MemorySpendStore is a SpendStore that keeps every tag in memory, so
+// verifier identifiers should be rotated, for example by including an epoch.
+// It is safe for concurrent use.
+type MemorySpendStore struct {
+	mu    sync.Mutex
+	spent map[string]map[string]bool
+}
+
+// NewMemorySpendStore returns an empty MemorySpendStore.
+func NewMemorySpendStore() *MemorySpendStore {
+	return &MemorySpendStore{spent: make(map[string]map[string]bool)}
+}
+
+// Spend records tag as redeemed at verifierID, and returns an error wrapping
+// ErrDoubleSpend if it already was.
+func (m *MemorySpendStore) Spend(verifierID, tag []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tags, ok := m.spent[string(verifierID)]
+	if !ok {
+		tags = make(map[string]bool)
+		m.spent[string(verifierID)] = tags
+	}
+	if tags[string(tag)] {
+		return wrapError(ErrDoubleSpend, "tag already redeemed at %q", verifierID)
+	}
+	tags[string(tag)] = true
+	return nil
+}
+
+// Forget drops the tags recorded for verifierID, once it expired.
+func (m *MemorySpendStore) Forget(verifierID []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.spent, string(verifierID))
+}
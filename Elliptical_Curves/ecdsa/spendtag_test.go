@@ -0,0 +1,94 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestSpendTag(t *testing.T) {
+	testAllCurves(t, testSpendTag)
+}
+
+func testSpendTag(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	verifier := []byte("origin.example")
+	msg := []byte("token")
+
+	sig, err := BlindKeySignTagged(rand.Reader, skS, skB, msg, crypto.SHA256, []byte("epoch 1"), verifier)
+	if err != nil {
+		t.Fatalf("BlindKeySignTagged error: %s", err)
+	}
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, []byte("epoch 1"))
+	store := NewMemorySpendStore()
+	if err := Redeem(store, pkR, msg, crypto.SHA256, verifier, sig); err != nil {
+		t.Fatalf("Redeem error: %s", err)
+	}
+	skR, _ := BlindPrivateKeyWithContext(skS, skB, []byte("epoch 1"))
+	if tag, _ := SpendTag(skR, verifier); !bytes.Equal(tag, sig.Tag()) {
+		t.Errorf("tag of the signature differs from SpendTag")
+	}
+
+	// A new signature by the same blinded key has the same tag at the same
+	// verifier, and a different one at another verifier.
+	again, _ := BlindKeySignTagged(rand.Reader, skS, skB, []byte("other token"), crypto.SHA256, []byte("epoch 1"), verifier)
+	if err := Redeem(store, pkR, []byte("other token"), crypto.SHA256, verifier, again); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("second redemption: got %v, want ErrDoubleSpend", err)
+	}
+	elsewhere, _ := BlindKeySignTagged(rand.Reader, skS, skB, msg, crypto.SHA256, []byte("epoch 1"), []byte("other.example"))
+	if bytes.Equal(elsewhere.Tag(), sig.Tag()) {
+		t.Errorf("tags of the same key are equal at two verifiers")
+	}
+	if err := Redeem(store, pkR, msg, crypto.SHA256, []byte("other.example"), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("redemption at another verifier: got %v, want ErrInvalidSignature", err)
+	}
+	if err := Redeem(store, pkR, msg, crypto.SHA256, []byte("other.example"), elsewhere); err != nil {
+		t.Errorf("Redeem at another verifier error: %s", err)
+	}
+
+	// The holder can't choose the tag: a proof with another tag, or by
+	// another key, doesn't verify.
+	other, _ := GenerateKey(c, rand.Reader)
+	scope, _ := spendScope(verifier)
+	forged := *again
+	forged.Proof, _ = SignRing(rand.Reader, other, Ring{&other.PublicKey}, []byte("other token"), scope)
+	if err := Redeem(store, pkR, []byte("other token"), crypto.SHA256, verifier, &forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Redeem with a tag of another key: got %v, want ErrInvalidSignature", err)
+	}
+	swapped := *again
+	proof := *again.Proof
+	proof.Tag = elsewhere.Proof.Tag
+	swapped.Proof = &proof
+	if err := Redeem(store, pkR, []byte("other token"), crypto.SHA256, verifier, &swapped); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Redeem with a replaced tag: got %v, want ErrInvalidSignature", err)
+	}
+	if err := VerifyTagged(pkR, msg, crypto.SHA256, verifier, &TaggedSignature{R: sig.R, S: sig.S}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyTagged without a proof: got %v, want ErrInvalidSignature", err)
+	}
+
+	store.Forget(verifier)
+	if err := store.Spend(verifier, sig.Tag()); err != nil {
+		t.Errorf("Spend after Forget: %s", err)
+	}
+}
+
+func TestSpendTagVector(t *testing.T) {
+	d, _ := NewScalar(elliptic.P256()).SetBytes(bytes.Repeat([]byte{0x03}, 32))
+	skR, _ := NewPrivateKey(d)
+	tag, err := SpendTag(skR, []byte("origin.example"))
+	if err != nil {
+		t.Fatalf("SpendTag error: %s", err)
+	}
+	want, _ := hex.DecodeString("02f37649e2b9129636d0d9ce1c83fb54ba8b2726b9f7862eb0262c274f8c6d1e7b")
+	if !bytes.Equal(tag, want) {
+		t.Errorf("SpendTag = %x, want %x", tag, want)
+	}
+	if _, err := SpendTag(&PrivateKey{PublicKey: PublicKey{Curve: elliptic.P256()}}, nil); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("SpendTag of a key without scalar: got %v, want ErrInvalidScalar", err)
+	}
+}
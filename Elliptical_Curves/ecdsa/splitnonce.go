@@ -0,0 +1,184 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"math/big"
+	"sync/atomic"
+	"time"
+)
+
+const nonceShareCommitmentDST = "ECDSA Nonce Share Commitment"
+
+// NonceShare is one party's share of a split signing nonce. Deployments that
+// don't trust a single component with nonce generation, such as a host
+// signing with a key held by an HSM, can have each side contribute a share,
+// and sign with the nonce k = k1 + k2: the nonce is then uniformly random as
+// long as either share is.
+//
+// To keep either side from choosing its share after seeing the other's, the
+// parties first exchange the commitments returned by Commitment. The external
+// party then reveals its share, with Scalar, and the signer reveals the
+// point of its own share, with Point. The signer checks the external share
+// against its commitment in SignWithNonceShare, and the external party can
+// check with VerifyNonceContribution that the signature was produced with
+// its share.
+//
+// A NonceShare can only be used to sign once.
+type NonceShare struct {
+	k    *Scalar
+	used int32
+}
+
+// GenerateNonceShare returns a random nonce share for the curve c, using
+// entropy from rand.
+func GenerateNonceShare(c elliptic.Curve, rand io.Reader) (*NonceShare, error) {
+	k, err := randFieldElement(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	return &NonceShare{k: &Scalar{c: c, v: k}}, nil
+}
+
+// NewNonceShare returns the nonce share with the value k, which was
+// generated externally. k must not be zero.
+func NewNonceShare(k *Scalar) (*NonceShare, error) {
+	if k.IsZero() == 1 {
+		return nil, wrapError(ErrInvalidScalar, "zero nonce share")
+	}
+	return &NonceShare{k: NewScalar(k.c).Set(k)}, nil
+}
+
+// Scalar returns the value of n, which the external party reveals to the
+// signer once it has received the signer's commitment.
+func (n *NonceShare) Scalar() *Scalar {
+	return NewScalar(n.k.c).Set(n.k)
+}
+
+// Point returns the point k * G of n, which the signer reveals to the
+// external party.
+func (n *NonceShare) Point() *Point {
+	return NewIdentityPoint(n.k.c).ScalarBaseMult(n.k)
+}
+
+// Commitment returns the commitment to n, NonceShareCommitment of its point.
+func (n *NonceShare) Commitment() []byte {
+	return NonceShareCommitment(n.Point())
+}
+
+// NonceShareCommitment returns the commitment to the nonce share with the
+// point p, which is SHA-256 of the domain separation tag "ECDSA Nonce Share
+// Commitment", the name of the curve and the compressed encoding of p, each
+// prefixed by its 2-byte length. Nonce shares are uniformly random, so the
+// commitment hides them.
+func NonceShareCommitment(p *Point) []byte {
+	h := sha256.New()
+	for _, b := range [][]byte{[]byte(nonceShareCommitmentDST), []byte(curveName(p.c)), p.BytesCompressed()} {
+		h.Write([]byte{byte(len(b) >> 8), byte(len(b))})
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// verifyNonceShareCommitment checks that commitment was returned by
+// NonceShareCommitment for p.
+func verifyNonceShareCommitment(commitment []byte, p *Point) error {
+	if subtle.ConstantTimeCompare(commitment, NonceShareCommitment(p)) != 1 {
+		return wrapError(ErrInvalidCommitment, "nonce share does not match its commitment")
+	}
+	return nil
+}
+
+// SignWithNonceShare signs hash with priv, like Sign, with the nonce k = k1 +
+// k2, where k1 is the share external revealed by the external party and k2 is
+// the share internal of the signer. It returns an error wrapping
+// ErrInvalidCommitment if external doesn't match externalCommitment, which
+// must have been received before the signer's commitment was sent, and an
+// error wrapping ErrNonceReuse if internal was already used.
+//
+// In the unlikely event that k yields an invalid signature, SignWithNonceShare
+// returns an error wrapping ErrInvalidScalar, and both parties must start
+// over with new shares. Split nonces are not allowed in FIPS mode, where
+// nonces are derived as specified by RFC 6979.
+func SignWithNonceShare(priv *PrivateKey, hash []byte, internal *NonceShare, external *Scalar, externalCommitment []byte) (r, s *big.Int, err error) {
+	start := time.Now()
+	if FIPSMode() {
+		return nil, nil, wrapError(ErrFIPS, "split nonces are not approved")
+	}
+	c := priv.Curve
+	if internal.k.c != c || external.c != c {
+		return nil, nil, wrapError(ErrCurveMismatch, "%s nonce shares for a %s key", curveName(external.c), curveName(c))
+	}
+	if err := verifyNonceShareCommitment(externalCommitment, NewIdentityPoint(c).ScalarBaseMult(external)); err != nil {
+		return nil, nil, err
+	}
+	if err := strictCheckDigest(hash); err != nil {
+		return nil, nil, err
+	}
+	if !atomic.CompareAndSwapInt32(&internal.used, 0, 1) {
+		return nil, nil, wrapError(ErrNonceReuse, "nonce share already used")
+	}
+
+	k := NewScalar(c).Add(internal.k, external)
+	drawn := false
+	r, s, err = signWithNonces(priv, c, hash, func() (*big.Int, error) {
+		if drawn || k.IsZero() == 1 {
+			return nil, wrapError(ErrInvalidScalar, "nonce shares yield an invalid signature")
+		}
+		drawn = true
+		return k.BigInt(), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	observeSign(c, start)
+	return r, s, nil
+}
+
+// BlindKeySignWithNonceShare is SignWithNonceShare with the signing key skS
+// blinded by skB and context, as in BlindKeySignWithContext.
+func BlindKeySignWithNonceShare(skS, skB *PrivateKey, hash, context []byte, internal *NonceShare, external *Scalar, externalCommitment []byte) (r, s *big.Int, err error) {
+	Db, err := blindPrivateScalar(skS, skB, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := skS.Curve
+	skR := &PrivateKey{PublicKey{Curve: c}, Db}
+	r, s, err = SignWithNonceShare(skR, hash, internal, external, externalCommitment)
+	if err != nil {
+		return nil, nil, err
+	}
+	if issuanceLogging() {
+		skR.X, skR.Y = c.ScalarBaseMult(Db.FillBytes(make([]byte, scalarSize(c))))
+		logIssuance(&skR.PublicKey, hash)
+	}
+	return r, s, nil
+}
+
+// VerifyNonceContribution lets the external party of a split nonce check
+// that the signature with the first half r was produced with its share
+// external and the signer's share with the point internal, after checking
+// that internal matches the commitment internalCommitment the signer sent
+// before the external share was revealed. It returns an error wrapping
+// ErrInvalidCommitment or ErrInvalidSignature otherwise.
+func VerifyNonceContribution(r *big.Int, external *Scalar, internal *Point, internalCommitment []byte) error {
+	c := external.c
+	if internal.c != c {
+		return wrapError(ErrCurveMismatch, "%s nonce share with a %s point", curveName(c), curveName(internal.c))
+	}
+	if err := verifyNonceShareCommitment(internalCommitment, internal); err != nil {
+		return err
+	}
+	R := NewIdentityPoint(c).ScalarBaseMult(external)
+	R.Add(R, internal)
+	if R.IsIdentity() == 1 {
+		return wrapError(ErrInvalidSignature, "nonce shares sum to zero")
+	}
+	x := new(big.Int).Mod(R.x, c.Params().N)
+	if r == nil || x.Cmp(r) != 0 {
+		return wrapError(ErrInvalidSignature, "signature not produced with the nonce share")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestSignWithNonceShare(t *testing.T) {
+	if FIPSMode() {
+		t.Skip("split nonces are not allowed in FIPS mode")
+	}
+	testAllCurves(t, testSignWithNonceShare)
+}
+
+func testSignWithNonceShare(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("context")
+	pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	hash := sha256.Sum256([]byte("message"))
+
+	// Both sides commit to their shares before revealing them.
+	hsm, err := GenerateNonceShare(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateNonceShare error: %s", err)
+	}
+	host, _ := GenerateNonceShare(c, rand.Reader)
+	hsmCommitment, hostCommitment := hsm.Commitment(), host.Commitment()
+
+	r, s, err := BlindKeySignWithNonceShare(skS, skB, hash[:], context, host, hsm.Scalar(), hsmCommitment)
+	if err != nil {
+		t.Fatalf("BlindKeySignWithNonceShare error: %s", err)
+	}
+	if !Verify(pkR, hash[:], r, s) {
+		t.Errorf("signature with a split nonce does not verify")
+	}
+	if err := VerifyNonceContribution(r, hsm.Scalar(), host.Point(), hostCommitment); err != nil {
+		t.Errorf("VerifyNonceContribution error: %s", err)
+	}
+
+	other, _ := GenerateNonceShare(c, rand.Reader)
+	if err := VerifyNonceContribution(r, other.Scalar(), host.Point(), hostCommitment); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyNonceContribution with another share: got %v, want ErrInvalidSignature", err)
+	}
+	if err := VerifyNonceContribution(r, hsm.Scalar(), other.Point(), hostCommitment); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("VerifyNonceContribution with an uncommitted point: got %v, want ErrInvalidCommitment", err)
+	}
+	if _, _, err := BlindKeySignWithNonceShare(skS, skB, hash[:], context, host, hsm.Scalar(), hsmCommitment); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("second signature with a nonce share: got %v, want ErrNonceReuse", err)
+	}
+	fresh, _ := GenerateNonceShare(c, rand.Reader)
+	if _, _, err := SignWithNonceShare(skS, hash[:], fresh, other.Scalar(), hsmCommitment); !errors.Is(err, ErrInvalidCommitment) {
+		t.Errorf("SignWithNonceShare with an uncommitted share: got %v, want ErrInvalidCommitment", err)
+	}
+}
+
+func TestNonceShareErrors(t *testing.T) {
+	if FIPSMode() {
+		t.Skip("split nonces are not allowed in FIPS mode")
+	}
+	sk, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	hash := sha256.Sum256([]byte("message"))
+	if _, err := NewNonceShare(NewScalar(elliptic.P256())); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("NewNonceShare of zero: got %v, want ErrInvalidScalar", err)
+	}
+
+	internal, _ := GenerateNonceShare(elliptic.P256(), rand.Reader)
+	external, _ := GenerateNonceShare(elliptic.P384(), rand.Reader)
+	if _, _, err := SignWithNonceShare(sk, hash[:], internal, external.Scalar(), external.Commitment()); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("SignWithNonceShare with a P-384 share: got %v, want ErrCurveMismatch", err)
+	}
+
+	// Shares that cancel out give no valid nonce.
+	negated, _ := NewNonceShare(NewScalar(elliptic.P256()).Negate(internal.Scalar()))
+	if _, _, err := SignWithNonceShare(sk, hash[:], internal, negated.Scalar(), negated.Commitment()); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("SignWithNonceShare with opposite shares: got %v, want ErrInvalidScalar", err)
+	}
+
+	setFIPSMode(t, true)
+	external, _ = GenerateNonceShare(elliptic.P256(), rand.Reader)
+	if _, _, err := SignWithNonceShare(sk, hash[:], external, external.Scalar(), external.Commitment()); !errors.Is(err, ErrFIPS) {
+		t.Errorf("SignWithNonceShare in FIPS mode: got %v, want ErrFIPS", err)
+	}
+}
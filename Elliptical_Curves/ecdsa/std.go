@@ -0,0 +1,67 @@
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
+)
+
+// isStdCurve reports whether c is one of the NIST curves implemented by
+// crypto/elliptic, for which crypto/ecdsa verifies signatures without going
+// through the big.Int API of crypto/elliptic and its allocations.
+func isStdCurve(c elliptic.Curve) bool {
+	switch c {
+	case elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return true
+	}
+	return false
+}
+
+// FromStdPublicKey converts a crypto/ecdsa public key to a public key of this
+// package. It returns an error if the key is not on its curve.
+func FromStdPublicKey(pub *ecdsa.PublicKey) (*PublicKey, error) {
+	if pub == nil || pub.Curve == nil || pub.X == nil || pub.Y == nil || !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, wrapError(ErrPointNotOnCurve, "invalid crypto/ecdsa public key")
+	}
+	return &PublicKey{pub.Curve, new(big.Int).Set(pub.X), new(big.Int).Set(pub.Y)}, nil
+}
+
+// FromStdPrivateKey converts a crypto/ecdsa private key, such as one parsed by
+// crypto/x509 or loaded by crypto/tls, to a private key of this package. It
+// returns an error if the key is not valid for its curve, or if its public
+// key doesn't match its private key.
+func FromStdPrivateKey(priv *ecdsa.PrivateKey) (*PrivateKey, error) {
+	if priv == nil || priv.D == nil {
+		return nil, wrapError(ErrInvalidScalar, "nil crypto/ecdsa private key")
+	}
+	pub, err := FromStdPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sk := &PrivateKey{*pub, new(big.Int).Set(priv.D)}
+	d, err := sk.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	if check, _ := NewPrivateKey(d); !check.PublicKey.Equal(pub) {
+		return nil, wrapError(ErrInvalidScalar, "private key does not match public key")
+	}
+	return sk, nil
+}
+
+// ToStdPublicKey converts pub to a crypto/ecdsa public key.
+func ToStdPublicKey(pub *PublicKey) *ecdsa.PublicKey {
+	return &ecdsa.PublicKey{
+		Curve: pub.Curve,
+		X:     new(big.Int).Set(pub.X),
+		Y:     new(big.Int).Set(pub.Y),
+	}
+}
+
+// ToStdPrivateKey converts priv to a crypto/ecdsa private key.
+func ToStdPrivateKey(priv *PrivateKey) *ecdsa.PrivateKey {
+	return &ecdsa.PrivateKey{
+		PublicKey: *ToStdPublicKey(&priv.PublicKey),
+		D:         new(big.Int).Set(priv.D),
+	}
+}
@@ -0,0 +1,68 @@
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestStdConversion(t *testing.T) {
+	testAllCurves(t, testStdConversion)
+}
+
+func testStdConversion(t *testing.T, c elliptic.Curve) {
+	std, err := ecdsa.GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := FromStdPrivateKey(std)
+	if err != nil {
+		t.Fatalf("FromStdPrivateKey error: %s", err)
+	}
+	if !std.Equal(ToStdPrivateKey(priv)) {
+		t.Errorf("private key does not round-trip through crypto/ecdsa")
+	}
+
+	hashed := testDigest("testing")
+	r, s, err := Sign(rand.Reader, priv, hashed)
+	if err != nil {
+		t.Fatalf("error signing: %s", err)
+	}
+	if !ecdsa.Verify(ToStdPublicKey(&priv.PublicKey), hashed, r, s) {
+		t.Errorf("crypto/ecdsa rejected a signature of this package")
+	}
+
+	skB, _ := GenerateKey(c, rand.Reader)
+	pkR, err := BlindPublicKey(c, &priv.PublicKey, skB)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	r, s, err = BlindKeySign(rand.Reader, priv, skB, hashed)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	if !ecdsa.Verify(ToStdPublicKey(pkR), hashed, r, s) {
+		t.Errorf("crypto/ecdsa rejected a blinded signature of this package")
+	}
+
+	bad := ToStdPublicKey(&priv.PublicKey)
+	bad.X.Add(bad.X, bad.Y)
+	if _, err := FromStdPublicKey(bad); err == nil {
+		t.Errorf("FromStdPublicKey accepted a point off the curve")
+	}
+}
+
+func TestStdConversionMismatch(t *testing.T) {
+	std, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	std.PublicKey = other.PublicKey
+	if _, err := FromStdPrivateKey(std); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("FromStdPrivateKey with another public key: got %v, want ErrInvalidScalar", err)
+	}
+	std.D = nil
+	if _, err := FromStdPrivateKey(std); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("FromStdPrivateKey without a private key: got %v, want ErrInvalidScalar", err)
+	}
+}
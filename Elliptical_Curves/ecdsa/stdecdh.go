@@ -0,0 +1,161 @@
+//go:build go1.20
+
+package ecdsa
+
+import (
+	"crypto/ecdh"
+	"crypto/elliptic"
+)
+
+// ecdhCurve returns the crypto/ecdh curve that is the same group as c, and
+// false if crypto/ecdh doesn't implement c.
+func ecdhCurve(c elliptic.Curve) (ecdh.Curve, bool) {
+	switch c {
+	case elliptic.P256():
+		return ecdh.P256(), true
+	case elliptic.P384():
+		return ecdh.P384(), true
+	case elliptic.P521():
+		return ecdh.P521(), true
+	}
+	return nil, false
+}
+
+// ellipticCurve returns the crypto/elliptic curve that is the same group as
+// the crypto/ecdh curve c, and false for X25519, which has no Weierstrass
+// form in crypto/elliptic.
+func ellipticCurve(c ecdh.Curve) (elliptic.Curve, bool) {
+	switch c {
+	case ecdh.P256():
+		return elliptic.P256(), true
+	case ecdh.P384():
+		return elliptic.P384(), true
+	case ecdh.P521():
+		return elliptic.P521(), true
+	}
+	return nil, false
+}
+
+// ToECDHPublicKey converts pub to a crypto/ecdh public key. It returns an
+// error wrapping ErrInvalidCurve if pub is not on P-256, P-384 or P-521, the
+// curves crypto/ecdh implements, and an error if pub is not a valid point.
+func ToECDHPublicKey(pub *PublicKey) (*ecdh.PublicKey, error) {
+	if pub == nil {
+		return nil, wrapError(ErrPointNotOnCurve, "nil public key")
+	}
+	c, ok := ecdhCurve(pub.Curve)
+	if !ok {
+		return nil, wrapError(ErrInvalidCurve, "crypto/ecdh does not implement %s", curveName(pub.Curve))
+	}
+	p, err := pub.Point()
+	if err != nil {
+		return nil, err
+	}
+	k, err := c.NewPublicKey(p.Bytes())
+	if err != nil {
+		return nil, wrapError(ErrPointNotOnCurve, "%s", err)
+	}
+	return k, nil
+}
+
+// FromECDHPublicKey converts a crypto/ecdh public key to a public key of
+// this package. It returns an error wrapping ErrInvalidCurve for X25519
+// keys, which can't be used for ECDSA.
+func FromECDHPublicKey(pub *ecdh.PublicKey) (*PublicKey, error) {
+	if pub == nil {
+		return nil, wrapError(ErrPointNotOnCurve, "nil crypto/ecdh public key")
+	}
+	c, ok := ellipticCurve(pub.Curve())
+	if !ok {
+		return nil, wrapError(ErrInvalidCurve, "crypto/ecdh curve %v has no ECDSA equivalent", pub.Curve())
+	}
+	p, err := NewPoint(c, pub.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKey(p)
+}
+
+// ToECDHPrivateKey converts priv to a crypto/ecdh private key, which can run
+// key agreements with crypto/ecdh and crypto/tls. It returns an error
+// wrapping ErrInvalidCurve if priv is not on a curve crypto/ecdh implements,
+// and wrapping ErrInvalidScalar if its scalar is out of range.
+func ToECDHPrivateKey(priv *PrivateKey) (*ecdh.PrivateKey, error) {
+	if priv == nil {
+		return nil, wrapError(ErrInvalidScalar, "nil private key")
+	}
+	c, ok := ecdhCurve(priv.Curve)
+	if !ok {
+		return nil, wrapError(ErrInvalidCurve, "crypto/ecdh does not implement %s", curveName(priv.Curve))
+	}
+	d, err := priv.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	k, err := c.NewPrivateKey(d.Bytes())
+	if err != nil {
+		return nil, wrapError(ErrInvalidScalar, "%s", err)
+	}
+	return k, nil
+}
+
+// FromECDHPrivateKey converts a crypto/ecdh private key to a private key of
+// this package, so that a key generated for key agreement can also be
+// blinded and sign. It returns an error wrapping ErrInvalidCurve for X25519
+// keys.
+func FromECDHPrivateKey(priv *ecdh.PrivateKey) (*PrivateKey, error) {
+	if priv == nil {
+		return nil, wrapError(ErrInvalidScalar, "nil crypto/ecdh private key")
+	}
+	c, ok := ellipticCurve(priv.Curve())
+	if !ok {
+		return nil, wrapError(ErrInvalidCurve, "crypto/ecdh curve %v has no ECDSA equivalent", priv.Curve())
+	}
+	d, err := NewScalar(c).SetBytes(priv.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(d)
+}
+
+// BlindECDHPublicKey blinds the crypto/ecdh public key pk by skB and context,
+// as BlindPublicKeyWithContext does. A peer that runs a crypto/ecdh key
+// agreement with the result shares a secret with the holder of the private
+// key returned by BlindECDHPrivateKey for the same blind and context. It
+// returns an error wrapping ErrCurveMismatch if skB is not on the curve of
+// pk.
+func BlindECDHPublicKey(pk *ecdh.PublicKey, skB *PrivateKey, context []byte) (*ecdh.PublicKey, error) {
+	pub, err := FromECDHPublicKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	if skB == nil || skB.Curve != pub.Curve {
+		return nil, wrapError(ErrCurveMismatch, "blind is not on %s", curveName(pub.Curve))
+	}
+	pkR, err := BlindPublicKeyWithContext(pub.Curve, pub, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	return ToECDHPublicKey(pkR)
+}
+
+// BlindECDHPrivateKey blinds the crypto/ecdh private key sk by skB and
+// context, as BlindPrivateKeyWithContext does. The scalar of the result is
+// the same as that of the key BlindKeySignWithContext signs with, so a key
+// pair used for blinded key agreement through crypto/ecdh and for blinded
+// signing has a single blinded public key. It returns an error wrapping
+// ErrCurveMismatch if skB is not on the curve of sk.
+func BlindECDHPrivateKey(sk *ecdh.PrivateKey, skB *PrivateKey, context []byte) (*ecdh.PrivateKey, error) {
+	skS, err := FromECDHPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	if skB == nil || skB.Curve != skS.Curve {
+		return nil, wrapError(ErrCurveMismatch, "blind is not on %s", curveName(skS.Curve))
+	}
+	skR, err := BlindPrivateKeyWithContext(skS, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	return ToECDHPrivateKey(skR)
+}
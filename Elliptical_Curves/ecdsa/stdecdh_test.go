@@ -0,0 +1,125 @@
+//go:build go1.20
+
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+func TestECDHConversions(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		sk, _ := GenerateKey(c, rand.Reader)
+		esk, err := ToECDHPrivateKey(sk)
+		if err != nil {
+			t.Fatalf("%s: ToECDHPrivateKey error: %s", c.Params().Name, err)
+		}
+		epk, err := ToECDHPublicKey(&sk.PublicKey)
+		if err != nil {
+			t.Fatalf("%s: ToECDHPublicKey error: %s", c.Params().Name, err)
+		}
+		if !esk.PublicKey().Equal(epk) {
+			t.Errorf("%s: converted public key does not match the converted private key", c.Params().Name)
+		}
+
+		back, err := FromECDHPrivateKey(esk)
+		if err != nil || back.D.Cmp(sk.D) != 0 || !back.PublicKey.Equal(&sk.PublicKey) {
+			t.Errorf("%s: FromECDHPrivateKey(ToECDHPrivateKey()) = %v", c.Params().Name, err)
+		}
+		pub, err := FromECDHPublicKey(epk)
+		if err != nil || !pub.Equal(&sk.PublicKey) {
+			t.Errorf("%s: FromECDHPublicKey(ToECDHPublicKey()) = %v", c.Params().Name, err)
+		}
+	}
+
+	// A key generated by crypto/ecdh signs and verifies.
+	generated, _ := ecdh.P256().GenerateKey(rand.Reader)
+	signer, err := FromECDHPrivateKey(generated)
+	if err != nil {
+		t.Fatalf("FromECDHPrivateKey error: %s", err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	r, s, _ := Sign(rand.Reader, signer, digest[:])
+	if !Verify(&signer.PublicKey, digest[:], r, s) {
+		t.Errorf("signature by a crypto/ecdh key does not verify")
+	}
+}
+
+func TestBlindECDHStd(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		skS, _ := GenerateKey(c, rand.Reader)
+		skB, _ := GenerateKey(c, rand.Reader)
+		context := []byte("handshake")
+		esk, _ := ToECDHPrivateKey(skS)
+
+		eskR, err := BlindECDHPrivateKey(esk, skB, context)
+		if err != nil {
+			t.Fatalf("%s: BlindECDHPrivateKey error: %s", c.Params().Name, err)
+		}
+		epkR, err := BlindECDHPublicKey(esk.PublicKey(), skB, context)
+		if err != nil {
+			t.Fatalf("%s: BlindECDHPublicKey error: %s", c.Params().Name, err)
+		}
+		if !eskR.PublicKey().Equal(epkR) {
+			t.Errorf("%s: blinded public keys do not match", c.Params().Name)
+		}
+
+		// The blinded key is the one that verifies blinded signatures.
+		pkR, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+		if pub, _ := FromECDHPublicKey(epkR); !pub.Equal(pkR) {
+			t.Errorf("%s: blinded crypto/ecdh key differs from BlindPublicKeyWithContext", c.Params().Name)
+		}
+
+		// crypto/ecdh key agreement with the blinded key matches BlindECDH.
+		peer, _ := GenerateKey(c, rand.Reader)
+		epeer, _ := ToECDHPrivateKey(peer)
+		theirs, err := epeer.ECDH(epkR)
+		if err != nil {
+			t.Fatalf("%s: crypto/ecdh ECDH error: %s", c.Params().Name, err)
+		}
+		ours, _ := BlindECDHWithContext(skS, skB, &peer.PublicKey, context)
+		if !bytes.Equal(ours, theirs) {
+			t.Errorf("%s: crypto/ecdh shared secret differs from BlindECDHWithContext", c.Params().Name)
+		}
+	}
+}
+
+func TestECDHGuards(t *testing.T) {
+	// The generator, since keys can't be generated on brainpoolP256r1 in FIPS
+	// mode.
+	c := brainpool.P256r1()
+	bp := &PrivateKey{PublicKey{c, c.Params().Gx, c.Params().Gy}, big.NewInt(1)}
+	if _, err := ToECDHPrivateKey(bp); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("ToECDHPrivateKey(brainpoolP256r1): got %v, want ErrInvalidCurve", err)
+	}
+	if _, err := ToECDHPublicKey(&bp.PublicKey); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("ToECDHPublicKey(brainpoolP256r1): got %v, want ErrInvalidCurve", err)
+	}
+	x, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	if _, err := FromECDHPrivateKey(x); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("FromECDHPrivateKey(X25519): got %v, want ErrInvalidCurve", err)
+	}
+	if _, err := FromECDHPublicKey(x.PublicKey()); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("FromECDHPublicKey(X25519): got %v, want ErrInvalidCurve", err)
+	}
+
+	esk, _ := ecdh.P256().GenerateKey(rand.Reader)
+	skB, _ := GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := BlindECDHPrivateKey(esk, skB, nil); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("BlindECDHPrivateKey with a P-384 blind: got %v, want ErrCurveMismatch", err)
+	}
+	if _, err := BlindECDHPublicKey(esk.PublicKey(), skB, nil); !errors.Is(err, ErrCurveMismatch) {
+		t.Errorf("BlindECDHPublicKey with a P-384 blind: got %v, want ErrCurveMismatch", err)
+	}
+	if _, err := ToECDHPrivateKey(&PrivateKey{PublicKey: PublicKey{Curve: elliptic.P256()}}); !errors.Is(err, ErrInvalidScalar) {
+		t.Errorf("ToECDHPrivateKey of a key without scalar: got %v, want ErrInvalidScalar", err)
+	}
+}
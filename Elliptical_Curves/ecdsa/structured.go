@@ -0,0 +1,322 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Codec is a canonical encoding of structured data. SignStructured encodes
+// data with its codec before hashing it, so that a signer and a verifier
+// that hold the same data compute the same digest even if the data was
+// re-encoded in between, with other whitespace, key order or number syntax.
+type Codec int
+
+const (
+	// CodecJSON is the JSON Canonicalization Scheme of RFC 8785: object
+	// members sorted by the UTF-16 code units of their names, no
+	// whitespace, minimal string escaping and numbers serialized as
+	// ECMAScript does. Numbers are IEEE 754 doubles, so integers beyond
+	// 2^53 lose precision and should be carried as strings.
+	CodecJSON Codec = iota
+
+	// CodecCBOR is the core deterministic encoding of RFC 8949, section
+	// 4.2.1: shortest forms of integers, lengths and floating-point values,
+	// definite lengths, and map keys sorted by their encoding.
+	CodecCBOR
+)
+
+// String returns the name of the codec.
+func (c Codec) String() string {
+	switch c {
+	case CodecJSON:
+		return "jcs"
+	case CodecCBOR:
+		return "cbor"
+	default:
+		return "unknown"
+	}
+}
+
+// structuredDecMode decodes CBOR data to be canonicalized: unlike
+// cborDecMode, it accepts indefinite lengths, which canonicalization removes,
+// but not duplicate map keys, which have no canonical form.
+var structuredDecMode cbor.DecMode
+
+func init() {
+	var err error
+	structuredDecMode, err = cbor.DecOptions{DupMapKey: cbor.DupMapKeyEnforcedAPF}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Canonicalize returns the canonical encoding of v. A json.RawMessage passed
+// to CodecJSON, or a cbor.RawMessage passed to CodecCBOR, is taken as an
+// already encoded document, which is parsed and encoded again canonically;
+// any other value is first marshaled with encoding/json or with the CBOR
+// encoder of this package.
+func (c Codec) Canonicalize(v interface{}) ([]byte, error) {
+	switch c {
+	case CodecJSON:
+		data, ok := v.(json.RawMessage)
+		if !ok {
+			var err error
+			if data, err = json.Marshal(v); err != nil {
+				return nil, wrapError(ErrInvalidStructuredData, "%v", err)
+			}
+		}
+		return canonicalJSON(data)
+	case CodecCBOR:
+		if raw, ok := v.(cbor.RawMessage); ok {
+			var decoded interface{}
+			if err := structuredDecMode.Unmarshal(raw, &decoded); err != nil {
+				return nil, wrapError(ErrInvalidStructuredData, "%v", err)
+			}
+			v = decoded
+		}
+		data, err := cborEncMode.Marshal(v)
+		if err != nil {
+			return nil, wrapError(ErrInvalidStructuredData, "%v", err)
+		}
+		return data, nil
+	default:
+		return nil, wrapError(ErrInvalidStructuredData, "unknown codec %d", int(c))
+	}
+}
+
+// structuredDigest hashes the canonical encoding of v with h.
+func structuredDigest(v interface{}, codec Codec, h crypto.Hash) ([]byte, error) {
+	data, err := codec.Canonicalize(v)
+	if err != nil {
+		return nil, err
+	}
+	return hashMessage(data, h)
+}
+
+// SignStructured encodes v canonically with codec, hashes the encoding with h
+// and signs the digest with priv, as SignMessage does. The signature is a
+// plain ECDSA signature of the canonical encoding, which other
+// implementations of RFC 8785 or of deterministic CBOR can check.
+func SignStructured(priv *PrivateKey, v interface{}, codec Codec, h crypto.Hash) (r, s *big.Int, err error) {
+	digest, err := structuredDigest(v, codec, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signDigest(nil, priv, digest)
+}
+
+// BlindKeySignStructured encodes v canonically with codec, hashes the
+// encoding with h and signs the digest with skS blinded by skB and context,
+// as BlindKeySignMessage does.
+func BlindKeySignStructured(skS, skB *PrivateKey, v interface{}, codec Codec, h crypto.Hash, context []byte) (r, s *big.Int, err error) {
+	digest, err := structuredDigest(v, codec, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	return BlindKeySignWithContext(nil, skS, skB, digest, context)
+}
+
+// VerifyStructured reports whether r, s is a valid signature under pub of v
+// encoded canonically with codec and hashed with h. v does not need to be the
+// value that was signed, only to have the same canonical encoding: a verifier
+// can pass the document it received as a json.RawMessage or a
+// cbor.RawMessage, however it was re-encoded on the way.
+func VerifyStructured(pub *PublicKey, v interface{}, codec Codec, h crypto.Hash, r, s *big.Int) bool {
+	digest, err := structuredDigest(v, codec, h)
+	if err != nil {
+		return false
+	}
+	return VerifyDigest(pub, digest, r, s)
+}
+
+// canonicalJSON returns the RFC 8785 encoding of the JSON document data.
+func canonicalJSON(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, wrapError(ErrInvalidStructuredData, "JSON document is not valid UTF-8")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	b, err := appendCanonicalJSON(nil, dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, wrapError(ErrInvalidStructuredData, "trailing data after JSON document")
+	}
+	return b, nil
+}
+
+// jsonMember is an object member with its canonically encoded value.
+type jsonMember struct {
+	name  string
+	key   []uint16
+	value []byte
+}
+
+func appendCanonicalJSON(b []byte, dec *json.Decoder) ([]byte, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, wrapError(ErrInvalidStructuredData, "%v", err)
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			b = append(b, '[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				if b, err = appendCanonicalJSON(b, dec); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, wrapError(ErrInvalidStructuredData, "%v", err)
+			}
+			return append(b, ']'), nil
+		}
+		var members []jsonMember
+		seen := make(map[string]bool)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, wrapError(ErrInvalidStructuredData, "%v", err)
+			}
+			name := tok.(string)
+			if seen[name] {
+				return nil, wrapError(ErrInvalidStructuredData, "duplicate JSON object key %q", name)
+			}
+			seen[name] = true
+			value, err := appendCanonicalJSON(nil, dec)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, jsonMember{name, utf16.Encode([]rune(name)), value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, wrapError(ErrInvalidStructuredData, "%v", err)
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return lessUTF16(members[i].key, members[j].key)
+		})
+		b = append(b, '{')
+		for i, m := range members {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, m.name)
+			b = append(b, ':')
+			b = append(b, m.value...)
+		}
+		return append(b, '}'), nil
+	case string:
+		return appendJSONString(b, t), nil
+	case json.Number:
+		f, err := strconv.ParseFloat(string(t), 64)
+		if err != nil {
+			return nil, wrapError(ErrInvalidStructuredData, "JSON number %s is not a finite double", t)
+		}
+		return appendES6Number(b, f), nil
+	case bool:
+		return strconv.AppendBool(b, t), nil
+	default:
+		return append(b, "null"...), nil
+	}
+}
+
+func lessUTF16(a, b []uint16) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// appendJSONString appends s as a JSON string, escaping only the quote, the
+// backslash and the control characters, as RFC 8785, section 3.2.2.2,
+// requires.
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b = append(b, `\"`...)
+		case '\\':
+			b = append(b, `\\`...)
+		case '\b':
+			b = append(b, `\b`...)
+		case '\f':
+			b = append(b, `\f`...)
+		case '\n':
+			b = append(b, `\n`...)
+		case '\r':
+			b = append(b, `\r`...)
+		case '\t':
+			b = append(b, `\t`...)
+		default:
+			if r < 0x20 {
+				b = append(b, '\\', 'u', '0', '0', hex[r>>4], hex[r&0xf])
+			} else {
+				b = utf8.AppendRune(b, r)
+			}
+		}
+	}
+	return append(b, '"')
+}
+
+// appendES6Number appends f serialized as ECMAScript Number.prototype.toString
+// does, as RFC 8785, section 3.2.2.3, requires. f must be finite.
+func appendES6Number(b []byte, f float64) []byte {
+	if f == 0 {
+		return append(b, '0')
+	}
+	if math.Signbit(f) {
+		b = append(b, '-')
+		f = -f
+	}
+	// The shortest decimal digits that round trip, and the exponent n such
+	// that f = 0.digits * 10^n.
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	i := strings.IndexByte(e, 'e')
+	digits := strings.Replace(e[:i], ".", "", 1)
+	exp, _ := strconv.Atoi(e[i+1:])
+	k, n := len(digits), exp+1
+	switch {
+	case k <= n && n <= 21:
+		b = append(b, digits...)
+		b = append(b, strings.Repeat("0", n-k)...)
+	case 0 < n && n <= 21:
+		b = append(b, digits[:n]...)
+		b = append(b, '.')
+		b = append(b, digits[n:]...)
+	case -6 < n && n <= 0:
+		b = append(b, "0."...)
+		b = append(b, strings.Repeat("0", -n)...)
+		b = append(b, digits...)
+	default:
+		b = append(b, digits[0])
+		if k > 1 {
+			b = append(b, '.')
+			b = append(b, digits[1:]...)
+		}
+		b = append(b, 'e')
+		if n-1 >= 0 {
+			b = append(b, '+')
+		}
+		b = strconv.AppendInt(b, int64(n-1), 10)
+	}
+	return b
+}
@@ -0,0 +1,121 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		// RFC 8785, section 3.2.2.
+		{
+			`{
+			  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+			  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+			  "literals": [null, true, false]
+			}`,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		// RFC 8785, section 3.2.3: members sorted by UTF-16 code units.
+		{
+			`{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\ud83d\ude00": 5, "\u0080": 6, "\u00f6": 7}`,
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"ö\":7,\"€\":1,\"😀\":5,\"\ufb33\":3}",
+		},
+		// Numbers of RFC 8785, appendix B.
+		{`[-0, 1e21, 1e20, 1e-7, 0.000001, 9007199254740993, -5e-324, 1.7976931348623157e308]`,
+			`[0,1e+21,100000000000000000000,1e-7,0.000001,9007199254740992,-5e-324,1.7976931348623157e+308]`},
+	} {
+		got, err := CodecJSON.Canonicalize(json.RawMessage(tt.in))
+		if err != nil {
+			t.Fatalf("Canonicalize(%s) error: %s", tt.in, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Canonicalize(%s)\n got %s\nwant %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{`{"a":1,"a":2}`, `[1e400]`, `{"a":1} {}`, "\"\xff\"", `{`} {
+		if _, err := CodecJSON.Canonicalize(json.RawMessage(in)); !errors.Is(err, ErrInvalidStructuredData) {
+			t.Errorf("Canonicalize(%q) error = %v", in, err)
+		}
+	}
+}
+
+func TestCanonicalizeCBOR(t *testing.T) {
+	// {"b": 1, "a": [_ 1]}, with a non-minimal integer and an indefinite
+	// length array.
+	raw := cbor.RawMessage{0xa2, 0x61, 'b', 0x18, 0x01, 0x61, 'a', 0x9f, 0x01, 0xff}
+	got, err := CodecCBOR.Canonicalize(raw)
+	if err != nil {
+		t.Fatalf("Canonicalize error: %s", err)
+	}
+	want := []byte{0xa2, 0x61, 'a', 0x81, 0x01, 0x61, 'b', 0x01}
+	if string(got) != string(want) {
+		t.Errorf("Canonicalize = %x, want %x", got, want)
+	}
+	dup := cbor.RawMessage{0xa2, 0x61, 'a', 0x01, 0x61, 'a', 0x02}
+	if _, err := CodecCBOR.Canonicalize(dup); !errors.Is(err, ErrInvalidStructuredData) {
+		t.Errorf("Canonicalize(duplicate keys) error = %v", err)
+	}
+	if _, err := Codec(42).Canonicalize(1); !errors.Is(err, ErrInvalidStructuredData) {
+		t.Errorf("Canonicalize with an unknown codec: error = %v", err)
+	}
+}
+
+type structuredClaims struct {
+	Subject string            `json:"sub" cbor:"sub"`
+	Scopes  []string          `json:"scopes" cbor:"scopes"`
+	Extra   map[string]string `json:"extra" cbor:"extra"`
+}
+
+func TestSignStructured(t *testing.T) {
+	priv, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	skB, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	context := []byte("structured")
+	pkR, _ := BlindPublicKeyWithContext(elliptic.P256(), &priv.PublicKey, skB, context)
+	claims := structuredClaims{"alice", []string{"read", "write"}, map[string]string{"z": "1", "a": "2"}}
+
+	// The verifier receives the same claims re-encoded differently.
+	cborDoc, _ := cbor.Marshal(map[string]interface{}{
+		"scopes": []string{"read", "write"}, "sub": "alice", "extra": map[string]string{"z": "1", "a": "2"},
+	})
+	reencoded := map[Codec]interface{}{
+		CodecJSON: json.RawMessage(`{ "scopes": ["read", "write"], "extra": {"a": "2", "z": "1"}, "sub": "\u0061lice" }`),
+		CodecCBOR: cbor.RawMessage(cborDoc),
+	}
+
+	for _, codec := range []Codec{CodecJSON, CodecCBOR} {
+		r, s, err := SignStructured(priv, claims, codec, crypto.SHA256)
+		if err != nil {
+			t.Fatalf("%s: SignStructured error: %s", codec, err)
+		}
+		if !VerifyStructured(&priv.PublicKey, reencoded[codec], codec, crypto.SHA256, r, s) {
+			t.Errorf("%s: signature does not verify over the re-encoded claims", codec)
+		}
+		canonical, _ := codec.Canonicalize(claims)
+		if !VerifyMessage(&priv.PublicKey, canonical, crypto.SHA256, r, s) {
+			t.Errorf("%s: signature is not a signature of the canonical encoding", codec)
+		}
+		other := claims
+		other.Subject = "bob"
+		if VerifyStructured(&priv.PublicKey, other, codec, crypto.SHA256, r, s) {
+			t.Errorf("%s: signature verifies over other claims", codec)
+		}
+
+		r, s, err = BlindKeySignStructured(priv, skB, claims, codec, crypto.SHA256, context)
+		if err != nil {
+			t.Fatalf("%s: BlindKeySignStructured error: %s", codec, err)
+		}
+		if !VerifyStructured(pkR, reencoded[codec], codec, crypto.SHA256, r, s) {
+			t.Errorf("%s: blinded signature does not verify", codec)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+//go:build ignore
+
+// This program generates the JSON files of this directory, which hold edge
+// cases of ECDSA verification in the test vector format of Project
+// Wycheproof. They are not Wycheproof vectors. Keys and nonces are drawn from
+// a generator seeded with the name of each file, so that running it again
+// gives the same files:
+//
+//	go run generate.go
+package main
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+type test struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Flags   []string `json:"flags"`
+	Msg     string   `json:"msg"`
+	Sig     string   `json:"sig"`
+	Result  string   `json:"result"`
+}
+
+type key struct {
+	Curve        string `json:"curve"`
+	KeySize      int    `json:"keySize"`
+	Type         string `json:"type"`
+	Uncompressed string `json:"uncompressed"`
+	Wx           string `json:"wx"`
+	Wy           string `json:"wy"`
+}
+
+type group struct {
+	Type      string  `json:"type"`
+	PublicKey key     `json:"publicKey"`
+	SHA       string  `json:"sha"`
+	Tests     []*test `json:"tests"`
+}
+
+type root struct {
+	Algorithm     string            `json:"algorithm"`
+	Schema        string            `json:"schema"`
+	NumberOfTests int               `json:"numberOfTests"`
+	Header        []string          `json:"header"`
+	Notes         map[string]string `json:"notes"`
+	TestGroups    []*group          `json:"testGroups"`
+}
+
+func der(r, s *big.Int) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.BytesOrPanic()
+}
+
+// rawDER encodes r and s as given byte strings, without normalization.
+func rawDER(r, s []byte) []byte {
+	body := append([]byte{0x02, byte(len(r))}, r...)
+	body = append(body, 0x02, byte(len(s)))
+	body = append(body, s...)
+	return append([]byte{0x30, byte(len(body))}, body...)
+}
+
+func hexpad(x *big.Int, size int) string {
+	return hex.EncodeToString(x.FillBytes(make([]byte, size)))
+}
+
+type gen struct {
+	c    elliptic.Curve
+	h    crypto.Hash
+	n    *big.Int
+	id   int
+	size int
+}
+
+func (g *gen) digest(msg []byte) *big.Int {
+	h := g.h.New()
+	h.Write(msg)
+	d := h.Sum(nil)
+	e := new(big.Int).SetBytes(d)
+	if excess := len(d)*8 - g.n.BitLen(); excess > 0 {
+		e.Rsh(e, uint(excess))
+	}
+	return e
+}
+
+func (g *gen) sign(d, k *big.Int, msg []byte) (r, s *big.Int) {
+	x, _ := g.c.ScalarBaseMult(k.Bytes())
+	r = new(big.Int).Mod(x, g.n)
+	s = new(big.Int).Mul(d, r)
+	s.Add(s, g.digest(msg))
+	s.Mul(s, new(big.Int).ModInverse(k, g.n))
+	s.Mod(s, g.n)
+	return
+}
+
+func (g *gen) key(x, y *big.Int) key {
+	size := (g.c.Params().BitSize + 7) / 8
+	return key{
+		Curve:        map[string]string{"P-256": "secp256r1", "P-384": "secp384r1", "P-521": "secp521r1"}[g.c.Params().Name],
+		KeySize:      g.c.Params().BitSize,
+		Type:         "EcPublicKey",
+		Uncompressed: hex.EncodeToString(elliptic.Marshal(g.c, x, y)),
+		Wx:           hexpad(x, size),
+		Wy:           hexpad(y, size),
+	}
+}
+
+func (g *gen) add(gr *group, comment string, flags []string, msg, sig []byte, result string) {
+	g.id++
+	gr.Tests = append(gr.Tests, &test{TcID: g.id, Comment: comment, Flags: flags, Msg: hex.EncodeToString(msg), Sig: hex.EncodeToString(sig), Result: result})
+}
+
+// seededReader returns SHA-256(seed || counter) for a 64-bit big-endian
+// counter starting at 0.
+type seededReader struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			var ctr [8]byte
+			binary.BigEndian.PutUint64(ctr[:], r.counter)
+			r.counter++
+			block := sha256.Sum256(append(append([]byte(nil), r.seed...), ctr[:]...))
+			r.buf = block[:]
+		}
+		m := copy(p[n:], r.buf)
+		r.buf = r.buf[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// randScalar returns a scalar in [1, n - 1] from 64 more bits than n has,
+// so that its bias is negligible.
+func randScalar(rand io.Reader, n *big.Int) *big.Int {
+	b := make([]byte, len(n.Bytes())+8)
+	if _, err := io.ReadFull(rand, b); err != nil {
+		panic(err)
+	}
+	nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
+	k := new(big.Int).Mod(new(big.Int).SetBytes(b), nMinus1)
+	return k.Add(k, big.NewInt(1))
+}
+
+func main() {
+	for _, p := range []struct {
+		c    elliptic.Curve
+		h    crypto.Hash
+		sha  string
+		file string
+	}{
+		{elliptic.P256(), crypto.SHA256, "SHA-256", "p256_sha256.json"},
+		{elliptic.P384(), crypto.SHA384, "SHA-384", "p384_sha384.json"},
+		{elliptic.P521(), crypto.SHA512, "SHA-512", "p521_sha512.json"},
+	} {
+		rand := &seededReader{seed: []byte(p.file)}
+		g := &gen{c: p.c, h: p.h, n: p.c.Params().N}
+		n := g.n
+		d := randScalar(rand, n)
+		qx, qy := p.c.ScalarBaseMult(d.Bytes())
+		gr := &group{Type: "EcdsaVerify", PublicKey: g.key(qx, qy), SHA: p.sha}
+		msg := []byte("123400")
+		r, s := g.sign(d, randScalar(rand, n), msg)
+		// Retry until r has its top bit set, for the MissingZero case,
+		// where the order is a whole number of bytes.
+		for n.BitLen()%8 == 0 && r.Bit(n.BitLen()-1) == 0 {
+			r, s = g.sign(d, randScalar(rand, n), msg)
+		}
+
+		g.add(gr, "signature malleability", []string{"SignatureMalleability"}, msg, der(r, new(big.Int).Sub(n, s)), "valid")
+		g.add(gr, "valid", []string{"ValidSignature"}, msg, der(r, s), "valid")
+		g.add(gr, "wrong message", []string{"ModifiedMessage"}, []byte("123401"), der(r, s), "invalid")
+		g.add(gr, "r and s swapped", []string{"ModifiedSignature"}, msg, der(s, r), "invalid")
+
+		zero, one := big.NewInt(0), big.NewInt(1)
+		nMinus1 := new(big.Int).Sub(n, one)
+		nPlus1 := new(big.Int).Add(n, one)
+		for _, v := range []struct {
+			comment string
+			r, s    *big.Int
+		}{
+			{"r = 0", zero, s},
+			{"s = 0", r, zero},
+			{"r = 0 and s = 0", zero, zero},
+			{"r = n", n, s},
+			{"s = n", r, n},
+			{"r = n and s = n", n, n},
+			{"r = n + 1", nPlus1, s},
+			{"r + n", new(big.Int).Add(r, n), s},
+			{"s + n", r, new(big.Int).Add(s, n)},
+			{"r = 1 and s = 1", one, one},
+			{"r = n - 1 and s = n - 1", nMinus1, nMinus1},
+			{"r = -r", new(big.Int).Neg(r), s},
+			{"s = -s", r, new(big.Int).Neg(s)},
+			{"s = n - s + n", r, new(big.Int).Sub(new(big.Int).Add(n, n), s)},
+		} {
+			g.add(gr, v.comment, []string{"InvalidSignature"}, msg, der(v.r, v.s), "invalid")
+		}
+
+		rb, sb := r.Bytes(), s.Bytes()
+		valid := der(r, s)
+		long := append([]byte{0x30, 0x81, valid[1]}, valid[2:]...)
+		g.add(gr, "long form encoding of length of sequence", []string{"BerEncodedSignature"}, msg, long, "invalid")
+		g.add(gr, "appending 0's to sequence", []string{"BerEncodedSignature"}, msg, append(append([]byte{}, valid...), 0, 0), "invalid")
+		g.add(gr, "truncated sequence", []string{"InvalidEncoding"}, msg, valid[:len(valid)-1], "invalid")
+		g.add(gr, "empty signature", []string{"InvalidEncoding"}, msg, []byte{}, "invalid")
+		g.add(gr, "leading zero in r", []string{"BerEncodedSignature"}, msg, rawDER(append([]byte{0, 0}, rb...), append([]byte{0}, sb...)), "invalid")
+		if rb[0]&0x80 != 0 {
+			g.add(gr, "missing leading zero in r", []string{"MissingZero"}, msg, rawDER(rb, append([]byte{0}, sb...)), "invalid")
+		}
+		g.add(gr, "indefinite length", []string{"BerEncodedSignature"}, msg, append(append([]byte{0x30, 0x80}, valid[2:]...), 0, 0), "invalid")
+		g.add(gr, "integers as octet strings", []string{"InvalidTypesInSignature"}, msg, func() []byte {
+			b := append([]byte{}, valid...)
+			b[2] = 0x04
+			return b
+		}(), "invalid")
+
+		// Special nonces.
+		for _, k := range []struct {
+			comment string
+			k       *big.Int
+		}{
+			{"k = 1", one},
+			{"k = 2", big.NewInt(2)},
+			{"k = n - 1", nMinus1},
+			{"k = (n - 1) / 2", new(big.Int).Rsh(nMinus1, 1)},
+		} {
+			r, s := g.sign(d, k.k, msg)
+			g.add(gr, k.comment, []string{"SpecialCaseNonce"}, msg, der(r, s), "valid")
+		}
+
+		groups := []*group{gr}
+
+		// A key for which u1 * G + u2 * Q is the point at infinity for any s:
+		// Q = -(e / r) * G, so that e * G + r * Q = 0.
+		e := g.digest(msg)
+		rInf := randScalar(rand, n)
+		w := new(big.Int).Mul(e, new(big.Int).ModInverse(rInf, n))
+		w.Neg(w).Mod(w, n)
+		ix, iy := p.c.ScalarBaseMult(w.Bytes())
+		gi := &group{Type: "EcdsaVerify", PublicKey: g.key(ix, iy), SHA: p.sha}
+		for _, sv := range []*big.Int{one, randScalar(rand, n), nMinus1} {
+			g.add(gi, "point at infinity during verification", []string{"PointAtInfinity"}, msg, der(rInf, sv), "invalid")
+		}
+		groups = append(groups, gi)
+
+		out := root{
+			Algorithm:     "ECDSA",
+			Schema:        "ecdsa_verify_schema_v1.json",
+			NumberOfTests: g.id,
+			Header: []string{
+				"Edge cases of ECDSA verification generated for this package, in the test",
+				"vector format of Project Wycheproof. These are not Wycheproof vectors.",
+			},
+			Notes:      map[string]string{},
+			TestGroups: groups,
+		}
+		for _, f := range []string{"SignatureMalleability", "ValidSignature", "ModifiedMessage", "ModifiedSignature", "InvalidSignature", "BerEncodedSignature", "InvalidEncoding", "MissingZero", "InvalidTypesInSignature", "SpecialCaseNonce", "PointAtInfinity"} {
+			out.Notes[f] = notes[f]
+		}
+		enc, _ := json.MarshalIndent(out, "", "  ")
+		if err := os.WriteFile(p.file, append(enc, '\n'), 0o644); err != nil {
+			panic(err)
+		}
+		fmt.Println(p.file, g.id)
+	}
+}
+
+var notes = map[string]string{
+	"SignatureMalleability":   "ECDSA signatures are malleable: (r, n - s) is valid if (r, s) is.",
+	"ValidSignature":          "A valid signature.",
+	"ModifiedMessage":         "The signature is of another message.",
+	"ModifiedSignature":       "The values of a valid signature were modified.",
+	"InvalidSignature":        "r or s is out of the range [1, n - 1], or the signature doesn't verify.",
+	"BerEncodedSignature":     "The signature is BER encoded but not DER encoded.",
+	"InvalidEncoding":         "The signature is not a valid ASN.1 encoding.",
+	"MissingZero":             "An integer is missing the leading zero that makes it positive.",
+	"InvalidTypesInSignature": "The signature uses ASN.1 types other than INTEGER.",
+	"SpecialCaseNonce":        "The signature was generated with a nonce of a special value.",
+	"PointAtInfinity":         "u1 * G + u2 * Q is the point at infinity for the key of the group.",
+}
@@ -0,0 +1,244 @@
+// Package testvectors parses the ECDSA test vector files of the NIST
+// Cryptographic Algorithm Validation Program (CAVP), so that implementations
+// of this module and other ECDSA implementations can validate themselves
+// against them.
+//
+// Both the SigVer files, whose vectors are signatures to verify along with
+// the expected result, and the SigGen files with answers, whose vectors
+// include the private key and the nonce that produced each signature, are
+// supported. The SigVer vectors of FIPS 186-3 for the curves P-224, P-256,
+// P-384 and P-521 are embedded, and returned by SigVer.
+package testvectors
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"crypto"
+	"crypto/elliptic"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// sigVer is the SigVer.rsp file of
+// https://csrc.nist.gov/groups/STM/cavp/documents/dss/186-3ecdsatestvectors.zip,
+// edited to remove the vectors for curves other than P-224, P-256, P-384 and
+// P-521, and compressed.
+//
+//go:embed testdata/SigVer.rsp.bz2
+var sigVer []byte
+
+// ErrMalformed is returned when a test vector file can't be parsed.
+var ErrMalformed = errors.New("testvectors: malformed test vector file")
+
+// Vector is an ECDSA test vector.
+type Vector struct {
+	// Line is the line number of the first field of the vector.
+	Line int
+	// CurveName and HashName are the names of the curve and hash function
+	// of the section of the vector, such as "P-256" and "SHA-256". Curve
+	// and Hash are the corresponding curve and hash function, or nil and
+	// zero if they are not supported by the standard library.
+	CurveName string
+	HashName  string
+	Curve     elliptic.Curve
+	Hash      crypto.Hash
+	// Msg is the signed message, before hashing.
+	Msg []byte
+	// D and K are the private key and the nonce of SigGen vectors, and are
+	// nil for SigVer vectors.
+	D, K *big.Int
+	// Qx and Qy are the coordinates of the public key, which is not
+	// necessarily on the curve for SigVer vectors.
+	Qx, Qy *big.Int
+	// R and S are the signature.
+	R, S *big.Int
+	// Valid reports whether the signature is valid. It is always true for
+	// SigGen vectors.
+	Valid bool
+}
+
+// Digest returns the hash of Msg with the hash function of v, which must be
+// supported.
+func (v *Vector) Digest() []byte {
+	h := v.Hash.New()
+	h.Write(v.Msg)
+	return h.Sum(nil)
+}
+
+// Reader reads the vectors of a CAVP file.
+type Reader struct {
+	s         *bufio.Scanner
+	line      int
+	curveName string
+	hashName  string
+	curve     elliptic.Curve
+	hash      crypto.Hash
+}
+
+// NewReader returns a Reader of the vectors of the uncompressed CAVP file
+// read from r.
+func NewReader(r io.Reader) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	return &Reader{s: s}
+}
+
+// SigVer returns a Reader of the embedded SigVer vectors of FIPS 186-3.
+func SigVer() *Reader {
+	return NewReader(bzip2.NewReader(bytes.NewReader(sigVer)))
+}
+
+var curves = map[string]func() elliptic.Curve{
+	"P-224": elliptic.P224,
+	"P-256": elliptic.P256,
+	"P-384": elliptic.P384,
+	"P-521": elliptic.P521,
+}
+
+var hashes = map[string]crypto.Hash{
+	"SHA-1":   crypto.SHA1,
+	"SHA-224": crypto.SHA224,
+	"SHA-256": crypto.SHA256,
+	"SHA-384": crypto.SHA384,
+	"SHA-512": crypto.SHA512,
+}
+
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: line %d: %s", ErrMalformed, r.line, fmt.Sprintf(format, args...))
+}
+
+// Next returns the next vector, or io.EOF after the last one. It returns an
+// error wrapping ErrMalformed if the file can't be parsed.
+func (r *Reader) Next() (*Vector, error) {
+	var v *Vector
+	for r.s.Scan() {
+		r.line++
+		line := strings.TrimSuffix(r.s.Text(), "\r")
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "" {
+			if v != nil {
+				return r.finish(v)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if v != nil {
+				return nil, r.errorf("section header in a vector")
+			}
+			if err := r.section(line); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if v == nil {
+			v = &Vector{
+				Line:      r.line,
+				CurveName: r.curveName,
+				HashName:  r.hashName,
+				Curve:     r.curve,
+				Hash:      r.hash,
+				Valid:     true,
+			}
+		}
+		if err := r.field(v, line); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+	if v != nil {
+		return r.finish(v)
+	}
+	return nil, io.EOF
+}
+
+// section parses a section header such as "[P-256,SHA-256]".
+func (r *Reader) section(line string) error {
+	if !strings.HasSuffix(line, "]") {
+		return r.errorf("malformed section header %q", line)
+	}
+	fields := strings.Split(line[1:len(line)-1], ",")
+	if len(fields) != 2 {
+		return r.errorf("malformed section header %q", line)
+	}
+	r.curveName, r.hashName = fields[0], fields[1]
+	r.curve, r.hash = nil, 0
+	if c, ok := curves[r.curveName]; ok {
+		r.curve = c()
+	}
+	if h, ok := hashes[r.hashName]; ok {
+		r.hash = h
+	}
+	return nil
+}
+
+func (r *Reader) field(v *Vector, line string) error {
+	i := strings.Index(line, " = ")
+	if i < 0 {
+		return r.errorf("malformed field %q", line)
+	}
+	name, value := line[:i], line[i+3:]
+	if name == "Result" {
+		if value == "" || (value[0] != 'P' && value[0] != 'F') {
+			return r.errorf("malformed result %q", value)
+		}
+		v.Valid = value[0] == 'P'
+		return nil
+	}
+	if name == "Msg" {
+		msg, err := hex.DecodeString(value)
+		if err != nil {
+			return r.errorf("malformed message: %s", err)
+		}
+		v.Msg = msg
+		return nil
+	}
+
+	var dst **big.Int
+	switch name {
+	case "d":
+		dst = &v.D
+	case "k":
+		dst = &v.K
+	case "Qx":
+		dst = &v.Qx
+	case "Qy":
+		dst = &v.Qy
+	case "R":
+		dst = &v.R
+	case "S":
+		dst = &v.S
+	default:
+		return r.errorf("unknown field %q", name)
+	}
+	n, ok := new(big.Int).SetString(value, 16)
+	if !ok {
+		return r.errorf("malformed integer %q", value)
+	}
+	*dst = n
+	return nil
+}
+
+// finish checks that v has all the fields of a SigVer or SigGen vector.
+func (r *Reader) finish(v *Vector) (*Vector, error) {
+	if v.Msg == nil || v.Qx == nil || v.Qy == nil || v.R == nil || v.S == nil {
+		return nil, fmt.Errorf("%w: line %d: incomplete vector", ErrMalformed, v.Line)
+	}
+	if (v.D == nil) != (v.K == nil) {
+		return nil, fmt.Errorf("%w: line %d: SigGen vector without d or k", ErrMalformed, v.Line)
+	}
+	return v, nil
+}
@@ -0,0 +1,114 @@
+package testvectors
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const sigGen = `#  CAVS 11.2
+#  "SigGen" information for "ecdsa2_values"
+
+[P-256,SHA-256]
+
+Msg = 73616d706c65
+d = 519b423d715f8b581f4fa8ee59f4771a5b44c8130b4e3eacca54a56dda72b464
+Qx = 1ccbe91c075fc7f4f033bfa248db8fccd3565de94bbfb12f3c59ff46c271bf83
+Qy = ce4014c68811f9a21a1fdb2c0e6113e06db7ca93b7404e78dc7ccd5ca89a4ca9
+k = 94a1bbb14b906a61a280f245f9e93c7f3b4a6247824f5d33b9670787642a68de
+R = f3ac8061b514795b8843e3d6629527ed2afd6b1f6a555a7acabb5e6f79c8c2ac
+S = 980469f75135ee913569505457321bd1c14212a2aaf41c5a2b7aca0283562e2a
+
+[P-192,SHA-256]
+
+Msg = 00
+d = 01
+Qx = 01
+Qy = 01
+k = 01
+R = 01
+S = 01
+`
+
+func TestSigGen(t *testing.T) {
+	r := NewReader(strings.NewReader(sigGen))
+	v, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next error: %s", err)
+	}
+	if v.Line != 6 || v.CurveName != "P-256" || v.Curve != elliptic.P256() || v.HashName != "SHA-256" || !v.Valid {
+		t.Errorf("unexpected vector %+v", v)
+	}
+	x, _ := v.Curve.ScalarBaseMult(v.D.Bytes())
+	if x.Cmp(v.Qx) != 0 {
+		t.Errorf("d does not match Qx")
+	}
+	x, _ = v.Curve.ScalarBaseMult(v.K.Bytes())
+	if x.Mod(x, v.Curve.Params().N).Cmp(v.R) != 0 {
+		t.Errorf("k does not match R")
+	}
+	pub := &ecdsa.PublicKey{Curve: v.Curve, X: v.Qx, Y: v.Qy}
+	if !ecdsa.Verify(pub, v.Digest(), v.R, v.S) {
+		t.Errorf("signature does not verify")
+	}
+
+	v, err = r.Next()
+	if err != nil {
+		t.Fatalf("Next error: %s", err)
+	}
+	if v.CurveName != "P-192" || v.Curve != nil {
+		t.Errorf("unsupported curve: got %s, %v", v.CurveName, v.Curve)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next after the last vector: got %v, want io.EOF", err)
+	}
+}
+
+func TestSigVer(t *testing.T) {
+	r := SigVer()
+	var n, valid int
+	for {
+		v, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next error: %s", err)
+		}
+		if v.Curve == nil || v.Hash == 0 || v.D != nil {
+			t.Fatalf("unexpected vector on line %d: %+v", v.Line, v)
+		}
+		n++
+		if v.Valid {
+			valid++
+		}
+		if testing.Short() {
+			continue
+		}
+		pub := &ecdsa.PublicKey{Curve: v.Curve, X: v.Qx, Y: v.Qy}
+		if v.Curve.IsOnCurve(v.Qx, v.Qy) && ecdsa.Verify(pub, v.Digest(), v.R, v.S) != v.Valid {
+			t.Errorf("crypto/ecdsa disagrees with line %d", v.Line)
+		}
+	}
+	if n == 0 || valid == 0 || valid == n {
+		t.Errorf("read %d vectors, %d of them valid", n, valid)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	for _, in := range []string{
+		"[P-256,SHA-256]\n\nMsg = 00\nQx = 01\n",
+		"[P-256,SHA-256]\n\nMsg = zz\n",
+		"[P-256,SHA-256]\n\nFoo = 01\n",
+		"[P-256]\n",
+		"Msg = 00\nQx = 01\nQy = 01\nR = 01\nS = 01\nResult = ?\n",
+		"Msg = 00\nQx = 01\nQy = 01\nR = 01\nS = 01\nd = 01\n",
+	} {
+		if _, err := NewReader(strings.NewReader(in)).Next(); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Next(%q): got %v, want ErrMalformed", in, err)
+		}
+	}
+}
@@ -0,0 +1,480 @@
+// Package timestamp binds signatures made with blinded keys to RFC 3161
+// timestamp tokens, so that a signer can prove that a signature existed
+// before its key expired or its blind was revealed.
+//
+// Sign signs a digest with a blinded key and asks a time stamping authority
+// for a token over the SHA-256 digest of the ASN.1 encoded signature. The
+// signature and the token are bundled into a Signature, which
+// VerifyWithTimestamp checks in a single call: the signature under the
+// blinded key, the binding of the token to the signature, and the CMS
+// signature of the authority and its certificate chain at the time of the
+// token.
+package timestamp
+
+import (
+	"bytes"
+	stdcontext "context"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// maxResponseSize bounds the size of the responses read by HTTPAuthority.
+const maxResponseSize = 1 << 20
+
+var (
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidSignedData     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidECPublicKey    = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3}
+	oidRSAEncryption  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	ecdsaWithSHA2Hash = map[int]crypto.Hash{2: crypto.SHA256, 3: crypto.SHA384, 4: crypto.SHA512}
+)
+
+// Errors returned by this package.
+var (
+	// ErrRejected is returned when the time stamping authority rejects a
+	// request, or returns a token for another request.
+	ErrRejected = errors.New("timestamp: request rejected")
+
+	// ErrInvalidToken is returned when a timestamp token or a Signature is
+	// malformed, or the token fails to verify.
+	ErrInvalidToken = errors.New("timestamp: invalid token")
+
+	// ErrInvalidSignature is returned when the timestamped signature fails
+	// to verify under the blinded key.
+	ErrInvalidSignature = errors.New("timestamp: invalid signature")
+)
+
+// Authority is an RFC 3161 time stamping authority.
+type Authority interface {
+	// Timestamp sends the DER encoded TimeStampReq req to the authority,
+	// and returns its DER encoded TimeStampResp.
+	Timestamp(ctx stdcontext.Context, req []byte) ([]byte, error)
+}
+
+// HTTPAuthority is an Authority reached over HTTP, as specified in RFC 3161,
+// Section 3.4.
+type HTTPAuthority struct {
+	// URL is the URL of the authority.
+	URL string
+	// Client is the HTTP client used to reach the authority. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Timestamp implements Authority.
+func (a *HTTPAuthority) Timestamp(ctx stdcontext.Context, req []byte) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: HTTP status %s", ErrRejected, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/timestamp-reply" {
+		return nil, fmt.Errorf("%w: content type %q", ErrRejected, ct)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// contentInfo is a CMS ContentInfo. Its content is explicitly tagged [0],
+// which encoding/asn1 does not support for a RawValue, so Content holds
+// the tagged value.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional,default:false"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// Signature is a signature made with a blinded key, bundled with an RFC
+// 3161 timestamp token over it.
+type Signature struct {
+	// Signature is the ASN.1 encoded ECDSA signature.
+	Signature []byte
+	// Token is the DER encoded TimeStampToken, a CMS ContentInfo.
+	Token []byte
+}
+
+type signatureASN1 struct {
+	Signature []byte
+	Token     asn1.RawValue
+}
+
+// Marshal returns the DER encoding of sig:
+//
+//	TimestampedSignature ::= SEQUENCE {
+//	    signature       OCTET STRING,
+//	    timeStampToken  TimeStampToken }
+func (sig *Signature) Marshal() ([]byte, error) {
+	return asn1.Marshal(signatureASN1{sig.Signature, asn1.RawValue{FullBytes: sig.Token}})
+}
+
+// Unmarshal parses a Signature encoded by Signature.Marshal.
+func Unmarshal(data []byte) (*Signature, error) {
+	var s signatureASN1
+	if rest, err := asn1.Unmarshal(data, &s); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	return &Signature{Signature: s.Signature, Token: s.Token.FullBytes}, nil
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// Sign signs hash with skS blinded by skB and context, as
+// ecdsa.BlindKeySignWithContext does, and obtains a timestamp token over the
+// signature from tsa.
+func Sign(ctx stdcontext.Context, rand io.Reader, tsa Authority, skS, skB *ecdsa.PrivateKey, hash, context []byte) (*Signature, error) {
+	r, s, err := ecdsa.BlindKeySignWithContext(rand, skS, skB, hash, context)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := asn1.Marshal(ecdsaSignature{r, s})
+	if err != nil {
+		return nil, err
+	}
+	token, err := Request(ctx, tsa, sig)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{Signature: sig, Token: token}, nil
+}
+
+// Request asks tsa for a timestamp token over the SHA-256 digest of data,
+// and returns the token after checking that it is for this request. The
+// signature of the token is not verified.
+func Request(ctx stdcontext.Context, tsa Authority, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	nonce, err := cryptorand.Int(cryptorand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	req, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest[:],
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out, err := tsa.Timestamp(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(out, &resp); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: malformed response", ErrRejected)
+	}
+	// Statuses 0 and 1 are granted and grantedWithMods.
+	if resp.Status.Status > 1 || len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("%w: status %d %q", ErrRejected, resp.Status.Status, resp.Status.StatusString)
+	}
+	token := resp.TimeStampToken.FullBytes
+	_, info, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 || !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, digest[:]) {
+		return nil, fmt.Errorf("%w: token for another request", ErrRejected)
+	}
+	return token, nil
+}
+
+// parseToken parses a TimeStampToken and the TSTInfo it signs.
+func parseToken(token []byte) (*signedData, *tstInfo, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(token, &ci); err != nil || len(rest) != 0 || !ci.ContentType.Equal(oidSignedData) ||
+		ci.Content.Class != asn1.ClassContextSpecific || ci.Content.Tag != 0 || !ci.Content.IsCompound {
+		return nil, nil, fmt.Errorf("%w: not a CMS SignedData", ErrInvalidToken)
+	}
+	var sd signedData
+	if rest, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil || len(rest) != 0 {
+		return nil, nil, fmt.Errorf("%w: malformed SignedData: %v", ErrInvalidToken, err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, nil, fmt.Errorf("%w: content is not a TSTInfo", ErrInvalidToken)
+	}
+	var info tstInfo
+	if rest, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil || len(rest) != 0 {
+		return nil, nil, fmt.Errorf("%w: malformed TSTInfo: %v", ErrInvalidToken, err)
+	}
+	return &sd, &info, nil
+}
+
+func hashByOID(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// signatureAlgorithm returns the X.509 signature algorithm of a SignerInfo.
+func signatureAlgorithm(si *signerInfo) (x509.SignatureAlgorithm, crypto.Hash, bool) {
+	h, ok := hashByOID(si.DigestAlgorithm.Algorithm)
+	if !ok {
+		return 0, 0, false
+	}
+	alg := si.SignatureAlgorithm.Algorithm
+	ecdsaAlgs := map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512}
+	rsaAlgs := map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA}
+	switch {
+	case alg.Equal(oidECPublicKey):
+		return ecdsaAlgs[h], h, true
+	case len(alg) == len(oidECDSAWithSHA)+1 && alg[:len(oidECDSAWithSHA)].Equal(oidECDSAWithSHA):
+		if ecdsaWithSHA2Hash[alg[len(alg)-1]] == h {
+			return ecdsaAlgs[h], h, true
+		}
+	case alg.Equal(oidRSAEncryption):
+		return rsaAlgs[h], h, true
+	case alg.Equal(oidSHA256WithRSA) && h == crypto.SHA256,
+		alg.Equal(oidSHA384WithRSA) && h == crypto.SHA384,
+		alg.Equal(oidSHA512WithRSA) && h == crypto.SHA512:
+		return rsaAlgs[h], h, true
+	}
+	return 0, 0, false
+}
+
+// signerCertificate returns the certificate of certs identified by the
+// signer identifier sid.
+func signerCertificate(certs []*x509.Certificate, sid asn1.RawValue) (*x509.Certificate, error) {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, cert := range certs {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: signer certificate not found", ErrInvalidToken)
+	}
+	var ias issuerAndSerialNumber
+	if rest, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: malformed signer identifier", ErrInvalidToken)
+	}
+	for _, cert := range certs {
+		if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return cert, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: signer certificate not found", ErrInvalidToken)
+}
+
+// VerifyOptions configures the verification of timestamp tokens.
+type VerifyOptions struct {
+	// Roots are the trusted roots of time stamping authorities. If nil,
+	// the system roots are used.
+	Roots *x509.CertPool
+	// Intermediates are intermediate certificates not included in tokens.
+	Intermediates *x509.CertPool
+}
+
+// VerifyToken verifies that token is a timestamp token over the SHA-256
+// digest of data, signed by an authority whose certificate chains to
+// opts.Roots and is valid for time stamping at the time of the token, and
+// returns that time.
+func VerifyToken(token, data []byte, opts VerifyOptions) (time.Time, error) {
+	sd, info, err := parseToken(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	digest := sha256.Sum256(data)
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest[:]) {
+		return time.Time{}, fmt.Errorf("%w: token is over other data", ErrInvalidToken)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return time.Time{}, fmt.Errorf("%w: %d signers", ErrInvalidToken, len(sd.SignerInfos))
+	}
+	si := &sd.SignerInfos[0]
+	var certs []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		if certs, err = x509.ParseCertificates(sd.Certificates.Bytes); err != nil {
+			return time.Time{}, fmt.Errorf("%w: malformed certificates: %v", ErrInvalidToken, err)
+		}
+	}
+	cert, err := signerCertificate(certs, si.SID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The signature is over the DER encoding of the signed attributes as a
+	// SET OF, which must include the content type and the digest of the
+	// TSTInfo.
+	alg, h, ok := signatureAlgorithm(si)
+	if !ok || len(si.SignedAttrs.FullBytes) == 0 {
+		return time.Time{}, fmt.Errorf("%w: unsupported signature algorithm", ErrInvalidToken)
+	}
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(si.SignedAttrs.FullBytes, &attrs, "set,tag:0"); err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed signed attributes", ErrInvalidToken)
+	}
+	hh := h.New()
+	hh.Write(sd.EncapContentInfo.EContent)
+	var haveType, haveDigest bool
+	for _, attr := range attrs {
+		if len(attr.Values) != 1 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var ct asn1.ObjectIdentifier
+			_, err := asn1.Unmarshal(attr.Values[0].FullBytes, &ct)
+			haveType = err == nil && ct.Equal(oidTSTInfo)
+		case attr.Type.Equal(oidMessageDigest):
+			var md []byte
+			_, err := asn1.Unmarshal(attr.Values[0].FullBytes, &md)
+			haveDigest = err == nil && bytes.Equal(md, hh.Sum(nil))
+		}
+	}
+	if !haveType || !haveDigest {
+		return time.Time{}, fmt.Errorf("%w: signed attributes do not match the TSTInfo", ErrInvalidToken)
+	}
+	signed := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+	if err := cert.CheckSignature(alg, signed, si.Signature); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	intermediates := x509.NewCertPool()
+	if opts.Intermediates != nil {
+		intermediates = opts.Intermediates.Clone()
+	}
+	for _, c := range certs {
+		intermediates.AddCert(c)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return info.GenTime, nil
+}
+
+// VerifyWithTimestamp verifies that sig is a valid signature of hash under
+// the blinded key pkR, and that its timestamp token is valid, as VerifyToken
+// checks. It returns the time of the token, before which the signature
+// existed.
+func VerifyWithTimestamp(pkR *ecdsa.PublicKey, hash []byte, sig *Signature, opts VerifyOptions) (time.Time, error) {
+	if sig == nil || !ecdsa.VerifyASN1(pkR, hash, sig.Signature) {
+		return time.Time{}, ErrInvalidSignature
+	}
+	return VerifyToken(sig.Token, sig.Signature, opts)
+}
@@ -0,0 +1,247 @@
+package timestamp
+
+import (
+	"bytes"
+	stdcontext "context"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// testAuthority is a time stamping authority issuing tokens signed with an
+// ECDSA key certified for time stamping by a test root.
+type testAuthority struct {
+	roots *x509.CertPool
+	cert  *x509.Certificate
+	key   *stdecdsa.PrivateKey
+	now   time.Time
+	// tamper, if set, modifies the TSTInfo before it is signed.
+	tamper func(*tstInfo)
+}
+
+func newTestAuthority(t *testing.T) *testAuthority {
+	caKey, _ := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test TSA Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, _ := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return &testAuthority{roots: roots, cert: cert, key: key, now: time.Now().UTC().Truncate(time.Second)}
+}
+
+func (a *testAuthority) Timestamp(ctx stdcontext.Context, data []byte) ([]byte, error) {
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(data, &req); err != nil {
+		return asn1.Marshal(timeStampResp{Status: pkiStatusInfo{Status: 2}})
+	}
+	info := tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   big.NewInt(42),
+		GenTime:        a.now,
+		Nonce:          req.Nonce,
+	}
+	if a.tamper != nil {
+		a.tamper(&info)
+	}
+	content, err := asn1.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(content)
+
+	ctValue, _ := asn1.Marshal(oidTSTInfo)
+	mdValue, _ := asn1.Marshal(digest[:])
+	attrs := []attribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: ctValue}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: mdValue}}},
+	}
+	attrsDER, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(attrsDER)
+	sig, err := stdecdsa.SignASN1(rand.Reader, a.key, attrsDigest[:])
+	if err != nil {
+		return nil, err
+	}
+	signedAttrs := append([]byte{0xa0}, attrsDER[1:]...)
+
+	sid, _ := asn1.Marshal(issuerAndSerialNumber{asn1.RawValue{FullBytes: a.cert.RawIssuer}, a.cert.SerialNumber})
+	sha256ID := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256ID},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: content},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.cert.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha256ID,
+			SignedAttrs:        asn1.RawValue{FullBytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	token, err := asn1.Marshal(contentInfo{ContentType: oidSignedData, Content: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd}})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(timeStampResp{TimeStampToken: asn1.RawValue{FullBytes: token}})
+}
+
+func TestSignAndVerify(t *testing.T) {
+	tsa := newTestAuthority(t)
+	skS, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	skB, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	context := []byte("epoch 1")
+	pkR, _ := ecdsa.BlindPublicKeyWithContext(elliptic.P256(), &skS.PublicKey, skB, context)
+	hash := sha256.Sum256([]byte("message"))
+
+	sig, err := Sign(stdcontext.Background(), rand.Reader, tsa, skS, skB, hash[:], context)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	data, err := sig.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	sig, err = Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+
+	opts := VerifyOptions{Roots: tsa.roots}
+	when, err := VerifyWithTimestamp(pkR, hash[:], sig, opts)
+	if err != nil {
+		t.Fatalf("VerifyWithTimestamp error: %s", err)
+	}
+	if !when.Equal(tsa.now) {
+		t.Errorf("timestamp %v, want %v", when, tsa.now)
+	}
+
+	other := sha256.Sum256([]byte("other message"))
+	if _, err := VerifyWithTimestamp(pkR, other[:], sig, opts); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyWithTimestamp of another message: got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := VerifyWithTimestamp(pkR, hash[:], sig, VerifyOptions{Roots: x509.NewCertPool()}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyWithTimestamp with another root: got %v, want ErrInvalidToken", err)
+	}
+
+	// A token over another signature doesn't verify. The other signature is
+	// of another message, since signatures of the same message are equal
+	// when nonces are derived deterministically, as in FIPS mode.
+	sig2, err := Sign(stdcontext.Background(), rand.Reader, tsa, skS, skB, other[:], context)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	swapped := &Signature{Signature: sig.Signature, Token: sig2.Token}
+	if _, err := VerifyWithTimestamp(pkR, hash[:], swapped, opts); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyWithTimestamp with another token: got %v, want ErrInvalidToken", err)
+	}
+
+	// A token whose signature doesn't cover its content doesn't verify.
+	corrupted := append([]byte{}, sig.Token...)
+	i := bytes.Index(corrupted, []byte{1, 2, 3, 4})
+	corrupted[i+3] ^= 1
+	if _, err := VerifyToken(corrupted, sig.Signature, opts); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken of a modified token: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRequestChecksResponse(t *testing.T) {
+	tsa := newTestAuthority(t)
+	tsa.tamper = func(info *tstInfo) { info.Nonce = big.NewInt(1) }
+	if _, err := Request(stdcontext.Background(), tsa, []byte("data")); !errors.Is(err, ErrRejected) {
+		t.Errorf("Request with a replayed token: got %v, want ErrRejected", err)
+	}
+	tsa.tamper = func(info *tstInfo) { info.MessageImprint.HashedMessage = make([]byte, 32) }
+	if _, err := Request(stdcontext.Background(), tsa, []byte("data")); !errors.Is(err, ErrRejected) {
+		t.Errorf("Request with a token for other data: got %v, want ErrRejected", err)
+	}
+}
+
+func TestHTTPAuthority(t *testing.T) {
+	tsa := newTestAuthority(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/timestamp-query" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		req, _ := io.ReadAll(r.Body)
+		resp, err := tsa.Timestamp(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(resp)
+	}))
+	defer srv.Close()
+
+	a := &HTTPAuthority{URL: srv.URL, Client: srv.Client()}
+	token, err := Request(stdcontext.Background(), a, []byte("data"))
+	if err != nil {
+		t.Fatalf("Request error: %s", err)
+	}
+	if _, err := VerifyToken(token, []byte("data"), VerifyOptions{Roots: tsa.roots}); err != nil {
+		t.Errorf("VerifyToken error: %s", err)
+	}
+
+	a.URL = srv.URL + "/missing"
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	cancel()
+	if _, err := Request(ctx, a, []byte("data")); !errors.Is(err, stdcontext.Canceled) {
+		t.Errorf("cancelled Request: got %v, want context.Canceled", err)
+	}
+}
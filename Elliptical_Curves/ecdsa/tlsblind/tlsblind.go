@@ -0,0 +1,361 @@
+// Package tlsblind authenticates TLS clients with certificates of blinded
+// ECDSA keys: every certificate is for a new blinding of a long-term key, so
+// servers can't link the connections of a client by its certificate, while
+// the client only stores the long-term key.
+//
+// A Client plugs into tls.Config.GetClientCertificate. It blinds its key with
+// a new blind for every handshake, or for every period of Config.Rotation,
+// and gets a certificate of the blinded key from Config.Issue, for example
+// from a CA that checks an ecdsa.Attestation of the blinded key before
+// signing it. Without Issue, certificates are self-signed, which only proves
+// possession of the blinded key.
+//
+// A CA only issues certificates of blinded keys whose holders prove, with an
+// ecdsa.PoP in answer to a single-use challenge, that they control both the
+// long-term key and the blind: a signature by the blinded key could be made
+// by anyone the blinded private key was delegated to. Its Enroll method can
+// be used as Config.Issue when the CA runs in the same process.
+//
+// TLS only supports ECDSA on P-256, P-384 and P-521.
+package tlsblind
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+var (
+	// ErrUnsupportedCurve is returned when a key is on a curve other than
+	// P-256, P-384 and P-521.
+	ErrUnsupportedCurve = errors.New("tlsblind: unsupported curve")
+
+	// ErrInvalidPoP is returned by CA.Issue when the proof of possession
+	// of a key is missing, stale or doesn't verify, or answers a challenge
+	// that the CA didn't issue, that expired or that was already used.
+	ErrInvalidPoP = errors.New("tlsblind: invalid proof of possession")
+)
+
+// clockSkew is the margin added to both ends of the validity of
+// certificates.
+const clockSkew = 5 * time.Minute
+
+func tlsCurve(c elliptic.Curve) bool {
+	switch c {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return true
+	}
+	return false
+}
+
+// Signer signs with a long-term key blinded by a blind and context, without
+// computing the blinded private key. It implements crypto.Signer, and its
+// public key is the blinded public key, as a *crypto/ecdsa.PublicKey, so it
+// can be used by crypto/tls and crypto/x509.
+type Signer struct {
+	skS, skB *ecdsa.PrivateKey
+	context  []byte
+	pkR      *stdecdsa.PublicKey
+}
+
+// NewSigner returns a Signer for skS blinded by skB and context.
+func NewSigner(skS, skB *ecdsa.PrivateKey, context []byte) (*Signer, error) {
+	if skS == nil || !tlsCurve(skS.Curve) {
+		return nil, fmt.Errorf("%w: key must be on P-256, P-384 or P-521", ErrUnsupportedCurve)
+	}
+	pkR, err := ecdsa.BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		skS:     skS,
+		skB:     skB,
+		context: append([]byte{}, context...),
+		pkR:     ecdsa.ToStdPublicKey(pkR),
+	}, nil
+}
+
+// Public returns the blinded public key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.pkR
+}
+
+// Sign signs digest with the blinded key, and returns the signature in ASN.1
+// DER encoding. If opts declares a hash function, digest must be as long as
+// its output.
+func (s *Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if h := opts.HashFunc(); h != 0 && len(digest) != h.Size() {
+		return nil, fmt.Errorf("%w: digest of %d bytes does not match %v", ecdsa.ErrInvalidDigest, len(digest), h)
+	}
+	r, ss, err := ecdsa.BlindKeySignWithContext(rand, s.skS, s.skB, digest, s.context)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(ss)
+	})
+	return b.Bytes()
+}
+
+// PoP proves possession of the long-term key and blind of s in answer to
+// challenge, for the enrollment of the blinded key with a CA.
+func (s *Signer) PoP(challenge []byte) (*ecdsa.PoP, error) {
+	return ecdsa.GeneratePoPWithContext(s.skS, s.skB, challenge, s.context)
+}
+
+// Config configures a Client.
+type Config struct {
+	// Key is the long-term key of the client. It must be set.
+	Key *ecdsa.PrivateKey
+
+	// Context is the blinding context, for example the name of the service
+	// the client authenticates to.
+	Context []byte
+
+	// Rotation is how long a blinded key and its certificate are used. If
+	// zero, every handshake uses a new blinded key.
+	Rotation time.Duration
+
+	// Template is the certificate template, for example with the Subject
+	// of the certificates. Its serial number, validity, public key and key
+	// usages are set by the Client. If nil, certificates have an empty
+	// subject.
+	Template *x509.Certificate
+
+	// Issue returns the certificate chain, leaf first and DER encoded, of
+	// the blinded key of signer, for example by signing template with a
+	// CA key, or by sending a CSR signed by signer to an online CA. If nil,
+	// the certificate is template self-signed by signer.
+	Issue func(template *x509.Certificate, signer *Signer) ([][]byte, error)
+
+	// Rand is the source of the blinds and nonces. If nil,
+	// crypto/rand.Reader is used.
+	Rand io.Reader
+}
+
+// Client provides TLS client certificates of blinded keys. It is safe for
+// concurrent use.
+type Client struct {
+	cfg Config
+	now func() time.Time
+
+	mu     sync.Mutex
+	cert   *tls.Certificate
+	period time.Time
+}
+
+// NewClient returns a Client for cfg.
+func NewClient(cfg Config) (*Client, error) {
+	return newClient(cfg, time.Now)
+}
+
+func newClient(cfg Config, now func() time.Time) (*Client, error) {
+	if cfg.Key == nil || !tlsCurve(cfg.Key.Curve) {
+		return nil, fmt.Errorf("%w: key must be on P-256, P-384 or P-521", ErrUnsupportedCurve)
+	}
+	if cfg.Rotation < 0 {
+		return nil, errors.New("tlsblind: negative rotation period")
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = cryptorand.Reader
+	}
+	cfg.Context = append([]byte{}, cfg.Context...)
+	return &Client{cfg: cfg, now: now}, nil
+}
+
+// TLSConfig returns a clone of base, or a new configuration if base is nil,
+// that authenticates with the certificates of c.
+func (c *Client) TLSConfig(base *tls.Config) *tls.Config {
+	var conf *tls.Config
+	if base != nil {
+		conf = base.Clone()
+	} else {
+		conf = new(tls.Config)
+	}
+	conf.Certificates = nil
+	conf.GetClientCertificate = c.GetClientCertificate
+	return conf
+}
+
+// GetClientCertificate returns the certificate of the current blinded key,
+// creating a new one for every call if the rotation period is zero, and
+// otherwise for the first call of every period. It implements
+// tls.Config.GetClientCertificate.
+func (c *Client) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	now := c.now()
+	if c.cfg.Rotation == 0 {
+		return c.newCertificate(now.Truncate(time.Hour), time.Hour)
+	}
+
+	period := now.Truncate(c.cfg.Rotation)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cert != nil && c.period.Equal(period) {
+		return c.cert, nil
+	}
+	cert, err := c.newCertificate(period, c.cfg.Rotation)
+	if err != nil {
+		return nil, err
+	}
+	c.cert, c.period = cert, period
+	return cert, nil
+}
+
+// newCertificate blinds the key of c with a new blind and returns a
+// certificate of the blinded key, valid from start for length, with a margin
+// for clock skew. Validity periods are aligned so that they don't tell apart
+// the certificates of a period.
+func (c *Client) newCertificate(start time.Time, length time.Duration) (*tls.Certificate, error) {
+	skB, err := ecdsa.GenerateKey(c.cfg.Key.Curve, c.cfg.Rand)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewSigner(c.cfg.Key, skB, c.cfg.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	template := new(x509.Certificate)
+	if c.cfg.Template != nil {
+		*template = *c.cfg.Template
+	}
+	serial := make([]byte, 16)
+	if _, err := io.ReadFull(c.cfg.Rand, serial); err != nil {
+		return nil, err
+	}
+	template.SerialNumber = new(big.Int).SetBytes(serial)
+	template.NotBefore = start.Add(-clockSkew)
+	template.NotAfter = start.Add(length + clockSkew)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	var chain [][]byte
+	if c.cfg.Issue != nil {
+		if chain, err = c.cfg.Issue(template, signer); err != nil {
+			return nil, err
+		}
+		if len(chain) == 0 {
+			return nil, errors.New("tlsblind: empty certificate chain")
+		}
+	} else {
+		der, err := x509.CreateCertificate(c.cfg.Rand, template, template, signer.Public(), signer)
+		if err != nil {
+			return nil, err
+		}
+		chain = [][]byte{der}
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, err
+	}
+	if pub, ok := leaf.PublicKey.(*stdecdsa.PublicKey); !ok || !pub.Equal(signer.Public()) {
+		return nil, errors.New("tlsblind: certificate is not for the blinded key")
+	}
+	return &tls.Certificate{Certificate: chain, PrivateKey: signer, Leaf: leaf}, nil
+}
+
+// challengeWindow is how long a challenge of a CA can be answered.
+const challengeWindow = 5 * time.Minute
+
+// CA issues certificates of blinded keys to their holders, who must prove
+// possession of the long-term key and blind of each key. It is safe for
+// concurrent use.
+type CA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	context []byte
+	now     func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]time.Time
+}
+
+// NewCA returns a CA that signs certificates with key, whose certificate is
+// cert, for keys blinded with context.
+func NewCA(cert *x509.Certificate, key crypto.Signer, context []byte) *CA {
+	return &CA{
+		cert:    cert,
+		key:     key,
+		context: append([]byte{}, context...),
+		now:     time.Now,
+		pending: make(map[string]time.Time),
+	}
+}
+
+// Challenge returns a new challenge drawn from rand, which can be answered
+// once with a proof of possession within five minutes.
+func (ca *CA) Challenge(rand io.Reader) ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := io.ReadFull(rand, challenge); err != nil {
+		return nil, err
+	}
+	now := ca.now()
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	for c, expires := range ca.pending {
+		if !now.Before(expires) {
+			delete(ca.pending, c)
+		}
+	}
+	ca.pending[string(challenge)] = now.Add(challengeWindow)
+	return challenge, nil
+}
+
+// Issue signs a certificate of the blinded key of pop, with the fields of
+// template, using entropy from rand. pop must answer challenge, which is
+// consumed whether or not the proof verifies. It returns an error wrapping
+// ErrInvalidPoP if the proof or challenge are not valid.
+func (ca *CA) Issue(rand io.Reader, template *x509.Certificate, pop *ecdsa.PoP, challenge []byte) ([]byte, error) {
+	now := ca.now()
+	ca.mu.Lock()
+	expires, ok := ca.pending[string(challenge)]
+	delete(ca.pending, string(challenge))
+	ca.mu.Unlock()
+	if !ok || !now.Before(expires) {
+		return nil, fmt.Errorf("%w: unknown, expired or used challenge", ErrInvalidPoP)
+	}
+	if pop == nil || pop.BlindedKey == nil || !tlsCurve(pop.BlindedKey.Curve) {
+		return nil, fmt.Errorf("%w: key must be on P-256, P-384 or P-521", ErrInvalidPoP)
+	}
+	if err := ecdsa.VerifyPoP(pop, challenge, ca.context, now.Add(clockSkew), challengeWindow+2*clockSkew); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPoP, err)
+	}
+	return x509.CreateCertificate(rand, template, ca.cert, ecdsa.ToStdPublicKey(pop.BlindedKey), ca.key)
+}
+
+// Enroll gets a challenge, answers it with a proof of possession by signer,
+// and returns the certificate of the blinded key of signer issued from
+// template, followed by the certificate of the CA. It has the signature of
+// Config.Issue.
+func (ca *CA) Enroll(template *x509.Certificate, signer *Signer) ([][]byte, error) {
+	challenge, err := ca.Challenge(cryptorand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	pop, err := signer.PoP(challenge)
+	if err != nil {
+		return nil, err
+	}
+	der, err := ca.Issue(cryptorand.Reader, template, pop, challenge)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{der, ca.cert.Raw}, nil
+}
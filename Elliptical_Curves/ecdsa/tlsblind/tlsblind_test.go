@@ -0,0 +1,266 @@
+package tlsblind
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func TestSigner(t *testing.T) {
+	skS, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	skB, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	context := []byte("tlsblind test")
+	s, err := NewSigner(skS, skB, context)
+	if err != nil {
+		t.Fatalf("NewSigner error: %s", err)
+	}
+	pkR, _ := ecdsa.BlindPublicKeyWithContext(skS.Curve, &skS.PublicKey, skB, context)
+	pub := s.Public().(*stdecdsa.PublicKey)
+	if !pub.Equal(ecdsa.ToStdPublicKey(pkR)) {
+		t.Fatal("Public is not the blinded public key")
+	}
+
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !stdecdsa.VerifyASN1(pub, digest[:], sig) {
+		t.Error("signature does not verify with crypto/ecdsa")
+	}
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA384); !errors.Is(err, ecdsa.ErrInvalidDigest) {
+		t.Errorf("Sign with another hash: got %v, want ErrInvalidDigest", err)
+	}
+
+	bp, _ := ecdsa.GenerateKey(brainpool.P256r1(), rand.Reader)
+	if _, err := NewSigner(bp, bp, nil); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("NewSigner on brainpoolP256r1: got %v, want ErrUnsupportedCurve", err)
+	}
+	if _, err := NewClient(Config{Key: bp}); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("NewClient on brainpoolP256r1: got %v, want ErrUnsupportedCurve", err)
+	}
+}
+
+// testCA is a CA that issues the certificates of a test server and of
+// blinded client keys.
+type testCA struct {
+	cert *x509.Certificate
+	key  *stdecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, _ := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tlsblind test CA"},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert, key, pool}
+}
+
+func (ca *testCA) Issue(template *x509.Certificate, signer *Signer) ([][]byte, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, signer.Public(), ca.key)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{der}, nil
+}
+
+func (ca *testCA) serverConfig(t *testing.T) *tls.Config {
+	key, _ := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     []string{"server.example"},
+		NotBefore:    time.Now().Add(-24 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool,
+	}
+}
+
+// handshake connects a client with clientConf to a server with serverConf,
+// and returns the client certificate seen by the server.
+func handshake(t *testing.T, clientConf, serverConf *tls.Config) *x509.Certificate {
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+	server := tls.Server(s, serverConf)
+	errc := make(chan error, 1)
+	go func() {
+		err := server.Handshake()
+		if err != nil {
+			// Unblock the client, which may be writing to the pipe.
+			s.Close()
+		}
+		errc <- err
+	}()
+	if err := tls.Client(c, clientConf).Handshake(); err != nil {
+		c.Close()
+		t.Fatalf("client handshake error: %s (server: %v)", err, <-errc)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("server handshake error: %s", err)
+	}
+	return server.ConnectionState().PeerCertificates[0]
+}
+
+func TestClient(t *testing.T) {
+	ca := newTestCA(t)
+	serverConf := ca.serverConfig(t)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for _, tt := range []struct {
+		name     string
+		rotation time.Duration
+	}{
+		{"PerConnection", 0},
+		{"Hourly", time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			client, err := newClient(Config{
+				Key:      key,
+				Context:  []byte("server.example"),
+				Rotation: tt.rotation,
+				Template: &x509.Certificate{Subject: pkix.Name{CommonName: "anonymous"}},
+				Issue:    ca.Issue,
+			}, func() time.Time { return now })
+			if err != nil {
+				t.Fatalf("NewClient error: %s", err)
+			}
+			clock := func() time.Time { return now }
+			clientConf := client.TLSConfig(&tls.Config{RootCAs: ca.pool, ServerName: "server.example", Time: clock})
+			serverConf := serverConf.Clone()
+			serverConf.Time = clock
+
+			first := handshake(t, clientConf, serverConf)
+			second := handshake(t, clientConf, serverConf)
+			if first.Subject.CommonName != "anonymous" {
+				t.Errorf("subject = %s, want CN=anonymous", first.Subject)
+			}
+			pub := first.PublicKey.(*stdecdsa.PublicKey)
+			if pub.Equal(ecdsa.ToStdPublicKey(&key.PublicKey)) {
+				t.Error("certificate is for the long-term key")
+			}
+			if same := pub.Equal(second.PublicKey); same != (tt.rotation != 0) {
+				t.Errorf("consecutive connections use the same key = %v", same)
+			}
+
+			now = now.Add(2 * time.Hour)
+			third := handshake(t, clientConf, serverConf)
+			if pub.Equal(third.PublicKey) {
+				t.Error("key not rotated after the rotation period")
+			}
+			if !third.NotBefore.Before(now) || !third.NotAfter.After(now) {
+				t.Errorf("certificate valid from %v to %v, not at %v", third.NotBefore, third.NotAfter, now)
+			}
+		})
+	}
+}
+
+func TestCA(t *testing.T) {
+	test := newTestCA(t)
+	ca := NewCA(test.cert, test.key, []byte("server.example"))
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	client, err := NewClient(Config{
+		Key:     key,
+		Context: []byte("server.example"),
+		Issue:   ca.Enroll,
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %s", err)
+	}
+	cert := handshake(t, client.TLSConfig(&tls.Config{RootCAs: test.pool, ServerName: "server.example"}), test.serverConfig(t))
+	if pub := cert.PublicKey.(*stdecdsa.PublicKey); pub.Equal(ecdsa.ToStdPublicKey(&key.PublicKey)) {
+		t.Error("certificate is for the long-term key")
+	}
+
+	skB, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := NewSigner(key, skB, []byte("server.example"))
+	template := &x509.Certificate{SerialNumber: big.NewInt(3), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	challenge, _ := ca.Challenge(rand.Reader)
+	pop, _ := signer.PoP(challenge)
+	if _, err := ca.Issue(rand.Reader, template, pop, challenge); err != nil {
+		t.Fatalf("Issue error: %s", err)
+	}
+	if _, err := ca.Issue(rand.Reader, template, pop, challenge); !errors.Is(err, ErrInvalidPoP) {
+		t.Errorf("Issue with a used challenge: got %v, want ErrInvalidPoP", err)
+	}
+
+	// A key blinded with another context, or by a holder of the blinded
+	// private key only, is refused.
+	other, _ := NewSigner(key, skB, []byte("other.example"))
+	challenge, _ = ca.Challenge(rand.Reader)
+	pop, _ = other.PoP(challenge)
+	if _, err := ca.Issue(rand.Reader, template, pop, challenge); !errors.Is(err, ErrInvalidPoP) {
+		t.Errorf("Issue for another context: got %v, want ErrInvalidPoP", err)
+	}
+	skR, _ := ecdsa.BlindPrivateKeyWithContext(key, skB, []byte("server.example"))
+	challenge, _ = ca.Challenge(rand.Reader)
+	pop, _ = ecdsa.GeneratePoPWithContext(skR, skB, challenge, []byte("server.example"))
+	pop.BlindedKey, _ = ecdsa.FromStdPublicKey(signer.Public().(*stdecdsa.PublicKey))
+	if _, err := ca.Issue(rand.Reader, template, pop, challenge); !errors.Is(err, ErrInvalidPoP) {
+		t.Errorf("Issue without the long-term key: got %v, want ErrInvalidPoP", err)
+	}
+
+	now := time.Now()
+	ca.now = func() time.Time { return now }
+	challenge, _ = ca.Challenge(rand.Reader)
+	pop, _ = signer.PoP(challenge)
+	now = now.Add(challengeWindow)
+	if _, err := ca.Issue(rand.Reader, template, pop, challenge); !errors.Is(err, ErrInvalidPoP) {
+		t.Errorf("Issue with an expired challenge: got %v, want ErrInvalidPoP", err)
+	}
+}
+
+func TestClientSelfSigned(t *testing.T) {
+	ca := newTestCA(t)
+	serverConf := ca.serverConfig(t)
+	serverConf.ClientAuth = tls.RequireAnyClientCert
+
+	key, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	client, err := NewClient(Config{Key: key})
+	if err != nil {
+		t.Fatalf("NewClient error: %s", err)
+	}
+	cert := handshake(t, client.TLSConfig(&tls.Config{RootCAs: ca.pool, ServerName: "server.example"}), serverConf)
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		t.Errorf("certificate is not self-signed: %s", err)
+	}
+	if cert.PublicKey.(*stdecdsa.PublicKey).Curve != elliptic.P521() {
+		t.Error("certificate key is not on P-521")
+	}
+}
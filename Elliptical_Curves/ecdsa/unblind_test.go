@@ -0,0 +1,149 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// malformedKey is a blinded public key that UnblindPublicKey must reject.
+type malformedKey struct {
+	name string
+	pub  *PublicKey
+	err  error
+}
+
+// malformedKeys returns a corpus of malformed public keys on c, derived from
+// the valid key pub.
+func malformedKeys(c elliptic.Curve, pub *PublicKey) []malformedKey {
+	P := c.Params().P
+	other := elliptic.P256()
+	if c.Params().Name == other.Params().Name {
+		other = elliptic.P384()
+	}
+	otherKey, _ := GenerateKey(other, rand.Reader)
+
+	// A point with a valid X coordinate whose Y coordinate is not a root,
+	// which is on the quadratic twist of c if X is on neither curve.
+	var twistX *big.Int
+	for x := big.NewInt(1); twistX == nil; x.Add(x, one) {
+		if _, err := NewPoint(c, append([]byte{0x02}, x.FillBytes(make([]byte, (c.Params().BitSize+7)/8))...)); err != nil {
+			twistX = new(big.Int).Set(x)
+		}
+	}
+
+	return []malformedKey{
+		{"OffCurve", &PublicKey{c, pub.X, new(big.Int).Add(pub.Y, one)}, ErrPointNotOnCurve},
+		{"Twist", &PublicKey{c, twistX, big.NewInt(1)}, ErrPointNotOnCurve},
+		{"Identity", &PublicKey{c, new(big.Int), new(big.Int)}, ErrPointNotOnCurve},
+		{"XOutOfRange", &PublicKey{c, new(big.Int).Add(pub.X, P), pub.Y}, ErrPointNotOnCurve},
+		{"YOutOfRange", &PublicKey{c, pub.X, new(big.Int).Add(pub.Y, P)}, ErrPointNotOnCurve},
+		{"NegativeX", &PublicKey{c, new(big.Int).Neg(pub.X), pub.Y}, ErrPointNotOnCurve},
+		{"MissingX", &PublicKey{c, nil, pub.Y}, ErrPointNotOnCurve},
+		{"MissingY", &PublicKey{c, pub.X, nil}, ErrPointNotOnCurve},
+		{"MissingCurve", &PublicKey{nil, pub.X, pub.Y}, ErrPointNotOnCurve},
+		{"MissingKey", nil, ErrPointNotOnCurve},
+		{"WrongCurve", &PublicKey{other, pub.X, pub.Y}, ErrCurveMismatch},
+		{"WrongCurveKey", &otherKey.PublicKey, ErrCurveMismatch},
+	}
+}
+
+func TestUnblindMalformed(t *testing.T) {
+	testAllCurves(t, testUnblindMalformed)
+}
+
+func testUnblindMalformed(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	context := []byte("epoch 1")
+
+	for _, tc := range malformedKeys(c, &skS.PublicKey) {
+		pk, err := UnblindPublicKeyWithContext(c, tc.pub, skB, context)
+		if !errors.Is(err, tc.err) || pk != nil {
+			t.Errorf("%s: UnblindPublicKeyWithContext = %v, %v, want %v", tc.name, pk, err, tc.err)
+		}
+	}
+
+	pkR, err := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	blinds := []struct {
+		name string
+		bk   *PrivateKey
+		err  error
+	}{
+		{"MissingBlind", nil, ErrZeroBlind},
+		{"MissingD", &PrivateKey{PublicKey: skB.PublicKey}, ErrZeroBlind},
+		{"NegativeD", &PrivateKey{PublicKey: skB.PublicKey, D: new(big.Int).Neg(skB.D)}, ErrInvalidScalar},
+	}
+	for _, tc := range blinds {
+		pk, err := UnblindPublicKeyWithContext(c, pkR, tc.bk, context)
+		if !errors.Is(err, tc.err) || pk != nil {
+			t.Errorf("%s: UnblindPublicKeyWithContext = %v, %v, want %v", tc.name, pk, err, tc.err)
+		}
+	}
+	if _, err := UnblindPublicKeyWithContext(nil, pkR, skB, context); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("UnblindPublicKeyWithContext without a curve: got %v, want ErrPointNotOnCurve", err)
+	}
+}
+
+// toyCurve returns the curve y² = x³ - 3x + 3 over GF(65519), which has
+// 2 * 32611 points. It is too small to be secure, but its point of order 2
+// exercises the subgroup check that the registered curves do not need.
+func toyCurve() *elliptic.CurveParams {
+	return &elliptic.CurveParams{
+		Name:    "toy",
+		P:       big.NewInt(65519),
+		N:       big.NewInt(32611),
+		B:       big.NewInt(3),
+		Gx:      big.NewInt(65517),
+		Gy:      big.NewInt(65518),
+		BitSize: 16,
+	}
+}
+
+func TestUnblindSmallSubgroup(t *testing.T) {
+	c := toyCurve()
+	skB, _ := GenerateKey(elliptic.P256(), rand.Reader)
+
+	low := &PublicKey{c, big.NewInt(5683), new(big.Int)}
+	if !c.IsOnCurve(low.X, low.Y) {
+		t.Fatalf("point of order 2 is not on the curve")
+	}
+	if _, err := UnblindPublicKey(c, low, skB); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("UnblindPublicKey with a point of order 2: got %v, want ErrPointNotOnCurve", err)
+	}
+
+	// A point in the prime-order subgroup passes validation, but the curve
+	// has no blinding parameters.
+	G := &PublicKey{c, c.Gx, c.Gy}
+	if _, err := UnblindPublicKey(c, G, skB); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("UnblindPublicKey on an unregistered curve: got %v, want ErrInvalidCurve", err)
+	}
+}
+
+// faultyCurve is P-256 with a ScalarMult that returns an off-curve point, as
+// a fault in the arithmetic would.
+type faultyCurve struct {
+	elliptic.Curve
+}
+
+func (fc faultyCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	x, y = fc.Curve.ScalarMult(x, y, k)
+	return x, new(big.Int).Add(y, one)
+}
+
+func TestUnblindFault(t *testing.T) {
+	c := faultyCurve{elliptic.P256()}
+	skS, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	skB, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	pkR := &PublicKey{c, skS.X, skS.Y}
+
+	pk, err := UnblindPublicKey(c, pkR, skB)
+	if !errors.Is(err, ErrPointNotOnCurve) || pk != nil {
+		t.Errorf("UnblindPublicKey with a faulty curve = %v, %v, want ErrPointNotOnCurve", pk, err)
+	}
+}
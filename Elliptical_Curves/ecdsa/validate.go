@@ -0,0 +1,64 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// ValidatePublicKey performs full public key validation of pub for the curve
+// c, as specified in SEC 1, Version 2.0, Section 3.2.2.1. It checks that pub
+// is on c, that its coordinates are in the range [0, P-1], that it is not the
+// point at infinity, and that it is in the subgroup generated by the base
+// point. It returns nil if pub is valid, and otherwise an error wrapping
+// ErrCurveMismatch or ErrPointNotOnCurve.
+//
+// The subgroup check is skipped for the NIST and Brainpool curves, which have
+// prime order, as every point on them other than the point at infinity is in
+// the subgroup.
+func ValidatePublicKey(c elliptic.Curve, pub *PublicKey) error {
+	if c == nil || pub == nil || pub.Curve == nil {
+		return wrapError(ErrPointNotOnCurve, "missing public key or curve")
+	}
+	if c.Params() == nil || c.Params().P == nil || c.Params().N == nil {
+		return wrapError(ErrInvalidCurve, "missing curve parameters")
+	}
+	if pub.Curve != c {
+		return wrapError(ErrCurveMismatch, "public key is not on %s", c.Params().Name)
+	}
+	if pub.X == nil || pub.Y == nil {
+		return wrapError(ErrPointNotOnCurve, "missing public key coordinates")
+	}
+
+	params := c.Params()
+	if pub.X.Sign() < 0 || pub.X.Cmp(params.P) >= 0 ||
+		pub.Y.Sign() < 0 || pub.Y.Cmp(params.P) >= 0 {
+		return wrapError(ErrPointNotOnCurve, "public key coordinate out of range")
+	}
+	if pub.X.Sign() == 0 && pub.Y.Sign() == 0 {
+		return wrapError(ErrPointNotOnCurve, "public key is the point at infinity")
+	}
+	if !c.IsOnCurve(pub.X, pub.Y) {
+		return wrapError(ErrPointNotOnCurve, "public key is not on %s", params.Name)
+	}
+	if !hasPrimeOrder(c) {
+		x, y := c.ScalarMult(pub.X, pub.Y, params.N.Bytes())
+		if x.Sign() != 0 || y.Sign() != 0 {
+			return wrapError(ErrPointNotOnCurve, "public key is not in the prime-order subgroup")
+		}
+	}
+	return nil
+}
+
+// hasPrimeOrder reports whether c is known to have a prime number of points,
+// in which case the subgroup check of ValidatePublicKey is redundant.
+func hasPrimeOrder(c elliptic.Curve) bool {
+	switch c.Params() {
+	case elliptic.P224().Params(), elliptic.P256().Params(),
+		elliptic.P384().Params(), elliptic.P521().Params(),
+		brainpool.P256r1().Params(), brainpool.P384r1().Params(),
+		brainpool.P512r1().Params():
+		return true
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestValidatePublicKey(t *testing.T) {
+	testAllCurves(t, testValidatePublicKey)
+}
+
+func testValidatePublicKey(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	P := c.Params().P
+
+	if err := ValidatePublicKey(c, &skS.PublicKey); err != nil {
+		t.Fatalf("ValidatePublicKey rejected a valid key: %v", err)
+	}
+
+	other := elliptic.P256()
+	if c == other {
+		other = elliptic.P384()
+	}
+	invalid := []struct {
+		name string
+		pub  *PublicKey
+		err  error
+	}{
+		{"OffCurve", &PublicKey{c, skS.X, new(big.Int).Add(skS.Y, big.NewInt(1))}, ErrPointNotOnCurve},
+		{"Infinity", &PublicKey{c, new(big.Int), new(big.Int)}, ErrPointNotOnCurve},
+		{"XOutOfRange", &PublicKey{c, new(big.Int).Add(skS.X, P), skS.Y}, ErrPointNotOnCurve},
+		{"NegativeY", &PublicKey{c, skS.X, new(big.Int).Sub(skS.Y, P)}, ErrPointNotOnCurve},
+		{"MissingY", &PublicKey{c, skS.X, nil}, ErrPointNotOnCurve},
+		{"WrongCurve", &PublicKey{other, skS.X, skS.Y}, ErrCurveMismatch},
+	}
+
+	hashed := testDigest("testing")
+	r, s, err := Sign(rand.Reader, skS, hashed)
+	if err != nil {
+		t.Fatalf("error signing: %s", err)
+	}
+	for _, tc := range invalid {
+		if err := ValidatePublicKey(c, tc.pub); !errors.Is(err, tc.err) {
+			t.Errorf("%s: ValidatePublicKey error = %v, want %v", tc.name, err, tc.err)
+		}
+		if _, err := BlindPublicKey(c, tc.pub, skB); !errors.Is(err, tc.err) {
+			t.Errorf("%s: BlindPublicKey error = %v, want %v", tc.name, err, tc.err)
+		}
+		if _, err := UnblindPublicKey(c, tc.pub, skB); !errors.Is(err, tc.err) {
+			t.Errorf("%s: UnblindPublicKey error = %v, want %v", tc.name, err, tc.err)
+		}
+		if tc.pub.Curve == c && Verify(tc.pub, hashed, r, s) {
+			t.Errorf("%s: Verify accepted an invalid public key", tc.name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ecdsa
+
+import "math/big"
+
+// VerifierSession verifies signatures under a single public key, such as the
+// blinded key of an issuer that checks many tokens. The public key is
+// validated once by NewVerifierSession rather than on every call, which saves
+// the allocations of the on-curve check.
+//
+// A VerifierSession is safe for concurrent use.
+type VerifierSession struct {
+	pub *PublicKey
+}
+
+// NewVerifierSession returns a session verifying signatures under pub. It
+// returns an error if pub does not pass ValidatePublicKey, or if FIPS mode is
+// enabled and its curve is not approved. pub must not be modified while the
+// session is in use.
+func NewVerifierSession(pub *PublicKey) (*VerifierSession, error) {
+	if pub == nil {
+		return nil, wrapError(ErrPointNotOnCurve, "missing public key")
+	}
+	if err := ValidatePublicKey(pub.Curve, pub); err != nil {
+		return nil, err
+	}
+	if err := fipsCheckCurve(pub.Curve); err != nil {
+		return nil, err
+	}
+	return &VerifierSession{pub: pub}, nil
+}
+
+// PublicKey returns the public key of the session.
+func (vs *VerifierSession) PublicKey() *PublicKey {
+	return vs.pub
+}
+
+// Verify is like the package-level Verify under the public key of the
+// session. Like it, Verify rejects every signature of a session whose curve
+// is not approved once FIPS mode is enabled.
+func (vs *VerifierSession) Verify(hash []byte, r, s *big.Int) bool {
+	N := vs.pub.Curve.Params().N
+	if fipsCheckCurve(vs.pub.Curve) != nil || strictCheckDigest(hash) != nil || r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 ||
+		!verify(vs.pub, vs.pub.Curve, hash, r, s) {
+		observeVerifyFailure(vs.pub.Curve)
+		return false
+	}
+	return true
+}
+
+// VerifyASN1 is like the package-level VerifyASN1 under the public key of the
+// session.
+func (vs *VerifierSession) VerifyASN1(hash, sig []byte) bool {
+	r, s, ok := parseASN1Signature(sig)
+	if !ok {
+		return false
+	}
+	return vs.Verify(hash, r, s)
+}
@@ -0,0 +1,98 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func TestVerifierSession(t *testing.T) {
+	testAllCurves(t, testVerifierSession)
+}
+
+func testVerifierSession(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	pkR, err := BlindPublicKey(c, &skS.PublicKey, skB)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	vs, err := NewVerifierSession(pkR)
+	if err != nil {
+		t.Fatalf("NewVerifierSession error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hashed := []byte{byte(i), 't', 'e', 's', 't'}
+			r, s, err := BlindKeySign(rand.Reader, skS, skB, hashed)
+			if err != nil {
+				t.Errorf("BlindKeySign error: %s", err)
+				return
+			}
+			if !vs.Verify(hashed, r, s) {
+				t.Errorf("Verify failed")
+			}
+			if vs.Verify(hashed, s, r) {
+				t.Errorf("Verify accepted a swapped signature")
+			}
+			if vs.Verify(hashed, r, new(big.Int)) || vs.Verify(hashed, nil, s) {
+				t.Errorf("Verify accepted an out of range signature")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	hashed := testDigest("testing")
+	sig, err := SignASN1(rand.Reader, skS, hashed)
+	if err != nil {
+		t.Fatalf("SignASN1 error: %s", err)
+	}
+	vsS, _ := NewVerifierSession(&skS.PublicKey)
+	if !vsS.VerifyASN1(hashed, sig) {
+		t.Errorf("VerifyASN1 failed")
+	}
+	if vsS.VerifyASN1(hashed, sig[:len(sig)-1]) {
+		t.Errorf("VerifyASN1 accepted a truncated signature")
+	}
+	if vs.VerifyASN1(hashed, sig) {
+		t.Errorf("VerifyASN1 accepted a signature under another key")
+	}
+
+	bad := &PublicKey{Curve: c, X: new(big.Int).Set(pkR.X), Y: new(big.Int).Add(pkR.Y, one)}
+	if _, err := NewVerifierSession(bad); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Errorf("NewVerifierSession with an invalid key: got %v, want ErrPointNotOnCurve", err)
+	}
+}
+
+func BenchmarkVerifierSession(b *testing.B) {
+	benchmarkAllCurves(b, func(b *testing.B, curve elliptic.Curve) {
+		priv, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			b.Fatal(err)
+		}
+		hashed := testDigest("testing")
+		r, s, err := Sign(rand.Reader, priv, hashed)
+		if err != nil {
+			b.Fatal(err)
+		}
+		vs, err := NewVerifierSession(&priv.PublicKey)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if !vs.Verify(hashed, r, s) {
+				b.Fatal("verify failed")
+			}
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package ecdsa
+
+import (
+	"math/big"
+)
+
+// VerifyAgainstChainWithContext verifies the signature in r, s of hash against
+// pkS and against pkS blinded by each of blinds under the given context string.
+// Each blind is applied to pkS independently, as is the case for a set of
+// epochs, rather than being composed with the preceding ones.
+//
+// It returns the index into blinds of the first blind whose blinded key
+// verifies the signature, or -1 if the signature verifies under pkS itself.
+// The boolean result records whether any key verified the signature; if it
+// is false the index is meaningless.
+func VerifyAgainstChainWithContext(pkS *PublicKey, blinds []*PrivateKey, hash, context []byte, r, s *big.Int) (int, bool) {
+	if Verify(pkS, hash, r, s) {
+		return -1, true
+	}
+	for i, bk := range blinds {
+		pkR, err := BlindPublicKeyWithContext(pkS.Curve, pkS, bk, context)
+		if err != nil {
+			continue
+		}
+		if Verify(pkR, hash, r, s) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// VerifyAgainstChain verifies the signature in r, s of hash against pkS and
+// against pkS blinded by each of blinds with an empty context string. See
+// VerifyAgainstChainWithContext for details on the return values.
+func VerifyAgainstChain(pkS *PublicKey, blinds []*PrivateKey, hash []byte, r, s *big.Int) (int, bool) {
+	return VerifyAgainstChainWithContext(pkS, blinds, hash, nil, r, s)
+}
+
+// VerifyAgainstBlindedKeys verifies the signature in r, s of hash against pkS
+// and against each of the already blinded public keys in pkRs. It is useful
+// for relying parties that cache the blinded keys of each epoch instead of
+// the blinds themselves. The return values match those of
+// VerifyAgainstChainWithContext.
+func VerifyAgainstBlindedKeys(pkS *PublicKey, pkRs []*PublicKey, hash []byte, r, s *big.Int) (int, bool) {
+	if Verify(pkS, hash, r, s) {
+		return -1, true
+	}
+	for i, pkR := range pkRs {
+		if Verify(pkR, hash, r, s) {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,61 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestVerifyAgainstChain(t *testing.T) {
+	testAllCurves(t, testVerifyAgainstChain)
+}
+
+func testVerifyAgainstChain(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	blinds := make([]*PrivateKey, 4)
+	for i := range blinds {
+		blinds[i], _ = GenerateKey(c, rand.Reader)
+	}
+
+	hashed := testDigest("testing")
+	r, s, err := BlindKeySign(rand.Reader, skS, blinds[2], hashed)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	if i, ok := VerifyAgainstChain(&skS.PublicKey, blinds, hashed, r, s); !ok || i != 2 {
+		t.Errorf("VerifyAgainstChain = (%d, %v), want (2, true)", i, ok)
+	}
+
+	r, s, err = Sign(rand.Reader, skS, hashed)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if i, ok := VerifyAgainstChain(&skS.PublicKey, blinds, hashed, r, s); !ok || i != -1 {
+		t.Errorf("VerifyAgainstChain = (%d, %v), want (-1, true)", i, ok)
+	}
+
+	context := []byte("epoch-1")
+	r, s, err = BlindKeySignWithContext(rand.Reader, skS, blinds[3], hashed, context)
+	if err != nil {
+		t.Fatalf("BlindKeySignWithContext error: %s", err)
+	}
+	if _, ok := VerifyAgainstChain(&skS.PublicKey, blinds, hashed, r, s); ok {
+		t.Errorf("VerifyAgainstChain accepted a signature made under a different context")
+	}
+	if i, ok := VerifyAgainstChainWithContext(&skS.PublicKey, blinds, hashed, context, r, s); !ok || i != 3 {
+		t.Errorf("VerifyAgainstChainWithContext = (%d, %v), want (3, true)", i, ok)
+	}
+
+	pkRs := make([]*PublicKey, len(blinds))
+	for i, bk := range blinds {
+		pkRs[i], _ = BlindPublicKeyWithContext(c, &skS.PublicKey, bk, context)
+	}
+	if i, ok := VerifyAgainstBlindedKeys(&skS.PublicKey, pkRs, hashed, r, s); !ok || i != 3 {
+		t.Errorf("VerifyAgainstBlindedKeys = (%d, %v), want (3, true)", i, ok)
+	}
+
+	hashed[0] ^= 0xff
+	if _, ok := VerifyAgainstChainWithContext(&skS.PublicKey, blinds, hashed, context, r, s); ok {
+		t.Errorf("VerifyAgainstChainWithContext always works!")
+	}
+}
@@ -0,0 +1,100 @@
+package ecdsa
+
+import "strconv"
+
+// VerifyReason is the outcome of VerifyDetailed: VerifyOK for a valid
+// signature, or the first check that rejected it.
+type VerifyReason int
+
+const (
+	// VerifyOK is returned for a valid signature.
+	VerifyOK VerifyReason = iota
+
+	// VerifyBadEncoding is returned for a signature that is not encoded in
+	// any profile ParseSignature accepts.
+	VerifyBadEncoding
+
+	// VerifyOutOfRange is returned for a signature whose r or s value is
+	// not in [1, N-1], where N is the order of the curve.
+	VerifyOutOfRange
+
+	// VerifyPointCheckFailed is returned for a public key that is missing,
+	// not on its curve or the point at infinity.
+	VerifyPointCheckFailed
+
+	// VerifyRefused is returned when the signature is not checked because
+	// of the mode of the package: in FIPS mode, for a curve that is not
+	// approved, and in strict mode, for a hash that is not as long as a
+	// digest.
+	VerifyRefused
+
+	// VerifyMismatch is returned for a well-formed signature that does not
+	// verify under the public key.
+	VerifyMismatch
+)
+
+// String returns a short name of the reason, suitable as a metric label.
+func (r VerifyReason) String() string {
+	switch r {
+	case VerifyOK:
+		return "ok"
+	case VerifyBadEncoding:
+		return "bad encoding"
+	case VerifyOutOfRange:
+		return "out of range"
+	case VerifyPointCheckFailed:
+		return "point check failed"
+	case VerifyRefused:
+		return "refused"
+	case VerifyMismatch:
+		return "mismatch"
+	default:
+		return "VerifyReason(" + strconv.Itoa(int(r)) + ")"
+	}
+}
+
+// VerifyDetailed verifies sig, a signature of hash under pub in any profile
+// ParseSignature accepts, and reports why it was rejected, for debugging and
+// for metrics that break failures down by cause.
+//
+// VerifyDetailed is opt-in: Verify and VerifyASN1 keep returning a bare
+// boolean, so that production paths expose a single failure outcome to
+// callers that might otherwise forward the reason to the party that
+// submitted the signature. The checks run in the same order as in Verify,
+// and VerifyDetailed returns VerifyOK exactly when Verify would accept the
+// decoded signature.
+func VerifyDetailed(pub *PublicKey, hash, sig []byte) VerifyReason {
+	reason := verifyDetailed(pub, hash, sig)
+	if reason != VerifyOK && pub != nil && pub.Curve != nil {
+		observeVerifyFailure(pub.Curve)
+	}
+	return reason
+}
+
+func verifyDetailed(pub *PublicKey, hash, sig []byte) VerifyReason {
+	if pub == nil || pub.Curve == nil {
+		return VerifyPointCheckFailed
+	}
+	if strictCheckDigest(hash) != nil {
+		return VerifyRefused
+	}
+	c := pub.Curve
+	if ValidatePublicKey(c, pub) != nil {
+		return VerifyPointCheckFailed
+	}
+	if fipsCheckCurve(c) != nil {
+		return VerifyRefused
+	}
+	parsed, _, err := ParseSignature(c, sig)
+	if err != nil {
+		return VerifyBadEncoding
+	}
+	r, s, N := parsed.R, parsed.S, c.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return VerifyOutOfRange
+	}
+	if !verify(pub, c, hash, r, s) {
+		return VerifyMismatch
+	}
+	return VerifyOK
+}
@@ -0,0 +1,71 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyDetailed(t *testing.T) {
+	testAllCurves(t, testVerifyDetailed)
+}
+
+func testVerifyDetailed(t *testing.T, c elliptic.Curve) {
+	priv, _ := GenerateKey(c, rand.Reader)
+	pub := &priv.PublicKey
+	hash := sha256.Sum256([]byte("testing"))
+	r, s, err := Sign(rand.Reader, priv, hash[:])
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	der, _ := EncodeSignature(pub, hash[:], r, s, ProfileDER)
+	raw, _ := EncodeSignature(pub, hash[:], r, s, ProfileP1363)
+	N := c.Params().N
+	outOfRange, _ := EncodeSignature(pub, hash[:], N, s, ProfileDER)
+	offCurve := &PublicKey{Curve: c, X: pub.X, Y: new(big.Int).Add(pub.Y, big.NewInt(1))}
+	other := sha256.Sum256([]byte("other"))
+
+	for _, tt := range []struct {
+		name string
+		pub  *PublicKey
+		hash []byte
+		sig  []byte
+		want VerifyReason
+	}{
+		{"DER", pub, hash[:], der, VerifyOK},
+		{"P1363", pub, hash[:], raw, VerifyOK},
+		{"bad encoding", pub, hash[:], []byte{1, 2, 3}, VerifyBadEncoding},
+		{"r = N", pub, hash[:], outOfRange, VerifyOutOfRange},
+		{"s = 0", pub, hash[:], append(raw[:len(raw)/2:len(raw)/2], make([]byte, len(raw)/2)...), VerifyOutOfRange},
+		{"off curve", offCurve, hash[:], der, VerifyPointCheckFailed},
+		{"nil key", nil, hash[:], der, VerifyPointCheckFailed},
+		{"other hash", pub, other[:], der, VerifyMismatch},
+	} {
+		if got := VerifyDetailed(tt.pub, tt.hash, tt.sig); got != tt.want {
+			t.Errorf("%s: VerifyDetailed = %s, want %s", tt.name, got, tt.want)
+		}
+		if tt.pub != nil {
+			if parsed, _, err := ParseSignature(c, tt.sig); err == nil &&
+				Verify(tt.pub, tt.hash, parsed.R, parsed.S) != (tt.want == VerifyOK) {
+				t.Errorf("%s: VerifyDetailed disagrees with Verify", tt.name)
+			}
+		}
+	}
+
+	SetStrictMode(true)
+	defer SetStrictMode(false)
+	if got := VerifyDetailed(pub, []byte("not a digest"), der); got != VerifyRefused {
+		t.Errorf("strict mode: VerifyDetailed = %s, want %s", got, VerifyRefused)
+	}
+}
+
+func TestVerifyReasonString(t *testing.T) {
+	if got := VerifyPointCheckFailed.String(); got != "point check failed" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := VerifyReason(42).String(); got != "VerifyReason(42)" {
+		t.Errorf("String() of an unknown reason = %q", got)
+	}
+}
@@ -0,0 +1,366 @@
+// Package voprf implements the verifiable oblivious pseudorandom function
+// (VOPRF) of RFC 9497 over the NIST curves supported by the ecdsa package.
+//
+// Keys, elements and scalars are the ecdsa package's PrivateKey, Point and
+// Scalar types, with their encodings: compressed points and fixed-length
+// big-endian scalars, which are the encodings RFC 9497 specifies for the
+// P256-SHA256, P384-SHA384 and P521-SHA512 suites. An OPRF key is thus an
+// ordinary ecdsa key pair, and outputs and proofs interoperate with other
+// implementations of the RFC.
+//
+// The protocol runs in two messages:
+//
+//	Client(pkS, input)                          Server(skS)
+//	------------------                          -----------
+//	state, blinded := client.Blind(input)
+//	                          blinded ->
+//	                                            evaluated, proof := BlindEvaluate(skS, blinded)
+//	                          <- evaluated, proof
+//	output := client.Finalize(state, evaluated, proof)
+package voprf
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+const modeVOPRF = 0x01
+
+// Errors returned by this package.
+var (
+	// ErrUnsupportedCurve is returned for curves without an RFC 9497 suite.
+	ErrUnsupportedCurve = errors.New("voprf: unsupported curve")
+
+	// ErrInvalidInput is returned when an input hashes to the identity
+	// element, which happens with negligible probability.
+	ErrInvalidInput = errors.New("voprf: invalid input")
+
+	// ErrInvalidProof is returned when an evaluation proof doesn't verify.
+	ErrInvalidProof = errors.New("voprf: invalid proof")
+
+	// ErrDeriveKey is returned when DeriveKey fails to find a key, which
+	// happens with negligible probability.
+	ErrDeriveKey = errors.New("voprf: failed to derive key")
+)
+
+// suite holds the parameters of the RFC 9497 suite of a curve.
+type suite struct {
+	c       elliptic.Curve
+	h       crypto.Hash
+	context []byte
+}
+
+func getSuite(c elliptic.Curve) (*suite, error) {
+	var s suite
+	var id string
+	switch c {
+	case elliptic.P256():
+		s.h, id = crypto.SHA256, "P256-SHA256"
+	case elliptic.P384():
+		s.h, id = crypto.SHA384, "P384-SHA384"
+	case elliptic.P521():
+		s.h, id = crypto.SHA512, "P521-SHA512"
+	default:
+		return nil, ErrUnsupportedCurve
+	}
+	s.c = c
+	s.context = append([]byte("OPRFV1-"), modeVOPRF, '-')
+	s.context = append(s.context, id...)
+	return &s, nil
+}
+
+func (s *suite) dst(prefix string) []byte {
+	return append([]byte(prefix), s.context...)
+}
+
+// hashToGroup hashes msg to a point with ecdsa.HashToCurve, and returns an
+// error if the point is the identity.
+func (s *suite) hashToGroup(msg []byte) (*ecdsa.Point, error) {
+	p, err := ecdsa.HashToCurve(s.c, msg, s.dst("HashToGroup-"))
+	if err != nil {
+		return nil, err
+	}
+	if p.IsIdentity() == 1 {
+		return nil, ErrInvalidInput
+	}
+	return p, nil
+}
+
+// hashToScalar hashes msg to a scalar with ecdsa.HashToScalar, using the
+// given DST, or HashToScalar-contextString if dst is nil.
+func (s *suite) hashToScalar(msg, dst []byte) *ecdsa.Scalar {
+	if dst == nil {
+		dst = s.dst("HashToScalar-")
+	}
+	k, err := ecdsa.HashToScalar(s.c, msg, dst)
+	if err != nil {
+		panic("voprf: internal error: " + err.Error())
+	}
+	return k
+}
+
+// lengthPrefixed appends I2OSP(len(x), 2) || x for each x to b.
+func lengthPrefixed(b []byte, xs ...[]byte) []byte {
+	for _, x := range xs {
+		var l [2]byte
+		binary.BigEndian.PutUint16(l[:], uint16(len(x)))
+		b = append(append(b, l[:]...), x...)
+	}
+	return b
+}
+
+// PrivateKey is a VOPRF server key.
+type PrivateKey struct {
+	s   *suite
+	k   *ecdsa.Scalar
+	pub *ecdsa.Point
+}
+
+// NewPrivateKey returns the VOPRF key of the ecdsa private key sk, which
+// must be on P-256, P-384 or P-521.
+func NewPrivateKey(sk *ecdsa.PrivateKey) (*PrivateKey, error) {
+	s, err := getSuite(sk.Curve)
+	if err != nil {
+		return nil, err
+	}
+	k, err := sk.Scalar()
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{s: s, k: k, pub: ecdsa.NewIdentityPoint(s.c).ScalarBaseMult(k)}, nil
+}
+
+// GenerateKey generates a VOPRF key on the curve c using entropy from rand.
+func GenerateKey(c elliptic.Curve, rand io.Reader) (*PrivateKey, error) {
+	if _, err := getSuite(c); err != nil {
+		return nil, err
+	}
+	sk, err := ecdsa.GenerateKey(c, rand)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(sk)
+}
+
+// DeriveKey deterministically derives a VOPRF key on the curve c from seed
+// and info, as DeriveKeyPair of RFC 9497 does.
+func DeriveKey(c elliptic.Curve, seed, info []byte) (*PrivateKey, error) {
+	s, err := getSuite(c)
+	if err != nil {
+		return nil, err
+	}
+	deriveInput := lengthPrefixed(append([]byte{}, seed...), info)
+	dst := s.dst("DeriveKeyPair")
+	for counter := 0; counter < 256; counter++ {
+		k := s.hashToScalar(append(deriveInput, byte(counter)), dst)
+		if k.IsZero() == 0 {
+			return &PrivateKey{s: s, k: k, pub: ecdsa.NewIdentityPoint(c).ScalarBaseMult(k)}, nil
+		}
+	}
+	return nil, ErrDeriveKey
+}
+
+// PublicKey returns the public key of k, which clients pass to NewClient.
+func (k *PrivateKey) PublicKey() *ecdsa.Point {
+	return ecdsa.NewIdentityPoint(k.s.c).Set(k.pub)
+}
+
+// Proof is a proof that an element was evaluated with the private key of a
+// known public key.
+type Proof struct {
+	C, S *ecdsa.Scalar
+}
+
+// Marshal encodes p as the concatenation of the encodings of C and S.
+func (p *Proof) Marshal() []byte {
+	return append(p.C.Bytes(), p.S.Bytes()...)
+}
+
+// UnmarshalProof decodes a proof for a key on the curve c encoded by
+// Proof.Marshal.
+func UnmarshalProof(c elliptic.Curve, data []byte) (*Proof, error) {
+	if len(data)%2 != 0 {
+		return nil, ErrInvalidProof
+	}
+	n := len(data) / 2
+	C, err := ecdsa.NewScalar(c).SetBytes(data[:n])
+	if err != nil {
+		return nil, err
+	}
+	S, err := ecdsa.NewScalar(c).SetBytes(data[n:])
+	if err != nil {
+		return nil, err
+	}
+	return &Proof{C: C, S: S}, nil
+}
+
+// composites computes the composite elements M and Z of a batched DLEQ proof
+// for the public key B, the blinded elements Cs and the evaluated elements
+// Ds. If k is not nil, Z is computed as [k]M.
+func (s *suite) composites(k *ecdsa.Scalar, B *ecdsa.Point, Cs, Ds []*ecdsa.Point) (M, Z *ecdsa.Point, err error) {
+	h := s.h.New()
+	h.Write(lengthPrefixed(nil, B.BytesCompressed(), s.dst("Seed-")))
+	seed := h.Sum(nil)
+
+	ds := make([]*ecdsa.Scalar, len(Cs))
+	for i := range Cs {
+		var idx [2]byte
+		binary.BigEndian.PutUint16(idx[:], uint16(i))
+		transcript := lengthPrefixed(nil, seed)
+		transcript = append(transcript, idx[:]...)
+		transcript = lengthPrefixed(transcript, Cs[i].BytesCompressed(), Ds[i].BytesCompressed())
+		transcript = append(transcript, "Composite"...)
+		ds[i] = s.hashToScalar(transcript, nil)
+	}
+	// The weights ds are public, so the composites can be computed with the
+	// variable-time ecdsa.MultiScalarMult.
+	M, err = ecdsa.MultiScalarMult(Cs, ds)
+	if err != nil {
+		return nil, nil, err
+	}
+	if k != nil {
+		return M, ecdsa.NewIdentityPoint(s.c).ScalarMult(k, M), nil
+	}
+	Z, err = ecdsa.MultiScalarMult(Ds, ds)
+	if err != nil {
+		return nil, nil, err
+	}
+	return M, Z, nil
+}
+
+func (s *suite) challenge(B, M, Z, t2, t3 *ecdsa.Point) *ecdsa.Scalar {
+	transcript := lengthPrefixed(nil, B.BytesCompressed(), M.BytesCompressed(),
+		Z.BytesCompressed(), t2.BytesCompressed(), t3.BytesCompressed())
+	return s.hashToScalar(append(transcript, "Challenge"...), nil)
+}
+
+// BlindEvaluate evaluates the PRF on a blinded element sent by a client, and
+// proves, using entropy from rand, that it used k.
+func (k *PrivateKey) BlindEvaluate(rand io.Reader, blinded *ecdsa.Point) (*ecdsa.Point, *Proof, error) {
+	r, err := ecdsa.GenerateKey(k.s.c, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	rs, err := r.Scalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	return k.blindEvaluate(rs, blinded)
+}
+
+// blindEvaluate is BlindEvaluate with the proof nonce r.
+func (k *PrivateKey) blindEvaluate(r *ecdsa.Scalar, blinded *ecdsa.Point) (*ecdsa.Point, *Proof, error) {
+	if blinded.Curve() != k.s.c || blinded.IsIdentity() == 1 {
+		return nil, nil, ErrInvalidInput
+	}
+	evaluated := ecdsa.NewIdentityPoint(k.s.c).ScalarMult(k.k, blinded)
+
+	M, Z, err := k.s.composites(k.k, k.pub, []*ecdsa.Point{blinded}, []*ecdsa.Point{evaluated})
+	if err != nil {
+		return nil, nil, err
+	}
+	t2 := ecdsa.NewIdentityPoint(k.s.c).ScalarBaseMult(r)
+	t3 := ecdsa.NewIdentityPoint(k.s.c).ScalarMult(r, M)
+	c := k.s.challenge(k.pub, M, Z, t2, t3)
+	s := ecdsa.NewScalar(k.s.c).Subtract(r, ecdsa.NewScalar(k.s.c).Multiply(c, k.k))
+	return evaluated, &Proof{C: c, S: s}, nil
+}
+
+// Evaluate computes the PRF output of input directly, without a client.
+func (k *PrivateKey) Evaluate(input []byte) ([]byte, error) {
+	P, err := k.s.hashToGroup(input)
+	if err != nil {
+		return nil, err
+	}
+	return k.s.finalize(input, ecdsa.NewIdentityPoint(k.s.c).ScalarMult(k.k, P)), nil
+}
+
+// finalize hashes an input and its unblinded evaluation to the PRF output.
+func (s *suite) finalize(input []byte, N *ecdsa.Point) []byte {
+	h := s.h.New()
+	h.Write(lengthPrefixed(nil, input, N.BytesCompressed()))
+	h.Write([]byte("Finalize"))
+	return h.Sum(nil)
+}
+
+// Client evaluates the PRF of a server with a known public key.
+type Client struct {
+	s   *suite
+	pub *ecdsa.Point
+}
+
+// NewClient returns a Client for the server public key pub.
+func NewClient(pub *ecdsa.Point) (*Client, error) {
+	s, err := getSuite(pub.Curve())
+	if err != nil {
+		return nil, err
+	}
+	if pub.IsIdentity() == 1 {
+		return nil, ErrInvalidInput
+	}
+	return &Client{s: s, pub: pub}, nil
+}
+
+// FinalizeData is the state of the client between Blind and Finalize.
+type FinalizeData struct {
+	input   []byte
+	blind   *ecdsa.Scalar
+	blinded *ecdsa.Point
+}
+
+// Blind blinds input with a blind generated using entropy from rand, and
+// returns the blinded element to send to the server.
+func (c *Client) Blind(rand io.Reader, input []byte) (*FinalizeData, *ecdsa.Point, error) {
+	r, err := ecdsa.GenerateKey(c.s.c, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	blind, err := r.Scalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.DeterministicBlind(input, blind)
+}
+
+// DeterministicBlind is like Blind with a given blind, for test vectors.
+func (c *Client) DeterministicBlind(input []byte, blind *ecdsa.Scalar) (*FinalizeData, *ecdsa.Point, error) {
+	P, err := c.s.hashToGroup(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	blinded := ecdsa.NewIdentityPoint(c.s.c).ScalarMult(blind, P)
+	f := &FinalizeData{input: append([]byte{}, input...), blind: blind, blinded: blinded}
+	return f, ecdsa.NewIdentityPoint(c.s.c).Set(blinded), nil
+}
+
+// Finalize verifies the proof of the server and unblinds its evaluation into
+// the PRF output of the input passed to Blind.
+func (c *Client) Finalize(f *FinalizeData, evaluated *ecdsa.Point, proof *Proof) ([]byte, error) {
+	if evaluated.Curve() != c.s.c || proof == nil || proof.C.Curve() != c.s.c || proof.S.Curve() != c.s.c {
+		return nil, ErrInvalidProof
+	}
+	M, Z, err := c.s.composites(nil, c.pub, []*ecdsa.Point{f.blinded}, []*ecdsa.Point{evaluated})
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	scalars := []*ecdsa.Scalar{proof.S, proof.C}
+	t2, err := ecdsa.MultiScalarMult([]*ecdsa.Point{ecdsa.NewGeneratorPoint(c.s.c), c.pub}, scalars)
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	t3, err := ecdsa.MultiScalarMult([]*ecdsa.Point{M, Z}, scalars)
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	if c.s.challenge(c.pub, M, Z, t2, t3).Equal(proof.C) != 1 {
+		return nil, ErrInvalidProof
+	}
+
+	inv := ecdsa.NewScalar(c.s.c).Invert(f.blind)
+	return c.s.finalize(f.input, ecdsa.NewIdentityPoint(c.s.c).ScalarMult(inv, evaluated)), nil
+}
@@ -0,0 +1,165 @@
+package voprf
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/circl/oprf"
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad hex %q: %s", s, err)
+	}
+	return b
+}
+
+// TestVectors checks the P256-SHA256 VOPRF test vectors of RFC 9497,
+// Appendix A.3.2.
+func TestVectors(t *testing.T) {
+	c := elliptic.P256()
+	seed := decodeHex(t, "a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3")
+	k, err := DeriveKey(c, seed, []byte("test key"))
+	if err != nil {
+		t.Fatalf("DeriveKey error: %s", err)
+	}
+	if got := hex.EncodeToString(k.k.Bytes()); got != "ca5d94c8807817669a51b196c34c1b7f8442fde4334a7121ae4736364312fca6" {
+		t.Fatalf("derived key %s", got)
+	}
+	if got := hex.EncodeToString(k.PublicKey().BytesCompressed()); got != "03e17e70604bcabe198882c0a1f27a92441e774224ed9c702e51dd17038b102462" {
+		t.Fatalf("derived public key %s", got)
+	}
+
+	for _, v := range []struct {
+		input, blinded, evaluated, proof, output string
+	}{
+		{
+			input:     "00",
+			blinded:   "02dd05901038bb31a6fae01828fd8d0e49e35a486b5c5d4b4994013648c01277da",
+			evaluated: "0209f33cab60cf8fe69239b0afbcfcd261af4c1c5632624f2e9ba29b90ae83e4a2",
+			proof:     "e7c2b3c5c954c035949f1f74e6bce2ed539a3be267d1481e9ddb178533df4c2664f69d065c604a4fd953e100b856ad83804eb3845189babfa5a702090d6fc5fa",
+			output:    "0412e8f78b02c415ab3a288e228978376f99927767ff37c5718d420010a645a1",
+		},
+		{
+			input:     "5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a",
+			blinded:   "03cd0f033e791c4d79dfa9c6ed750f2ac009ec46cd4195ca6fd3800d1e9b887dbd",
+			evaluated: "030d2985865c693bf7af47ba4d3a3813176576383d19aff003ef7b0784a0d83cf1",
+			proof:     "2787d729c57e3d9512d3aa9e8708ad226bc48e0f1750b0767aaff73482c44b8d2873d74ec88aebd3504961acea16790a05c542d9fbff4fe269a77510db00abab",
+			output:    "771e10dcd6bcd3664e23b8f2a710cfaaa8357747c4a8cbba03133967b5c24f18",
+		},
+	} {
+		blind, _ := ecdsa.NewScalar(c).SetBytes(decodeHex(t, "3338fa65ec36e0290022b48eb562889d89dbfa691d1cde91517fa222ed7ad364"))
+		r, _ := ecdsa.NewScalar(c).SetBytes(decodeHex(t, "f9db001266677f62c095021db018cd8cbb55941d4073698ce45c405d1348b7b1"))
+		input := decodeHex(t, v.input)
+
+		client, err := NewClient(k.PublicKey())
+		if err != nil {
+			t.Fatalf("NewClient error: %s", err)
+		}
+		f, blinded, err := client.DeterministicBlind(input, blind)
+		if err != nil {
+			t.Fatalf("DeterministicBlind error: %s", err)
+		}
+		if got := hex.EncodeToString(blinded.BytesCompressed()); got != v.blinded {
+			t.Errorf("blinded element %s, want %s", got, v.blinded)
+		}
+		evaluated, proof, err := k.blindEvaluate(r, blinded)
+		if err != nil {
+			t.Fatalf("blindEvaluate error: %s", err)
+		}
+		if got := hex.EncodeToString(evaluated.BytesCompressed()); got != v.evaluated {
+			t.Errorf("evaluated element %s, want %s", got, v.evaluated)
+		}
+		if got := hex.EncodeToString(proof.Marshal()); got != v.proof {
+			t.Errorf("proof %s, want %s", got, v.proof)
+		}
+		output, err := client.Finalize(f, evaluated, proof)
+		if err != nil {
+			t.Fatalf("Finalize error: %s", err)
+		}
+		if got := hex.EncodeToString(output); got != v.output {
+			t.Errorf("output %s, want %s", got, v.output)
+		}
+		if direct, _ := k.Evaluate(input); !bytes.Equal(direct, output) {
+			t.Errorf("Evaluate does not match the protocol output")
+		}
+	}
+}
+
+// TestInteropCIRCL checks the encodings against the circl oprf package on
+// every supported curve.
+func TestInteropCIRCL(t *testing.T) {
+	for _, tc := range []struct {
+		c     elliptic.Curve
+		suite oprf.Suite
+	}{
+		{elliptic.P256(), oprf.SuiteP256},
+		{elliptic.P384(), oprf.SuiteP384},
+		{elliptic.P521(), oprf.SuiteP521},
+	} {
+		k, err := GenerateKey(tc.c, rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey error: %s", err)
+		}
+		circlKey := new(oprf.PrivateKey)
+		if err := circlKey.UnmarshalBinary(tc.suite, k.k.Bytes()); err != nil {
+			t.Fatalf("UnmarshalBinary error: %s", err)
+		}
+		input := []byte("testing")
+		want, err := oprf.NewVerifiableServer(tc.suite, circlKey).FullEvaluate(input)
+		if err != nil {
+			t.Fatalf("FullEvaluate error: %s", err)
+		}
+
+		client, _ := NewClient(k.PublicKey())
+		f, blinded, err := client.Blind(rand.Reader, input)
+		if err != nil {
+			t.Fatalf("Blind error: %s", err)
+		}
+		evaluated, proof, err := k.BlindEvaluate(rand.Reader, blinded)
+		if err != nil {
+			t.Fatalf("BlindEvaluate error: %s", err)
+		}
+		decoded, err := UnmarshalProof(tc.c, proof.Marshal())
+		if err != nil {
+			t.Fatalf("UnmarshalProof error: %s", err)
+		}
+		got, err := client.Finalize(f, evaluated, decoded)
+		if err != nil {
+			t.Fatalf("Finalize error: %s", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: output does not match circl", tc.c.Params().Name)
+		}
+	}
+}
+
+func TestInvalidProof(t *testing.T) {
+	c := elliptic.P384()
+	k, _ := GenerateKey(c, rand.Reader)
+	other, _ := GenerateKey(c, rand.Reader)
+	client, _ := NewClient(k.PublicKey())
+	f, blinded, _ := client.Blind(rand.Reader, []byte("testing"))
+
+	evaluated, proof, _ := other.BlindEvaluate(rand.Reader, blinded)
+	if _, err := client.Finalize(f, evaluated, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("Finalize of another key's evaluation: got %v, want ErrInvalidProof", err)
+	}
+	evaluated, proof, _ = k.BlindEvaluate(rand.Reader, blinded)
+	evaluated.Add(evaluated, ecdsa.NewGeneratorPoint(c))
+	if _, err := client.Finalize(f, evaluated, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("Finalize of a tampered evaluation: got %v, want ErrInvalidProof", err)
+	}
+	if _, _, err := k.BlindEvaluate(rand.Reader, ecdsa.NewIdentityPoint(c)); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("BlindEvaluate of the identity: got %v, want ErrInvalidInput", err)
+	}
+	if _, err := GenerateKey(elliptic.P224(), rand.Reader); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("GenerateKey on P-224: got %v, want ErrUnsupportedCurve", err)
+	}
+}
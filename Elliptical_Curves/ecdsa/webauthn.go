@@ -0,0 +1,228 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// COSE algorithm identifiers of ECDSA with the hash functions WebAuthn pairs
+// with each curve.
+const (
+	coseAlgES256 = -7
+	coseAlgES384 = -35
+	coseAlgES512 = -36
+)
+
+// Flags of the authenticator data of WebAuthn.
+const (
+	authDataUserPresent  = 0x01
+	authDataAttestedData = 0x40
+	authDataExtensions   = 0x80
+)
+
+// webAuthnAlgorithm returns the COSE algorithm identifier and hash function
+// of signatures on the curve c in WebAuthn.
+func webAuthnAlgorithm(c elliptic.Curve) (int, crypto.Hash, error) {
+	switch c.Params() {
+	case elliptic.P256().Params():
+		return coseAlgES256, crypto.SHA256, nil
+	case elliptic.P384().Params():
+		return coseAlgES384, crypto.SHA384, nil
+	case elliptic.P521().Params():
+		return coseAlgES512, crypto.SHA512, nil
+	}
+	return 0, 0, wrapError(ErrInvalidCurve, "no WebAuthn algorithm for %s", c.Params().Name)
+}
+
+// credentialKey is the COSE_Key of a WebAuthn credential, which unlike the
+// keys of MarshalCBOR carries its algorithm.
+type credentialKey struct {
+	Kty int    `cbor:"1,keyasint"`
+	Alg int    `cbor:"3,keyasint"`
+	Crv int    `cbor:"-1,keyasint"`
+	X   []byte `cbor:"-2,keyasint"`
+	Y   []byte `cbor:"-3,keyasint"`
+}
+
+// packedStatement is the attStmt of the "packed" attestation statement
+// format, section 8.2 of WebAuthn Level 2, without a certificate chain.
+type packedStatement struct {
+	Alg int    `cbor:"alg"`
+	Sig []byte `cbor:"sig"`
+}
+
+type attestationObject struct {
+	Fmt      string          `cbor:"fmt"`
+	AttStmt  packedStatement `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// WebAuthnCredential is a WebAuthn credential whose public key is a device
+// base key blinded for one relying party.
+//
+// AttestationObject is a standard attestation object in the "packed" format
+// with self attestation: it is signed by the blinded credential key, and
+// carries nothing that links it to the base key or to the credentials of the
+// same device at other relying parties. Chain is an Attestation by the base
+// key of the blinded key for the relying party, and must only be shown to
+// verifiers allowed to link the credentials of the device, such as the
+// organization that provisioned it.
+type WebAuthnCredential struct {
+	CredentialID      []byte
+	AttestationObject []byte
+	Chain             *Attestation
+}
+
+// CreateWebAuthnCredential creates a credential for the relying party rpID
+// from the base key skS, blinded by skB with rpID as the context string, and
+// attests it over clientDataHash, the SHA-256 hash of the client data of the
+// registration ceremony. The credential ID is the SHA-256 hash of the
+// compressed blinded key, and the AAGUID and signature counter are zero.
+//
+// Assertions are signed with BlindKeySignWithContext with the same blind and
+// context, over the hash of the authenticator data and clientDataHash.
+func CreateWebAuthnCredential(rand io.Reader, skS, skB *PrivateKey, rpID string, clientDataHash []byte) (*WebAuthnCredential, error) {
+	alg, h, err := webAuthnAlgorithm(skS.Curve)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := CreateAttestation(rand, skS, skB, 0, []byte(rpID))
+	if err != nil {
+		return nil, err
+	}
+	pkR := chain.BlindedKey
+	k, err := newCOSEKey(pkR)
+	if err != nil {
+		return nil, err
+	}
+	key, err := cborEncMode.Marshal(&credentialKey{Kty: k.Kty, Alg: alg, Crv: k.Crv, X: k.X, Y: k.Y})
+	if err != nil {
+		return nil, err
+	}
+	credentialID := sha256.Sum256(elliptic.MarshalCompressed(pkR.Curve, pkR.X, pkR.Y))
+
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	var b cryptobyte.Builder
+	b.AddBytes(rpIDHash[:])
+	b.AddUint8(authDataUserPresent | authDataAttestedData)
+	b.AddUint32(0)
+	b.AddBytes(make([]byte, 16))
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(credentialID[:])
+	})
+	b.AddBytes(key)
+	authData := b.BytesOrPanic()
+
+	md := h.New()
+	md.Write(authData)
+	md.Write(clientDataHash)
+	r, s, err := BlindKeySignWithContext(rand, skS, skB, md.Sum(nil), []byte(rpID))
+	if err != nil {
+		return nil, err
+	}
+	sig, err := EncodeSignature(pkR, nil, r, s, ProfileDER)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := cborEncMode.Marshal(&attestationObject{
+		Fmt:      "packed",
+		AttStmt:  packedStatement{Alg: alg, Sig: sig},
+		AuthData: authData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &WebAuthnCredential{
+		CredentialID:      credentialID[:],
+		AttestationObject: obj,
+		Chain:             chain,
+	}, nil
+}
+
+// parseAuthenticatorData returns the credential ID and public key of the
+// authenticator data of a registration for rpID. It rejects authenticator
+// data with extensions or without user presence.
+func parseAuthenticatorData(authData []byte, rpID string) ([]byte, *credentialKey, error) {
+	s := cryptobyte.String(authData)
+	var rpIDHash, aaguid []byte
+	var flags uint8
+	var counter uint32
+	var credentialID cryptobyte.String
+	if !s.ReadBytes(&rpIDHash, sha256.Size) ||
+		!s.ReadUint8(&flags) ||
+		!s.ReadUint32(&counter) ||
+		!s.ReadBytes(&aaguid, 16) ||
+		!s.ReadUint16LengthPrefixed(&credentialID) {
+		return nil, nil, wrapError(ErrInvalidSignature, "malformed authenticator data")
+	}
+	want := sha256.Sum256([]byte(rpID))
+	if !bytes.Equal(rpIDHash, want[:]) {
+		return nil, nil, wrapError(ErrInvalidSignature, "authenticator data for another relying party")
+	}
+	if flags&authDataUserPresent == 0 || flags&authDataAttestedData == 0 || flags&authDataExtensions != 0 {
+		return nil, nil, wrapError(ErrInvalidSignature, "unexpected authenticator data flags %#x", flags)
+	}
+	var key credentialKey
+	if !cborUnmarshal([]byte(s), &key) {
+		return nil, nil, wrapError(ErrPointNotOnCurve, "malformed credential public key")
+	}
+	return []byte(credentialID), &key, nil
+}
+
+// VerifyWebAuthnCredential checks cred for the relying party rpID and the
+// client data hash clientDataHash: that its attestation object is a packed
+// self attestation by its credential key, and that Chain attests the
+// credential key as the base key pkS blinded for rpID. It returns the
+// credential public key.
+func VerifyWebAuthnCredential(pkS *PublicKey, rpID string, clientDataHash []byte, cred *WebAuthnCredential) (*PublicKey, error) {
+	if cred == nil || cred.Chain == nil || cred.Chain.BlindedKey == nil {
+		return nil, wrapError(ErrInvalidSignature, "incomplete credential")
+	}
+	alg, h, err := webAuthnAlgorithm(pkS.Curve)
+	if err != nil {
+		return nil, err
+	}
+	var obj attestationObject
+	if !cborUnmarshal(cred.AttestationObject, &obj) || obj.Fmt != "packed" {
+		return nil, wrapError(ErrInvalidSignature, "malformed packed attestation object")
+	}
+	credentialID, key, err := parseAuthenticatorData(obj.AuthData, rpID)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(credentialID, cred.CredentialID) {
+		return nil, wrapError(ErrInvalidSignature, "credential ID mismatch")
+	}
+	if obj.AttStmt.Alg != alg || key.Alg != alg {
+		return nil, wrapError(ErrCurveMismatch, "algorithm %d, want %d", key.Alg, alg)
+	}
+	pkR, err := (&coseKey{Kty: key.Kty, Crv: key.Crv, X: key.X, Y: key.Y}).publicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	r, s, ok := parseASN1Signature(obj.AttStmt.Sig)
+	if !ok {
+		return nil, wrapError(ErrInvalidSignature, "malformed attestation signature")
+	}
+	md := h.New()
+	md.Write(obj.AuthData)
+	md.Write(clientDataHash)
+	if err := CheckSignature(pkR, md.Sum(nil), r, s); err != nil {
+		return nil, err
+	}
+
+	if !cred.Chain.BlindedKey.Equal(pkR) || string(cred.Chain.Context) != rpID {
+		return nil, wrapError(ErrInvalidSignature, "chain attests another key")
+	}
+	if err := VerifyAttestation(pkS, cred.Chain); err != nil {
+		return nil, err
+	}
+	return pkR, nil
+}
@@ -0,0 +1,90 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+func TestWebAuthnCredential(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(c.Params().Name, func(t *testing.T) {
+			testWebAuthnCredential(t, c)
+		})
+	}
+}
+
+func testWebAuthnCredential(t *testing.T, c elliptic.Curve) {
+	skS, _ := GenerateKey(c, rand.Reader)
+	skB, _ := GenerateKey(c, rand.Reader)
+	clientDataHash := sha256.Sum256([]byte(`{"type":"webauthn.create"}`))
+
+	cred, err := CreateWebAuthnCredential(rand.Reader, skS, skB, "example.com", clientDataHash[:])
+	if err != nil {
+		t.Fatalf("CreateWebAuthnCredential error: %s", err)
+	}
+	pkR, err := VerifyWebAuthnCredential(&skS.PublicKey, "example.com", clientDataHash[:], cred)
+	if err != nil {
+		t.Fatalf("VerifyWebAuthnCredential error: %s", err)
+	}
+	want, _ := BlindPublicKeyWithContext(c, &skS.PublicKey, skB, []byte("example.com"))
+	if !pkR.Equal(want) {
+		t.Errorf("credential key is not the base key blinded for the relying party")
+	}
+
+	// Credentials for other relying parties have unrelated keys and IDs.
+	other, err := CreateWebAuthnCredential(rand.Reader, skS, skB, "example.org", clientDataHash[:])
+	if err != nil {
+		t.Fatalf("CreateWebAuthnCredential error: %s", err)
+	}
+	if bytes.Equal(other.CredentialID, cred.CredentialID) || other.Chain.BlindedKey.Equal(pkR) {
+		t.Errorf("credentials for two relying parties share an ID or key")
+	}
+
+	if _, err := VerifyWebAuthnCredential(&skS.PublicKey, "example.org", clientDataHash[:], cred); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("credential verified for another relying party: %v", err)
+	}
+	if _, err := VerifyWebAuthnCredential(&skS.PublicKey, "example.com", make([]byte, 32), cred); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("credential verified for another client data hash: %v", err)
+	}
+	skO, _ := GenerateKey(c, rand.Reader)
+	if _, err := VerifyWebAuthnCredential(&skO.PublicKey, "example.com", clientDataHash[:], cred); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("credential verified under another base key: %v", err)
+	}
+
+	swapped := *cred
+	swapped.Chain = other.Chain
+	if _, err := VerifyWebAuthnCredential(&skS.PublicKey, "example.com", clientDataHash[:], &swapped); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("credential verified with the chain of another credential: %v", err)
+	}
+	swapped = *cred
+	swapped.AttestationObject = append([]byte{}, cred.AttestationObject...)
+	swapped.AttestationObject[len(swapped.AttestationObject)-1] ^= 1
+	if _, err := VerifyWebAuthnCredential(&skS.PublicKey, "example.com", clientDataHash[:], &swapped); err == nil {
+		t.Errorf("tampered attestation object verified")
+	}
+	if _, err := VerifyWebAuthnCredential(&skS.PublicKey, "example.com", clientDataHash[:], nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("nil credential: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestWebAuthnUnsupportedCurve(t *testing.T) {
+	// Fixed keys, since keys can't be generated on brainpoolP256r1 in FIPS
+	// mode.
+	c := brainpool.P256r1()
+	newKey := func(d int64) *PrivateKey {
+		k := big.NewInt(d)
+		x, y := c.ScalarBaseMult(k.Bytes())
+		return &PrivateKey{PublicKey{c, x, y}, k}
+	}
+	skS, skB := newKey(2), newKey(3)
+	if _, err := CreateWebAuthnCredential(rand.Reader, skS, skB, "example.com", make([]byte, 32)); !errors.Is(err, ErrInvalidCurve) {
+		t.Errorf("CreateWebAuthnCredential on %s: got %v, want ErrInvalidCurve", c.Params().Name, err)
+	}
+}
@@ -0,0 +1,115 @@
+// Package webcrypto signs and verifies ECDSA signatures with the WebCrypto
+// SubtleCrypto API when built for GOOS=js GOARCH=wasm, so that browser clients
+// can blind keys in Go without shipping a second implementation of the base
+// signature algorithm.
+//
+// Key blinding is always computed by the ecdsa package; only the final
+// signature and verification are delegated to SubtleCrypto. SubtleCrypto
+// supports the P-256, P-384 and P-521 curves with SHA-256, SHA-384 and
+// SHA-512. Other curves and hash functions, and every platform other than
+// js/wasm or a JavaScript host without crypto.subtle, fall back to the ecdsa
+// package.
+//
+// SubtleCrypto operations are asynchronous. The functions of this package
+// block the calling goroutine until the returned promise settles, so they must
+// not be called from the goroutine running a js.Func callback.
+package webcrypto
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// errUnsupported is returned by the platform backends for operations
+// SubtleCrypto can't perform, which are then performed by the ecdsa package.
+var errUnsupported = errors.New("webcrypto: unsupported")
+
+// hashName returns the WebCrypto name of h.
+func hashName(h crypto.Hash) (string, bool) {
+	switch h {
+	case crypto.SHA256:
+		return "SHA-256", true
+	case crypto.SHA384:
+		return "SHA-384", true
+	case crypto.SHA512:
+		return "SHA-512", true
+	default:
+		return "", false
+	}
+}
+
+// supported reports whether SubtleCrypto can sign on curve with hash h.
+func supported(curve string, h crypto.Hash) bool {
+	if _, ok := hashName(h); !ok {
+		return false
+	}
+	switch curve {
+	case "P-256", "P-384", "P-521":
+		return Available()
+	default:
+		return false
+	}
+}
+
+func digest(h crypto.Hash, message []byte) ([]byte, error) {
+	if !h.Available() {
+		return nil, fmt.Errorf("webcrypto: hash function %v not available", h)
+	}
+	hh := h.New()
+	hh.Write(message)
+	return hh.Sum(nil), nil
+}
+
+// Sign hashes message with h and signs the digest with priv. With
+// SubtleCrypto, the nonce is generated by the JavaScript host and rand is
+// not used.
+func Sign(rand io.Reader, priv *ecdsa.PrivateKey, message []byte, h crypto.Hash) (r, s *big.Int, err error) {
+	if supported(priv.Curve.Params().Name, h) {
+		r, s, err = subtleSign(priv, message, h)
+		if err != errUnsupported {
+			return r, s, err
+		}
+	}
+	d, err := digest(h, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ecdsa.Sign(rand, priv, d)
+}
+
+// BlindKeySignWithContext blinds skS by skB and context with
+// ecdsa.BlindPrivateKeyWithContext, and signs message with the blinded key
+// as Sign does. The signature verifies under the public key returned by
+// ecdsa.BlindPublicKeyWithContext.
+func BlindKeySignWithContext(rand io.Reader, skS *ecdsa.PrivateKey, skB *ecdsa.PrivateKey, message []byte, h crypto.Hash, context []byte) (r, s *big.Int, err error) {
+	skR, err := ecdsa.BlindPrivateKeyWithContext(skS, skB, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Sign(rand, skR, message, h)
+}
+
+// Verify reports whether r, s is a valid signature of message hashed with h
+// under pub.
+func Verify(pub *ecdsa.PublicKey, message []byte, h crypto.Hash, r, s *big.Int) bool {
+	N := pub.Curve.Params().N
+	if r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+	if supported(pub.Curve.Params().Name, h) {
+		ok, err := subtleVerify(pub, message, h, r, s)
+		if err != errUnsupported {
+			return err == nil && ok
+		}
+	}
+	d, err := digest(h, message)
+	if err != nil {
+		return false
+	}
+	return ecdsa.Verify(pub, d, r, s)
+}
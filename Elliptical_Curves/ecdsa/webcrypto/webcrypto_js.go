@@ -0,0 +1,143 @@
+//go:build js && wasm
+
+package webcrypto
+
+import (
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"syscall/js"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// subtle returns crypto.subtle, or undefined if the host doesn't provide it.
+func subtle() js.Value {
+	c := js.Global().Get("crypto")
+	if !c.Truthy() {
+		return js.Undefined()
+	}
+	return c.Get("subtle")
+}
+
+// Available reports whether the JavaScript host provides crypto.subtle.
+func Available() bool {
+	return subtle().Truthy()
+}
+
+// await blocks until the promise p settles, and returns its value or the
+// reason it was rejected.
+func await(p js.Value) (js.Value, error) {
+	var (
+		v   js.Value
+		err error
+	)
+	done := make(chan struct{})
+	onFulfilled := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v = args[0]
+		close(done)
+		return nil
+	})
+	defer onFulfilled.Release()
+	onRejected := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err = fmt.Errorf("webcrypto: %s", args[0].Call("toString").String())
+		close(done)
+		return nil
+	})
+	defer onRejected.Release()
+	p.Call("then", onFulfilled, onRejected)
+	<-done
+	return v, err
+}
+
+func toUint8Array(b []byte) js.Value {
+	a := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(a, b)
+	return a
+}
+
+func fromArrayBuffer(buf js.Value) []byte {
+	a := js.Global().Get("Uint8Array").New(buf)
+	b := make([]byte, a.Get("length").Int())
+	js.CopyBytesToGo(b, a)
+	return b
+}
+
+func coordinateSize(pub *ecdsa.PublicKey) int {
+	return (pub.Curve.Params().BitSize + 7) / 8
+}
+
+func b64(x *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(x.FillBytes(make([]byte, size)))
+}
+
+// jwk returns the JSON Web Key encoding of pub, and of the private scalar d
+// if it isn't nil.
+func jwk(pub *ecdsa.PublicKey, d *big.Int) js.Value {
+	size := coordinateSize(pub)
+	k := map[string]interface{}{
+		"kty": "EC",
+		"crv": pub.Curve.Params().Name,
+		"x":   b64(pub.X, size),
+		"y":   b64(pub.Y, size),
+		"ext": true,
+	}
+	if d != nil {
+		k["d"] = b64(d, (pub.Curve.Params().N.BitLen()+7)/8)
+	}
+	return js.ValueOf(k)
+}
+
+func importKey(pub *ecdsa.PublicKey, d *big.Int, usage string) (js.Value, error) {
+	alg := js.ValueOf(map[string]interface{}{
+		"name":       "ECDSA",
+		"namedCurve": pub.Curve.Params().Name,
+	})
+	usages := js.ValueOf([]interface{}{usage})
+	return await(subtle().Call("importKey", "jwk", jwk(pub, d), alg, false, usages))
+}
+
+func algorithm(h crypto.Hash) js.Value {
+	name, _ := hashName(h)
+	return js.ValueOf(map[string]interface{}{
+		"name": "ECDSA",
+		"hash": name,
+	})
+}
+
+func subtleSign(priv *ecdsa.PrivateKey, message []byte, h crypto.Hash) (r, s *big.Int, err error) {
+	key, err := importKey(&priv.PublicKey, priv.D, "sign")
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := await(subtle().Call("sign", algorithm(h), key, toUint8Array(message)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// SubtleCrypto encodes signatures as the concatenation of r and s, each
+	// as long as the order of the curve.
+	b := fromArrayBuffer(sig)
+	size := (priv.Curve.Params().N.BitLen() + 7) / 8
+	if len(b) != 2*size {
+		return nil, nil, fmt.Errorf("webcrypto: invalid signature length %d", len(b))
+	}
+	return new(big.Int).SetBytes(b[:size]), new(big.Int).SetBytes(b[size:]), nil
+}
+
+func subtleVerify(pub *ecdsa.PublicKey, message []byte, h crypto.Hash, r, s *big.Int) (bool, error) {
+	key, err := importKey(pub, nil, "verify")
+	if err != nil {
+		return false, err
+	}
+	size := (pub.Curve.Params().N.BitLen() + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	ok, err := await(subtle().Call("verify", algorithm(h), key, toUint8Array(sig), toUint8Array(message)))
+	if err != nil {
+		return false, err
+	}
+	return ok.Bool(), nil
+}
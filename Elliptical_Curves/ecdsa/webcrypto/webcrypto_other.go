@@ -0,0 +1,24 @@
+//go:build !(js && wasm)
+
+package webcrypto
+
+import (
+	"crypto"
+	"math/big"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// Available reports whether SubtleCrypto can be used. It is always false
+// outside of js/wasm.
+func Available() bool {
+	return false
+}
+
+func subtleSign(priv *ecdsa.PrivateKey, message []byte, h crypto.Hash) (r, s *big.Int, err error) {
+	return nil, nil, errUnsupported
+}
+
+func subtleVerify(pub *ecdsa.PublicKey, message []byte, h crypto.Hash, r, s *big.Int) (bool, error) {
+	return false, errUnsupported
+}
@@ -0,0 +1,84 @@
+package webcrypto
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"math/big"
+	"testing"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func TestSign(t *testing.T) {
+	t.Logf("SubtleCrypto available: %v", Available())
+	curves := []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()}
+	for _, c := range curves {
+		for _, h := range []crypto.Hash{crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+			t.Run(c.Params().Name+"/"+h.String(), func(t *testing.T) {
+				testSign(t, c, h)
+			})
+		}
+	}
+}
+
+func testSign(t *testing.T, c elliptic.Curve, h crypto.Hash) {
+	sk, _ := ecdsa.GenerateKey(c, rand.Reader)
+	message := []byte("hello world")
+
+	r, s, err := Sign(rand.Reader, sk, message, h)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !Verify(&sk.PublicKey, message, h, r, s) {
+		t.Errorf("Verify failed")
+	}
+	// Signatures are interchangeable with those of the ecdsa package.
+	d, _ := digest(h, message)
+	if !ecdsa.Verify(&sk.PublicKey, d, r, s) {
+		t.Errorf("ecdsa.Verify failed")
+	}
+	r2, s2, err := ecdsa.Sign(rand.Reader, sk, d)
+	if err != nil {
+		t.Fatalf("ecdsa.Sign error: %s", err)
+	}
+	if !Verify(&sk.PublicKey, message, h, r2, s2) {
+		t.Errorf("Verify failed on a signature of the ecdsa package")
+	}
+
+	if Verify(&sk.PublicKey, []byte("goodbye world"), h, r, s) {
+		t.Errorf("Verify accepted a signature of another message")
+	}
+	if Verify(&sk.PublicKey, message, h, r, new(big.Int)) {
+		t.Errorf("Verify accepted a zero s")
+	}
+	if Verify(&sk.PublicKey, message, h, r, nil) {
+		t.Errorf("Verify accepted a nil s")
+	}
+}
+
+func TestBlindKeySignWithContext(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		skS, _ := ecdsa.GenerateKey(c, rand.Reader)
+		skB, _ := ecdsa.GenerateKey(c, rand.Reader)
+		context := []byte("context")
+		message := []byte("hello world")
+
+		r, s, err := BlindKeySignWithContext(rand.Reader, skS, skB, message, crypto.SHA256, context)
+		if err != nil {
+			t.Fatalf("BlindKeySignWithContext error: %s", err)
+		}
+		pkR, err := ecdsa.BlindPublicKeyWithContext(c, &skS.PublicKey, skB, context)
+		if err != nil {
+			t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+		}
+		if !Verify(pkR, message, crypto.SHA256, r, s) {
+			t.Errorf("%s: Verify failed under the blinded key", c.Params().Name)
+		}
+		if Verify(&skS.PublicKey, message, crypto.SHA256, r, s) {
+			t.Errorf("%s: Verify succeeded under the unblinded key", c.Params().Name)
+		}
+	}
+}
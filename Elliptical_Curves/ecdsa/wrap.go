@@ -0,0 +1,296 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	wrapDST     = "ECDSA Blind Wrap"
+	wrapVersion = 1
+
+	// WrapKeySize is the size, in bytes, of the X25519 private and public
+	// keys of the recipients of wrapped blinds.
+	WrapKeySize = curve25519.ScalarSize
+
+	// wrapRecipientSize is the size of a Recipient: a fingerprint and an
+	// encrypted content key.
+	wrapRecipientSize = sha256.Size + chacha20poly1305.KeySize + chacha20poly1305.Overhead
+)
+
+// BlindFactor is a blind with the context string it is used with, which
+// together derive a blinded key from a base key.
+type BlindFactor struct {
+	Blind   *PrivateKey
+	Context []byte
+}
+
+// GenerateWrapKey returns a new X25519 key pair of a recipient of wrapped
+// blinds, such as a device of the user, with entropy from rand.
+func GenerateWrapKey(rand io.Reader) (priv, pub []byte, err error) {
+	priv = make([]byte, WrapKeySize)
+	if _, err := io.ReadFull(entropySource(rand), priv); err != nil {
+		return nil, nil, entropyError(err)
+	}
+	pub, err = curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv, pub, nil
+}
+
+// WrapKeyFingerprint returns the SHA-256 digest of the X25519 public key pub,
+// which identifies a recipient in wrapped blinds.
+func WrapKeyFingerprint(pub []byte) [sha256.Size]byte {
+	return sha256.Sum256(pub)
+}
+
+// wrapKEK derives the key that encrypts the content key for the recipient
+// pub from the X25519 shared secret of the ephemeral key and pub.
+func wrapKEK(shared, ephemeral, pub []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeral...), pub...)
+	kek := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(wrapDST)), kek); err != nil {
+		return nil, err
+	}
+	return kek, nil
+}
+
+// marshalBlinds encodes blinds:
+//
+//	struct {
+//	    opaque curve<1..2^8-1>;
+//	    opaque blind<1..2^16-1>;
+//	    opaque context<0..2^16-1>;
+//	} Blind;
+//
+//	Blind blinds<1..2^24-1>;
+//
+// where curve is the registered name of a curve.
+func marshalBlinds(blinds []BlindFactor) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, f := range blinds {
+			d, err := f.Blind.Scalar()
+			if err != nil {
+				b.SetError(err)
+				return
+			}
+			if len(f.Context) > 0xffff {
+				b.SetError(wrapError(ErrInvalidWrap, "context too long"))
+				return
+			}
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(curveName(f.Blind.Curve)))
+			})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(d.Bytes())
+			})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(f.Context)
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+func unmarshalBlinds(data []byte) ([]BlindFactor, error) {
+	s := cryptobyte.String(data)
+	var list cryptobyte.String
+	if !s.ReadUint24LengthPrefixed(&list) || !s.Empty() || list.Empty() {
+		return nil, wrapError(ErrInvalidWrap, "malformed blinds")
+	}
+	var blinds []BlindFactor
+	for !list.Empty() {
+		var name, blind, context cryptobyte.String
+		if !list.ReadUint8LengthPrefixed(&name) || !list.ReadUint16LengthPrefixed(&blind) ||
+			!list.ReadUint16LengthPrefixed(&context) {
+			return nil, wrapError(ErrInvalidWrap, "malformed blind")
+		}
+		c, err := CurveByName(string(name))
+		if err != nil {
+			return nil, err
+		}
+		d, err := NewScalar(c).SetBytes(blind)
+		if err != nil {
+			return nil, err
+		}
+		bk, err := NewPrivateKey(d)
+		if err != nil {
+			return nil, err
+		}
+		blinds = append(blinds, BlindFactor{Blind: bk, Context: append([]byte{}, context...)})
+	}
+	return blinds, nil
+}
+
+// WrapBlinds encrypts blinds, with their context strings, to the X25519
+// public keys of recipients, so that they can be moved between the devices
+// of a user. The container can't be unwrapped after expiry, unless expiry is
+// the zero time. Its format is:
+//
+//	struct {
+//	    opaque fingerprint[32];
+//	    opaque wrapped_key[48];
+//	} Recipient;
+//
+//	struct {
+//	    uint8 version = 1;
+//	    uint64 expiry;
+//	    opaque ephemeral[32];
+//	    Recipient recipients<1..2^16-1>;
+//	    opaque ciphertext[];
+//	} WrappedBlinds;
+//
+// where expiry is in seconds since the Unix epoch, or zero, ephemeral is an
+// X25519 public key, and fingerprint is WrapKeyFingerprint of a recipient
+// key. A random content key encrypts the blinds, each with the name of its
+// curve and its context string, into ciphertext with ChaCha20-Poly1305, and
+// is itself encrypted for each recipient into wrapped_key, with
+// ChaCha20-Poly1305 under a key derived with HKDF-SHA256 from the X25519
+// shared secret of ephemeral and the recipient key. Both encryptions use a
+// zero nonce, as their keys are only used once, and authenticate the header,
+// up to the recipients for the wrapped keys and including them for the
+// ciphertext.
+func WrapBlinds(rand io.Reader, blinds []BlindFactor, recipients [][]byte, expiry time.Time) ([]byte, error) {
+	if len(blinds) == 0 || len(recipients) == 0 || len(recipients) > 0xffff/wrapRecipientSize {
+		return nil, wrapError(ErrInvalidWrap, "%d blinds for %d recipients", len(blinds), len(recipients))
+	}
+	plaintext, err := marshalBlinds(blinds)
+	if err != nil {
+		return nil, err
+	}
+	var exp uint64
+	if !expiry.IsZero() {
+		if expiry.Unix() <= 0 {
+			return nil, wrapError(ErrInvalidWrap, "expiry %v before the Unix epoch", expiry)
+		}
+		exp = uint64(expiry.Unix())
+	}
+
+	random := entropySource(rand)
+	cek := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(random, cek); err != nil {
+		return nil, entropyError(err)
+	}
+	ephPriv, ephPub, err := GenerateWrapKey(random)
+	if err != nil {
+		return nil, err
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint8(wrapVersion)
+	b.AddUint32(uint32(exp >> 32))
+	b.AddUint32(uint32(exp))
+	b.AddBytes(ephPub)
+	header := append([]byte{}, b.BytesOrPanic()...)
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, pub := range recipients {
+			if len(pub) != WrapKeySize {
+				b.SetError(wrapError(ErrInvalidWrap, "recipient key of %d bytes, want %d", len(pub), WrapKeySize))
+				return
+			}
+			shared, err := curve25519.X25519(ephPriv, pub)
+			if err != nil {
+				b.SetError(wrapError(ErrInvalidWrap, "recipient key: %v", err))
+				return
+			}
+			kek, err := wrapKEK(shared, ephPub, pub)
+			if err != nil {
+				b.SetError(err)
+				return
+			}
+			aead, _ := chacha20poly1305.New(kek)
+			fp := WrapKeyFingerprint(pub)
+			b.AddBytes(fp[:])
+			b.AddBytes(aead.Seal(nil, nonce, cek, header))
+		}
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	aead, _ := chacha20poly1305.New(cek)
+	return aead.Seal(out, nonce, plaintext, append([]byte{}, out...)), nil
+}
+
+// UnwrapBlinds decrypts blinds wrapped by WrapBlinds with the X25519 private
+// key priv of one of their recipients. It returns an error wrapping
+// ErrInvalidWrap if the container is malformed, expired, not for priv, or
+// fails to authenticate.
+func UnwrapBlinds(data, priv []byte) ([]BlindFactor, error) {
+	return unwrapBlinds(data, priv, time.Now())
+}
+
+func unwrapBlinds(data, priv []byte, now time.Time) ([]BlindFactor, error) {
+	if len(priv) != WrapKeySize {
+		return nil, wrapError(ErrInvalidWrap, "private key of %d bytes, want %d", len(priv), WrapKeySize)
+	}
+	pub, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	fp := WrapKeyFingerprint(pub)
+
+	s := cryptobyte.String(data)
+	var version uint8
+	var hi, lo uint32
+	var ephPub []byte
+	var recipients cryptobyte.String
+	if !s.ReadUint8(&version) || !s.ReadUint32(&hi) || !s.ReadUint32(&lo) ||
+		!s.ReadBytes(&ephPub, WrapKeySize) || !s.ReadUint16LengthPrefixed(&recipients) {
+		return nil, wrapError(ErrInvalidWrap, "malformed header")
+	}
+	if version != wrapVersion {
+		return nil, wrapError(ErrInvalidWrap, "unsupported version %d", version)
+	}
+	if exp := uint64(hi)<<32 | uint64(lo); exp != 0 && uint64(now.Unix()) >= exp {
+		return nil, wrapError(ErrInvalidWrap, "expired at %v", time.Unix(int64(exp), 0).UTC())
+	}
+	header := data[:1+8+WrapKeySize]
+	ad := data[:len(data)-len(s)]
+
+	var wrapped []byte
+	for !recipients.Empty() {
+		var f, k []byte
+		if !recipients.ReadBytes(&f, sha256.Size) || !recipients.ReadBytes(&k, wrapRecipientSize-sha256.Size) {
+			return nil, wrapError(ErrInvalidWrap, "malformed recipient")
+		}
+		if bytes.Equal(f, fp[:]) {
+			wrapped = k
+		}
+	}
+	if wrapped == nil {
+		return nil, wrapError(ErrInvalidWrap, "not a recipient")
+	}
+
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	shared, err := curve25519.X25519(priv, ephPub)
+	if err != nil {
+		return nil, wrapError(ErrInvalidWrap, "ephemeral key: %v", err)
+	}
+	kek, err := wrapKEK(shared, ephPub, pub)
+	if err != nil {
+		return nil, err
+	}
+	aead, _ := chacha20poly1305.New(kek)
+	cek, err := aead.Open(nil, nonce, wrapped, header)
+	if err != nil {
+		return nil, wrapError(ErrInvalidWrap, "authentication failed")
+	}
+	aead, _ = chacha20poly1305.New(cek)
+	plaintext, err := aead.Open(nil, nonce, s, ad)
+	if err != nil {
+		return nil, wrapError(ErrInvalidWrap, "authentication failed")
+	}
+	return unmarshalBlinds(plaintext)
+}
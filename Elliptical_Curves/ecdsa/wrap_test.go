@@ -0,0 +1,97 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+func TestWrapBlinds(t *testing.T) {
+	// Blinds on different curves, among those allowed.
+	c2 := brainpool.P384r1()
+	if FIPSMode() {
+		c2 = elliptic.P384()
+	}
+	b1, err := GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	b2, err := GenerateKey(c2, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	blinds := []BlindFactor{
+		{Blind: b1, Context: []byte("forum")},
+		{Blind: b2, Context: nil},
+	}
+	phonePriv, phonePub, err := GenerateWrapKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateWrapKey error: %s", err)
+	}
+	laptopPriv, laptopPub, _ := GenerateWrapKey(rand.Reader)
+	otherPriv, _, _ := GenerateWrapKey(rand.Reader)
+
+	now := time.Now()
+	wrapped, err := WrapBlinds(rand.Reader, blinds, [][]byte{phonePub, laptopPub}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("WrapBlinds error: %s", err)
+	}
+	for _, priv := range [][]byte{phonePriv, laptopPriv} {
+		got, err := unwrapBlinds(wrapped, priv, now)
+		if err != nil {
+			t.Fatalf("UnwrapBlinds error: %s", err)
+		}
+		if len(got) != len(blinds) {
+			t.Fatalf("UnwrapBlinds returned %d blinds, want %d", len(got), len(blinds))
+		}
+		for i := range got {
+			if !got[i].Blind.Equal(blinds[i].Blind) || !bytes.Equal(got[i].Context, blinds[i].Context) {
+				t.Errorf("blind %d does not round trip", i)
+			}
+		}
+	}
+
+	if _, err := unwrapBlinds(wrapped, otherPriv, now); !errors.Is(err, ErrInvalidWrap) {
+		t.Errorf("UnwrapBlinds by another key: got %v, want ErrInvalidWrap", err)
+	}
+	if _, err := unwrapBlinds(wrapped, phonePriv, now.Add(2*time.Hour)); !errors.Is(err, ErrInvalidWrap) {
+		t.Errorf("UnwrapBlinds after expiry: got %v, want ErrInvalidWrap", err)
+	}
+	for _, i := range []int{0, 5, 20, 60, len(wrapped) - 1} {
+		tampered := append([]byte{}, wrapped...)
+		tampered[i] ^= 1
+		if _, err := unwrapBlinds(tampered, phonePriv, now); !errors.Is(err, ErrInvalidWrap) {
+			t.Errorf("UnwrapBlinds with byte %d flipped: got %v, want ErrInvalidWrap", i, err)
+		}
+	}
+	if _, err := unwrapBlinds(wrapped[:len(wrapped)-1], phonePriv, now); !errors.Is(err, ErrInvalidWrap) {
+		t.Errorf("UnwrapBlinds of a truncated container: got %v, want ErrInvalidWrap", err)
+	}
+
+	forever, err := WrapBlinds(rand.Reader, blinds[:1], [][]byte{phonePub}, time.Time{})
+	if err != nil {
+		t.Fatalf("WrapBlinds error: %s", err)
+	}
+	if _, err := unwrapBlinds(forever, phonePriv, now.AddDate(100, 0, 0)); err != nil {
+		t.Errorf("UnwrapBlinds without expiry error: %s", err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		blinds     []BlindFactor
+		recipients [][]byte
+	}{
+		{"no blinds", nil, [][]byte{phonePub}},
+		{"no recipients", blinds, nil},
+		{"short key", blinds, [][]byte{phonePub[1:]}},
+	} {
+		if _, err := WrapBlinds(rand.Reader, tt.blinds, tt.recipients, time.Time{}); !errors.Is(err, ErrInvalidWrap) {
+			t.Errorf("WrapBlinds with %s: got %v, want ErrInvalidWrap", tt.name, err)
+		}
+	}
+}
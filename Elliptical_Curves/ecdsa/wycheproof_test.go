@@ -0,0 +1,192 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/pat-go/brainpool"
+)
+
+// wycheproofFiles holds ECDSA verification vectors in the format of Project
+// Wycheproof. testdata/wycheproof has unmodified upstream files, with ASN.1
+// and P1363 encoded signatures (see its README.md for their source), and
+// testdata/edgecases has edge cases generated for this package by its
+// generate.go: out of range and malleable values, BER encodings, special
+// nonces, and keys for which verification hits the point at infinity.
+//
+//go:embed testdata/wycheproof/*.json testdata/edgecases/*.json
+var wycheproofFiles embed.FS
+
+// p1363Schema is the schema of the files whose signatures are the
+// concatenation of r and s, as UnmarshalRawSignature expects.
+const p1363Schema = "ecdsa_p1363_verify_schema_v1.json"
+
+type wycheproofTest struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Flags   []string `json:"flags"`
+	Msg     string   `json:"msg"`
+	Sig     string   `json:"sig"`
+	Result  string   `json:"result"`
+}
+
+type wycheproofGroup struct {
+	PublicKey struct {
+		Curve string `json:"curve"`
+		Wx    string `json:"wx"`
+		Wy    string `json:"wy"`
+	} `json:"publicKey"`
+	SHA   string            `json:"sha"`
+	Tests []*wycheproofTest `json:"tests"`
+}
+
+var wycheproofCurves = map[string]func() elliptic.Curve{
+	"secp224r1":       elliptic.P224,
+	"secp256r1":       elliptic.P256,
+	"secp384r1":       elliptic.P384,
+	"secp521r1":       elliptic.P521,
+	"brainpoolP256r1": brainpool.P256r1,
+	"brainpoolP384r1": brainpool.P384r1,
+	"brainpoolP512r1": brainpool.P512r1,
+}
+
+var wycheproofHashes = map[string]crypto.Hash{
+	"SHA-224": crypto.SHA224,
+	"SHA-256": crypto.SHA256,
+	"SHA-384": crypto.SHA384,
+	"SHA-512": crypto.SHA512,
+}
+
+func TestWycheproof(t *testing.T) {
+	testVectorFiles(t, "testdata/wycheproof")
+}
+
+func TestEdgeCaseVectors(t *testing.T) {
+	testVectorFiles(t, "testdata/edgecases")
+}
+
+func testVectorFiles(t *testing.T, dir string) {
+	files, err := wycheproofFiles.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		t.Run(name, func(t *testing.T) {
+			data, err := wycheproofFiles.ReadFile(path.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var root struct {
+				Schema     string             `json:"schema"`
+				TestGroups []*wycheproofGroup `json:"testGroups"`
+			}
+			if err := json.Unmarshal(data, &root); err != nil {
+				t.Fatal(err)
+			}
+			if len(root.TestGroups) == 0 {
+				t.Fatal("no test groups")
+			}
+			for _, g := range root.TestGroups {
+				testWycheproofGroup(t, g, root.Schema == p1363Schema)
+			}
+		})
+	}
+}
+
+func testWycheproofGroup(t *testing.T, g *wycheproofGroup, p1363 bool) {
+	curve, ok := wycheproofCurves[g.PublicKey.Curve]
+	if !ok {
+		t.Fatalf("unknown curve %q", g.PublicKey.Curve)
+	}
+	h, ok := wycheproofHashes[g.SHA]
+	if !ok {
+		t.Fatalf("unknown hash %q", g.SHA)
+	}
+	c := curve()
+	if err := fipsCheckCurve(c); err != nil {
+		t.Skipf("%s: %s", g.PublicKey.Curve, err)
+	}
+	if testing.Short() && strings.HasPrefix(g.PublicKey.Curve, "brainpool") {
+		t.Skip("generic curve arithmetic is slow; skipping in short mode")
+	}
+	pub := &PublicKey{Curve: c, X: new(big.Int).SetBytes(mustDecodeHex(t, g.PublicKey.Wx)), Y: new(big.Int).SetBytes(mustDecodeHex(t, g.PublicKey.Wy))}
+	if err := ValidatePublicKey(c, pub); err != nil {
+		t.Fatalf("invalid group key: %s", err)
+	}
+
+	// The group key is also checked as the blinding of an unblinded key
+	// pkS, through VerifyWithBlind.
+	skB, err := GenerateKey(c, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	pkS, err := UnblindPublicKey(c, pub, skB)
+	if err != nil {
+		t.Fatalf("UnblindPublicKey error: %s", err)
+	}
+
+	for _, tc := range g.Tests {
+		if tc.Result == "acceptable" {
+			continue
+		}
+		want := tc.Result == "valid"
+		md := h.New()
+		md.Write(mustDecodeHex(t, tc.Msg))
+		hashed := md.Sum(nil)
+		sig := mustDecodeHex(t, tc.Sig)
+
+		var r, s *big.Int
+		if p1363 {
+			// UnmarshalRawSignature only rejects signatures of the wrong
+			// length, which no valid signature has.
+			raw, err := UnmarshalRawSignature(c, sig)
+			if err != nil {
+				if want {
+					t.Errorf("tcId %d (%s): UnmarshalRawSignature error: %s", tc.TcID, tc.Comment, err)
+				}
+				continue
+			}
+			r, s = raw.R, raw.S
+		} else {
+			if got := VerifyASN1(pub, hashed, sig); got != want {
+				t.Errorf("tcId %d (%s): VerifyASN1 = %v, want %v", tc.TcID, tc.Comment, got, want)
+			}
+			r, s, ok = parseASN1Signature(sig)
+			if !ok {
+				continue
+			}
+		}
+		if got := Verify(pub, hashed, r, s); got != want {
+			t.Errorf("tcId %d (%s): Verify = %v, want %v", tc.TcID, tc.Comment, got, want)
+		}
+		err := CheckSignature(pub, hashed, r, s)
+		if want && err != nil {
+			t.Errorf("tcId %d (%s): CheckSignature error: %s", tc.TcID, tc.Comment, err)
+		} else if !want && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("tcId %d (%s): CheckSignature: got %v, want ErrInvalidSignature", tc.TcID, tc.Comment, err)
+		}
+		if got := VerifyWithBlind(pkS, skB, hashed, r, s); got != want {
+			t.Errorf("tcId %d (%s): VerifyWithBlind = %v, want %v", tc.TcID, tc.Comment, got, want)
+		}
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %s", s, err)
+	}
+	return b
+}
@@ -0,0 +1,182 @@
+package ed25519
+
+import (
+	"crypto/sha512"
+	"errors"
+
+	"github.com/cloudflare/pat-go/ed25519/internal/edwards25519"
+)
+
+// ErrTorsion is returned by Params with CofactorReject for a public key with
+// a component of small order.
+var ErrTorsion = errors.New("ed25519: point has a small-order component")
+
+// CofactorMode selects how Params handles points with a component in the
+// subgroup of order 8 of edwards25519. Keys generated by this package have
+// none, but a key blinded by one implementation from a key with such a
+// component can differ from the same key blinded by another, and signatures
+// under it can be accepted by one verifier and rejected by another, since
+// RFC 8032 allows both the cofactored and the cofactorless verification
+// equations.
+type CofactorMode int
+
+const (
+	// CofactorIgnore accepts any point, blinds and unblinds keys without
+	// touching their small-order component, and verifies with the
+	// cofactorless equation [S]B = R + [k]A. This is the behavior of the
+	// package-level functions.
+	CofactorIgnore CofactorMode = iota
+
+	// CofactorClear removes the small-order component of keys before
+	// blinding and unblinding them, so that every implementation that
+	// clears it agrees on blinded keys, and verifies with the cofactored
+	// equation [8][S]B = [8]R + [8][k]A, which every valid signature
+	// satisfies whatever the small-order components of R and A.
+	CofactorClear
+
+	// CofactorReject refuses keys of small order or with a small-order
+	// component, and signatures whose R has one, and verifies with the
+	// cofactorless equation. It is the strictest mode, on which
+	// implementations can't disagree.
+	CofactorReject
+)
+
+// String returns the name of the mode.
+func (m CofactorMode) String() string {
+	switch m {
+	case CofactorIgnore:
+		return "ignore"
+	case CofactorClear:
+		return "clear"
+	case CofactorReject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+// Params are parameters of the scheme that the RFCs leave to the
+// implementation. The zero value matches the package-level functions.
+type Params struct {
+	Cofactor CofactorMode
+}
+
+// multByCofactor returns [8]p.
+func multByCofactor(p *edwards25519.Point) *edwards25519.Point {
+	q := new(edwards25519.Point).Add(p, p)
+	q.Add(q, q)
+	return q.Add(q, q)
+}
+
+// isTorsionFree reports whether p is in the prime-order subgroup, that is,
+// whether [l]p is the identity, computed as [l-1]p + p.
+func isTorsionFree(p *edwards25519.Point) bool {
+	var one [32]byte
+	one[0] = 1
+	lMinusOne := edwards25519.NewScalar().Negate(edwards25519.NewScalar().SetBytes(one[:]))
+	q := new(edwards25519.Point).ScalarMult(lMinusOne, p)
+	return q.Add(q, p).Equal(edwards25519.NewIdentityPoint()) == 1
+}
+
+// clearTorsion returns the component of p in the prime-order subgroup,
+// [8^-1 mod l][8]p, which is p itself if p is torsion-free.
+func clearTorsion(p *edwards25519.Point) *edwards25519.Point {
+	var eight [32]byte
+	eight[0] = 8
+	inv := edwards25519.NewScalar().SetBytes(eight[:]).ModInverse()
+	return new(edwards25519.Point).ScalarMult(inv, multByCofactor(p))
+}
+
+// publicKeyPoint decodes publicKey and applies the cofactor mode of p to it.
+func (p Params) publicKeyPoint(publicKey []byte) (*edwards25519.Point, error) {
+	A, err := new(edwards25519.Point).SetBytes(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	switch p.Cofactor {
+	case CofactorClear:
+		return clearTorsion(A), nil
+	case CofactorReject:
+		if multByCofactor(A).Equal(edwards25519.NewIdentityPoint()) == 1 || !isTorsionFree(A) {
+			return nil, ErrTorsion
+		}
+	}
+	return A, nil
+}
+
+// blindScalar returns the scalar that blinds keys with blind and context.
+func blindScalar(blind, context []byte) *edwards25519.Scalar {
+	blindContext := append(append(append([]byte{}, blind...), 0x00), context...)
+	b := sha512.Sum512(blindContext)
+	return edwards25519.NewScalar().SetBytes(b[:32])
+}
+
+// BlindPublicKeyWithContext is BlindPublicKeyWithContext with the cofactor
+// mode of p applied to publicKey.
+func (p Params) BlindPublicKeyWithContext(publicKey PublicKey, blind []byte, context []byte) (PublicKey, error) {
+	P, err := p.publicKeyPoint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return P.ScalarMult(blindScalar(blind, context), P).Bytes(), nil
+}
+
+// UnblindPublicKeyWithContext is UnblindPublicKeyWithContext with the
+// cofactor mode of p applied to publicKey.
+func (p Params) UnblindPublicKeyWithContext(publicKey PublicKey, blind []byte, context []byte) (PublicKey, error) {
+	P, err := p.publicKeyPoint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	rInv := blindScalar(blind, context).ModInverse()
+	return P.ScalarMult(rInv, P).Bytes(), nil
+}
+
+// Verify is Verify with the cofactor mode of p. With CofactorIgnore, it is
+// the package-level Verify. It will panic if len(publicKey) is not
+// PublicKeySize.
+func (p Params) Verify(publicKey PublicKey, message, sig []byte) bool {
+	switch p.Cofactor {
+	case CofactorIgnore:
+		return Verify(publicKey, message, sig)
+	case CofactorClear, CofactorReject:
+	default:
+		return false
+	}
+	if len(publicKey) != PublicKeySize {
+		panic("ed25519: bad public key length")
+	}
+	if len(sig) != SignatureSize || sig[63]&224 != 0 {
+		return false
+	}
+	A, err := new(edwards25519.Point).SetBytes(publicKey)
+	if err != nil {
+		return false
+	}
+	R, err := new(edwards25519.Point).SetBytes(sig[:32])
+	if err != nil {
+		return false
+	}
+	if p.Cofactor == CofactorReject {
+		if _, err := p.publicKeyPoint(publicKey); err != nil || !isTorsionFree(R) {
+			return false
+		}
+		return Verify(publicKey, message, sig)
+	}
+
+	kh := sha512.New()
+	kh.Write(sig[:32])
+	kh.Write(publicKey)
+	kh.Write(message)
+	k := edwards25519.NewScalar().SetUniformBytes(kh.Sum(nil))
+	S, err := edwards25519.NewScalar().SetCanonicalBytes(sig[32:])
+	if err != nil {
+		return false
+	}
+
+	// [8]([S]B - [k]A - R) = 0.
+	minusA := new(edwards25519.Point).Negate(A)
+	Q := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(k, minusA, S)
+	Q.Subtract(Q, R)
+	return multByCofactor(Q).Equal(edwards25519.NewIdentityPoint()) == 1
+}
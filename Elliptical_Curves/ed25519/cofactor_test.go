@@ -0,0 +1,111 @@
+package ed25519
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/ed25519/internal/edwards25519"
+)
+
+// order8Point is a point of order 8 of edwards25519.
+const order8Point = "c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a"
+
+func mustPoint(t *testing.T, b []byte) *edwards25519.Point {
+	t.Helper()
+	p, err := new(edwards25519.Point).SetBytes(b)
+	if err != nil {
+		t.Fatalf("SetBytes error: %s", err)
+	}
+	return p
+}
+
+// torsionedKey returns the public key of priv plus a point of order 8.
+func torsionedKey(t *testing.T, priv PrivateKey) PublicKey {
+	T, _ := hex.DecodeString(order8Point)
+	A := mustPoint(t, priv.Public().(PublicKey))
+	return A.Add(A, mustPoint(t, T)).Bytes()
+}
+
+// torsionedSign signs message under priv with a nonce point R plus a point of
+// order 8, which satisfies only the cofactored verification equation.
+func torsionedSign(t *testing.T, priv PrivateKey, message []byte) []byte {
+	h := sha512.Sum512(priv.Seed())
+	s := edwards25519.NewScalar().SetBytesWithClamping(h[:32])
+	r := edwards25519.NewScalar().SetUniformBytes(bytes.Repeat([]byte{7}, 64))
+	T, _ := hex.DecodeString(order8Point)
+	R := new(edwards25519.Point).ScalarBaseMult(r)
+	R.Add(R, mustPoint(t, T))
+
+	kh := sha512.New()
+	kh.Write(R.Bytes())
+	kh.Write(priv.Public().(PublicKey))
+	kh.Write(message)
+	k := edwards25519.NewScalar().SetUniformBytes(kh.Sum(nil))
+	S := edwards25519.NewScalar().MultiplyAdd(k, s, r)
+	return append(R.Bytes(), S.Bytes()...)
+}
+
+func TestCofactorModes(t *testing.T) {
+	var zero zeroReader
+	public, private, _ := GenerateKey(zero)
+	blind := bytes.Repeat([]byte{1}, SeedSize)
+	context := []byte("context")
+	message := []byte("test message")
+	sig := Sign(private, message)
+
+	// Every mode agrees with the package-level functions on honest keys.
+	want, _ := BlindPublicKeyWithContext(public, blind, context)
+	for _, mode := range []CofactorMode{CofactorIgnore, CofactorClear, CofactorReject} {
+		p := Params{Cofactor: mode}
+		got, err := p.BlindPublicKeyWithContext(public, blind, context)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: BlindPublicKeyWithContext = %x, %v, want %x", mode, got, err, want)
+		}
+		unblinded, err := p.UnblindPublicKeyWithContext(got, blind, context)
+		if err != nil || !bytes.Equal(unblinded, public) {
+			t.Errorf("%s: UnblindPublicKeyWithContext did not invert BlindPublicKeyWithContext", mode)
+		}
+		if !p.Verify(public, message, sig) {
+			t.Errorf("%s: valid signature rejected", mode)
+		}
+		if p.Verify(public, []byte("wrong message"), sig) {
+			t.Errorf("%s: signature of different message accepted", mode)
+		}
+	}
+
+	torsioned := torsionedKey(t, private)
+	ignored, err := Params{}.BlindPublicKeyWithContext(torsioned, blind, context)
+	if err != nil || bytes.Equal(ignored, want) {
+		t.Errorf("ignore: blinding a torsioned key removed its small-order component")
+	}
+	cleared, err := Params{Cofactor: CofactorClear}.BlindPublicKeyWithContext(torsioned, blind, context)
+	if err != nil || !bytes.Equal(cleared, want) {
+		t.Errorf("clear: blinding a torsioned key = %x, %v, want %x", cleared, err, want)
+	}
+	if _, err := (Params{Cofactor: CofactorReject}).BlindPublicKeyWithContext(torsioned, blind, context); !errors.Is(err, ErrTorsion) {
+		t.Errorf("reject: blinding a torsioned key: got %v, want ErrTorsion", err)
+	}
+	T, _ := hex.DecodeString(order8Point)
+	if _, err := (Params{Cofactor: CofactorReject}).UnblindPublicKeyWithContext(T, blind, context); !errors.Is(err, ErrTorsion) {
+		t.Errorf("reject: unblinding a small-order key: got %v, want ErrTorsion", err)
+	}
+
+	// A signature with a torsioned R is valid only under the cofactored
+	// equation.
+	tsig := torsionedSign(t, private, message)
+	if Verify(public, message, tsig) || (Params{Cofactor: CofactorReject}).Verify(public, message, tsig) {
+		t.Errorf("cofactorless verification accepted a signature with a torsioned R")
+	}
+	if !(Params{Cofactor: CofactorClear}).Verify(public, message, tsig) {
+		t.Errorf("cofactored verification rejected a signature with a torsioned R")
+	}
+	if (Params{Cofactor: CofactorReject}).Verify(torsioned, message, sig) {
+		t.Errorf("reject: signature under a torsioned key accepted")
+	}
+	if (Params{Cofactor: CofactorMode(42)}).Verify(public, message, sig) {
+		t.Errorf("unknown mode accepted a signature")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"crypto"
 	cryptorand "crypto/rand"
 	"crypto/sha512"
+	"crypto/subtle"
 	"errors"
 	"io"
 	"strconv"
@@ -66,7 +67,7 @@ func (priv PrivateKey) Equal(x crypto.PrivateKey) bool {
 	if !ok {
 		return false
 	}
-	return bytes.Equal(priv, xx)
+	return subtle.ConstantTimeCompare(priv, xx) == 1
 }
 
 // Seed returns the private key seed corresponding to priv. It is provided for
@@ -112,20 +113,7 @@ func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
 
 // BlindPublicKeyWithContext augments the public key pair by the blind key and context string.
 func BlindPublicKeyWithContext(publicKey PublicKey, blind []byte, context []byte) (PublicKey, error) {
-	blindContext := append(blind, 0x00)
-	blindContext = append(blindContext, context...)
-	b := sha512.Sum512(blindContext)
-	r := edwards25519.NewScalar().SetBytes(b[:32])
-
-	P, err := (&edwards25519.Point{}).SetBytes(publicKey)
-	if err != nil {
-		return nil, err
-	}
-
-	P.ScalarMult(r, P)
-	blindedKey := P.Bytes()
-
-	return blindedKey, nil
+	return Params{}.BlindPublicKeyWithContext(publicKey, blind, context)
 }
 
 // BlindPublicKey augments the public key pair by the blind key.
@@ -135,22 +123,7 @@ func BlindPublicKey(publicKey PublicKey, blind []byte) (PublicKey, error) {
 
 // UnblindPublicKey unblinds the public key pair by the blind key and context string.
 func UnblindPublicKeyWithContext(publicKey PublicKey, blind []byte, context []byte) (PublicKey, error) {
-	blindContext := append(blind, 0x00)
-	blindContext = append(blindContext, context...)
-	b := sha512.Sum512(blindContext)
-
-	r := edwards25519.NewScalar().SetBytes(b[:32])
-	rInv := edwards25519.NewScalar().Set(r).ModInverse()
-
-	P, err := (&edwards25519.Point{}).SetBytes(publicKey)
-	if err != nil {
-		return nil, err
-	}
-
-	P.ScalarMult(rInv, P)
-	unblindedKey := P.Bytes()
-
-	return unblindedKey, nil
+	return Params{}.UnblindPublicKeyWithContext(publicKey, blind, context)
 }
 
 // UnblindPublicKey unblinds the public key pair by the blind key.
@@ -0,0 +1,154 @@
+//go:build interop
+
+package ed25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// The interop tests check this package against the JSON test vectors
+// published alongside the CFRG key blinding draft. Run them with:
+//
+//	CFRG_ED25519_BLINDING_TEST_VECTORS_IN=vectors.json go test -tags=interop -run TestInterop ./ed25519
+//
+// The input file must come from the draft or another implementation: the
+// vectors in tokens/type3 were generated by this package, so checking against
+// them would only compare it with itself. Without an input file, the test is
+// skipped.
+const (
+	inputCFRGEd25519BlindingTestVectorEnvironmentKey  = "CFRG_ED25519_BLINDING_TEST_VECTORS_IN"
+	outputCFRGEd25519BlindingTestVectorEnvironmentKey = "CFRG_ED25519_BLINDING_TEST_VECTORS_OUT"
+)
+
+type rawCFRGEd25519BlindingTestVector struct {
+	PrivateKey     string `json:"skS"`
+	PublicKey      string `json:"pkS"`
+	PrivateBlind   string `json:"bk"`
+	PublicBlind    string `json:"pkB"`
+	BlindPublicKey string `json:"pkR"`
+	Message        string `json:"message"`
+	Context        string `json:"context"`
+	Signature      string `json:"signature"`
+}
+
+func mustUnhex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("Unhex failed: %v", err)
+	}
+	return b
+}
+
+func verifyCFRGEd25519BlindingTestVector(t *testing.T, raw rawCFRGEd25519BlindingTestVector) {
+	privateKey := NewKeyFromSeed(mustUnhex(t, raw.PrivateKey))
+	publicKey := privateKey.Public().(PublicKey)
+	if !bytes.Equal(publicKey, mustUnhex(t, raw.PublicKey)) {
+		t.Fatalf("public key mismatch: got %x, want %s", publicKey, raw.PublicKey)
+	}
+
+	blind := mustUnhex(t, raw.PrivateBlind)
+	context := mustUnhex(t, raw.Context)
+	publicBlind, err := BlindPublicKeyWithContext(publicKey, blind, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(publicBlind, mustUnhex(t, raw.BlindPublicKey)) {
+		t.Fatalf("blinded public key mismatch: got %x, want %s", publicBlind, raw.BlindPublicKey)
+	}
+
+	unblinded, err := UnblindPublicKeyWithContext(publicBlind, blind, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unblinded, publicKey) {
+		t.Fatal("unblinded public key mismatch")
+	}
+
+	// Blinded Ed25519 signatures are deterministic, so they must match
+	// byte for byte.
+	message := mustUnhex(t, raw.Message)
+	signature := BlindKeySignWithContext(privateKey, message, blind, context)
+	if !bytes.Equal(signature, mustUnhex(t, raw.Signature)) {
+		t.Fatalf("signature mismatch: got %x, want %s", signature, raw.Signature)
+	}
+	if !Verify(publicBlind, message, signature) {
+		t.Fatal("signature with blinded key verification failed")
+	}
+}
+
+func TestInteropVerifyEd25519Blinding(t *testing.T) {
+	inputFile := os.Getenv(inputCFRGEd25519BlindingTestVectorEnvironmentKey)
+	if len(inputFile) == 0 {
+		t.Skipf("%s not set; no external test vectors to check against", inputCFRGEd25519BlindingTestVectorEnvironmentKey)
+	}
+	encoded, err := os.ReadFile(inputFile)
+	if err != nil {
+		t.Fatalf("Failed reading test vectors: %v", err)
+	}
+
+	var vectors []rawCFRGEd25519BlindingTestVector
+	if err := json.Unmarshal(encoded, &vectors); err != nil {
+		t.Fatalf("Error decoding test vector string: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("no test vectors found")
+	}
+	for i, vector := range vectors {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			verifyCFRGEd25519BlindingTestVector(t, vector)
+		})
+	}
+}
+
+// TestInteropGenerateEd25519Blinding generates vectors in the draft format,
+// covering the combinations of empty and random blinds and contexts.
+func TestInteropGenerateEd25519Blinding(t *testing.T) {
+	var vectors []rawCFRGEd25519BlindingTestVector
+	for _, blindLen := range []int{0, 32} {
+		for _, contextLen := range []int{0, 32} {
+			seed := make([]byte, SeedSize)
+			rand.Read(seed)
+			blind := make([]byte, 32)
+			rand.Read(blind[:blindLen])
+			context := make([]byte, contextLen)
+			rand.Read(context)
+
+			privateKey := NewKeyFromSeed(seed)
+			publicKey := privateKey.Public().(PublicKey)
+			publicBlind, err := BlindPublicKeyWithContext(publicKey, blind, context)
+			if err != nil {
+				t.Fatal(err)
+			}
+			message := []byte("hello world")
+			vectors = append(vectors, rawCFRGEd25519BlindingTestVector{
+				PrivateKey:     hex.EncodeToString(seed),
+				PublicKey:      hex.EncodeToString(publicKey),
+				PrivateBlind:   hex.EncodeToString(blind),
+				BlindPublicKey: hex.EncodeToString(publicBlind),
+				Message:        hex.EncodeToString(message),
+				Context:        hex.EncodeToString(context),
+				Signature:      hex.EncodeToString(BlindKeySignWithContext(privateKey, message, blind, context)),
+			})
+		}
+	}
+
+	for _, vector := range vectors {
+		verifyCFRGEd25519BlindingTestVector(t, vector)
+	}
+
+	if outputFile := os.Getenv(outputCFRGEd25519BlindingTestVectorEnvironmentKey); len(outputFile) > 0 {
+		encoded, err := json.Marshal(vectors)
+		if err != nil {
+			t.Fatalf("Error producing test vectors: %v", err)
+		}
+		if err := os.WriteFile(outputFile, encoded, 0644); err != nil {
+			t.Fatalf("Error writing test vectors: %v", err)
+		}
+	}
+}
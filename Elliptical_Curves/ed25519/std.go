@@ -0,0 +1,47 @@
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"strconv"
+
+	"github.com/cloudflare/pat-go/ed25519/internal/edwards25519"
+)
+
+// FromStdPublicKey converts a crypto/ed25519 public key, such as one parsed
+// by crypto/x509, to a public key of this package. It returns an error if the
+// key is not the encoding of a point.
+func FromStdPublicKey(pub ed25519.PublicKey) (PublicKey, error) {
+	if len(pub) != PublicKeySize {
+		return nil, errors.New("ed25519: bad public key length: " + strconv.Itoa(len(pub)))
+	}
+	if _, err := new(edwards25519.Point).SetBytes(pub); err != nil {
+		return nil, errors.New("ed25519: invalid public key")
+	}
+	return append(PublicKey{}, pub...), nil
+}
+
+// FromStdPrivateKey converts a crypto/ed25519 private key to a private key of
+// this package. Both packages encode private keys as the seed followed by the
+// public key; the conversion checks that the public key matches the seed.
+func FromStdPrivateKey(priv ed25519.PrivateKey) (PrivateKey, error) {
+	if len(priv) != PrivateKeySize {
+		return nil, errors.New("ed25519: bad private key length: " + strconv.Itoa(len(priv)))
+	}
+	sk := NewKeyFromSeed(priv[:SeedSize])
+	if !bytes.Equal(sk[SeedSize:], priv[SeedSize:]) {
+		return nil, errors.New("ed25519: private key does not match its public key")
+	}
+	return sk, nil
+}
+
+// ToStdPublicKey converts pub to a crypto/ed25519 public key.
+func ToStdPublicKey(pub PublicKey) ed25519.PublicKey {
+	return append(ed25519.PublicKey{}, pub...)
+}
+
+// ToStdPrivateKey converts priv to a crypto/ed25519 private key.
+func ToStdPrivateKey(priv PrivateKey) ed25519.PrivateKey {
+	return append(ed25519.PrivateKey{}, priv...)
+}
@@ -0,0 +1,61 @@
+package ed25519
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+func TestStdConversion(t *testing.T) {
+	stdPub, stdPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := FromStdPrivateKey(stdPriv)
+	if err != nil {
+		t.Fatalf("FromStdPrivateKey error: %s", err)
+	}
+	pub, err := FromStdPublicKey(stdPub)
+	if err != nil {
+		t.Fatalf("FromStdPublicKey error: %s", err)
+	}
+	if !pub.Equal(priv.Public()) {
+		t.Errorf("public key does not match private key")
+	}
+	if !stdPriv.Equal(ToStdPrivateKey(priv)) || !stdPub.Equal(ToStdPublicKey(pub)) {
+		t.Errorf("keys do not round-trip through crypto/ed25519")
+	}
+
+	message := []byte("test message")
+	if sig, _ := priv.Sign(nil, message, crypto.Hash(0)); !ed25519.Verify(stdPub, message, sig) {
+		t.Errorf("crypto/ed25519 rejected a signature of this package")
+	}
+	blind := make([]byte, SeedSize)
+	rand.Read(blind)
+	pkR, err := BlindPublicKey(pub, blind)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	if sig := BlindKeySign(priv, message, blind); !ed25519.Verify(ToStdPublicKey(pkR), message, sig) {
+		t.Errorf("crypto/ed25519 rejected a blinded signature of this package")
+	}
+
+	mismatched := append(ed25519.PrivateKey{}, stdPriv...)
+	mismatched[SeedSize] ^= 1
+	if _, err := FromStdPrivateKey(mismatched); err == nil {
+		t.Errorf("FromStdPrivateKey accepted a private key with another public key")
+	}
+	if _, err := FromStdPrivateKey(stdPriv[:SeedSize]); err == nil {
+		t.Errorf("FromStdPrivateKey accepted a seed")
+	}
+	if _, err := FromStdPublicKey(stdPub[1:]); err == nil {
+		t.Errorf("FromStdPublicKey accepted a short key")
+	}
+	// The y-coordinate 2 is not on the curve.
+	invalid := make(ed25519.PublicKey, PublicKeySize)
+	invalid[0] = 2
+	if _, err := FromStdPublicKey(invalid); err == nil {
+		t.Errorf("FromStdPublicKey accepted an invalid point")
+	}
+}
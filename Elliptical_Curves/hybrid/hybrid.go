@@ -0,0 +1,179 @@
+// Package hybrid implements hybrid signatures that combine a signature with
+// key blinding with a Dilithium (ML-DSA) signature over the same message, for
+// deployments preparing a migration to post-quantum signatures.
+//
+// A hybrid signature verifies only if both component signatures verify. The
+// classical component is produced with a blinded key, as with the schemes of
+// the blinding package, while the Dilithium component is produced with a
+// conventional key, since no key blinding is known for Dilithium. The
+// Dilithium public key is therefore the same for every blinded key, and
+// signatures are only unlinkable across Dilithium keys: deployments that need
+// unlinkability should use a fresh Dilithium key for each context.
+//
+// Both components sign the message prefixed with a domain separation label
+// and the two public keys, so a component can't be stripped and reused as a
+// standalone signature, or combined with the component of another key.
+package hybrid
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/sign/dilithium"
+	"golang.org/x/crypto/cryptobyte"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+var labelPrefix = []byte("Hybrid Blinded Signature")
+
+var errInvalidPrivateKey = errors.New("hybrid: invalid private key")
+
+// Scheme is a hybrid signature scheme.
+type Scheme struct {
+	classical blinding.BlindableScheme
+	pq        dilithium.Mode
+}
+
+// New returns the hybrid of the classical scheme with key blinding and the
+// Dilithium mode pq.
+func New(classical blinding.BlindableScheme, pq dilithium.Mode) *Scheme {
+	return &Scheme{classical: classical, pq: pq}
+}
+
+// Name returns the name of the scheme.
+func (s *Scheme) Name() string {
+	return s.classical.Name() + "+" + s.pq.Name()
+}
+
+// PublicKey is a hybrid public key. Classical is encoded as in the classical
+// scheme, and may be blinded; PQ is a packed Dilithium public key.
+type PublicKey struct {
+	Classical []byte
+	PQ        []byte
+}
+
+// PrivateKey is a hybrid private key. Classical is encoded as in the
+// classical scheme; PQ is a packed Dilithium private key.
+type PrivateKey struct {
+	Classical []byte
+	PQ        []byte
+}
+
+// GenerateKey generates a hybrid key pair using entropy from rand.
+func (s *Scheme) GenerateKey(rand io.Reader) (*PublicKey, *PrivateKey, error) {
+	pkC, skC, err := s.classical.GenerateKey(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkPQ, skPQ, err := s.pq.GenerateKey(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &PublicKey{Classical: pkC, PQ: pkPQ.Bytes()},
+		&PrivateKey{Classical: skC, PQ: skPQ.Bytes()}, nil
+}
+
+// BlindPublicKey blinds the classical component of pk by blind and context.
+// The Dilithium component is left unchanged.
+func (s *Scheme) BlindPublicKey(pk *PublicKey, blind, context []byte) (*PublicKey, error) {
+	pkC, err := s.classical.BlindPublicKey(pk.Classical, blind, context)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{Classical: pkC, PQ: append([]byte{}, pk.PQ...)}, nil
+}
+
+// label returns the string that is signed by both components: a label
+// binding the two public keys, followed by the message.
+func (s *Scheme) label(pkC, pkPQ, message []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddBytes(labelPrefix)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(s.Name()))
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(pkC)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(pkPQ)
+	})
+	b.AddBytes(message)
+	return b.BytesOrPanic()
+}
+
+// Sign signs message with the unblinded key sk.
+func (s *Scheme) Sign(rand io.Reader, sk *PrivateKey, message []byte) ([]byte, error) {
+	return s.sign(rand, sk, nil, nil, message)
+}
+
+// BlindKeySign signs message with the classical component of sk blinded by
+// blind and context, and with its Dilithium component. The signature
+// verifies under the public key returned by BlindPublicKey with the same
+// blind and context.
+func (s *Scheme) BlindKeySign(rand io.Reader, sk *PrivateKey, blind, message, context []byte) ([]byte, error) {
+	if blind == nil {
+		// A nil blind selects unblinded signing in sign.
+		blind = []byte{}
+	}
+	return s.sign(rand, sk, blind, context, message)
+}
+
+func (s *Scheme) sign(rand io.Reader, sk *PrivateKey, blind, context, message []byte) ([]byte, error) {
+	if len(sk.PQ) != s.pq.PrivateKeySize() {
+		return nil, errInvalidPrivateKey
+	}
+	skPQ := s.pq.PrivateKeyFromBytes(sk.PQ)
+	pkPQ := skPQ.Public().(dilithium.PublicKey).Bytes()
+
+	pkC, err := s.classical.PublicKey(sk.Classical)
+	if err != nil {
+		return nil, err
+	}
+	if blind != nil {
+		if pkC, err = s.classical.BlindPublicKey(pkC, blind, context); err != nil {
+			return nil, err
+		}
+	}
+	m := s.label(pkC, pkPQ, message)
+
+	var sigC []byte
+	if blind == nil {
+		sigC, err = s.classical.Sign(rand, sk.Classical, m)
+	} else {
+		sigC, err = s.classical.BlindKeySign(rand, sk.Classical, blind, m, context)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sigPQ := s.pq.Sign(skPQ, m)
+
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sigC)
+	})
+	b.AddBytes(sigPQ)
+	return b.BytesOrPanic(), nil
+}
+
+// Verify reports whether sig is a valid hybrid signature of message by pk.
+// Both the classical and the Dilithium components must be valid.
+func (s *Scheme) Verify(pk *PublicKey, message, sig []byte) bool {
+	if len(pk.PQ) != s.pq.PublicKeySize() {
+		return false
+	}
+
+	str := cryptobyte.String(sig)
+	var sigC cryptobyte.String
+	if !str.ReadUint16LengthPrefixed(&sigC) || len(str) != s.pq.SignatureSize() {
+		return false
+	}
+	sigPQ := []byte(str)
+
+	m := s.label(pk.Classical, pk.PQ, message)
+	if !s.classical.Verify(pk.Classical, m, sigC) {
+		return false
+	}
+	return s.pq.Verify(s.pq.PublicKeyFromBytes(pk.PQ), m, sigPQ)
+}
@@ -0,0 +1,95 @@
+package hybrid
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/dilithium"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+func testAllSchemes(t *testing.T, f func(*testing.T, *Scheme)) {
+	schemes := []*Scheme{
+		New(blinding.Ed25519, dilithium.Mode2),
+		New(blinding.Ristretto255, dilithium.Mode2),
+		New(blinding.ECDSA(elliptic.P256(), crypto.SHA256), dilithium.Mode3),
+	}
+	for _, s := range schemes {
+		t.Run(s.Name(), func(t *testing.T) {
+			f(t, s)
+		})
+	}
+}
+
+func TestBlindKeySign(t *testing.T) {
+	testAllSchemes(t, testBlindKeySign)
+}
+
+func testBlindKeySign(t *testing.T, s *Scheme) {
+	pk, sk, err := s.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	message := []byte("test message")
+
+	sig, err := s.Sign(rand.Reader, sk, message)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !s.Verify(pk, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+
+	blind, err := s.classical.GenerateBlind(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateBlind error: %s", err)
+	}
+	context := []byte("context")
+
+	pkR, err := s.BlindPublicKey(pk, blind, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	sigR, err := s.BlindKeySign(rand.Reader, sk, blind, message, context)
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	if !s.Verify(pkR, message, sigR) {
+		t.Errorf("valid blinded signature rejected")
+	}
+	if s.Verify(pk, message, sigR) {
+		t.Errorf("blinded signature accepted under the unblinded key")
+	}
+	if s.Verify(pkR, []byte("wrong message"), sigR) {
+		t.Errorf("signature of different message accepted")
+	}
+}
+
+func TestComponentsBound(t *testing.T) {
+	s := New(blinding.Ed25519, dilithium.Mode2)
+	pk1, sk1, _ := s.GenerateKey(rand.Reader)
+	pk2, sk2, _ := s.GenerateKey(rand.Reader)
+	message := []byte("test message")
+
+	sig1, _ := s.Sign(rand.Reader, sk1, message)
+	sig2, _ := s.Sign(rand.Reader, sk2, message)
+
+	// Splice the classical component of sig1 with the Dilithium component of
+	// sig2, under a public key mixing the two keys.
+	mixed := &PublicKey{Classical: pk1.Classical, PQ: pk2.PQ}
+	n := len(sig1) - s.pq.SignatureSize()
+	spliced := append(append([]byte{}, sig1[:n]...), sig2[n:]...)
+	if s.Verify(mixed, message, spliced) {
+		t.Errorf("spliced signature accepted")
+	}
+
+	if s.Verify(pk1, message, sig1[:len(sig1)-1]) {
+		t.Errorf("truncated signature accepted")
+	}
+	if s.Verify(pk1, message, sig1[n:]) {
+		t.Errorf("Dilithium component accepted alone")
+	}
+}
@@ -0,0 +1,303 @@
+// Package issuer implements an HTTP handler that issues signatures with
+// blinded keys, complementing the gRPC service of the blindsign package for
+// deployments built on net/http.
+//
+// Clients POST an issuance request with the message to sign, the blind and
+// context string of the key to sign it with, and the blinded public key they
+// expect, encoded in JSON (application/json) or CBOR (application/cbor). The
+// response, encoded as selected by the Accept header, carries the signature.
+//
+// Each request carries a random nonce and a timestamp. The handler refuses
+// requests whose timestamp is too far from its clock, and requests whose
+// nonce it has already seen within that window, so that a request is signed
+// at most once even if it is retried or replayed. Authenticating clients is
+// left to the server the handler is mounted on.
+package issuer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+// Media types of the encodings of requests and responses.
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeCBOR = "application/cbor"
+)
+
+const (
+	// MinNonceSize is the minimum size, in bytes, of request nonces.
+	MinNonceSize = 16
+
+	defaultReplayWindow   = 5 * time.Minute
+	defaultMaxRequestSize = 64 << 10
+)
+
+// Request is an issuance request. In JSON, byte strings are encoded in
+// base64; in CBOR, fields are keyed by their integer tags.
+type Request struct {
+	// Message is the message to sign.
+	Message []byte `json:"message" cbor:"1,keyasint"`
+	// Blind and Context select the blinded key to sign with.
+	Blind   []byte `json:"blind" cbor:"2,keyasint"`
+	Context []byte `json:"context,omitempty" cbor:"3,keyasint,omitempty"`
+	// BlindedKey is the blinded public key expected by the client. The
+	// request is refused if it doesn't match the key selected by Blind and
+	// Context.
+	BlindedKey []byte `json:"blinded_key" cbor:"4,keyasint"`
+	// Nonce is a random string of at least MinNonceSize bytes, unique to the
+	// request.
+	Nonce []byte `json:"nonce" cbor:"5,keyasint"`
+	// Timestamp is the time of the request in Unix seconds.
+	Timestamp int64 `json:"timestamp" cbor:"6,keyasint"`
+}
+
+// Response is the response to a successful issuance request.
+type Response struct {
+	// Scheme is the name of the signature scheme.
+	Scheme    string `json:"scheme" cbor:"1,keyasint"`
+	Signature []byte `json:"signature" cbor:"2,keyasint"`
+}
+
+// Handler is an http.Handler that signs issuance requests with a private key
+// of a blinding scheme. It is safe for concurrent use.
+type Handler struct {
+	scheme     blinding.BlindableScheme
+	privateKey []byte
+	publicKey  []byte
+	rand       io.Reader
+
+	// ReplayWindow is the maximum difference between the timestamp of a
+	// request and the time it is received. Nonces are remembered until the
+	// timestamp of their request leaves the window. It defaults to five
+	// minutes.
+	ReplayWindow time.Duration
+
+	// MaxRequestSize is the maximum size, in bytes, of a request body. It
+	// defaults to 64 KiB.
+	MaxRequestSize int64
+
+	// Timeout bounds the time spent on a request, in addition to the
+	// deadline of its context. Requests that run out of time, or whose
+	// client goes away, are answered with 503 Service Unavailable without
+	// being signed. Zero means no timeout.
+	Timeout time.Duration
+
+	replay *replayCache
+}
+
+// NewHandler returns a Handler that signs with privateKey, a private key of
+// scheme. Signatures are randomized with entropy from rand.
+func NewHandler(rand io.Reader, scheme blinding.BlindableScheme, privateKey []byte) (*Handler, error) {
+	pk, err := scheme.PublicKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		scheme:     scheme,
+		privateKey: privateKey,
+		publicKey:  pk,
+		rand:       rand,
+		replay:     newReplayCache(time.Now),
+	}, nil
+}
+
+// PublicKey returns the unblinded public key of the handler.
+func (h *Handler) PublicKey() []byte {
+	return h.publicKey
+}
+
+func (h *Handler) replayWindow() time.Duration {
+	if h.ReplayWindow > 0 {
+		return h.ReplayWindow
+	}
+	return defaultReplayWindow
+}
+
+func (h *Handler) maxRequestSize() int64 {
+	if h.MaxRequestSize > 0 {
+		return h.MaxRequestSize
+	}
+	return defaultMaxRequestSize
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reqType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (reqType != MediaTypeJSON && reqType != MediaTypeCBOR) {
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+	respType := negotiate(r.Header.Get("Accept"), reqType)
+	if respType == "" {
+		http.Error(w, "no acceptable content type", http.StatusNotAcceptable)
+		return
+	}
+
+	ctx := r.Context()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxRequestSize()))
+	if err != nil {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var req Request
+	if err := unmarshal(reqType, body, &req); err != nil {
+		http.Error(w, "malformed request", http.StatusBadRequest)
+		return
+	}
+
+	sig, status, err := h.issue(ctx, &req)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	out, err := marshal(respType, &Response{Scheme: h.scheme.Name(), Signature: sig})
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", respType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(out)
+}
+
+var (
+	errShortNonce      = errors.New("nonce too short")
+	errStaleRequest    = errors.New("timestamp outside of the replay window")
+	errReplayedRequest = errors.New("replayed request")
+	errKeyMismatch     = errors.New("blinded key does not match blind and context")
+)
+
+// issue checks req and signs its message, returning the HTTP status to
+// respond with on error. It gives up with ctx.Err() once ctx is done.
+func (h *Handler) issue(ctx context.Context, req *Request) ([]byte, int, error) {
+	if len(req.Nonce) < MinNonceSize {
+		return nil, http.StatusBadRequest, errShortNonce
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, http.StatusServiceUnavailable, err
+	}
+	pkR, err := h.scheme.BlindPublicKey(h.publicKey, req.Blind, req.Context)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if !bytes.Equal(pkR, req.BlindedKey) {
+		return nil, http.StatusBadRequest, errKeyMismatch
+	}
+	// The nonce is not recorded for a request that ran out of time, so that
+	// the client can retry it.
+	if err := ctx.Err(); err != nil {
+		return nil, http.StatusServiceUnavailable, err
+	}
+	// Nonces are only recorded for well-formed requests, so that a malformed
+	// request can't be used to burn the nonce of a valid one.
+	if err := h.replay.check(req.Nonce, time.Unix(req.Timestamp, 0), h.replayWindow()); err != nil {
+		if err == errReplayedRequest {
+			return nil, http.StatusConflict, err
+		}
+		return nil, http.StatusBadRequest, err
+	}
+
+	sig, err := h.scheme.BlindKeySign(h.rand, h.privateKey, req.Blind, req.Message, req.Context)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return sig, http.StatusOK, nil
+}
+
+func unmarshal(mediaType string, data []byte, v interface{}) error {
+	if mediaType == MediaTypeCBOR {
+		return cbor.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("trailing data")
+	}
+	return nil
+}
+
+func marshal(mediaType string, v interface{}) ([]byte, error) {
+	if mediaType == MediaTypeCBOR {
+		return cbor.Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
+// negotiate returns the supported media type preferred by the Accept header
+// accept, or "" if none is acceptable. Ties, wildcards and a missing header
+// select fallback, the media type of the request.
+func negotiate(accept, fallback string) string {
+	if strings.TrimSpace(accept) == "" {
+		return fallback
+	}
+
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var candidates []candidate
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(item)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		switch mediaType {
+		case MediaTypeJSON, MediaTypeCBOR:
+		case "*/*", "application/*":
+			mediaType = fallback
+		default:
+			continue
+		}
+		candidates = append(candidates, candidate{mediaType, q})
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].q != candidates[j].q {
+			return candidates[i].q > candidates[j].q
+		}
+		return candidates[i].mediaType == fallback && candidates[j].mediaType != fallback
+	})
+	return candidates[0].mediaType
+}
@@ -0,0 +1,221 @@
+package issuer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+type testIssuer struct {
+	h      *Handler
+	scheme blinding.BlindableScheme
+	blind  []byte
+	pkR    []byte
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	scheme := blinding.Ristretto255
+	_, sk, err := scheme.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	h, err := NewHandler(rand.Reader, scheme, sk)
+	if err != nil {
+		t.Fatalf("NewHandler error: %s", err)
+	}
+	blind, err := scheme.GenerateBlind(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateBlind error: %s", err)
+	}
+	pkR, err := scheme.BlindPublicKey(h.PublicKey(), blind, []byte("context"))
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	return &testIssuer{h: h, scheme: scheme, blind: blind, pkR: pkR}
+}
+
+func (ti *testIssuer) newRequest(t *testing.T) *Request {
+	nonce := make([]byte, MinNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatalf("rand.Read error: %s", err)
+	}
+	return &Request{
+		Message:    []byte("test message"),
+		Blind:      ti.blind,
+		Context:    []byte("context"),
+		BlindedKey: ti.pkR,
+		Nonce:      nonce,
+		Timestamp:  time.Now().Unix(),
+	}
+}
+
+func (ti *testIssuer) do(t *testing.T, contentType, accept string, req *Request) *httptest.ResponseRecorder {
+	body, err := marshal(contentType, req)
+	if err != nil {
+		t.Fatalf("marshal error: %s", err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	ti.h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIssue(t *testing.T) {
+	ti := newTestIssuer(t)
+	for _, tc := range []struct {
+		contentType, accept, want string
+	}{
+		{MediaTypeJSON, "", MediaTypeJSON},
+		{MediaTypeCBOR, "", MediaTypeCBOR},
+		{MediaTypeJSON, MediaTypeCBOR, MediaTypeCBOR},
+		{MediaTypeCBOR, "application/json, application/cbor;q=0.5", MediaTypeJSON},
+		{MediaTypeCBOR, "*/*", MediaTypeCBOR},
+	} {
+		req := ti.newRequest(t)
+		w := ti.do(t, tc.contentType, tc.accept, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s accepting %q: status %d: %s", tc.contentType, tc.accept, w.Code, w.Body)
+		}
+		if got := w.Header().Get("Content-Type"); got != tc.want {
+			t.Errorf("%s accepting %q: response type %s, want %s", tc.contentType, tc.accept, got, tc.want)
+		}
+		var resp Response
+		if err := unmarshal(tc.want, w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal error: %s", err)
+		}
+		if resp.Scheme != ti.scheme.Name() {
+			t.Errorf("scheme %q, want %q", resp.Scheme, ti.scheme.Name())
+		}
+		if !ti.scheme.Verify(ti.pkR, req.Message, resp.Signature) {
+			t.Errorf("signature rejected")
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	ti := newTestIssuer(t)
+	req := ti.newRequest(t)
+	if w := ti.do(t, MediaTypeJSON, "", req); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if w := ti.do(t, MediaTypeCBOR, "", req); w.Code != http.StatusConflict {
+		t.Errorf("replayed request: status %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	req = ti.newRequest(t)
+	req.Timestamp = time.Now().Add(-time.Hour).Unix()
+	if w := ti.do(t, MediaTypeJSON, "", req); w.Code != http.StatusBadRequest {
+		t.Errorf("stale request: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestReplayCacheExpiry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := newReplayCache(func() time.Time { return now })
+	window := time.Minute
+	nonce := []byte("nonce")
+
+	if err := c.check(nonce, now, window); err != nil {
+		t.Fatalf("check error: %s", err)
+	}
+	if err := c.check(nonce, now, window); err != errReplayedRequest {
+		t.Errorf("replayed nonce: got %v, want %v", err, errReplayedRequest)
+	}
+	now = now.Add(2 * window)
+	if err := c.check(nonce, now, window); err != nil {
+		t.Errorf("nonce of an expired request: %s", err)
+	}
+
+	for i := 0; i < maxReplayEntries; i++ {
+		c.check([]byte{byte(i), byte(i >> 8)}, now, window)
+	}
+	now = now.Add(2 * window)
+	c.check([]byte("new"), now, window)
+	if len(c.seen) != 1 {
+		t.Errorf("%d nonces remembered after pruning, want 1", len(c.seen))
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	ti := newTestIssuer(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	ti.h.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("{}")))
+	r.Header.Set("Content-Type", "text/plain")
+	w = httptest.NewRecorder()
+	ti.h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain: status %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+
+	if w := ti.do(t, MediaTypeJSON, "text/html", ti.newRequest(t)); w.Code != http.StatusNotAcceptable {
+		t.Errorf("accepting text/html: status %d, want %d", w.Code, http.StatusNotAcceptable)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("{")))
+	r.Header.Set("Content-Type", MediaTypeJSON)
+	w = httptest.NewRecorder()
+	ti.h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("malformed JSON: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	req := ti.newRequest(t)
+	req.Nonce = req.Nonce[:MinNonceSize-1]
+	if w := ti.do(t, MediaTypeJSON, "", req); w.Code != http.StatusBadRequest {
+		t.Errorf("short nonce: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	req = ti.newRequest(t)
+	req.Context = []byte("other context")
+	if w := ti.do(t, MediaTypeJSON, "", req); w.Code != http.StatusBadRequest {
+		t.Errorf("mismatched blinded key: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	ti.h.MaxRequestSize = 16
+	if w := ti.do(t, MediaTypeJSON, "", ti.newRequest(t)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large request: status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestCancelledRequest(t *testing.T) {
+	ti := newTestIssuer(t)
+	req := ti.newRequest(t)
+	body, _ := marshal(MediaTypeJSON, req)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)).WithContext(ctx)
+	r.Header.Set("Content-Type", MediaTypeJSON)
+	w := httptest.NewRecorder()
+	ti.h.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("cancelled request: status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	// The nonce of the cancelled request was not burned.
+	if w := ti.do(t, MediaTypeJSON, "", req); w.Code != http.StatusOK {
+		t.Errorf("retried request: status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	ti.h.Timeout = time.Nanosecond
+	if w := ti.do(t, MediaTypeJSON, "", ti.newRequest(t)); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("timed out request: status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
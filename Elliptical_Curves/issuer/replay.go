@@ -0,0 +1,53 @@
+package issuer
+
+import (
+	"sync"
+	"time"
+)
+
+// maxReplayEntries is the number of remembered nonces above which expired
+// ones are dropped.
+const maxReplayEntries = 4096
+
+// replayCache remembers the nonces of recent requests.
+type replayCache struct {
+	now func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newReplayCache(now func() time.Time) *replayCache {
+	return &replayCache{now: now, seen: make(map[string]time.Time)}
+}
+
+// check records nonce, and returns an error if the request time t is more
+// than window away from the current time or if nonce was already recorded.
+func (c *replayCache) check(nonce []byte, t time.Time, window time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+
+	if t.Before(now.Add(-window)) || t.After(now.Add(window)) {
+		return errStaleRequest
+	}
+	if expiry, ok := c.seen[string(nonce)]; ok && now.Before(expiry) {
+		return errReplayedRequest
+	}
+	if len(c.seen) >= maxReplayEntries {
+		c.pruneLocked(now)
+	}
+	// Past t + window, the request is refused as stale, so the nonce no
+	// longer needs to be remembered.
+	c.seen[string(nonce)] = t.Add(window)
+	return nil
+}
+
+// pruneLocked drops the nonces of requests that are now stale.
+func (c *replayCache) pruneLocked(now time.Time) {
+	for nonce, expiry := range c.seen {
+		if !now.Before(expiry) {
+			delete(c.seen, nonce)
+		}
+	}
+}
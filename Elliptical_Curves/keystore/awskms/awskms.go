@@ -0,0 +1,246 @@
+// Package awskms implements keystore.SignerStore with AWS Key Management
+// Service, for asymmetric signing keys of the ECC_NIST_P256, ECC_NIST_P384
+// and ECC_NIST_P521 key specs.
+//
+// Requests are sent to the JSON API of KMS, signed with Signature Version 4,
+// so that the package doesn't depend on the AWS SDK. Private keys stay in
+// KMS, which signs digests with them. Blinded signatures are computed by
+// wrapping a Signer with ecdsa.NewRemoteBlindSigner, which only asks KMS for
+// signatures under the base key.
+package awskms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+// ErrUnsupportedKey is returned when a KMS key is not an ECDSA signing key.
+var ErrUnsupportedKey = errors.New("awskms: unsupported key")
+
+const (
+	service      = "kms"
+	targetPrefix = "TrentService."
+)
+
+// keySpecs maps the ECC key specs of KMS to their curve, and the signing
+// algorithm and hash function they are used with.
+var keySpecs = map[string]struct {
+	curve     elliptic.Curve
+	algorithm string
+	hash      crypto.Hash
+}{
+	"ECC_NIST_P256": {elliptic.P256(), "ECDSA_SHA_256", crypto.SHA256},
+	"ECC_NIST_P384": {elliptic.P384(), "ECDSA_SHA_384", crypto.SHA384},
+	"ECC_NIST_P521": {elliptic.P521(), "ECDSA_SHA_512", crypto.SHA512},
+}
+
+// Client is a client of KMS in a region. It implements keystore.SignerStore:
+// key IDs are the key IDs, ARNs or aliases accepted by KMS.
+type Client struct {
+	// Region is the AWS region, such as "us-east-1".
+	Region string
+	// Credentials sign the requests.
+	Credentials Credentials
+	// Endpoint is the URL of the KMS endpoint. It defaults to
+	// "https://kms.<region>.amazonaws.com/".
+	Endpoint string
+	// HTTPClient is the HTTP client used for requests. It defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+var _ keystore.SignerStore = (*Client)(nil)
+
+// apiError is the body of an error response of KMS.
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// do calls the KMS operation op with the request in, and decodes its
+// response into out.
+func (c *Client) do(ctx context.Context, op string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = "https://kms." + c.Region + ".amazonaws.com/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", targetPrefix+op)
+	signV4(req, body, c.Credentials, c.Region, service, time.Now())
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e apiError
+		json.Unmarshal(data, &e)
+		if e.Type == "NotFoundException" {
+			return fmt.Errorf("%w: %s", keystore.ErrNotFound, e.Message)
+		}
+		return fmt.Errorf("awskms: %s: %s: %s %s", op, resp.Status, e.Type, e.Message)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("awskms: %s: malformed response: %v", op, err)
+	}
+	return nil
+}
+
+type keyMetadata struct {
+	KeyID        string  `json:"KeyId"`
+	CreationDate float64 `json:"CreationDate"`
+	KeySpec      string  `json:"KeySpec"`
+	KeyUsage     string  `json:"KeyUsage"`
+	KeyState     string  `json:"KeyState"`
+}
+
+// Signer returns a signer for the KMS key id.
+func (c *Client) Signer(ctx context.Context, id string) (crypto.Signer, error) {
+	s, _, err := c.signer(ctx, id)
+	return s, err
+}
+
+func (c *Client) signer(ctx context.Context, id string) (*Signer, *keyMetadata, error) {
+	var desc struct {
+		KeyMetadata keyMetadata `json:"KeyMetadata"`
+	}
+	if err := c.do(ctx, "DescribeKey", map[string]string{"KeyId": id}, &desc); err != nil {
+		return nil, nil, err
+	}
+	m := &desc.KeyMetadata
+	spec, ok := keySpecs[m.KeySpec]
+	if !ok || m.KeyUsage != "SIGN_VERIFY" {
+		return nil, nil, fmt.Errorf("%w: %s is a %s key for %s", ErrUnsupportedKey, id, m.KeySpec, m.KeyUsage)
+	}
+	var pk struct {
+		PublicKey []byte `json:"PublicKey"`
+	}
+	if err := c.do(ctx, "GetPublicKey", map[string]string{"KeyId": id}, &pk); err != nil {
+		return nil, nil, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(pk.PublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("awskms: public key of %s: %v", id, err)
+	}
+	ecPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok || ecPub.Curve != spec.curve {
+		return nil, nil, fmt.Errorf("%w: public key of %s is not on %s", ErrUnsupportedKey, id, spec.curve.Params().Name)
+	}
+	return &Signer{client: c, id: id, pub: ecPub, algorithm: spec.algorithm, hash: spec.hash}, m, nil
+}
+
+// List returns the metadata of the enabled ECDSA signing keys of the
+// account in the region, sorted by key ID.
+func (c *Client) List(ctx context.Context) ([]keystore.Metadata, error) {
+	var list []keystore.Metadata
+	marker := ""
+	for {
+		req := map[string]interface{}{"Limit": 1000}
+		if marker != "" {
+			req["Marker"] = marker
+		}
+		var page struct {
+			Keys []struct {
+				KeyID string `json:"KeyId"`
+			} `json:"Keys"`
+			NextMarker string `json:"NextMarker"`
+			Truncated  bool   `json:"Truncated"`
+		}
+		if err := c.do(ctx, "ListKeys", req, &page); err != nil {
+			return nil, err
+		}
+		for _, k := range page.Keys {
+			s, m, err := c.signer(ctx, k.KeyID)
+			if errors.Is(err, ErrUnsupportedKey) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if m.KeyState != "Enabled" {
+				continue
+			}
+			sec, frac := math.Modf(m.CreationDate)
+			created := time.Unix(int64(sec), int64(frac*1e9)).UTC()
+			list = append(list, keystore.ECDSAMetadata(k.KeyID, s.pub, s.hash, created))
+		}
+		if !page.Truncated {
+			break
+		}
+		marker = page.NextMarker
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Signer signs digests with a KMS key. It implements crypto.Signer.
+type Signer struct {
+	client    *Client
+	id        string
+	pub       *ecdsa.PublicKey
+	algorithm string
+	hash      crypto.Hash
+}
+
+// Public returns the *crypto/ecdsa.PublicKey of the key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign signs digest, which must be a digest of the hash function of the key
+// spec: SHA-256, SHA-384 or SHA-512 for P-256, P-384 and P-521. It returns
+// the ASN.1 encoded signature.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext is Sign with a context for the request to KMS.
+func (s *Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != s.hash || len(digest) != s.hash.Size() {
+		return nil, fmt.Errorf("awskms: key %s signs %v digests", s.id, s.hash)
+	}
+	req := map[string]interface{}{
+		"KeyId":            s.id,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": s.algorithm,
+	}
+	var resp struct {
+		Signature []byte `json:"Signature"`
+	}
+	if err := s.client.do(ctx, "Sign", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
@@ -0,0 +1,145 @@
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pecdsa "github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+// TestSignV4 checks signV4 against the get-vanilla case of the AWS Signature
+// Version 4 test suite.
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
+
+var testCredentials = Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}
+
+// fakeKMS serves the KMS API for the ECC_NIST_P256 signing key "issuer" and
+// the symmetric key "aes".
+func fakeKMS(t *testing.T, key *ecdsa.PrivateKey) *httptest.Server {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(auth, "/kms/aws4_request") || r.Header.Get("X-Amz-Security-Token") != "token" {
+			t.Errorf("request not signed: %q", auth)
+		}
+		var req map[string]interface{}
+		json.Unmarshal(body, &req)
+		fail := func(typ string) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(apiError{Type: typ, Message: "fake"})
+		}
+		var resp interface{}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.ListKeys":
+			resp = map[string]interface{}{"Keys": []map[string]string{{"KeyId": "issuer"}, {"KeyId": "aes"}}}
+		case "TrentService.DescribeKey":
+			switch req["KeyId"] {
+			case "issuer":
+				resp = map[string]interface{}{"KeyMetadata": keyMetadata{
+					KeyID: "issuer", CreationDate: 1767323045.5, KeySpec: "ECC_NIST_P256", KeyUsage: "SIGN_VERIFY", KeyState: "Enabled",
+				}}
+			case "aes":
+				resp = map[string]interface{}{"KeyMetadata": keyMetadata{
+					KeyID: "aes", KeySpec: "SYMMETRIC_DEFAULT", KeyUsage: "ENCRYPT_DECRYPT", KeyState: "Enabled",
+				}}
+			default:
+				fail("NotFoundException")
+				return
+			}
+		case "TrentService.GetPublicKey":
+			resp = map[string]interface{}{"KeyId": "issuer", "PublicKey": der}
+		case "TrentService.Sign":
+			var sign struct {
+				Message          []byte
+				MessageType      string
+				SigningAlgorithm string
+			}
+			json.Unmarshal(body, &sign)
+			if sign.MessageType != "DIGEST" || sign.SigningAlgorithm != "ECDSA_SHA_256" || len(sign.Message) != 32 {
+				fail("ValidationException")
+				return
+			}
+			sig, _ := ecdsa.SignASN1(rand.Reader, key, sign.Message)
+			resp = map[string]interface{}{"Signature": sig}
+		default:
+			fail("UnknownOperationException")
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestClient(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := fakeKMS(t, key)
+	defer srv.Close()
+	c := &Client{Region: "us-east-1", Credentials: testCredentials, Endpoint: srv.URL}
+	ctx := context.Background()
+
+	list, err := c.List(ctx)
+	if err != nil {
+		t.Fatalf("List error: %s", err)
+	}
+	if len(list) != 1 || list[0].ID != "issuer" || list[0].Scheme != "ECDSA-P-256-SHA-256" ||
+		!list[0].Created.Equal(time.Date(2026, 1, 2, 3, 4, 5, 5e8, time.UTC)) {
+		t.Errorf("List = %+v, want the issuer key", list)
+	}
+
+	signer, err := c.Signer(ctx, "issuer")
+	if err != nil {
+		t.Fatalf("Signer error: %s", err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Errorf("signature does not verify")
+	}
+
+	skB, _ := pecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rs, err := pecdsa.NewRemoteBlindSigner(signer, skB, []byte("kms"))
+	if err != nil {
+		t.Fatalf("NewRemoteBlindSigner error: %s", err)
+	}
+	sig, err = rs.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("blinded Sign error: %s", err)
+	}
+	if !pecdsa.VerifyASN1(rs.BlindedKey(), digest[:], sig) {
+		t.Errorf("blinded signature does not verify")
+	}
+
+	if _, err := c.Signer(ctx, "missing"); !errors.Is(err, keystore.ErrNotFound) {
+		t.Errorf("Signer of a missing key: got %v, want ErrNotFound", err)
+	}
+	if _, err := c.Signer(ctx, "aes"); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Signer of a symmetric key: got %v, want ErrUnsupportedKey", err)
+	}
+}
@@ -0,0 +1,156 @@
+package keystore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const keyFileSuffix = ".key"
+
+// Dir is a Keystore that holds each key in a file of a directory, named
+// after its ID with the suffix ".key". A file holds the key and its metadata
+// encoded in JSON, encrypted with AES-256-GCM; it starts with the random
+// nonce, and is only readable by its owner. Files are created atomically, so
+// that a crash never leaves a partial key.
+type Dir struct {
+	path   string
+	sealer *sealer
+}
+
+// NewDir returns a keystore in the directory at path, which is created if it
+// doesn't exist, that encrypts keys with key, which must be KeySize bytes
+// long.
+func NewDir(path string, key []byte) (*Dir, error) {
+	s, err := newSealer(rand.Reader, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	return &Dir{path: path, sealer: s}, nil
+}
+
+func (d *Dir) file(id string) string {
+	return filepath.Join(d.path, id+keyFileSuffix)
+}
+
+// Get implements Keystore.
+func (d *Dir) Get(ctx context.Context, id string) (*Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := CheckID(id); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(d.file(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := d.sealer.open(id, data)
+	if err != nil {
+		return nil, err
+	}
+	var key Key
+	if err := json.Unmarshal(plaintext, &key); err != nil || key.ID != id {
+		return nil, fmt.Errorf("%w: key %q is malformed", ErrInvalidKey, id)
+	}
+	return &key, nil
+}
+
+// Put implements Keystore.
+func (d *Dir) Put(ctx context.Context, key *Key) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	data, err := d.sealer.seal(key.ID, plaintext)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(d.path, ".tmp-"+key.ID)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// Unlike a rename, a link fails if the key file exists, so that
+	// concurrent writers can't overwrite each other's keys.
+	if err := os.Link(f.Name(), d.file(key.ID)); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%w: %q", ErrExists, key.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// List implements Keystore. It returns an error wrapping ErrInvalidKey if a
+// key file of the directory can't be decrypted.
+func (d *Dir) List(ctx context.Context) ([]Metadata, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	var list []Metadata
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasSuffix(name, keyFileSuffix) || strings.HasPrefix(name, ".") {
+			continue
+		}
+		key, err := d.Get(ctx, strings.TrimSuffix(name, keyFileSuffix))
+		if errors.Is(err, ErrNotFound) {
+			// Deleted since the directory was read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, key.Metadata)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Delete implements Keystore.
+func (d *Dir) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := CheckID(id); err != nil {
+		return err
+	}
+	err := os.Remove(d.file(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	return err
+}
@@ -0,0 +1,305 @@
+// Package gcpkms implements keystore.SignerStore with Google Cloud Key
+// Management Service, for the crypto key versions of a key ring with the
+// EC_SIGN_P256_SHA256 and EC_SIGN_P384_SHA384 algorithms.
+//
+// Requests are sent to the REST API of Cloud KMS, authenticated with OAuth 2
+// access tokens from a TokenSource, so that the package doesn't depend on the
+// Google Cloud client libraries. Private keys stay in Cloud KMS, which signs
+// digests with them. Blinded signatures are computed by wrapping a Signer
+// with ecdsa.NewRemoteBlindSigner, which only asks Cloud KMS for signatures
+// under the base key.
+package gcpkms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+// ErrUnsupportedKey is returned when a crypto key version is not an ECDSA
+// signing key.
+var ErrUnsupportedKey = errors.New("gcpkms: unsupported key")
+
+const defaultEndpoint = "https://cloudkms.googleapis.com/"
+
+// algorithms maps the ECDSA algorithms of Cloud KMS to their curve, the hash
+// function they sign digests of, and the name of the digest in requests.
+var algorithms = map[string]struct {
+	curve  elliptic.Curve
+	hash   crypto.Hash
+	digest string
+}{
+	"EC_SIGN_P256_SHA256": {elliptic.P256(), crypto.SHA256, "sha256"},
+	"EC_SIGN_P384_SHA384": {elliptic.P384(), crypto.SHA384, "sha384"},
+}
+
+// TokenSource returns an OAuth 2 access token with the
+// https://www.googleapis.com/auth/cloudkms scope, such as the Token of an
+// oauth2.TokenSource.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Client is a client of Cloud KMS. It implements keystore.SignerStore: key
+// IDs are the resource names of crypto key versions, such as
+// "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1".
+type Client struct {
+	// KeyRing is the resource name of the key ring whose keys List
+	// returns, such as "projects/p/locations/global/keyRings/r".
+	KeyRing string
+	// Token authenticates the requests.
+	Token TokenSource
+	// Endpoint is the URL of the Cloud KMS API. It defaults to
+	// "https://cloudkms.googleapis.com/".
+	Endpoint string
+	// HTTPClient is the HTTP client used for requests. It defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+var _ keystore.SignerStore = (*Client)(nil)
+
+// resourceURL returns the URL of the resource name, followed by suffix.
+func (c *Client) resourceURL(name, suffix string) string {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/v1/" + strings.Join(segments, "/") + suffix
+}
+
+// do sends a request to Cloud KMS, and decodes its response into out.
+func (c *Client) do(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	token, err := c.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("gcpkms: access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &e)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", keystore.ErrNotFound, e.Error.Message)
+		}
+		return fmt.Errorf("gcpkms: %s %s: %s: %s %s", method, url, resp.Status, e.Error.Status, e.Error.Message)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("gcpkms: malformed response: %v", err)
+	}
+	return nil
+}
+
+type cryptoKeyVersion struct {
+	Name       string    `json:"name"`
+	State      string    `json:"state"`
+	Algorithm  string    `json:"algorithm"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+// Signer returns a signer for the crypto key version name.
+func (c *Client) Signer(ctx context.Context, name string) (crypto.Signer, error) {
+	var v cryptoKeyVersion
+	if err := c.do(ctx, http.MethodGet, c.resourceURL(name, ""), nil, &v); err != nil {
+		return nil, err
+	}
+	return c.signer(ctx, &v)
+}
+
+func (c *Client) signer(ctx context.Context, v *cryptoKeyVersion) (*Signer, error) {
+	alg, ok := algorithms[v.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is a %s key", ErrUnsupportedKey, v.Name, v.Algorithm)
+	}
+	var pk struct {
+		PEM string `json:"pem"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.resourceURL(v.Name, "/publicKey"), nil, &pk); err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(pk.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("gcpkms: %s has no public key", v.Name)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: public key of %s: %v", v.Name, err)
+	}
+	ecPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok || ecPub.Curve != alg.curve {
+		return nil, fmt.Errorf("%w: public key of %s is not on %s", ErrUnsupportedKey, v.Name, alg.curve.Params().Name)
+	}
+	return &Signer{client: c, name: v.Name, pub: ecPub, hash: alg.hash, digest: alg.digest}, nil
+}
+
+// list calls the List method at url of a collection, and passes each page
+// of its response to add.
+func (c *Client) list(ctx context.Context, collection string, add func(page []byte) error) error {
+	token := ""
+	for {
+		u := collection
+		if token != "" {
+			u += "?pageToken=" + url.QueryEscape(token)
+		}
+		var page json.RawMessage
+		if err := c.do(ctx, http.MethodGet, u, nil, &page); err != nil {
+			return err
+		}
+		if err := add(page); err != nil {
+			return fmt.Errorf("gcpkms: malformed response: %v", err)
+		}
+		var next struct {
+			NextPageToken string `json:"nextPageToken"`
+		}
+		json.Unmarshal(page, &next)
+		if next.NextPageToken == "" {
+			return nil
+		}
+		token = next.NextPageToken
+	}
+}
+
+type cryptoKey struct {
+	Name    string `json:"name"`
+	Purpose string `json:"purpose"`
+}
+
+// List returns the metadata of the enabled ECDSA crypto key versions of the
+// asymmetric signing keys of the key ring, sorted by resource name.
+func (c *Client) List(ctx context.Context) ([]keystore.Metadata, error) {
+	var keys []cryptoKey
+	err := c.list(ctx, c.resourceURL(c.KeyRing, "/cryptoKeys"), func(page []byte) error {
+		var p struct {
+			CryptoKeys []cryptoKey `json:"cryptoKeys"`
+		}
+		err := json.Unmarshal(page, &p)
+		keys = append(keys, p.CryptoKeys...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata []keystore.Metadata
+	for _, k := range keys {
+		if k.Purpose != "ASYMMETRIC_SIGN" {
+			continue
+		}
+		var versions []cryptoKeyVersion
+		err := c.list(ctx, c.resourceURL(k.Name, "/cryptoKeyVersions"), func(page []byte) error {
+			var p struct {
+				CryptoKeyVersions []cryptoKeyVersion `json:"cryptoKeyVersions"`
+			}
+			err := json.Unmarshal(page, &p)
+			versions = append(versions, p.CryptoKeyVersions...)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range versions {
+			v := &versions[i]
+			if v.State != "ENABLED" {
+				continue
+			}
+			s, err := c.signer(ctx, v)
+			if errors.Is(err, ErrUnsupportedKey) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			metadata = append(metadata, keystore.ECDSAMetadata(v.Name, s.pub, s.hash, v.CreateTime))
+		}
+	}
+	sort.Slice(metadata, func(i, j int) bool { return metadata[i].ID < metadata[j].ID })
+	return metadata, nil
+}
+
+// Signer signs digests with a crypto key version. It implements
+// crypto.Signer.
+type Signer struct {
+	client *Client
+	name   string
+	pub    *ecdsa.PublicKey
+	hash   crypto.Hash
+	digest string
+}
+
+// Public returns the *crypto/ecdsa.PublicKey of the key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign signs digest, which must be a digest of the hash function of the
+// algorithm of the key: SHA-256 for P-256 and SHA-384 for P-384. It returns
+// the ASN.1 encoded signature.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext is Sign with a context for the request to Cloud KMS.
+func (s *Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != s.hash || len(digest) != s.hash.Size() {
+		return nil, fmt.Errorf("gcpkms: %s signs %v digests", s.name, s.hash)
+	}
+	req := map[string]interface{}{
+		"digest": map[string][]byte{s.digest: digest},
+	}
+	var resp struct {
+		Signature []byte `json:"signature"`
+	}
+	if err := s.client.do(ctx, http.MethodPost, s.client.resourceURL(s.name, ":asymmetricSign"), req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
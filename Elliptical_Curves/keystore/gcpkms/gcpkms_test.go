@@ -0,0 +1,144 @@
+package gcpkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pecdsa "github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+const (
+	testToken   = "ya29.test"
+	testKeyRing = "projects/p/locations/global/keyRings/r"
+	testVersion = testKeyRing + "/cryptoKeys/issuer/cryptoKeyVersions/1"
+)
+
+// fakeKMS serves the Cloud KMS API for a key ring with the asymmetric
+// signing key "issuer", whose version 1 is an EC_SIGN_P256_SHA256 key and
+// version 2 is disabled, and the symmetric key "aes". The crypto keys are
+// listed one per page.
+func fakeKMS(t *testing.T, key *ecdsa.PrivateKey) *httptest.Server {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	pubPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	version := map[string]interface{}{
+		"name": testVersion, "state": "ENABLED", "algorithm": "EC_SIGN_P256_SHA256", "createTime": "2026-01-02T03:04:05Z",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			t.Errorf("request not authenticated: %q", r.Header.Get("Authorization"))
+		}
+		var resp interface{}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/" + testKeyRing + "/cryptoKeys":
+			if r.URL.Query().Get("pageToken") == "" {
+				resp = map[string]interface{}{
+					"cryptoKeys":    []map[string]string{{"name": testKeyRing + "/cryptoKeys/issuer", "purpose": "ASYMMETRIC_SIGN"}},
+					"nextPageToken": "page 2",
+				}
+			} else {
+				resp = map[string]interface{}{
+					"cryptoKeys": []map[string]string{{"name": testKeyRing + "/cryptoKeys/aes", "purpose": "ENCRYPT_DECRYPT"}},
+				}
+			}
+		case "GET /v1/" + testKeyRing + "/cryptoKeys/issuer/cryptoKeyVersions":
+			resp = map[string]interface{}{"cryptoKeyVersions": []interface{}{
+				version,
+				map[string]string{"name": testKeyRing + "/cryptoKeys/issuer/cryptoKeyVersions/2", "state": "DISABLED"},
+			}}
+		case "GET /v1/" + testVersion:
+			resp = version
+		case "GET /v1/" + testKeyRing + "/cryptoKeys/aes/cryptoKeyVersions/1":
+			resp = map[string]string{"name": testKeyRing + "/cryptoKeys/aes/cryptoKeyVersions/1", "algorithm": "GOOGLE_SYMMETRIC_ENCRYPTION"}
+		case "GET /v1/" + testVersion + "/publicKey":
+			resp = map[string]string{"pem": pubPEM, "algorithm": "EC_SIGN_P256_SHA256"}
+		case "POST /v1/" + testVersion + ":asymmetricSign":
+			var req struct {
+				Digest map[string][]byte `json:"digest"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if len(req.Digest) != 1 || len(req.Digest["sha256"]) != 32 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			sig, _ := ecdsa.SignASN1(rand.Reader, key, req.Digest["sha256"])
+			resp = map[string][]byte{"signature": sig}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			resp = map[string]interface{}{"error": map[string]string{"status": "NOT_FOUND", "message": r.URL.Path}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestClient(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := fakeKMS(t, key)
+	defer srv.Close()
+	c := &Client{
+		KeyRing:  testKeyRing,
+		Token:    func(context.Context) (string, error) { return testToken, nil },
+		Endpoint: srv.URL,
+	}
+	ctx := context.Background()
+
+	list, err := c.List(ctx)
+	if err != nil {
+		t.Fatalf("List error: %s", err)
+	}
+	if len(list) != 1 || list[0].ID != testVersion || list[0].Scheme != "ECDSA-P-256-SHA-256" ||
+		!list[0].Created.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("List = %+v, want version 1 of the issuer key", list)
+	}
+
+	signer, err := c.Signer(ctx, testVersion)
+	if err != nil {
+		t.Fatalf("Signer error: %s", err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Errorf("signature does not verify")
+	}
+
+	skB, _ := pecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rs, err := pecdsa.NewRemoteBlindSigner(signer, skB, []byte("cloudkms"))
+	if err != nil {
+		t.Fatalf("NewRemoteBlindSigner error: %s", err)
+	}
+	sig, err = rs.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("blinded Sign error: %s", err)
+	}
+	if !pecdsa.VerifyASN1(rs.BlindedKey(), digest[:], sig) {
+		t.Errorf("blinded signature does not verify")
+	}
+
+	if _, err := c.Signer(ctx, testKeyRing+"/cryptoKeys/missing/cryptoKeyVersions/1"); !errors.Is(err, keystore.ErrNotFound) {
+		t.Errorf("Signer of a missing key: got %v, want ErrNotFound", err)
+	}
+	if _, err := c.Signer(ctx, testKeyRing+"/cryptoKeys/aes/cryptoKeyVersions/1"); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Signer of a symmetric key: got %v, want ErrUnsupportedKey", err)
+	}
+	failing := &Client{KeyRing: testKeyRing, Endpoint: srv.URL, Token: func(context.Context) (string, error) {
+		return "", errors.New("no credentials")
+	}}
+	if _, err := failing.List(ctx); err == nil {
+		t.Errorf("List without a token succeeded")
+	}
+}
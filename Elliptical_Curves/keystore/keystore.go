@@ -0,0 +1,201 @@
+// Package keystore stores private keys of the schemes of the blinding package
+// with their metadata, so that applications and services don't serialize
+// private keys into ad-hoc files.
+//
+// A Keystore is implemented by Memory, which holds keys in memory, and by
+// Dir, which holds one file per key in a directory. Both encrypt private keys
+// at rest with AES-256-GCM under a key of KeySize bytes, and bind each
+// encrypted key to its ID, so that a key can't be substituted for another by
+// swapping ciphertexts.
+//
+// Keys that can't be exported, such as those of a cloud key management
+// service, are held by a SignerStore instead, which signs with them. The
+// vault, awskms and gcpkms packages implement it for HashiCorp Vault Transit,
+// AWS KMS and Google Cloud KMS.
+package keystore
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+const (
+	keystoreDST = "pat-go Keystore v1"
+
+	// KeySize is the size, in bytes, of the keys that encrypt private keys
+	// at rest.
+	KeySize = 32
+
+	maxIDLength = 128
+)
+
+var (
+	// ErrNotFound is returned when no key has the requested ID.
+	ErrNotFound = errors.New("keystore: key not found")
+
+	// ErrExists is returned by Put when a key with the same ID is already
+	// stored. Keys are never overwritten; delete a key to replace it.
+	ErrExists = errors.New("keystore: key exists")
+
+	// ErrInvalidKey is returned when a key is malformed or has an invalid
+	// ID, or when a stored key can't be decrypted.
+	ErrInvalidKey = errors.New("keystore: invalid key")
+)
+
+// Metadata describes a stored key.
+type Metadata struct {
+	// ID identifies the key in its keystore. In a Keystore, it is 1 to 128
+	// ASCII letters, digits, '-', '_' and '.', and does not start with '.'.
+	// In a SignerStore, it is the name of the key in the service.
+	ID string `json:"id"`
+	// Scheme is the name of the blinding scheme of the key.
+	Scheme string `json:"scheme"`
+	// PublicKey is the encoded public key.
+	PublicKey []byte `json:"public_key"`
+	// Created is the time the key was created.
+	Created time.Time `json:"created"`
+	// Labels are application-defined attributes of the key.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Key is a private key with its metadata.
+type Key struct {
+	Metadata
+	// PrivateKey is the encoded private key.
+	PrivateKey []byte `json:"private_key"`
+}
+
+// Keystore stores private keys by ID. Implementations are safe for
+// concurrent use, and return errors wrapping ErrNotFound, ErrExists and
+// ErrInvalidKey.
+type Keystore interface {
+	// Get returns the key with the given ID.
+	Get(ctx context.Context, id string) (*Key, error)
+	// Put stores key under its ID.
+	Put(ctx context.Context, key *Key) error
+	// List returns the metadata of the stored keys, sorted by ID.
+	List(ctx context.Context) ([]Metadata, error)
+	// Delete removes the key with the given ID.
+	Delete(ctx context.Context, id string) error
+}
+
+// SignerStore is a store of keys whose private keys can't be exported, which
+// signs with them instead of returning them. Its signers take digests, which
+// they sign without hashing them again, so that they can be used with
+// ecdsa.NewRemoteBlindSigner to sign with blindings of their keys.
+type SignerStore interface {
+	// Signer returns a signer for the key with the given ID.
+	Signer(ctx context.Context, id string) (crypto.Signer, error)
+	// List returns the metadata of the stored keys, sorted by ID.
+	List(ctx context.Context) ([]Metadata, error)
+}
+
+// ECDSAMetadata returns the metadata of the ECDSA key pub, used with the hash
+// function h, under id. Its scheme is that of blinding.ECDSA, and its public
+// key is compressed.
+func ECDSAMetadata(id string, pub *ecdsa.PublicKey, h crypto.Hash, created time.Time) Metadata {
+	return Metadata{
+		ID:        id,
+		Scheme:    blinding.ECDSA(pub.Curve, h).Name(),
+		PublicKey: elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y),
+		Created:   created,
+	}
+}
+
+// CheckID returns an error wrapping ErrInvalidKey if id is not a valid key ID.
+// IDs are restricted so that they are safe to use as file names.
+func CheckID(id string) error {
+	if len(id) == 0 || len(id) > maxIDLength || id[0] == '.' {
+		return fmt.Errorf("%w: invalid ID %q", ErrInvalidKey, id)
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.':
+		default:
+			return fmt.Errorf("%w: invalid ID %q", ErrInvalidKey, id)
+		}
+	}
+	return nil
+}
+
+func checkKey(key *Key) error {
+	if key == nil {
+		return fmt.Errorf("%w: missing key", ErrInvalidKey)
+	}
+	if err := CheckID(key.ID); err != nil {
+		return err
+	}
+	if key.Scheme == "" || len(key.PrivateKey) == 0 {
+		return fmt.Errorf("%w: key %q has no scheme or private key", ErrInvalidKey, key.ID)
+	}
+	return nil
+}
+
+// sealer encrypts private keys at rest, with the ID of their key as
+// associated data.
+type sealer struct {
+	aead cipher.AEAD
+	rand io.Reader
+}
+
+func newSealer(rand io.Reader, key []byte) (*sealer, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: encryption key of %d bytes, want %d", ErrInvalidKey, len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead, rand: rand}, nil
+}
+
+func (s *sealer) ad(id string) []byte {
+	return append([]byte(keystoreDST+"\x00"), id...)
+}
+
+func (s *sealer) seal(id string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(s.rand, nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, s.ad(id)), nil
+}
+
+func (s *sealer) open(id string, data []byte) ([]byte, error) {
+	if len(data) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: key %q is truncated", ErrInvalidKey, id)
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, s.ad(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: key %q failed to decrypt", ErrInvalidKey, id)
+	}
+	return plaintext, nil
+}
+
+func copyMetadata(m Metadata) Metadata {
+	m.PublicKey = append([]byte(nil), m.PublicKey...)
+	if m.Labels != nil {
+		labels := make(map[string]string, len(m.Labels))
+		for k, v := range m.Labels {
+			labels[k] = v
+		}
+		m.Labels = labels
+	}
+	return m
+}
@@ -0,0 +1,155 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestKey(id string) *Key {
+	return &Key{
+		Metadata: Metadata{
+			ID:        id,
+			Scheme:    "ristretto255",
+			PublicKey: []byte("public " + id),
+			Created:   time.Unix(1700000000, 0).UTC(),
+			Labels:    map[string]string{"use": "issuance"},
+		},
+		PrivateKey: []byte("private " + id),
+	}
+}
+
+func testKeystore(t *testing.T, ks Keystore) {
+	ctx := context.Background()
+	for _, id := range []string{"b", "a", "c.2026-01"} {
+		if err := ks.Put(ctx, newTestKey(id)); err != nil {
+			t.Fatalf("Put(%q) error: %s", id, err)
+		}
+	}
+	if err := ks.Put(ctx, newTestKey("a")); !errors.Is(err, ErrExists) {
+		t.Errorf("Put of an existing key: got %v, want ErrExists", err)
+	}
+	for _, id := range []string{"", ".hidden", "../a", "a/b", string(make([]byte, maxIDLength+1))} {
+		if err := ks.Put(ctx, newTestKey(id)); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): got %v, want ErrInvalidKey", id, err)
+		}
+	}
+	if err := ks.Put(ctx, &Key{Metadata: Metadata{ID: "empty", Scheme: "ristretto255"}}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put without a private key: got %v, want ErrInvalidKey", err)
+	}
+
+	key, err := ks.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	want := newTestKey("a")
+	if !bytes.Equal(key.PrivateKey, want.PrivateKey) || !bytes.Equal(key.PublicKey, want.PublicKey) ||
+		key.Scheme != want.Scheme || !key.Created.Equal(want.Created) || key.Labels["use"] != "issuance" {
+		t.Errorf("Get = %+v, want %+v", key, want)
+	}
+	// Keys are returned by value.
+	key.Labels["use"] = "other"
+	key.PublicKey[0] ^= 1
+	if key, _ := ks.Get(ctx, "a"); key.Labels["use"] != "issuance" || !bytes.Equal(key.PublicKey, want.PublicKey) {
+		t.Errorf("modifying a key returned by Get modified the stored key")
+	}
+
+	list, err := ks.List(ctx)
+	if err != nil {
+		t.Fatalf("List error: %s", err)
+	}
+	if len(list) != 3 || list[0].ID != "a" || list[1].ID != "b" || list[2].ID != "c.2026-01" {
+		t.Errorf("List = %v, want keys a, b and c.2026-01", list)
+	}
+
+	if err := ks.Delete(ctx, "b"); err != nil {
+		t.Fatalf("Delete error: %s", err)
+	}
+	if _, err := ks.Get(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a deleted key: got %v, want ErrNotFound", err)
+	}
+	if err := ks.Delete(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete of a deleted key: got %v, want ErrNotFound", err)
+	}
+	if list, _ := ks.List(ctx); len(list) != 2 {
+		t.Errorf("List after Delete returned %d keys, want 2", len(list))
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := ks.Get(cancelled, "a"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get with a cancelled context: got %v, want context.Canceled", err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ks, err := NewMemory(nil)
+	if err != nil {
+		t.Fatalf("NewMemory error: %s", err)
+	}
+	testKeystore(t, ks)
+
+	if _, err := NewMemory(make([]byte, 16)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("NewMemory with a short key: got %v, want ErrInvalidKey", err)
+	}
+	// Private keys are not held in the clear.
+	ks.Put(context.Background(), newTestKey("sealed"))
+	if bytes.Contains(ks.keys["sealed"].sealed, []byte("private")) {
+		t.Errorf("private key held in the clear")
+	}
+}
+
+func TestDir(t *testing.T) {
+	key := make([]byte, KeySize)
+	rand.Read(key)
+	path := filepath.Join(t.TempDir(), "keys")
+	ks, err := NewDir(path, key)
+	if err != nil {
+		t.Fatalf("NewDir error: %s", err)
+	}
+	testKeystore(t, ks)
+	ctx := context.Background()
+
+	data, err := os.ReadFile(filepath.Join(path, "a.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("private")) || bytes.Contains(data, []byte("issuance")) {
+		t.Errorf("key file holds the key in the clear")
+	}
+	if fi, _ := os.Stat(filepath.Join(path, "a.key")); fi.Mode().Perm()&0077 != 0 {
+		t.Errorf("key file mode %v is readable by others", fi.Mode())
+	}
+
+	// Keys persist across keystores on the same directory.
+	reopened, _ := NewDir(path, key)
+	if k, err := reopened.Get(ctx, "a"); err != nil || !bytes.Equal(k.PrivateKey, []byte("private a")) {
+		t.Errorf("Get from a reopened keystore = %v, %v", k, err)
+	}
+
+	other := make([]byte, KeySize)
+	rand.Read(other)
+	wrong, _ := NewDir(path, other)
+	if _, err := wrong.Get(ctx, "a"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Get with the wrong key: got %v, want ErrInvalidKey", err)
+	}
+	if _, err := wrong.List(ctx); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("List with the wrong key: got %v, want ErrInvalidKey", err)
+	}
+
+	// A key file can't be substituted for another.
+	if err := os.WriteFile(filepath.Join(path, "d.key"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Get(ctx, "d"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Get of a copied key file: got %v, want ErrInvalidKey", err)
+	}
+	if _, err := ks.Get(ctx, "../keys/a"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Get of a path: got %v, want ErrInvalidKey", err)
+	}
+}
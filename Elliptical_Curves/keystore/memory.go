@@ -0,0 +1,109 @@
+package keystore
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+type memoryEntry struct {
+	metadata Metadata
+	sealed   []byte
+}
+
+// Memory is a Keystore that holds keys in memory, with private keys
+// encrypted, so that they don't appear in the clear in memory dumps taken
+// between uses. Keys are lost when the process exits.
+type Memory struct {
+	sealer *sealer
+
+	mu   sync.RWMutex
+	keys map[string]*memoryEntry
+}
+
+// NewMemory returns an empty Memory keystore that encrypts private keys with
+// key, which must be KeySize bytes long. If key is nil, a random key is used.
+func NewMemory(key []byte) (*Memory, error) {
+	if key == nil {
+		key = make([]byte, KeySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+	}
+	s, err := newSealer(rand.Reader, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Memory{sealer: s, keys: make(map[string]*memoryEntry)}, nil
+}
+
+// Get implements Keystore.
+func (m *Memory) Get(ctx context.Context, id string) (*Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	e, ok := m.keys[id]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	sk, err := m.sealer.open(id, e.sealed)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{Metadata: copyMetadata(e.metadata), PrivateKey: sk}, nil
+}
+
+// Put implements Keystore.
+func (m *Memory) Put(ctx context.Context, key *Key) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	sealed, err := m.sealer.seal(key.ID, key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key.ID]; ok {
+		return fmt.Errorf("%w: %q", ErrExists, key.ID)
+	}
+	m.keys[key.ID] = &memoryEntry{metadata: copyMetadata(key.Metadata), sealed: sealed}
+	return nil
+}
+
+// List implements Keystore.
+func (m *Memory) List(ctx context.Context) ([]Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	list := make([]Metadata, 0, len(m.keys))
+	for _, e := range m.keys {
+		list = append(list, copyMetadata(e.metadata))
+	}
+	m.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Delete implements Keystore.
+func (m *Memory) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[id]; !ok {
+		return fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	delete(m.keys, id)
+	return nil
+}
@@ -0,0 +1,255 @@
+// Package vault implements keystore.SignerStore with the Transit secrets
+// engine of HashiCorp Vault, for ECDSA keys of type ecdsa-p256, ecdsa-p384
+// and ecdsa-p521.
+//
+// Private keys stay in Vault, which signs digests with them. Blinded
+// signatures are computed by wrapping a Signer with
+// ecdsa.NewRemoteBlindSigner, which only asks Vault for signatures under the
+// base key.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+// ErrUnsupportedKey is returned when a Transit key is not an ECDSA key.
+var ErrUnsupportedKey = errors.New("vault: unsupported key type")
+
+const defaultMount = "transit"
+
+// Client is a client of a Transit secrets engine. It implements
+// keystore.SignerStore: key IDs are the names of Transit keys, and signers
+// sign with their latest version.
+type Client struct {
+	// Address is the address of the Vault server, such as
+	// "https://vault.example.com:8200".
+	Address string
+	// Token is the Vault token of the client.
+	Token string
+	// Mount is the path the Transit engine is mounted at. It defaults to
+	// "transit".
+	Mount string
+	// HTTPClient is the HTTP client used for requests. It defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+var _ keystore.SignerStore = (*Client)(nil)
+
+func (c *Client) url(parts ...string) string {
+	mount := c.Mount
+	if mount == "" {
+		mount = defaultMount
+	}
+	u := strings.TrimSuffix(c.Address, "/") + "/v1/" + strings.Trim(mount, "/")
+	for _, p := range parts {
+		u += "/" + url.PathEscape(p)
+	}
+	return u
+}
+
+// do sends a request to Vault, and decodes the data field of its response
+// into out.
+func (c *Client) do(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", c.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", keystore.ErrNotFound, url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(data, &e)
+		return fmt.Errorf("vault: %s %s: %s: %s", method, url, resp.Status, strings.Join(e.Errors, "; "))
+	}
+	var r struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("vault: malformed response: %v", err)
+	}
+	return json.Unmarshal(r.Data, out)
+}
+
+type keyVersion struct {
+	PublicKey    string    `json:"public_key"`
+	CreationTime time.Time `json:"creation_time"`
+}
+
+// keyHashes maps the Transit types of ECDSA keys to their curve and the hash
+// function they sign with.
+var keyHashes = map[string]struct {
+	curve elliptic.Curve
+	hash  crypto.Hash
+	name  string
+}{
+	"ecdsa-p256": {elliptic.P256(), crypto.SHA256, "sha2-256"},
+	"ecdsa-p384": {elliptic.P384(), crypto.SHA384, "sha2-384"},
+	"ecdsa-p521": {elliptic.P521(), crypto.SHA512, "sha2-512"},
+}
+
+// Signer returns a signer for the latest version of the Transit key name.
+func (c *Client) Signer(ctx context.Context, name string) (crypto.Signer, error) {
+	s, _, err := c.signer(ctx, name)
+	return s, err
+}
+
+func (c *Client) signer(ctx context.Context, name string) (*Signer, keystore.Metadata, error) {
+	var key struct {
+		Type          string                `json:"type"`
+		LatestVersion int                   `json:"latest_version"`
+		Keys          map[string]keyVersion `json:"keys"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.url("keys", name), nil, &key); err != nil {
+		return nil, keystore.Metadata{}, err
+	}
+	kh, ok := keyHashes[key.Type]
+	if !ok {
+		return nil, keystore.Metadata{}, fmt.Errorf("%w: %q is of type %q", ErrUnsupportedKey, name, key.Type)
+	}
+	version := key.Keys[strconv.Itoa(key.LatestVersion)]
+	block, _ := pem.Decode([]byte(version.PublicKey))
+	if block == nil {
+		return nil, keystore.Metadata{}, fmt.Errorf("vault: key %q has no public key", name)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, keystore.Metadata{}, fmt.Errorf("vault: key %q: %v", name, err)
+	}
+	ecPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok || ecPub.Curve != kh.curve {
+		return nil, keystore.Metadata{}, fmt.Errorf("%w: public key of %q is not on %s", ErrUnsupportedKey, name, kh.curve.Params().Name)
+	}
+	s := &Signer{
+		client:   c,
+		name:     name,
+		version:  key.LatestVersion,
+		pub:      ecPub,
+		hash:     kh.hash,
+		hashName: kh.name,
+	}
+	return s, keystore.ECDSAMetadata(name, ecPub, kh.hash, version.CreationTime), nil
+}
+
+// List returns the metadata of the ECDSA keys of the Transit engine, sorted
+// by name, with the public key of their latest version.
+func (c *Client) List(ctx context.Context) ([]keystore.Metadata, error) {
+	var keys struct {
+		Keys []string `json:"keys"`
+	}
+	if err := c.do(ctx, "LIST", c.url("keys"), nil, &keys); err != nil {
+		if errors.Is(err, keystore.ErrNotFound) {
+			// Vault answers 404 to listing an engine without keys.
+			return nil, nil
+		}
+		return nil, err
+	}
+	sort.Strings(keys.Keys)
+	var list []keystore.Metadata
+	for _, name := range keys.Keys {
+		_, m, err := c.signer(ctx, name)
+		if errors.Is(err, ErrUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// Signer signs digests with a version of a Transit key. It implements
+// crypto.Signer.
+type Signer struct {
+	client   *Client
+	name     string
+	version  int
+	pub      *ecdsa.PublicKey
+	hash     crypto.Hash
+	hashName string
+}
+
+// Public returns the *crypto/ecdsa.PublicKey of the key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign signs digest, which must be a digest of the hash function of the key
+// type: SHA-256, SHA-384 or SHA-512 for P-256, P-384 and P-521. It returns
+// the ASN.1 encoded signature.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext is Sign with a context for the request to Vault.
+func (s *Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != s.hash || len(digest) != s.hash.Size() {
+		return nil, fmt.Errorf("vault: key %q signs %v digests", s.name, s.hash)
+	}
+	req := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"prehashed":            true,
+		"hash_algorithm":       s.hashName,
+		"marshaling_algorithm": "asn1",
+		"key_version":          s.version,
+	}
+	var resp struct {
+		Signature string `json:"signature"`
+	}
+	if err := s.client.do(ctx, http.MethodPost, s.client.url("sign", s.name), req, &resp); err != nil {
+		return nil, err
+	}
+	prefix := "vault:v" + strconv.Itoa(s.version) + ":"
+	if !strings.HasPrefix(resp.Signature, prefix) {
+		return nil, fmt.Errorf("vault: malformed signature %q", resp.Signature)
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(resp.Signature, prefix))
+}
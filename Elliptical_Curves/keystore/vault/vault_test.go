@@ -0,0 +1,138 @@
+package vault
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pecdsa "github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/keystore"
+)
+
+const testToken = "s.test"
+
+// fakeTransit serves the Transit API for the key "issuer", of type
+// ecdsa-p256, and the key "aes", of type aes256-gcm96.
+func fakeTransit(key *ecdsa.PrivateKey) *httptest.Server {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	pubPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	reply := func(w http.ResponseWriter, data interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != testToken {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/transit/keys":
+			reply(w, map[string]interface{}{"keys": []string{"issuer", "aes"}})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/issuer":
+			reply(w, map[string]interface{}{
+				"type":           "ecdsa-p256",
+				"latest_version": 2,
+				"keys": map[string]interface{}{
+					"2": map[string]interface{}{"public_key": pubPEM, "creation_time": "2026-01-02T03:04:05Z"},
+				},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/aes":
+			reply(w, map[string]interface{}{"type": "aes256-gcm96", "latest_version": 1})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/transit/sign/issuer":
+			var req struct {
+				Input      string `json:"input"`
+				Prehashed  bool   `json:"prehashed"`
+				Hash       string `json:"hash_algorithm"`
+				Marshaling string `json:"marshaling_algorithm"`
+				KeyVersion int    `json:"key_version"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			digest, _ := base64.StdEncoding.DecodeString(req.Input)
+			if !req.Prehashed || req.Hash != "sha2-256" || req.Marshaling != "asn1" || req.KeyVersion != 2 || len(digest) != 32 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			sig, _ := ecdsa.SignASN1(rand.Reader, key, digest)
+			reply(w, map[string]interface{}{"signature": "vault:v2:" + base64.StdEncoding.EncodeToString(sig)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+		}
+	}))
+}
+
+func TestClient(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := fakeTransit(key)
+	defer srv.Close()
+	c := &Client{Address: srv.URL, Token: testToken}
+	ctx := context.Background()
+
+	list, err := c.List(ctx)
+	if err != nil {
+		t.Fatalf("List error: %s", err)
+	}
+	if len(list) != 1 || list[0].ID != "issuer" || list[0].Scheme != "ECDSA-P-256-SHA-256" ||
+		!list[0].Created.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("List = %+v, want the issuer key", list)
+	}
+
+	signer, err := c.Signer(ctx, "issuer")
+	if err != nil {
+		t.Fatalf("Signer error: %s", err)
+	}
+	if !key.PublicKey.Equal(signer.Public()) {
+		t.Errorf("Signer has another public key")
+	}
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Errorf("signature does not verify")
+	}
+	if _, err := signer.Sign(rand.Reader, digest[:], crypto.SHA384); err == nil {
+		t.Errorf("Sign of a SHA-384 digest succeeded")
+	}
+
+	// The blinding is computed locally, with signatures of the base key.
+	skB, _ := pecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rs, err := pecdsa.NewRemoteBlindSigner(signer, skB, []byte("vault"))
+	if err != nil {
+		t.Fatalf("NewRemoteBlindSigner error: %s", err)
+	}
+	sig, err = rs.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("blinded Sign error: %s", err)
+	}
+	pkS, _ := pecdsa.FromStdPublicKey(&key.PublicKey)
+	pkR, _ := pecdsa.BlindPublicKeyWithContext(elliptic.P256(), pkS, skB, []byte("vault"))
+	if !pecdsa.VerifyASN1(pkR, digest[:], sig) {
+		t.Errorf("blinded signature does not verify")
+	}
+
+	if _, err := c.Signer(ctx, "missing"); !errors.Is(err, keystore.ErrNotFound) {
+		t.Errorf("Signer of a missing key: got %v, want ErrNotFound", err)
+	}
+	if _, err := c.Signer(ctx, "aes"); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Signer of an AES key: got %v, want ErrUnsupportedKey", err)
+	}
+	bad := &Client{Address: srv.URL, Token: "wrong"}
+	if _, err := bad.List(ctx); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("List with a wrong token: got %v, want permission denied", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package logverify verifies streams of signed records, such as the logs of
+// the signatures an issuer produced with blinded keys, incrementally and with
+// memory bounded by the size of a single record, so that auditors can check
+// logs of many gigabytes.
+//
+// A log is a sequence of records, each made of a public key, a message and a
+// signature of the message under the key, in the encoding of a
+// blinding.BlindableScheme. Each field is prefixed with its length as a
+// 4-byte big-endian integer:
+//
+//	struct {
+//	  opaque public_key<0..2^32-1>;
+//	  opaque message<0..2^32-1>;
+//	  opaque signature<0..2^32-1>;
+//	} Record;
+//
+// Writer produces logs in this format.
+package logverify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+// DefaultMaxFieldSize is the largest field accepted by a Verifier whose
+// MaxFieldSize is zero.
+const DefaultMaxFieldSize = 1 << 20
+
+// DefaultProgressInterval is the number of records between calls to the
+// progress callback of a Verifier whose ProgressInterval is zero.
+const DefaultProgressInterval = 10000
+
+// ErrMalformed is returned when a log is truncated or has a field longer than
+// the limit of the Verifier.
+var ErrMalformed = errors.New("logverify: malformed log")
+
+// Record is a signed record of a log.
+type Record struct {
+	PublicKey []byte
+	Message   []byte
+	Signature []byte
+}
+
+// Writer appends records to a log.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer appending records to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write appends rec to the log.
+func (w *Writer) Write(rec Record) error {
+	for _, f := range [][]byte{rec.PublicKey, rec.Message, rec.Signature} {
+		if uint64(len(f)) > 0xffffffff {
+			return fmt.Errorf("%w: field of %d bytes", ErrMalformed, len(f))
+		}
+		var l [4]byte
+		binary.BigEndian.PutUint32(l[:], uint32(len(f)))
+		if _, err := w.w.Write(l[:]); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Progress reports how much of a log was verified.
+type Progress struct {
+	// Records is the number of records read, and Valid and Invalid count
+	// those whose signature verified and those whose signature didn't.
+	Records, Valid, Invalid uint64
+	// Bytes is the number of bytes of the log read.
+	Bytes int64
+}
+
+// Failure describes a record whose signature didn't verify.
+type Failure struct {
+	// Index is the position of the record in the log, starting at zero, and
+	// Offset the position of its first byte.
+	Index  uint64
+	Offset int64
+	// Record is the record. Its fields are only valid during the call to
+	// the callback, and must be copied to be retained.
+	Record Record
+}
+
+// Verifier verifies logs of signatures of a scheme.
+type Verifier struct {
+	// Scheme is the scheme of the keys and signatures of the log.
+	Scheme blinding.BlindableScheme
+
+	// MaxFieldSize is the largest field accepted, which bounds the memory
+	// used to three times its value. Zero means DefaultMaxFieldSize.
+	MaxFieldSize int
+
+	// OnProgress, if not nil, is called every ProgressInterval records, or
+	// DefaultProgressInterval if it is zero, and once at the end of the log.
+	OnProgress       func(Progress)
+	ProgressInterval uint64
+
+	// OnInvalid, if not nil, is called for each record whose signature
+	// doesn't verify.
+	OnInvalid func(Failure)
+}
+
+// reader reads the fields of records, reusing their buffers.
+type reader struct {
+	r      *bufio.Reader
+	max    int
+	offset int64
+}
+
+// readField reads a length-prefixed field into buf, grown as needed. It
+// returns io.EOF if the log ends before the length, and an error wrapping
+// ErrMalformed if it ends within the field.
+func (r *reader) readField(buf []byte) ([]byte, error) {
+	var l [4]byte
+	n, err := io.ReadFull(r.r, l[:])
+	r.offset += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%w: truncated length at offset %d", ErrMalformed, r.offset)
+	} else if err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(l[:])
+	if uint64(size) > uint64(r.max) {
+		return nil, fmt.Errorf("%w: field of %d bytes at offset %d", ErrMalformed, size, r.offset)
+	}
+	if cap(buf) < int(size) {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	n, err = io.ReadFull(r.r, buf)
+	r.offset += int64(n)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%w: truncated field at offset %d", ErrMalformed, r.offset)
+	} else if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Verify reads the log from r to its end and verifies every record. It
+// returns the final progress, and an error if the log is malformed, r fails,
+// or ctx is done; invalid signatures are not errors, but are counted and
+// passed to OnInvalid.
+func (v *Verifier) Verify(ctx context.Context, r io.Reader) (Progress, error) {
+	var p Progress
+	if v.Scheme == nil {
+		return p, errors.New("logverify: missing scheme")
+	}
+	rd := &reader{r: bufio.NewReader(r), max: v.MaxFieldSize}
+	if rd.max <= 0 {
+		rd.max = DefaultMaxFieldSize
+	}
+	interval := v.ProgressInterval
+	if interval == 0 {
+		interval = DefaultProgressInterval
+	}
+	defer func() {
+		if v.OnProgress != nil {
+			v.OnProgress(p)
+		}
+	}()
+
+	var bufs [3][]byte
+	for {
+		if err := ctx.Err(); err != nil {
+			return p, err
+		}
+		start := rd.offset
+		var err error
+		for i := range bufs {
+			var f []byte
+			if f, err = rd.readField(bufs[i]); err != nil {
+				break
+			}
+			bufs[i] = f
+		}
+		p.Bytes = rd.offset
+		if err == io.EOF && rd.offset == start {
+			return p, nil
+		} else if err == io.EOF {
+			return p, fmt.Errorf("%w: truncated record at offset %d", ErrMalformed, start)
+		} else if err != nil {
+			return p, err
+		}
+
+		rec := Record{PublicKey: bufs[0], Message: bufs[1], Signature: bufs[2]}
+		if v.Scheme.Verify(rec.PublicKey, rec.Message, rec.Signature) {
+			p.Valid++
+		} else {
+			p.Invalid++
+			if v.OnInvalid != nil {
+				v.OnInvalid(Failure{Index: p.Records, Offset: start, Record: rec})
+			}
+		}
+		p.Records++
+		if v.OnProgress != nil && p.Records%interval == 0 {
+			v.OnProgress(p)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package logverify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+// writeLog writes a log of n records signed with blinded keys of s, where the
+// records whose index is in invalid have a corrupted message.
+func writeLog(t *testing.T, s blinding.BlindableScheme, n int, invalid map[int]bool) []byte {
+	pk, sk, err := s.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := 0; i < n; i++ {
+		blind, _ := s.GenerateBlind(rand.Reader)
+		context := []byte("epoch")
+		pkR, err := s.BlindPublicKey(pk, blind, context)
+		if err != nil {
+			t.Fatalf("BlindPublicKey error: %s", err)
+		}
+		msg := []byte(fmt.Sprintf("token %d", i))
+		sig, err := s.BlindKeySign(rand.Reader, sk, blind, msg, context)
+		if err != nil {
+			t.Fatalf("BlindKeySign error: %s", err)
+		}
+		if invalid[i] {
+			msg = append(msg, '!')
+		}
+		if err := w.Write(Record{PublicKey: pkR, Message: msg, Signature: sig}); err != nil {
+			t.Fatalf("Write error: %s", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestVerify(t *testing.T) {
+	for _, s := range []blinding.BlindableScheme{blinding.Ristretto255, blinding.ECDSA(elliptic.P256(), crypto.SHA256)} {
+		t.Run(s.Name(), func(t *testing.T) {
+			testVerify(t, s)
+		})
+	}
+}
+
+func testVerify(t *testing.T, s blinding.BlindableScheme) {
+	log := writeLog(t, s, 25, map[int]bool{3: true, 17: true})
+
+	var progress []Progress
+	var failures []uint64
+	v := &Verifier{
+		Scheme:           s,
+		ProgressInterval: 10,
+		OnProgress:       func(p Progress) { progress = append(progress, p) },
+		OnInvalid:        func(f Failure) { failures = append(failures, f.Index) },
+	}
+	p, err := v.Verify(context.Background(), bytes.NewReader(log))
+	if err != nil {
+		t.Fatalf("Verify error: %s", err)
+	}
+	if p.Records != 25 || p.Valid != 23 || p.Invalid != 2 || p.Bytes != int64(len(log)) {
+		t.Errorf("Verify = %+v, want 25 records, 2 invalid, %d bytes", p, len(log))
+	}
+	if len(failures) != 2 || failures[0] != 3 || failures[1] != 17 {
+		t.Errorf("invalid records %v, want [3 17]", failures)
+	}
+	if len(progress) != 3 || progress[0].Records != 10 || progress[1].Records != 20 || progress[2] != p {
+		t.Errorf("progress %+v, want calls at 10, 20 and the end", progress)
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	s := blinding.Ristretto255
+	log := writeLog(t, s, 3, nil)
+	v := &Verifier{Scheme: s}
+
+	if p, err := v.Verify(context.Background(), bytes.NewReader(log[:len(log)-1])); !errors.Is(err, ErrMalformed) || p.Records != 2 {
+		t.Errorf("Verify of a truncated log = %+v, %v, want 2 records and ErrMalformed", p, err)
+	}
+	if _, err := v.Verify(context.Background(), bytes.NewReader(log[:2])); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Verify of a truncated length: got %v, want ErrMalformed", err)
+	}
+	small := &Verifier{Scheme: s, MaxFieldSize: 16}
+	if _, err := small.Verify(context.Background(), bytes.NewReader(log)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Verify of a field over the limit: got %v, want ErrMalformed", err)
+	}
+	if p, err := v.Verify(context.Background(), bytes.NewReader(nil)); err != nil || p.Records != 0 {
+		t.Errorf("Verify of an empty log = %+v, %v", p, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := v.Verify(ctx, bytes.NewReader(log)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Verify with a canceled context: got %v, want context.Canceled", err)
+	}
+}
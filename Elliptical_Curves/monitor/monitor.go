@@ -0,0 +1,221 @@
+// Package monitor watches published blinded public keys, such as the entries
+// of a signed directory, on behalf of the owner of base keys: each published
+// key that claims to descend from an owned base key is recomputed from the
+// base key with every known blind, and an alert is raised for keys that
+// don't match, which were not blinded by the owner, and for the owner's keys
+// published under another identity.
+//
+// A Monitor only needs the base public keys and the blinds, not the private
+// keys, so it can run on a separate, less trusted host.
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"github.com/cloudflare/pat-go/directory"
+)
+
+// ErrUnknownKey is returned when adding a blind for a base key the monitor
+// doesn't watch.
+var ErrUnknownKey = errors.New("monitor: unknown base key")
+
+// AlertKind is the reason for an alert.
+type AlertKind int
+
+const (
+	// AlertUnexpectedKey is raised for a key that claims to descend from an
+	// owned base key, but isn't that key blinded by any known blind.
+	AlertUnexpectedKey AlertKind = iota + 1
+
+	// AlertMisattributedKey is raised for a key blinded from an owned base
+	// key that is published under the identity of another key.
+	AlertMisattributedKey
+)
+
+// String returns the name of the kind.
+func (k AlertKind) String() string {
+	switch k {
+	case AlertUnexpectedKey:
+		return "unexpected key"
+	case AlertMisattributedKey:
+		return "misattributed key"
+	default:
+		return "unknown"
+	}
+}
+
+// Observation is a blinded public key seen in a feed.
+type Observation struct {
+	// Source describes where the key was seen, for alerts.
+	Source string
+	// ID is the identity of the base key the key claims to descend from.
+	ID string
+	// PublicKey is the blinded public key.
+	PublicKey []byte
+	// Context is the context string the key is supposed to be blinded
+	// with.
+	Context []byte
+}
+
+// Alert reports an observation of a blinded key that the owner of the base
+// keys didn't publish.
+type Alert struct {
+	Kind        AlertKind
+	Observation Observation
+	// Owner is the ID of the owned base key the observed key descends
+	// from, for AlertMisattributedKey.
+	Owner string
+}
+
+// Error returns a description of the alert.
+func (a *Alert) Error() string {
+	switch a.Kind {
+	case AlertMisattributedKey:
+		return fmt.Sprintf("monitor: %s: key of %q published as %q", a.Observation.Source, a.Owner, a.Observation.ID)
+	default:
+		return fmt.Sprintf("monitor: %s: %s for %q", a.Observation.Source, a.Kind, a.Observation.ID)
+	}
+}
+
+type ownedKey struct {
+	publicKey []byte
+	blinds    [][]byte
+}
+
+// Monitor checks observed blinded keys against owned base keys. It is safe
+// for concurrent use.
+type Monitor struct {
+	scheme blinding.BlindableScheme
+	alert  func(*Alert)
+
+	mu   sync.RWMutex
+	keys map[string]*ownedKey
+}
+
+// New returns a monitor for base keys of the scheme s, which calls alert with
+// every alert it raises. alert may be nil.
+func New(s blinding.BlindableScheme, alert func(*Alert)) *Monitor {
+	return &Monitor{scheme: s, alert: alert, keys: make(map[string]*ownedKey)}
+}
+
+// AddKey watches the base public key publicKey, known in feeds as id, with
+// the given known blinds. Adding an ID again replaces its key and blinds.
+func (m *Monitor) AddKey(id string, publicKey []byte, blinds ...[]byte) {
+	k := &ownedKey{publicKey: append([]byte{}, publicKey...)}
+	for _, b := range blinds {
+		k.blinds = append(k.blinds, append([]byte{}, b...))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[id] = k
+}
+
+// AddBlind adds a known blind of the base key id, so that the keys it blinds
+// are no longer reported.
+func (m *Monitor) AddBlind(id string, blind []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	k.blinds = append(k.blinds, append([]byte{}, blind...))
+	return nil
+}
+
+// derives reports whether k blinded by one of its blinds with context is pk.
+func (m *Monitor) derives(k *ownedKey, pk, context []byte) (bool, error) {
+	for _, b := range k.blinds {
+		pkR, err := m.scheme.BlindPublicKey(k.publicKey, b, context)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(pkR, pk) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Check checks an observation, and returns the alert it raises, or nil. It
+// returns an error if a known blind can't be applied to its base key.
+func (m *Monitor) Check(o Observation) (*Alert, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if k, ok := m.keys[o.ID]; ok {
+		match, err := m.derives(k, o.PublicKey, o.Context)
+		if err != nil {
+			return nil, fmt.Errorf("monitor: key %q: %w", o.ID, err)
+		}
+		if !match {
+			return &Alert{Kind: AlertUnexpectedKey, Observation: o}, nil
+		}
+		return nil, nil
+	}
+	for id, k := range m.keys {
+		match, err := m.derives(k, o.PublicKey, o.Context)
+		if err != nil {
+			return nil, fmt.Errorf("monitor: key %q: %w", id, err)
+		}
+		if match {
+			return &Alert{Kind: AlertMisattributedKey, Observation: o, Owner: id}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Observe checks an observation and passes the alert it raises, if any, to
+// the alert function of m.
+func (m *Monitor) Observe(o Observation) (*Alert, error) {
+	a, err := m.Check(o)
+	if a != nil && m.alert != nil {
+		m.alert(a)
+	}
+	return a, err
+}
+
+// CheckDirectory observes every entry of d, blinded with the context of its
+// epoch, and returns the alerts raised. It returns an error if d is not a
+// directory of the scheme of m.
+func (m *Monitor) CheckDirectory(d *directory.Directory) ([]*Alert, error) {
+	if d.Scheme != m.scheme.Name() {
+		return nil, fmt.Errorf("monitor: directory of scheme %s, want %s", d.Scheme, m.scheme.Name())
+	}
+	context := directory.EpochContext(d.Epoch)
+	source := fmt.Sprintf("directory epoch %d", d.Epoch)
+	var alerts []*Alert
+	for _, e := range d.Entries {
+		a, err := m.Observe(Observation{Source: source, ID: e.ID, PublicKey: e.PublicKey, Context: context})
+		if err != nil {
+			return alerts, err
+		}
+		if a != nil {
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts, nil
+}
+
+// Watch observes the keys received from feed until it is closed or ctx is
+// done, and returns the first error or ctx.Err(). Alerts are passed to the
+// alert function of m.
+func (m *Monitor) Watch(ctx context.Context, feed <-chan Observation) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case o, ok := <-feed:
+			if !ok {
+				return nil
+			}
+			if _, err := m.Observe(o); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"github.com/cloudflare/pat-go/directory"
+)
+
+func TestCheckDirectory(t *testing.T) {
+	s := blinding.Ristretto255
+	var keys []directory.Key
+	for _, id := range []string{"ours", "theirs"} {
+		pk, _, _ := s.GenerateKey(rand.Reader)
+		blind, _ := s.GenerateBlind(rand.Reader)
+		keys = append(keys, directory.Key{ID: id, PublicKey: pk, Blind: blind})
+	}
+	var alerts []*Alert
+	m := New(s, func(a *Alert) { alerts = append(alerts, a) })
+	m.AddKey("ours", keys[0].PublicKey, keys[0].Blind)
+
+	d, err := directory.Build(s, 3, keys)
+	if err != nil {
+		t.Fatalf("Build error: %s", err)
+	}
+	if got, err := m.CheckDirectory(d); err != nil || len(got) != 0 {
+		t.Fatalf("CheckDirectory = %v, %v, want no alerts", got, err)
+	}
+
+	// An attacker publishes a key under our ID, and our key under theirs.
+	forged := keys[0]
+	forged.Blind, _ = s.GenerateBlind(rand.Reader)
+	stolen := keys[0]
+	stolen.ID = "theirs"
+	d, _ = directory.Build(s, 4, []directory.Key{forged, stolen})
+	got, err := m.CheckDirectory(d)
+	if err != nil {
+		t.Fatalf("CheckDirectory error: %s", err)
+	}
+	if len(got) != 2 || got[0].Kind != AlertUnexpectedKey || got[1].Kind != AlertMisattributedKey || got[1].Owner != "ours" {
+		t.Fatalf("CheckDirectory = %v, want an unexpected and a misattributed key", got)
+	}
+	if len(alerts) != 2 {
+		t.Errorf("alert function called %d times, want 2", len(alerts))
+	}
+
+	// Once the blind is known, the key is expected.
+	if err := m.AddBlind("ours", forged.Blind); err != nil {
+		t.Fatalf("AddBlind error: %s", err)
+	}
+	if got, _ := m.CheckDirectory(d); len(got) != 1 {
+		t.Errorf("CheckDirectory after AddBlind raised %d alerts, want 1", len(got))
+	}
+	if err := m.AddBlind("nobody", forged.Blind); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("AddBlind for an unknown key: got %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s := blinding.Ed25519
+	pk, _, _ := s.GenerateKey(rand.Reader)
+	blind, _ := s.GenerateBlind(rand.Reader)
+	other, _ := s.GenerateBlind(rand.Reader)
+	good, _ := s.BlindPublicKey(pk, blind, []byte("feed"))
+	bad, _ := s.BlindPublicKey(pk, other, []byte("feed"))
+
+	alerts := make(chan *Alert, 2)
+	m := New(s, func(a *Alert) { alerts <- a })
+	m.AddKey("ours", pk, blind)
+	feed := make(chan Observation, 2)
+	feed <- Observation{Source: "test", ID: "ours", PublicKey: good, Context: []byte("feed")}
+	feed <- Observation{Source: "test", ID: "ours", PublicKey: bad, Context: []byte("feed")}
+	close(feed)
+	if err := m.Watch(context.Background(), feed); err != nil {
+		t.Fatalf("Watch error: %s", err)
+	}
+	close(alerts)
+	var n int
+	for a := range alerts {
+		n++
+		if a.Kind != AlertUnexpectedKey || a.Error() == "" {
+			t.Errorf("got alert %v, want an unexpected key", a)
+		}
+	}
+	if n != 1 {
+		t.Errorf("got %d alerts, want 1", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Watch(ctx, make(chan Observation)); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Watch: got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package perf
+
+// raceEnabled is set when the race detector is enabled.
+const raceEnabled = false
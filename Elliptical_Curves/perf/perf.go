@@ -0,0 +1,328 @@
+// Package perf is the performance regression gate of this module: a fixed
+// suite of operations of the signature schemes with key blinding, measured
+// with deterministic inputs, whose reports can be saved as JSON baselines and
+// compared, so that performance-sensitive changes, such as a constant-time
+// rewrite of an arithmetic backend, are checked automatically.
+//
+// Every case of the suite draws its keys, blinds, messages and nonces from
+// NewRand seeded with its name, so that two runs measure the same work, and
+// has an allocation budget per operation, which is checked by the tests of
+// this package and by Report.Check. The cmd/perfgate command runs the suite,
+// writes reports and compares them with a baseline.
+//
+// Timings only compare between runs on the same machine: the baseline of a
+// gate must be recorded on the machine that runs it. Allocation counts don't
+// depend on the machine, only on the architecture and the Go version, so they
+// are only checked against budgets and baselines of the same Go release, and
+// not at all under the race detector, which allocates on its own.
+package perf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Case is an operation of the suite.
+type Case struct {
+	// Name identifies the case in reports, as a path such as
+	// "ECDSA-P256-SHA256-BLIND-MUL/BlindKeySign".
+	Name string
+
+	// MaxAllocs is the allocation budget of an operation: the number of
+	// heap allocations it makes on average, measured with BudgetGoRelease on
+	// linux/amd64. Operations may exceed it by AllocMargin.
+	MaxAllocs int64
+
+	// Setup prepares the inputs of the case with entropy from rand, and
+	// returns the operation to measure.
+	Setup func(rand io.Reader) (op func() error, err error)
+}
+
+// BudgetGoRelease is the Go release the allocation budgets of the suite were
+// measured with. Other releases may allocate differently, so budgets are not
+// checked for them.
+const BudgetGoRelease = "go1.27"
+
+// AllocMargin is the relative margin allowed over an allocation budget, for
+// changes of the runtime and the standard library between patch releases. At
+// least one allocation over the budget is always allowed.
+const AllocMargin = 0.1
+
+// overBudget reports whether allocs is over the budget max with its margin.
+func overBudget(allocs, max int64) bool {
+	limit := max + int64(AllocMargin*float64(max))
+	if limit == max {
+		limit++
+	}
+	return allocs > limit
+}
+
+// goRelease returns the release of the Go version v, such as "go1.27" for
+// "go1.27.1". Versions it doesn't recognize are returned unchanged.
+func goRelease(v string) string {
+	if parts := strings.SplitN(v, ".", 3); len(parts) == 3 {
+		return parts[0] + "." + parts[1]
+	}
+	return v
+}
+
+// NewRand returns a deterministic source of randomness seeded with seed: the
+// concatenation of SHA-256(seed || counter) for a 64-bit big-endian counter
+// starting at 0. It must only be used for benchmarks and tests.
+func NewRand(seed string) io.Reader {
+	return &seededRand{seed: []byte(seed)}
+}
+
+type seededRand struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (r *seededRand) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			var ctr [8]byte
+			binary.BigEndian.PutUint64(ctr[:], r.counter)
+			r.counter++
+			block := sha256.Sum256(append(append([]byte(nil), r.seed...), ctr[:]...))
+			r.buf = block[:]
+		}
+		m := copy(p[n:], r.buf)
+		r.buf = r.buf[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Result is the measurement of a case.
+type Result struct {
+	Name        string `json:"name"`
+	N           int    `json:"n"`
+	NsPerOp     int64  `json:"ns_per_op"`
+	AllocsPerOp int64  `json:"allocs_per_op"`
+	BytesPerOp  int64  `json:"bytes_per_op"`
+	MaxAllocs   int64  `json:"max_allocs"`
+}
+
+// Report is the result of a run of the suite.
+type Report struct {
+	GoVersion string `json:"go_version"`
+	GOOS      string `json:"goos"`
+	GOARCH    string `json:"goarch"`
+	// Race is set when the suite ran with the race detector enabled.
+	Race    bool      `json:"race,omitempty"`
+	Date    time.Time `json:"date"`
+	Results []Result  `json:"results"`
+}
+
+// allocsComparable reports whether the allocation counts of r compare with
+// those of a report made with the Go version goVersion, without the race
+// detector.
+func (r *Report) allocsComparable(goVersion string) bool {
+	return !r.Race && goRelease(r.GoVersion) == goRelease(goVersion)
+}
+
+// Options are the options of Run.
+type Options struct {
+	// Benchtime is the minimum time each case runs for, one second if zero.
+	Benchtime time.Duration
+
+	// Match selects the cases to run by name. All cases run if it is nil.
+	Match func(name string) bool
+}
+
+// Run measures cases, in order, and returns their report.
+func Run(cases []Case, opts *Options) (*Report, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Benchtime == 0 {
+		o.Benchtime = time.Second
+	}
+	report := &Report{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		Race:      raceEnabled,
+		Date:      time.Now().UTC().Truncate(time.Second),
+	}
+	for _, c := range cases {
+		if o.Match != nil && !o.Match(c.Name) {
+			continue
+		}
+		r, err := measure(c, o.Benchtime)
+		if err != nil {
+			return nil, fmt.Errorf("perf: %s: %w", c.Name, err)
+		}
+		report.Results = append(report.Results, r)
+	}
+	return report, nil
+}
+
+// measure runs the operation of c, as testing.B does, with a number of
+// iterations that grows until the run lasts at least benchtime.
+func measure(c Case, benchtime time.Duration) (Result, error) {
+	op, err := c.Setup(NewRand(c.Name))
+	if err != nil {
+		return Result{}, err
+	}
+	// Warm up caches, such as the precomputed tables of the curves.
+	if err := op(); err != nil {
+		return Result{}, err
+	}
+	var before, after runtime.MemStats
+	n := 1
+	for {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := op(); err != nil {
+				return Result{}, err
+			}
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		if elapsed >= benchtime || n >= 1e9 {
+			return Result{
+				Name:        c.Name,
+				N:           n,
+				NsPerOp:     elapsed.Nanoseconds() / int64(n),
+				AllocsPerOp: int64(after.Mallocs-before.Mallocs) / int64(n),
+				BytesPerOp:  int64(after.TotalAlloc-before.TotalAlloc) / int64(n),
+				MaxAllocs:   c.MaxAllocs,
+			}, nil
+		}
+		// Aim 20% over benchtime, growing at least by one and at most
+		// a hundredfold.
+		next := int(1.2 * float64(n) * float64(benchtime) / math.Max(float64(elapsed), 1))
+		if next > 100*n {
+			next = 100 * n
+		}
+		if next <= n {
+			next = n + 1
+		}
+		n = next
+	}
+}
+
+// ErrRegression is returned by Report.Check and by the comparison of reports
+// when a case is over its allocation budget or slower than its baseline.
+var ErrRegression = errors.New("perf: regression")
+
+// Check returns an error wrapping ErrRegression that lists the cases of r
+// over their allocation budget and its margin. Reports made with another Go
+// release than BudgetGoRelease, or with the race detector, always pass.
+func (r *Report) Check() error {
+	if !r.allocsComparable(BudgetGoRelease) {
+		return nil
+	}
+	var over []string
+	for _, res := range r.Results {
+		if overBudget(res.AllocsPerOp, res.MaxAllocs) {
+			over = append(over, fmt.Sprintf("%s: %d allocs/op, budget %d", res.Name, res.AllocsPerOp, res.MaxAllocs))
+		}
+	}
+	if len(over) > 0 {
+		return fmt.Errorf("%w: over allocation budget:\n\t%s", ErrRegression, strings.Join(over, "\n\t"))
+	}
+	return nil
+}
+
+// Delta is the change of a case between a baseline and a current report.
+type Delta struct {
+	Name string
+	// Time is the relative change of the time per operation, 0.1 for 10%
+	// slower.
+	Time float64
+	// Allocs is the change of the number of allocations per operation, and
+	// BaseAllocs their number in the baseline. Both are zero when the
+	// allocations of the reports don't compare, because they were made with
+	// different Go releases or one used the race detector.
+	Allocs     int64
+	BaseAllocs int64
+}
+
+// Regressed reports whether the case got more than tolerance slower, or
+// allocates more than tolerance more. A case that didn't allocate regresses
+// when it allocates more than tolerance allocations.
+func (d Delta) Regressed(tolerance float64) bool {
+	base := d.BaseAllocs
+	if base < 1 {
+		base = 1
+	}
+	return d.Time > tolerance || float64(d.Allocs) > tolerance*float64(base)
+}
+
+func (d Delta) String() string {
+	return fmt.Sprintf("%s: %+.1f%% time, %+d allocs/op", d.Name, 100*d.Time, d.Allocs)
+}
+
+// Compare returns the changes of the cases of current that are in baseline,
+// sorted by name, and an error wrapping ErrRegression listing the cases that
+// got more than tolerance slower or allocate more than tolerance more. Cases
+// that are only in one of the reports are ignored, and so are allocations
+// unless both reports were made with the same Go release without the race
+// detector.
+func Compare(baseline, current *Report, tolerance float64) ([]Delta, error) {
+	allocs := !current.Race && baseline.allocsComparable(current.GoVersion)
+	base := make(map[string]Result, len(baseline.Results))
+	for _, r := range baseline.Results {
+		base[r.Name] = r
+	}
+	var deltas []Delta
+	var regressed []string
+	for _, cur := range current.Results {
+		b, ok := base[cur.Name]
+		if !ok {
+			continue
+		}
+		d := Delta{Name: cur.Name}
+		if allocs {
+			d.Allocs, d.BaseAllocs = cur.AllocsPerOp-b.AllocsPerOp, b.AllocsPerOp
+		}
+		if b.NsPerOp > 0 {
+			d.Time = float64(cur.NsPerOp-b.NsPerOp) / float64(b.NsPerOp)
+		}
+		deltas = append(deltas, d)
+		if d.Regressed(tolerance) {
+			regressed = append(regressed, d.String())
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Name < deltas[j].Name })
+	if len(regressed) > 0 {
+		sort.Strings(regressed)
+		return deltas, fmt.Errorf("%w: against the baseline:\n\t%s", ErrRegression, strings.Join(regressed, "\n\t"))
+	}
+	return deltas, nil
+}
+
+// WriteJSON writes r as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadReport reads a report written by Report.WriteJSON.
+func ReadReport(rd io.Reader) (*Report, error) {
+	var r Report
+	dec := json.NewDecoder(rd)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("perf: reading report: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,218 @@
+package perf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAllocationBudgets(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("allocation budgets are measured on amd64")
+	}
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	if goRelease(runtime.Version()) != BudgetGoRelease {
+		t.Skipf("allocation budgets are measured with %s", BudgetGoRelease)
+	}
+	for _, c := range Suite() {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if testing.Short() && strings.Contains(c.Name, "BP") {
+				t.Skip("skipping Brainpool curves in short mode")
+			}
+			op, err := c.Setup(NewRand(c.Name))
+			if err != nil {
+				t.Fatalf("Setup error: %s", err)
+			}
+			if err := op(); err != nil {
+				t.Fatalf("operation error: %s", err)
+			}
+			allocs := testing.AllocsPerRun(3, func() {
+				if err := op(); err != nil {
+					t.Fatalf("operation error: %s", err)
+				}
+			})
+			if overBudget(int64(allocs), c.MaxAllocs) {
+				t.Errorf("%v allocs/op, budget %d", allocs, c.MaxAllocs)
+			}
+		})
+	}
+}
+
+func TestNewRand(t *testing.T) {
+	a, b := make([]byte, 100), make([]byte, 100)
+	io.ReadFull(NewRand("seed"), a)
+	r := NewRand("seed")
+	io.ReadFull(r, b[:7])
+	io.ReadFull(r, b[7:])
+	if !bytes.Equal(a, b) {
+		t.Error("NewRand is not deterministic")
+	}
+	first := sha256.Sum256(append([]byte("seed"), 0, 0, 0, 0, 0, 0, 0, 0))
+	if !bytes.Equal(a[:32], first[:]) {
+		t.Errorf("first block = %x, want %x", a[:32], first)
+	}
+	io.ReadFull(NewRand("other"), b)
+	if bytes.Equal(a, b) {
+		t.Error("different seeds give the same stream")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{GoVersion: "go1.27.1", Results: []Result{
+		{Name: "a", NsPerOp: 1000, AllocsPerOp: 10},
+		{Name: "b", NsPerOp: 1000, AllocsPerOp: 10},
+		{Name: "c", NsPerOp: 1000, AllocsPerOp: 10},
+		{Name: "removed", NsPerOp: 1000},
+	}}
+	current := &Report{GoVersion: "go1.27.3", Results: []Result{
+		{Name: "c", NsPerOp: 1050, AllocsPerOp: 11},
+		{Name: "b", NsPerOp: 900, AllocsPerOp: 12},
+		{Name: "a", NsPerOp: 1200, AllocsPerOp: 9},
+		{Name: "added", NsPerOp: 1000},
+	}}
+	deltas, err := Compare(baseline, current, 0.1)
+	if !errors.Is(err, ErrRegression) {
+		t.Fatalf("Compare error = %v, want ErrRegression", err)
+	}
+	if len(deltas) != 3 || deltas[0].Name != "a" || deltas[2].Name != "c" {
+		t.Fatalf("Compare deltas = %v", deltas)
+	}
+	for _, d := range deltas {
+		if want := d.Name != "c"; d.Regressed(0.1) != want {
+			t.Errorf("%s: Regressed = %v, want %v", d, !want, want)
+		}
+	}
+	if !strings.Contains(err.Error(), "a: +20.0% time, -1 allocs/op") {
+		t.Errorf("Compare error does not list the slower case: %s", err)
+	}
+	if _, err := Compare(baseline, baseline, 0); err != nil {
+		t.Errorf("Compare of a report with itself: %s", err)
+	}
+
+	// Allocations don't compare across Go releases, or with the race
+	// detector.
+	current.Results[0].NsPerOp = 1000
+	current.Results[2].NsPerOp = 1000
+	current.GoVersion = "go1.28.0"
+	if _, err := Compare(baseline, current, 0.1); err != nil {
+		t.Errorf("Compare with another Go release: %s", err)
+	}
+	current.GoVersion, current.Race = baseline.GoVersion, true
+	if _, err := Compare(baseline, current, 0.1); err != nil {
+		t.Errorf("Compare with the race detector: %s", err)
+	}
+}
+
+func TestAllocationMargin(t *testing.T) {
+	for _, tt := range []struct {
+		allocs, max int64
+		over        bool
+	}{
+		{0, 0, false},
+		{1, 0, false},
+		{2, 0, true},
+		{4, 3, false},
+		{5, 3, true},
+		{110, 100, false},
+		{111, 100, true},
+	} {
+		if got := overBudget(tt.allocs, tt.max); got != tt.over {
+			t.Errorf("overBudget(%d, %d) = %v, want %v", tt.allocs, tt.max, got, tt.over)
+		}
+	}
+
+	report := &Report{GoVersion: BudgetGoRelease + ".0", Results: []Result{{Name: "a", AllocsPerOp: 200, MaxAllocs: 100}}}
+	if err := report.Check(); !errors.Is(err, ErrRegression) {
+		t.Errorf("Check of a case over budget: error = %v", err)
+	}
+	report.Race = true
+	if err := report.Check(); err != nil {
+		t.Errorf("Check with the race detector: %s", err)
+	}
+	report.Race, report.GoVersion = false, "go1.10.8"
+	if err := report.Check(); err != nil {
+		t.Errorf("Check with another Go release: %s", err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	report, err := Run(Suite(), &Options{
+		Benchtime: 1,
+		Match:     func(name string) bool { return strings.HasPrefix(name, "EDDSA-ED25519") },
+	})
+	if err != nil {
+		t.Fatalf("Run error: %s", err)
+	}
+	if len(report.Results) != 4 {
+		t.Fatalf("Run measured %d cases, want 4", len(report.Results))
+	}
+	if err := report.Check(); err != nil {
+		t.Errorf("Check error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON error: %s", err)
+	}
+	read, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadReport error: %s", err)
+	}
+	if read.GoVersion != report.GoVersion || len(read.Results) != 4 || read.Results[0] != report.Results[0] {
+		t.Errorf("ReadReport = %+v, want %+v", read, report)
+	}
+
+	read.GoVersion, read.Race = BudgetGoRelease+".0", false
+	read.Results[0].AllocsPerOp = 2*read.Results[0].MaxAllocs + 2
+	if err := read.Check(); !errors.Is(err, ErrRegression) {
+		t.Errorf("Check of a case over budget: error = %v", err)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	f, err := os.Open("testdata/baseline.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	baseline, err := ReadReport(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, r := range baseline.Results {
+		names[r.Name] = true
+	}
+	for _, c := range Suite() {
+		if !names[c.Name] {
+			t.Errorf("case %s is missing from the baseline", c.Name)
+		}
+	}
+}
+
+func BenchmarkSuite(b *testing.B) {
+	for _, c := range Suite() {
+		c := c
+		b.Run(c.Name, func(b *testing.B) {
+			op, err := c.Setup(NewRand(c.Name))
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := op(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
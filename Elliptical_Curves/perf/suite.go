@@ -0,0 +1,192 @@
+package perf
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"github.com/cloudflare/pat-go/brainpool"
+	"github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/treehash"
+)
+
+var errInvalidSignature = errors.New("perf: signature does not verify")
+
+// schemeBudgets are the allocation budgets of the operations of each
+// registered algorithm, in the order BlindPublicKey, UnblindPublicKey,
+// BlindKeySign and Verify. Budgets are the allocations measured on
+// linux/amd64 with BudgetGoRelease, and are checked with AllocMargin; a
+// change that lowers them should lower the budget too.
+var schemeBudgets = map[blinding.AlgorithmID][4]int64{
+	blinding.AlgorithmRistretto255:       {4, 4, 13, 3},
+	blinding.AlgorithmEd25519:            {5, 20, 3, 0},
+	blinding.AlgorithmBLS12381:           {34, 34, 57, 47},
+	blinding.AlgorithmECDSAP256:          {69, 83, 126, 40},
+	blinding.AlgorithmECDSAP384:          {84, 104, 136, 56},
+	blinding.AlgorithmECDSAP521:          {84, 106, 137, 56},
+	blinding.AlgorithmECDSABrainpoolP256: {26930, 25898, 39065, 26533},
+	blinding.AlgorithmECDSABrainpoolP384: {31739, 31005, 47807, 32573},
+	blinding.AlgorithmECDSABrainpoolP512: {42838, 43348, 65078, 43343},
+}
+
+// Suite returns the cases of the regression gate: blinding, unblinding,
+// signing with a blinded key and verifying for every algorithm of the
+// blinding package, and the multi-scalar multiplications and tree hashing
+// that batch verification and large messages rely on.
+func Suite() []Case {
+	var cases []Case
+	for _, id := range blinding.RegisteredAlgorithms() {
+		budgets, ok := schemeBudgets[id]
+		if !ok {
+			// Algorithms registered by other packages have no budget.
+			continue
+		}
+		s, _ := blinding.LookupAlgorithm(id)
+		cases = append(cases, schemeCases(string(id), s, budgets)...)
+	}
+	cases = append(cases,
+		msmCase(elliptic.P256(), 64, 1629),
+		msmCase(brainpool.P256r1(), 64, 521946),
+		treeHashCase(16<<20, 16),
+	)
+	return cases
+}
+
+type schemeInputs struct {
+	publicKey, privateKey, blind, blindedKey, signature []byte
+}
+
+var (
+	benchMessage = []byte("a message signed in the performance suite")
+	benchContext = []byte("perf")
+)
+
+func newSchemeInputs(s blinding.BlindableScheme, rand io.Reader) (*schemeInputs, error) {
+	in := new(schemeInputs)
+	var err error
+	if in.publicKey, in.privateKey, err = s.GenerateKey(rand); err != nil {
+		return nil, err
+	}
+	if in.blind, err = s.GenerateBlind(rand); err != nil {
+		return nil, err
+	}
+	if in.blindedKey, err = s.BlindPublicKey(in.publicKey, in.blind, benchContext); err != nil {
+		return nil, err
+	}
+	if in.signature, err = s.BlindKeySign(rand, in.privateKey, in.blind, benchMessage, benchContext); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+func schemeCases(name string, s blinding.BlindableScheme, budgets [4]int64) []Case {
+	return []Case{
+		{
+			Name:      name + "/BlindPublicKey",
+			MaxAllocs: budgets[0],
+			Setup: func(rand io.Reader) (func() error, error) {
+				in, err := newSchemeInputs(s, rand)
+				if err != nil {
+					return nil, err
+				}
+				return func() error {
+					_, err := s.BlindPublicKey(in.publicKey, in.blind, benchContext)
+					return err
+				}, nil
+			},
+		},
+		{
+			Name:      name + "/UnblindPublicKey",
+			MaxAllocs: budgets[1],
+			Setup: func(rand io.Reader) (func() error, error) {
+				in, err := newSchemeInputs(s, rand)
+				if err != nil {
+					return nil, err
+				}
+				return func() error {
+					_, err := s.UnblindPublicKey(in.blindedKey, in.blind, benchContext)
+					return err
+				}, nil
+			},
+		},
+		{
+			Name:      name + "/BlindKeySign",
+			MaxAllocs: budgets[2],
+			Setup: func(rand io.Reader) (func() error, error) {
+				in, err := newSchemeInputs(s, rand)
+				if err != nil {
+					return nil, err
+				}
+				return func() error {
+					_, err := s.BlindKeySign(rand, in.privateKey, in.blind, benchMessage, benchContext)
+					return err
+				}, nil
+			},
+		},
+		{
+			Name:      name + "/Verify",
+			MaxAllocs: budgets[3],
+			Setup: func(rand io.Reader) (func() error, error) {
+				in, err := newSchemeInputs(s, rand)
+				if err != nil {
+					return nil, err
+				}
+				return func() error {
+					if !s.Verify(in.blindedKey, benchMessage, in.signature) {
+						return errInvalidSignature
+					}
+					return nil
+				}, nil
+			},
+		},
+	}
+}
+
+func msmCase(c elliptic.Curve, n int, budget int64) Case {
+	return Case{
+		Name:      "ecdsa/MultiScalarMult/" + c.Params().Name,
+		MaxAllocs: budget,
+		Setup: func(rand io.Reader) (func() error, error) {
+			points := make([]*ecdsa.Point, n)
+			scalars := make([]*ecdsa.Scalar, n)
+			for i := range points {
+				k, err := ecdsa.GenerateKey(c, rand)
+				if err != nil {
+					return nil, err
+				}
+				if points[i], err = k.PublicKey.Point(); err != nil {
+					return nil, err
+				}
+				if scalars[i], err = k.Scalar(); err != nil {
+					return nil, err
+				}
+			}
+			return func() error {
+				_, err := ecdsa.MultiScalarMult(points, scalars)
+				return err
+			}, nil
+		},
+	}
+}
+
+func treeHashCase(size int, budget int64) Case {
+	return Case{
+		Name:      fmt.Sprintf("treehash/HashReaderAt/%dMiB", size>>20),
+		MaxAllocs: budget,
+		Setup: func(rand io.Reader) (func() error, error) {
+			data := make([]byte, size)
+			if _, err := io.ReadFull(rand, data); err != nil {
+				return nil, err
+			}
+			r := bytes.NewReader(data)
+			opts := &treehash.Options{ChunkSize: 64 << 10, Workers: 4}
+			return func() error {
+				_, err := treehash.HashReaderAt(r, int64(size), opts)
+				return err
+			}, nil
+		},
+	}
+}
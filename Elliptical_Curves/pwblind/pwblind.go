@@ -0,0 +1,178 @@
+// Package pwblind lets a user recover a key blind from a password with the
+// help of a server, so that a low-entropy secret can gate the ability to
+// blind and unblind keys.
+//
+// The blind is derived from the output of the verifiable oblivious PRF of
+// RFC 9497, implemented by the ecdsa/voprf package, evaluated on the
+// password, as in the first step of OPAQUE. The
+// server holds the PRF key and never learns the password or the blind, and
+// the client verifies every evaluation against the public key it pinned at
+// enrollment. Without the server, guessing the password requires guessing the
+// PRF key, so password guesses can only be made online and the server should
+// rate-limit Evaluate for each credential identifier.
+//
+// The server derives a distinct PRF key for each credential identifier from
+// a single seed, so that the outputs of different users are unrelated and the
+// server stores no per-user state.
+package pwblind
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/ecdsa/voprf"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// SeedSize is the size, in bytes, of the blind seeds returned by
+	// ClientState.Finalize, and the minimum size of server seeds.
+	SeedSize = 32
+	// BlindSize is the size, in bytes, of the blinds returned by Blind,
+	// which is the blind size of the ed25519, ristretto255 and bls packages.
+	BlindSize = 32
+
+	blindInfo = "pwblind blind"
+)
+
+// curve is the curve of the OPRF suite of the protocol, P256-SHA256.
+var curve = elliptic.P256()
+
+// Errors returned by this package.
+var (
+	// ErrInvalidSeed is returned when a server seed is too short.
+	ErrInvalidSeed = errors.New("pwblind: invalid server seed")
+
+	// ErrInvalidMessage is returned when a request or response is badly
+	// encoded, or a response doesn't verify under the server public key.
+	ErrInvalidMessage = errors.New("pwblind: invalid protocol message")
+)
+
+// Server evaluates the PRF for clients recovering their blinds.
+type Server struct {
+	seed []byte
+}
+
+// NewServer returns a Server whose PRF keys are derived from seed, which
+// must be at least SeedSize bytes long and kept secret. Losing the seed makes
+// every blind unrecoverable.
+func NewServer(seed []byte) (*Server, error) {
+	if len(seed) < SeedSize {
+		return nil, ErrInvalidSeed
+	}
+	return &Server{seed: append([]byte{}, seed...)}, nil
+}
+
+func (s *Server) key(credentialID []byte) (*voprf.PrivateKey, error) {
+	return voprf.DeriveKey(curve, s.seed, credentialID)
+}
+
+// PublicKey returns the public key of the PRF key of credentialID, which the
+// client pins at enrollment and passes to NewClient.
+func (s *Server) PublicKey(credentialID []byte) ([]byte, error) {
+	key, err := s.key(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey().BytesCompressed(), nil
+}
+
+// Evaluate answers the request of the client of credentialID, using entropy
+// from rand for the proof.
+func (s *Server) Evaluate(rand io.Reader, credentialID, request []byte) ([]byte, error) {
+	key, err := s.key(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	blinded, err := ecdsa.NewPoint(curve, request)
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	evaluated, proof, err := key.BlindEvaluate(rand, blinded)
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+
+	// The response is the compressed evaluated element followed by the
+	// proof, as in the issuance protocol of Privacy Pass.
+	return append(evaluated.BytesCompressed(), proof.Marshal()...), nil
+}
+
+// Client recovers the blind of one credential.
+type Client struct {
+	client *voprf.Client
+}
+
+// NewClient returns a Client for a credential whose server public key is
+// serverPublicKey, as returned by Server.PublicKey.
+func NewClient(serverPublicKey []byte) (*Client, error) {
+	pk, err := ecdsa.NewPoint(curve, serverPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	client, err := voprf.NewClient(pk)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client: client}, nil
+}
+
+// ClientState is the state of the client between its request and the
+// response of the server.
+type ClientState struct {
+	client   *Client
+	finalize *voprf.FinalizeData
+}
+
+// Request blinds password, using entropy from rand, and returns the request
+// to send to the server.
+func (c *Client) Request(rand io.Reader, password []byte) (*ClientState, []byte, error) {
+	finalize, blinded, err := c.client.Blind(rand, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ClientState{client: c, finalize: finalize}, blinded.BytesCompressed(), nil
+}
+
+// Finalize checks the response of the server and returns the blind seed of
+// the password, which is SeedSize bytes long. Pass it to Blind or ECDSABlind
+// to obtain the blind of a key blinding scheme.
+func (st *ClientState) Finalize(response []byte) ([]byte, error) {
+	size := 1 + (curve.Params().BitSize+7)/8
+	if len(response) < size {
+		return nil, ErrInvalidMessage
+	}
+	evaluated, err := ecdsa.NewPoint(curve, response[:size])
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	proof, err := voprf.UnmarshalProof(curve, response[size:])
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	output, err := st.client.client.Finalize(st.finalize, evaluated, proof)
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	return output, nil
+}
+
+// Blind derives from seed a blind for the ed25519, ristretto255 and bls
+// packages, or for the matching schemes of the blinding package.
+func Blind(seed []byte) []byte {
+	blind := make([]byte, BlindSize)
+	r := hkdf.Expand(sha256.New, seed, []byte(blindInfo))
+	if _, err := io.ReadFull(r, blind); err != nil {
+		panic("pwblind: internal error: " + err.Error())
+	}
+	return blind
+}
+
+// ECDSABlind derives from seed a blind key on the curve c for the ecdsa
+// package, with ecdsa.GenerateKeyFromSeed.
+func ECDSABlind(c elliptic.Curve, seed []byte) (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKeyFromSeed(c, seed)
+}
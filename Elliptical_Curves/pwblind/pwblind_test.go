@@ -0,0 +1,115 @@
+package pwblind
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+	"github.com/cloudflare/pat-go/ristretto255"
+)
+
+func recoverSeed(t *testing.T, server *Server, credentialID, password []byte) []byte {
+	pk, err := server.PublicKey(credentialID)
+	if err != nil {
+		t.Fatalf("PublicKey error: %s", err)
+	}
+	client, err := NewClient(pk)
+	if err != nil {
+		t.Fatalf("NewClient error: %s", err)
+	}
+	state, request, err := client.Request(rand.Reader, password)
+	if err != nil {
+		t.Fatalf("Request error: %s", err)
+	}
+	response, err := server.Evaluate(rand.Reader, credentialID, request)
+	if err != nil {
+		t.Fatalf("Evaluate error: %s", err)
+	}
+	seed, err := state.Finalize(response)
+	if err != nil {
+		t.Fatalf("Finalize error: %s", err)
+	}
+	if len(seed) != SeedSize {
+		t.Fatalf("seed of %d bytes, want %d", len(seed), SeedSize)
+	}
+	return seed
+}
+
+func TestRecoverBlind(t *testing.T) {
+	serverSeed := make([]byte, SeedSize)
+	rand.Read(serverSeed)
+	server, err := NewServer(serverSeed)
+	if err != nil {
+		t.Fatalf("NewServer error: %s", err)
+	}
+	alice, bob := []byte("alice"), []byte("bob")
+	password := []byte("correct horse battery staple")
+
+	seed := recoverSeed(t, server, alice, password)
+	if again := recoverSeed(t, server, alice, password); !bytes.Equal(seed, again) {
+		t.Fatalf("the same password recovered different seeds")
+	}
+	if other := recoverSeed(t, server, alice, []byte("wrong")); bytes.Equal(seed, other) {
+		t.Errorf("another password recovered the same seed")
+	}
+	if other := recoverSeed(t, server, bob, password); bytes.Equal(seed, other) {
+		t.Errorf("another credential recovered the same seed")
+	}
+
+	// The recovered blinds work with the key blinding schemes.
+	public, private, _ := ristretto255.GenerateKey(rand.Reader)
+	blind := Blind(seed)
+	blinded, err := ristretto255.BlindPublicKey(public, blind)
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	if !ristretto255.Verify(blinded, []byte("testing"), ristretto255.BlindKeySign(private, []byte("testing"), blind)) {
+		t.Errorf("signature under the recovered blind rejected")
+	}
+	skB, err := ECDSABlind(elliptic.P256(), seed)
+	if err != nil {
+		t.Fatalf("ECDSABlind error: %s", err)
+	}
+	skS, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	hashed := sha256.Sum256([]byte("testing"))
+	r, s, err := ecdsa.BlindKeySign(rand.Reader, skS, skB, hashed[:])
+	if err != nil {
+		t.Fatalf("BlindKeySign error: %s", err)
+	}
+	pkR, _ := ecdsa.BlindPublicKey(elliptic.P256(), &skS.PublicKey, skB)
+	if !ecdsa.Verify(pkR, hashed[:], r, s) {
+		t.Errorf("ECDSA signature under the recovered blind rejected")
+	}
+}
+
+func TestWrongServerKey(t *testing.T) {
+	serverSeed := make([]byte, SeedSize)
+	rand.Read(serverSeed)
+	server, _ := NewServer(serverSeed)
+	rand.Read(serverSeed)
+	impostor, _ := NewServer(serverSeed)
+
+	pk, _ := server.PublicKey([]byte("alice"))
+	client, _ := NewClient(pk)
+	state, request, _ := client.Request(rand.Reader, []byte("password"))
+	response, err := impostor.Evaluate(rand.Reader, []byte("alice"), request)
+	if err != nil {
+		t.Fatalf("Evaluate error: %s", err)
+	}
+	if _, err := state.Finalize(response); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Finalize of an impostor response: got %v, want ErrInvalidMessage", err)
+	}
+	if _, err := state.Finalize(response[:10]); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Finalize of a truncated response: got %v, want ErrInvalidMessage", err)
+	}
+	if _, err := server.Evaluate(rand.Reader, []byte("alice"), request[1:]); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Evaluate of a truncated request: got %v, want ErrInvalidMessage", err)
+	}
+	if _, err := NewServer(serverSeed[1:]); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("NewServer with a short seed: got %v, want ErrInvalidSeed", err)
+	}
+}
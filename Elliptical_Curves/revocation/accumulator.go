@@ -0,0 +1,416 @@
+package revocation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"github.com/cloudflare/pat-go/transparency"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const accumulatorDST = "RevocationAccumulator v1"
+
+var (
+	// ErrInvalidAccumulator is returned when a signed accumulator digest is
+	// badly encoded or its signature doesn't verify.
+	ErrInvalidAccumulator = errors.New("revocation: invalid accumulator")
+
+	// ErrInvalidWitness is returned when a witness is badly encoded or
+	// doesn't prove what it is checked for.
+	ErrInvalidWitness = errors.New("revocation: invalid witness")
+)
+
+// itemSize is the size of the leaves of an accumulator: a one-byte tag,
+// itemEpoch or itemKey, followed by a 32-byte value.
+const itemSize = 1 + sha256.Size
+
+const (
+	itemEpoch = 0
+	itemKey   = 1
+)
+
+type item [itemSize]byte
+
+func epochItem(epoch uint64) item {
+	var it item
+	it[0] = itemEpoch
+	binary.BigEndian.PutUint64(it[itemSize-8:], epoch)
+	return it
+}
+
+func keyItem(fp Fingerprint) item {
+	var it item
+	it[0] = itemKey
+	copy(it[1:], fp[:])
+	return it
+}
+
+func (it item) less(other item) bool {
+	return bytes.Compare(it[:], other[:]) < 0
+}
+
+// Accumulator is a Merkle tree over the revocations of a list, sorted so
+// that it can prove both that a key is revoked and that it isn't. Verifiers
+// only need its AccumulatorDigest, whose size doesn't depend on the number
+// of revocations.
+//
+// The leaves of the tree, hashed as in RFC 9162, are the revoked epochs,
+// encoded as a zero byte followed by the epoch as a 32-byte big-endian
+// integer, and the revoked key fingerprints, prefixed by a byte of one, in
+// increasing order.
+type Accumulator struct {
+	digest AccumulatorDigest
+	items  []item
+	log    *transparency.Log
+}
+
+// AccumulatorDigest is the digest of an Accumulator, which commits to all the
+// revocations of a list.
+type AccumulatorDigest struct {
+	Scheme string
+	// Number is the number of the list the accumulator was built from.
+	Number uint64
+	// Size is the number of leaves of the tree, and Root its root hash.
+	Size uint64
+	Root [sha256.Size]byte
+}
+
+// NewAccumulator returns the accumulator of the revocations of l.
+func NewAccumulator(l *List) (*Accumulator, error) {
+	if err := checkSorted(l.Epochs, l.Keys); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidList, err)
+	}
+	a := &Accumulator{log: transparency.NewLog()}
+	// Epoch items sort before key items, and both lists are sorted, so the
+	// items are sorted.
+	for _, e := range l.Epochs {
+		a.items = append(a.items, epochItem(e))
+	}
+	for _, k := range l.Keys {
+		a.items = append(a.items, keyItem(k))
+	}
+	for _, it := range a.items {
+		a.log.Append(it[:])
+	}
+	a.digest.Scheme = l.Scheme
+	a.digest.Number = l.Number
+	a.digest.Size, a.digest.Root = a.log.Root()
+	return a, nil
+}
+
+// Digest returns the digest of a.
+func (a *Accumulator) Digest() AccumulatorDigest {
+	return a.digest
+}
+
+// MembershipWitness proves that an item is a leaf of an accumulator.
+type MembershipWitness struct {
+	item  item
+	index uint64
+	path  [][sha256.Size]byte
+}
+
+func (a *Accumulator) membershipWitness(i int) *MembershipWitness {
+	path, _ := a.log.InclusionProof(uint64(i), a.digest.Size)
+	return &MembershipWitness{item: a.items[i], index: uint64(i), path: path}
+}
+
+func (d *AccumulatorDigest) verifyMembership(w *MembershipWitness) bool {
+	return transparency.VerifyInclusion(w.index, d.Size, transparency.LeafHash(w.item[:]), w.path, d.Root) == nil
+}
+
+// NonMembershipWitness proves that an item isn't a leaf of an accumulator,
+// with the membership witnesses of the consecutive leaves around it.
+type NonMembershipWitness struct {
+	// low is the largest leaf smaller than the item, and high the smallest
+	// leaf larger than it. Either is nil if there is no such leaf.
+	low, high *MembershipWitness
+}
+
+// nonMembershipWitness returns the witness that it is not in a, or false if
+// it is.
+func (a *Accumulator) nonMembershipWitness(it item) (*NonMembershipWitness, bool) {
+	i := sort.Search(len(a.items), func(i int) bool { return !a.items[i].less(it) })
+	if i < len(a.items) && a.items[i] == it {
+		return nil, false
+	}
+	w := new(NonMembershipWitness)
+	if i > 0 {
+		w.low = a.membershipWitness(i - 1)
+	}
+	if i < len(a.items) {
+		w.high = a.membershipWitness(i)
+	}
+	return w, true
+}
+
+func (d *AccumulatorDigest) verifyNonMembership(it item, w *NonMembershipWitness) bool {
+	switch {
+	case w.low == nil && w.high == nil:
+		return d.Size == 0
+	case w.low == nil:
+		if w.high.index != 0 {
+			return false
+		}
+	case w.high == nil:
+		if w.low.index != d.Size-1 {
+			return false
+		}
+	default:
+		if w.high.index != w.low.index+1 {
+			return false
+		}
+	}
+	if w.low != nil && (!w.low.item.less(it) || !d.verifyMembership(w.low)) {
+		return false
+	}
+	if w.high != nil && (!it.less(w.high.item) || !d.verifyMembership(w.high)) {
+		return false
+	}
+	return true
+}
+
+// NonRevocationProof proves that a key blinded for an epoch is revoked
+// neither by fingerprint nor by epoch.
+type NonRevocationProof struct {
+	Epoch, Key NonMembershipWitness
+}
+
+// ProveNotRevoked returns the proof that the blinded public key pk, blinded
+// for epoch, is not revoked by the list of a, or an error wrapping ErrRevoked
+// if it is.
+func (a *Accumulator) ProveNotRevoked(pk []byte, epoch uint64) (*NonRevocationProof, error) {
+	epochWitness, ok := a.nonMembershipWitness(epochItem(epoch))
+	if !ok {
+		return nil, fmt.Errorf("%w: epoch %d revoked", ErrRevoked, epoch)
+	}
+	keyWitness, ok := a.nonMembershipWitness(keyItem(KeyFingerprint(pk)))
+	if !ok {
+		return nil, fmt.Errorf("%w: key %x revoked", ErrRevoked, KeyFingerprint(pk))
+	}
+	return &NonRevocationProof{Epoch: *epochWitness, Key: *keyWitness}, nil
+}
+
+// VerifyNotRevoked checks that proof proves that the blinded public key pk,
+// blinded for epoch, is not revoked by the list of d. It returns an error
+// wrapping ErrInvalidWitness otherwise.
+func (d *AccumulatorDigest) VerifyNotRevoked(pk []byte, epoch uint64, proof *NonRevocationProof) error {
+	if !d.verifyNonMembership(epochItem(epoch), &proof.Epoch) {
+		return fmt.Errorf("%w: epoch %d not proven unrevoked", ErrInvalidWitness, epoch)
+	}
+	if !d.verifyNonMembership(keyItem(KeyFingerprint(pk)), &proof.Key) {
+		return fmt.Errorf("%w: key not proven unrevoked", ErrInvalidWitness)
+	}
+	return nil
+}
+
+// ProveRevoked returns the proof that the blinded public key pk, blinded for
+// epoch, is revoked by the list of a, by epoch if its epoch is revoked and by
+// fingerprint otherwise. It returns an error if pk is not revoked.
+func (a *Accumulator) ProveRevoked(pk []byte, epoch uint64) (*MembershipWitness, error) {
+	for _, it := range []item{epochItem(epoch), keyItem(KeyFingerprint(pk))} {
+		i := sort.Search(len(a.items), func(i int) bool { return !a.items[i].less(it) })
+		if i < len(a.items) && a.items[i] == it {
+			return a.membershipWitness(i), nil
+		}
+	}
+	return nil, errors.New("revocation: key not revoked")
+}
+
+// VerifyRevoked checks that w proves that the blinded public key pk, blinded
+// for epoch, is revoked by the list of d. It returns an error wrapping
+// ErrInvalidWitness otherwise.
+func (d *AccumulatorDigest) VerifyRevoked(pk []byte, epoch uint64, w *MembershipWitness) error {
+	if w.item != epochItem(epoch) && w.item != keyItem(KeyFingerprint(pk)) {
+		return fmt.Errorf("%w: witness for another key", ErrInvalidWitness)
+	}
+	if !d.verifyMembership(w) {
+		return fmt.Errorf("%w: bad inclusion proof", ErrInvalidWitness)
+	}
+	return nil
+}
+
+// body encodes d, without a signature.
+func (d *AccumulatorDigest) body() []byte {
+	var b cryptobyte.Builder
+	b.AddUint8(listVersion)
+	addString(&b, d.Scheme)
+	addUint64(&b, d.Number)
+	addUint64(&b, d.Size)
+	b.AddBytes(d.Root[:])
+	return b.BytesOrPanic()
+}
+
+// Marshal encodes d, signed by the base private key baseKey of the scheme s,
+// as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque scheme<1..2^8-1>;
+//	  uint64 number;
+//	  uint64 size;
+//	  opaque root[32];
+//	  opaque signature<1..2^16-1>;
+//	} SignedAccumulatorDigest;
+//
+// The encoding doesn't grow with the number of revocations.
+func (d *AccumulatorDigest) Marshal(rand io.Reader, s blinding.BlindableScheme, baseKey []byte) ([]byte, error) {
+	if d.Scheme != s.Name() || len(d.Scheme) > 255 {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidAccumulator, d.Scheme, s.Name())
+	}
+	return sign(rand, s, baseKey, accumulatorDST, d.body())
+}
+
+// UnmarshalAccumulatorDigest decodes a digest encoded by
+// AccumulatorDigest.Marshal, and checks that it was signed by the base public
+// key basePublicKey of the scheme s.
+func UnmarshalAccumulatorDigest(s blinding.BlindableScheme, basePublicKey, data []byte) (*AccumulatorDigest, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var scheme cryptobyte.String
+	var root []byte
+	d := new(AccumulatorDigest)
+	if !in.ReadUint8(&version) ||
+		!in.ReadUint8LengthPrefixed(&scheme) ||
+		!readUint64(&in, &d.Number) ||
+		!readUint64(&in, &d.Size) ||
+		!in.ReadBytes(&root, sha256.Size) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidAccumulator)
+	}
+	body := data[:len(data)-len(in)]
+	if err := verify(s, basePublicKey, accumulatorDST, body, in); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAccumulator, err)
+	}
+	if version != listVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidAccumulator, version)
+	}
+	d.Scheme = string(scheme)
+	if d.Scheme != s.Name() {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidAccumulator, d.Scheme, s.Name())
+	}
+	copy(d.Root[:], root)
+	return d, nil
+}
+
+// Marshal encodes w as:
+//
+//	struct {
+//	  opaque item[33];
+//	  uint64 index;
+//	  opaque path<0..2^16-1>;
+//	} MembershipWitness;
+//
+// where path is the concatenation of the hashes of the inclusion proof.
+func (w *MembershipWitness) Marshal() []byte {
+	var b cryptobyte.Builder
+	addMembershipWitness(&b, w)
+	return b.BytesOrPanic()
+}
+
+// UnmarshalMembershipWitness decodes a witness encoded by
+// MembershipWitness.Marshal.
+func UnmarshalMembershipWitness(data []byte) (*MembershipWitness, error) {
+	in := cryptobyte.String(data)
+	w, ok := readMembershipWitness(&in)
+	if !ok || !in.Empty() {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidWitness)
+	}
+	return w, nil
+}
+
+// Marshal encodes p as:
+//
+//	struct {
+//	  uint8 present;
+//	  MembershipWitness low;  /* if present & 1 */
+//	  MembershipWitness high; /* if present & 2 */
+//	} NonMembershipWitness;
+//
+//	struct {
+//	  NonMembershipWitness epoch;
+//	  NonMembershipWitness key;
+//	} NonRevocationProof;
+func (p *NonRevocationProof) Marshal() []byte {
+	var b cryptobyte.Builder
+	addNonMembershipWitness(&b, &p.Epoch)
+	addNonMembershipWitness(&b, &p.Key)
+	return b.BytesOrPanic()
+}
+
+// UnmarshalNonRevocationProof decodes a proof encoded by
+// NonRevocationProof.Marshal.
+func UnmarshalNonRevocationProof(data []byte) (*NonRevocationProof, error) {
+	in := cryptobyte.String(data)
+	p := new(NonRevocationProof)
+	if !readNonMembershipWitness(&in, &p.Epoch) || !readNonMembershipWitness(&in, &p.Key) || !in.Empty() {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidWitness)
+	}
+	return p, nil
+}
+
+func addMembershipWitness(b *cryptobyte.Builder, w *MembershipWitness) {
+	b.AddBytes(w.item[:])
+	addUint64(b, w.index)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, h := range w.path {
+			b.AddBytes(h[:])
+		}
+	})
+}
+
+func readMembershipWitness(s *cryptobyte.String) (*MembershipWitness, bool) {
+	w := new(MembershipWitness)
+	var path cryptobyte.String
+	if !s.CopyBytes(w.item[:]) || !readUint64(s, &w.index) ||
+		!s.ReadUint16LengthPrefixed(&path) || len(path)%sha256.Size != 0 {
+		return nil, false
+	}
+	for !path.Empty() {
+		var h [sha256.Size]byte
+		path.CopyBytes(h[:])
+		w.path = append(w.path, h)
+	}
+	return w, true
+}
+
+func addNonMembershipWitness(b *cryptobyte.Builder, w *NonMembershipWitness) {
+	var present uint8
+	if w.low != nil {
+		present |= 1
+	}
+	if w.high != nil {
+		present |= 2
+	}
+	b.AddUint8(present)
+	if w.low != nil {
+		addMembershipWitness(b, w.low)
+	}
+	if w.high != nil {
+		addMembershipWitness(b, w.high)
+	}
+}
+
+func readNonMembershipWitness(s *cryptobyte.String, w *NonMembershipWitness) bool {
+	var present uint8
+	if !s.ReadUint8(&present) || present > 3 {
+		return false
+	}
+	var ok bool
+	if present&1 != 0 {
+		if w.low, ok = readMembershipWitness(s); !ok {
+			return false
+		}
+	}
+	if present&2 != 0 {
+		if w.high, ok = readMembershipWitness(s); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package revocation
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+func TestAccumulator(t *testing.T) {
+	s := blinding.Ristretto255
+	pk, sk, _ := s.GenerateKey(rand.Reader)
+	var revoked [][]byte
+	for i := 0; i < 5; i++ {
+		revoked = append(revoked, blindedKey(t, s, pk))
+	}
+	valid := blindedKey(t, s, pk)
+	l := Create(s, 1, revoked, []uint64{2, 5})
+	a, err := NewAccumulator(l)
+	if err != nil {
+		t.Fatalf("NewAccumulator error: %s", err)
+	}
+	digest := a.Digest()
+	data, err := digest.Marshal(rand.Reader, s, sk)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	d, err := UnmarshalAccumulatorDigest(s, pk, data)
+	if err != nil {
+		t.Fatalf("UnmarshalAccumulatorDigest error: %s", err)
+	}
+	if *d != digest {
+		t.Fatalf("UnmarshalAccumulatorDigest = %+v, want %+v", d, digest)
+	}
+
+	// Epochs before, between and after the revoked ones.
+	for _, epoch := range []uint64{0, 3, 9} {
+		proof, err := a.ProveNotRevoked(valid, epoch)
+		if err != nil {
+			t.Fatalf("ProveNotRevoked error: %s", err)
+		}
+		proof, err = UnmarshalNonRevocationProof(proof.Marshal())
+		if err != nil {
+			t.Fatalf("UnmarshalNonRevocationProof error: %s", err)
+		}
+		if err := d.VerifyNotRevoked(valid, epoch, proof); err != nil {
+			t.Errorf("VerifyNotRevoked for epoch %d: %s", epoch, err)
+		}
+		if err := d.VerifyNotRevoked(revoked[0], epoch, proof); !errors.Is(err, ErrInvalidWitness) {
+			t.Errorf("VerifyNotRevoked of another key: got %v, want ErrInvalidWitness", err)
+		}
+		if err := d.VerifyNotRevoked(valid, 5, proof); !errors.Is(err, ErrInvalidWitness) {
+			t.Errorf("VerifyNotRevoked for a revoked epoch: got %v, want ErrInvalidWitness", err)
+		}
+	}
+	if _, err := a.ProveNotRevoked(revoked[3], 3); !errors.Is(err, ErrRevoked) {
+		t.Errorf("ProveNotRevoked of a revoked key: got %v, want ErrRevoked", err)
+	}
+	if _, err := a.ProveNotRevoked(valid, 2); !errors.Is(err, ErrRevoked) {
+		t.Errorf("ProveNotRevoked for a revoked epoch: got %v, want ErrRevoked", err)
+	}
+
+	for _, tc := range []struct {
+		pk    []byte
+		epoch uint64
+	}{{revoked[3], 3}, {valid, 5}} {
+		w, err := a.ProveRevoked(tc.pk, tc.epoch)
+		if err != nil {
+			t.Fatalf("ProveRevoked error: %s", err)
+		}
+		w, err = UnmarshalMembershipWitness(w.Marshal())
+		if err != nil {
+			t.Fatalf("UnmarshalMembershipWitness error: %s", err)
+		}
+		if err := d.VerifyRevoked(tc.pk, tc.epoch, w); err != nil {
+			t.Errorf("VerifyRevoked error: %s", err)
+		}
+	}
+	if _, err := a.ProveRevoked(valid, 3); err == nil {
+		t.Errorf("ProveRevoked of a valid key succeeded")
+	}
+
+	// A witness against another accumulator doesn't verify.
+	other, _ := NewAccumulator(Create(s, 2, revoked[:4], nil))
+	proof, _ := other.ProveNotRevoked(revoked[4], 2)
+	if err := d.VerifyNotRevoked(revoked[4], 2, proof); !errors.Is(err, ErrInvalidWitness) {
+		t.Errorf("VerifyNotRevoked with another accumulator's proof: got %v, want ErrInvalidWitness", err)
+	}
+}
+
+func TestEmptyAccumulator(t *testing.T) {
+	s := blinding.Ristretto255
+	pk, _, _ := s.GenerateKey(rand.Reader)
+	a, _ := NewAccumulator(Create(s, 1, nil, nil))
+	proof, err := a.ProveNotRevoked(pk, 1)
+	if err != nil {
+		t.Fatalf("ProveNotRevoked error: %s", err)
+	}
+	d := a.Digest()
+	if err := d.VerifyNotRevoked(pk, 1, proof); err != nil {
+		t.Errorf("VerifyNotRevoked error: %s", err)
+	}
+}
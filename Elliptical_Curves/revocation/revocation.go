@@ -0,0 +1,501 @@
+// Package revocation builds compact revocation lists for blinded keys: the
+// holder of a base key signs, with that base key, a list of the blinded keys
+// derived from it that were compromised, and of the epochs all of whose keys
+// must be rejected. Verifiers that know the base public key can then reject
+// the signatures of revoked blinds without contacting the issuer.
+//
+// Blinded keys are listed by their SHA-256 fingerprint, so a list only
+// reveals that a revoked key was derived from the base key, not the keys
+// that weren't revoked. Revocations are permanent: each list carries a
+// sequence number, and the list that follows it can be distributed as a
+// Delta that only carries the new revocations, and is signed with the digest
+// of the list it produces.
+//
+// Verifiers that can't hold the whole list can instead hold the signed
+// digest of its Accumulator, a sorted Merkle tree of its revocations, and
+// check the non-revocation proofs that signers attach to their signatures.
+package revocation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cloudflare/pat-go/blinding"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	listVersion = 1
+	listDST     = "RevocationList v1"
+	deltaDST    = "RevocationDelta v1"
+)
+
+var (
+	// ErrInvalidList is returned when a revocation list is badly encoded,
+	// isn't sorted, or its signature doesn't verify.
+	ErrInvalidList = errors.New("revocation: invalid list")
+
+	// ErrInvalidDelta is returned when a delta is badly encoded, its
+	// signature doesn't verify, or it doesn't apply to a list.
+	ErrInvalidDelta = errors.New("revocation: invalid delta")
+
+	// ErrRevoked is returned by List.Check for a revoked key.
+	ErrRevoked = errors.New("revocation: key revoked")
+)
+
+// Fingerprint is the SHA-256 hash of a blinded public key, which identifies
+// it in revocation lists.
+type Fingerprint [sha256.Size]byte
+
+// KeyFingerprint returns the fingerprint of the blinded public key pk.
+func KeyFingerprint(pk []byte) Fingerprint {
+	return sha256.Sum256(pk)
+}
+
+// List is a revocation list of the blinded keys of a base key.
+type List struct {
+	// Scheme is the name of the scheme of the keys.
+	Scheme string
+	// Number is the sequence number of the list, which increases with each
+	// list issued for the base key.
+	Number uint64
+	// Epochs holds the revoked epochs, in increasing order. All the keys
+	// blinded for these epochs are revoked.
+	Epochs []uint64
+	// Keys holds the fingerprints of the revoked keys, in increasing order.
+	Keys []Fingerprint
+}
+
+// Create returns the revocation list number of the scheme s revoking the
+// blinded public keys keys and all keys of epochs.
+func Create(s blinding.BlindableScheme, number uint64, keys [][]byte, epochs []uint64) *List {
+	l := &List{Scheme: s.Name(), Number: number}
+	for _, pk := range keys {
+		l.Keys = append(l.Keys, KeyFingerprint(pk))
+	}
+	l.Epochs = append(l.Epochs, epochs...)
+	l.normalize()
+	return l
+}
+
+// normalize sorts the revocations of l and removes duplicates.
+func (l *List) normalize() {
+	sort.Slice(l.Epochs, func(i, j int) bool { return l.Epochs[i] < l.Epochs[j] })
+	sort.Slice(l.Keys, func(i, j int) bool { return bytes.Compare(l.Keys[i][:], l.Keys[j][:]) < 0 })
+	epochs := l.Epochs[:0]
+	for i, e := range l.Epochs {
+		if i == 0 || e != l.Epochs[i-1] {
+			epochs = append(epochs, e)
+		}
+	}
+	l.Epochs = epochs
+	keys := l.Keys[:0]
+	for i, k := range l.Keys {
+		if i == 0 || k != l.Keys[i-1] {
+			keys = append(keys, k)
+		}
+	}
+	l.Keys = keys
+}
+
+// checkSorted checks that epochs and keys are strictly increasing.
+func checkSorted(epochs []uint64, keys []Fingerprint) error {
+	for i := 1; i < len(epochs); i++ {
+		if epochs[i-1] >= epochs[i] {
+			return fmt.Errorf("duplicate or unsorted epoch %d", epochs[i])
+		}
+	}
+	for i := 1; i < len(keys); i++ {
+		if bytes.Compare(keys[i-1][:], keys[i][:]) >= 0 {
+			return fmt.Errorf("duplicate or unsorted key %x", keys[i])
+		}
+	}
+	return nil
+}
+
+// EpochRevoked reports whether all the keys of epoch are revoked.
+func (l *List) EpochRevoked(epoch uint64) bool {
+	i := sort.Search(len(l.Epochs), func(i int) bool { return l.Epochs[i] >= epoch })
+	return i < len(l.Epochs) && l.Epochs[i] == epoch
+}
+
+// KeyRevoked reports whether the blinded public key pk is revoked by
+// fingerprint. It doesn't check the epoch of pk.
+func (l *List) KeyRevoked(pk []byte) bool {
+	return l.hasKey(KeyFingerprint(pk))
+}
+
+// Check returns an error wrapping ErrRevoked if the blinded public key pk,
+// blinded for epoch, is revoked by l.
+func (l *List) Check(pk []byte, epoch uint64) error {
+	if l.EpochRevoked(epoch) {
+		return fmt.Errorf("%w: epoch %d revoked", ErrRevoked, epoch)
+	}
+	if l.KeyRevoked(pk) {
+		return fmt.Errorf("%w: key %x revoked", ErrRevoked, KeyFingerprint(pk))
+	}
+	return nil
+}
+
+// body encodes l, without a signature.
+func (l *List) body() ([]byte, error) {
+	if err := checkSorted(l.Epochs, l.Keys); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidList, err)
+	}
+	var b cryptobyte.Builder
+	b.AddUint8(listVersion)
+	addString(&b, l.Scheme)
+	addUint64(&b, l.Number)
+	addRevocations(&b, l.Epochs, l.Keys)
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: scheme name or revocations too long", ErrInvalidList)
+	}
+	return body, nil
+}
+
+// Digest returns the SHA-256 hash of the encoding of l without its
+// signature, which identifies l in deltas.
+func (l *List) Digest() ([sha256.Size]byte, error) {
+	body, err := l.body()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(body), nil
+}
+
+// Marshal encodes l, signed by the base private key baseKey of the scheme s,
+// as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque scheme<1..2^8-1>;
+//	  uint64 number;
+//	  uint64 epochs<0..2^24-1>;
+//	  opaque keys<0..2^24-1>;
+//	  opaque signature<1..2^16-1>;
+//	} SignedRevocationList;
+//
+// where epochs are in increasing order, and keys is the concatenation of the
+// 32-byte fingerprints of the revoked keys, in increasing order. The
+// signature covers all the preceding fields and the name of the scheme.
+func (l *List) Marshal(rand io.Reader, s blinding.BlindableScheme, baseKey []byte) ([]byte, error) {
+	if l.Scheme != s.Name() {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidList, l.Scheme, s.Name())
+	}
+	body, err := l.body()
+	if err != nil {
+		return nil, err
+	}
+	return sign(rand, s, baseKey, listDST, body)
+}
+
+// Unmarshal decodes a revocation list encoded by List.Marshal, and checks
+// that it was signed by the base public key basePublicKey of the scheme s.
+func Unmarshal(s blinding.BlindableScheme, basePublicKey, data []byte) (*List, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var scheme cryptobyte.String
+	l := new(List)
+	if !in.ReadUint8(&version) ||
+		!in.ReadUint8LengthPrefixed(&scheme) ||
+		!readUint64(&in, &l.Number) ||
+		!readRevocations(&in, &l.Epochs, &l.Keys) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidList)
+	}
+	body := data[:len(data)-len(in)]
+	if err := verify(s, basePublicKey, listDST, body, in); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidList, err)
+	}
+	if version != listVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidList, version)
+	}
+	l.Scheme = string(scheme)
+	if l.Scheme != s.Name() {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidList, l.Scheme, s.Name())
+	}
+	if err := checkSorted(l.Epochs, l.Keys); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidList, err)
+	}
+	return l, nil
+}
+
+// Delta turns a revocation list into a later one, which revokes more keys or
+// epochs.
+type Delta struct {
+	Scheme string
+	// BaseNumber and BaseDigest identify the list the delta applies to.
+	BaseNumber uint64
+	BaseDigest [sha256.Size]byte
+	// Number and Digest identify the list the delta produces.
+	Number uint64
+	Digest [sha256.Size]byte
+	// Epochs and Keys hold the revocations of the new list missing from
+	// the base one, in increasing order.
+	Epochs []uint64
+	Keys   []Fingerprint
+}
+
+// Diff returns the delta from the list old to the list new, which must have a
+// higher number and hold all the revocations of old.
+func Diff(old, new *List) (*Delta, error) {
+	if old.Scheme != new.Scheme {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidDelta, new.Scheme, old.Scheme)
+	}
+	if new.Number <= old.Number {
+		return nil, fmt.Errorf("%w: list %d does not follow list %d", ErrInvalidDelta, new.Number, old.Number)
+	}
+	baseDigest, err := old.Digest()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := new.Digest()
+	if err != nil {
+		return nil, err
+	}
+	d := &Delta{
+		Scheme:     new.Scheme,
+		BaseNumber: old.Number,
+		BaseDigest: baseDigest,
+		Number:     new.Number,
+		Digest:     digest,
+	}
+	for _, e := range old.Epochs {
+		if !new.EpochRevoked(e) {
+			return nil, fmt.Errorf("%w: epoch %d no longer revoked", ErrInvalidDelta, e)
+		}
+	}
+	for _, k := range old.Keys {
+		if !new.hasKey(k) {
+			return nil, fmt.Errorf("%w: key %x no longer revoked", ErrInvalidDelta, k)
+		}
+	}
+	for _, e := range new.Epochs {
+		if !old.EpochRevoked(e) {
+			d.Epochs = append(d.Epochs, e)
+		}
+	}
+	for _, k := range new.Keys {
+		if !old.hasKey(k) {
+			d.Keys = append(d.Keys, k)
+		}
+	}
+	return d, nil
+}
+
+func (l *List) hasKey(fp Fingerprint) bool {
+	i := sort.Search(len(l.Keys), func(i int) bool { return bytes.Compare(l.Keys[i][:], fp[:]) >= 0 })
+	return i < len(l.Keys) && l.Keys[i] == fp
+}
+
+// Apply returns the list produced by applying d to l, or an error wrapping
+// ErrInvalidDelta if d does not apply to l or does not produce the list it
+// was signed for. l is not modified.
+func (l *List) Apply(d *Delta) (*List, error) {
+	baseDigest, err := l.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if l.Scheme != d.Scheme || l.Number != d.BaseNumber || baseDigest != d.BaseDigest {
+		return nil, fmt.Errorf("%w: delta does not apply to list %d", ErrInvalidDelta, l.Number)
+	}
+	if d.Number <= l.Number {
+		return nil, fmt.Errorf("%w: list %d does not follow list %d", ErrInvalidDelta, d.Number, l.Number)
+	}
+	next := &List{
+		Scheme: l.Scheme,
+		Number: d.Number,
+		Epochs: append(append([]uint64{}, l.Epochs...), d.Epochs...),
+		Keys:   append(append([]Fingerprint{}, l.Keys...), d.Keys...),
+	}
+	next.normalize()
+	digest, err := next.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if digest != d.Digest {
+		return nil, fmt.Errorf("%w: digest of list %d does not match", ErrInvalidDelta, d.Number)
+	}
+	return next, nil
+}
+
+// body encodes d, without a signature.
+func (d *Delta) body() ([]byte, error) {
+	if err := checkSorted(d.Epochs, d.Keys); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDelta, err)
+	}
+	var b cryptobyte.Builder
+	b.AddUint8(listVersion)
+	addString(&b, d.Scheme)
+	addUint64(&b, d.BaseNumber)
+	b.AddBytes(d.BaseDigest[:])
+	addUint64(&b, d.Number)
+	b.AddBytes(d.Digest[:])
+	addRevocations(&b, d.Epochs, d.Keys)
+	body, err := b.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: scheme name or revocations too long", ErrInvalidDelta)
+	}
+	return body, nil
+}
+
+// Marshal encodes d, signed by the base private key baseKey of the scheme s,
+// as:
+//
+//	struct {
+//	  uint8 version = 1;
+//	  opaque scheme<1..2^8-1>;
+//	  uint64 base_number;
+//	  opaque base_digest[32];
+//	  uint64 number;
+//	  opaque digest[32];
+//	  uint64 epochs<0..2^24-1>;
+//	  opaque keys<0..2^24-1>;
+//	  opaque signature<1..2^16-1>;
+//	} SignedRevocationDelta;
+//
+// where epochs and keys are encoded as in List.Marshal. As the signature
+// covers the digest of the list the delta produces, a list obtained with
+// Apply is as authentic as a signed list.
+func (d *Delta) Marshal(rand io.Reader, s blinding.BlindableScheme, baseKey []byte) ([]byte, error) {
+	if d.Scheme != s.Name() {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidDelta, d.Scheme, s.Name())
+	}
+	body, err := d.body()
+	if err != nil {
+		return nil, err
+	}
+	return sign(rand, s, baseKey, deltaDST, body)
+}
+
+// UnmarshalDelta decodes a delta encoded by Delta.Marshal, and checks that it
+// was signed by the base public key basePublicKey of the scheme s.
+func UnmarshalDelta(s blinding.BlindableScheme, basePublicKey, data []byte) (*Delta, error) {
+	in := cryptobyte.String(data)
+	var version uint8
+	var scheme cryptobyte.String
+	var baseDigest, digest []byte
+	d := new(Delta)
+	if !in.ReadUint8(&version) ||
+		!in.ReadUint8LengthPrefixed(&scheme) ||
+		!readUint64(&in, &d.BaseNumber) ||
+		!in.ReadBytes(&baseDigest, sha256.Size) ||
+		!readUint64(&in, &d.Number) ||
+		!in.ReadBytes(&digest, sha256.Size) ||
+		!readRevocations(&in, &d.Epochs, &d.Keys) {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidDelta)
+	}
+	body := data[:len(data)-len(in)]
+	if err := verify(s, basePublicKey, deltaDST, body, in); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDelta, err)
+	}
+	if version != listVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidDelta, version)
+	}
+	d.Scheme = string(scheme)
+	if d.Scheme != s.Name() {
+		return nil, fmt.Errorf("%w: scheme %s does not match %s", ErrInvalidDelta, d.Scheme, s.Name())
+	}
+	copy(d.BaseDigest[:], baseDigest)
+	copy(d.Digest[:], digest)
+	if err := checkSorted(d.Epochs, d.Keys); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDelta, err)
+	}
+	return d, nil
+}
+
+func addString(b *cryptobyte.Builder, s string) {
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(s))
+	})
+}
+
+func addUint64(b *cryptobyte.Builder, v uint64) {
+	var u64 [8]byte
+	binary.BigEndian.PutUint64(u64[:], v)
+	b.AddBytes(u64[:])
+}
+
+func readUint64(s *cryptobyte.String, out *uint64) bool {
+	var v []byte
+	if !s.ReadBytes(&v, 8) {
+		return false
+	}
+	*out = binary.BigEndian.Uint64(v)
+	return true
+}
+
+func addRevocations(b *cryptobyte.Builder, epochs []uint64, keys []Fingerprint) {
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, e := range epochs {
+			addUint64(b, e)
+		}
+	})
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, k := range keys {
+			b.AddBytes(k[:])
+		}
+	})
+}
+
+func readRevocations(s *cryptobyte.String, epochs *[]uint64, keys *[]Fingerprint) bool {
+	var es, ks cryptobyte.String
+	if !s.ReadUint24LengthPrefixed(&es) || !s.ReadUint24LengthPrefixed(&ks) ||
+		len(es)%8 != 0 || len(ks)%sha256.Size != 0 {
+		return false
+	}
+	for !es.Empty() {
+		var e uint64
+		readUint64(&es, &e)
+		*epochs = append(*epochs, e)
+	}
+	for !ks.Empty() {
+		var k Fingerprint
+		ks.CopyBytes(k[:])
+		*keys = append(*keys, k)
+	}
+	return true
+}
+
+// signedMessage returns the message signed by the base key: the body
+// prefixed by a domain separation tag and the name of the scheme, each
+// prefixed by its 1-byte length.
+func signedMessage(s blinding.BlindableScheme, dst string, body []byte) []byte {
+	var b cryptobyte.Builder
+	addString(&b, dst)
+	addString(&b, s.Name())
+	b.AddBytes(body)
+	return b.BytesOrPanic()
+}
+
+// sign appends to body its signature by baseKey.
+func sign(rand io.Reader, s blinding.BlindableScheme, baseKey []byte, dst string, body []byte) ([]byte, error) {
+	sig, err := s.Sign(rand, baseKey, signedMessage(s, dst, body))
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(body)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sig)
+	})
+	return b.Bytes()
+}
+
+// verify checks that rest holds only the signature of body by
+// basePublicKey.
+func verify(s blinding.BlindableScheme, basePublicKey []byte, dst string, body []byte, rest cryptobyte.String) error {
+	var sig cryptobyte.String
+	if !rest.ReadUint16LengthPrefixed(&sig) || !rest.Empty() {
+		return errors.New("malformed encoding")
+	}
+	if !s.Verify(basePublicKey, signedMessage(s, dst, body), sig) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package revocation
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+func blindedKey(t *testing.T, s blinding.BlindableScheme, pk []byte) []byte {
+	blind, _ := s.GenerateBlind(rand.Reader)
+	pkR, err := s.BlindPublicKey(pk, blind, []byte("epoch"))
+	if err != nil {
+		t.Fatalf("BlindPublicKey error: %s", err)
+	}
+	return pkR
+}
+
+func TestList(t *testing.T) {
+	for _, s := range []blinding.BlindableScheme{
+		blinding.Ristretto255,
+		blinding.Ed25519,
+		blinding.ECDSA(elliptic.P256(), crypto.SHA256),
+	} {
+		t.Run(s.Name(), func(t *testing.T) {
+			testList(t, s)
+		})
+	}
+}
+
+func testList(t *testing.T, s blinding.BlindableScheme) {
+	pk, sk, _ := s.GenerateKey(rand.Reader)
+	revoked, valid := blindedKey(t, s, pk), blindedKey(t, s, pk)
+	l := Create(s, 1, [][]byte{revoked, revoked}, []uint64{9, 4, 9})
+	if len(l.Keys) != 1 || len(l.Epochs) != 2 || l.Epochs[0] != 4 {
+		t.Fatalf("Create returned %d keys and epochs %v, want 1 key and epochs [4 9]", len(l.Keys), l.Epochs)
+	}
+	data, err := l.Marshal(rand.Reader, s, sk)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	got, err := Unmarshal(s, pk, data)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+
+	if err := got.Check(valid, 5); err != nil {
+		t.Errorf("Check of a valid key: %s", err)
+	}
+	if err := got.Check(revoked, 5); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Check of a revoked key: got %v, want ErrRevoked", err)
+	}
+	if err := got.Check(valid, 9); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Check of a key of a revoked epoch: got %v, want ErrRevoked", err)
+	}
+
+	other, _, _ := s.GenerateKey(rand.Reader)
+	if _, err := Unmarshal(s, other, data); !errors.Is(err, ErrInvalidList) {
+		t.Errorf("Unmarshal with another base key: got %v, want ErrInvalidList", err)
+	}
+	data[len(data)-1] ^= 1
+	if _, err := Unmarshal(s, pk, data); !errors.Is(err, ErrInvalidList) {
+		t.Errorf("corrupted signature: got %v, want ErrInvalidList", err)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	s := blinding.Ristretto255
+	pk, sk, _ := s.GenerateKey(rand.Reader)
+	k1, k2, k3 := blindedKey(t, s, pk), blindedKey(t, s, pk), blindedKey(t, s, pk)
+	old := Create(s, 1, [][]byte{k1}, []uint64{3})
+	next := Create(s, 2, [][]byte{k1, k2, k3}, []uint64{3, 7})
+
+	d, err := Diff(old, next)
+	if err != nil {
+		t.Fatalf("Diff error: %s", err)
+	}
+	if len(d.Keys) != 2 || len(d.Epochs) != 1 {
+		t.Errorf("got %d keys and %d epochs, want 2 and 1", len(d.Keys), len(d.Epochs))
+	}
+	data, err := d.Marshal(rand.Reader, s, sk)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	d, err = UnmarshalDelta(s, pk, data)
+	if err != nil {
+		t.Fatalf("UnmarshalDelta error: %s", err)
+	}
+	applied, err := old.Apply(d)
+	if err != nil {
+		t.Fatalf("Apply error: %s", err)
+	}
+	want, _ := next.Digest()
+	if got, _ := applied.Digest(); got != want {
+		t.Errorf("applied delta does not produce the new list")
+	}
+	if err := applied.Check(k3, 1); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Check of a key revoked by the delta: got %v, want ErrRevoked", err)
+	}
+
+	if _, err := applied.Apply(d); !errors.Is(err, ErrInvalidDelta) {
+		t.Errorf("delta applied twice: got %v, want ErrInvalidDelta", err)
+	}
+	d.Epochs = []uint64{8}
+	if _, err := old.Apply(d); !errors.Is(err, ErrInvalidDelta) {
+		t.Errorf("tampered delta: got %v, want ErrInvalidDelta", err)
+	}
+
+	// Revocations can't be undone, and lists can't go back.
+	if _, err := Diff(next, Create(s, 3, [][]byte{k1}, []uint64{3, 7})); !errors.Is(err, ErrInvalidDelta) {
+		t.Errorf("Diff dropping a revocation: got %v, want ErrInvalidDelta", err)
+	}
+	if _, err := Diff(next, old); !errors.Is(err, ErrInvalidDelta) {
+		t.Errorf("Diff to an older list: got %v, want ErrInvalidDelta", err)
+	}
+}
+
+func TestUnsortedList(t *testing.T) {
+	s := blinding.Ristretto255
+	_, sk, _ := s.GenerateKey(rand.Reader)
+	l := &List{Scheme: s.Name(), Number: 1, Epochs: []uint64{2, 1}}
+	if _, err := l.Marshal(rand.Reader, s, sk); !errors.Is(err, ErrInvalidList) {
+		t.Errorf("unsorted epochs: got %v, want ErrInvalidList", err)
+	}
+}
@@ -0,0 +1,137 @@
+package ristretto255
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+
+	"filippo.io/edwards25519"
+)
+
+const aggregateDST = "ristretto255 half-aggregation"
+
+var errAggregateInput = errors.New("ristretto255: invalid aggregation input")
+
+// AggregateSize returns the size, in bytes, of the aggregate of n signatures.
+func AggregateSize(n int) int {
+	return 32*n + 32
+}
+
+// aggregateCoefficients returns the coefficients z_i that randomize the sum
+// of the S values of the signatures. Each one commits to every public key,
+// message and R value of the aggregate, so that signatures can't be combined
+// with ones they weren't aggregated with, nor reordered.
+func aggregateCoefficients(publicKeys []PublicKey, messages [][]byte, Rs [][]byte) []*edwards25519.Scalar {
+	var buf [8]byte
+	th := sha512.New()
+	th.Write([]byte(aggregateDST))
+	binary.BigEndian.PutUint64(buf[:], uint64(len(publicKeys)))
+	th.Write(buf[:])
+	for i := range publicKeys {
+		th.Write(Rs[i])
+		th.Write(publicKeys[i])
+		binary.BigEndian.PutUint64(buf[:], uint64(len(messages[i])))
+		th.Write(buf[:])
+		th.Write(messages[i])
+	}
+	transcript := th.Sum(nil)
+
+	z := make([]*edwards25519.Scalar, len(publicKeys))
+	for i := range z {
+		zh := sha512.New()
+		zh.Write([]byte(aggregateDST))
+		zh.Write(transcript)
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		zh.Write(buf[:])
+		var err error
+		z[i], err = edwards25519.NewScalar().SetUniformBytes(zh.Sum(nil))
+		if err != nil {
+			panic("ristretto255: internal error: " + err.Error())
+		}
+	}
+	return z
+}
+
+// AggregateSignatures half-aggregates the signatures sigs of messages by
+// publicKeys, which may be the same key or blinded keys of the same signer,
+// into a single aggregate of AggregateSize(len(sigs)) bytes instead of
+// SignatureSize bytes per signature. The aggregate is verified with
+// VerifyAggregate and the same keys and messages, in the same order.
+//
+// The aggregate is the R values of the signatures followed by the sum of
+// their S values, each multiplied by a coefficient derived from all the
+// inputs, as in the half-aggregation scheme of Chalkias et al. (ePrint
+// 2021/350). The signatures are not verified, and an aggregate including an
+// invalid signature fails to verify.
+func AggregateSignatures(publicKeys []PublicKey, messages [][]byte, sigs [][]byte) ([]byte, error) {
+	if len(publicKeys) == 0 || len(publicKeys) != len(messages) || len(publicKeys) != len(sigs) {
+		return nil, errAggregateInput
+	}
+	Rs := make([][]byte, len(sigs))
+	for i, sig := range sigs {
+		if len(publicKeys[i]) != PublicKeySize || len(sig) != SignatureSize {
+			return nil, errAggregateInput
+		}
+		Rs[i] = sig[:32]
+	}
+
+	z := aggregateCoefficients(publicKeys, messages, Rs)
+	S := edwards25519.NewScalar()
+	aggregate := make([]byte, 0, AggregateSize(len(sigs)))
+	for i, sig := range sigs {
+		s, err := edwards25519.NewScalar().SetCanonicalBytes(sig[32:])
+		if err != nil {
+			return nil, errAggregateInput
+		}
+		S.MultiplyAdd(z[i], s, S)
+		aggregate = append(aggregate, sig[:32]...)
+	}
+	return append(aggregate, S.Bytes()...), nil
+}
+
+// VerifyAggregate reports whether aggregate is a valid aggregate of
+// signatures of messages by publicKeys, as produced by AggregateSignatures.
+// It verifies all the signatures at once with a single multi-scalar
+// multiplication.
+func VerifyAggregate(publicKeys []PublicKey, messages [][]byte, aggregate []byte) bool {
+	n := len(publicKeys)
+	if n == 0 || len(messages) != n || len(aggregate) != AggregateSize(n) {
+		return false
+	}
+	S, err := edwards25519.NewScalar().SetCanonicalBytes(aggregate[32*n:])
+	if err != nil {
+		return false
+	}
+
+	Rs := make([][]byte, n)
+	for i := range Rs {
+		Rs[i] = aggregate[32*i : 32*i+32]
+	}
+	z := aggregateCoefficients(publicKeys, messages, Rs)
+
+	// [S]B = sum [z_i](R_i + [k_i]A_i)
+	//   --> sum [z_i]R_i + sum [z_i*k_i]A_i + [-S]B = 0
+	scalars := make([]*edwards25519.Scalar, 0, 2*n+1)
+	elements := make([]*Element, 0, 2*n+1)
+	for i, publicKey := range publicKeys {
+		if len(publicKey) != PublicKeySize {
+			return false
+		}
+		A, err := NewElement().SetCanonicalBytes(publicKey)
+		if err != nil {
+			return false
+		}
+		R, err := NewElement().SetCanonicalBytes(Rs[i])
+		if err != nil {
+			return false
+		}
+		k := challenge(Rs[i], publicKey, messages[i])
+		scalars = append(scalars, z[i], edwards25519.NewScalar().Multiply(z[i], k))
+		elements = append(elements, R, A)
+	}
+	scalars = append(scalars, edwards25519.NewScalar().Negate(S))
+	elements = append(elements, NewGeneratorElement())
+
+	sum := NewElement().VarTimeMultiScalarMult(scalars, elements)
+	return sum.Equal(NewElement()) == 1
+}
@@ -0,0 +1,106 @@
+package ristretto255
+
+import (
+	"crypto/rand"
+	"fmt"
+	"testing"
+)
+
+func aggregateInputs(t testing.TB, n int) ([]PublicKey, [][]byte, [][]byte) {
+	public, private, _ := GenerateKey(rand.Reader)
+	blind := make([]byte, BlindSize)
+	rand.Read(blind)
+	blindedPublic, err := BlindPublicKeyWithContext(public, blind, []byte("epoch"))
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+
+	publicKeys := make([]PublicKey, n)
+	messages := make([][]byte, n)
+	sigs := make([][]byte, n)
+	for i := range sigs {
+		messages[i] = []byte(fmt.Sprintf("message %d", i))
+		if i%2 == 0 {
+			publicKeys[i] = public
+			sigs[i] = Sign(private, messages[i])
+		} else {
+			publicKeys[i] = blindedPublic
+			sigs[i] = BlindKeySignWithContext(private, messages[i], blind, []byte("epoch"))
+		}
+	}
+	return publicKeys, messages, sigs
+}
+
+func TestAggregateSignatures(t *testing.T) {
+	for _, n := range []int{1, 2, 17} {
+		publicKeys, messages, sigs := aggregateInputs(t, n)
+		aggregate, err := AggregateSignatures(publicKeys, messages, sigs)
+		if err != nil {
+			t.Fatalf("AggregateSignatures error: %s", err)
+		}
+		if len(aggregate) != AggregateSize(n) {
+			t.Fatalf("aggregate size %d, want %d", len(aggregate), AggregateSize(n))
+		}
+		if !VerifyAggregate(publicKeys, messages, aggregate) {
+			t.Fatalf("valid aggregate of %d signatures rejected", n)
+		}
+
+		wrong := append([][]byte{}, messages...)
+		wrong[n-1] = []byte("wrong message")
+		if VerifyAggregate(publicKeys, wrong, aggregate) {
+			t.Errorf("aggregate accepted with a different message")
+		}
+		for i := range aggregate {
+			tampered := append([]byte{}, aggregate...)
+			tampered[i] ^= 0x01
+			if VerifyAggregate(publicKeys, messages, tampered) {
+				t.Fatalf("aggregate accepted with byte %d tampered", i)
+			}
+		}
+		if VerifyAggregate(publicKeys[:n-1], messages[:n-1], aggregate[:len(aggregate)-32]) {
+			t.Errorf("truncated aggregate accepted")
+		}
+	}
+}
+
+func TestAggregateSignaturesOrder(t *testing.T) {
+	publicKeys, messages, sigs := aggregateInputs(t, 2)
+	aggregate, _ := AggregateSignatures(publicKeys, messages, sigs)
+
+	// Swapping the inputs along with the R values of the aggregate changes
+	// the coefficients, so the aggregate no longer verifies.
+	swapped := append(append(append([]byte{}, aggregate[32:64]...), aggregate[:32]...), aggregate[64:]...)
+	if VerifyAggregate([]PublicKey{publicKeys[1], publicKeys[0]}, [][]byte{messages[1], messages[0]}, swapped) {
+		t.Errorf("reordered aggregate accepted")
+	}
+}
+
+func TestAggregateInvalidSignature(t *testing.T) {
+	publicKeys, messages, sigs := aggregateInputs(t, 4)
+	sigs[2] = Sign(NewKeyFromSeed(make([]byte, SeedSize)), messages[2])
+	aggregate, err := AggregateSignatures(publicKeys, messages, sigs)
+	if err != nil {
+		t.Fatalf("AggregateSignatures error: %s", err)
+	}
+	if VerifyAggregate(publicKeys, messages, aggregate) {
+		t.Errorf("aggregate including an invalid signature accepted")
+	}
+
+	if _, err := AggregateSignatures(publicKeys, messages[:3], sigs); err == nil {
+		t.Errorf("AggregateSignatures accepted mismatched inputs")
+	}
+	if _, err := AggregateSignatures(nil, nil, nil); err == nil {
+		t.Errorf("AggregateSignatures accepted no signatures")
+	}
+}
+
+func BenchmarkVerifyAggregate(b *testing.B) {
+	publicKeys, messages, sigs := aggregateInputs(b, 64)
+	aggregate, _ := AggregateSignatures(publicKeys, messages, sigs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyAggregate(publicKeys, messages, aggregate) {
+			b.Fatal("verification failed")
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package ristretto255
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"filippo.io/edwards25519"
+	"filippo.io/edwards25519/field"
+)
+
+// Constants from RFC 9496, Section 4.1.
+var (
+	d = fieldElementFromDecimal(
+		"37095705934669439343138083508754565189542113879843219016388785533085940283555")
+	sqrtM1 = fieldElementFromDecimal(
+		"19681161376707505956807079304988542015446066515923890162744021073123829784752")
+	sqrtADMinusOne = fieldElementFromDecimal(
+		"25063068953384623474111414158702152701244531502492656460079210482610430750235")
+	invSqrtAMinusD = fieldElementFromDecimal(
+		"54469307008909316920995813868745141605393597292927456921205312896311721017578")
+	oneMinusDSQ = fieldElementFromDecimal(
+		"1159843021668779879193775521855586647937357759715417654439879720876111806838")
+	dMinusOneSQ = fieldElementFromDecimal(
+		"40440834346308536858101042469323190826248399146238708352240133220865137265952")
+
+	one = new(field.Element).One()
+)
+
+func fieldElementFromDecimal(s string) *field.Element {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("ristretto255: not a valid decimal string")
+	}
+	b := n.FillBytes(make([]byte, 32))
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	fe, err := new(field.Element).SetBytes(b)
+	if err != nil {
+		panic("ristretto255: " + err.Error())
+	}
+	return fe
+}
+
+// ElementSize is the size, in bytes, of the canonical encoding of an Element.
+const ElementSize = 32
+
+var errInvalidEncoding = errors.New("ristretto255: invalid element encoding")
+
+// Element is an element of the ristretto255 prime-order group of RFC 9496.
+//
+// Each Element is represented internally by one of the Edwards25519 points of
+// its equivalence class, so Elements must only be compared with Equal or
+// through their encodings. The zero value is not usable; use NewElement or
+// NewGeneratorElement instead.
+type Element struct {
+	p edwards25519.Point
+}
+
+// NewElement returns a new Element set to the identity.
+func NewElement() *Element {
+	e := new(Element)
+	e.p.Set(edwards25519.NewIdentityPoint())
+	return e
+}
+
+// NewGeneratorElement returns a new Element set to the canonical generator.
+func NewGeneratorElement() *Element {
+	e := new(Element)
+	e.p.Set(edwards25519.NewGeneratorPoint())
+	return e
+}
+
+// Set sets e = x, and returns e.
+func (e *Element) Set(x *Element) *Element {
+	e.p.Set(&x.p)
+	return e
+}
+
+// Equal returns 1 if e is equivalent to x, and 0 otherwise.
+func (e *Element) Equal(x *Element) int {
+	X1, Y1, _, _ := e.p.ExtendedCoordinates()
+	X2, Y2, _, _ := x.p.ExtendedCoordinates()
+
+	var f0, f1 field.Element
+	f0.Multiply(X1, Y2)
+	f1.Multiply(Y1, X2)
+	out := f0.Equal(&f1)
+
+	f0.Multiply(Y1, Y2)
+	f1.Multiply(X1, X2)
+	return out | f0.Equal(&f1)
+}
+
+// Add sets e = x + y, and returns e.
+func (e *Element) Add(x, y *Element) *Element {
+	e.p.Add(&x.p, &y.p)
+	return e
+}
+
+// Subtract sets e = x - y, and returns e.
+func (e *Element) Subtract(x, y *Element) *Element {
+	e.p.Subtract(&x.p, &y.p)
+	return e
+}
+
+// Negate sets e = -x, and returns e.
+func (e *Element) Negate(x *Element) *Element {
+	e.p.Negate(&x.p)
+	return e
+}
+
+// ScalarMult sets e = s * x, and returns e.
+func (e *Element) ScalarMult(s *edwards25519.Scalar, x *Element) *Element {
+	e.p.ScalarMult(s, &x.p)
+	return e
+}
+
+// ScalarBaseMult sets e = s * B, where B is the canonical generator, and
+// returns e.
+func (e *Element) ScalarBaseMult(s *edwards25519.Scalar) *Element {
+	e.p.ScalarBaseMult(s)
+	return e
+}
+
+// VarTimeDoubleScalarBaseMult sets e = a * A + b * B, where B is the canonical
+// generator, and returns e. Execution time depends on the inputs.
+func (e *Element) VarTimeDoubleScalarBaseMult(a *edwards25519.Scalar, A *Element, b *edwards25519.Scalar) *Element {
+	e.p.VarTimeDoubleScalarBaseMult(a, &A.p, b)
+	return e
+}
+
+// VarTimeMultiScalarMult sets e = sum(scalars[i] * elements[i]), and returns
+// e. Execution time depends on the inputs. It panics if the lengths of
+// scalars and elements differ.
+func (e *Element) VarTimeMultiScalarMult(scalars []*edwards25519.Scalar, elements []*Element) *Element {
+	points := make([]*edwards25519.Point, len(elements))
+	for i := range elements {
+		points[i] = &elements[i].p
+	}
+	e.p.VarTimeMultiScalarMult(scalars, points)
+	return e
+}
+
+// SetUniformBytes maps the 64-byte string b to e uniformly and
+// deterministically, as in Section 4.3.4 of RFC 9496, and returns e. It can
+// be used to hash to the group or to obtain a random Element.
+func (e *Element) SetUniformBytes(b []byte) (*Element, error) {
+	if len(b) != 64 {
+		return nil, errors.New("ristretto255: invalid uniform bytes length")
+	}
+	var t field.Element
+	p1, p2 := new(Element), new(Element)
+	if _, err := t.SetBytes(b[:32]); err != nil {
+		return nil, err
+	}
+	mapToPoint(&p1.p, &t)
+	if _, err := t.SetBytes(b[32:]); err != nil {
+		return nil, err
+	}
+	mapToPoint(&p2.p, &t)
+	return e.Add(p1, p2), nil
+}
+
+// mapToPoint implements MAP from Section 4.3.4 of RFC 9496.
+func mapToPoint(out *edwards25519.Point, t *field.Element) {
+	// r = SQRT_M1 * t^2
+	r := new(field.Element).Square(t)
+	r.Multiply(sqrtM1, r)
+
+	// u = (r + 1) * ONE_MINUS_D_SQ
+	u := new(field.Element).Add(r, one)
+	u.Multiply(u, oneMinusDSQ)
+
+	// c = -1
+	c := new(field.Element).Negate(one)
+
+	// v = (c - r*D) * (r + D)
+	rPlusD := new(field.Element).Add(r, d)
+	v := new(field.Element).Multiply(r, d)
+	v.Subtract(c, v).Multiply(v, rPlusD)
+
+	// (was_square, s) = SQRT_RATIO_M1(u, v)
+	s, wasSquare := new(field.Element).SqrtRatio(u, v)
+
+	// s_prime = -CT_ABS(s*t)
+	sPrime := new(field.Element).Multiply(s, t)
+	sPrime.Absolute(sPrime).Negate(sPrime)
+
+	// s = CT_SELECT(s IF was_square ELSE s_prime)
+	s.Select(s, sPrime, wasSquare)
+	// c = CT_SELECT(c IF was_square ELSE r)
+	c.Select(c, r, wasSquare)
+
+	// N = c * (r - 1) * D_MINUS_ONE_SQ - v
+	N := new(field.Element).Subtract(r, one)
+	N.Multiply(c, N).Multiply(N, dMinusOneSQ).Subtract(N, v)
+
+	s2 := new(field.Element).Square(s)
+
+	// w0 = 2 * s * v
+	w0 := new(field.Element).Multiply(s, v)
+	w0.Add(w0, w0)
+	// w1 = N * SQRT_AD_MINUS_ONE
+	w1 := new(field.Element).Multiply(N, sqrtADMinusOne)
+	// w2 = 1 - s^2
+	w2 := new(field.Element).Subtract(one, s2)
+	// w3 = 1 + s^2
+	w3 := new(field.Element).Add(one, s2)
+
+	// return (w0*w3, w2*w1, w1*w3, w0*w2)
+	X := new(field.Element).Multiply(w0, w3)
+	Y := new(field.Element).Multiply(w2, w1)
+	Z := new(field.Element).Multiply(w1, w3)
+	T := new(field.Element).Multiply(w0, w2)
+	if _, err := out.SetExtendedCoordinates(X, Y, Z, T); err != nil {
+		panic("ristretto255: internal error: MAP returned an invalid point")
+	}
+}
+
+// Bytes returns the 32-byte canonical encoding of e, as specified in Section
+// 4.3.2 of RFC 9496.
+func (e *Element) Bytes() []byte {
+	X0, Y0, Z0, T0 := e.p.ExtendedCoordinates()
+	tmp := new(field.Element)
+
+	// u1 = (z0 + y0) * (z0 - y0)
+	u1 := new(field.Element).Add(Z0, Y0)
+	u1.Multiply(u1, tmp.Subtract(Z0, Y0))
+
+	// u2 = x0 * y0
+	u2 := new(field.Element).Multiply(X0, Y0)
+
+	// Ignore was_square since this is always square.
+	// (_, invsqrt) = SQRT_RATIO_M1(1, u1 * u2^2)
+	invSqrt, _ := new(field.Element).SqrtRatio(one, tmp.Square(u2).Multiply(tmp, u1))
+
+	// den1 = invsqrt * u1
+	// den2 = invsqrt * u2
+	den1 := new(field.Element).Multiply(invSqrt, u1)
+	den2 := new(field.Element).Multiply(invSqrt, u2)
+	// z_inv = den1 * den2 * t0
+	zInv := new(field.Element).Multiply(den1, den2)
+	zInv.Multiply(zInv, T0)
+
+	// ix0 = x0 * SQRT_M1
+	// iy0 = y0 * SQRT_M1
+	ix0 := new(field.Element).Multiply(X0, sqrtM1)
+	iy0 := new(field.Element).Multiply(Y0, sqrtM1)
+	// enchanted_denominator = den1 * INVSQRT_A_MINUS_D
+	enchantedDenominator := new(field.Element).Multiply(den1, invSqrtAMinusD)
+
+	// rotate = IS_NEGATIVE(t0 * z_inv)
+	rotate := tmp.Multiply(T0, zInv).IsNegative()
+
+	// x = CT_SELECT(iy0 IF rotate ELSE x0)
+	// y = CT_SELECT(ix0 IF rotate ELSE y0)
+	x := new(field.Element).Select(iy0, X0, rotate)
+	y := new(field.Element).Select(ix0, Y0, rotate)
+	// den_inv = CT_SELECT(enchanted_denominator IF rotate ELSE den2)
+	denInv := new(field.Element).Select(enchantedDenominator, den2, rotate)
+
+	// y = CT_NEG(y, IS_NEGATIVE(x * z_inv))
+	negY := new(field.Element).Negate(y)
+	y.Select(negY, y, tmp.Multiply(x, zInv).IsNegative())
+
+	// s = CT_ABS(den_inv * (z0 - y))
+	s := new(field.Element).Subtract(Z0, y)
+	s.Multiply(s, denInv).Absolute(s)
+
+	return s.Bytes()
+}
+
+// SetCanonicalBytes sets e to the decoding of the 32-byte canonical encoding
+// b, as specified in Section 4.3.1 of RFC 9496, and returns e. If b is not a
+// canonical encoding, SetCanonicalBytes returns nil and an error, and e is
+// unchanged.
+func (e *Element) SetCanonicalBytes(b []byte) (*Element, error) {
+	if len(b) != ElementSize {
+		return nil, errInvalidEncoding
+	}
+
+	// Interpret the string as an integer s in little-endian representation.
+	// If the resulting value is >= p, or IS_NEGATIVE(s), decoding fails.
+	s, err := new(field.Element).SetBytes(b)
+	if err != nil || !bytes.Equal(s.Bytes(), b) || s.IsNegative() == 1 {
+		return nil, errInvalidEncoding
+	}
+
+	// ss = s^2
+	ss := new(field.Element).Square(s)
+	// u1 = 1 - ss
+	u1 := new(field.Element).Subtract(one, ss)
+	// u2 = 1 + ss
+	u2 := new(field.Element).Add(one, ss)
+	// u2_sqr = u2^2
+	u2Sqr := new(field.Element).Square(u2)
+
+	// v = -(D * u1^2) - u2_sqr
+	v := new(field.Element).Square(u1)
+	v.Multiply(v, d).Negate(v).Subtract(v, u2Sqr)
+
+	// (was_square, invsqrt) = SQRT_RATIO_M1(1, v * u2_sqr)
+	tmp := new(field.Element).Multiply(v, u2Sqr)
+	invSqrt, wasSquare := new(field.Element).SqrtRatio(one, tmp)
+
+	// den_x = invsqrt * u2
+	// den_y = invsqrt * den_x * v
+	denX := new(field.Element).Multiply(invSqrt, u2)
+	denY := new(field.Element).Multiply(invSqrt, denX)
+	denY.Multiply(denY, v)
+
+	// x = CT_ABS(2 * s * den_x)
+	// y = u1 * den_y
+	// t = x * y
+	x := new(field.Element).Add(s, s)
+	x.Multiply(x, denX).Absolute(x)
+	y := new(field.Element).Multiply(u1, denY)
+	t := new(field.Element).Multiply(x, y)
+
+	// If was_square is FALSE, or IS_NEGATIVE(t), or y = 0, decoding fails.
+	if wasSquare == 0 || t.IsNegative() == 1 || y.Equal(new(field.Element).Zero()) == 1 {
+		return nil, errInvalidEncoding
+	}
+
+	if _, err := e.p.SetExtendedCoordinates(x, y, new(field.Element).One(), t); err != nil {
+		return nil, errInvalidEncoding
+	}
+	return e, nil
+}
@@ -0,0 +1,342 @@
+// Package ristretto255 implements a Schnorr signature scheme with key
+// blinding over the ristretto255 prime-order group of RFC 9496.
+//
+// The API mirrors the one of the ed25519 package: keys are byte strings,
+// blinds are 32-byte strings, and a public key blinded with a blind and a
+// context string verifies signatures produced by BlindKeySignWithContext with
+// the same blind and context. Unlike Ed25519, ristretto255 has prime order, so
+// blinded keys and signatures have no cofactor component that could be used
+// to link them, and no clamping or cofactor clearing is needed.
+//
+// Signatures are (R, S) where R = [r]B, k = H(R || A || M) and S = r + k*s,
+// with H being SHA-512 reduced modulo the group order.
+package ristretto255
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"strconv"
+
+	"filippo.io/edwards25519"
+)
+
+const (
+	// PublicKeySize is the size, in bytes, of public keys as used in this package.
+	PublicKeySize = 32
+	// PrivateKeySize is the size, in bytes, of private keys as used in this package.
+	PrivateKeySize = 64
+	// SignatureSize is the size, in bytes, of signatures generated and verified by this package.
+	SignatureSize = 64
+	// SeedSize is the size, in bytes, of private key seeds.
+	SeedSize = 32
+	// BlindSize is the size, in bytes, of blinds.
+	BlindSize = 32
+)
+
+// PublicKey is the type of ristretto255 public keys.
+type PublicKey []byte
+
+// Equal reports whether pub and x have the same value.
+func (pub PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(PublicKey)
+	if !ok {
+		return false
+	}
+	return bytes.Equal(pub, xx)
+}
+
+// PrivateKey is the type of ristretto255 private keys. It is the seed
+// followed by the public key.
+type PrivateKey []byte
+
+// Public returns the PublicKey corresponding to priv.
+func (priv PrivateKey) Public() crypto.PublicKey {
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, priv[SeedSize:])
+	return PublicKey(publicKey)
+}
+
+// Equal reports whether priv and x have the same value, in constant time.
+func (priv PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(PrivateKey)
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare(priv, xx) == 1
+}
+
+// Seed returns the private key seed corresponding to priv.
+func (priv PrivateKey) Seed() []byte {
+	seed := make([]byte, SeedSize)
+	copy(seed, priv[:SeedSize])
+	return seed
+}
+
+// Scalar returns the secret scalar s of priv, such that its public key is
+// [s]B. It will panic if len(priv) is not PrivateKeySize.
+func (priv PrivateKey) Scalar() *edwards25519.Scalar {
+	if l := len(priv); l != PrivateKeySize {
+		panic("ristretto255: bad private key length: " + strconv.Itoa(l))
+	}
+	s, _ := expandSeed(priv[:SeedSize])
+	return s
+}
+
+// BlindScalar returns the secret scalar of priv blinded by blind and context,
+// such that the public key blinded with BlindPublicKeyWithContext is [s]B.
+func (priv PrivateKey) BlindScalar(blind, context []byte) (*edwards25519.Scalar, error) {
+	if l := len(blind); l != BlindSize {
+		return nil, errors.New("ristretto255: bad blind length: " + strconv.Itoa(l))
+	}
+	r, _, err := blindScalar(blind, context)
+	if err != nil {
+		return nil, err
+	}
+	return r.Multiply(priv.Scalar(), r), nil
+}
+
+// GenerateKey generates a public/private key pair using entropy from rand.
+// If rand is nil, crypto/rand.Reader will be used.
+func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, nil, err
+	}
+
+	privateKey := NewKeyFromSeed(seed)
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, privateKey[SeedSize:])
+
+	return publicKey, privateKey, nil
+}
+
+// NewKeyFromSeed calculates a private key from a seed. It will panic if
+// len(seed) is not SeedSize.
+func NewKeyFromSeed(seed []byte) PrivateKey {
+	if l := len(seed); l != SeedSize {
+		panic("ristretto255: bad seed length: " + strconv.Itoa(l))
+	}
+
+	s, _ := expandSeed(seed)
+	A := NewElement().ScalarBaseMult(s)
+
+	privateKey := make([]byte, PrivateKeySize)
+	copy(privateKey, seed)
+	copy(privateKey[SeedSize:], A.Bytes())
+	return privateKey
+}
+
+// expandSeed derives the secret scalar and the nonce prefix from a seed.
+func expandSeed(seed []byte) (*edwards25519.Scalar, []byte) {
+	h := sha512.New()
+	h.Write([]byte{0x00})
+	h.Write(seed)
+	s, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		panic("ristretto255: internal error: " + err.Error())
+	}
+
+	h.Reset()
+	h.Write([]byte{0x01})
+	h.Write(seed)
+	prefix := h.Sum(nil)[:32]
+
+	return s, prefix
+}
+
+// blindScalar derives the blinding scalar and its nonce prefix from a blind
+// and context string. It returns an error if the scalar is zero, which
+// happens with negligible probability.
+//
+// The blind and the context are hashed as blind || 0x00 || context, which
+// only separates them because callers check that len(blind) is BlindSize.
+func blindScalar(blind, context []byte) (*edwards25519.Scalar, []byte, error) {
+	h := sha512.New()
+	h.Write(blind)
+	h.Write([]byte{0x00})
+	h.Write(context)
+	r, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.Equal(edwards25519.NewScalar()) == 1 {
+		return nil, nil, errors.New("ristretto255: blind is zero")
+	}
+
+	h.Reset()
+	h.Write([]byte{0x01})
+	h.Write(blind)
+	h.Write([]byte{0x00})
+	h.Write(context)
+	prefix := h.Sum(nil)[:32]
+
+	return r, prefix, nil
+}
+
+// BlindPublicKeyWithContext augments the public key pair by the blind key and context string.
+// It returns an error if len(blind) is not BlindSize.
+func BlindPublicKeyWithContext(publicKey PublicKey, blind []byte, context []byte) (PublicKey, error) {
+	if l := len(blind); l != BlindSize {
+		return nil, errors.New("ristretto255: bad blind length: " + strconv.Itoa(l))
+	}
+	r, _, err := blindScalar(blind, context)
+	if err != nil {
+		return nil, err
+	}
+
+	A, err := NewElement().SetCanonicalBytes(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return A.ScalarMult(r, A).Bytes(), nil
+}
+
+// BlindPublicKey augments the public key pair by the blind key.
+func BlindPublicKey(publicKey PublicKey, blind []byte) (PublicKey, error) {
+	return BlindPublicKeyWithContext(publicKey, blind, nil)
+}
+
+// UnblindPublicKeyWithContext unblinds the public key pair by the blind key and context string.
+// It returns an error if len(blind) is not BlindSize.
+func UnblindPublicKeyWithContext(publicKey PublicKey, blind []byte, context []byte) (PublicKey, error) {
+	if l := len(blind); l != BlindSize {
+		return nil, errors.New("ristretto255: bad blind length: " + strconv.Itoa(l))
+	}
+	r, _, err := blindScalar(blind, context)
+	if err != nil {
+		return nil, err
+	}
+	rInv := edwards25519.NewScalar().Invert(r)
+
+	A, err := NewElement().SetCanonicalBytes(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return A.ScalarMult(rInv, A).Bytes(), nil
+}
+
+// UnblindPublicKey unblinds the public key pair by the blind key.
+func UnblindPublicKey(publicKey PublicKey, blind []byte) (PublicKey, error) {
+	return UnblindPublicKeyWithContext(publicKey, blind, nil)
+}
+
+// Sign signs the message with privateKey and returns a signature. It will
+// panic if len(privateKey) is not PrivateKeySize.
+func Sign(privateKey PrivateKey, message []byte) []byte {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ristretto255: bad private key length: " + strconv.Itoa(l))
+	}
+	s, prefix := expandSeed(privateKey[:SeedSize])
+
+	signature := make([]byte, SignatureSize)
+	signInternal(signature, privateKey[SeedSize:], message, prefix, s)
+	return signature
+}
+
+// BlindKeySignWithContext signs the message with privateKey blinded by a blind key and context string,
+// and returns a signature. It will panic if len(privateKey) is not PrivateKeySize
+// or len(blind) is not BlindSize.
+func BlindKeySignWithContext(privateKey PrivateKey, message, blind, context []byte) []byte {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ristretto255: bad private key length: " + strconv.Itoa(l))
+	}
+	if l := len(blind); l != BlindSize {
+		panic("ristretto255: bad blind length: " + strconv.Itoa(l))
+	}
+
+	r, prefix2, err := blindScalar(blind, context)
+	if err != nil {
+		panic(err.Error())
+	}
+	k, prefix1 := expandSeed(privateKey[:SeedSize])
+	prefix := append(prefix1, prefix2...)
+
+	s := edwards25519.NewScalar().Multiply(k, r)
+	A := NewElement().ScalarBaseMult(s)
+
+	signature := make([]byte, SignatureSize)
+	signInternal(signature, A.Bytes(), message, prefix, s)
+	return signature
+}
+
+// BlindKeySign signs the message with privateKey blinded by a blind key,
+// and returns a signature. It will panic if len(privateKey) is not PrivateKeySize
+// or len(blind) is not BlindSize.
+func BlindKeySign(privateKey PrivateKey, message, blind []byte) []byte {
+	return BlindKeySignWithContext(privateKey, message, blind, nil)
+}
+
+func signInternal(signature, publicKey, message, prefix []byte, s *edwards25519.Scalar) {
+	mh := sha512.New()
+	mh.Write(prefix)
+	mh.Write(message)
+	r, err := edwards25519.NewScalar().SetUniformBytes(mh.Sum(nil))
+	if err != nil {
+		panic("ristretto255: internal error: " + err.Error())
+	}
+
+	R := NewElement().ScalarBaseMult(r)
+	encodedR := R.Bytes()
+
+	k := challenge(encodedR, publicKey, message)
+	S := edwards25519.NewScalar().MultiplyAdd(k, s, r)
+
+	copy(signature[:32], encodedR)
+	copy(signature[32:], S.Bytes())
+}
+
+// challenge returns the challenge SHA-512(R || A || M) of a signature. As in
+// Ed25519, it covers the public key, so a signature can't be re-randomized
+// into a signature under a blinded key without the private key: that would
+// require a challenge under the blinded key equal to the original one.
+func challenge(R, publicKey, message []byte) *edwards25519.Scalar {
+	kh := sha512.New()
+	kh.Write(R)
+	kh.Write(publicKey)
+	kh.Write(message)
+	k, err := edwards25519.NewScalar().SetUniformBytes(kh.Sum(nil))
+	if err != nil {
+		panic("ristretto255: internal error: " + err.Error())
+	}
+	return k
+}
+
+// Verify reports whether sig is a valid signature of message by publicKey. It
+// will panic if len(publicKey) is not PublicKeySize.
+func Verify(publicKey PublicKey, message, sig []byte) bool {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ristretto255: bad public key length: " + strconv.Itoa(l))
+	}
+
+	if len(sig) != SignatureSize {
+		return false
+	}
+
+	A, err := NewElement().SetCanonicalBytes(publicKey)
+	if err != nil {
+		return false
+	}
+	S, err := edwards25519.NewScalar().SetCanonicalBytes(sig[32:])
+	if err != nil {
+		return false
+	}
+
+	k := challenge(sig[:32], publicKey, message)
+
+	// [S]B = R + [k]A --> [k](-A) + [S]B = R
+	minusA := NewElement().Negate(A)
+	R := NewElement().VarTimeDoubleScalarBaseMult(k, minusA, S)
+
+	return bytes.Equal(sig[:32], R.Bytes())
+}
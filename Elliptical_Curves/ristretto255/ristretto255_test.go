@@ -0,0 +1,165 @@
+package ristretto255
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+type zeroReader struct{}
+
+func (zeroReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	return len(buf), nil
+}
+
+func TestSignVerify(t *testing.T) {
+	var zero zeroReader
+	public, private, _ := GenerateKey(zero)
+
+	message := []byte("test message")
+	sig := Sign(private, message)
+	if !Verify(public, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+
+	wrongMessage := []byte("wrong message")
+	if Verify(public, wrongMessage, sig) {
+		t.Errorf("signature of different message accepted")
+	}
+}
+
+func TestMaskSignVerify(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+
+	blind := make([]byte, BlindSize)
+	rand.Reader.Read(blind)
+
+	context := []byte("context")
+	blindedKey, err := BlindPublicKeyWithContext(public, blind, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+
+	message := []byte("test message")
+	sig := BlindKeySignWithContext(private, message, blind, context)
+	if !Verify(blindedKey, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+	if Verify(public, message, sig) {
+		t.Errorf("blinded signature accepted under the unblinded key")
+	}
+
+	wrongMessage := []byte("wrong message")
+	if Verify(blindedKey, wrongMessage, sig) {
+		t.Errorf("signature of different message accepted")
+	}
+}
+
+func TestBlindUnblindKeyWithContext(t *testing.T) {
+	publicKey, _, _ := GenerateKey(rand.Reader)
+
+	blind := make([]byte, BlindSize)
+	rand.Reader.Read(blind)
+
+	context := make([]byte, 32)
+	rand.Reader.Read(context)
+
+	blindedKey, err := BlindPublicKeyWithContext(publicKey, blind, context)
+	if err != nil {
+		t.Fatalf("BlindPublicKeyWithContext error: %s", err)
+	}
+	if bytes.Equal(publicKey, blindedKey) {
+		t.Fatal("blinded key equals the public key")
+	}
+
+	unblindedKey, err := UnblindPublicKeyWithContext(blindedKey, blind, context)
+	if err != nil {
+		t.Fatalf("UnblindPublicKeyWithContext error: %s", err)
+	}
+	if !bytes.Equal(publicKey, unblindedKey) {
+		t.Fatal("Blind-unblind mismatch")
+	}
+
+	context[0] ^= 0xFF
+	invalidKey, err := UnblindPublicKeyWithContext(blindedKey, blind, context)
+	if err != nil {
+		t.Fatalf("UnblindPublicKeyWithContext error: %s", err)
+	}
+	if bytes.Equal(publicKey, invalidKey) {
+		t.Fatal("Invalid Blind-unblind mismatch")
+	}
+}
+
+func TestInvalidPublicKey(t *testing.T) {
+	invalid := bytes.Repeat([]byte{0xff}, PublicKeySize)
+	if _, err := BlindPublicKey(invalid, make([]byte, BlindSize)); err == nil {
+		t.Errorf("invalid public key blinded")
+	}
+	if Verify(invalid, []byte("test message"), make([]byte, SignatureSize)) {
+		t.Errorf("signature accepted under an invalid public key")
+	}
+}
+
+func TestBlindLength(t *testing.T) {
+	public, _, _ := GenerateKey(rand.Reader)
+	// With blinds of any length, ("x", "\x00y") and ("x\x00", "y") would
+	// derive the same blinding scalar.
+	for _, blind := range [][]byte{nil, []byte("x"), make([]byte, BlindSize-1), make([]byte, BlindSize+1)} {
+		if _, err := BlindPublicKeyWithContext(public, blind, []byte("context")); err == nil {
+			t.Errorf("BlindPublicKeyWithContext accepted a blind of length %d", len(blind))
+		}
+		if _, err := UnblindPublicKeyWithContext(public, blind, []byte("context")); err == nil {
+			t.Errorf("UnblindPublicKeyWithContext accepted a blind of length %d", len(blind))
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	public, private, _ := GenerateKey(rand.Reader)
+
+	if !public.Equal(public) {
+		t.Errorf("public key is not equal to itself")
+	}
+	if !private.Equal(private) {
+		t.Errorf("private key is not equal to itself")
+	}
+	if !private.Public().(PublicKey).Equal(public) {
+		t.Errorf("private.Public() is not Equal to public")
+	}
+
+	otherPub, otherPriv, _ := GenerateKey(rand.Reader)
+	if public.Equal(otherPub) {
+		t.Errorf("different public keys are Equal")
+	}
+	if private.Equal(otherPriv) {
+		t.Errorf("different private keys are Equal")
+	}
+}
+
+func BenchmarkSigning(b *testing.B) {
+	_, priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Sign(priv, message)
+	}
+}
+
+func BenchmarkVerification(b *testing.B) {
+	pub, priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := []byte("Hello, world!")
+	signature := Sign(priv, message)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Verify(pub, message, signature)
+	}
+}
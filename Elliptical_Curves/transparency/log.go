@@ -0,0 +1,82 @@
+package transparency
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+// Log is an in-memory append-only Merkle tree log. It is safe for concurrent
+// use.
+type Log struct {
+	mu     sync.RWMutex
+	leaves [][HashSize]byte
+}
+
+// NewLog returns an empty log.
+func NewLog() *Log {
+	return &Log{}
+}
+
+// Append adds a leaf with the given data to the log, and returns its index.
+func (l *Log) Append(data []byte) uint64 {
+	h := LeafHash(data)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leaves = append(l.leaves, h)
+	return uint64(len(l.leaves) - 1)
+}
+
+// Size returns the number of leaves in the log.
+func (l *Log) Size() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return uint64(len(l.leaves))
+}
+
+// Root returns the size of the log and its root hash.
+func (l *Log) Root() (uint64, [HashSize]byte) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return uint64(len(l.leaves)), rootHash(l.leaves)
+}
+
+// InclusionProof returns the proof that the leaf at index is included in the
+// tree made of the first size leaves of the log.
+func (l *Log) InclusionProof(index, size uint64) ([][HashSize]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if size > uint64(len(l.leaves)) || index >= size {
+		return nil, errors.New("transparency: index out of range")
+	}
+	return inclusionPath(index, l.leaves[:size]), nil
+}
+
+// LogIssuance implements ecdsa.IssuanceLogger by appending the encoding of
+// entry returned by MarshalEntry to the log.
+func (l *Log) LogIssuance(entry ecdsa.IssuanceEntry) {
+	l.Append(MarshalEntry(entry))
+}
+
+// MarshalEntry returns the leaf data of an issuance entry:
+//
+//	struct {
+//	  opaque blinded_key[32];
+//	  uint64 timestamp;
+//	  opaque digest<0..2^32-1>;
+//	} IssuanceEntry;
+//
+// where timestamp is the time of the entry in Unix milliseconds.
+func MarshalEntry(entry ecdsa.IssuanceEntry) []byte {
+	out := make([]byte, 0, len(entry.BlindedKey)+8+4+len(entry.Digest))
+	out = append(out, entry.BlindedKey[:]...)
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(entry.Time.UnixMilli()))
+	out = append(out, ts[:]...)
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(entry.Digest)))
+	out = append(out, length[:]...)
+	return append(out, entry.Digest...)
+}
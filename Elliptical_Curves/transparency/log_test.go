@@ -0,0 +1,40 @@
+package transparency
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/cloudflare/pat-go/ecdsa"
+)
+
+func TestLogIssuance(t *testing.T) {
+	l := NewLog()
+	ecdsa.SetIssuanceLogger(l)
+	defer ecdsa.SetIssuanceLogger(nil)
+
+	skS, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	skB, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	for i := 0; i < 5; i++ {
+		digest := sha256.Sum256([]byte{byte(i)})
+		if _, _, err := ecdsa.BlindKeySign(rand.Reader, skS, skB, digest[:]); err != nil {
+			t.Fatalf("BlindKeySign error: %s", err)
+		}
+	}
+
+	size, root := l.Root()
+	if size != 5 {
+		t.Fatalf("got %d entries, want 5", size)
+	}
+	proof, err := l.InclusionProof(2, size)
+	if err != nil {
+		t.Fatalf("InclusionProof error: %s", err)
+	}
+	l.mu.RLock()
+	leaf := l.leaves[2]
+	l.mu.RUnlock()
+	if err := VerifyInclusion(2, size, leaf, proof, root); err != nil {
+		t.Errorf("VerifyInclusion error: %s", err)
+	}
+}
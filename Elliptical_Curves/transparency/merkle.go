@@ -0,0 +1,101 @@
+// Package transparency implements an append-only Merkle tree log of blinded
+// signature issuances, with inclusion proofs, following the Merkle tree
+// construction of RFC 9162 with SHA-256.
+//
+// A Log implements ecdsa.IssuanceLogger, so an issuer can record every
+// signature produced with a blinded key by installing it with
+// ecdsa.SetIssuanceLogger and periodically publishing the tree head. Anyone
+// holding an entry can then check its inclusion with VerifyInclusion.
+package transparency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/bits"
+)
+
+// HashSize is the size, in bytes, of the hashes of the tree.
+const HashSize = sha256.Size
+
+var errInvalidProof = errors.New("transparency: invalid inclusion proof")
+
+// LeafHash returns the hash of a leaf with the given data, SHA-256(0x00 || data).
+func LeafHash(data []byte) [HashSize]byte {
+	return sha256.Sum256(append([]byte{0x00}, data...))
+}
+
+// nodeHash returns the hash of an interior node, SHA-256(0x01 || left || right).
+func nodeHash(left, right [HashSize]byte) [HashSize]byte {
+	buf := make([]byte, 0, 1+2*HashSize)
+	buf = append(buf, 0x01)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
+
+// splitPoint returns the largest power of two smaller than n, for n > 1.
+func splitPoint(n uint64) uint64 {
+	return 1 << (bits.Len64(n-1) - 1)
+}
+
+// rootHash computes the Merkle tree hash of leaves, MTH(D[n]).
+func rootHash(leaves [][HashSize]byte) [HashSize]byte {
+	switch n := uint64(len(leaves)); n {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return leaves[0]
+	default:
+		k := splitPoint(n)
+		return nodeHash(rootHash(leaves[:k]), rootHash(leaves[k:]))
+	}
+}
+
+// inclusionPath computes the audit path PATH(m, D[n]) of the leaf at index m.
+func inclusionPath(m uint64, leaves [][HashSize]byte) [][HashSize]byte {
+	n := uint64(len(leaves))
+	if n <= 1 {
+		return nil
+	}
+	k := splitPoint(n)
+	if m < k {
+		return append(inclusionPath(m, leaves[:k]), rootHash(leaves[k:]))
+	}
+	return append(inclusionPath(m-k, leaves[k:]), rootHash(leaves[:k]))
+}
+
+// VerifyInclusion checks that proof proves that the leaf with hash leafHash
+// is at index in the tree of the given size and root hash, as specified in
+// Section 2.1.3.2 of RFC 9162.
+func VerifyInclusion(index, size uint64, leafHash [HashSize]byte, proof [][HashSize]byte, root [HashSize]byte) error {
+	if index >= size {
+		return errInvalidProof
+	}
+
+	fn, sn := index, size-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return errInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			if fn&1 == 0 {
+				for fn&1 == 0 && fn != 0 {
+					fn >>= 1
+					sn >>= 1
+				}
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 || !bytes.Equal(r[:], root[:]) {
+		return errInvalidProof
+	}
+	return nil
+}
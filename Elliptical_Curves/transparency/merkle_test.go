@@ -0,0 +1,91 @@
+package transparency
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Leaves and root hashes of the test tree of the certificate transparency
+// reference implementation.
+var (
+	testLeaves = []string{
+		"",
+		"00",
+		"10",
+		"2021",
+		"3031",
+		"40414243",
+		"5051525354555657",
+		"606162636465666768696a6b6c6d6e6f",
+	}
+	testRoots = []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+)
+
+func TestRootHash(t *testing.T) {
+	l := NewLog()
+	for i, leaf := range testLeaves {
+		data, _ := hex.DecodeString(leaf)
+		l.Append(data)
+		size, root := l.Root()
+		if size != uint64(i+1) {
+			t.Fatalf("got size %d, want %d", size, i+1)
+		}
+		if got := hex.EncodeToString(root[:]); got != testRoots[i] {
+			t.Errorf("size %d: got root %s, want %s", size, got, testRoots[i])
+		}
+	}
+}
+
+func TestInclusionProof(t *testing.T) {
+	l := NewLog()
+	for i := 0; i < 33; i++ {
+		l.Append([]byte{byte(i)})
+	}
+
+	for size := uint64(1); size <= l.Size(); size++ {
+		l2 := NewLog()
+		for i := uint64(0); i < size; i++ {
+			l2.Append([]byte{byte(i)})
+		}
+		_, root := l2.Root()
+
+		for index := uint64(0); index < size; index++ {
+			proof, err := l.InclusionProof(index, size)
+			if err != nil {
+				t.Fatalf("InclusionProof(%d, %d) error: %s", index, size, err)
+			}
+			leaf := LeafHash([]byte{byte(index)})
+			if err := VerifyInclusion(index, size, leaf, proof, root); err != nil {
+				t.Errorf("VerifyInclusion(%d, %d) error: %s", index, size, err)
+			}
+
+			if err := VerifyInclusion(index, size, LeafHash([]byte("other")), proof, root); err == nil {
+				t.Errorf("VerifyInclusion(%d, %d) accepted a different leaf", index, size)
+			}
+			if size > 1 {
+				if err := VerifyInclusion((index+1)%size, size, leaf, proof, root); err == nil {
+					t.Errorf("VerifyInclusion(%d, %d) accepted a different index", index, size)
+				}
+				if err := VerifyInclusion(index, size, leaf, proof[:len(proof)-1], root); err == nil {
+					t.Errorf("VerifyInclusion(%d, %d) accepted a truncated proof", index, size)
+				}
+			}
+		}
+	}
+
+	if _, err := l.InclusionProof(3, 2); err == nil {
+		t.Errorf("InclusionProof accepted an index beyond the tree size")
+	}
+	if _, err := l.InclusionProof(0, 34); err == nil {
+		t.Errorf("InclusionProof accepted a size beyond the log size")
+	}
+}
@@ -0,0 +1,313 @@
+// Package treehash signs very large messages, such as files of many
+// gigabytes, through a Merkle tree of their chunks, so that the chunks can be
+// hashed in parallel and a range of chunks can be checked against the signed
+// root without reading the rest of the message.
+//
+// A message is split into chunks of a fixed size, the last one possibly
+// shorter, and an empty message is a single empty chunk. The chunks are the
+// leaves of a Merkle tree built as in RFC 9162 with SHA-256: a leaf hashes
+// to SHA-256(0x00 || chunk) and an interior node to
+// SHA-256(0x01 || left || right). A Header binds the root to the chunk size
+// and length of the message, and its encoding is the message signed by Sign,
+// with any blinding.BlindableScheme and, typically, a blinded key.
+package treehash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"runtime"
+	"sync"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+// HashSize is the size, in bytes, of the hashes of the tree.
+const HashSize = sha256.Size
+
+// DefaultChunkSize is the chunk size used when Options.ChunkSize is zero.
+const DefaultChunkSize = 1 << 20
+
+// MaxChunkSize is the largest chunk size a Header may carry.
+const MaxChunkSize = 1 << 30
+
+const headerDST = "Tree Hash v1"
+
+// HeaderSize is the size, in bytes, of an encoded Header.
+const HeaderSize = len(headerDST) + 4 + 8 + HashSize
+
+var (
+	// ErrInvalidHeader is returned when a header is badly encoded or has a
+	// chunk size out of range.
+	ErrInvalidHeader = errors.New("treehash: invalid header")
+
+	// ErrInvalidRange is returned when a range of chunks does not match
+	// the root of a header, or is out of the bounds of the message.
+	ErrInvalidRange = errors.New("treehash: invalid range")
+)
+
+// Header describes a message hashed into a tree.
+type Header struct {
+	ChunkSize uint32
+	Length    uint64
+	Root      [HashSize]byte
+}
+
+// Chunks returns the number of chunks of the message.
+func (h *Header) Chunks() uint64 {
+	if h.Length == 0 {
+		return 1
+	}
+	return (h.Length + uint64(h.ChunkSize) - 1) / uint64(h.ChunkSize)
+}
+
+// ChunkRange returns the range of chunks [first, end) holding the length
+// bytes of the message starting at offset.
+func (h *Header) ChunkRange(offset, length uint64) (first, end uint64, err error) {
+	if h.ChunkSize == 0 || length == 0 || offset+length < offset || offset+length > h.Length {
+		return 0, 0, fmt.Errorf("%w: bytes [%d, %d+%d) of a message of %d bytes", ErrInvalidRange, offset, offset, length, h.Length)
+	}
+	cs := uint64(h.ChunkSize)
+	return offset / cs, (offset + length + cs - 1) / cs, nil
+}
+
+// Marshal encodes h as the message to sign:
+//
+//	struct {
+//	  opaque dst[12] = "Tree Hash v1";
+//	  uint32 chunk_size;
+//	  uint64 length;
+//	  opaque root[32];
+//	} Header;
+func (h *Header) Marshal() []byte {
+	b := make([]byte, HeaderSize)
+	n := copy(b, headerDST)
+	binary.BigEndian.PutUint32(b[n:], h.ChunkSize)
+	binary.BigEndian.PutUint64(b[n+4:], h.Length)
+	copy(b[n+12:], h.Root[:])
+	return b
+}
+
+// UnmarshalHeader decodes a header encoded by Marshal.
+func UnmarshalHeader(data []byte) (*Header, error) {
+	if len(data) != HeaderSize || !bytes.HasPrefix(data, []byte(headerDST)) {
+		return nil, ErrInvalidHeader
+	}
+	n := len(headerDST)
+	h := &Header{
+		ChunkSize: binary.BigEndian.Uint32(data[n:]),
+		Length:    binary.BigEndian.Uint64(data[n+4:]),
+	}
+	copy(h.Root[:], data[n+12:])
+	if h.ChunkSize == 0 || h.ChunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("%w: chunk size %d", ErrInvalidHeader, h.ChunkSize)
+	}
+	return h, nil
+}
+
+func leafHash(chunk []byte) [HashSize]byte {
+	md := sha256.New()
+	md.Write([]byte{0x00})
+	md.Write(chunk)
+	var h [HashSize]byte
+	md.Sum(h[:0])
+	return h
+}
+
+func nodeHash(left, right [HashSize]byte) [HashSize]byte {
+	buf := make([]byte, 0, 1+2*HashSize)
+	buf = append(buf, 0x01)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
+
+// splitPoint returns the largest power of two smaller than n, for n > 1.
+func splitPoint(n uint64) uint64 {
+	return 1 << (bits.Len64(n-1) - 1)
+}
+
+func rootHash(leaves [][HashSize]byte) [HashSize]byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := splitPoint(uint64(len(leaves)))
+	return nodeHash(rootHash(leaves[:k]), rootHash(leaves[k:]))
+}
+
+// Options are the options of HashReaderAt.
+type Options struct {
+	// ChunkSize is the size of the chunks, DefaultChunkSize if zero.
+	ChunkSize uint32
+
+	// Workers is the number of chunks hashed in parallel, GOMAXPROCS if
+	// zero.
+	Workers int
+}
+
+// Tree is the Merkle tree of a message, which proves ranges of its chunks.
+type Tree struct {
+	Header
+	leaves [][HashSize]byte
+}
+
+// HashReaderAt hashes the size bytes of r, such as an *os.File, into a tree,
+// reading and hashing opts.Workers chunks in parallel.
+func HashReaderAt(r io.ReaderAt, size int64, opts *Options) (*Tree, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize == 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if o.ChunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("%w: chunk size %d", ErrInvalidHeader, o.ChunkSize)
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("treehash: negative size %d", size)
+	}
+
+	t := &Tree{Header: Header{ChunkSize: o.ChunkSize, Length: uint64(size)}}
+	n := t.Chunks()
+	t.leaves = make([][HashSize]byte, n)
+	indices := make(chan uint64)
+	errs := make(chan error, o.Workers)
+	var wg sync.WaitGroup
+	for w := 0; w < o.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, o.ChunkSize)
+			for i := range indices {
+				off := i * uint64(o.ChunkSize)
+				chunk := buf[:min64(uint64(o.ChunkSize), t.Length-off)]
+				if len(chunk) > 0 {
+					n, err := r.ReadAt(chunk, int64(off))
+					if err != nil && !(err == io.EOF && n == len(chunk)) {
+						errs <- fmt.Errorf("treehash: reading chunk %d: %w", i, err)
+						return
+					}
+				}
+				t.leaves[i] = leafHash(chunk)
+			}
+		}()
+	}
+	var err error
+	for i := uint64(0); i < n && err == nil; i++ {
+		select {
+		case indices <- i:
+		case err = <-errs:
+		}
+	}
+	close(indices)
+	wg.Wait()
+	if err == nil {
+		select {
+		case err = <-errs:
+		default:
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Root = rootHash(t.leaves)
+	return t, nil
+}
+
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ProveRange returns the proof that the chunks [first, end) are part of the
+// message of t: the hashes of the subtrees outside of the range, in the order
+// VerifyRange consumes them.
+func (t *Tree) ProveRange(first, end uint64) ([][HashSize]byte, error) {
+	if first >= end || end > uint64(len(t.leaves)) {
+		return nil, fmt.Errorf("%w: chunks [%d, %d) of %d", ErrInvalidRange, first, end, len(t.leaves))
+	}
+	var proof [][HashSize]byte
+	var walk func(lo, hi uint64)
+	walk = func(lo, hi uint64) {
+		if hi <= first || lo >= end {
+			proof = append(proof, rootHash(t.leaves[lo:hi]))
+			return
+		}
+		if hi-lo == 1 {
+			return
+		}
+		k := splitPoint(hi - lo)
+		walk(lo, lo+k)
+		walk(lo+k, hi)
+	}
+	walk(0, uint64(len(t.leaves)))
+	return proof, nil
+}
+
+// VerifyRange checks that data is the content of the chunks [first, end) of
+// the message described by h, given the proof returned by ProveRange. Only
+// the last chunk of the message may be shorter than the chunk size.
+func VerifyRange(h *Header, first, end uint64, data []byte, proof [][HashSize]byte) error {
+	n := h.Chunks()
+	if h.ChunkSize == 0 || first >= end || end > n {
+		return fmt.Errorf("%w: chunks [%d, %d) of %d", ErrInvalidRange, first, end, n)
+	}
+	cs := uint64(h.ChunkSize)
+	want := min64(end*cs, h.Length) - first*cs
+	if uint64(len(data)) != want {
+		return fmt.Errorf("%w: %d bytes of data, want %d", ErrInvalidRange, len(data), want)
+	}
+
+	var walk func(lo, hi uint64) ([HashSize]byte, bool)
+	walk = func(lo, hi uint64) ([HashSize]byte, bool) {
+		if hi <= first || lo >= end {
+			if len(proof) == 0 {
+				return [HashSize]byte{}, false
+			}
+			p := proof[0]
+			proof = proof[1:]
+			return p, true
+		}
+		if hi-lo == 1 {
+			off := (lo - first) * cs
+			return leafHash(data[off:min64(off+cs, uint64(len(data)))]), true
+		}
+		k := splitPoint(hi - lo)
+		left, ok := walk(lo, lo+k)
+		if !ok {
+			return left, false
+		}
+		right, ok := walk(lo+k, hi)
+		return nodeHash(left, right), ok
+	}
+	root, ok := walk(0, n)
+	if !ok || len(proof) != 0 || root != h.Root {
+		return fmt.Errorf("%w: chunks [%d, %d) do not match the root", ErrInvalidRange, first, end)
+	}
+	return nil
+}
+
+// Sign signs the encoding of h with privateKey under scheme, blinded by blind
+// and context if blind is not nil.
+func Sign(scheme blinding.BlindableScheme, rand io.Reader, privateKey, blind, context []byte, h *Header) ([]byte, error) {
+	if blind == nil {
+		return scheme.Sign(rand, privateKey, h.Marshal())
+	}
+	return scheme.BlindKeySign(rand, privateKey, blind, h.Marshal(), context)
+}
+
+// Verify reports whether sig is a signature of the encoding of h under
+// publicKey, a blinded key if the header was signed with a blind.
+func Verify(scheme blinding.BlindableScheme, publicKey []byte, h *Header, sig []byte) bool {
+	return scheme.Verify(publicKey, h.Marshal(), sig)
+}
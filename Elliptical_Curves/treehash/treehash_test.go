@@ -0,0 +1,135 @@
+package treehash
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/pat-go/blinding"
+)
+
+func testMessage(n int) []byte {
+	msg := make([]byte, n)
+	for i := range msg {
+		msg[i] = byte(i * 7)
+	}
+	return msg
+}
+
+func TestHashReaderAt(t *testing.T) {
+	// A message of a single chunk hashes to its leaf hash.
+	msg := testMessage(100)
+	tree, err := HashReaderAt(bytes.NewReader(msg), int64(len(msg)), &Options{ChunkSize: 128})
+	if err != nil {
+		t.Fatalf("HashReaderAt error: %s", err)
+	}
+	if want := sha256.Sum256(append([]byte{0}, msg...)); tree.Root != want {
+		t.Errorf("root of a single chunk is not its leaf hash")
+	}
+
+	// The root does not depend on the number of workers.
+	msg = testMessage(1000)
+	t1, _ := HashReaderAt(bytes.NewReader(msg), int64(len(msg)), &Options{ChunkSize: 64, Workers: 1})
+	t8, _ := HashReaderAt(bytes.NewReader(msg), int64(len(msg)), &Options{ChunkSize: 64, Workers: 8})
+	if t1.Root != t8.Root || t1.Chunks() != 16 {
+		t.Errorf("roots differ with the number of workers, or %d chunks, want 16", t1.Chunks())
+	}
+	msg[500] ^= 1
+	t2, _ := HashReaderAt(bytes.NewReader(msg), int64(len(msg)), &Options{ChunkSize: 64})
+	if t2.Root == t1.Root {
+		t.Errorf("root did not change with the message")
+	}
+
+	empty, err := HashReaderAt(bytes.NewReader(nil), 0, nil)
+	if err != nil || empty.Chunks() != 1 {
+		t.Errorf("HashReaderAt of an empty message: %v", err)
+	}
+	if _, err := HashReaderAt(bytes.NewReader(msg), int64(len(msg))+1, &Options{ChunkSize: 64}); err == nil {
+		t.Errorf("HashReaderAt past the end of the reader succeeded")
+	}
+}
+
+func TestVerifyRange(t *testing.T) {
+	for _, size := range []int{0, 1, 63, 64, 65, 1000, 1024} {
+		msg := testMessage(size)
+		tree, err := HashReaderAt(bytes.NewReader(msg), int64(len(msg)), &Options{ChunkSize: 64})
+		if err != nil {
+			t.Fatalf("HashReaderAt error: %s", err)
+		}
+		n := tree.Chunks()
+		for first := uint64(0); first < n; first++ {
+			for end := first + 1; end <= n; end++ {
+				proof, err := tree.ProveRange(first, end)
+				if err != nil {
+					t.Fatalf("ProveRange(%d, %d) error: %s", first, end, err)
+				}
+				data := msg[first*64 : min64(end*64, uint64(size))]
+				if err := VerifyRange(&tree.Header, first, end, data, proof); err != nil {
+					t.Errorf("size %d: VerifyRange(%d, %d) error: %s", size, first, end, err)
+				}
+				if len(data) > 0 {
+					bad := append([]byte{}, data...)
+					bad[len(bad)-1] ^= 1
+					if err := VerifyRange(&tree.Header, first, end, bad, proof); !errors.Is(err, ErrInvalidRange) {
+						t.Errorf("size %d: tampered chunks [%d, %d) verified", size, first, end)
+					}
+				}
+			}
+		}
+	}
+
+	msg := testMessage(1000)
+	tree, _ := HashReaderAt(bytes.NewReader(msg), int64(len(msg)), &Options{ChunkSize: 64})
+	first, end, err := tree.ChunkRange(100, 200)
+	if err != nil || first != 1 || end != 5 {
+		t.Errorf("ChunkRange(100, 200) = %d, %d, %v, want 1, 5", first, end, err)
+	}
+	proof, _ := tree.ProveRange(first, end)
+	if err := VerifyRange(&tree.Header, first, end, msg[64:320], proof[1:]); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("range verified with a truncated proof: %v", err)
+	}
+	if err := VerifyRange(&tree.Header, first, end, msg[64:319], proof); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("range verified with short data: %v", err)
+	}
+	if _, err := tree.ProveRange(3, 3); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("ProveRange of an empty range: %v", err)
+	}
+	if _, _, err := tree.ChunkRange(900, 200); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("ChunkRange past the end of the message: %v", err)
+	}
+}
+
+func TestSignHeader(t *testing.T) {
+	msg := testMessage(5000)
+	tree, _ := HashReaderAt(bytes.NewReader(msg), int64(len(msg)), &Options{ChunkSize: 256})
+	s := blinding.Ristretto255
+	pk, sk, _ := s.GenerateKey(rand.Reader)
+	blind, _ := s.GenerateBlind(rand.Reader)
+	context := []byte("context")
+	pkR, _ := s.BlindPublicKey(pk, blind, context)
+
+	sig, err := Sign(s, rand.Reader, sk, blind, context, &tree.Header)
+	if err != nil {
+		t.Fatalf("Sign error: %s", err)
+	}
+	h, err := UnmarshalHeader(tree.Header.Marshal())
+	if err != nil || *h != tree.Header {
+		t.Fatalf("UnmarshalHeader(Marshal()) = %v, %v", h, err)
+	}
+	if !Verify(s, pkR, h, sig) {
+		t.Errorf("signature of the header failed to verify under the blinded key")
+	}
+	if Verify(s, pk, h, sig) {
+		t.Errorf("signature under the blinded key verified under the unblinded key")
+	}
+	h.Length--
+	if Verify(s, pkR, h, sig) {
+		t.Errorf("signature verified for a header of another length")
+	}
+
+	if _, err := UnmarshalHeader(make([]byte, HeaderSize)); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("UnmarshalHeader of zeros: %v", err)
+	}
+}